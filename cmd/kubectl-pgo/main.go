@@ -5,6 +5,8 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -18,5 +20,14 @@ func main() {
 	pflag.CommandLine = flags
 
 	root := cmd.NewPGOCommand(os.Stdin, os.Stdout, os.Stderr)
-	cobra.CheckErr(root.Execute())
+
+	// Plugins and hooks are added here rather than in [cmd.NewPGOCommand] so
+	// that generated documentation does not depend on the local machine.
+	if err := cmd.AddPlugins(root, os.Getenv("PATH"), cmd.PluginConfigPath()); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: %v\n", err)
+	}
+	// Declining a prompt is not a failure.
+	if err := root.Execute(); !errors.Is(err, cmd.ErrCancelled) {
+		cobra.CheckErr(err)
+	}
 }
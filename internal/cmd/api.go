@@ -0,0 +1,248 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newAPICommand returns the api subcommand of the PGO plugin.
+// Subcommands of api describe the plugin itself for other programs.
+func newAPICommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "api",
+		Short: "Describe the commands of this plugin for other programs",
+		Long:  "Describe the commands of this plugin for other programs",
+	}
+
+	cmd.AddCommand(newAPIExportCommand(config))
+
+	return cmd
+}
+
+// newAPIExportCommand returns the api export subcommand. It prints every
+// command and flag of the plugin as an OpenAPI or JSON Schema document.
+func newAPIExportCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Print the commands and flags of this plugin as OpenAPI or JSON Schema",
+		Long: `Print a machine-readable description of every command of this plugin and
+its flags. Use it to generate wrappers that call the plugin.
+
+In OpenAPI output each command is an operation whose request body holds its
+arguments and flags. In JSON Schema output each command is a definition of an
+object with one property per flag. Flags that apply to every command are
+described once, on the root command.
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Print an OpenAPI document
+pgo api export --format=openapi
+
+# Print a JSON Schema document
+pgo api export --format=jsonschema
+
+### Example output
+{
+  "$defs": {
+    "pgo backup": {
+      "description": "Backup cluster",
+      "properties": {
+        "force-conflicts": {
+          "default": false,
+          "description": "take ownership and overwrite the backup settings",
+          "type": "boolean"
+        },
+...`)
+
+	var formatEnum = util.OpenAPIFormat
+	cmd.Flags().Var(&formatEnum, "format",
+		"output format. types supported: openapi,jsonschema")
+
+	cmd.Args = cobra.NoArgs
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		var document map[string]any
+		if formatEnum == util.JSONSchemaFormat {
+			document = commandJSONSchema(cmd.Root())
+		} else {
+			document = commandOpenAPI(cmd.Root())
+		}
+
+		b, err := json.MarshalIndent(document, "", "  ")
+		if err != nil {
+			return err
+		}
+		cmd.Println(string(b))
+		return nil
+	}
+
+	return cmd
+}
+
+// availableCommands calls visit for root and every available command below it.
+func availableCommands(root *cobra.Command, visit func(*cobra.Command)) {
+	visit(root)
+	for _, child := range root.Commands() {
+		if child.IsAvailableCommand() {
+			availableCommands(child, visit)
+		}
+	}
+}
+
+// commandFlags returns a JSON Schema of the flags that belong to command. The
+// persistent flags of the root command are included only for the root.
+func commandFlags(command *cobra.Command) map[string]any {
+	flags := command.LocalNonPersistentFlags()
+	if !command.HasParent() {
+		flags = command.Flags()
+	}
+
+	properties := map[string]any{}
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Hidden || flag.Name == "help" {
+			return
+		}
+		properties[flag.Name] = flagSchema(flag)
+	})
+
+	schema := map[string]any{
+		"type":                 "object",
+		"description":          command.Short,
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+
+	var required []string
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if _, ok := flag.Annotations[cobra.BashCompOneRequiredFlag]; ok {
+			required = append(required, flag.Name)
+		}
+	})
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+// flagSchema returns a JSON Schema of the values of flag.
+func flagSchema(flag *pflag.Flag) map[string]any {
+	schema := map[string]any{"description": flag.Usage}
+
+	switch kind := flag.Value.Type(); kind {
+	case "bool":
+		schema["type"] = "boolean"
+		schema["default"] = flag.DefValue == "true"
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		schema["type"] = "integer"
+	case "float32", "float64":
+		schema["type"] = "number"
+	case "stringArray", "stringSlice":
+		schema["type"] = "array"
+		schema["items"] = map[string]any{"type": "string"}
+	case "duration":
+		schema["type"] = "string"
+		schema["format"] = "duration"
+	default:
+		schema["type"] = "string"
+	}
+
+	if _, ok := schema["default"]; !ok && flag.DefValue != "" && flag.DefValue != "[]" {
+		schema["default"] = flag.DefValue
+	}
+	if flag.Shorthand != "" {
+		schema["x-shorthand"] = flag.Shorthand
+	}
+
+	return schema
+}
+
+// commandJSONSchema returns a JSON Schema document with a definition for
+// every command below and including root.
+func commandJSONSchema(root *cobra.Command) map[string]any {
+	definitions := map[string]any{}
+	availableCommands(root, func(command *cobra.Command) {
+		schema := commandFlags(command)
+		schema["x-usage"] = command.UseLine()
+		definitions[command.CommandPath()] = schema
+	})
+
+	return map[string]any{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title":   root.Name(),
+		"version": clientVersion,
+		"$defs":   definitions,
+	}
+}
+
+// commandOpenAPI returns an OpenAPI document with an operation for every
+// runnable command below and including root.
+func commandOpenAPI(root *cobra.Command) map[string]any {
+	paths := map[string]any{}
+	availableCommands(root, func(command *cobra.Command) {
+		if !command.Runnable() {
+			return
+		}
+
+		words := strings.Fields(command.CommandPath())[1:]
+		paths["/"+strings.Join(words, "/")] = map[string]any{
+			"post": map[string]any{
+				"operationId": strings.Join(append([]string{root.Name()}, words...), "-"),
+				"summary":     command.Short,
+				"description": command.UseLine(),
+				"requestBody": map[string]any{
+					"content": map[string]any{
+						"application/json": map[string]any{
+							"schema": map[string]any{
+								"type": "object",
+								"properties": map[string]any{
+									"args": map[string]any{
+										"type":  "array",
+										"items": map[string]any{"type": "string"},
+									},
+									"flags": commandFlags(command),
+								},
+							},
+						},
+					},
+				},
+				"responses": map[string]any{
+					"default": map[string]any{
+						"description": "The output of the command",
+						"content": map[string]any{
+							"text/plain": map[string]any{
+								"schema": map[string]any{"type": "string"},
+							},
+						},
+					},
+				},
+			},
+		}
+	})
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       root.Name(),
+			"description": root.Short,
+			"version":     clientVersion,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": map[string]any{
+				"globalFlags": commandFlags(root),
+			},
+		},
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+)
+
+func TestCommandSchemas(t *testing.T) {
+	root := &cobra.Command{Use: "pgo", Short: "root"}
+	root.PersistentFlags().String("namespace", "", "the namespace")
+
+	child := &cobra.Command{Use: "backup CLUSTER_NAME", Short: "Backup cluster", Run: func(*cobra.Command, []string) {}}
+	child.Flags().Bool("force-conflicts", false, "take ownership")
+	child.Flags().StringArray("options", nil, "options for a backup")
+	child.Flags().IntP("count", "c", 3, "how many")
+	child.Flags().String("repoName", "", "repo")
+	assert.NilError(t, child.MarkFlagRequired("repoName"))
+
+	hidden := &cobra.Command{Use: "secret", Hidden: true, Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(child, hidden)
+
+	t.Run("JSONSchema", func(t *testing.T) {
+		definitions := commandJSONSchema(root)["$defs"].(map[string]any)
+		assert.Equal(t, len(definitions), 2)
+
+		backup := definitions["pgo backup"].(map[string]any)
+		assert.Equal(t, backup["x-usage"], "pgo backup CLUSTER_NAME [flags]")
+		assert.DeepEqual(t, backup["required"], []string{"repoName"})
+		assert.DeepEqual(t, backup["properties"], map[string]any{
+			"count": map[string]any{
+				"description": "how many", "type": "integer", "default": "3", "x-shorthand": "c",
+			},
+			"force-conflicts": map[string]any{
+				"description": "take ownership", "type": "boolean", "default": false,
+			},
+			"options": map[string]any{
+				"description": "options for a backup", "type": "array",
+				"items": map[string]any{"type": "string"},
+			},
+			"repoName": map[string]any{
+				"description": "repo", "type": "string",
+			},
+		})
+
+		// Persistent flags are described on the root only.
+		properties := definitions["pgo"].(map[string]any)["properties"].(map[string]any)
+		assert.Assert(t, properties["namespace"] != nil)
+	})
+
+	t.Run("OpenAPI", func(t *testing.T) {
+		paths := commandOpenAPI(root)["paths"].(map[string]any)
+		assert.Equal(t, len(paths), 1)
+
+		operation := paths["/backup"].(map[string]any)["post"].(map[string]any)
+		assert.Equal(t, operation["operationId"], "pgo-backup")
+		assert.Equal(t, operation["summary"], "Backup cluster")
+	})
+}
@@ -0,0 +1,165 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// newApplyCommand returns the apply subcommand of the PGO plugin. It replays
+// changes that were captured by the --record flag of mutating commands.
+func newApplyCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply --from-record FILE",
+		Short: "Apply changes recorded by other commands",
+		Long: `Apply replays the changes that mutating commands appended to a file with
+their --record flag. This allows one person to prepare changes and another to
+review and execute them.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    postgresclusters.postgres-operator.crunchydata.com  [create delete patch]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Record a backup of the 'hippo' postgrescluster without starting it
+pgo backup hippo --repoName repo1 --record changes.yaml
+
+# Review and apply the recorded changes
+pgo apply --from-record changes.yaml
+
+### Example output
+Recorded changes:
+  1. apply postgresclusters/hippo in namespace postgres-operator
+
+Do you want to apply these changes? (yes/no): yes
+postgresclusters/hippo applied`)
+
+	var fromRecord string
+	cmd.Flags().StringVar(&fromRecord, "from-record", "", "Path to a file written by the --record flag (required)")
+	cobra.CheckErr(cmd.MarkFlagRequired("from-record"))
+
+	cmd.Args = cobra.NoArgs
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		changes, err := internal.ReadRecordsFile(fromRecord)
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			cmd.Printf("No changes found in %s\n", fromRecord)
+			return nil
+		}
+
+		cmd.Println("Recorded changes:")
+		for i, change := range changes {
+			cmd.Printf("  %d. %s\n", i+1, change)
+		}
+		_, _ = fmt.Fprint(config.ErrOut, config.Messages.Sprintf("apply.confirm"))
+
+		var confirmed *bool
+		for i := 0; confirmed == nil && i < 10; i++ {
+			// retry 10 times or until a confirmation is given or denied,
+			// whichever comes first
+			confirmed = config.Messages.Confirm(config.In, config.ErrOut)
+		}
+		if confirmed == nil || !*confirmed {
+			return ErrCancelled
+		}
+
+		restConfig, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := dynamic.NewForConfig(restConfig)
+		if err != nil {
+			return err
+		}
+
+		// Stop at the first failure; later changes may depend on earlier ones.
+		for _, change := range changes {
+			if err := applyRecordedChange(ctx, client, config, change); err != nil {
+				return fmt.Errorf("%s: %w", change, err)
+			}
+			cmd.Printf("%s/%s %s\n", change.Resource, change.Name,
+				map[string]string{
+					internal.RecordApply:  "applied",
+					internal.RecordCreate: "created",
+					internal.RecordDelete: "deleted",
+				}[change.Operation])
+		}
+
+		return nil
+	}
+
+	return cmd
+}
+
+// applyRecordedChange sends one recorded change to the Kubernetes API.
+func applyRecordedChange(ctx context.Context, client dynamic.Interface,
+	config *internal.Config, change internal.RecordedChange,
+) error {
+	resource := client.Resource(change.GroupVersionResource()).Namespace(change.Namespace)
+
+	// Use the field manager of the recording so ownership is the same as if
+	// the original command had sent the change.
+	patchConfig := config.Patch
+	if change.FieldManager != "" {
+		patchConfig.FieldManager = change.FieldManager
+	}
+
+	switch change.Operation {
+	case internal.RecordCreate:
+		_, err := resource.Create(ctx,
+			&unstructured.Unstructured{Object: change.Object},
+			patchConfig.CreateOptions(metav1.CreateOptions{}))
+		return err
+
+	case internal.RecordDelete:
+		return resource.Delete(ctx, change.Name, metav1.DeleteOptions{})
+
+	default:
+		patch, err := (&unstructured.Unstructured{Object: change.Object}).MarshalJSON()
+		if err != nil {
+			return err
+		}
+
+		patchOptions := metav1.PatchOptions{}
+		if change.Force {
+			b := true
+			patchOptions.Force = &b
+		}
+
+		_, err = resource.Patch(ctx, change.Name, types.ApplyPatchType, patch,
+			patchConfig.PatchOptions(patchOptions))
+		return err
+	}
+}
+
+// recordChange appends change to the file named by the --record flag. It
+// returns a message telling the user the change was recorded rather than applied.
+func recordChange(config *internal.Config, change internal.RecordedChange) (string, error) {
+	change.FieldManager = config.Patch.FieldManager
+
+	if err := internal.AppendRecord(config.Record.File, change); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/%s %s recorded to %s\n",
+		change.Resource, change.Name, change.Operation, config.Record.File), nil
+}
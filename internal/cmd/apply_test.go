@@ -0,0 +1,165 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+)
+
+func TestApplyRecordedChange(t *testing.T) {
+	type request struct{ Method, Path, Query, ContentType, Body string }
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		requests = append(requests, request{
+			r.Method, r.URL.Path, r.URL.RawQuery, r.Header.Get("Content-Type"), string(b),
+		})
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"apiVersion":"postgres-operator.crunchydata.com/v1beta1","kind":"PostgresCluster"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := dynamic.NewForConfig(&rest.Config{Host: server.URL})
+	assert.NilError(t, err)
+	config := &internal.Config{Patch: internal.PatchConfig{FieldManager: "kubectl-pgo"}}
+	gvr := v1beta1.GroupVersion.WithResource("postgresclusters")
+
+	// The change is only the fields that the recording field manager owns,
+	// with the modification made by the command.
+	var cluster unstructured.Unstructured
+	assert.NilError(t, yaml.Unmarshal([]byte(`
+apiVersion: postgres-operator.crunchydata.com/v1beta1
+kind: PostgresCluster
+metadata:
+  name: hippo
+  namespace: zoo
+  managedFields:
+  - manager: kubectl-pgo-stop
+    operation: Apply
+    apiVersion: postgres-operator.crunchydata.com/v1beta1
+    fieldsType: FieldsV1
+    fieldsV1: {f:spec: {f:shutdown: {}}}
+spec:
+  postgresVersion: 16
+  shutdown: false
+`), &cluster.Object))
+	intent := new(unstructured.Unstructured)
+	assert.NilError(t, internal.ExtractFieldsInto(&cluster, intent, "kubectl-pgo-stop"))
+	assert.NilError(t, unstructured.SetNestedField(intent.Object, true, "spec", "shutdown"))
+
+	for _, tt := range []struct {
+		name    string
+		change  internal.RecordedChange
+		request request
+	}{
+		{
+			name: "Apply",
+			change: func() internal.RecordedChange {
+				change := internal.NewRecordedChange(internal.RecordApply, gvr, "zoo", "hippo", intent)
+				change.FieldManager, change.Force = "kubectl-pgo-stop", true
+				return change
+			}(),
+			request: request{
+				Method: "PATCH", Path: "/apis/postgres-operator.crunchydata.com/v1beta1/namespaces/zoo/postgresclusters/hippo",
+				Query: "fieldManager=kubectl-pgo-stop&force=true", ContentType: "application/apply-patch+yaml",
+				Body: `{"apiVersion":"postgres-operator.crunchydata.com/v1beta1","kind":"PostgresCluster",` +
+					`"spec":{"shutdown":true}}` + "\n",
+			},
+		},
+		{
+			name: "Create",
+			change: internal.NewRecordedChange(internal.RecordCreate, gvr, "zoo", "rhino",
+				&unstructured.Unstructured{Object: map[string]any{"metadata": map[string]any{"name": "rhino"}}}),
+			request: request{
+				Method: "POST", Path: "/apis/postgres-operator.crunchydata.com/v1beta1/namespaces/zoo/postgresclusters",
+				Query: "fieldManager=kubectl-pgo", ContentType: "application/json",
+				Body: `{"metadata":{"name":"rhino"}}` + "\n",
+			},
+		},
+		{
+			name:   "Delete",
+			change: internal.NewRecordedChange(internal.RecordDelete, gvr, "zoo", "elephant", nil),
+			request: request{
+				Method: "DELETE", Path: "/apis/postgres-operator.crunchydata.com/v1beta1/namespaces/zoo/postgresclusters/elephant",
+				ContentType: "application/json",
+				Body:        `{"kind":"DeleteOptions","apiVersion":"v1"}` + "\n",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = nil
+			assert.NilError(t, applyRecordedChange(context.Background(), client, config, tt.change))
+			assert.DeepEqual(t, requests, []request{tt.request})
+		})
+	}
+}
+
+func TestRecordChange(t *testing.T) {
+	dir := t.TempDir()
+	config := &internal.Config{
+		Patch:  internal.PatchConfig{FieldManager: "kubectl-pgo-backup"},
+		Record: internal.RecordConfig{File: filepath.Join(dir, "changes.yaml")},
+	}
+	change := internal.NewRecordedChange(internal.RecordApply,
+		v1beta1.GroupVersion.WithResource("postgresclusters"), "zoo", "hippo",
+		&unstructured.Unstructured{Object: map[string]any{"spec": map[string]any{"shutdown": true}}})
+
+	msg, err := recordChange(config, change)
+	assert.NilError(t, err)
+	assert.Equal(t, msg, "postgresclusters/hippo apply recorded to "+config.Record.File+"\n")
+
+	changes, err := internal.ReadRecordsFile(config.Record.File)
+	assert.NilError(t, err)
+	assert.Equal(t, len(changes), 1)
+	assert.Equal(t, changes[0].FieldManager, "kubectl-pgo-backup")
+	assert.DeepEqual(t, changes[0].Object, change.Object)
+}
+
+func TestApplyDeclined(t *testing.T) {
+	// Nothing is sent to Kubernetes, so there is no cluster at all.
+	t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "missing"))
+
+	path := filepath.Join(t.TempDir(), "changes.yaml")
+	assert.NilError(t, internal.AppendRecord(path, internal.NewRecordedChange(internal.RecordDelete,
+		v1beta1.GroupVersion.WithResource("postgresclusters"), "zoo", "hippo", nil)))
+
+	var stdout, stderr bytes.Buffer
+	root := NewPGOCommand(strings.NewReader("no\n"), &stdout, &stderr)
+	root.SetArgs([]string{"apply", "--from-record", path})
+	assert.Assert(t, errors.Is(root.Execute(), ErrCancelled))
+	assert.Equal(t, stdout.String(), `Recorded changes:
+  1. delete postgresclusters/hippo in namespace zoo
+`)
+	assert.Equal(t, stderr.String(), "\nDo you want to apply these changes? (yes/no): ")
+
+	t.Run("Empty", func(t *testing.T) {
+		empty := filepath.Join(t.TempDir(), "empty.yaml")
+		assert.NilError(t, os.WriteFile(empty, nil, 0o600))
+
+		stdout.Reset()
+		root := NewPGOCommand(strings.NewReader(""), &stdout, io.Discard)
+		root.SetArgs([]string{"apply", "--from-record", empty})
+		assert.NilError(t, root.Execute())
+		assert.Equal(t, stdout.String(), "No changes found in "+empty+"\n")
+	})
+}
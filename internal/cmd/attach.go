@@ -0,0 +1,277 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+)
+
+// watchPollInterval is how often a watched operation checks its PostgresCluster.
+const watchPollInterval = 5 * time.Second
+
+// newAttachCommand returns the attach command. It resumes watching an
+// operation that was started with "--detach" or interrupted while waiting.
+func newAttachCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "attach TOKEN",
+		Short: "Watch a long-running operation",
+		Long: `Watch a long-running operation, such as a backup or restore, until it finishes. The
+token is printed by commands that are run with the "--wait" or "--detach" flag.
+
+While it waits, this prints a heartbeat with the elapsed time, the current
+phase, and the most recent condition transition of the PostgresCluster.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    postgresclusters.postgres-operator.crunchydata.com  [get]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Resume watching a restore of the 'hippo' postgrescluster
+pgo attach eyJvcGVyYXRpb24iOiJyZXN0b3JlIiwibmFtZXNwYWNlIjoicG9zdGdyZXMtb3BlcmF0b3IiLCJuYW1lIjoiaGlwcG8iLCJpZCI6IjIwMjUtMDEtMDJUMDM6MDQ6MDVaIn0
+
+### Example output
+[5m0s] restore of postgresclusters/hippo: running; last transition PGBackRestRestoreProgressing=True (RestoreInProgress) 4m55s ago
+[6m10s] restore of postgresclusters/hippo: succeeded`)
+
+	var heartbeat time.Duration
+	cmd.Flags().DurationVar(&heartbeat, "heartbeat", 30*time.Second,
+		"how often to print progress while waiting")
+
+	// Only one positional argument: the token.
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		token, err := parseWatchToken(args[0])
+		if err != nil {
+			return err
+		}
+
+		_, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+
+		return watchOperation(context.Background(), cmd.OutOrStdout(),
+			client, token, heartbeat)
+	}
+
+	return cmd
+}
+
+// watchToken identifies an operation on a PostgresCluster so that another
+// invocation of the CLI can watch it.
+type watchToken struct {
+	Operation string `json:"operation"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	ID        string `json:"id"`
+}
+
+// String returns the token in the form accepted by "pgo attach".
+func (token watchToken) String() string {
+	b, _ := json.Marshal(token)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Started returns when the operation was requested, if its ID records that.
+func (token watchToken) Started() (time.Time, bool) {
+	started, err := time.Parse(time.RFC3339, token.ID)
+	return started, err == nil
+}
+
+// parseWatchToken reverses [watchToken.String].
+func parseWatchToken(value string) (watchToken, error) {
+	var token watchToken
+
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err == nil {
+		err = json.Unmarshal(b, &token)
+	}
+	if err == nil && (token.Namespace == "" || token.Name == "" || token.ID == "") {
+		err = errors.New("missing fields")
+	}
+	if err == nil && watchedOperations[token.Operation] == nil {
+		err = fmt.Errorf("unknown operation %q", token.Operation)
+	}
+	if err != nil {
+		return token, fmt.Errorf("invalid token: %w", err)
+	}
+	return token, nil
+}
+
+// operationPhase is the progress of an operation as seen in the status of
+// its PostgresCluster.
+type operationPhase struct {
+	Phase string
+	Done  bool
+	Err   error
+}
+
+// watchedOperations are the operations that can be watched, by name. Each
+// function reports the phase of the operation identified by id.
+var watchedOperations = map[string]func(cluster *unstructured.Unstructured, id string) operationPhase{
+	"backup":  backupPhase,
+	"restore": restorePhase,
+}
+
+// backupPhase reports the progress of the manual backup requested with id.
+// PGO copies the backup annotation into the status of the backup Job.
+func backupPhase(cluster *unstructured.Unstructured, id string) operationPhase {
+	status, _, _ := unstructured.NestedMap(cluster.Object, "status", "pgbackrest", "manualBackup")
+	if current, _, _ := unstructured.NestedString(status, "id"); current != id {
+		return operationPhase{Phase: "pending"}
+	}
+
+	active, _, _ := unstructured.NestedInt64(status, "active")
+	failed, _, _ := unstructured.NestedInt64(status, "failed")
+	finished, _, _ := unstructured.NestedBool(status, "finished")
+	succeeded, _, _ := unstructured.NestedInt64(status, "succeeded")
+
+	switch {
+	case finished && succeeded > 0:
+		return operationPhase{Phase: "succeeded", Done: true}
+	case finished:
+		return operationPhase{Phase: "failed", Done: true, Err: errors.New("backup failed")}
+	case active > 0 && failed > 0:
+		return operationPhase{Phase: fmt.Sprintf("running (%d failed attempts)", failed)}
+	case active > 0:
+		return operationPhase{Phase: "running"}
+	}
+	return operationPhase{Phase: "starting"}
+}
+
+// restorePhase reports the progress of the in-place restore requested with id.
+// PGO copies the restore annotation into the status of the restore Job.
+func restorePhase(cluster *unstructured.Unstructured, id string) operationPhase {
+	status, _, _ := unstructured.NestedMap(cluster.Object, "status", "pgbackrest", "restore")
+	if current, _, _ := unstructured.NestedString(status, "id"); current != id {
+		return operationPhase{Phase: "pending"}
+	}
+
+	active, _, _ := unstructured.NestedInt64(status, "active")
+	failed, _, _ := unstructured.NestedInt64(status, "failed")
+	finished, _, _ := unstructured.NestedBool(status, "finished")
+	succeeded, _, _ := unstructured.NestedInt64(status, "succeeded")
+
+	switch {
+	case finished && succeeded > 0:
+		return operationPhase{Phase: "succeeded", Done: true}
+	case finished:
+		return operationPhase{Phase: "failed", Done: true, Err: errors.New("restore failed")}
+	case active > 0 && failed > 0:
+		return operationPhase{Phase: fmt.Sprintf("running (%d failed attempts)", failed)}
+	case active > 0:
+		return operationPhase{Phase: "running"}
+	}
+	return operationPhase{Phase: "starting"}
+}
+
+// lastConditionTransition describes the condition of cluster that changed most
+// recently. It returns an empty string when there are no conditions.
+func lastConditionTransition(cluster *unstructured.Unstructured, now time.Time) string {
+	conditions, _, _ := unstructured.NestedSlice(cluster.Object, "status", "conditions")
+
+	var latest map[string]any
+	var latestTime time.Time
+	for _, item := range conditions {
+		condition, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		value, _, _ := unstructured.NestedString(condition, "lastTransitionTime")
+		if transition, err := time.Parse(time.RFC3339, value); err == nil &&
+			(latest == nil || transition.After(latestTime)) {
+			latest, latestTime = condition, transition
+		}
+	}
+	if latest == nil {
+		return ""
+	}
+
+	kind, _, _ := unstructured.NestedString(latest, "type")
+	status, _, _ := unstructured.NestedString(latest, "status")
+	reason, _, _ := unstructured.NestedString(latest, "reason")
+
+	out := kind + "=" + status
+	if reason != "" {
+		out += " (" + reason + ")"
+	}
+	return out + " " + now.Sub(latestTime).Round(time.Second).String() + " ago"
+}
+
+// formatHeartbeat returns one line of progress for an operation.
+func formatHeartbeat(token watchToken, elapsed time.Duration, phase, transition string) string {
+	out := fmt.Sprintf("[%s] %s of postgresclusters/%s: %s",
+		elapsed.Round(time.Second), token.Operation, token.Name, phase)
+	if transition != "" {
+		out += "; last transition " + transition
+	}
+	return out
+}
+
+// watchOperation prints progress of the operation identified by token until it
+// finishes. Progress is printed when the phase changes and at least once every
+// heartbeat.
+func watchOperation(
+	ctx context.Context, out io.Writer,
+	client dynamic.NamespaceableResourceInterface, token watchToken, heartbeat time.Duration,
+) error {
+	check := watchedOperations[token.Operation]
+
+	start, ok := token.Started()
+	if !ok {
+		start = time.Now()
+	}
+
+	var lastPhase string
+	var lastPrinted time.Time
+
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	for {
+		cluster, err := client.Namespace(token.Namespace).Get(ctx, token.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		phase := check(cluster, token.ID)
+		if phase.Done || phase.Phase != lastPhase || now.Sub(lastPrinted) >= heartbeat {
+			transition := ""
+			if !phase.Done {
+				transition = lastConditionTransition(cluster, now)
+			}
+			_, _ = fmt.Fprintln(out, formatHeartbeat(token, now.Sub(start), phase.Phase, transition))
+			lastPhase, lastPrinted = phase.Phase, now
+		}
+		if phase.Done {
+			return phase.Err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
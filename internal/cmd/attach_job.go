@@ -0,0 +1,301 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// jobLogTypes are the values of "attach-job --type" and the [jobType] of the
+// Jobs that each one streams.
+var jobLogTypes = map[string]string{
+	"backup":  "backup",
+	"restore": "restore",
+	"upgrade": "pgupgrade",
+}
+
+// newAttachJobCommand returns the attach-job command. It streams the logs of
+// the running backup, restore, or upgrade Job of a PostgresCluster.
+func newAttachJobCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "attach-job CLUSTER_NAME",
+		Short: "Stream the logs of a running backup, restore, or upgrade Job",
+		Long: `Stream the logs of the most recent backup, restore, or upgrade Job of a
+PostgresCluster until the Job finishes. The Pod of the Job is found for you.
+When the Pod restarts or the Job replaces it, the logs of the new Pod are
+streamed without repeating lines that were already printed.
+
+The last "--tail" lines of the Job are printed first. When no Job of the type
+is running, the logs of the most recent one are printed.
+
+### RBAC Requirements
+    Resources   Verbs
+    ---------   -----
+    jobs.batch  [get list]
+    pods        [list]
+    pods/log    [get]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Stream the logs of the running backup of the 'hippo' postgrescluster
+pgo attach-job hippo --type=backup
+
+### Example output
+--- job/hippo-backup-b9p7 pod/hippo-backup-b9p7-xm2sl ---
+2024-05-01 14:22:40.101 P00   INFO: backup command begin 2.51: --exec-id=123-ab12cd34 ...
+2024-05-01 14:22:43.512 P00   INFO: execute non-exclusive backup start
+--- job/hippo-backup-b9p7 Complete ---`)
+
+	var typ string
+	var tail int64
+	cmd.Flags().StringVar(&typ, "type", "backup", "type of Job. types supported: backup,restore,upgrade")
+	cmd.Flags().Int64Var(&tail, "tail", 100, "number of lines of earlier logs to print first")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		if jobLogTypes[typ] == "" {
+			return fmt.Errorf("unknown type %q; types supported: backup,restore,upgrade", typ)
+		}
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		clientset, err := kubernetes.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		jobs, err := clientset.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.LabelCluster + "=" + args[0],
+		})
+		if err != nil {
+			return err
+		}
+		job := selectLogJob(jobs.Items, jobLogTypes[typ])
+		if job == nil {
+			return fmt.Errorf("no %s Job found for cluster %s", typ, args[0])
+		}
+
+		stream := &jobLogStream{
+			Out:  cmd.OutOrStdout(),
+			Tail: tail,
+			Poll: 2 * time.Second,
+			Job: func() (*batchv1.Job, error) {
+				return clientset.BatchV1().Jobs(namespace).Get(ctx, job.GetName(), metav1.GetOptions{})
+			},
+			Pods: func() ([]corev1.Pod, error) {
+				pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+					LabelSelector: "job-name=" + job.GetName(),
+				})
+				if err != nil {
+					return nil, err
+				}
+				return pods.Items, nil
+			},
+			Logs: func(pod string, options *corev1.PodLogOptions) (io.ReadCloser, error) {
+				return clientset.CoreV1().Pods(namespace).GetLogs(pod, options).Stream(ctx)
+			},
+		}
+		return stream.Run(job.GetName())
+	}
+
+	return cmd
+}
+
+// selectLogJob returns the newest running Job in jobs of typ, as reported by
+// [jobType], or the newest one of typ when none is running.
+func selectLogJob(jobs []batchv1.Job, typ string) *batchv1.Job {
+	var matching []*batchv1.Job
+	for i := range jobs {
+		if kind, _, _ := strings.Cut(jobType(&jobs[i]), "/"); kind == typ {
+			matching = append(matching, &jobs[i])
+		}
+	}
+	sort.SliceStable(matching, func(i, j int) bool {
+		return matching[j].CreationTimestamp.Before(&matching[i].CreationTimestamp)
+	})
+	for _, job := range matching {
+		if status := jobStatus(job); status == "Running" || status == "Pending" {
+			return job
+		}
+	}
+	if len(matching) > 0 {
+		return matching[0]
+	}
+	return nil
+}
+
+// jobLogPod returns the Pod in pods whose logs to stream: the newest one that
+// has started a container.
+func jobLogPod(pods []corev1.Pod) *corev1.Pod {
+	var newest *corev1.Pod
+	for i := range pods {
+		if pods[i].Status.Phase == corev1.PodPending || len(pods[i].Spec.Containers) == 0 {
+			continue
+		}
+		if newest == nil || newest.CreationTimestamp.Before(&pods[i].CreationTimestamp) {
+			newest = &pods[i]
+		}
+	}
+	return newest
+}
+
+// jobLogStream follows the logs of a Job across its Pods. It reaches the
+// cluster through functions so the loop can be tested.
+type jobLogStream struct {
+	Out  io.Writer
+	Tail int64
+	Poll time.Duration
+
+	Job  func() (*batchv1.Job, error)
+	Pods func() ([]corev1.Pod, error)
+	Logs func(pod string, options *corev1.PodLogOptions) (io.ReadCloser, error)
+}
+
+// jobLogLinesKept is how many printed lines are remembered to avoid printing
+// them again after reconnecting. Kubernetes resumes logs at a whole second.
+const jobLogLinesKept = 1000
+
+// Run prints the logs of the Job called name until it finishes. It returns an
+// error when the Job failed.
+func (stream *jobLogStream) Run(name string) error {
+	seen := newLogLineBuffer(jobLogLinesKept)
+	since := map[string]*metav1.Time{}
+	var current, waiting string
+
+	for {
+		job, err := stream.Job()
+		if err != nil {
+			return err
+		}
+		status := jobStatus(job)
+		finished := status == "Complete" || status == "Failed"
+
+		pods, err := stream.Pods()
+		if err != nil {
+			return err
+		}
+		if pod := jobLogPod(pods); pod != nil {
+			options := &corev1.PodLogOptions{
+				Container:  pod.Spec.Containers[0].Name,
+				Follow:     true,
+				Timestamps: true,
+			}
+			if since[pod.GetName()] != nil {
+				options.SinceTime = since[pod.GetName()]
+			} else if stream.Tail >= 0 && current == "" {
+				options.TailLines = &stream.Tail
+			}
+
+			reader, err := stream.Logs(pod.GetName(), options)
+			if err != nil {
+				if message := err.Error(); message != waiting {
+					fmt.Fprintf(stream.Out, "--- waiting for pod/%s: %s ---\n", pod.GetName(), message)
+					waiting = message
+				}
+			} else {
+				if pod.GetName() != current {
+					fmt.Fprintf(stream.Out, "--- job/%s pod/%s ---\n", name, pod.GetName())
+					current = pod.GetName()
+				}
+				waiting = ""
+				last := copyNewLogLines(stream.Out, reader, seen)
+				_ = reader.Close()
+				if !last.IsZero() {
+					since[pod.GetName()] = &metav1.Time{Time: last}
+				}
+			}
+		}
+
+		// Any logs written before the Job finished have been read.
+		if finished {
+			fmt.Fprintf(stream.Out, "--- job/%s %s ---\n", name, status)
+			if status == "Failed" {
+				return fmt.Errorf("job %s failed", name)
+			}
+			return nil
+		}
+		time.Sleep(stream.Poll)
+	}
+}
+
+// copyNewLogLines copies the lines of reader that are not in seen to w
+// without their timestamps. It returns the timestamp of the last line.
+func copyNewLogLines(w io.Writer, reader io.Reader, seen *logLineBuffer) time.Time {
+	var last time.Time
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if seen.contains(line) {
+			continue
+		}
+		seen.add(line)
+
+		stamp, text, _ := strings.Cut(line, " ")
+		if t, err := time.Parse(time.RFC3339Nano, stamp); err == nil {
+			last = t
+		} else {
+			text = line
+		}
+		fmt.Fprintln(w, text)
+	}
+	return last
+}
+
+// logLineBuffer remembers the most recent lines up to its size.
+type logLineBuffer struct {
+	lines []string
+	next  int
+	count map[string]int
+}
+
+func newLogLineBuffer(size int) *logLineBuffer {
+	return &logLineBuffer{lines: make([]string, 0, size), count: map[string]int{}}
+}
+
+// add remembers line, forgetting the oldest line when the buffer is full.
+func (buffer *logLineBuffer) add(line string) {
+	if len(buffer.lines) < cap(buffer.lines) {
+		buffer.lines = append(buffer.lines, line)
+	} else {
+		oldest := buffer.lines[buffer.next]
+		if buffer.count[oldest]--; buffer.count[oldest] == 0 {
+			delete(buffer.count, oldest)
+		}
+		buffer.lines[buffer.next] = line
+		buffer.next = (buffer.next + 1) % len(buffer.lines)
+	}
+	buffer.count[line]++
+}
+
+// contains returns whether line is remembered.
+func (buffer *logLineBuffer) contains(line string) bool {
+	return buffer.count[line] > 0
+}
@@ -0,0 +1,138 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+func TestSelectLogJob(t *testing.T) {
+	job := func(name, backup string, created time.Time, active int32) batchv1.Job {
+		job := batchv1.Job{}
+		job.Name = name
+		job.CreationTimestamp = metav1.NewTime(created)
+		job.Labels = map[string]string{util.LabelPGBackRestBackup: backup}
+		job.Status.Active = active
+		if active == 0 {
+			job.Status.Conditions = []batchv1.JobCondition{{
+				Type: batchv1.JobComplete, Status: corev1.ConditionTrue,
+			}}
+		}
+		return job
+	}
+	now := time.Now()
+	jobs := []batchv1.Job{
+		job("old", "scheduled", now.Add(-time.Hour), 0),
+		job("running", "manual", now.Add(-time.Minute), 1),
+		job("newest", "scheduled", now, 0),
+	}
+
+	assert.Equal(t, selectLogJob(jobs, "backup").Name, "running")
+	assert.Equal(t, selectLogJob(jobs[2:], "backup").Name, "newest")
+	assert.Assert(t, selectLogJob(jobs, "restore") == nil)
+}
+
+func TestLogLineBuffer(t *testing.T) {
+	buffer := newLogLineBuffer(2)
+	buffer.add("a")
+	buffer.add("b")
+	assert.Assert(t, buffer.contains("a") && buffer.contains("b"))
+
+	buffer.add("c")
+	assert.Assert(t, !buffer.contains("a"), "oldest is forgotten")
+	assert.Assert(t, buffer.contains("b") && buffer.contains("c"))
+
+	buffer.add("c")
+	buffer.add("d")
+	assert.Assert(t, !buffer.contains("b"))
+	assert.Assert(t, buffer.contains("c") && buffer.contains("d"))
+}
+
+func TestJobLogStream(t *testing.T) {
+	pod := func(name string, created time.Time) corev1.Pod {
+		pod := corev1.Pod{}
+		pod.Name = name
+		pod.CreationTimestamp = metav1.NewTime(created)
+		pod.Spec.Containers = []corev1.Container{{Name: "pgbackrest"}}
+		pod.Status.Phase = corev1.PodRunning
+		return pod
+	}
+	now := time.Now()
+
+	// The first Pod fails after two lines, and reconnecting to it repeats the
+	// second line. Then the Job replaces it and finishes.
+	type call struct {
+		pod     string
+		options corev1.PodLogOptions
+	}
+	var calls []call
+	step := 0
+	logs := map[int]string{
+		0: "2024-05-01T14:22:40.1Z begin\n2024-05-01T14:22:41.2Z working\n",
+		1: "2024-05-01T14:22:41.2Z working\n2024-05-01T14:22:42.3Z crashed\n",
+		2: "2024-05-01T14:23:00.0Z begin again\n2024-05-01T14:23:05.0Z done\n",
+	}
+
+	var out bytes.Buffer
+	stream := &jobLogStream{
+		Out: &out, Tail: 10,
+		Job: func() (*batchv1.Job, error) {
+			job := &batchv1.Job{}
+			job.Status.Active = 1
+			if step == 3 {
+				job.Status.Conditions = []batchv1.JobCondition{{
+					Type: batchv1.JobFailed, Status: corev1.ConditionTrue,
+				}}
+			}
+			return job, nil
+		},
+		Pods: func() ([]corev1.Pod, error) {
+			if step < 2 {
+				return []corev1.Pod{pod("backup-a", now)}, nil
+			}
+			return []corev1.Pod{pod("backup-a", now), pod("backup-b", now.Add(time.Minute))}, nil
+		},
+		Logs: func(name string, options *corev1.PodLogOptions) (io.ReadCloser, error) {
+			calls = append(calls, call{pod: name, options: *options})
+			if step == 3 {
+				return nil, errors.New("gone")
+			}
+			defer func() { step++ }()
+			return io.NopCloser(strings.NewReader(logs[step])), nil
+		},
+	}
+
+	err := stream.Run("backup")
+	assert.ErrorContains(t, err, "job backup failed")
+	assert.Equal(t, out.String(), `--- job/backup pod/backup-a ---
+begin
+working
+crashed
+--- job/backup pod/backup-b ---
+begin again
+done
+--- waiting for pod/backup-b: gone ---
+--- job/backup Failed ---
+`)
+
+	assert.Equal(t, len(calls), 4)
+	assert.Equal(t, *calls[0].options.TailLines, int64(10))
+	assert.Assert(t, calls[1].options.SinceTime != nil)
+	assert.Assert(t, calls[1].options.TailLines == nil)
+	assert.Assert(t, calls[2].options.SinceTime == nil && calls[2].options.TailLines == nil,
+		"all the logs of a new Pod")
+}
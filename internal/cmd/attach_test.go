@@ -0,0 +1,153 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/json"
+	"sigs.k8s.io/yaml"
+)
+
+func TestWatchToken(t *testing.T) {
+	token := watchToken{
+		Operation: "restore",
+		Namespace: "postgres-operator",
+		Name:      "hippo",
+		ID:        "2025-01-02T03:04:05Z",
+	}
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		parsed, err := parseWatchToken(token.String())
+		assert.NilError(t, err)
+		assert.Equal(t, parsed, token)
+
+		started, ok := parsed.Started()
+		assert.Assert(t, ok)
+		assert.Equal(t, started, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := parseWatchToken("not a token!")
+		assert.ErrorContains(t, err, "invalid token")
+
+		_, err = parseWatchToken(watchToken{Operation: "restore"}.String())
+		assert.ErrorContains(t, err, "missing fields")
+
+		unknown := token
+		unknown.Operation = "explode"
+		_, err = parseWatchToken(unknown.String())
+		assert.ErrorContains(t, err, `unknown operation "explode"`)
+	})
+}
+
+func TestRestorePhase(t *testing.T) {
+	// Decode numbers as int64 the way the API client does.
+	cluster := func(status string) *unstructured.Unstructured {
+		b, err := yaml.YAMLToJSON([]byte(status))
+		assert.NilError(t, err)
+
+		u := new(unstructured.Unstructured)
+		assert.NilError(t, json.Unmarshal(b, &u.Object))
+		return u
+	}
+
+	for _, tt := range []struct {
+		Name, Status, Phase string
+		Done, Failed        bool
+	}{
+		{Name: "NoStatus", Status: `{}`, Phase: "pending"},
+		{Name: "PreviousRestore", Phase: "pending", Status: `
+status: { pgbackrest: { restore: { id: older, finished: true, succeeded: 1 } } }`},
+		{Name: "Starting", Phase: "starting", Status: `
+status: { pgbackrest: { restore: { id: abc } } }`},
+		{Name: "Running", Phase: "running", Status: `
+status: { pgbackrest: { restore: { id: abc, active: 1 } } }`},
+		{Name: "Retrying", Phase: "running (2 failed attempts)", Status: `
+status: { pgbackrest: { restore: { id: abc, active: 1, failed: 2 } } }`},
+		{Name: "Succeeded", Phase: "succeeded", Done: true, Status: `
+status: { pgbackrest: { restore: { id: abc, finished: true, succeeded: 1 } } }`},
+		{Name: "Failed", Phase: "failed", Done: true, Failed: true, Status: `
+status: { pgbackrest: { restore: { id: abc, finished: true, failed: 6 } } }`},
+	} {
+		t.Run(tt.Name, func(t *testing.T) {
+			phase := restorePhase(cluster(tt.Status), "abc")
+			assert.Equal(t, phase.Phase, tt.Phase)
+			assert.Equal(t, phase.Done, tt.Done)
+			assert.Equal(t, phase.Err != nil, tt.Failed)
+		})
+	}
+}
+
+func TestBackupPhase(t *testing.T) {
+	// Decode numbers as int64 the way the API client does.
+	cluster := func(status string) *unstructured.Unstructured {
+		b, err := yaml.YAMLToJSON([]byte(status))
+		assert.NilError(t, err)
+
+		u := new(unstructured.Unstructured)
+		assert.NilError(t, json.Unmarshal(b, &u.Object))
+		return u
+	}
+
+	for _, tt := range []struct {
+		Name, Status, Phase string
+		Done, Failed        bool
+	}{
+		{Name: "NoStatus", Status: `{}`, Phase: "pending"},
+		{Name: "PreviousBackup", Phase: "pending", Status: `
+status: { pgbackrest: { manualBackup: { id: older, finished: true, succeeded: 1 } } }`},
+		{Name: "Running", Phase: "running", Status: `
+status: { pgbackrest: { manualBackup: { id: abc, active: 1 } } }`},
+		{Name: "Succeeded", Phase: "succeeded", Done: true, Status: `
+status: { pgbackrest: { manualBackup: { id: abc, finished: true, succeeded: 1 } } }`},
+		{Name: "Failed", Phase: "failed", Done: true, Failed: true, Status: `
+status: { pgbackrest: { manualBackup: { id: abc, finished: true, failed: 2 } } }`},
+	} {
+		t.Run(tt.Name, func(t *testing.T) {
+			phase := backupPhase(cluster(tt.Status), "abc")
+			assert.Equal(t, phase.Phase, tt.Phase)
+			assert.Equal(t, phase.Done, tt.Done)
+			assert.Equal(t, phase.Err != nil, tt.Failed)
+		})
+	}
+}
+
+func TestLastConditionTransition(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 10, 0, 0, time.UTC)
+
+	u := new(unstructured.Unstructured)
+	assert.Equal(t, lastConditionTransition(u, now), "")
+
+	assert.NilError(t, yaml.Unmarshal([]byte(`
+status:
+  conditions:
+  - type: ProxyAvailable
+    status: "True"
+    lastTransitionTime: "2025-01-01T00:00:00Z"
+  - type: PGBackRestRestoreProgressing
+    status: "True"
+    reason: RestoreInProgress
+    lastTransitionTime: "2025-01-02T03:05:00Z"
+  - type: Broken
+    status: "False"
+    lastTransitionTime: garbage
+`), &u.Object))
+
+	assert.Equal(t, lastConditionTransition(u, now),
+		"PGBackRestRestoreProgressing=True (RestoreInProgress) 5m0s ago")
+}
+
+func TestFormatHeartbeat(t *testing.T) {
+	token := watchToken{Operation: "restore", Name: "hippo"}
+
+	assert.Equal(t, formatHeartbeat(token, 90*time.Second+400*time.Millisecond, "running", "Ready=True 1s ago"),
+		"[1m30s] restore of postgresclusters/hippo: running; last transition Ready=True 1s ago")
+	assert.Equal(t, formatHeartbeat(token, time.Hour, "succeeded", ""),
+		"[1h0m0s] restore of postgresclusters/hippo: succeeded")
+}
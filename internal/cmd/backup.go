@@ -6,6 +6,7 @@ package cmd
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -17,6 +18,7 @@ import (
 
 	"github.com/crunchydata/postgres-operator-client/internal"
 	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
 )
 
 // newBackupCommand returns the backup command of the PGO plugin.
@@ -53,6 +55,9 @@ pgo backup hippo --repoName="repo1" --options="--type=full"
 # Resolve ownership conflict
 pgo backup hippo --force-conflicts
 
+# Record the backup request to apply later with "pgo apply"
+pgo backup hippo --repoName="repo1" --record changes.yaml
+
 ### Example output
 postgresclusters/hippo backup initiated`)
 
@@ -64,6 +69,7 @@ postgresclusters/hippo backup initiated`)
 	cmdBackup.Flags().StringVar(&backup.RepoName, "repoName", "", "repoName to backup to")
 	cmdBackup.Flags().StringArrayVar(&backup.Options, "options", []string{},
 		"options for taking a backup; can be used multiple times")
+	config.Record.AddFlags(cmdBackup.Flags())
 
 	// Define the 'backup' command
 	cmdBackup.RunE = func(cmd *cobra.Command, args []string) error {
@@ -81,7 +87,7 @@ postgresclusters/hippo backup initiated`)
 		if msg != "" {
 			cmd.Println(msg)
 		}
-		if err == nil {
+		if err == nil && !config.Record.Enabled() {
 			// Our `backup` command initiates a job, but does not signal to the user
 			// that a backup has finished; consider a `--wait` flag to wait until the
 			// backup is done.
@@ -106,7 +112,7 @@ func (backup pgBackRestBackupArgs) modifyIntent(
 ) error {
 	intent.SetAnnotations(internal.MergeStringMaps(
 		intent.GetAnnotations(), map[string]string{
-			"postgres-operator.crunchydata.com/pgbackrest-backup": now.UTC().Format(time.RFC3339),
+			util.AnnotationPGBackRestBackup: now.UTC().Format(time.RFC3339),
 		}))
 
 	if value, path := backup.Options, []string{
@@ -168,13 +174,26 @@ func (backup pgBackRestBackupArgs) Run(client dynamic.NamespaceableResourceInter
 	if err = backup.modifyIntent(intent, time.Now()); err != nil {
 		return "", err
 	}
+	if err = checkClusterChange(config.ErrOut, &config.Messages, cluster, intent); err != nil {
+		return "", err
+	}
 
 	if patch, err = intent.MarshalJSON(); err != nil {
 		return "Error packaging payload", err
 	}
 
+	// Save the change for later rather than sending it.
+	if config.Record.Enabled() {
+		change := internal.NewRecordedChange(internal.RecordApply,
+			v1beta1.GroupVersion.WithResource("postgresclusters"),
+			namespace, backup.ClusterName, intent)
+		change.Force = backup.ForceConflicts
+
+		msg, err := recordChange(config, change)
+		return strings.TrimSpace(msg), err
+	}
+
 	// Update the spec/annotate
-	// TODO(benjaminjb): Would we want to allow a dry-run option here?
 	patchOptions := metav1.PatchOptions{}
 	if backup.ForceConflicts {
 		b := true
@@ -0,0 +1,525 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newBenchCommand returns the bench subcommand of the PGO plugin. It runs
+// pgbench against a PostgresCluster from a Pod in Kubernetes.
+func newBenchCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bench CLUSTER_NAME",
+		Short: "Benchmark a PostgresCluster with pgbench",
+		Long: `Run the default pgbench workload against a PostgresCluster from a Pod in the
+same namespace, so the network between this machine and Kubernetes does not
+skew the results. The Pod uses the Postgres image of the cluster and the
+credentials of "--user" from its Secret. It is deleted when the run ends.
+
+The pgbench tables must exist first; create them with "pgo seed --dataset=pgbench".
+Use "--via=pgbouncer" to connect through pgBouncer rather than directly to
+the primary.
+
+Throughput and latency are printed every "--progress" while pgbench runs. Use
+"--report" to also write the results as JSON, along with the resources and
+storage of the primary, to compare instance sizes or storage classes.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    persistentvolumeclaims                              [get]
+    pods                                                [list create get delete]
+    pods/exec                                           [create]
+    pods/log                                            [get]
+    postgresclusters.postgres-operator.crunchydata.com  [get]
+    secrets                                             [list]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Benchmark the 'hippo' postgrescluster with 16 clients for five minutes
+pgo bench hippo --clients=16 --duration=5m
+
+# Benchmark through pgBouncer and save the results
+pgo bench hippo --clients=16 --duration=5m --via=pgbouncer --report=bench.json
+
+### Example output
+pods/hippo-pgbench-x7k2q created; connecting to hippo-primary.postgres-operator.svc
+[10s] 1843.2 tps, latency 8.672 ms (stddev 2.104), 0 failed
+[20s] 1912.7 tps, latency 8.361 ms (stddev 1.977), 0 failed
+...
+transactions: 562811
+tps: 1876.04
+latency average: 8.525 ms
+latency stddev: 2.051 ms
+failed: 0
+pods/hippo-pgbench-x7k2q deleted`)
+
+	options := benchOptions{}
+	cmd.Flags().IntVar(&options.Clients, "clients", 8, "connections pgbench uses")
+	cmd.Flags().IntVar(&options.Jobs, "jobs", 0, "pgbench threads; defaults to --clients")
+	cmd.Flags().DurationVar(&options.Duration, "duration", time.Minute, "how long to run pgbench")
+	cmd.Flags().DurationVar(&options.Progress, "progress", 10*time.Second, "how often to print throughput and latency")
+	cmd.Flags().StringVar(&options.Via, "via", "primary", "how to connect. types supported: primary,pgbouncer")
+	cmd.Flags().StringVar(&options.User, "user", "", "user to connect as; defaults to the name of the cluster")
+	cmd.Flags().StringVar(&options.Database, "dbname", "", "database with the pgbench tables; defaults to that of --user")
+	cmd.Flags().StringVar(&options.Image, "image", "", "image with pgbench; defaults to the Postgres image of the cluster")
+	cmd.Flags().StringVar(&options.Report, "report", "", "path of a file to write the results to as JSON")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		if options.User == "" {
+			options.User = args[0]
+		}
+		if options.Jobs == 0 {
+			options.Jobs = options.Clients
+		}
+		if err := options.Validate(); err != nil {
+			return err
+		}
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		_, clusterClient, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		cluster, err := clusterClient.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if _, found, _ := unstructured.NestedMap(cluster.Object,
+			"spec", "proxy", "pgBouncer"); options.Via == "pgbouncer" && !found {
+			return fmt.Errorf("postgresclusters/%s does not have pgBouncer enabled", args[0])
+		}
+
+		secrets, err := client.Secrets(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.LabelCluster + "=" + args[0] +
+				",postgres-operator.crunchydata.com/pguser=" + options.User,
+		})
+		if err != nil {
+			return err
+		}
+		if len(secrets.Items) == 0 {
+			return fmt.Errorf("user %q has no Secret; add it to spec.users of postgrescluster/%s first",
+				options.User, args[0])
+		}
+		secret := secrets.Items[0]
+		if options.Database == "" {
+			options.Database = string(secret.Data["dbname"])
+		}
+		if options.Database == "" {
+			return fmt.Errorf("user %q has no database; use --dbname", options.User)
+		}
+
+		pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.PrimaryInstanceLabels(args[0]),
+		})
+		if err != nil {
+			return err
+		}
+		var primary *corev1.Pod
+		for i := range pods.Items {
+			if podIsReady(&pods.Items[i]) {
+				primary = &pods.Items[i]
+				break
+			}
+		}
+		if primary == nil {
+			return errors.New("no ready primary instance Pod found")
+		}
+
+		// Fail now rather than after the Pod starts.
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+		exec := podexec.Container(podExec, namespace, primary.GetName(), util.ContainerDatabase)
+		stdout, stderr, err := podexec.PSQL(exec, options.Database,
+			`SELECT to_regclass('pgbench_accounts') IS NOT NULL;`)
+		if err != nil {
+			return commandError(err, stderr)
+		}
+		if strings.TrimSpace(stdout) != "t" {
+			return fmt.Errorf("database %q has no pgbench tables; create them with "+
+				"\"pgo seed %s --dbname=%s --dataset=pgbench\"", options.Database, args[0], options.Database)
+		}
+
+		report := benchReport{
+			Cluster:  args[0],
+			Via:      options.Via,
+			Database: options.Database,
+			Clients:  options.Clients,
+			Jobs:     options.Jobs,
+			Duration: options.Duration.String(),
+			Primary:  benchPrimaryOf(ctx, client, primary),
+		}
+		if options.Image == "" {
+			options.Image = report.Primary.Image
+		}
+		report.Image = options.Image
+
+		pod, err := client.Pods(namespace).Create(ctx,
+			options.pod(args[0], secret.GetName()), metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := client.Pods(namespace).Delete(context.Background(),
+				pod.GetName(), metav1.DeleteOptions{}); err != nil {
+				cmd.PrintErrf("WARNING: unable to delete pods/%s: %v\n", pod.GetName(), err)
+			} else {
+				cmd.Printf("pods/%s deleted\n", pod.GetName())
+			}
+		}()
+		host := args[0] + "-primary." + namespace + ".svc"
+		if options.Via == "pgbouncer" {
+			host = args[0] + "-pgbouncer." + namespace + ".svc"
+		}
+		cmd.Printf("pods/%s created; connecting to %s\n", pod.GetName(), host)
+
+		// Wait for the image to be pulled and pgbench to start.
+		for deadline := time.Now().Add(5 * time.Minute); ; time.Sleep(2 * time.Second) {
+			pod, err = client.Pods(namespace).Get(ctx, pod.GetName(), metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			if pod.Status.Phase != corev1.PodPending {
+				break
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("pods/%s did not start in 5m0s", pod.GetName())
+			}
+		}
+
+		report.Started = time.Now().UTC()
+		logs, err := client.Pods(namespace).GetLogs(pod.GetName(),
+			&corev1.PodLogOptions{Follow: true}).Stream(ctx)
+		if err != nil {
+			return err
+		}
+		output, samples := streamBenchOutput(cmd.OutOrStdout(), logs)
+		_ = logs.Close()
+		report.Samples = samples
+		report.Summary = parsePGBenchSummary(output)
+
+		// The log ends when pgbench does, but the phase can lag behind.
+		for i := 0; i < 30 && pod.Status.Phase == corev1.PodRunning; i++ {
+			time.Sleep(time.Second)
+			if pod, err = client.Pods(namespace).Get(ctx, pod.GetName(), metav1.GetOptions{}); err != nil {
+				return err
+			}
+		}
+		if pod.Status.Phase != corev1.PodSucceeded || report.Summary.Transactions == 0 {
+			report.Error = strings.TrimSpace(lastLines(output, 5))
+		}
+
+		printBenchSummary(cmd, report.Summary)
+
+		if options.Report != "" {
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(options.Report, append(data, '\n'), 0o600); err != nil {
+				return err
+			}
+		}
+
+		if report.Error != "" {
+			return fmt.Errorf("pgbench failed: %s", report.Error)
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// benchOptions are the flags of the bench command.
+type benchOptions struct {
+	Clients, Jobs      int
+	Duration, Progress time.Duration
+	Via                string
+	User, Database     string
+	Image              string
+	Report             string
+}
+
+// Validate returns an error when the options are not a complete request.
+func (options benchOptions) Validate() error {
+	switch {
+	case options.Clients < 1:
+		return errors.New("--clients must be at least 1")
+	case options.Jobs < 1 || options.Jobs > options.Clients:
+		return errors.New("--jobs must be between 1 and --clients")
+	case options.Duration < time.Second:
+		return errors.New("--duration must be at least 1s")
+	case options.Progress < time.Second:
+		return errors.New("--progress must be at least 1s")
+	case options.Via != "primary" && options.Via != "pgbouncer":
+		return fmt.Errorf("unknown --via %q; types supported: primary,pgbouncer", options.Via)
+	}
+	return nil
+}
+
+// pod returns a Pod that runs pgbench once against the cluster named
+// clusterName with the credentials in the Secret named secretName.
+func (options benchOptions) pod(clusterName, secretName string) *corev1.Pod {
+	hostKey, portKey := "host", "port"
+	if options.Via == "pgbouncer" {
+		hostKey, portKey = "pgbouncer-host", "pgbouncer-port"
+	}
+	fromSecret := func(name, key string) corev1.EnvVar {
+		return corev1.EnvVar{Name: name, ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			},
+		}}
+	}
+
+	// The Pod removes itself from the API well after pgbench would finish,
+	// in case this command is interrupted before it deletes the Pod.
+	deadline := int64((options.Duration + 10*time.Minute).Seconds())
+	nonRoot := true
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: clusterName + "-pgbench-",
+			Labels:       map[string]string{util.LabelCluster: clusterName},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy:         corev1.RestartPolicyNever,
+			ActiveDeadlineSeconds: &deadline,
+			SecurityContext:       &corev1.PodSecurityContext{RunAsNonRoot: &nonRoot},
+			Containers: []corev1.Container{{
+				Name:    "pgbench",
+				Image:   options.Image,
+				Command: options.command(),
+				Env: []corev1.EnvVar{
+					fromSecret("PGHOST", hostKey),
+					fromSecret("PGPORT", portKey),
+					fromSecret("PGUSER", "user"),
+					fromSecret("PGPASSWORD", "password"),
+					{Name: "PGDATABASE", Value: options.Database},
+					{Name: "PGSSLMODE", Value: "require"},
+				},
+			}},
+		},
+	}
+}
+
+// command returns the pgbench command of options.
+func (options benchOptions) command() []string {
+	return []string{"pgbench", "--no-vacuum",
+		"--progress=" + strconv.Itoa(int(options.Progress.Seconds())),
+		"--time=" + strconv.Itoa(int(options.Duration.Seconds())),
+		"--client=" + strconv.Itoa(options.Clients),
+		"--jobs=" + strconv.Itoa(options.Jobs)}
+}
+
+// benchReport is the outcome of a benchmark.
+type benchReport struct {
+	Cluster  string    `json:"cluster"`
+	Via      string    `json:"via"`
+	Database string    `json:"database"`
+	Image    string    `json:"image"`
+	Clients  int       `json:"clients"`
+	Jobs     int       `json:"jobs"`
+	Duration string    `json:"duration"`
+	Started  time.Time `json:"started"`
+
+	Primary benchPrimary  `json:"primary"`
+	Summary benchSummary  `json:"summary"`
+	Samples []benchSample `json:"samples"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// benchPrimary is what the primary ran on during a benchmark.
+type benchPrimary struct {
+	Pod          string                      `json:"pod"`
+	Node         string                      `json:"node"`
+	Image        string                      `json:"-"`
+	Resources    corev1.ResourceRequirements `json:"resources"`
+	StorageClass string                      `json:"storageClass,omitempty"`
+}
+
+// benchPrimaryOf describes pod. The storage class is left out when the data
+// volume cannot be read.
+func benchPrimaryOf(ctx context.Context, client v1.CoreV1Interface, pod *corev1.Pod) benchPrimary {
+	primary := benchPrimary{Pod: pod.GetName(), Node: pod.Spec.NodeName}
+	for _, container := range pod.Spec.Containers {
+		if container.Name == util.ContainerDatabase {
+			primary.Image, primary.Resources = container.Image, container.Resources
+		}
+	}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Name == "postgres-data" && volume.PersistentVolumeClaim != nil {
+			pvc, err := client.PersistentVolumeClaims(pod.GetNamespace()).Get(ctx,
+				volume.PersistentVolumeClaim.ClaimName, metav1.GetOptions{})
+			if err == nil && pvc.Spec.StorageClassName != nil {
+				primary.StorageClass = *pvc.Spec.StorageClassName
+			}
+		}
+	}
+	return primary
+}
+
+// benchSample is one progress report of pgbench.
+type benchSample struct {
+	Seconds   float64 `json:"seconds"`
+	TPS       float64 `json:"tps"`
+	LatencyMS float64 `json:"latencyMs"`
+	StddevMS  float64 `json:"stddevMs"`
+	Failed    int64   `json:"failed"`
+}
+
+// pgbenchProgress matches a progress line of pgbench, such as
+// "progress: 10.0 s, 1843.2 tps, lat 8.672 ms stddev 2.104, 0 failed".
+// Versions before Postgres 15 do not report failures.
+var pgbenchProgress = regexp.MustCompile(
+	`^progress: ([\d.]+) s, ([\d.]+) tps, lat ([\d.]+) ms stddev ([\d.]+|NaN)(?:, (\d+) failed)?`)
+
+// parsePGBenchProgress parses a progress line of pgbench.
+func parsePGBenchProgress(line string) (benchSample, bool) {
+	match := pgbenchProgress.FindStringSubmatch(strings.TrimSpace(line))
+	if match == nil {
+		return benchSample{}, false
+	}
+	var sample benchSample
+	sample.Seconds, _ = strconv.ParseFloat(match[1], 64)
+	sample.TPS, _ = strconv.ParseFloat(match[2], 64)
+	sample.LatencyMS, _ = strconv.ParseFloat(match[3], 64)
+	if match[4] != "NaN" {
+		sample.StddevMS, _ = strconv.ParseFloat(match[4], 64)
+	}
+	sample.Failed, _ = strconv.ParseInt(match[5], 10, 64)
+	return sample, true
+}
+
+// formatBenchSample returns one line of live progress.
+func formatBenchSample(sample benchSample) string {
+	return fmt.Sprintf("[%s] %.1f tps, latency %.3f ms (stddev %.3f), %d failed",
+		time.Duration(sample.Seconds*float64(time.Second)).Round(time.Second),
+		sample.TPS, sample.LatencyMS, sample.StddevMS, sample.Failed)
+}
+
+// streamBenchOutput prints the progress lines of pgbench in logs to out as
+// they arrive. It returns the other lines and the progress.
+func streamBenchOutput(out io.Writer, logs io.Reader) (string, []benchSample) {
+	var rest strings.Builder
+	var samples []benchSample
+
+	scanner := bufio.NewScanner(logs)
+	for scanner.Scan() {
+		if sample, ok := parsePGBenchProgress(scanner.Text()); ok {
+			samples = append(samples, sample)
+			_, _ = fmt.Fprintln(out, formatBenchSample(sample))
+			continue
+		}
+		rest.WriteString(scanner.Text())
+		rest.WriteString("\n")
+	}
+	return rest.String(), samples
+}
+
+// benchSummary is the result pgbench prints when it finishes.
+type benchSummary struct {
+	Transactions int64   `json:"transactions"`
+	Failed       int64   `json:"failed"`
+	TPS          float64 `json:"tps"`
+	LatencyMS    float64 `json:"latencyMs"`
+	StddevMS     float64 `json:"stddevMs"`
+	ConnectMS    float64 `json:"connectMs"`
+}
+
+// parsePGBenchSummary reads the summary that pgbench prints at the end of output.
+func parsePGBenchSummary(output string) benchSummary {
+	var summary benchSummary
+	number := func(line, prefix string) (float64, bool) {
+		value, found := strings.CutPrefix(line, prefix)
+		if !found {
+			return 0, false
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			return 0, false
+		}
+		n, err := strconv.ParseFloat(fields[0], 64)
+		return n, err == nil
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if n, ok := number(line, "number of transactions actually processed:"); ok {
+			summary.Transactions = int64(n)
+		} else if n, ok := number(line, "number of failed transactions:"); ok {
+			summary.Failed = int64(n)
+		} else if n, ok := number(line, "latency average ="); ok {
+			summary.LatencyMS = n
+		} else if n, ok := number(line, "latency stddev ="); ok {
+			summary.StddevMS = n
+		} else if n, ok := number(line, "initial connection time ="); ok {
+			summary.ConnectMS = n
+		} else if n, ok := number(line, "tps ="); ok {
+			summary.TPS = n
+		}
+	}
+	return summary
+}
+
+// printBenchSummary prints summary one field per line.
+func printBenchSummary(cmd *cobra.Command, summary benchSummary) {
+	cmd.Printf("transactions: %d\n", summary.Transactions)
+	cmd.Printf("tps: %.2f\n", summary.TPS)
+	cmd.Printf("latency average: %.3f ms\n", summary.LatencyMS)
+	cmd.Printf("latency stddev: %.3f ms\n", summary.StddevMS)
+	cmd.Printf("failed: %d\n", summary.Failed)
+}
+
+// lastLines returns at most n lines from the end of s.
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
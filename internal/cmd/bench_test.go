@@ -0,0 +1,166 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+
+	"github.com/crunchydata/postgres-operator-client/internal/testing/cmp"
+)
+
+func TestBenchOptionsValidate(t *testing.T) {
+	valid := benchOptions{
+		Clients: 16, Jobs: 4, Duration: 5 * time.Minute, Progress: 10 * time.Second, Via: "primary",
+	}
+	assert.NilError(t, valid.Validate())
+
+	for _, tt := range []struct {
+		Change func(*benchOptions)
+		Error  string
+	}{
+		{func(o *benchOptions) { o.Clients = 0 }, "--clients must be at least 1"},
+		{func(o *benchOptions) { o.Jobs = 32 }, "--jobs must be between 1 and --clients"},
+		{func(o *benchOptions) { o.Duration = 0 }, "--duration must be at least 1s"},
+		{func(o *benchOptions) { o.Progress = time.Millisecond }, "--progress must be at least 1s"},
+		{func(o *benchOptions) { o.Via = "replicas" }, `unknown --via "replicas"`},
+	} {
+		options := valid
+		tt.Change(&options)
+		assert.ErrorContains(t, options.Validate(), tt.Error)
+	}
+}
+
+func TestBenchPod(t *testing.T) {
+	options := benchOptions{
+		Clients: 16, Jobs: 4, Duration: 5 * time.Minute, Progress: 10 * time.Second,
+		Via: "pgbouncer", Database: "hippo", Image: "postgres:16",
+	}
+
+	pod := options.pod("hippo", "hippo-pguser-hippo")
+	assert.Assert(t, cmp.MarshalMatches(pod, `
+metadata:
+  creationTimestamp: null
+  generateName: hippo-pgbench-
+  labels:
+    postgres-operator.crunchydata.com/cluster: hippo
+spec:
+  activeDeadlineSeconds: 900
+  containers:
+  - command:
+    - pgbench
+    - --no-vacuum
+    - --progress=10
+    - --time=300
+    - --client=16
+    - --jobs=4
+    env:
+    - name: PGHOST
+      valueFrom:
+        secretKeyRef:
+          key: pgbouncer-host
+          name: hippo-pguser-hippo
+    - name: PGPORT
+      valueFrom:
+        secretKeyRef:
+          key: pgbouncer-port
+          name: hippo-pguser-hippo
+    - name: PGUSER
+      valueFrom:
+        secretKeyRef:
+          key: user
+          name: hippo-pguser-hippo
+    - name: PGPASSWORD
+      valueFrom:
+        secretKeyRef:
+          key: password
+          name: hippo-pguser-hippo
+    - name: PGDATABASE
+      value: hippo
+    - name: PGSSLMODE
+      value: require
+    image: postgres:16
+    name: pgbench
+    resources: {}
+  restartPolicy: Never
+  securityContext:
+    runAsNonRoot: true
+status: {}
+	`))
+
+	options.Via = "primary"
+	pod = options.pod("hippo", "hippo-pguser-hippo")
+	assert.Equal(t, pod.Spec.Containers[0].Env[0].ValueFrom.SecretKeyRef.Key, "host")
+	assert.Equal(t, pod.Spec.Containers[0].Env[1].ValueFrom.SecretKeyRef.Key, "port")
+}
+
+func TestParsePGBenchProgress(t *testing.T) {
+	sample, ok := parsePGBenchProgress("progress: 10.0 s, 1843.2 tps, lat 8.672 ms stddev 2.104, 3 failed")
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, sample, benchSample{
+		Seconds: 10, TPS: 1843.2, LatencyMS: 8.672, StddevMS: 2.104, Failed: 3,
+	})
+
+	// Postgres 14 and older do not count failures.
+	sample, ok = parsePGBenchProgress("progress: 5.0 s, 0.0 tps, lat 0.000 ms stddev NaN")
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, sample, benchSample{Seconds: 5})
+
+	_, ok = parsePGBenchProgress("starting vacuum...end.")
+	assert.Assert(t, !ok)
+}
+
+func TestBenchOutput(t *testing.T) {
+	logs := strings.NewReader(`pgbench (16.4)
+progress: 10.0 s, 1843.2 tps, lat 8.672 ms stddev 2.104, 0 failed
+progress: 20.0 s, 1912.7 tps, lat 8.361 ms stddev 1.977, 0 failed
+transaction type: <builtin: TPC-B (sort of)>
+scaling factor: 10
+query mode: simple
+number of clients: 16
+number of threads: 16
+maximum number of tries: 1
+duration: 300 s
+number of transactions actually processed: 562811
+number of failed transactions: 0 (0.000%)
+latency average = 8.525 ms
+latency stddev = 2.051 ms
+initial connection time = 41.870 ms
+tps = 1876.043174 (without initial connection time)
+`)
+
+	var out bytes.Buffer
+	output, samples := streamBenchOutput(&out, logs)
+	assert.Equal(t, len(samples), 2)
+	assert.Equal(t, out.String(), ""+
+		"[10s] 1843.2 tps, latency 8.672 ms (stddev 2.104), 0 failed\n"+
+		"[20s] 1912.7 tps, latency 8.361 ms (stddev 1.977), 0 failed\n")
+	assert.Assert(t, !strings.Contains(output, "progress:"))
+
+	summary := parsePGBenchSummary(output)
+	assert.DeepEqual(t, summary, benchSummary{
+		Transactions: 562811, TPS: 1876.043174, LatencyMS: 8.525, StddevMS: 2.051, ConnectMS: 41.87,
+	})
+
+	out.Reset()
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+	printBenchSummary(cmd, summary)
+	assert.Equal(t, out.String(), `transactions: 562811
+tps: 1876.04
+latency average: 8.525 ms
+latency stddev: 2.051 ms
+failed: 0
+`)
+
+	assert.Equal(t, lastLines(output, 2), ""+
+		"initial connection time = 41.870 ms\n"+
+		"tps = 1876.043174 (without initial connection time)")
+}
@@ -0,0 +1,25 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// newBrowseCommand returns the browse subcommand of the PGO plugin.
+// Subcommands of browse look inside the storage of a PostgresCluster.
+func newBrowseCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "browse",
+		Short: "Look inside the storage of a PostgresCluster",
+		Long:  "Look inside the storage of a PostgresCluster",
+	}
+
+	cmd.AddCommand(newBrowseRepoCommand(config))
+
+	return cmd
+}
@@ -0,0 +1,164 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newBrowseRepoCommand returns the repo subcommand of the browse command. It
+// lists and reads the files of a pgBackRest repository.
+func newBrowseRepoCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "repo CLUSTER_NAME",
+		Short: "List or read the files of a pgBackRest repository",
+		Long: `List the files of a pgBackRest repository of a PostgresCluster, or print
+one of them, to confirm what exists in S3, GCS, Azure, or a volume without the
+credentials of the bucket.
+
+Paths are relative to the repository, e.g. "backup/db" or "archive/db". The
+commands "pgbackrest repo-ls" and "pgbackrest repo-get" run in the database
+container of the primary, which reaches every repository as backups do. Files
+of encrypted repositories are decrypted.
+
+### RBAC Requirements
+    Resources  Verbs
+    ---------  -----
+    pods       [list]
+    pods/exec  [create]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# List the backups of the 'hippo' postgrescluster in repo2
+pgo browse repo hippo --repoName=repo2 --path=backup/db
+
+# Print the backup.info file of repo2
+pgo browse repo hippo --repoName=repo2 --get=backup/db/backup.info
+
+### Example output
+TYPE  SIZE    MODIFIED              NAME
+path  -       -                     20240501-060000F
+path  -       -                     20240502-060000F_20240502-180000D
+path  -       -                     backup.history
+file  2.1KiB  2024-05-02T18:00:41Z  backup.info
+file  2.1KiB  2024-05-02T18:00:41Z  backup.info.copy
+link  -       -                     latest -> 20240502-060000F_20240502-180000D`)
+
+	var repoName, path, get string
+	var recurse bool
+	var outputEnum = util.TextPGBackRest
+	cmd.Flags().StringVar(&repoName, "repoName", "", "repository to browse, e.g. repo2")
+	cobra.CheckErr(cmd.MarkFlagRequired("repoName"))
+	cmd.Flags().StringVar(&path, "path", "", "path in the repository to list")
+	cmd.Flags().BoolVar(&recurse, "recurse", false, "list the paths under --path too")
+	cmd.Flags().StringVar(&get, "get", "", "path of a file in the repository to print rather than list")
+	cmd.Flags().VarP(&outputEnum, "output", "o", "output format of the list. types supported: text,json")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if !repoNamePattern.MatchString(repoName) {
+			return fmt.Errorf("%q is not a valid repository name; use repo1, repo2, repo3, or repo4", repoName)
+		}
+		if get != "" && (path != "" || recurse) {
+			return errors.New("--get cannot be used with --path or --recurse")
+		}
+
+		exec, err := getPrimaryExec(config, args)
+		if err != nil {
+			return err
+		}
+
+		command := repoBrowseCommand(strings.TrimPrefix(repoName, "repo"), path, recurse, get)
+		stdout, stderr, err := podexec.Output(exec, nil, command...)
+		if err != nil {
+			return commandError(err, stderr)
+		}
+
+		if get != "" || outputEnum == util.JSONPGBackRest {
+			cmd.Print(stdout)
+			return nil
+		}
+		return printRepoList(cmd.OutOrStdout(), stdout)
+	}
+
+	return cmd
+}
+
+// repoBrowseCommand returns the pgBackRest command that lists path, or prints
+// the file get, in the repository numbered repoNum.
+func repoBrowseCommand(repoNum, path string, recurse bool, get string) []string {
+	if get != "" {
+		return []string{"pgbackrest", "repo-get", "--repo=" + repoNum, get}
+	}
+	command := []string{"pgbackrest", "repo-ls", "--repo=" + repoNum, "--output=json"}
+	if recurse {
+		command = append(command, "--recurse")
+	}
+	if path != "" {
+		command = append(command, path)
+	}
+	return command
+}
+
+// repoEntry is one path, file, or link of "pgbackrest repo-ls --output=json".
+// - https://pgbackrest.org/command.html#command-repo-ls
+type repoEntry struct {
+	Type        string `json:"type"`
+	Size        *int64 `json:"size"`
+	Time        *int64 `json:"time"`
+	Destination string `json:"destination"`
+}
+
+// printRepoList writes the output of "pgbackrest repo-ls --output=json" as a
+// table sorted by name.
+func printRepoList(out io.Writer, stdout string) error {
+	var entries map[string]repoEntry
+	if err := json.Unmarshal([]byte(stdout), &entries); err != nil {
+		return fmt.Errorf("unable to read pgbackrest repo-ls: %w", err)
+	}
+	delete(entries, ".")
+	if len(entries) == 0 {
+		_, err := fmt.Fprintln(out, "no files found")
+		return err
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(out, 0, 2, 2, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tSIZE\tMODIFIED\tNAME")
+	for _, name := range names {
+		entry := entries[name]
+		size, modified := "-", "-"
+		if entry.Size != nil {
+			size = formatBytes(*entry.Size)
+		}
+		if entry.Time != nil {
+			modified = formatUnixTime(*entry.Time)
+		}
+		if entry.Destination != "" {
+			name += " -> " + entry.Destination
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", entry.Type, size, modified, name)
+	}
+	return w.Flush()
+}
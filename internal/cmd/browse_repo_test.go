@@ -0,0 +1,52 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestRepoBrowseCommand(t *testing.T) {
+	assert.DeepEqual(t, repoBrowseCommand("2", "", false, ""),
+		[]string{"pgbackrest", "repo-ls", "--repo=2", "--output=json"})
+	assert.DeepEqual(t, repoBrowseCommand("1", "backup/db", true, ""),
+		[]string{"pgbackrest", "repo-ls", "--repo=1", "--output=json", "--recurse", "backup/db"})
+	assert.DeepEqual(t, repoBrowseCommand("3", "", false, "backup/db/backup.info"),
+		[]string{"pgbackrest", "repo-get", "--repo=3", "backup/db/backup.info"})
+}
+
+func TestPrintRepoList(t *testing.T) {
+	t.Run("Entries", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NilError(t, printRepoList(&out, `{
+".": {"type": "path"},
+"backup.info": {"type": "file", "size": 2150, "time": 1714672841},
+"20240501-060000F": {"type": "path"},
+"latest": {"type": "link", "destination": "20240501-060000F"},
+"empty": {"type": "file", "size": 0, "time": 1714672841}
+}`))
+		assert.Equal(t, out.String(), `
+TYPE  SIZE    MODIFIED              NAME
+path  -       -                     20240501-060000F
+file  2.1KiB  2024-05-02T18:00:41Z  backup.info
+file  0B      2024-05-02T18:00:41Z  empty
+link  -       -                     latest -> 20240501-060000F
+`[1:])
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NilError(t, printRepoList(&out, `{".": {"type": "path"}}`))
+		assert.Equal(t, out.String(), "no files found\n")
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.ErrorContains(t, printRepoList(&out, "ERROR: [055]"), "unable to read pgbackrest repo-ls")
+	})
+}
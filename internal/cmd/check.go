@@ -0,0 +1,37 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// newCheckCommand returns the check subcommand of the PGO plugin.
+// Subcommands of check verify that a PostgresCluster works as configured.
+func newCheckCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Verify parts of a PostgresCluster",
+		Long:  "Verify parts of a PostgresCluster",
+	}
+
+	cmd.AddCommand(
+		newCheckArchivingCommand(config),
+		newCheckCertReloadCommand(config),
+		newCheckCorruptionCommand(config),
+		newCheckGuardrailsCommand(config),
+		newCheckImagesCommand(config),
+		newCheckReadyCommand(config),
+		newCheckRepoHostCommand(config),
+		newCheckRoutingCommand(config),
+		newCheckStandbyCommand(config),
+		newCheckTLSCommand(config),
+		newCheckUserPolicyCommand(config),
+	)
+
+	return cmd
+}
@@ -0,0 +1,486 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newCheckArchivingCommand returns the archiving subcommand of the check
+// command. It looks for WAL archiving that is falling behind.
+func newCheckArchivingCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "archiving CLUSTER_NAME",
+		Short: "Check that WAL archiving keeps up on a PostgresCluster",
+		Long: `Check WAL archiving on the primary of a PostgresCluster. A backlog of WAL that
+is not archived fills pg_wal and leaves a gap that point-in-time recovery
+cannot cross, often without any error that someone sees:
+  - archive: archiving is not failing and fewer than "--max-archive-pending"
+    WAL files wait to be archived
+  - async: the "archive-async" settings of pgBackRest
+  - spool: with archive-async, the spool directory holds no errors and is
+    smaller than "--max-spool-mb"
+  - push: at most "--max-push-failure-percent" of the recent runs of
+    "pgbackrest archive-push" in its log failed, and how long they took
+  - repo: each repository has WAL within "--max-archive-pending" files of the
+    last WAL that Postgres archived, and the errors the log reports for it
+
+pgBackRest pushes each WAL file to every repository in one run, so the timing
+of pushes is across repositories. The recent runs are the last 2000 lines of
+the log.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    pods                                                [list]
+    pods/exec                                           [create]
+    postgresclusters.postgres-operator.crunchydata.com  [get]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Check WAL archiving of the 'hippo' postgrescluster
+pgo check archiving hippo
+
+### Example output
+CHECK    RESULT  DETAIL
+archive  ok      2 WAL files waiting; last archived 2024-05-01 15:11:48+00
+async    ok      archive-async on; spool-path /pgdata/pgbackrest-spool, process-max 2, archive-push-queue-max unset
+spool    ok      3 files, 0B; no errors
+push     ok      0 of 118 runs failed (0.0%); took 240ms median, 1.9s max
+repo     ok      repo1: WAL archived to 000000010000000000000083, 0 files behind; 0 errors
+repo     FAILED  repo2: WAL archived to 000000010000000000000071, 18 files behind; 6 errors, last: [HostConnectError] unable to connect
+Error: 1 of 6 checks failed`)
+
+	var (
+		maxArchivePending int
+		maxSpool          int64
+		maxFailures       float64
+		outputEnum        = util.TextReadiness
+	)
+	cmd.Flags().IntVar(&maxArchivePending, "max-archive-pending", 10,
+		"WAL files waiting to be archived, or missing from a repository, at which the check fails")
+	cmd.Flags().Int64Var(&maxSpool, "max-spool-mb", 256,
+		"megabytes in the spool directory above which the check fails")
+	cmd.Flags().Float64Var(&maxFailures, "max-push-failure-percent", 5,
+		"percent of recent archive-push runs that can fail")
+	cmd.Flags().VarP(&outputEnum, "output", "o", "output format. types supported: text,json,prom")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		now := time.Now()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		_, clusterClient, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+
+		cluster, err := clusterClient.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.PrimaryInstanceLabels(args[0]),
+		})
+		if err != nil {
+			return err
+		}
+		var primary *corev1.Pod
+		for i := range pods.Items {
+			if podIsReady(&pods.Items[i]) {
+				primary = &pods.Items[i]
+			}
+		}
+
+		var checks []readinessCheck
+		if primary == nil {
+			checks = append(checks, readinessCheck{Name: "primary",
+				Detail: "no ready primary instance Pod found"})
+		} else {
+			exec := podexec.Container(podExec, namespace, primary.GetName(), util.ContainerDatabase)
+			checks = runArchivingChecks(exec, clusterRepoNames(cluster), archivingLimits{
+				Pending: maxArchivePending, SpoolMB: maxSpool, FailurePercent: maxFailures,
+			})
+		}
+
+		switch outputEnum {
+		case util.JSONReadiness:
+			b, err := json.MarshalIndent(checks, "", "  ")
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(b))
+		case util.PromReadiness:
+			if err := writePromGauges(cmd.OutOrStdout(),
+				[]string{"cluster", args[0], "namespace", namespace},
+				archivingGauges(checks), checkTimestampGauge("archiving", now)); err != nil {
+				return err
+			}
+		default:
+			if err := printReadinessChecks(cmd, checks); err != nil {
+				return err
+			}
+		}
+
+		var failed int
+		for _, check := range checks {
+			if !check.Healthy {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// archivingLimits are the thresholds of "check archiving".
+type archivingLimits struct {
+	Pending        int
+	SpoolMB        int64
+	FailurePercent float64
+}
+
+// runArchivingChecks runs the checks of a primary using exec.
+func runArchivingChecks(exec podexec.Executor, repos []string, limits archivingLimits) []readinessCheck {
+	stdout, stderr, err := podexec.PSQL(exec, "", archiveStatusSQL)
+	checks := []readinessCheck{checkArchiving(stdout, commandError(err, stderr), limits.Pending)}
+
+	stdout, stderr, err = podexec.Bash(exec, archiveOptionsScript)
+	if err = commandError(err, stderr); err != nil {
+		return append(checks, readinessCheck{Name: "async",
+			Detail: "unable to read the pgBackRest config: " + err.Error()})
+	}
+	options := parseArchiveOptions(stdout)
+	checks = append(checks, checkArchiveAsync(options))
+
+	stdout, stderr, err = podexec.Bash(exec, archiveSpoolScript(options))
+	if err = commandError(err, stderr); err != nil {
+		return append(checks, readinessCheck{Name: "spool", Detail: err.Error()})
+	}
+	spool, log := parseArchiveSpool(stdout)
+	if options.async() {
+		checks = append(checks, checkArchiveSpool(spool, limits.SpoolMB))
+	}
+	checks = append(checks, checkArchivePush(log, limits.FailurePercent))
+
+	last, stderr, err := podexec.PSQL(exec, "", archivedWALSQL)
+	lastErr := commandError(err, stderr)
+	stdout, stderr, err = podexec.PGBackRestInfo(exec, "")
+	return append(checks, checkArchiveRepos(repos, last, lastErr,
+		stdout, commandError(err, stderr), log, limits.Pending)...)
+}
+
+// archiveOptions are the settings of pgBackRest that shape archive-push.
+type archiveOptions map[string]string
+
+// async returns whether WAL is pushed by a background process.
+func (options archiveOptions) async() bool {
+	value := strings.ToLower(options["archive-async"])
+	return value == "y" || value == "yes" || value == "true"
+}
+
+// archiveOptionsScript prints the archive-push options in the pgBackRest config
+// files of PGO as "name=value" lines. Later files and sections win.
+const archiveOptionsScript = `cat /etc/pgbackrest/pgbackrest.conf /etc/pgbackrest/conf.d/*.conf 2>/dev/null |
+  sed -n -E 's/^[[:space:]]*(archive-async|archive-push-queue-max|log-path|process-max|spool-path)[[:space:]]*=[[:space:]]*(.*[^[:space:]])[[:space:]]*$/\1=\2/p'`
+
+// parseArchiveOptions reads the output of [archiveOptionsScript].
+func parseArchiveOptions(stdout string) archiveOptions {
+	options := archiveOptions{}
+	for _, line := range strings.Split(stdout, "\n") {
+		if name, value, ok := strings.Cut(line, "="); ok {
+			options[name] = value
+		}
+	}
+	return options
+}
+
+// checkArchiveAsync describes the archive-async settings.
+func checkArchiveAsync(options archiveOptions) readinessCheck {
+	check := readinessCheck{Name: "async", Healthy: true}
+	if !options.async() {
+		check.Detail = "archive-async off; Postgres waits while each WAL file is pushed to every repository"
+		return check
+	}
+	value := func(name, fallback string) string {
+		if options[name] != "" {
+			return options[name]
+		}
+		return fallback
+	}
+	check.Detail = fmt.Sprintf("archive-async on; spool-path %s, process-max %s, archive-push-queue-max %s",
+		value("spool-path", "/var/spool/pgbackrest"), value("process-max", "1"),
+		value("archive-push-queue-max", "unset"))
+	return check
+}
+
+// archiveSpoolScript prints the size of the outbound spool directory and its
+// number of files and error files, then the end of the archive-push log.
+func archiveSpoolScript(options archiveOptions) string {
+	spool, logs := options["spool-path"], options["log-path"]
+	if spool == "" {
+		spool = "/var/spool/pgbackrest"
+	}
+	if logs == "" {
+		logs = "/var/log/pgbackrest"
+	}
+	file := "db-archive-push.log"
+	if options.async() {
+		file = "db-archive-push-async.log"
+	}
+	return fmt.Sprintf(`out=%s/archive/db/out
+if [ -d "${out}" ]; then
+  printf 'spool\t%%s\t%%s\t%%s\n' "$(du -sb "${out}" | cut -f1)" \
+    "$(find "${out}" -type f | wc -l)" "$(find "${out}" -type f -name '*.error' | wc -l)"
+fi
+tail -n 2000 %s/%s 2>/dev/null || true`, shellQuote(spool), shellQuote(logs), file)
+}
+
+// archiveSpool is what waits in the outbound spool directory.
+type archiveSpool struct {
+	Bytes, Files, Errors int64
+}
+
+// parseArchiveSpool reads the output of [archiveSpoolScript].
+func parseArchiveSpool(stdout string) (archiveSpool, archivePushLog) {
+	var spool archiveSpool
+	var lines []string
+	for _, line := range strings.Split(stdout, "\n") {
+		if fields := strings.Split(line, "\t"); len(fields) == 4 && fields[0] == "spool" {
+			spool.Bytes, _ = strconv.ParseInt(fields[1], 10, 64)
+			spool.Files, _ = strconv.ParseInt(fields[2], 10, 64)
+			spool.Errors, _ = strconv.ParseInt(fields[3], 10, 64)
+		} else {
+			lines = append(lines, line)
+		}
+	}
+	return spool, parseArchivePushLog(lines)
+}
+
+// checkArchiveSpool fails when the spool directory has errors or is too big.
+func checkArchiveSpool(spool archiveSpool, maxMB int64) readinessCheck {
+	check := readinessCheck{Name: "spool"}
+	check.measured(float64(spool.Bytes) / (1 << 20))
+	detail := fmt.Sprintf("%d files, %s", spool.Files, formatBytes(spool.Bytes))
+	switch {
+	case spool.Errors > 0:
+		check.Detail = fmt.Sprintf("%s; %d WAL files failed to push", detail, spool.Errors)
+	case spool.Bytes > maxMB<<20:
+		check.Detail = fmt.Sprintf("%s, more than %dMiB", detail, maxMB)
+	default:
+		check.Healthy = true
+		check.Detail = detail + "; no errors"
+	}
+	return check
+}
+
+// archivePushLog summarizes the runs of archive-push in its log.
+type archivePushLog struct {
+	Runs, Failed int
+	Durations    []time.Duration
+
+	// RepoErrors counts the errors and warnings about each repository, by
+	// name, and keeps the last one.
+	RepoErrors map[string]int
+	RepoLast   map[string]string
+}
+
+var (
+	// archivePushEndPattern matches the line that ends a run of pgBackRest,
+	// such as "command end: completed successfully (1034ms)".
+	archivePushEndPattern = regexp.MustCompile(`command end: (.*?)(?: \((\d+)ms\))?$`)
+
+	// archivePushRepoPattern matches a warning or an error about one repository,
+	// such as "WARN: repo2: [FileOpenError] raised from remote-0".
+	archivePushRepoPattern = regexp.MustCompile(`(?:WARN|ERROR): (?:\[\d+\]: )?(repo\d+): (.*)$`)
+)
+
+// parseArchivePushLog reads lines of the archive-push log of pgBackRest.
+func parseArchivePushLog(lines []string) archivePushLog {
+	log := archivePushLog{RepoErrors: map[string]int{}, RepoLast: map[string]string{}}
+	for _, line := range lines {
+		if match := archivePushEndPattern.FindStringSubmatch(line); match != nil {
+			log.Runs++
+			if !strings.HasPrefix(match[1], "completed successfully") {
+				log.Failed++
+			}
+			if ms, err := strconv.ParseInt(match[2], 10, 64); err == nil {
+				log.Durations = append(log.Durations, time.Duration(ms)*time.Millisecond)
+			}
+		}
+		if match := archivePushRepoPattern.FindStringSubmatch(line); match != nil {
+			log.RepoErrors[match[1]]++
+			log.RepoLast[match[1]] = match[2]
+		}
+	}
+	return log
+}
+
+// checkArchivePush fails when too many runs in log failed.
+func checkArchivePush(log archivePushLog, maxPercent float64) readinessCheck {
+	check := readinessCheck{Name: "push"}
+	if log.Runs == 0 {
+		check.Healthy = true
+		check.Detail = "no runs of archive-push in the log"
+		return check
+	}
+
+	percent := float64(log.Failed) * 100 / float64(log.Runs)
+	check.measured(percent)
+	check.Healthy = percent <= maxPercent
+	check.Detail = fmt.Sprintf("%d of %d runs failed (%.1f%%)", log.Failed, log.Runs, percent)
+	if !check.Healthy {
+		check.Detail += fmt.Sprintf(", more than %g%%", maxPercent)
+	}
+	if len(log.Durations) > 0 {
+		durations := append([]time.Duration(nil), log.Durations...)
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		check.Detail += fmt.Sprintf("; took %s median, %s max",
+			durations[len(durations)/2], durations[len(durations)-1])
+	}
+	return check
+}
+
+// archivedWALSQL prints the last WAL file that Postgres archived and the size
+// of WAL segments.
+const archivedWALSQL = `SELECT COALESCE(last_archived_wal, ''),
+  (SELECT setting FROM pg_catalog.pg_settings WHERE name = 'wal_segment_size')
+FROM pg_catalog.pg_stat_archiver;`
+
+// checkArchiveRepos compares the newest WAL in each of repos, from the output
+// of "pgbackrest info --output=json", to the output of [archivedWALSQL].
+func checkArchiveRepos(
+	repos []string, archived string, archivedErr error,
+	info string, infoErr error, log archivePushLog, maxBehind int,
+) []readinessCheck {
+	var last string
+	var segmentSize uint64
+	if rows := parseRows(archived); archivedErr == nil && len(rows) == 1 && len(rows[0]) == 2 {
+		last = rows[0][0]
+		segmentSize, _ = strconv.ParseUint(rows[0][1], 10, 64)
+	}
+	var stanzas []pgBackRestStanza
+	if infoErr == nil {
+		infoErr = json.Unmarshal([]byte(info), &stanzas)
+	}
+
+	checks := make([]readinessCheck, 0, len(repos))
+	for _, repo := range repos {
+		check := readinessCheck{Name: "repo", Subject: repo}
+		reported := fmt.Sprintf("%d errors", log.RepoErrors[repo])
+		if log.RepoErrors[repo] > 0 {
+			reported += ", last: " + log.RepoLast[repo]
+		}
+
+		var newest string
+		key, _ := strconv.Atoi(strings.TrimPrefix(repo, "repo"))
+		for _, stanza := range stanzas {
+			for _, archive := range stanza.Archive {
+				if archive.Database.RepoKey == key && archive.Max > newest {
+					newest = archive.Max
+				}
+			}
+		}
+
+		// A repository is only behind when both files are segments on the same
+		// timeline, such as after Postgres has archived anything at all.
+		behind, known := walFilesBetween(newest, last, segmentSize)
+		switch {
+		case infoErr != nil:
+			check.Detail = fmt.Sprintf("%s: %v", repo, infoErr)
+		case newest == "":
+			check.Detail = fmt.Sprintf("%s: no WAL archived; %s", repo, reported)
+		case !known:
+			check.Healthy = true
+			check.Detail = fmt.Sprintf("%s: WAL archived to %s; %s", repo, newest, reported)
+		default:
+			check.measured(float64(behind))
+			check.Healthy = behind <= maxBehind
+			check.Detail = fmt.Sprintf("%s: WAL archived to %s, %d files behind; %s",
+				repo, newest, behind, reported)
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// walFilesBetween returns how many WAL segments come after from up to and
+// including to. It is not known when either is not a segment on the same
+// timeline.
+func walFilesBetween(from, to string, segmentSize uint64) (int, bool) {
+	parse := func(name string) (string, uint64, bool) {
+		if len(name) != 24 || segmentSize == 0 {
+			return "", 0, false
+		}
+		high, err1 := strconv.ParseUint(name[8:16], 16, 32)
+		low, err2 := strconv.ParseUint(name[16:], 16, 32)
+		return name[:8], high*((uint64(1)<<32)/segmentSize) + low, err1 == nil && err2 == nil
+	}
+	fromTimeline, fromSegment, ok1 := parse(from)
+	toTimeline, toSegment, ok2 := parse(to)
+	if !ok1 || !ok2 || fromTimeline != toTimeline {
+		return 0, false
+	}
+	if toSegment < fromSegment {
+		return 0, true
+	}
+	return int(toSegment - fromSegment), true
+}
+
+// archivingGauges returns checks as gauges.
+func archivingGauges(checks []readinessCheck) *promGauge {
+	healthy := &promGauge{
+		Name: "pgo_check_archiving_healthy",
+		Help: "Whether a check of pgo check archiving passed (1) or failed (0).",
+	}
+	for _, check := range checks {
+		healthy.add(promBool(check.Healthy), "check", check.Name, "repo", check.Subject)
+	}
+	return healthy
+}
+
+// shellQuote returns word as one argument of a shell command.
+func shellQuote(word string) string {
+	return "'" + strings.ReplaceAll(word, "'", `'"'"'`) + "'"
+}
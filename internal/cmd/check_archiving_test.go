@@ -0,0 +1,113 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+)
+
+func TestParseArchiveOptions(t *testing.T) {
+	options := parseArchiveOptions("archive-async=y\nspool-path=/pgdata/pgbackrest-spool\nprocess-max=2\n")
+	assert.Assert(t, options.async())
+	assert.Equal(t, checkArchiveAsync(options).Detail,
+		"archive-async on; spool-path /pgdata/pgbackrest-spool, process-max 2, archive-push-queue-max unset")
+
+	options = parseArchiveOptions("archive-async=y\narchive-async=n\n")
+	assert.Assert(t, !options.async(), "later settings win")
+	assert.Assert(t, strings.HasPrefix(checkArchiveAsync(options).Detail, "archive-async off"))
+}
+
+func TestArchiveSpoolScript(t *testing.T) {
+	script := archiveSpoolScript(archiveOptions{
+		"archive-async": "y", "spool-path": "/pgdata/it's", "log-path": "/pgdata/pgbackrest/log",
+	})
+	assert.Assert(t, strings.Contains(script, `out='/pgdata/it'"'"'s'/archive/db/out`))
+	assert.Assert(t, strings.Contains(script, `'/pgdata/pgbackrest/log'/db-archive-push-async.log`))
+
+	script = archiveSpoolScript(archiveOptions{})
+	assert.Assert(t, strings.Contains(script, `'/var/log/pgbackrest'/db-archive-push.log`))
+}
+
+func TestParseArchiveSpool(t *testing.T) {
+	spool, log := parseArchiveSpool(`spool	4096	3	1
+2024-05-01 15:10:01.123 P00   INFO: archive-push:async command begin 2.49: [pg_wal]
+2024-05-01 15:10:01.400 P00   WARN: repo2: [FileOpenError] unable to open file
+2024-05-01 15:10:01.401 P00   INFO: archive-push:async command end: completed successfully (278ms)
+2024-05-01 15:11:01.100 P00  ERROR: [082]: repo2: WAL segment 000000010000000000000072 was not pushed due to error
+2024-05-01 15:11:01.120 P00   INFO: archive-push:async command end: aborted with exception [082] (1900ms)
+2024-05-01 15:12:01.401 P00   INFO: archive-push:async command end: completed successfully (240ms)
+`)
+	assert.DeepEqual(t, spool, archiveSpool{Bytes: 4096, Files: 3, Errors: 1})
+	assert.Equal(t, log.Runs, 3)
+	assert.Equal(t, log.Failed, 1)
+	assert.DeepEqual(t, log.Durations,
+		[]time.Duration{278 * time.Millisecond, 1900 * time.Millisecond, 240 * time.Millisecond})
+	assert.Equal(t, log.RepoErrors["repo2"], 2)
+	assert.Equal(t, log.RepoLast["repo2"], "WAL segment 000000010000000000000072 was not pushed due to error")
+
+	check := checkArchiveSpool(spool, 256)
+	assert.Assert(t, !check.Healthy)
+	assert.Equal(t, check.Detail, "3 files, 4.0KiB; 1 WAL files failed to push")
+
+	check = checkArchivePush(log, 50)
+	assert.Assert(t, check.Healthy)
+	assert.Equal(t, check.Detail, "1 of 3 runs failed (33.3%); took 278ms median, 1.9s max")
+
+	check = checkArchivePush(log, 5)
+	assert.Assert(t, !check.Healthy)
+	assert.Equal(t, check.Detail, "1 of 3 runs failed (33.3%), more than 5%; took 278ms median, 1.9s max")
+}
+
+func TestWALFilesBetween(t *testing.T) {
+	behind, known := walFilesBetween("000000010000000000000071", "000000010000000000000083", 16<<20)
+	assert.Assert(t, known)
+	assert.Equal(t, behind, 18)
+
+	behind, known = walFilesBetween("0000000100000000000000FF", "000000010000000100000001", 16<<20)
+	assert.Assert(t, known)
+	assert.Equal(t, behind, 2)
+
+	_, known = walFilesBetween("000000010000000000000071", "000000020000000000000072", 16<<20)
+	assert.Assert(t, !known, "different timelines")
+	_, known = walFilesBetween("000000010000000000000071", "00000002.history", 16<<20)
+	assert.Assert(t, !known)
+}
+
+func TestRunArchivingChecks(t *testing.T) {
+	fake := &podexec.Fake{Replies: []podexec.Reply{
+		{Match: "last_failed_time", Stdout: "f\t2\t2024-05-01 15:11:48+00\t\n"},
+		{Match: "conf.d", Stdout: "archive-async=y\nspool-path=/pgdata/pgbackrest-spool\n"},
+		{Match: "archive/db/out", Stdout: "spool\t0\t0\t0\n" +
+			"P00   INFO: archive-push:async command end: completed successfully (240ms)\n" +
+			"P00   WARN: repo2: [HostConnectError] unable to connect\n"},
+		{Match: "wal_segment_size", Stdout: "000000010000000000000083\t16777216\n"},
+		{Match: "pgbackrest info --output=json", Stdout: `[{"name":"db","archive":[
+			{"max":"000000010000000000000083","database":{"repo-key":1}},
+			{"max":"000000010000000000000071","database":{"repo-key":2}}]}]`},
+	}}
+
+	checks := runArchivingChecks(fake, []string{"repo1", "repo2"}, archivingLimits{
+		Pending: 10, SpoolMB: 256, FailurePercent: 5,
+	})
+
+	var names []string
+	for _, check := range checks {
+		names = append(names, check.Name+":"+check.Subject)
+	}
+	assert.DeepEqual(t, names, []string{"archive:", "async:", "spool:", "push:", "repo:repo1", "repo:repo2"})
+
+	for _, check := range checks[:5] {
+		assert.Assert(t, check.Healthy, "%+v", check)
+	}
+	assert.Assert(t, !checks[5].Healthy)
+	assert.Equal(t, checks[5].Detail, "repo2: WAL archived to 000000010000000000000071, 18 files behind; "+
+		"1 errors, last: [HostConnectError] unable to connect")
+}
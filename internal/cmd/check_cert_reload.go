@@ -0,0 +1,339 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// postgresCertScript prints the certificate file that Postgres is configured
+// to use. Relative paths are relative to the data directory.
+const postgresCertScript = `cd "$(psql --no-psqlrc -Atc 'SHOW data_directory')" && ` +
+	`cat -- "$(psql --no-psqlrc -Atc 'SHOW ssl_cert_file')"`
+
+// newCheckCertReloadCommand returns the cert-reload subcommand of the check
+// command. It compares the certificate each server presents with the one in
+// its files.
+func newCheckCertReloadCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cert-reload CLUSTER_NAME",
+		Short: "Check that every server of a PostgresCluster presents its current certificate",
+		Long: `Check that every instance and pgBouncer Pod of a PostgresCluster presents the
+certificate in its files rather than an older one it loaded before the
+certificate was rotated. Each server is reached through a port forward and its
+certificate is compared by serial number with the file named by ssl_cert_file
+of Postgres or client_tls_cert_file of pgBouncer.
+
+The report ends with the Pods that still present an old certificate. Postgres
+and pgBouncer load certificates again when they reload their configuration;
+restarting those Pods does that too.
+
+Use "--output=json" for results that scripts can read. Use "--output=prom" for
+gauges in the Prometheus text format, such as for the textfile collector of
+node_exporter.
+
+### RBAC Requirements
+    Resources             Verbs
+    ---------             -----
+    pods                  [list]
+    pods/exec             [create]
+    pods/portforward      [create]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Check that the servers of the 'hippo' postgrescluster use their new certificates
+pgo check cert-reload hippo
+
+### Example output
+POD                              SERVER     PRESENTED  FILE       RESULT
+hippo-instance1-8x7m-0           postgres   5d2f01a8   5d2f01a8   ok
+hippo-instance1-x2pq-0           postgres   31c9e7b4   5d2f01a8   STALE
+hippo-pgbouncer-6b4f9c7d5-x2kqp  pgbouncer  7a01ce93   7a01ce93   ok
+
+Restart these Pods to load the new certificate: hippo-instance1-x2pq-0
+Error: 1 of 3 servers present an old certificate`)
+
+	outputEnum := util.TextReadiness
+	cmd.Flags().VarP(&outputEnum, "output", "o", "output format. types supported: text,json,prom")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+		forward, err := util.NewPodPortForwarder(rest)
+		if err != nil {
+			return err
+		}
+
+		var results []certReloadResult
+		for _, server := range []struct {
+			Name, Selector, Container, Port string
+			File                            func(podexec.Executor) ([]byte, error)
+		}{
+			{"postgres", util.DBInstanceLabels(args[0]), util.ContainerDatabase, "postgres", postgresCertFile},
+			{"pgbouncer", util.PGBouncerLabels(args[0]), util.ContainerPGBouncer, "pgbouncer", pgbouncerCertFile},
+		} {
+			pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+				LabelSelector: server.Selector,
+			})
+			if err != nil {
+				return err
+			}
+			sort.Slice(pods.Items, func(i, j int) bool { return pods.Items[i].Name < pods.Items[j].Name })
+
+			for i := range pods.Items {
+				pod := &pods.Items[i]
+				result := certReloadResult{Pod: pod.Name, Server: server.Name}
+
+				exec := podexec.Container(podExec, namespace, pod.Name, server.Container)
+				file, err := server.File(exec)
+				if err == nil {
+					result.File, err = firstPEMCertificate(file)
+				}
+				if err == nil {
+					result.Presented, err = presentedCertificate(forward, pod, server.Container, server.Port)
+				}
+				result.Error = err
+				results = append(results, result)
+			}
+		}
+
+		if len(results) == 0 {
+			return fmt.Errorf("no instance or pgBouncer Pods found for postgresclusters/%s", args[0])
+		}
+
+		switch outputEnum {
+		case util.JSONReadiness:
+			b, err := json.MarshalIndent(certReloadReports(results), "", "  ")
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(b))
+		case util.PromReadiness:
+			if err := writePromGauges(cmd.OutOrStdout(),
+				[]string{"cluster", args[0], "namespace", namespace},
+				certReloadGauge(results), checkTimestampGauge("cert-reload", time.Now())); err != nil {
+				return err
+			}
+		default:
+			return printCertReloadResults(cmd, results)
+		}
+		return certReloadError(results)
+	}
+
+	return cmd
+}
+
+// postgresCertFile returns the certificate file of Postgres in exec.
+func postgresCertFile(exec podexec.Executor) ([]byte, error) {
+	stdout, stderr, err := podexec.Bash(exec, postgresCertScript)
+	return []byte(stdout), commandError(err, stderr)
+}
+
+// pgbouncerCertFile returns the certificate file of pgBouncer in exec, as
+// named in its configuration files.
+func pgbouncerCertFile(exec podexec.Executor) ([]byte, error) {
+	stdout, stderr, err := podexec.Bash(exec, "cat /etc/pgbouncer/*.ini")
+	if err != nil {
+		return nil, commandError(err, stderr)
+	}
+	path := parsePGBouncerINI(stdout)["client_tls_cert_file"]
+	if path == "" {
+		return nil, errors.New("client_tls_cert_file is not set")
+	}
+	stdout, stderr, err = podexec.Output(exec, nil, "cat", "--", path)
+	return []byte(stdout), commandError(err, stderr)
+}
+
+// presentedCertificate returns the certificate that the server in container
+// of pod presents on the port named port.
+func presentedCertificate(
+	forward func(namespace, pod string, port int32) (string, func(), error),
+	pod *corev1.Pod, container, port string,
+) (*x509.Certificate, error) {
+	address, stop, err := forward(pod.Namespace, pod.Name, containerPort(pod, container, port, 5432))
+	if err != nil {
+		return nil, err
+	}
+	defer stop()
+
+	// #nosec G402 -- Only the certificate is compared; nothing is sent.
+	state, err := postgresTLSHandshake(func() (net.Conn, error) {
+		return net.DialTimeout("tcp", address, 10*time.Second)
+	}, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		return nil, err
+	}
+	if len(state.PeerCertificates) == 0 {
+		return nil, errors.New("the server presented no certificate")
+	}
+	return state.PeerCertificates[0], nil
+}
+
+// firstPEMCertificate returns the first certificate in data, which is the
+// certificate of the server when data is a chain.
+func firstPEMCertificate(data []byte) (*x509.Certificate, error) {
+	for {
+		var block *pem.Block
+		if block, data = pem.Decode(data); block == nil {
+			return nil, errors.New("no certificate found in the file")
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
+
+// certReloadResult compares the certificate that one server presents with
+// the one in its files.
+type certReloadResult struct {
+	Pod, Server     string
+	Presented, File *x509.Certificate
+	Error           error
+}
+
+// Stale returns true when the server presents an old certificate.
+func (result certReloadResult) Stale() bool {
+	return result.Error == nil && !result.Presented.Equal(result.File)
+}
+
+// Outcome returns "ok", "STALE", or the error of result.
+func (result certReloadResult) Outcome() string {
+	switch {
+	case result.Error != nil:
+		return "ERROR: " + result.Error.Error()
+	case result.Stale():
+		return "STALE"
+	}
+	return "ok"
+}
+
+// certReloadSerial returns the serial number of certificate in hexadecimal.
+func certReloadSerial(certificate *x509.Certificate) string {
+	if certificate == nil {
+		return "-"
+	}
+	return certificate.SerialNumber.Text(16)
+}
+
+// certReloadError returns an error when any server of results is stale or
+// could not be checked.
+func certReloadError(results []certReloadResult) error {
+	var stale, failed int
+	for _, result := range results {
+		switch {
+		case result.Error != nil:
+			failed++
+		case result.Stale():
+			stale++
+		}
+	}
+	switch {
+	case failed > 0:
+		return fmt.Errorf("%d of %d servers could not be checked", failed, len(results))
+	case stale > 0:
+		return fmt.Errorf("%d of %d servers present an old certificate", stale, len(results))
+	}
+	return nil
+}
+
+// certReloadReport is a [certReloadResult] as "--output=json" prints it.
+type certReloadReport struct {
+	Pod       string `json:"pod"`
+	Server    string `json:"server"`
+	Presented string `json:"presented"`
+	File      string `json:"file"`
+	Result    string `json:"result"`
+	Healthy   bool   `json:"healthy"`
+}
+
+// certReloadReports returns results with their outcomes.
+func certReloadReports(results []certReloadResult) []certReloadReport {
+	reports := make([]certReloadReport, 0, len(results))
+	for _, result := range results {
+		outcome := result.Outcome()
+		reports = append(reports, certReloadReport{
+			Pod: result.Pod, Server: result.Server,
+			Presented: certReloadSerial(result.Presented), File: certReloadSerial(result.File),
+			Result: outcome, Healthy: outcome == "ok",
+		})
+	}
+	return reports
+}
+
+// certReloadGauge returns whether each server of results presents its
+// current certificate as a gauge.
+func certReloadGauge(results []certReloadResult) *promGauge {
+	healthy := &promGauge{
+		Name: "pgo_check_cert_reload_healthy",
+		Help: "Whether a server presents the certificate in its files (1) or not (0).",
+	}
+	for _, result := range results {
+		healthy.add(promBool(result.Outcome() == "ok"), "pod", result.Pod, "server", result.Server)
+	}
+	return healthy
+}
+
+// printCertReloadResults prints a table of results followed by the Pods that
+// need to load their certificate. It returns an error when any server is
+// stale or could not be checked.
+func printCertReloadResults(cmd *cobra.Command, results []certReloadResult) error {
+	var stale []string
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "POD\tSERVER\tPRESENTED\tFILE\tRESULT")
+	for _, result := range results {
+		if result.Stale() {
+			stale = append(stale, result.Pod)
+		}
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", result.Pod, result.Server,
+			certReloadSerial(result.Presented), certReloadSerial(result.File), result.Outcome())
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	if len(stale) > 0 {
+		cmd.Printf("\nRestart these Pods to load the new certificate: %s\n", strings.Join(stale, ", "))
+	}
+	return certReloadError(results)
+}
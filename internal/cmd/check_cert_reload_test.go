@@ -0,0 +1,117 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+)
+
+func TestFirstPEMCertificate(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+
+	certificate := func(serial int64) []byte {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial), Subject: pkix.Name{CommonName: "hippo-primary"},
+			NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		assert.NilError(t, err)
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	}
+
+	t.Run("Chain", func(t *testing.T) {
+		data := append(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: []byte{1}}),
+			append(certificate(10), certificate(1)...)...)
+
+		parsed, err := firstPEMCertificate(data)
+		assert.NilError(t, err)
+		assert.Equal(t, parsed.SerialNumber.Int64(), int64(10))
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		_, err := firstPEMCertificate([]byte("cat: no such file\n"))
+		assert.ErrorContains(t, err, "no certificate")
+	})
+}
+
+func TestPrintCertReloadResults(t *testing.T) {
+	current := &x509.Certificate{SerialNumber: big.NewInt(0x5d2f), Raw: []byte{1}}
+	old := &x509.Certificate{SerialNumber: big.NewInt(0x31c9), Raw: []byte{2}}
+
+	t.Run("Stale", func(t *testing.T) {
+		var out bytes.Buffer
+		cmd := &cobra.Command{}
+		cmd.SetOut(&out)
+
+		err := printCertReloadResults(cmd, []certReloadResult{
+			{Pod: "hippo-instance1-8x7m-0", Server: "postgres", Presented: current, File: current},
+			{Pod: "hippo-instance1-x2pq-0", Server: "postgres", Presented: old, File: current},
+			{Pod: "hippo-pgbouncer-abc", Server: "pgbouncer", Presented: old, File: current},
+		})
+		assert.Error(t, err, "2 of 3 servers present an old certificate")
+		assert.Equal(t, out.String(), `
+POD                     SERVER     PRESENTED  FILE  RESULT
+hippo-instance1-8x7m-0  postgres   5d2f       5d2f  ok
+hippo-instance1-x2pq-0  postgres   31c9       5d2f  STALE
+hippo-pgbouncer-abc     pgbouncer  31c9       5d2f  STALE
+
+Restart these Pods to load the new certificate: hippo-instance1-x2pq-0, hippo-pgbouncer-abc
+`[1:])
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		var out bytes.Buffer
+		cmd := &cobra.Command{}
+		cmd.SetOut(&out)
+
+		err := printCertReloadResults(cmd, []certReloadResult{
+			{Pod: "hippo-instance1-8x7m-0", Server: "postgres", Presented: current, File: current},
+			{Pod: "hippo-pgbouncer-abc", Server: "pgbouncer", File: current, Error: errors.New("connection refused")},
+		})
+		assert.Error(t, err, "1 of 2 servers could not be checked")
+		assert.Equal(t, out.String(), `
+POD                     SERVER     PRESENTED  FILE  RESULT
+hippo-instance1-8x7m-0  postgres   5d2f       5d2f  ok
+hippo-pgbouncer-abc     pgbouncer  -          5d2f  ERROR: connection refused
+`[1:])
+	})
+
+	t.Run("Machine", func(t *testing.T) {
+		results := []certReloadResult{
+			{Pod: "hippo-instance1-8x7m-0", Server: "postgres", Presented: current, File: current},
+			{Pod: "hippo-pgbouncer-abc", Server: "pgbouncer", Presented: old, File: current},
+		}
+		assert.DeepEqual(t, certReloadReports(results), []certReloadReport{
+			{Pod: "hippo-instance1-8x7m-0", Server: "postgres", Presented: "5d2f", File: "5d2f",
+				Result: "ok", Healthy: true},
+			{Pod: "hippo-pgbouncer-abc", Server: "pgbouncer", Presented: "31c9", File: "5d2f",
+				Result: "STALE"},
+		})
+		assert.Error(t, certReloadError(results), "1 of 2 servers present an old certificate")
+
+		var out bytes.Buffer
+		assert.NilError(t, writePromGauges(&out, []string{"cluster", "hippo"}, certReloadGauge(results)))
+		assert.Equal(t, out.String(), `
+# HELP pgo_check_cert_reload_healthy Whether a server presents the certificate in its files (1) or not (0).
+# TYPE pgo_check_cert_reload_healthy gauge
+pgo_check_cert_reload_healthy{cluster="hippo",pod="hippo-instance1-8x7m-0",server="postgres"} 1
+pgo_check_cert_reload_healthy{cluster="hippo",pod="hippo-pgbouncer-abc",server="pgbouncer"} 0
+`[1:])
+	})
+}
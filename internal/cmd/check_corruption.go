@@ -0,0 +1,422 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newCheckCorruptionCommand returns the corruption subcommand of the check
+// command. It looks for signs of data corruption in every instance.
+func newCheckCorruptionCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "corruption CLUSTER_NAME",
+		Short: "Look for data corruption in a PostgresCluster",
+		Long: `Look for signs of data corruption in each ready instance of a PostgresCluster:
+  - checksums: whether data checksums are enabled; without them Postgres
+    cannot detect a corrupt data page when it reads one
+  - checksum-failures: the checksum failures Postgres counted in pg_stat_database
+  - logs: messages about corrupt pages, blocks, or rows in the "--logs" newest
+    Postgres log files
+
+With "--amcheck", the B-tree indexes of the tables in "--table", or of the ten
+largest tables when that is empty, are verified on the primary with the
+bt_index_check function of the amcheck extension. The extension must already
+be in the "--dbname" database. This takes a lock that does not block reads or
+writes, but it does read every page of those indexes.
+
+Each finding has a risk of none, low, unknown, or high. The command fails when
+any risk is unknown or high. Use "--output=json" for a result that scripts can read.
+Use "--output=prom" for gauges in the Prometheus text format, such as for the
+textfile collector of node_exporter.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    pods                                                [list]
+    pods/exec                                           [create]
+    postgresclusters.postgres-operator.crunchydata.com  [get]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Look for corruption in the 'hippo' postgrescluster
+pgo check corruption hippo
+
+# Also verify the indexes of two tables in the 'app' database
+pgo check corruption hippo --dbname=app --amcheck --table=orders --table=public.customers
+
+### Example output
+CHECK              INSTANCE                RISK  DETAIL
+checksums          hippo-instance1-8x7m-0  none  data checksums are enabled
+checksum-failures  hippo-instance1-8x7m-0  none  none counted
+logs               hippo-instance1-8x7m-0  none  no corruption messages in the 5 newest log files
+checksums          hippo-instance1-2d4n-0  none  data checksums are enabled
+checksum-failures  hippo-instance1-2d4n-0  none  none counted
+logs               hippo-instance1-2d4n-0  none  no corruption messages in the 5 newest log files
+amcheck            hippo-instance1-8x7m-0  none  4 indexes in database "app" verified
+Corruption risk: none`)
+
+	var (
+		database   string
+		amcheck    bool
+		tables     []string
+		numLogs    int
+		outputEnum = util.TextReadiness
+	)
+	cmd.Flags().StringVar(&database, "dbname", "", "database to verify with --amcheck; defaults to postgres")
+	cmd.Flags().BoolVar(&amcheck, "amcheck", false, "verify B-tree indexes with the amcheck extension")
+	cmd.Flags().StringSliceVar(&tables, "table", nil, "table whose indexes to verify with --amcheck")
+	cmd.Flags().IntVar(&numLogs, "logs", 5, "newest Postgres log files to search on each instance")
+	cmd.Flags().VarP(&outputEnum, "output", "o", "output format. types supported: text,json,prom")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		_, clusterClient, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+
+		cluster, err := clusterClient.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		_, hasInstrumentation, _ := unstructured.NestedMap(cluster.Object, "spec", "instrumentation")
+
+		pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.DBInstanceLabels(args[0]),
+		})
+		if err != nil {
+			return err
+		}
+		sort.Slice(pods.Items, func(i, j int) bool {
+			// Check the primary first.
+			iLeader := pods.Items[i].GetLabels()[util.LabelRole] == util.RolePatroniLeader
+			jLeader := pods.Items[j].GetLabels()[util.LabelRole] == util.RolePatroniLeader
+			if iLeader != jLeader {
+				return iLeader
+			}
+			return pods.Items[i].GetName() < pods.Items[j].GetName()
+		})
+
+		var findings []corruptionFinding
+		var primary *corev1.Pod
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			if !podIsReady(pod) {
+				findings = append(findings, corruptionFinding{Check: "instance", Instance: pod.GetName(),
+					Risk: riskUnknown, Detail: "the Pod is not ready; it was not checked"})
+				continue
+			}
+			if primary == nil && pod.GetLabels()[util.LabelRole] == util.RolePatroniLeader {
+				primary = pod
+			}
+			exec := podexec.Container(podExec, namespace, pod.GetName(), util.ContainerDatabase)
+
+			stdout, stderr, err := podexec.PSQL(exec, "", dataChecksumsSQL)
+			findings = append(findings, checkDataChecksums(pod.GetName(), stdout, commandError(err, stderr))...)
+
+			stdout, stderr, err = grepPGLogs(exec, numLogs, hasInstrumentation, corruptionLogPattern)
+			findings = append(findings, checkCorruptionLogs(pod.GetName(), numLogs, stdout, commandError(err, stderr)))
+		}
+
+		switch {
+		case !amcheck:
+		case primary == nil:
+			findings = append(findings, corruptionFinding{Check: "amcheck", Risk: riskUnknown,
+				Detail: "no ready primary instance Pod found"})
+		default:
+			exec := podexec.Container(podExec, namespace, primary.GetName(), util.ContainerDatabase)
+			command := []string{"psql", "--no-psqlrc", "--quiet", "--no-align", "--tuples-only",
+				"--field-separator=\t", "--set=ON_ERROR_STOP=1", "--set=tables=" + strings.Join(tables, ","),
+				"--file=-"}
+			if database != "" {
+				command = append(command, "--dbname="+database)
+			}
+			var stdout, stderr bytes.Buffer
+			err := exec.Exec(strings.NewReader(amcheckSQL), &stdout, &stderr, command...)
+			findings = append(findings, checkAmcheck(primary.GetName(), database,
+				stdout.String(), commandError(err, stderr.String()))...)
+		}
+
+		risk := corruptionRisk(findings)
+		switch outputEnum {
+		case util.JSONReadiness:
+			b, err := json.MarshalIndent(map[string]any{
+				"risk": risk, "findings": findings,
+			}, "", "  ")
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(b))
+		case util.PromReadiness:
+			if err := writePromGauges(cmd.OutOrStdout(),
+				[]string{"cluster", args[0], "namespace", namespace},
+				corruptionGauge(findings), checkTimestampGauge("corruption", time.Now())); err != nil {
+				return err
+			}
+		default:
+			if err := printCorruptionFindings(cmd, findings, risk); err != nil {
+				return err
+			}
+		}
+
+		if risk == riskHigh || risk == riskUnknown {
+			return fmt.Errorf("corruption risk is %s", risk)
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// Risks of a [corruptionFinding], from least to most severe.
+const (
+	riskNone    = "none"
+	riskLow     = "low"
+	riskUnknown = "unknown"
+	riskHigh    = "high"
+)
+
+// corruptionFinding is the result of one check for corruption.
+type corruptionFinding struct {
+	Check    string `json:"check"`
+	Instance string `json:"instance,omitempty"`
+	Risk     string `json:"risk"`
+	Detail   string `json:"detail"`
+}
+
+// corruptionRisk returns the most severe risk of findings.
+func corruptionRisk(findings []corruptionFinding) string {
+	order := []string{riskNone, riskLow, riskUnknown, riskHigh}
+	var worst int
+	for _, finding := range findings {
+		for i, risk := range order {
+			if finding.Risk == risk && i > worst {
+				worst = i
+			}
+		}
+	}
+	return order[worst]
+}
+
+// corruptionGauge returns the most severe risk of each check on each instance
+// as a gauge. Risks are numbered from least to most severe starting at zero.
+func corruptionGauge(findings []corruptionFinding) *promGauge {
+	gauge := &promGauge{
+		Name: "pgo_check_corruption_risk",
+		Help: "The risk of a corruption check: none (0), low (1), unknown (2), or high (3).",
+	}
+	type key struct{ check, instance string }
+	var keys []key
+	grouped := map[key][]corruptionFinding{}
+	for _, finding := range findings {
+		k := key{finding.Check, finding.Instance}
+		if _, ok := grouped[k]; !ok {
+			keys = append(keys, k)
+		}
+		grouped[k] = append(grouped[k], finding)
+	}
+
+	levels := map[string]float64{riskNone: 0, riskLow: 1, riskUnknown: 2, riskHigh: 3}
+	for _, k := range keys {
+		gauge.add(levels[corruptionRisk(grouped[k])], "check", k.check, "instance", k.instance)
+	}
+	return gauge
+}
+
+// dataChecksumsSQL prints whether data checksums are enabled, the number of
+// checksum failures counted, and when the last one happened. Postgres 12
+// added the counters.
+const dataChecksumsSQL = `SELECT pg_catalog.current_setting('data_checksums'),
+ COALESCE(sum(checksum_failures), 0), COALESCE(max(checksum_last_failure)::text, '')
+  FROM pg_catalog.pg_stat_database;`
+
+// checkDataChecksums reads the output of [dataChecksumsSQL] on instance.
+func checkDataChecksums(instance, stdout string, err error) []corruptionFinding {
+	checksums := corruptionFinding{Check: "checksums", Instance: instance}
+	failures := corruptionFinding{Check: "checksum-failures", Instance: instance}
+
+	// The last field is empty when there have been no failures.
+	fields := strings.Split(strings.TrimRight(stdout, "\n"), "\t")
+	if err == nil && len(fields) != 3 {
+		err = fmt.Errorf("unexpected output %q", strings.TrimSpace(stdout))
+	}
+	if err != nil {
+		checksums.Risk, checksums.Detail = riskUnknown, err.Error()
+		return []corruptionFinding{checksums}
+	}
+
+	if fields[0] != "on" {
+		checksums.Risk = riskLow
+		checksums.Detail = "data checksums are disabled; corrupt data pages go undetected"
+		return []corruptionFinding{checksums}
+	}
+	checksums.Risk, checksums.Detail = riskNone, "data checksums are enabled"
+
+	count, _ := strconv.ParseInt(fields[1], 10, 64)
+	if count == 0 {
+		failures.Risk, failures.Detail = riskNone, "none counted"
+	} else {
+		failures.Risk = riskHigh
+		failures.Detail = fmt.Sprintf("%d counted, the last at %s", count, fields[2])
+	}
+	return []corruptionFinding{checksums, failures}
+}
+
+// corruptionLogPattern matches messages that Postgres logs when it reads a
+// corrupt page, block, or row.
+const corruptionLogPattern = `page verification failed|invalid page in block|` +
+	`could not read block|unexpected zero page|invalid memory alloc request size|` +
+	`missing chunk number|from before relfrozenxid|could not access status of transaction`
+
+// checkCorruptionLogs reads the output of [grepPGLogs] on instance.
+func checkCorruptionLogs(instance string, numLogs int, stdout string, err error) corruptionFinding {
+	finding := corruptionFinding{Check: "logs", Instance: instance}
+
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	count, parseErr := strconv.Atoi(strings.TrimSpace(lines[0]))
+	if err == nil && parseErr != nil {
+		err = fmt.Errorf("unexpected output %q", strings.TrimSpace(stdout))
+	}
+	if err != nil {
+		finding.Risk, finding.Detail = riskUnknown, err.Error()
+		return finding
+	}
+
+	if count == 0 {
+		finding.Risk = riskNone
+		finding.Detail = fmt.Sprintf("no corruption messages in the %d newest log files", numLogs)
+		return finding
+	}
+	finding.Risk = riskHigh
+	finding.Detail = fmt.Sprintf("%d corruption messages in the %d newest log files; the last: %s",
+		count, numLogs, strings.TrimSpace(lines[len(lines)-1]))
+	return finding
+}
+
+// amcheckSQL verifies the B-tree indexes of the tables named in the "tables"
+// variable, separated by commas, or of the ten largest tables when it is
+// empty. It prints "missing" when the amcheck extension is not installed and
+// otherwise each index followed by "ok" or the error that verifying it raised.
+const amcheckSQL = `SELECT EXISTS (SELECT FROM pg_catalog.pg_extension WHERE extname = 'amcheck') AS installed \gset
+\if :installed
+CREATE FUNCTION pg_temp.pgo_index_check(index regclass) RETURNS text LANGUAGE plpgsql AS $$
+BEGIN
+  PERFORM bt_index_check(index);
+  RETURN 'ok';
+EXCEPTION WHEN OTHERS THEN
+  RETURN SQLERRM;
+END $$;
+SELECT i.indexrelid::regclass, pg_temp.pgo_index_check(i.indexrelid)
+  FROM pg_catalog.pg_index i
+  JOIN pg_catalog.pg_class c ON c.oid = i.indexrelid
+  JOIN pg_catalog.pg_am am ON am.oid = c.relam
+ WHERE am.amname = 'btree' AND i.indisvalid AND i.indisready AND c.relpersistence <> 't'
+   AND i.indrelid IN (
+     SELECT pg_catalog.to_regclass(t) FROM pg_catalog.unnest(pg_catalog.string_to_array(:'tables', ',')) t
+     UNION ALL
+     (SELECT r.oid FROM pg_catalog.pg_class r
+        JOIN pg_catalog.pg_namespace n ON n.oid = r.relnamespace
+       WHERE :'tables' = '' AND r.relkind IN ('r', 'm')
+         AND n.nspname NOT IN ('pg_catalog', 'information_schema') AND n.nspname !~ '^pg_toast'
+       ORDER BY pg_catalog.pg_total_relation_size(r.oid) DESC LIMIT 10))
+ ORDER BY 1;
+\else
+\echo missing
+\endif`
+
+// checkAmcheck reads the output of [amcheckSQL] in database on instance.
+func checkAmcheck(instance, database, stdout string, err error) []corruptionFinding {
+	if database == "" {
+		database = "postgres"
+	}
+	summary := corruptionFinding{Check: "amcheck", Instance: instance}
+	if err != nil {
+		summary.Risk, summary.Detail = riskUnknown, err.Error()
+		return []corruptionFinding{summary}
+	}
+	if strings.TrimSpace(stdout) == "missing" {
+		summary.Risk = riskUnknown
+		summary.Detail = fmt.Sprintf("the amcheck extension is not in database %q; "+
+			"run CREATE EXTENSION amcheck there first", database)
+		return []corruptionFinding{summary}
+	}
+
+	var findings []corruptionFinding
+	var verified int
+	for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+		index, result, found := strings.Cut(line, "\t")
+		switch {
+		case !found:
+		case result == "ok":
+			verified++
+		default:
+			findings = append(findings, corruptionFinding{Check: "amcheck", Instance: instance,
+				Risk: riskHigh, Detail: index + ": " + result})
+		}
+	}
+
+	summary.Risk = riskNone
+	summary.Detail = fmt.Sprintf("%d indexes in database %q verified", verified, database)
+	if verified == 0 && len(findings) == 0 {
+		summary.Risk = riskLow
+		summary.Detail = fmt.Sprintf("no B-tree indexes to verify in database %q", database)
+	}
+	return append([]corruptionFinding{summary}, findings...)
+}
+
+// printCorruptionFindings prints findings as a table followed by their risk.
+func printCorruptionFindings(cmd *cobra.Command, findings []corruptionFinding, risk string) error {
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "CHECK\tINSTANCE\tRISK\tDETAIL")
+	for _, finding := range findings {
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n",
+			finding.Check, finding.Instance, finding.Risk, finding.Detail)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	cmd.Printf("Corruption risk: %s\n", risk)
+	return nil
+}
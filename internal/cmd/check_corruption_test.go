@@ -0,0 +1,122 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+)
+
+func TestCheckDataChecksums(t *testing.T) {
+	const pod = "hippo-instance1-8x7m-0"
+
+	assert.DeepEqual(t, checkDataChecksums(pod, "on\t0\t\n", nil), []corruptionFinding{
+		{Check: "checksums", Instance: pod, Risk: riskNone, Detail: "data checksums are enabled"},
+		{Check: "checksum-failures", Instance: pod, Risk: riskNone, Detail: "none counted"},
+	})
+	assert.DeepEqual(t, checkDataChecksums(pod, "on\t3\t2024-05-01 15:11:48+00\n", nil), []corruptionFinding{
+		{Check: "checksums", Instance: pod, Risk: riskNone, Detail: "data checksums are enabled"},
+		{Check: "checksum-failures", Instance: pod, Risk: riskHigh,
+			Detail: "3 counted, the last at 2024-05-01 15:11:48+00"},
+	})
+	assert.DeepEqual(t, checkDataChecksums(pod, "off\t0\t\n", nil), []corruptionFinding{
+		{Check: "checksums", Instance: pod, Risk: riskLow,
+			Detail: "data checksums are disabled; corrupt data pages go undetected"},
+	})
+	assert.DeepEqual(t, checkDataChecksums(pod, "", errors.New("boom")), []corruptionFinding{
+		{Check: "checksums", Instance: pod, Risk: riskUnknown, Detail: "boom"},
+	})
+}
+
+func TestCheckCorruptionLogs(t *testing.T) {
+	const pod = "hippo-instance1-8x7m-0"
+
+	assert.DeepEqual(t, checkCorruptionLogs(pod, 5, "0\n\n", nil), corruptionFinding{
+		Check: "logs", Instance: pod, Risk: riskNone,
+		Detail: "no corruption messages in the 5 newest log files",
+	})
+	assert.DeepEqual(t, checkCorruptionLogs(pod, 5, `7
+2024-05-01 15:11:48.000 UTC [95] WARNING:  page verification failed, calculated checksum 1 but expected 2
+2024-05-01 15:11:48.000 UTC [95] ERROR:  invalid page in block 4 of relation base/16384/16385
+`, nil), corruptionFinding{
+		Check: "logs", Instance: pod, Risk: riskHigh,
+		Detail: "7 corruption messages in the 5 newest log files; the last: " +
+			"2024-05-01 15:11:48.000 UTC [95] ERROR:  invalid page in block 4 of relation base/16384/16385",
+	})
+	assert.Equal(t, checkCorruptionLogs(pod, 5, "ls: cannot access", nil).Risk, riskUnknown)
+	assert.Equal(t, checkCorruptionLogs(pod, 5, "", errors.New("boom")).Detail, "boom")
+}
+
+func TestCheckAmcheck(t *testing.T) {
+	const pod = "hippo-instance1-8x7m-0"
+
+	assert.DeepEqual(t, checkAmcheck(pod, "app", "orders_pkey\tok\norders_customer_idx\titem order invariant violated\n", nil),
+		[]corruptionFinding{
+			{Check: "amcheck", Instance: pod, Risk: riskNone, Detail: `1 indexes in database "app" verified`},
+			{Check: "amcheck", Instance: pod, Risk: riskHigh,
+				Detail: "orders_customer_idx: item order invariant violated"},
+		})
+	assert.DeepEqual(t, checkAmcheck(pod, "", "", nil), []corruptionFinding{
+		{Check: "amcheck", Instance: pod, Risk: riskLow, Detail: `no B-tree indexes to verify in database "postgres"`},
+	})
+
+	findings := checkAmcheck(pod, "app", "missing\n", nil)
+	assert.Equal(t, len(findings), 1)
+	assert.Equal(t, findings[0].Risk, riskUnknown)
+	assert.Assert(t, strings.Contains(findings[0].Detail, "CREATE EXTENSION amcheck"))
+}
+
+func TestCorruptionRisk(t *testing.T) {
+	assert.Equal(t, corruptionRisk(nil), riskNone)
+	assert.Equal(t, corruptionRisk([]corruptionFinding{{Risk: riskLow}, {Risk: riskNone}}), riskLow)
+	assert.Equal(t, corruptionRisk([]corruptionFinding{{Risk: riskHigh}, {Risk: riskUnknown}}), riskHigh)
+	assert.Equal(t, corruptionRisk([]corruptionFinding{{Risk: riskUnknown}, {Risk: riskLow}}), riskUnknown)
+}
+
+func TestPrintCorruptionFindings(t *testing.T) {
+	var findings []corruptionFinding
+	for _, pod := range []string{"hippo-instance1-8x7m-0", "hippo-instance1-2d4n-0"} {
+		findings = append(findings, checkDataChecksums(pod, "on\t0\t", nil)...)
+		findings = append(findings, checkCorruptionLogs(pod, 5, "0", nil))
+	}
+	findings = append(findings, checkAmcheck("hippo-instance1-8x7m-0", "app",
+		"a_pkey\tok\nb_pkey\tok\nc_pkey\tok\nd_idx\tok", nil)...)
+
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+	assert.NilError(t, printCorruptionFindings(cmd, findings, corruptionRisk(findings)))
+	assert.Equal(t, out.String(), `CHECK              INSTANCE                RISK  DETAIL
+checksums          hippo-instance1-8x7m-0  none  data checksums are enabled
+checksum-failures  hippo-instance1-8x7m-0  none  none counted
+logs               hippo-instance1-8x7m-0  none  no corruption messages in the 5 newest log files
+checksums          hippo-instance1-2d4n-0  none  data checksums are enabled
+checksum-failures  hippo-instance1-2d4n-0  none  none counted
+logs               hippo-instance1-2d4n-0  none  no corruption messages in the 5 newest log files
+amcheck            hippo-instance1-8x7m-0  none  4 indexes in database "app" verified
+Corruption risk: none
+`)
+}
+
+func TestCorruptionGauge(t *testing.T) {
+	var out bytes.Buffer
+	assert.NilError(t, writePromGauges(&out, nil, corruptionGauge([]corruptionFinding{
+		{Check: "checksums", Instance: "hippo-a-0", Risk: riskLow},
+		{Check: "amcheck", Instance: "hippo-a-0", Risk: riskNone},
+		{Check: "amcheck", Instance: "hippo-a-0", Risk: riskHigh},
+		{Check: "amcheck", Risk: riskUnknown},
+	})))
+	assert.Equal(t, out.String(), `# HELP pgo_check_corruption_risk The risk of a corruption check: none (0), low (1), unknown (2), or high (3).
+# TYPE pgo_check_corruption_risk gauge
+pgo_check_corruption_risk{check="checksums",instance="hippo-a-0"} 1
+pgo_check_corruption_risk{check="amcheck",instance="hippo-a-0"} 3
+pgo_check_corruption_risk{check="amcheck"} 2
+`)
+}
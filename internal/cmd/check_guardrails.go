@@ -0,0 +1,425 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newCheckGuardrailsCommand returns the guardrails subcommand of the check
+// command. It reports configurations of a cluster that risk its availability
+// or its data.
+func newCheckGuardrailsCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "guardrails CLUSTER_NAME",
+		Short: "Check a PostgresCluster for risky configurations",
+		Long: `Check a PostgresCluster for configurations that are risky in production:
+  - single-replica: an instance set has only one instance (failure)
+  - backups-disabled: the cluster has no pgBackRest repositories (failure)
+  - no-anti-affinity: an instance set has neither podAntiAffinity nor
+    topologySpreadConstraints, so its instances can share a node (warning)
+  - no-pdb: an instance set has no PodDisruptionBudget (warning)
+  - repo-colocated: a volume repository uses the storage class or zone of the
+    data of an instance set (warning)
+
+Use "--policy" to choose the severity of each finding with a YAML file that
+maps rules to "failure", "warning", or "ignore". Rules missing from the file
+keep the severity above. The command fails when any finding is a failure.
+
+    severity:
+      single-replica: warning
+      repo-colocated: failure
+
+Use "--output=json" for findings that scripts can read. Use "--output=prom" for
+gauges in the Prometheus text format, such as for the textfile collector of
+node_exporter.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    persistentvolumeclaims                              [list]
+    persistentvolumes                                   [get]
+    poddisruptionbudgets.policy                         [list]
+    postgresclusters.postgres-operator.crunchydata.com  [get]
+
+    Note: Getting persistentvolumes requires cluster-scoped RBAC. Without it,
+    zones are not compared.
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Check the 'hippo' postgrescluster for risky configurations
+pgo check guardrails hippo
+
+# Check it with the severities of a platform team
+pgo check guardrails hippo --policy=./policy.yaml
+
+### Example output
+RULE            SEVERITY  SUBJECT    DETAIL
+single-replica  failure   instance1  1 replica
+no-pdb          warning   instance1  no PodDisruptionBudget
+repo-colocated  warning   repo1      same storage class as instance1: standard
+Error: 1 of 3 findings are failures`)
+
+	var policyFile string
+	cmd.Flags().StringVar(&policyFile, "policy", "", "path to a YAML file of finding severities")
+
+	outputEnum := util.TextReadiness
+	cmd.Flags().VarP(&outputEnum, "output", "o", "output format. types supported: text,json,prom")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		policy := guardrailPolicy{}
+		if policyFile != "" {
+			b, err := os.ReadFile(policyFile)
+			if err != nil {
+				return err
+			}
+			if policy, err = parseGuardrailPolicy(b); err != nil {
+				return fmt.Errorf("--policy: %w", err)
+			}
+		}
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		clientset, err := kubernetes.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		_, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		cluster, err := client.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		selector := metav1.ListOptions{LabelSelector: util.LabelCluster + "=" + args[0]}
+		pdbs, err := clientset.PolicyV1().PodDisruptionBudgets(namespace).List(ctx, selector)
+		if err != nil {
+			return err
+		}
+		pvcs, err := clientset.CoreV1().PersistentVolumeClaims(namespace).List(ctx, selector)
+		if err != nil {
+			return err
+		}
+
+		// Zones are labels of the volumes. Skip them when volumes cannot be read.
+		zones := map[string]string{}
+		for _, pvc := range pvcs.Items {
+			if pvc.Spec.VolumeName == "" {
+				continue
+			}
+			pv, err := clientset.CoreV1().PersistentVolumes().Get(ctx, pvc.Spec.VolumeName, metav1.GetOptions{})
+			if err != nil {
+				zones = nil
+				break
+			}
+			if zone := pv.Labels[corev1.LabelTopologyZone]; zone != "" {
+				zones[pvc.Name] = zone
+			}
+		}
+
+		findings := policy.apply(checkGuardrails(cluster, pdbs.Items, pvcs.Items, zones))
+
+		switch outputEnum {
+		case util.JSONReadiness:
+			b, err := json.MarshalIndent(findings, "", "  ")
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(b))
+		case util.PromReadiness:
+			if err := writePromGauges(cmd.OutOrStdout(),
+				[]string{"cluster", args[0], "namespace", namespace},
+				policy.gauge(findings), checkTimestampGauge("guardrails", time.Now())); err != nil {
+				return err
+			}
+		default:
+			if err := printGuardrailFindings(cmd, findings); err != nil {
+				return err
+			}
+		}
+
+		var failures int
+		for _, finding := range findings {
+			if finding.Severity == guardrailFailure {
+				failures++
+			}
+		}
+		if failures > 0 {
+			return fmt.Errorf("%d of %d findings are failures", failures, len(findings))
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// Severities of a [guardrailFinding].
+const (
+	guardrailFailure = "failure"
+	guardrailWarning = "warning"
+	guardrailIgnore  = "ignore"
+)
+
+// guardrailSeverities are the rules of [checkGuardrails] and the severity of
+// each when a policy does not set it.
+var guardrailSeverities = map[string]string{
+	"backups-disabled": guardrailFailure,
+	"no-anti-affinity": guardrailWarning,
+	"no-pdb":           guardrailWarning,
+	"repo-colocated":   guardrailWarning,
+	"single-replica":   guardrailFailure,
+}
+
+// guardrailFinding is one risky configuration of a cluster.
+type guardrailFinding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Subject  string `json:"subject"`
+	Detail   string `json:"detail"`
+}
+
+// guardrailPolicy is the file of the --policy flag.
+type guardrailPolicy struct {
+	Severity map[string]string `json:"severity"`
+}
+
+// parseGuardrailPolicy reads a policy file and validates its rules and
+// severities.
+func parseGuardrailPolicy(b []byte) (guardrailPolicy, error) {
+	var policy guardrailPolicy
+	if err := yaml.UnmarshalStrict(b, &policy); err != nil {
+		return policy, err
+	}
+	for rule, severity := range policy.Severity {
+		if _, ok := guardrailSeverities[rule]; !ok {
+			return policy, fmt.Errorf("unknown rule %q", rule)
+		}
+		switch severity {
+		case guardrailFailure, guardrailWarning, guardrailIgnore:
+		default:
+			return policy, fmt.Errorf("rule %q: severity must be one of failure, warning, or ignore", rule)
+		}
+	}
+	return policy, nil
+}
+
+// apply sets the severity of each finding and drops those that are ignored.
+func (policy guardrailPolicy) apply(findings []guardrailFinding) []guardrailFinding {
+	kept := make([]guardrailFinding, 0, len(findings))
+	for _, finding := range findings {
+		finding.Severity = guardrailSeverities[finding.Rule]
+		if severity, ok := policy.Severity[finding.Rule]; ok {
+			finding.Severity = severity
+		}
+		if finding.Severity != guardrailIgnore {
+			kept = append(kept, finding)
+		}
+	}
+	return kept
+}
+
+// gauge returns the number of findings of each rule that policy does not
+// ignore. Rules without findings are zero, so alerts on them resolve.
+func (policy guardrailPolicy) gauge(findings []guardrailFinding) *promGauge {
+	gauge := &promGauge{
+		Name: "pgo_check_guardrails_findings",
+		Help: "The number of risky configurations that a rule of pgo check guardrails found.",
+	}
+	rules := make([]string, 0, len(guardrailSeverities))
+	for rule := range guardrailSeverities {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return guardrailOrder(rules[i]) < guardrailOrder(rules[j]) })
+
+	for _, rule := range rules {
+		severity := guardrailSeverities[rule]
+		if s, ok := policy.Severity[rule]; ok {
+			severity = s
+		}
+		if severity == guardrailIgnore {
+			continue
+		}
+		var count int
+		for _, finding := range findings {
+			if finding.Rule == rule {
+				count++
+			}
+		}
+		gauge.add(float64(count), "rule", rule, "severity", severity)
+	}
+	return gauge
+}
+
+// checkGuardrails returns the risky configurations of cluster without their
+// severity. The PodDisruptionBudgets and PersistentVolumeClaims are those of
+// cluster; zones are the zones of the claims by name, when known.
+func checkGuardrails(
+	cluster *unstructured.Unstructured, pdbs []policyv1.PodDisruptionBudget,
+	pvcs []corev1.PersistentVolumeClaim, zones map[string]string,
+) []guardrailFinding {
+	var findings []guardrailFinding
+
+	protected := map[string]bool{}
+	for _, pdb := range pdbs {
+		if set := pdb.Labels[util.LabelInstanceSet]; set != "" {
+			protected[set] = true
+		}
+	}
+
+	// The zones that hold the data of each instance set.
+	setZones := map[string][]string{}
+	repoZones := map[string]string{}
+	for _, pvc := range pvcs {
+		zone := zones[pvc.Name]
+		switch {
+		case zone == "":
+		case pvc.Labels[util.LabelRole] == util.RolePostgresData:
+			set := pvc.Labels[util.LabelInstanceSet]
+			if len(missingStrings(setZones[set], []string{zone})) > 0 {
+				setZones[set] = append(setZones[set], zone)
+			}
+		case pvc.Labels[util.LabelData] == util.DataBackrest:
+			repoZones[pvc.Labels[labelPGBackRestRepo]] = zone
+		}
+	}
+
+	sets, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "instances")
+	storageClasses := map[string]string{}
+	var setNames []string
+	for _, entry := range sets {
+		set, ok := entry.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(set, "name")
+		setNames = append(setNames, name)
+		storageClasses[name], _, _ = unstructured.NestedString(set,
+			"dataVolumeClaimSpec", "storageClassName")
+
+		replicas, found, _ := unstructured.NestedInt64(set, "replicas")
+		if !found {
+			replicas = 1
+		}
+		if replicas < 2 {
+			findings = append(findings, guardrailFinding{Rule: "single-replica", Subject: name,
+				Detail: fmt.Sprintf("%d replica", replicas)})
+		}
+
+		_, affinity, _ := unstructured.NestedMap(set, "affinity", "podAntiAffinity")
+		spread, _, _ := unstructured.NestedSlice(set, "topologySpreadConstraints")
+		if replicas > 1 && !affinity && len(spread) == 0 {
+			findings = append(findings, guardrailFinding{Rule: "no-anti-affinity", Subject: name,
+				Detail: "no podAntiAffinity or topologySpreadConstraints"})
+		}
+
+		if !protected[name] {
+			findings = append(findings, guardrailFinding{Rule: "no-pdb", Subject: name,
+				Detail: "no PodDisruptionBudget"})
+		}
+	}
+
+	repos, found, _ := unstructured.NestedSlice(cluster.Object, "spec", "backups", "pgbackrest", "repos")
+	if !found || len(repos) == 0 {
+		findings = append(findings, guardrailFinding{Rule: "backups-disabled",
+			Subject: cluster.GetName(), Detail: "no pgBackRest repositories"})
+	}
+	for _, entry := range repos {
+		repo, ok := entry.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(repo, "name")
+		spec, isVolume, _ := unstructured.NestedMap(repo, "volume", "volumeClaimSpec")
+		if !isVolume {
+			continue
+		}
+		class, _, _ := unstructured.NestedString(spec, "storageClassName")
+
+		var reasons []string
+		for _, set := range setNames {
+			if storageClasses[set] == class {
+				shown := class
+				if shown == "" {
+					shown = "the default"
+				}
+				reasons = append(reasons, fmt.Sprintf("same storage class as %s: %s", set, shown))
+			}
+			if zone := repoZones[name]; zone != "" && len(missingStrings(setZones[set], []string{zone})) == 0 {
+				reasons = append(reasons, fmt.Sprintf("same zone as %s: %s", set, zone))
+			}
+		}
+		if len(reasons) > 0 {
+			findings = append(findings, guardrailFinding{Rule: "repo-colocated", Subject: name,
+				Detail: strings.Join(reasons, "; ")})
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return guardrailOrder(findings[i].Rule) < guardrailOrder(findings[j].Rule)
+	})
+	return findings
+}
+
+// labelPGBackRestRepo identifies the volume of a pgBackRest repository.
+const labelPGBackRestRepo = "postgres-operator.crunchydata.com/pgbackrest-repo"
+
+// guardrailOrder is the position of rule in the output.
+func guardrailOrder(rule string) int {
+	for i, r := range []string{
+		"single-replica", "backups-disabled", "no-anti-affinity", "no-pdb", "repo-colocated",
+	} {
+		if r == rule {
+			return i
+		}
+	}
+	return len(guardrailSeverities)
+}
+
+// printGuardrailFindings prints findings as a table.
+func printGuardrailFindings(cmd *cobra.Command, findings []guardrailFinding) error {
+	if len(findings) == 0 {
+		cmd.Println("no risky configurations found")
+		return nil
+	}
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "RULE\tSEVERITY\tSUBJECT\tDETAIL")
+	for _, finding := range findings {
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n",
+			finding.Rule, finding.Severity, finding.Subject, finding.Detail)
+	}
+	return writer.Flush()
+}
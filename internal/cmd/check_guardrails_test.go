@@ -0,0 +1,153 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// guardrailCluster decodes manifest the way the API client does, with integers
+// as int64.
+func guardrailCluster(t *testing.T, manifest string) *unstructured.Unstructured {
+	b, err := yaml.YAMLToJSON([]byte(`
+apiVersion: postgres-operator.crunchydata.com/v1beta1
+kind: PostgresCluster
+` + manifest))
+	assert.NilError(t, err)
+
+	cluster := new(unstructured.Unstructured)
+	assert.NilError(t, cluster.UnmarshalJSON(b))
+	return cluster
+}
+
+func TestCheckGuardrails(t *testing.T) {
+	cluster := guardrailCluster(t, `
+metadata: { name: hippo }
+spec:
+  instances:
+  - name: one
+    dataVolumeClaimSpec: { storageClassName: fast }
+  - name: many
+    replicas: 3
+    dataVolumeClaimSpec: { storageClassName: fast }
+  - name: spread
+    replicas: 2
+    dataVolumeClaimSpec: { storageClassName: fast }
+    topologySpreadConstraints: [{ maxSkew: 1 }]
+  backups:
+    pgbackrest:
+      repos:
+      - name: repo1
+        volume: { volumeClaimSpec: { storageClassName: slow } }
+      - name: repo2
+        s3: { bucket: b }
+`)
+
+	labeled := func(name string, labels map[string]string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Labels: labels}
+	}
+	pdbs := []policyv1.PodDisruptionBudget{
+		{ObjectMeta: labeled("hippo-set-many", map[string]string{util.LabelInstanceSet: "many"})},
+		{ObjectMeta: labeled("hippo-set-spread", map[string]string{util.LabelInstanceSet: "spread"})},
+	}
+	pvcs := []corev1.PersistentVolumeClaim{
+		{ObjectMeta: labeled("hippo-many-abc-pgdata", map[string]string{
+			util.LabelRole: util.RolePostgresData, util.LabelInstanceSet: "many"})},
+		{ObjectMeta: labeled("hippo-repo1", map[string]string{
+			util.LabelData: util.DataBackrest, labelPGBackRestRepo: "repo1"})},
+	}
+	zones := map[string]string{"hippo-many-abc-pgdata": "us-east-1a", "hippo-repo1": "us-east-1a"}
+
+	assert.DeepEqual(t, checkGuardrails(cluster, pdbs, pvcs, zones), []guardrailFinding{
+		{Rule: "single-replica", Subject: "one", Detail: "1 replica"},
+		{Rule: "no-anti-affinity", Subject: "many", Detail: "no podAntiAffinity or topologySpreadConstraints"},
+		{Rule: "no-pdb", Subject: "one", Detail: "no PodDisruptionBudget"},
+		{Rule: "repo-colocated", Subject: "repo1", Detail: "same zone as many: us-east-1a"},
+	})
+
+	t.Run("NoBackups", func(t *testing.T) {
+		cluster := guardrailCluster(t, `
+metadata: { name: hippo }
+spec:
+  instances:
+  - name: one
+    replicas: 2
+    affinity: { podAntiAffinity: {} }
+    dataVolumeClaimSpec: {}
+`)
+
+		assert.DeepEqual(t, checkGuardrails(cluster, pdbs, nil, nil), []guardrailFinding{
+			{Rule: "backups-disabled", Subject: "hippo", Detail: "no pgBackRest repositories"},
+			{Rule: "no-pdb", Subject: "one", Detail: "no PodDisruptionBudget"},
+		})
+	})
+}
+
+func TestGuardrailPolicy(t *testing.T) {
+	findings := []guardrailFinding{
+		{Rule: "single-replica", Subject: "one"},
+		{Rule: "no-pdb", Subject: "one"},
+		{Rule: "repo-colocated", Subject: "repo1"},
+	}
+
+	assert.DeepEqual(t, guardrailPolicy{}.apply(findings), []guardrailFinding{
+		{Rule: "single-replica", Subject: "one", Severity: "failure"},
+		{Rule: "no-pdb", Subject: "one", Severity: "warning"},
+		{Rule: "repo-colocated", Subject: "repo1", Severity: "warning"},
+	})
+
+	policy, err := parseGuardrailPolicy([]byte(`
+severity:
+  single-replica: warning
+  repo-colocated: ignore
+`))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, policy.apply(findings), []guardrailFinding{
+		{Rule: "single-replica", Subject: "one", Severity: "warning"},
+		{Rule: "no-pdb", Subject: "one", Severity: "warning"},
+	})
+
+	_, err = parseGuardrailPolicy([]byte(`severity: { one-replica: warning }`))
+	assert.ErrorContains(t, err, `unknown rule "one-replica"`)
+	_, err = parseGuardrailPolicy([]byte(`severity: { no-pdb: error }`))
+	assert.ErrorContains(t, err, "severity must be one of")
+	_, err = parseGuardrailPolicy([]byte(`rules: {}`))
+	assert.ErrorContains(t, err, "unknown field")
+}
+
+func TestGuardrailPolicyGauge(t *testing.T) {
+	policy, err := parseGuardrailPolicy([]byte(`
+severity:
+  single-replica: warning
+  repo-colocated: ignore
+`))
+	assert.NilError(t, err)
+
+	var out bytes.Buffer
+	assert.NilError(t, writePromGauges(&out, []string{"cluster", "hippo"},
+		policy.gauge(policy.apply([]guardrailFinding{
+			{Rule: "no-pdb", Subject: "one"},
+			{Rule: "no-pdb", Subject: "two"},
+			{Rule: "single-replica", Subject: "one"},
+			{Rule: "repo-colocated", Subject: "repo1"},
+		}))))
+	assert.Equal(t, out.String(), `# HELP pgo_check_guardrails_findings The number of risky configurations that a rule of pgo check guardrails found.
+# TYPE pgo_check_guardrails_findings gauge
+pgo_check_guardrails_findings{cluster="hippo",rule="single-replica",severity="warning"} 1
+pgo_check_guardrails_findings{cluster="hippo",rule="backups-disabled",severity="failure"} 0
+pgo_check_guardrails_findings{cluster="hippo",rule="no-anti-affinity",severity="warning"} 0
+pgo_check_guardrails_findings{cluster="hippo",rule="no-pdb",severity="warning"} 2
+`)
+}
@@ -0,0 +1,567 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newCheckImagesCommand returns the images subcommand of the check command. It
+// verifies that every image of a cluster can be pulled on its nodes.
+func newCheckImagesCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "images CLUSTER_NAME",
+		Short: "Check that the images of a PostgresCluster exist for its nodes",
+		Long: `Check the images a PostgresCluster uses before it is created or updated:
+  - postgres, pgbackrest, pgbouncer, and exporter images are read from the spec
+    or, when the spec does not set them, from the environment of the operator
+  - each image is looked up in its registry
+  - the architectures of each image are compared to those of the nodes
+
+The nodes are those running Pods of the cluster or, when none do yet, every
+node of the Kubernetes cluster.
+
+Use "--mirror" when nodes pull through a mirror, such as in an air-gapped
+environment. Each "--mirror=SOURCE=MIRROR" replaces the SOURCE prefix of image
+names with MIRROR. A MIRROR that starts with "http://" is reached without TLS.
+
+Registries that require credentials cannot be checked; those images are
+reported as unverified.
+
+Use "--output=prom" for gauges in the Prometheus text format, such as for the
+textfile collector of node_exporter.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    nodes                                               [list]
+    pods                                                [list]
+    postgresclusters.postgres-operator.crunchydata.com  [get]
+
+    Note: Listing nodes requires cluster-scoped RBAC. Without
+    "--operator-namespace", the operator is found by listing deployments in
+    all namespaces; the namespace of the cluster is used when that is not
+    allowed.
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Check the images of the 'hippo' postgrescluster
+pgo check images hippo
+
+# Check the images in a mirror of the Crunchy Data registry
+pgo check images hippo --mirror=registry.developers.crunchydata.com=mirror.example.com:5000
+
+### Example output
+COMPONENT   IMAGE                                                                           ARCHITECTURES             RESULT
+postgres    registry.developers.crunchydata.com/crunchydata/crunchy-postgres:ubi8-16.3-1    linux/amd64, linux/arm64  ok
+pgbackrest  registry.developers.crunchydata.com/crunchydata/crunchy-pgbackrest:ubi8-2.51-1  linux/amd64, linux/arm64  ok
+pgbouncer   registry.developers.crunchydata.com/crunchydata/crunchy-pgbouncer:ubi8-1.22-1   linux/amd64               no linux/arm64 for nodes worker-3
+Error: 1 of 3 images cannot be used`)
+
+	var mirrors []string
+	var operatorNamespace string
+	cmd.Flags().StringArrayVar(&mirrors, "mirror", nil,
+		"a registry mirror as SOURCE=MIRROR; can be used multiple times")
+	cmd.Flags().StringVar(&operatorNamespace, "operator-namespace", "",
+		"namespace of the operator; found from its deployment by default")
+
+	outputEnum := util.TextCheck
+	cmd.Flags().VarP(&outputEnum, "output", "o", "output format. types supported: text,prom")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rewrites, err := parseImageMirrors(mirrors)
+		if err != nil {
+			return err
+		}
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		clientset, err := kubernetes.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		_, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		if operatorNamespace == "" {
+			operatorNamespace = operatorNamespaceFor(ctx, clientset, namespace)
+		}
+
+		cluster, err := client.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		operators, err := clientset.CoreV1().Pods(operatorNamespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.LabelOperator,
+		})
+		if err != nil {
+			return err
+		}
+		env := map[string]string{}
+		for _, pod := range operators.Items {
+			for _, container := range pod.Spec.Containers {
+				for _, variable := range container.Env {
+					env[variable.Name] = variable.Value
+				}
+			}
+		}
+
+		pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.LabelCluster + "=" + args[0],
+		})
+		if err != nil {
+			return err
+		}
+		nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		platforms := nodePlatforms(nodes.Items, pods.Items)
+
+		registry := &registryClient{Client: &http.Client{Timeout: 30 * time.Second}}
+
+		out := cmd.OutOrStdout()
+		if outputEnum == util.PromCheck {
+			out = io.Discard
+		}
+		healthy := &promGauge{
+			Name: "pgo_check_images_healthy",
+			Help: "Whether an image can be used (1), cannot be used (0), or could not be verified (-1).",
+		}
+
+		writer := tabwriter.NewWriter(out, 0, 2, 2, ' ', 0)
+		_, _ = fmt.Fprintln(writer, "COMPONENT\tIMAGE\tARCHITECTURES\tRESULT")
+
+		components := clusterImages(cluster, env)
+		var failed int
+		for _, component := range components {
+			image := rewriteImage(component.Image, rewrites)
+			available, result := "-", ""
+
+			switch {
+			case image == "":
+				result = "no image; set it in the spec or the environment of the operator"
+			default:
+				found, err := registry.Platforms(ctx, image)
+				switch {
+				case errors.Is(err, errImageUnauthorized):
+					available, result = "-", "unverified: the registry requires credentials"
+				case err != nil:
+					result = err.Error()
+				default:
+					available = strings.Join(found, ", ")
+					result = missingPlatforms(found, platforms)
+				}
+			}
+
+			switch {
+			case result == "":
+				result = "ok"
+				healthy.add(1, "component", component.Name, "image", image)
+			case strings.HasPrefix(result, "unverified"):
+				healthy.add(-1, "component", component.Name, "image", image)
+			default:
+				failed++
+				healthy.add(0, "component", component.Name, "image", image)
+			}
+			if image == "" {
+				image = "-"
+			}
+			_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", component.Name, image, available, result)
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		if outputEnum == util.PromCheck {
+			if err := writePromGauges(cmd.OutOrStdout(),
+				[]string{"cluster", args[0], "namespace", namespace},
+				healthy, checkTimestampGauge("images", time.Now())); err != nil {
+				return err
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d of %d images cannot be used", failed, len(components))
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// clusterImage is one image that a component of a cluster runs.
+type clusterImage struct {
+	Name  string
+	Image string
+}
+
+// clusterImages returns the images that cluster uses. Images not set in the
+// spec come from env, the environment of the operator.
+func clusterImages(cluster *unstructured.Unstructured, env map[string]string) []clusterImage {
+	image := func(fallback string, path ...string) string {
+		if value, _, _ := unstructured.NestedString(cluster.Object, append(path, "image")...); value != "" {
+			return value
+		}
+		return env[fallback]
+	}
+
+	postgres, _, _ := unstructured.NestedInt64(cluster.Object, "spec", "postgresVersion")
+	postgis, _, _ := unstructured.NestedString(cluster.Object, "spec", "postGISVersion")
+	postgresEnv := fmt.Sprintf("RELATED_IMAGE_POSTGRES_%d", postgres)
+	if postgis != "" {
+		postgresEnv += "_GIS_" + postgis
+	}
+
+	images := []clusterImage{
+		{Name: "postgres", Image: image(postgresEnv, "spec")},
+		{Name: "pgbackrest", Image: image("RELATED_IMAGE_PGBACKREST", "spec", "backups", "pgbackrest")},
+	}
+	if _, found, _ := unstructured.NestedMap(cluster.Object, "spec", "proxy", "pgBouncer"); found {
+		images = append(images, clusterImage{Name: "pgbouncer",
+			Image: image("RELATED_IMAGE_PGBOUNCER", "spec", "proxy", "pgBouncer")})
+	}
+	if _, found, _ := unstructured.NestedMap(cluster.Object,
+		"spec", "monitoring", "pgmonitor", "exporter"); found {
+		images = append(images, clusterImage{Name: "exporter",
+			Image: image("RELATED_IMAGE_PGEXPORTER", "spec", "monitoring", "pgmonitor", "exporter")})
+	}
+	return images
+}
+
+// imageMirror replaces the Source prefix of image names with Mirror.
+type imageMirror struct{ Source, Mirror string }
+
+// parseImageMirrors reads the values of "--mirror". Longer sources come first
+// so that the most specific mirror applies.
+func parseImageMirrors(values []string) ([]imageMirror, error) {
+	mirrors := make([]imageMirror, 0, len(values))
+	for _, value := range values {
+		source, mirror, ok := strings.Cut(value, "=")
+		if !ok || source == "" || mirror == "" {
+			return nil, fmt.Errorf("invalid --mirror %q: expected SOURCE=MIRROR", value)
+		}
+		mirrors = append(mirrors, imageMirror{
+			Source: strings.TrimSuffix(source, "/"),
+			Mirror: strings.TrimSuffix(mirror, "/"),
+		})
+	}
+	sort.SliceStable(mirrors, func(i, j int) bool {
+		return len(mirrors[i].Source) > len(mirrors[j].Source)
+	})
+	return mirrors, nil
+}
+
+// rewriteImage returns image with the first matching mirror applied. A source
+// matches whole parts of the name: a registry, a repository, or both.
+func rewriteImage(image string, mirrors []imageMirror) string {
+	for _, mirror := range mirrors {
+		if rest, ok := strings.CutPrefix(image, mirror.Source); ok &&
+			(rest == "" || strings.ContainsAny(rest[:1], "/:@")) {
+			return mirror.Mirror + rest
+		}
+	}
+	return image
+}
+
+// nodePlatforms returns the "os/arch" of every node keyed by platform. Only the
+// nodes that run pods are considered, unless pods run on none of them.
+func nodePlatforms(nodes []corev1.Node, pods []corev1.Pod) map[string][]string {
+	used := map[string]bool{}
+	for _, pod := range pods {
+		if pod.Spec.NodeName != "" {
+			used[pod.Spec.NodeName] = true
+		}
+	}
+
+	platforms := map[string][]string{}
+	for _, node := range nodes {
+		if len(used) > 0 && !used[node.Name] {
+			continue
+		}
+		info := node.Status.NodeInfo
+		platform := info.OperatingSystem + "/" + info.Architecture
+		platforms[platform] = append(platforms[platform], node.Name)
+	}
+	return platforms
+}
+
+// missingPlatforms describes the platforms of nodes that are not in available,
+// or returns an empty string when the image runs on all of them.
+func missingPlatforms(available []string, nodes map[string][]string) string {
+	have := map[string]bool{}
+	for _, platform := range available {
+		have[platform] = true
+	}
+
+	var missing []string
+	for platform, names := range nodes {
+		if !have[platform] {
+			sort.Strings(names)
+			missing = append(missing, fmt.Sprintf("no %s for nodes %s",
+				platform, strings.Join(names, ", ")))
+		}
+	}
+	sort.Strings(missing)
+	return strings.Join(missing, "; ")
+}
+
+// imageReference is an image name split into the parts of a registry request.
+type imageReference struct {
+	Scheme     string
+	Registry   string
+	Repository string
+
+	// Reference is a tag or a digest.
+	Reference string
+}
+
+// imageNameRegistry matches the first part of an image name when it is a
+// registry host rather than part of the repository.
+var imageNameRegistry = regexp.MustCompile(`^(localhost|.*[.:].*)$`)
+
+// parseImageReference splits image the way container runtimes do. Images
+// without a registry come from Docker Hub.
+func parseImageReference(image string) (imageReference, error) {
+	ref := imageReference{Scheme: "https"}
+	if rest, ok := strings.CutPrefix(image, "http://"); ok {
+		ref.Scheme, image = "http", rest
+	}
+
+	name, digest, hasDigest := strings.Cut(image, "@")
+	if first, rest, ok := strings.Cut(name, "/"); ok && imageNameRegistry.MatchString(first) {
+		ref.Registry, name = first, rest
+	} else {
+		ref.Registry = "registry-1.docker.io"
+		if !strings.Contains(name, "/") {
+			name = "library/" + name
+		}
+	}
+
+	ref.Repository, ref.Reference = name, "latest"
+	if i := strings.LastIndex(name, ":"); i > 0 {
+		ref.Repository, ref.Reference = name[:i], name[i+1:]
+	}
+	if hasDigest {
+		ref.Reference = digest
+	}
+
+	if ref.Repository == "" || ref.Reference == "" {
+		return ref, fmt.Errorf("invalid image %q", image)
+	}
+	return ref, nil
+}
+
+var (
+	errImageNotFound     = errors.New("not found in the registry")
+	errImageUnauthorized = errors.New("the registry requires credentials")
+)
+
+// registryClient reads image manifests using the OCI distribution API.
+// - https://github.com/opencontainers/distribution-spec/blob/main/spec.md
+type registryClient struct {
+	Client *http.Client
+}
+
+// manifestMediaTypes are the manifest formats the client understands.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Platforms returns the sorted "os/arch" platforms of image.
+func (registry *registryClient) Platforms(ctx context.Context, image string) ([]string, error) {
+	ref, err := parseImageReference(image)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest struct {
+		Manifests []struct {
+			Platform struct {
+				OS           string `json:"os"`
+				Architecture string `json:"architecture"`
+			} `json:"platform"`
+		} `json:"manifests"`
+		Config struct {
+			Digest string `json:"digest"`
+		} `json:"config"`
+	}
+	if err := registry.get(ctx, ref, "manifests/"+ref.Reference, &manifest); err != nil {
+		return nil, err
+	}
+
+	var platforms []string
+	for _, entry := range manifest.Manifests {
+		// Attestations are listed with an unknown platform.
+		if entry.Platform.OS != "unknown" {
+			platforms = append(platforms, entry.Platform.OS+"/"+entry.Platform.Architecture)
+		}
+	}
+
+	// A single manifest keeps its platform in the image config.
+	if len(manifest.Manifests) == 0 && manifest.Config.Digest != "" {
+		var config struct {
+			OS           string `json:"os"`
+			Architecture string `json:"architecture"`
+		}
+		if err := registry.get(ctx, ref, "blobs/"+manifest.Config.Digest, &config); err != nil {
+			return nil, err
+		}
+		platforms = append(platforms, config.OS+"/"+config.Architecture)
+	}
+
+	sort.Strings(platforms)
+	return platforms, nil
+}
+
+// Tags returns the tags of the repository of image. Registries that page
+// their tags return only the first page.
+func (registry *registryClient) Tags(ctx context.Context, image string) ([]string, error) {
+	ref, err := parseImageReference(image)
+	if err != nil {
+		return nil, err
+	}
+
+	var list struct {
+		Tags []string `json:"tags"`
+	}
+	if err := registry.get(ctx, ref, "tags/list", &list); err != nil {
+		return nil, err
+	}
+	return list.Tags, nil
+}
+
+// get decodes the JSON at path of the repository of ref into out. It follows
+// the anonymous token flow when the registry asks for one.
+func (registry *registryClient) get(ctx context.Context, ref imageReference, path string, out any) error {
+	location := (&url.URL{
+		Scheme: ref.Scheme, Host: ref.Registry,
+		Path: "/v2/" + ref.Repository + "/" + path,
+	}).String()
+
+	var token string
+	for attempt := 0; ; attempt++ {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+		if err != nil {
+			return err
+		}
+		request.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		response, err := registry.Client.Do(request)
+		if err != nil {
+			return err
+		}
+		body, err := io.ReadAll(io.LimitReader(response.Body, 4<<20))
+		_ = response.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case response.StatusCode == http.StatusOK:
+			return json.Unmarshal(body, out)
+		case response.StatusCode == http.StatusNotFound:
+			return errImageNotFound
+		case response.StatusCode == http.StatusUnauthorized && attempt == 0:
+			if token, err = registry.token(ctx, response.Header.Get("WWW-Authenticate")); err != nil {
+				return err
+			}
+		case response.StatusCode == http.StatusUnauthorized,
+			response.StatusCode == http.StatusForbidden:
+			return errImageUnauthorized
+		default:
+			return fmt.Errorf("the registry responded %s", response.Status)
+		}
+	}
+}
+
+// registryChallenge matches the parameters of a WWW-Authenticate header.
+var registryChallenge = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// token requests an anonymous token as described by the Bearer challenge.
+// - https://distribution.github.io/distribution/spec/auth/token/
+func (registry *registryClient) token(ctx context.Context, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", errImageUnauthorized
+	}
+
+	values := url.Values{}
+	var realm string
+	for _, match := range registryChallenge.FindAllStringSubmatch(params, -1) {
+		if match[1] == "realm" {
+			realm = match[2]
+		} else {
+			values.Set(match[1], match[2])
+		}
+	}
+	if realm == "" {
+		return "", errImageUnauthorized
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, realm+"?"+values.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	response, err := registry.Client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", errImageUnauthorized
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.Token == "" {
+		body.Token = body.AccessToken
+	}
+	return body.Token, nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseImageReference(t *testing.T) {
+	for _, tt := range []struct {
+		image  string
+		expect imageReference
+	}{
+		{"postgres", imageReference{"https", "registry-1.docker.io", "library/postgres", "latest"}},
+		{"bitnami/postgresql:16", imageReference{"https", "registry-1.docker.io", "bitnami/postgresql", "16"}},
+		{
+			"registry.developers.crunchydata.com/crunchydata/crunchy-postgres:ubi8-16.3-1",
+			imageReference{"https", "registry.developers.crunchydata.com", "crunchydata/crunchy-postgres", "ubi8-16.3-1"},
+		},
+		{
+			"http://mirror:5000/crunchydata/crunchy-pgbouncer:ubi8-1.22-1@sha256:abc",
+			imageReference{"http", "mirror:5000", "crunchydata/crunchy-pgbouncer", "sha256:abc"},
+		},
+		{"localhost/pgbackrest", imageReference{"https", "localhost", "pgbackrest", "latest"}},
+	} {
+		ref, err := parseImageReference(tt.image)
+		assert.NilError(t, err, tt.image)
+		assert.DeepEqual(t, ref, tt.expect)
+	}
+
+	_, err := parseImageReference("example.com/")
+	assert.ErrorContains(t, err, "invalid image")
+}
+
+func TestImageMirrors(t *testing.T) {
+	_, err := parseImageMirrors([]string{"registry.example.com"})
+	assert.ErrorContains(t, err, "expected SOURCE=MIRROR")
+
+	mirrors, err := parseImageMirrors([]string{
+		"registry.developers.crunchydata.com=mirror:5000/",
+		"registry.developers.crunchydata.com/crunchydata/crunchy-pgbouncer=http://other/bouncer",
+	})
+	assert.NilError(t, err)
+
+	assert.Equal(t, rewriteImage(
+		"registry.developers.crunchydata.com/crunchydata/crunchy-postgres:ubi8-16.3-1", mirrors),
+		"mirror:5000/crunchydata/crunchy-postgres:ubi8-16.3-1")
+	assert.Equal(t, rewriteImage(
+		"registry.developers.crunchydata.com/crunchydata/crunchy-pgbouncer:ubi8-1.22-1", mirrors),
+		"http://other/bouncer:ubi8-1.22-1")
+	assert.Equal(t, rewriteImage("registry.developers.crunchydata.community/x", mirrors),
+		"registry.developers.crunchydata.community/x")
+}
+
+func TestClusterImages(t *testing.T) {
+	cluster := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{
+			"postgresVersion": int64(16),
+			"postGISVersion":  "3.4",
+			"proxy":           map[string]any{"pgBouncer": map[string]any{"image": "custom-bouncer"}},
+			"monitoring": map[string]any{
+				"pgmonitor": map[string]any{"exporter": map[string]any{}},
+			},
+		},
+	}}
+
+	images := clusterImages(cluster, map[string]string{
+		"RELATED_IMAGE_POSTGRES_16_GIS_3.4": "postgis-16",
+		"RELATED_IMAGE_PGBACKREST":          "pgbackrest",
+		"RELATED_IMAGE_PGBOUNCER":           "pgbouncer",
+	})
+	assert.DeepEqual(t, images, []clusterImage{
+		{Name: "postgres", Image: "postgis-16"},
+		{Name: "pgbackrest", Image: "pgbackrest"},
+		{Name: "pgbouncer", Image: "custom-bouncer"},
+		{Name: "exporter", Image: ""},
+	})
+}
+
+func TestNodePlatforms(t *testing.T) {
+	node := func(name, arch string) corev1.Node {
+		node := corev1.Node{}
+		node.Name = name
+		node.Status.NodeInfo.OperatingSystem = "linux"
+		node.Status.NodeInfo.Architecture = arch
+		return node
+	}
+	nodes := []corev1.Node{node("a", "amd64"), node("b", "arm64"), node("c", "arm64")}
+
+	assert.DeepEqual(t, nodePlatforms(nodes, nil), map[string][]string{
+		"linux/amd64": {"a"}, "linux/arm64": {"b", "c"},
+	})
+
+	pod := corev1.Pod{}
+	pod.Spec.NodeName = "c"
+	assert.DeepEqual(t, nodePlatforms(nodes, []corev1.Pod{pod, {}}), map[string][]string{
+		"linux/arm64": {"c"},
+	})
+
+	assert.Equal(t, missingPlatforms([]string{"linux/amd64", "linux/arm64"}, nodePlatforms(nodes, nil)), "")
+	assert.Equal(t, missingPlatforms([]string{"linux/amd64"}, nodePlatforms(nodes, nil)),
+		"no linux/arm64 for nodes b, c")
+}
+
+func TestRegistryClientPlatforms(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			assert.Equal(t, r.URL.Query().Get("scope"), "repository:crunchydata/index:pull")
+			_, _ = w.Write([]byte(`{"token":"secret"}`))
+
+		case r.Header.Get("Authorization") != "Bearer secret":
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+
+				`/token",service="registry",scope="repository:crunchydata/index:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+
+		case r.URL.Path == "/v2/crunchydata/index/manifests/v1":
+			assert.Assert(t, strings.Contains(r.Header.Get("Accept"), "image.index"))
+			_, _ = w.Write([]byte(`{"manifests":[
+				{"platform":{"os":"linux","architecture":"arm64"}},
+				{"platform":{"os":"linux","architecture":"amd64"}},
+				{"platform":{"os":"unknown","architecture":"unknown"}}]}`))
+
+		case r.URL.Path == "/v2/crunchydata/index/manifests/single":
+			_, _ = w.Write([]byte(`{"config":{"digest":"sha256:abc"}}`))
+		case r.URL.Path == "/v2/crunchydata/index/blobs/sha256:abc":
+			_, _ = w.Write([]byte(`{"os":"linux","architecture":"amd64"}`))
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	registry := &registryClient{Client: server.Client()}
+	image := "http://" + strings.TrimPrefix(server.URL, "http://") + "/crunchydata/index:"
+
+	platforms, err := registry.Platforms(context.Background(), image+"v1")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, platforms, []string{"linux/amd64", "linux/arm64"})
+
+	platforms, err = registry.Platforms(context.Background(), image+"single")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, platforms, []string{"linux/amd64"})
+
+	_, err = registry.Platforms(context.Background(), image+"missing")
+	assert.ErrorIs(t, err, errImageNotFound)
+}
@@ -0,0 +1,474 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newCheckReadyCommand returns the ready-for-release subcommand of the check
+// command. It exits with an error unless a cluster is healthy enough to
+// deploy changes that touch the database.
+func newCheckReadyCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ready-for-release CLUSTER_NAME",
+		Short: "Check that a PostgresCluster is healthy enough for a release",
+		Long: `Check a PostgresCluster before a release that touches its database, such as in
+a continuous deployment pipeline. The command fails when any check fails:
+  - backup: every repository has a successful backup newer than "--max-backup-age"
+  - replication: every instance is a member of the cluster, and every replica
+    is streaming within "--max-replica-lag-mb" of the primary
+  - archive: WAL archiving is not failing and fewer than "--max-archive-pending"
+    WAL files wait to be archived
+  - restart: no instance has a pending restart from a changed setting
+  - reconcile: PGO is not paused by "pgo pause reconcile"
+
+Use "--output=json" for a result that scripts can read. Use "--output=prom" for
+gauges in the Prometheus text format, such as for the textfile collector of
+node_exporter; the command still fails when a check fails.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    pods                                                [list]
+    pods/exec                                           [create]
+    postgresclusters.postgres-operator.crunchydata.com  [get]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Check the 'hippo' postgrescluster before a release
+pgo check ready-for-release hippo --max-backup-age=12h
+
+### Example output
+CHECK        RESULT  DETAIL
+backup       ok      repo1: incr backup 20240501-060001F_20240501-120002I finished 3h12m0s ago
+replication  ok      hippo-instance1-8dcl-0: streaming, 0 MB behind
+replication  FAILED  hippo-instance1-wkq2-0: not a member of the cluster
+archive      ok      0 WAL files waiting; last archived 2024-05-01 15:11:48+00
+restart      ok      no pending restarts
+reconcile    ok      reconciliation is not paused
+Error: 1 of 6 checks failed`)
+
+	var (
+		maxBackupAge      time.Duration
+		maxReplicaLag     int64
+		maxArchivePending int
+		outputEnum        = util.TextReadiness
+	)
+	cmd.Flags().DurationVar(&maxBackupAge, "max-backup-age", 24*time.Hour,
+		"age of the latest backup of each repository above which the check fails")
+	cmd.Flags().Int64Var(&maxReplicaLag, "max-replica-lag-mb", 16,
+		"megabytes a replica can be behind the primary")
+	cmd.Flags().IntVar(&maxArchivePending, "max-archive-pending", 10,
+		"WAL files waiting to be archived at which the check fails")
+	cmd.Flags().VarP(&outputEnum, "output", "o", "output format. types supported: text,json,prom")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		now := time.Now()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		_, clusterClient, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+
+		cluster, err := clusterClient.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.DBInstanceLabels(args[0]),
+		})
+		if err != nil {
+			return err
+		}
+
+		var primary *corev1.Pod
+		for i := range pods.Items {
+			if pods.Items[i].GetLabels()[util.LabelRole] == util.RolePatroniLeader &&
+				podIsReady(&pods.Items[i]) {
+				primary = &pods.Items[i]
+			}
+		}
+
+		var checks []readinessCheck
+		if primary == nil {
+			checks = append(checks, readinessCheck{Name: "primary",
+				Detail: "no ready primary instance Pod found"})
+		} else {
+			exec := podexec.Container(podExec, namespace, primary.GetName(), util.ContainerDatabase)
+
+			stdout, stderr, err := podexec.PGBackRestInfo(exec, "")
+			checks = append(checks, checkBackupFreshness(
+				clusterRepoNames(cluster), stdout, commandError(err, stderr), maxBackupAge, now)...)
+
+			members, stderr, err := podexec.Patronictl(exec, "list", "json")
+			membersErr := commandError(err, stderr)
+			checks = append(checks, checkReplication(pods.Items, members, membersErr, maxReplicaLag)...)
+
+			stdout, stderr, err = podexec.PSQL(exec, "", archiveStatusSQL)
+			checks = append(checks, checkArchiving(stdout, commandError(err, stderr), maxArchivePending))
+			checks = append(checks, checkPendingRestarts(members, membersErr))
+		}
+		checks = append(checks, checkReconciliation(clusterPaused(cluster), now))
+
+		switch outputEnum {
+		case util.JSONReadiness:
+			b, err := json.MarshalIndent(checks, "", "  ")
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(b))
+		case util.PromReadiness:
+			if err := writePromGauges(cmd.OutOrStdout(),
+				[]string{"cluster", args[0], "namespace", namespace},
+				append(readinessGauges(checks), checkTimestampGauge("ready-for-release", now))...); err != nil {
+				return err
+			}
+		default:
+			if err := printReadinessChecks(cmd, checks); err != nil {
+				return err
+			}
+		}
+
+		var failed int
+		for _, check := range checks {
+			if !check.Healthy {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// readinessCheck is the result of one check of a cluster before a release.
+type readinessCheck struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	Detail  string `json:"detail"`
+
+	// Subject is the repository or instance that was checked, if any.
+	Subject string `json:"subject,omitempty"`
+
+	// Value is what the check measured, when it could, in the unit of its
+	// threshold: seconds, megabytes, or WAL files.
+	Value *float64 `json:"value,omitempty"`
+}
+
+// measured sets the Value of check.
+func (check *readinessCheck) measured(value float64) { check.Value = &value }
+
+// commandError combines the error of a command run in a Pod with its stderr.
+func commandError(err error, stderr string) error {
+	if err != nil && strings.TrimSpace(stderr) != "" {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr))
+	}
+	return err
+}
+
+// checkBackupFreshness checks that each of repos has a backup newer than
+// maxAge in the output of "pgbackrest info --output=json".
+func checkBackupFreshness(
+	repos []string, stdout string, err error, maxAge time.Duration, now time.Time,
+) []readinessCheck {
+	if err != nil {
+		return []readinessCheck{{Name: "backup", Detail: err.Error()}}
+	}
+	var stanzas []pgBackRestStanza
+	if err := json.Unmarshal([]byte(stdout), &stanzas); err != nil {
+		return []readinessCheck{{Name: "backup", Detail: err.Error()}}
+	}
+
+	latest := map[int]pgBackRestBackup{}
+	for _, stanza := range stanzas {
+		for _, backup := range stanza.Backup {
+			key := backup.Database.RepoKey
+			if !backup.Error && backup.Timestamp.Stop > latest[key].Timestamp.Stop {
+				latest[key] = backup
+			}
+		}
+	}
+
+	checks := make([]readinessCheck, 0, len(repos))
+	for _, repo := range repos {
+		check := readinessCheck{Name: "backup", Subject: repo}
+		key, _ := strconv.Atoi(strings.TrimPrefix(repo, "repo"))
+		backup, ok := latest[key]
+		age := now.Sub(time.Unix(backup.Timestamp.Stop, 0)).Round(time.Second)
+		if ok {
+			check.measured(age.Seconds())
+		}
+		switch {
+		case !ok:
+			check.Detail = repo + ": no successful backup"
+		case age > maxAge:
+			check.Detail = fmt.Sprintf("%s: latest backup %s finished %s ago, more than %s",
+				repo, backup.Label, age, maxAge)
+		default:
+			check.Healthy = true
+			check.Detail = fmt.Sprintf("%s: %s backup %s finished %s ago",
+				repo, backup.Type, backup.Label, age)
+		}
+		checks = append(checks, check)
+	}
+	if len(checks) == 0 {
+		checks = append(checks, readinessCheck{Name: "backup", Detail: "no repositories are defined"})
+	}
+	return checks
+}
+
+// patroniMember is one member in the output of "patronictl list --format json".
+type patroniMember struct {
+	Member         string         `json:"Member"`
+	Role           string         `json:"Role"`
+	State          string         `json:"State"`
+	Timeline       any            `json:"TL"`
+	Lag            any            `json:"Lag in MB"`
+	PendingRestart string         `json:"Pending restart"`
+	Tags           map[string]any `json:"Tags"`
+}
+
+// parsePatroniMembers decodes the output of "patronictl list --format json".
+func parsePatroniMembers(stdout string) ([]patroniMember, error) {
+	var members []patroniMember
+	err := json.Unmarshal([]byte(stdout), &members)
+	return members, err
+}
+
+// checkReplication checks that every Pod in pods is a member in the output of
+// "patronictl list --format json" and that replicas are at most maxLag
+// megabytes behind.
+func checkReplication(pods []corev1.Pod, stdout string, err error, maxLag int64) []readinessCheck {
+	members, parseErr := parsePatroniMembers(stdout)
+	if err == nil {
+		err = parseErr
+	}
+	if err != nil {
+		return []readinessCheck{{Name: "replication", Detail: err.Error()}}
+	}
+
+	byName := map[string]patroniMember{}
+	for _, member := range members {
+		byName[member.Member] = member
+	}
+
+	names := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		names = append(names, pod.GetName())
+	}
+	sort.Strings(names)
+
+	var checks []readinessCheck
+	for _, name := range names {
+		check := readinessCheck{Name: "replication", Subject: name}
+		member, ok := byName[name]
+		lag, known := patroniNumber(member.Lag)
+		if ok && known {
+			check.measured(float64(lag))
+		}
+		switch {
+		case !ok:
+			check.Detail = name + ": not a member of the cluster"
+		case member.Role == "Leader":
+			continue
+		case member.State != "streaming" && member.State != "running":
+			check.Detail = fmt.Sprintf("%s: %s", name, member.State)
+		case !known:
+			check.Detail = name + ": lag is unknown"
+		case lag > maxLag:
+			check.Detail = fmt.Sprintf("%s: %d MB behind, more than %d MB", name, lag, maxLag)
+		default:
+			check.Healthy = true
+			check.Detail = fmt.Sprintf("%s: %s, %d MB behind", name, member.State, lag)
+		}
+		checks = append(checks, check)
+	}
+	if len(checks) == 0 {
+		checks = append(checks, readinessCheck{Name: "replication", Healthy: true,
+			Detail: "no replicas"})
+	}
+	return checks
+}
+
+// patroniNumber reads a number field of a member, such as "Lag in MB" or
+// "TL". Patroni prints text in place of numbers it does not know.
+func patroniNumber(value any) (int64, bool) {
+	switch value := value.(type) {
+	case float64:
+		return int64(value), true
+	case string:
+		lag, err := strconv.ParseInt(value, 10, 64)
+		return lag, err == nil
+	}
+	return 0, false
+}
+
+// checkPendingRestarts checks that no member in the output of "patronictl
+// list --format json" waits to be restarted.
+func checkPendingRestarts(stdout string, err error) readinessCheck {
+	members, parseErr := parsePatroniMembers(stdout)
+	if err == nil {
+		err = parseErr
+	}
+	if err != nil {
+		return readinessCheck{Name: "restart", Detail: err.Error()}
+	}
+
+	var pending []string
+	for _, member := range members {
+		if member.PendingRestart != "" {
+			pending = append(pending, member.Member)
+		}
+	}
+	check := readinessCheck{Name: "restart", Healthy: true, Detail: "no pending restarts"}
+	check.measured(float64(len(pending)))
+	if len(pending) > 0 {
+		sort.Strings(pending)
+		check.Healthy, check.Detail = false, "pending restart: "+strings.Join(pending, ", ")
+	}
+	return check
+}
+
+// checkReconciliation checks that PGO is reconciling the cluster. A forgotten
+// pause keeps every later change to the cluster from taking effect.
+func checkReconciliation(pause clusterPause, now time.Time) readinessCheck {
+	check := readinessCheck{Name: "reconcile", Healthy: !pause.Paused, Detail: pause.status(now)}
+	if pause.Paused && !pause.Since.IsZero() {
+		check.measured(now.Sub(pause.Since).Round(time.Second).Seconds())
+	}
+	return check
+}
+
+// archiveStatusSQL reports whether the last attempt to archive WAL failed, how
+// many WAL files wait to be archived, and when WAL was last archived.
+const archiveStatusSQL = `SELECT
+  COALESCE(last_failed_time > COALESCE(last_archived_time, '-infinity'), false),
+  (SELECT count(*) FROM pg_catalog.pg_ls_archive_statusdir() WHERE name LIKE '%.ready'),
+  COALESCE(last_archived_time::text, ''), COALESCE(last_failed_wal, '')
+FROM pg_catalog.pg_stat_archiver;`
+
+// checkArchiving checks the output of [archiveStatusSQL].
+func checkArchiving(stdout string, err error, maxPending int) readinessCheck {
+	check := readinessCheck{Name: "archive"}
+	if err != nil {
+		check.Detail = err.Error()
+		return check
+	}
+	rows := parseRows(stdout)
+	if len(rows) != 1 || len(rows[0]) != 4 {
+		check.Detail = "unable to read pg_stat_archiver"
+		return check
+	}
+	failing, last, wal := rows[0][0] == "t", rows[0][2], rows[0][3]
+	pending, _ := strconv.Atoi(rows[0][1])
+	check.measured(float64(pending))
+	if last == "" {
+		last = "never"
+	}
+
+	switch {
+	case failing:
+		check.Detail = fmt.Sprintf("archiving %s is failing; last archived %s", wal, last)
+	case pending >= maxPending:
+		check.Detail = fmt.Sprintf("%d WAL files waiting, at least %d; last archived %s",
+			pending, maxPending, last)
+	default:
+		check.Healthy = true
+		check.Detail = fmt.Sprintf("%d WAL files waiting; last archived %s", pending, last)
+	}
+	return check
+}
+
+// readinessGauges returns checks and what they measured as gauges.
+func readinessGauges(checks []readinessCheck) []*promGauge {
+	healthy := &promGauge{
+		Name: "pgo_check_ready_healthy",
+		Help: "Whether a check of pgo check ready-for-release passed (1) or failed (0).",
+	}
+	measures := []struct {
+		check, label string
+		gauge        *promGauge
+	}{
+		{"backup", "repo", &promGauge{Name: "pgo_backup_age_seconds",
+			Help: "Seconds since the latest successful backup to a repository finished."}},
+		{"replication", "instance", &promGauge{Name: "pgo_replica_lag_megabytes",
+			Help: "Megabytes of WAL that a replica is behind the primary."}},
+		{"archive", "", &promGauge{Name: "pgo_archive_pending_wal_files",
+			Help: "WAL files waiting to be archived on the primary."}},
+		{"restart", "", &promGauge{Name: "pgo_pending_restart_instances",
+			Help: "Instances waiting to be restarted for a changed setting."}},
+		{"reconcile", "", &promGauge{Name: "pgo_reconcile_paused_seconds",
+			Help: "Seconds since PGO reconciliation of the cluster was paused."}},
+	}
+
+	gauges := []*promGauge{healthy}
+	for _, measure := range measures {
+		gauges = append(gauges, measure.gauge)
+	}
+	for _, check := range checks {
+		healthy.add(promBool(check.Healthy), "check", check.Name, "subject", check.Subject)
+		for _, measure := range measures {
+			if measure.check == check.Name && check.Value != nil {
+				measure.gauge.add(*check.Value, measure.label, check.Subject)
+			}
+		}
+	}
+	return gauges
+}
+
+// printReadinessChecks prints checks as a table.
+func printReadinessChecks(cmd *cobra.Command, checks []readinessCheck) error {
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "CHECK\tRESULT\tDETAIL")
+	for _, check := range checks {
+		result := "ok"
+		if !check.Healthy {
+			result = "FAILED"
+		}
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\n", check.Name, result, check.Detail)
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,163 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// measured returns a pointer to value for comparing [readinessCheck.Value].
+func measured(value float64) *float64 { return &value }
+
+func TestCheckBackupFreshness(t *testing.T) {
+	now := time.Unix(1714560000, 0)
+	info := `[{"name":"db","backup":[
+{"label":"20240501-060001F","type":"full","error":false,"database":{"repo-key":1},"timestamp":{"start":1714520000,"stop":1714520100}},
+{"label":"20240501-060001F_20240501-120002I","type":"incr","error":false,"database":{"repo-key":1},"timestamp":{"start":1714548000,"stop":1714548480}},
+{"label":"20240502-000000F","type":"full","error":true,"database":{"repo-key":1},"timestamp":{"start":1714550000,"stop":1714559000}},
+{"label":"20240420-000000F","type":"full","error":false,"database":{"repo-key":2},"timestamp":{"start":1713571200,"stop":1713571300}}
+]}]`
+
+	checks := checkBackupFreshness([]string{"repo1", "repo2", "repo3"}, info, nil, 24*time.Hour, now)
+	assert.DeepEqual(t, checks, []readinessCheck{
+		{Name: "backup", Healthy: true, Subject: "repo1", Value: measured(11520),
+			Detail: "repo1: incr backup 20240501-060001F_20240501-120002I finished 3h12m0s ago"},
+		{Name: "backup", Subject: "repo2", Value: measured(988700),
+			Detail: "repo2: latest backup 20240420-000000F finished 274h38m20s ago, more than 24h0m0s"},
+		{Name: "backup", Subject: "repo3", Detail: "repo3: no successful backup"},
+	})
+
+	assert.DeepEqual(t, checkBackupFreshness(nil, "[]", nil, time.Hour, now), []readinessCheck{
+		{Name: "backup", Detail: "no repositories are defined"},
+	})
+	assert.DeepEqual(t, checkBackupFreshness(nil, "", errors.New("boom"), time.Hour, now), []readinessCheck{
+		{Name: "backup", Detail: "boom"},
+	})
+}
+
+func TestCheckReplication(t *testing.T) {
+	pods := make([]corev1.Pod, 4)
+	for i, name := range []string{"hippo-a-0", "hippo-b-0", "hippo-c-0", "hippo-d-0"} {
+		pods[i].Name = name
+	}
+	members := `[
+{"Cluster":"hippo-ha","Member":"hippo-a-0","Role":"Leader","State":"running","TL":1},
+{"Cluster":"hippo-ha","Member":"hippo-b-0","Role":"Replica","State":"streaming","TL":1,"Lag in MB":0},
+{"Cluster":"hippo-ha","Member":"hippo-c-0","Role":"Replica","State":"streaming","TL":1,"Lag in MB":120,"Pending restart":"*"}
+]`
+
+	assert.DeepEqual(t, checkReplication(pods, members, nil, 16), []readinessCheck{
+		{Name: "replication", Healthy: true, Subject: "hippo-b-0", Value: measured(0),
+			Detail: "hippo-b-0: streaming, 0 MB behind"},
+		{Name: "replication", Subject: "hippo-c-0", Value: measured(120),
+			Detail: "hippo-c-0: 120 MB behind, more than 16 MB"},
+		{Name: "replication", Subject: "hippo-d-0", Detail: "hippo-d-0: not a member of the cluster"},
+	})
+	assert.DeepEqual(t, checkReplication(pods[:1], members, nil, 16), []readinessCheck{
+		{Name: "replication", Healthy: true, Detail: "no replicas"},
+	})
+
+	assert.DeepEqual(t, checkPendingRestarts(members, nil), readinessCheck{
+		Name: "restart", Detail: "pending restart: hippo-c-0", Value: measured(1),
+	})
+	assert.DeepEqual(t, checkPendingRestarts("[]", nil), readinessCheck{
+		Name: "restart", Healthy: true, Detail: "no pending restarts", Value: measured(0),
+	})
+}
+
+func TestPatroniNumber(t *testing.T) {
+	lag, ok := patroniNumber(float64(3))
+	assert.Assert(t, ok)
+	assert.Equal(t, lag, int64(3))
+
+	_, ok = patroniNumber("unknown")
+	assert.Assert(t, !ok)
+
+	_, ok = patroniNumber(nil)
+	assert.Assert(t, !ok)
+}
+
+func TestCheckArchiving(t *testing.T) {
+	assert.DeepEqual(t, checkArchiving("f\t0\t2024-05-01 15:11:48+00\t\n", nil, 10), readinessCheck{
+		Name: "archive", Healthy: true, Value: measured(0),
+		Detail: "0 WAL files waiting; last archived 2024-05-01 15:11:48+00",
+	})
+	assert.DeepEqual(t, checkArchiving("f\t12\t\t\n", nil, 10), readinessCheck{
+		Name: "archive", Value: measured(12),
+		Detail: "12 WAL files waiting, at least 10; last archived never",
+	})
+	assert.DeepEqual(t, checkArchiving("t\t3\t2024-05-01 15:11:48+00\t000000010000000000000004\n", nil, 10),
+		readinessCheck{
+			Name: "archive", Value: measured(3),
+			Detail: "archiving 000000010000000000000004 is failing; last archived 2024-05-01 15:11:48+00",
+		})
+	assert.DeepEqual(t, checkArchiving("", nil, 10), readinessCheck{
+		Name: "archive", Detail: "unable to read pg_stat_archiver",
+	})
+}
+
+func TestPrintReadinessChecks(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	assert.NilError(t, printReadinessChecks(cmd, []readinessCheck{
+		{Name: "backup", Healthy: true, Detail: "repo1: incr backup 20240501-060001F_20240501-120002I finished 3h12m0s ago"},
+		{Name: "replication", Healthy: true, Detail: "hippo-instance1-8dcl-0: streaming, 0 MB behind"},
+		{Name: "replication", Detail: "hippo-instance1-wkq2-0: not a member of the cluster"},
+		{Name: "archive", Healthy: true, Detail: "0 WAL files waiting; last archived 2024-05-01 15:11:48+00"},
+		{Name: "restart", Healthy: true, Detail: "no pending restarts"},
+	}))
+	assert.Equal(t, out.String(), `CHECK        RESULT  DETAIL
+backup       ok      repo1: incr backup 20240501-060001F_20240501-120002I finished 3h12m0s ago
+replication  ok      hippo-instance1-8dcl-0: streaming, 0 MB behind
+replication  FAILED  hippo-instance1-wkq2-0: not a member of the cluster
+archive      ok      0 WAL files waiting; last archived 2024-05-01 15:11:48+00
+restart      ok      no pending restarts
+`)
+}
+
+func TestReadinessGauges(t *testing.T) {
+	var out bytes.Buffer
+	assert.NilError(t, writePromGauges(&out, []string{"cluster", "hippo", "namespace", "postgres"},
+		append(readinessGauges([]readinessCheck{
+			{Name: "backup", Healthy: true, Subject: "repo1", Value: measured(11520)},
+			{Name: "backup", Subject: "repo2"},
+			{Name: "replication", Subject: "hippo-c-0", Value: measured(120)},
+			{Name: "archive", Healthy: true, Value: measured(0)},
+			{Name: "restart", Healthy: true, Value: measured(0)},
+		}), checkTimestampGauge("ready-for-release", time.Unix(1714560000, 0)))...))
+
+	assert.Equal(t, out.String(), `# HELP pgo_check_ready_healthy Whether a check of pgo check ready-for-release passed (1) or failed (0).
+# TYPE pgo_check_ready_healthy gauge
+pgo_check_ready_healthy{check="backup",cluster="hippo",namespace="postgres",subject="repo1"} 1
+pgo_check_ready_healthy{check="backup",cluster="hippo",namespace="postgres",subject="repo2"} 0
+pgo_check_ready_healthy{check="replication",cluster="hippo",namespace="postgres",subject="hippo-c-0"} 0
+pgo_check_ready_healthy{check="archive",cluster="hippo",namespace="postgres"} 1
+pgo_check_ready_healthy{check="restart",cluster="hippo",namespace="postgres"} 1
+# HELP pgo_backup_age_seconds Seconds since the latest successful backup to a repository finished.
+# TYPE pgo_backup_age_seconds gauge
+pgo_backup_age_seconds{cluster="hippo",namespace="postgres",repo="repo1"} 11520
+# HELP pgo_replica_lag_megabytes Megabytes of WAL that a replica is behind the primary.
+# TYPE pgo_replica_lag_megabytes gauge
+pgo_replica_lag_megabytes{cluster="hippo",instance="hippo-c-0",namespace="postgres"} 120
+# HELP pgo_archive_pending_wal_files WAL files waiting to be archived on the primary.
+# TYPE pgo_archive_pending_wal_files gauge
+pgo_archive_pending_wal_files{cluster="hippo",namespace="postgres"} 0
+# HELP pgo_pending_restart_instances Instances waiting to be restarted for a changed setting.
+# TYPE pgo_pending_restart_instances gauge
+pgo_pending_restart_instances{cluster="hippo",namespace="postgres"} 0
+# HELP pgo_check_timestamp_seconds When a pgo check command last ran, in seconds since the Unix epoch.
+# TYPE pgo_check_timestamp_seconds gauge
+pgo_check_timestamp_seconds{check="ready-for-release",cluster="hippo",namespace="postgres"} 1714560000
+`)
+}
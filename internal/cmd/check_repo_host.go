@@ -0,0 +1,411 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newCheckRepoHostCommand returns the repo-host subcommand of the check
+// command. It tests the connections between instances and the repo host.
+func newCheckRepoHostCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "repo-host CLUSTER_NAME",
+		Short: "Check the connections between instances and the pgBackRest repo host",
+		Long: `Check that pgBackRest in the instances of a PostgresCluster and on its repo
+host can reach each other. Each leg is probed from the side that opens it,
+using the hosts, ports, and certificates in the pgBackRest config there:
+  - cert: the client certificate is signed by the CA and has not expired
+  - ping: "pgbackrest server-ping" reaches the TLS server on the other side
+  - tls: a TLS handshake with the client certificate verifies the server
+    certificate and its host name
+  - repo: "pgbackrest repo-ls" from an instance lists the repository through
+    the repo host
+  - path: the archive and backup paths of the stanza are writable on the repo
+    host
+  - ssh: for repositories reached over SSH, the port accepts connections
+
+Instances reach the repo host to push WAL; the repo host reaches the
+instances to take backups. Each failed check names the Pods on both ends.
+
+### RBAC Requirements
+    Resources  Verbs
+    ---------  -----
+    pods       [list]
+    pods/exec  [create]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Check pgBackRest connectivity of the 'hippo' postgrescluster
+pgo check repo-host hippo
+
+### Example output
+CHECK  RESULT  DETAIL
+cert   ok      hippo-instance1-8x7m-0: client certificate valid until Jan  2 15:04:05 2026 GMT
+ping   ok      hippo-instance1-8x7m-0 -> hippo-repo-host-0.hippo-pods.postgres-operator.svc.cluster.local.:8432: server responds
+tls    ok      hippo-instance1-8x7m-0 -> hippo-repo-host-0.hippo-pods.postgres-operator.svc.cluster.local.:8432: handshake verified
+repo   ok      hippo-instance1-8x7m-0 -> repo1: listed
+cert   ok      hippo-repo-host-0: client certificate valid until Jan  2 15:04:05 2026 GMT
+ping   FAILED  hippo-repo-host-0 -> hippo-instance1-8x7m-0.hippo-pods.postgres-operator.svc.cluster.local.:8432: unable to connect
+tls    FAILED  hippo-repo-host-0 -> hippo-instance1-8x7m-0.hippo-pods.postgres-operator.svc.cluster.local.:8432: Connection refused
+path   ok      hippo-repo-host-0: repo1 /pgbackrest/repo1
+Error: 2 of 8 checks failed`)
+
+	outputEnum := util.TextReadiness
+	cmd.Flags().VarP(&outputEnum, "output", "o", "output format. types supported: text,json,prom")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		now := time.Now()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+
+		running := func(selector string) ([]corev1.Pod, error) {
+			pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+			if err != nil {
+				return nil, err
+			}
+			var items []corev1.Pod
+			for _, pod := range pods.Items {
+				if pod.Status.Phase == corev1.PodRunning {
+					items = append(items, pod)
+				}
+			}
+			return items, nil
+		}
+		instances, err := running(util.DBInstanceLabels(args[0]))
+		if err != nil {
+			return err
+		}
+		hosts, err := running(util.RepoHostInstanceLabels(args[0]))
+		if err != nil {
+			return err
+		}
+		if len(hosts) == 0 {
+			return fmt.Errorf("no running repo host Pod found for cluster %s", args[0])
+		}
+
+		var checks []readinessCheck
+		for _, side := range []struct {
+			pods      []corev1.Pod
+			container string
+			repoHost  bool
+		}{
+			{instances, util.ContainerDatabase, false},
+			{hosts, util.ContainerPGBackrest, true},
+		} {
+			for _, pod := range side.pods {
+				exec := podexec.Container(podExec, namespace, pod.GetName(), side.container)
+				checks = append(checks, runRepoHostChecks(exec, pod.GetName(), side.repoHost)...)
+			}
+		}
+
+		switch outputEnum {
+		case util.JSONReadiness:
+			b, err := json.MarshalIndent(checks, "", "  ")
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(b))
+		case util.PromReadiness:
+			if err := writePromGauges(cmd.OutOrStdout(),
+				[]string{"cluster", args[0], "namespace", namespace},
+				repoHostGauges(checks), checkTimestampGauge("repo_host", now)); err != nil {
+				return err
+			}
+		default:
+			if err := printReadinessChecks(cmd, checks); err != nil {
+				return err
+			}
+		}
+
+		var failed int
+		for _, check := range checks {
+			if !check.Healthy {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// runRepoHostChecks probes the legs that start in pod using exec. On the repo
+// host, it checks the paths of its repositories, too.
+func runRepoHostChecks(exec podexec.Executor, pod string, repoHost bool) []readinessCheck {
+	stdout, stderr, err := podexec.Bash(exec, repoHostOptionsScript)
+	if err = commandError(err, stderr); err != nil {
+		return []readinessCheck{{Name: "config", Subject: pod,
+			Detail: pod + ": unable to read the pgBackRest config: " + err.Error()}}
+	}
+	options := parseArchiveOptions(stdout)
+
+	// Instances connect to repositories; the repo host connects to instances.
+	prefix := "repo"
+	if repoHost {
+		prefix = "pg"
+	}
+	probes := repoHostProbes(pod, repoHostRemotes(options, prefix))
+	if repoHost {
+		probes = append(probes, repoPathProbes(pod, options)...)
+	}
+	if len(probes) == 0 {
+		return nil
+	}
+
+	stdout, stderr, err = podexec.Bash(exec, repoHostProbeScript(probes))
+	return repoHostResults(probes, stdout, commandError(err, stderr))
+}
+
+// repoHostOptionsScript prints the options in the pgBackRest config files of
+// PGO that describe remote hosts and repository paths as "name=value" lines.
+const repoHostOptionsScript = `cat /etc/pgbackrest/pgbackrest.conf /etc/pgbackrest/conf.d/*.conf 2>/dev/null |
+  sed -n -E 's/^[[:space:]]*((repo|pg)[0-9]+-(host|host-port|host-type|host-ca-file|host-cert-file|host-key-file|path))[[:space:]]*=[[:space:]]*(.*[^[:space:]])[[:space:]]*$/\1=\4/p'`
+
+// repoHostRemote is a repository or instance that pgBackRest reaches on
+// another host.
+type repoHostRemote struct {
+	Key                 string
+	Host, Port, Type    string
+	CAFile, CertFile    string
+	KeyFile             string
+	UsesTLS, Repository bool
+}
+
+// repoHostOptionPattern matches the host option of a repository or instance,
+// such as "repo1-host" or "pg2-host".
+var repoHostOptionPattern = regexp.MustCompile(`^((?:repo|pg)[0-9]+)-host$`)
+
+// repoHostRemotes returns the remotes in options whose keys start with prefix,
+// in order. The defaults of pgBackRest fill the port and type.
+func repoHostRemotes(options archiveOptions, prefix string) []repoHostRemote {
+	var remotes []repoHostRemote
+	for name, host := range options {
+		match := repoHostOptionPattern.FindStringSubmatch(name)
+		if match == nil || !strings.HasPrefix(match[1], prefix) {
+			continue
+		}
+		key := match[1]
+		remote := repoHostRemote{
+			Key:        key,
+			Host:       host,
+			Port:       options[key+"-host-port"],
+			Type:       options[key+"-host-type"],
+			CAFile:     options[key+"-host-ca-file"],
+			CertFile:   options[key+"-host-cert-file"],
+			KeyFile:    options[key+"-host-key-file"],
+			Repository: prefix == "repo",
+		}
+		if remote.Type == "" {
+			remote.Type = "ssh"
+		}
+		remote.UsesTLS = remote.Type == "tls"
+		if remote.Port == "" && remote.UsesTLS {
+			remote.Port = "8432"
+		}
+		if remote.Port == "" {
+			remote.Port = "22"
+		}
+		remotes = append(remotes, remote)
+	}
+	sort.Slice(remotes, func(i, j int) bool { return remotes[i].Key < remotes[j].Key })
+	return remotes
+}
+
+// repoHostProbe is one command that tests a leg from a Pod.
+type repoHostProbe struct {
+	Name, Subject string
+	Command       string
+
+	// Leg describes what is tested. When OK is empty, the check reports the
+	// last line of output when the command succeeds.
+	Leg, OK string
+}
+
+// repoHostProbes returns the probes from pod to each of remotes. Remotes that
+// share a client certificate share its check.
+func repoHostProbes(pod string, remotes []repoHostRemote) []repoHostProbe {
+	var probes []repoHostProbe
+	certificates := map[string]bool{}
+
+	for _, remote := range remotes {
+		address := remote.Host + ":" + remote.Port
+		leg := pod + " -> " + address
+		subject := pod + "/" + remote.Key
+
+		// PGO uses fully qualified names, but certificates have no final dot.
+		name := strings.TrimSuffix(remote.Host, ".")
+
+		if !remote.UsesTLS {
+			probes = append(probes, repoHostProbe{
+				Name: "ssh", Subject: subject, Leg: leg, OK: "port accepts connections",
+				Command: fmt.Sprintf("timeout 10 bash -c %s",
+					shellQuote(fmt.Sprintf(": </dev/tcp/%s/%s", remote.Host, remote.Port))),
+			})
+			continue
+		}
+
+		if !certificates[remote.CertFile] {
+			certificates[remote.CertFile] = true
+			probes = append(probes, repoHostProbe{
+				Name: "cert", Subject: pod, Leg: pod,
+				Command: fmt.Sprintf(`openssl verify -CAfile %[1]s %[2]s &&
+openssl x509 -noout -checkend 0 -in %[2]s &&
+openssl x509 -noout -enddate -in %[2]s | sed 's/^notAfter=/client certificate valid until /'`,
+					shellQuote(remote.CAFile), shellQuote(remote.CertFile)),
+			})
+		}
+
+		probes = append(probes,
+			repoHostProbe{
+				Name: "ping", Subject: subject, Leg: leg, OK: "server responds",
+				Command: fmt.Sprintf("timeout 10 pgbackrest server-ping --tls-server-port=%s %s",
+					shellQuote(remote.Port), shellQuote(remote.Host)),
+			},
+			repoHostProbe{
+				Name: "tls", Subject: subject, Leg: leg, OK: "handshake verified",
+				Command: fmt.Sprintf("timeout 10 openssl s_client -brief -verify_return_error"+
+					" -connect %s -servername %[2]s -verify_hostname %[2]s"+
+					" -CAfile %s -cert %s -key %s </dev/null",
+					shellQuote(address), shellQuote(name),
+					shellQuote(remote.CAFile), shellQuote(remote.CertFile), shellQuote(remote.KeyFile)),
+			})
+
+		// Listing the repository authenticates with the client certificate,
+		// which the handshake above cannot confirm on every TLS version.
+		if remote.Repository {
+			probes = append(probes, repoHostProbe{
+				Name: "repo", Subject: subject, Leg: pod + " -> " + remote.Key, OK: "listed",
+				Command: fmt.Sprintf("timeout 30 pgbackrest --stanza=db --repo=%s repo-ls",
+					strings.TrimPrefix(remote.Key, "repo")),
+			})
+		}
+	}
+
+	return probes
+}
+
+// repoPathProbes returns the probes of the local repositories in options.
+func repoPathProbes(pod string, options archiveOptions) []repoHostProbe {
+	var probes []repoHostProbe
+	for name, path := range options {
+		key, ok := strings.CutSuffix(name, "-path")
+		if !ok || !strings.HasPrefix(key, "repo") || options[key+"-host"] != "" {
+			continue
+		}
+		probes = append(probes, repoHostProbe{
+			Name: "path", Subject: pod + "/" + key, Leg: pod, OK: key + " " + path,
+			Command: fmt.Sprintf(`for dir in %[1]s/archive/db %[1]s/backup/db; do
+  [ -d "${dir}" ] || dir=%[1]s
+  [ -w "${dir}" ] || { echo "${dir} is not writable"; exit 1; }
+done`, shellQuote(path)),
+		})
+	}
+	sort.Slice(probes, func(i, j int) bool { return probes[i].Subject < probes[j].Subject })
+	return probes
+}
+
+// repoHostProbeScript runs every probe and prints its result, its index, and
+// the last line of its output separated by tabs.
+func repoHostProbeScript(probes []repoHostProbe) string {
+	var script strings.Builder
+	for i, probe := range probes {
+		fmt.Fprintf(&script, `if output=$( { %s ; } 2>&1 ); then result=ok; else result=failed; fi
+printf '%%s\t%d\t%%s\n' "${result}" "$(printf '%%s\n' "${output}" | sed '/^[[:space:]]*$/d' | tail -n 1)"
+`, probe.Command, i)
+	}
+	return script.String()
+}
+
+// repoHostResults reads the output of [repoHostProbeScript] as checks. Probes
+// that print no result fail with err, if any.
+func repoHostResults(probes []repoHostProbe, stdout string, err error) []readinessCheck {
+	type result struct {
+		ok     bool
+		output string
+	}
+	results := map[int]result{}
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if i, parseErr := strconv.Atoi(fields[1]); parseErr == nil {
+			results[i] = result{ok: fields[0] == "ok", output: fields[2]}
+		}
+	}
+
+	checks := make([]readinessCheck, 0, len(probes))
+	for i, probe := range probes {
+		check := readinessCheck{Name: probe.Name, Subject: probe.Subject}
+		found, ok := results[i]
+		switch {
+		case !ok && err != nil:
+			check.Detail = probe.Leg + ": " + err.Error()
+		case !ok:
+			check.Detail = probe.Leg + ": no result"
+		case found.ok && probe.OK != "":
+			check.Healthy = true
+			check.Detail = probe.Leg + ": " + probe.OK
+		case found.ok:
+			check.Healthy = true
+			check.Detail = probe.Leg + ": " + found.output
+		default:
+			check.Detail = probe.Leg + ": " + found.output
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// repoHostGauges returns checks as gauges.
+func repoHostGauges(checks []readinessCheck) *promGauge {
+	healthy := &promGauge{
+		Name: "pgo_check_repo_host_healthy",
+		Help: "Whether a check of pgo check repo-host passed (1) or failed (0).",
+	}
+	for _, check := range checks {
+		healthy.add(promBool(check.Healthy), "check", check.Name, "subject", check.Subject)
+	}
+	return healthy
+}
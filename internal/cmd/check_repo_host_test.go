@@ -0,0 +1,137 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+)
+
+func TestRepoHostRemotes(t *testing.T) {
+	options := parseArchiveOptions("" +
+		"repo2-host=hippo-repo-host-0.hippo-pods.zoo.svc.cluster.local.\n" +
+		"repo2-host-type=tls\n" +
+		"repo2-host-ca-file=/etc/pgbackrest/conf.d/~postgres-operator/tls-ca.crt\n" +
+		"repo2-host-cert-file=/etc/pgbackrest/conf.d/~postgres-operator/client-tls.crt\n" +
+		"repo2-host-key-file=/etc/pgbackrest/conf.d/~postgres-operator/client-tls.key\n" +
+		"repo1-host=old-repo-host\n" +
+		"repo1-host-port=2022\n" +
+		"pg1-host=hippo-instance1-8x7m-0\n" +
+		"repo3-path=/pgbackrest/repo3\n")
+
+	assert.DeepEqual(t, repoHostRemotes(options, "repo"), []repoHostRemote{
+		{Key: "repo1", Host: "old-repo-host", Port: "2022", Type: "ssh", Repository: true},
+		{
+			Key: "repo2", Host: "hippo-repo-host-0.hippo-pods.zoo.svc.cluster.local.", Port: "8432", Type: "tls",
+			CAFile:   "/etc/pgbackrest/conf.d/~postgres-operator/tls-ca.crt",
+			CertFile: "/etc/pgbackrest/conf.d/~postgres-operator/client-tls.crt",
+			KeyFile:  "/etc/pgbackrest/conf.d/~postgres-operator/client-tls.key",
+			UsesTLS:  true, Repository: true,
+		},
+	})
+	assert.DeepEqual(t, repoHostRemotes(options, "pg"), []repoHostRemote{
+		{Key: "pg1", Host: "hippo-instance1-8x7m-0", Port: "22", Type: "ssh"},
+	})
+}
+
+func TestRepoHostProbes(t *testing.T) {
+	remote := repoHostRemote{
+		Key: "repo1", Host: "hippo-repo-host-0.hippo-pods.zoo.svc.cluster.local.", Port: "8432",
+		Type: "tls", CAFile: "/ca.crt", CertFile: "/client.crt", KeyFile: "/client.key",
+		UsesTLS: true, Repository: true,
+	}
+	other := remote
+	other.Key = "repo2"
+
+	probes := repoHostProbes("hippo-instance1-8x7m-0", []repoHostRemote{remote, other})
+
+	var names []string
+	for _, probe := range probes {
+		names = append(names, probe.Name+":"+probe.Subject)
+	}
+	assert.DeepEqual(t, names, []string{
+		// The client certificate is checked once.
+		"cert:hippo-instance1-8x7m-0",
+		"ping:hippo-instance1-8x7m-0/repo1", "tls:hippo-instance1-8x7m-0/repo1", "repo:hippo-instance1-8x7m-0/repo1",
+		"ping:hippo-instance1-8x7m-0/repo2", "tls:hippo-instance1-8x7m-0/repo2", "repo:hippo-instance1-8x7m-0/repo2",
+	})
+
+	assert.Assert(t, strings.Contains(probes[2].Command,
+		"-verify_hostname 'hippo-repo-host-0.hippo-pods.zoo.svc.cluster.local'"), "no final dot")
+	assert.Assert(t, strings.Contains(probes[3].Command, "--stanza=db --repo=1 repo-ls"))
+
+	t.Run("SSH", func(t *testing.T) {
+		probes := repoHostProbes("hippo-repo-host-0", []repoHostRemote{
+			{Key: "pg1", Host: "hippo-instance1-8x7m-0", Port: "2022"},
+		})
+		assert.Equal(t, len(probes), 1)
+		assert.Equal(t, probes[0].Name, "ssh")
+		assert.Assert(t, strings.Contains(probes[0].Command, "/dev/tcp/hippo-instance1-8x7m-0/2022"))
+	})
+
+	t.Run("Paths", func(t *testing.T) {
+		probes := repoPathProbes("hippo-repo-host-0", parseArchiveOptions(
+			"repo2-path=/pgbackrest/repo2\nrepo1-path=/pgbackrest/repo1\n"+
+				"repo3-host=elsewhere\nrepo3-path=/remote\npg1-path=/pgdata/pg16\n"))
+		assert.Equal(t, len(probes), 2)
+		assert.Equal(t, probes[0].Subject, "hippo-repo-host-0/repo1")
+		assert.Equal(t, probes[1].Subject, "hippo-repo-host-0/repo2")
+		assert.Assert(t, strings.Contains(probes[0].Command, "'/pgbackrest/repo1'/archive/db"))
+	})
+}
+
+func TestRepoHostResults(t *testing.T) {
+	probes := []repoHostProbe{
+		{Name: "cert", Leg: "pod"},
+		{Name: "ping", Leg: "pod -> host:8432", OK: "server responds"},
+		{Name: "tls", Leg: "pod -> host:8432", OK: "handshake verified"},
+	}
+
+	checks := repoHostResults(probes, ""+
+		"ok\t0\tclient certificate valid until Jan  2 15:04:05 2026 GMT\n"+
+		"failed\t1\tunable to connect\n", errors.New("boom"))
+	assert.DeepEqual(t, checks, []readinessCheck{
+		{Name: "cert", Healthy: true, Detail: "pod: client certificate valid until Jan  2 15:04:05 2026 GMT"},
+		{Name: "ping", Detail: "pod -> host:8432: unable to connect"},
+		{Name: "tls", Detail: "pod -> host:8432: boom"},
+	})
+
+	checks = repoHostResults(probes[2:], "ok\t0\tCONNECTION ESTABLISHED\n", nil)
+	assert.DeepEqual(t, checks, []readinessCheck{
+		{Name: "tls", Healthy: true, Detail: "pod -> host:8432: handshake verified"},
+	})
+}
+
+func TestRunRepoHostChecks(t *testing.T) {
+	fake := &podexec.Fake{Replies: []podexec.Reply{
+		{Match: "conf.d/*.conf", Stdout: "repo1-path=/pgbackrest/repo1\n" +
+			"pg1-host=hippo-instance1-8x7m-0.hippo-pods.zoo.svc.cluster.local.\npg1-host-type=tls\n"},
+		{Match: "server-ping", Stdout: "ok\t0\tvalid\nfailed\t1\tunable to connect\n" +
+			"failed\t2\tConnection refused\nok\t3\t\n"},
+	}}
+
+	checks := runRepoHostChecks(fake, "hippo-repo-host-0", true)
+
+	var results []string
+	for _, check := range checks {
+		results = append(results, check.Name+":"+strconv.FormatBool(check.Healthy))
+	}
+	assert.DeepEqual(t, results, []string{"cert:true", "ping:false", "tls:false", "path:true"})
+	assert.Equal(t, checks[3].Detail, "hippo-repo-host-0: repo1 /pgbackrest/repo1")
+
+	t.Run("NoConfig", func(t *testing.T) {
+		fake := &podexec.Fake{Replies: []podexec.Reply{{Stderr: "cat: permission denied", Err: errors.New("exit 1")}}}
+		checks := runRepoHostChecks(fake, "hippo-instance1-8x7m-0", false)
+		assert.Equal(t, len(checks), 1)
+		assert.Equal(t, checks[0].Name, "config")
+		assert.Assert(t, strings.Contains(checks[0].Detail, "permission denied"), checks[0].Detail)
+	})
+}
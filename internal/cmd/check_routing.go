@@ -0,0 +1,453 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newCheckRoutingCommand returns the routing subcommand of the check command.
+// It connects through each Service and pgBouncer database to see which role
+// handles reads and writes.
+func newCheckRoutingCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "routing CLUSTER_NAME",
+		Short: "Check that reads and writes reach the intended instances",
+		Long: `Check the paths that clients use to reach a PostgresCluster:
+  - the primary Service, which should reach the primary
+  - the replicas Service, which should select and reach replicas
+  - each database in "spec.proxy.pgBouncer.config.databases", which should
+    reach the role of the Service in its host
+
+A read probe and a write probe run through each path. The write probe creates
+a temporary table in a transaction that is rolled back, so nothing is kept.
+A path meant for writes that reaches a replica is reported as misrouted;
+applications using it see "read-only transaction" errors.
+
+Probes run in the database container of the primary with the credentials of
+"--user". A pgBouncer database that points outside the Services of the
+cluster is probed but has no intended role.
+
+Use "--output=json" for results that scripts can read. Use "--output=prom" for
+gauges in the Prometheus text format, such as for the textfile collector of
+node_exporter.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    pods                                                [list]
+    pods/exec                                           [create]
+    postgresclusters.postgres-operator.crunchydata.com  [get]
+    secrets                                             [list]
+    services                                            [get]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Check the routing of the 'hippo' postgrescluster
+pgo check routing hippo
+
+### Example output
+PATH                    TARGET          INTENDED  READ     WRITE      RESULT
+service/hippo-primary   hippo-primary   primary   primary  ok         ok
+service/hippo-replicas  hippo-replicas  replica   replica  read-only  ok
+pgbouncer/*             hippo-primary   primary   primary  ok         ok
+pgbouncer/reports       hippo-primary   primary   replica  read-only  MISROUTED: writes reach a replica and fail as read-only
+Error: 1 of 4 paths are misrouted or failed`)
+
+	var user string
+	cmd.Flags().StringVar(&user, "user", "", "user to connect as; defaults to the name of the cluster")
+
+	outputEnum := util.TextReadiness
+	cmd.Flags().VarP(&outputEnum, "output", "o", "output format. types supported: text,json,prom")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		if user == "" {
+			user = args[0]
+		}
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		_, clusterClient, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		cluster, err := clusterClient.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		secrets, err := client.Secrets(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.LabelCluster + "=" + args[0] +
+				",postgres-operator.crunchydata.com/pguser=" + user,
+		})
+		if err != nil {
+			return err
+		}
+		if len(secrets.Items) == 0 {
+			return fmt.Errorf("user %q has no Secret; add it to spec.users of postgrescluster/%s first",
+				user, args[0])
+		}
+
+		var warnings []string
+		replicas, err := client.Services(namespace).Get(ctx, args[0]+"-replicas", metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			warnings = append(warnings, fmt.Sprintf("Service %s-replicas not found", args[0]))
+		case err != nil:
+			return err
+		default:
+			if warning := checkReplicaSelector(replicas); warning != "" {
+				warnings = append(warnings, warning)
+			}
+		}
+
+		pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.PrimaryInstanceLabels(args[0]),
+		})
+		if err != nil {
+			return err
+		}
+		if len(pods.Items) != 1 {
+			return fmt.Errorf("expected one primary instance Pod, found %d", len(pods.Items))
+		}
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+		exec := podexec.Container(podExec, namespace, pods.Items[0].GetName(), util.ContainerDatabase)
+
+		secret := &secrets.Items[0]
+		var results []routingResult
+		for _, path := range routingPaths(cluster, secret) {
+			results = append(results, probeRouting(exec, path, string(secret.Data["password"])))
+		}
+
+		var failed int
+		switch outputEnum {
+		case util.JSONReadiness:
+			reports := routingReports(results)
+			for _, report := range reports {
+				if !report.Healthy {
+					failed++
+				}
+			}
+			b, err := json.MarshalIndent(reports, "", "  ")
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(b))
+		case util.PromReadiness:
+			gauge := routingGauge(results)
+			for _, sample := range gauge.Samples {
+				if sample.Value == 0 {
+					failed++
+				}
+			}
+			if err := writePromGauges(cmd.OutOrStdout(),
+				[]string{"cluster", args[0], "namespace", namespace},
+				gauge, checkTimestampGauge("routing", time.Now())); err != nil {
+				return err
+			}
+		default:
+			if failed, err = printRoutingResults(cmd, results); err != nil {
+				return err
+			}
+		}
+		for _, warning := range warnings {
+			cmd.PrintErrf("WARNING: %s\n", warning)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d paths are misrouted or failed", failed, len(results))
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// routingPath is one way that clients reach a cluster.
+type routingPath struct {
+	Name   string
+	Target string
+
+	// Intended is "primary", "replica", or empty when the path does not
+	// point to a Service of the cluster.
+	Intended string
+
+	// ConnInfo is how psql connects through the path, without a password.
+	ConnInfo string
+}
+
+// routingResult is the outcome of probing a [routingPath].
+type routingResult struct {
+	Path routingPath
+
+	// Role is where the read probe landed, or "error".
+	Role      string
+	ReadError string
+
+	// Write is "ok", "read-only", or "error".
+	Write      string
+	WriteError string
+}
+
+// routingPaths returns the Services of cluster and the databases of its
+// pgBouncer, if any, with the credentials in secret.
+func routingPaths(cluster *unstructured.Unstructured, secret *corev1.Secret) []routingPath {
+	name, namespace := cluster.GetName(), cluster.GetNamespace()
+	user, database := string(secret.Data["user"]), string(secret.Data["dbname"])
+	if database == "" {
+		database = "postgres"
+	}
+	port := string(secret.Data["port"])
+	if port == "" {
+		port = "5432"
+	}
+	connInfo := func(host, port, dbname string) string {
+		return fmt.Sprintf("host=%s port=%s dbname=%s user=%s sslmode=require connect_timeout=10",
+			host, port, dbname, user)
+	}
+
+	paths := []routingPath{
+		{Name: "service/" + name + "-primary", Target: name + "-primary", Intended: "primary",
+			ConnInfo: connInfo(name+"-primary."+namespace+".svc", port, database)},
+		{Name: "service/" + name + "-replicas", Target: name + "-replicas", Intended: "replica",
+			ConnInfo: connInfo(name+"-replicas."+namespace+".svc", port, database)},
+	}
+
+	if _, found, _ := unstructured.NestedMap(cluster.Object, "spec", "proxy", "pgBouncer"); !found {
+		return paths
+	}
+	bouncerHost := string(secret.Data["pgbouncer-host"])
+	if bouncerHost == "" {
+		bouncerHost = name + "-pgbouncer." + namespace + ".svc"
+	}
+	bouncerPort := string(secret.Data["pgbouncer-port"])
+	if bouncerPort == "" {
+		bouncerPort = "5432"
+	}
+
+	// PGO sends every database to the primary unless "*" is defined.
+	databases := map[string]string{"*": "host=" + name + "-primary port=5432"}
+	defined, _, _ := unstructured.NestedStringMap(cluster.Object,
+		"spec", "proxy", "pgBouncer", "config", "databases")
+	for key, value := range defined {
+		databases[key] = value
+	}
+	keys := make([]string, 0, len(databases))
+	for key := range databases {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		target := pgBouncerDatabaseHost(databases[key])
+		dbname := key
+		if key == "*" {
+			dbname = database
+		}
+		paths = append(paths, routingPath{
+			Name: "pgbouncer/" + key, Target: target,
+			Intended: serviceRole(target, name, namespace),
+			ConnInfo: connInfo(bouncerHost, bouncerPort, dbname),
+		})
+	}
+	return paths
+}
+
+// pgBouncerDatabaseHost returns the host in the connection string of a
+// pgBouncer database definition.
+// - https://www.pgbouncer.org/config.html#section-databases
+func pgBouncerDatabaseHost(definition string) string {
+	for _, field := range strings.Fields(definition) {
+		if host, ok := strings.CutPrefix(field, "host="); ok {
+			return strings.Trim(host, "'")
+		}
+	}
+	return ""
+}
+
+// serviceRole returns the role that host should reach when it names the
+// primary or replicas Service of the cluster named cluster in namespace.
+func serviceRole(host, cluster, namespace string) string {
+	service, domain, _ := strings.Cut(host, ".")
+	if domain != "" && domain != namespace && !strings.HasPrefix(domain, namespace+".") {
+		return ""
+	}
+	switch service {
+	case cluster + "-primary":
+		return "primary"
+	case cluster + "-replicas":
+		return "replica"
+	}
+	return ""
+}
+
+// checkReplicaSelector returns a warning when the replicas Service would not
+// select only replicas.
+func checkReplicaSelector(service *corev1.Service) string {
+	if role := service.Spec.Selector[util.LabelRole]; role != util.RolePatroniReplica {
+		return fmt.Sprintf("Service %s selects %s=%q rather than %q", service.GetName(),
+			util.LabelRole, role, util.RolePatroniReplica)
+	}
+	return ""
+}
+
+// routingReadSQL reports whether the server is a replica.
+const routingReadSQL = `SELECT pg_catalog.pg_is_in_recovery();`
+
+// routingWriteSQL changes nothing that outlives it but fails on a replica.
+const routingWriteSQL = `BEGIN;
+CREATE TEMPORARY TABLE pgo_routing_probe ();
+ROLLBACK;`
+
+// routingScript runs psql with the password on the first line of stdin and
+// the SQL on the rest. The password stays out of the arguments of processes.
+const routingScript = `read -r PGPASSWORD; export PGPASSWORD
+exec psql -X -A -t -v ON_ERROR_STOP=1 "$1" -f -`
+
+// probeRouting runs the read and write probes through path using exec.
+func probeRouting(exec podexec.Executor, path routingPath, password string) routingResult {
+	result := routingResult{Path: path}
+	run := func(sql string) (string, error) {
+		stdout, stderr, err := podexec.Output(exec, strings.NewReader(password+"\n"+sql),
+			"bash", "-ceu", "--", routingScript, "-", path.ConnInfo)
+		return stdout, commandError(err, stderr)
+	}
+
+	stdout, err := run(routingReadSQL)
+	switch {
+	case err != nil:
+		result.Role, result.ReadError = "error", err.Error()
+	case strings.TrimSpace(stdout) == "f":
+		result.Role = "primary"
+	case strings.TrimSpace(stdout) == "t":
+		result.Role = "replica"
+	default:
+		result.Role, result.ReadError = "error", fmt.Sprintf("unexpected output %q", stdout)
+	}
+
+	_, err = run(routingWriteSQL)
+	switch {
+	case err == nil:
+		result.Write = "ok"
+	case strings.Contains(err.Error(), "read-only transaction"):
+		result.Write = "read-only"
+	default:
+		result.Write, result.WriteError = "error", err.Error()
+	}
+	return result
+}
+
+// routingOutcome explains result or returns "ok".
+func routingOutcome(result routingResult) string {
+	switch {
+	case result.ReadError != "":
+		return "FAILED: " + result.ReadError
+	case result.Path.Intended == "primary" && result.Role == "replica":
+		return "MISROUTED: writes reach a replica and fail as read-only"
+	case result.Path.Intended == "replica" && result.Role == "primary":
+		return "MISROUTED: reads meant for replicas reach the primary"
+	case result.Path.Intended == "primary" && result.Write != "ok":
+		return "FAILED: " + result.WriteError
+	case result.Write == "error":
+		return "FAILED: " + result.WriteError
+	}
+	return "ok"
+}
+
+// routingReport is a [routingResult] as "--output=json" prints it.
+type routingReport struct {
+	Path     string `json:"path"`
+	Target   string `json:"target,omitempty"`
+	Intended string `json:"intended,omitempty"`
+	Read     string `json:"read"`
+	Write    string `json:"write"`
+	Result   string `json:"result"`
+	Healthy  bool   `json:"healthy"`
+}
+
+// routingReports returns results with their outcomes.
+func routingReports(results []routingResult) []routingReport {
+	reports := make([]routingReport, 0, len(results))
+	for _, result := range results {
+		outcome := routingOutcome(result)
+		reports = append(reports, routingReport{
+			Path: result.Path.Name, Target: result.Path.Target, Intended: result.Path.Intended,
+			Read: result.Role, Write: result.Write, Result: outcome, Healthy: outcome == "ok",
+		})
+	}
+	return reports
+}
+
+// routingGauge returns whether each path of results is ok as a gauge.
+func routingGauge(results []routingResult) *promGauge {
+	healthy := &promGauge{
+		Name: "pgo_check_routing_healthy",
+		Help: "Whether a path to a cluster reached its intended role (1) or not (0).",
+	}
+	for _, result := range results {
+		healthy.add(promBool(routingOutcome(result) == "ok"), "path", result.Path.Name,
+			"intended", result.Path.Intended, "read", result.Role)
+	}
+	return healthy
+}
+
+// printRoutingResults prints results as a table. It returns the number of
+// results that are not ok.
+func printRoutingResults(cmd *cobra.Command, results []routingResult) (int, error) {
+	var failed int
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "PATH\tTARGET\tINTENDED\tREAD\tWRITE\tRESULT")
+	for _, result := range results {
+		outcome := routingOutcome(result)
+		if outcome != "ok" {
+			failed++
+		}
+		target, intended := result.Path.Target, result.Path.Intended
+		if target == "" {
+			target = "-"
+		}
+		if intended == "" {
+			intended = "-"
+		}
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n", result.Path.Name,
+			target, intended, result.Role, result.Write, outcome)
+	}
+	return failed, writer.Flush()
+}
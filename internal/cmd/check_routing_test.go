@@ -0,0 +1,144 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+func TestRoutingPaths(t *testing.T) {
+	cluster := &unstructured.Unstructured{Object: map[string]any{}}
+	cluster.SetName("hippo")
+	cluster.SetNamespace("postgres")
+	secret := &corev1.Secret{Data: map[string][]byte{
+		"user": []byte("hippo"), "dbname": []byte("hippo"), "port": []byte("5432"),
+		"pgbouncer-host": []byte("hippo-pgbouncer.postgres.svc"), "pgbouncer-port": []byte("5432"),
+	}}
+
+	paths := routingPaths(cluster, secret)
+	assert.Equal(t, len(paths), 2, "no pgBouncer")
+	assert.DeepEqual(t, paths[1], routingPath{
+		Name: "service/hippo-replicas", Target: "hippo-replicas", Intended: "replica",
+		ConnInfo: "host=hippo-replicas.postgres.svc port=5432 dbname=hippo user=hippo sslmode=require connect_timeout=10",
+	})
+
+	assert.NilError(t, unstructured.SetNestedStringMap(cluster.Object, map[string]string{
+		"reports": "host=hippo-replicas.postgres.svc port=5432 dbname=app",
+		"legacy":  "host=db.example.com dbname=app",
+	}, "spec", "proxy", "pgBouncer", "config", "databases"))
+
+	paths = routingPaths(cluster, secret)
+	assert.Equal(t, len(paths), 5)
+	for i, expected := range []routingPath{
+		{Name: "pgbouncer/*", Target: "hippo-primary", Intended: "primary"},
+		{Name: "pgbouncer/legacy", Target: "db.example.com"},
+		{Name: "pgbouncer/reports", Target: "hippo-replicas.postgres.svc", Intended: "replica"},
+	} {
+		assert.Equal(t, paths[i+2].Name, expected.Name)
+		assert.Equal(t, paths[i+2].Target, expected.Target)
+		assert.Equal(t, paths[i+2].Intended, expected.Intended)
+		assert.Assert(t, strings.HasPrefix(paths[i+2].ConnInfo, "host=hippo-pgbouncer.postgres.svc "))
+	}
+	assert.Assert(t, strings.Contains(paths[2].ConnInfo, " dbname=hippo "))
+	assert.Assert(t, strings.Contains(paths[4].ConnInfo, " dbname=reports "))
+}
+
+func TestServiceRole(t *testing.T) {
+	assert.Equal(t, serviceRole("hippo-primary", "hippo", "postgres"), "primary")
+	assert.Equal(t, serviceRole("hippo-primary.postgres.svc.cluster.local", "hippo", "postgres"), "primary")
+	assert.Equal(t, serviceRole("hippo-replicas.postgres.svc", "hippo", "postgres"), "replica")
+	assert.Equal(t, serviceRole("hippo-replicas.other.svc", "hippo", "postgres"), "")
+	assert.Equal(t, serviceRole("rhino-primary", "hippo", "postgres"), "")
+
+	assert.Equal(t, pgBouncerDatabaseHost("dbname=app host='hippo-primary' port=5432"), "hippo-primary")
+	assert.Equal(t, pgBouncerDatabaseHost("dbname=app"), "")
+}
+
+func TestCheckReplicaSelector(t *testing.T) {
+	service := &corev1.Service{}
+	service.Name = "hippo-replicas"
+	service.Spec.Selector = map[string]string{util.LabelRole: util.RolePatroniReplica}
+	assert.Equal(t, checkReplicaSelector(service), "")
+
+	service.Spec.Selector = map[string]string{util.LabelCluster: "hippo"}
+	assert.Equal(t, checkReplicaSelector(service),
+		`Service hippo-replicas selects postgres-operator.crunchydata.com/role="" rather than "replica"`)
+}
+
+func TestProbeRouting(t *testing.T) {
+	// Hosts with "replica" in their name reach a replica.
+	exec := podexec.Func(func(stdin io.Reader, stdout, stderr io.Writer, command ...string) error {
+		b, _ := io.ReadAll(stdin)
+		password, sql, _ := strings.Cut(string(b), "\n")
+		assert.Equal(t, password, "secret")
+		assert.Equal(t, command[len(command)-2], "-")
+
+		conninfo := command[len(command)-1]
+		switch {
+		case strings.Contains(conninfo, "unreachable"):
+			_, _ = io.WriteString(stderr, "psql: error: connection refused")
+			return errors.New("exit status 2")
+		case strings.Contains(sql, "pg_is_in_recovery"):
+			_, _ = io.WriteString(stdout, map[bool]string{true: "t\n", false: "f\n"}[strings.Contains(conninfo, "replica")])
+		case strings.Contains(conninfo, "replica"):
+			_, _ = io.WriteString(stderr, "ERROR:  cannot execute CREATE TABLE in a read-only transaction")
+			return errors.New("exit status 3")
+		}
+		return nil
+	})
+
+	var results []routingResult
+	for _, path := range []routingPath{
+		{Name: "service/hippo-primary", Target: "hippo-primary", Intended: "primary", ConnInfo: "host=primary"},
+		{Name: "service/hippo-replicas", Target: "hippo-replicas", Intended: "replica", ConnInfo: "host=replicas"},
+		{Name: "pgbouncer/reports", Target: "hippo-primary", Intended: "primary", ConnInfo: "host=replica-pool"},
+		{Name: "pgbouncer/legacy", Target: "db.example.com", ConnInfo: "host=unreachable"},
+	} {
+		results = append(results, probeRouting(exec, path, "secret"))
+	}
+
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+	failed, err := printRoutingResults(cmd, results)
+	assert.NilError(t, err)
+	assert.Equal(t, failed, 2)
+	assert.Equal(t, out.String(), `PATH                    TARGET          INTENDED  READ     WRITE      RESULT
+service/hippo-primary   hippo-primary   primary   primary  ok         ok
+service/hippo-replicas  hippo-replicas  replica   replica  read-only  ok
+pgbouncer/reports       hippo-primary   primary   replica  read-only  MISROUTED: writes reach a replica and fail as read-only
+pgbouncer/legacy        db.example.com  -         error    error      FAILED: exit status 2: psql: error: connection refused
+`)
+
+	reports := routingReports(results)
+	assert.DeepEqual(t, reports[2], routingReport{
+		Path: "pgbouncer/reports", Target: "hippo-primary", Intended: "primary",
+		Read: "replica", Write: "read-only",
+		Result: "MISROUTED: writes reach a replica and fail as read-only",
+	})
+	assert.Assert(t, reports[1].Healthy)
+
+	out.Reset()
+	assert.NilError(t, writePromGauges(&out, []string{"cluster", "hippo"}, routingGauge(results)))
+	assert.Equal(t, out.String(), `# HELP pgo_check_routing_healthy Whether a path to a cluster reached its intended role (1) or not (0).
+# TYPE pgo_check_routing_healthy gauge
+pgo_check_routing_healthy{cluster="hippo",intended="primary",path="service/hippo-primary",read="primary"} 1
+pgo_check_routing_healthy{cluster="hippo",intended="replica",path="service/hippo-replicas",read="replica"} 1
+pgo_check_routing_healthy{cluster="hippo",intended="primary",path="pgbouncer/reports",read="replica"} 0
+pgo_check_routing_healthy{cluster="hippo",path="pgbouncer/legacy",read="error"} 0
+`)
+}
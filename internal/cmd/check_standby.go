@@ -0,0 +1,412 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// standbyConditionType is the type of the Condition that "check standby"
+// sets on a PostgresCluster with "--publish-condition".
+const standbyConditionType = "StandbyPromotable"
+
+// newCheckStandbyCommand returns the standby subcommand of the check command.
+// It verifies that a standby cluster could be promoted.
+func newCheckStandbyCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "standby CLUSTER_NAME",
+		Short: "Check that a standby PostgresCluster can be promoted",
+		Long: `Check that a standby PostgresCluster could be promoted if its source were lost:
+  - repo: the repository in "spec.standby.repoName" can be read and its stanza is ok
+  - wal: the standby leader is replaying WAL, replay is not paused, and the last
+    transaction it replayed is newer than "--max-replay-lag"
+  - replay: the WAL segment being replayed can be fetched from the repository
+    with "pgbackrest archive-get", as the restore_command would
+
+The repo and replay checks are skipped for standbys that stream from a host
+without a repository. The last replayed transaction ages when the source
+cluster is idle, so choose "--max-replay-lag" to suit its write rate.
+
+Use "--publish-condition" to record the result as the "StandbyPromotable"
+Condition in the status of the PostgresCluster. Use "--interval" to repeat the
+checks until interrupted rather than checking once.
+
+### RBAC Requirements
+    Resources                                                  Verbs
+    ---------                                                  -----
+    pods                                                       [list]
+    pods/exec                                                  [create]
+    postgresclusters.postgres-operator.crunchydata.com         [get]
+    postgresclusters.postgres-operator.crunchydata.com/status  [patch]
+
+    Note: Patching status is only needed with "--publish-condition".
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Check that the 'hippo-dr' standby postgrescluster can be promoted
+pgo check standby hippo-dr
+
+# Check every 15 minutes and record the result on the postgrescluster
+pgo check standby hippo-dr --interval=15m --publish-condition
+
+### Example output
+CHECK   RESULT  DETAIL
+repo    ok      repo2: stanza db ok; WAL archived to 000000010000000000000021
+wal     ok      last transaction replayed 42s ago at 0/21000148
+replay  ok      fetched 000000010000000000000021 from repo2`)
+
+	var (
+		maxReplayLag     time.Duration
+		interval         time.Duration
+		publishCondition bool
+		outputEnum       = util.TextReadiness
+	)
+	cmd.Flags().DurationVar(&maxReplayLag, "max-replay-lag", 5*time.Minute,
+		"age of the last replayed transaction above which the check fails")
+	cmd.Flags().DurationVar(&interval, "interval", 0,
+		"how often to repeat the checks; zero checks once")
+	cmd.Flags().BoolVar(&publishCondition, "publish-condition", false,
+		"record the result as a Condition in the status of the postgrescluster")
+	cmd.Flags().VarP(&outputEnum, "output", "o", "output format. types supported: text,json,prom")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		_, clusterClient, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+
+		check := func() ([]readinessCheck, error) {
+			now := time.Now()
+			cluster, err := clusterClient.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+			if enabled, _, _ := unstructured.NestedBool(cluster.Object,
+				"spec", "standby", "enabled"); !enabled {
+				return nil, fmt.Errorf("postgresclusters/%s is not a standby", args[0])
+			}
+			repo, _, _ := unstructured.NestedString(cluster.Object, "spec", "standby", "repoName")
+
+			pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+				LabelSelector: util.PrimaryInstanceLabels(args[0]),
+			})
+			if err != nil {
+				return nil, err
+			}
+			var leader *corev1.Pod
+			for i := range pods.Items {
+				if podIsReady(&pods.Items[i]) {
+					leader = &pods.Items[i]
+				}
+			}
+
+			var checks []readinessCheck
+			if leader == nil {
+				checks = append(checks, readinessCheck{Name: "leader",
+					Detail: "no ready standby leader Pod found"})
+			} else {
+				exec := podexec.Container(podExec, namespace, leader.GetName(), util.ContainerDatabase)
+				checks = runStandbyChecks(exec, repo, maxReplayLag)
+			}
+
+			if publishCondition {
+				patch, err := standbyCondition(cluster, checks, now).MarshalJSON()
+				if err != nil {
+					return checks, err
+				}
+				force := true
+				if _, err := clusterClient.Namespace(namespace).Patch(ctx, args[0],
+					types.ApplyPatchType, patch,
+					config.Patch.PatchOptions(metav1.PatchOptions{Force: &force}), "status",
+				); err != nil {
+					return checks, err
+				}
+			}
+
+			switch outputEnum {
+			case util.JSONReadiness:
+				b, err := json.MarshalIndent(checks, "", "  ")
+				if err != nil {
+					return checks, err
+				}
+				cmd.Println(string(b))
+			case util.PromReadiness:
+				return checks, writePromGauges(cmd.OutOrStdout(),
+					[]string{"cluster", args[0], "namespace", namespace},
+					standbyGauges(checks), checkTimestampGauge("standby", now))
+			default:
+				if interval > 0 {
+					cmd.Printf("--- %s ---\n", now.UTC().Format(time.RFC3339))
+				}
+				return checks, printReadinessChecks(cmd, checks)
+			}
+			return checks, nil
+		}
+
+		for {
+			checks, err := check()
+			if err == nil {
+				var failed int
+				for _, check := range checks {
+					if !check.Healthy {
+						failed++
+					}
+				}
+				if failed > 0 {
+					err = fmt.Errorf("%d of %d checks failed", failed, len(checks))
+				}
+			}
+			if interval == 0 {
+				return err
+			}
+			if err != nil {
+				cmd.PrintErrf("Error: %s\n", err)
+			}
+			time.Sleep(interval)
+		}
+	}
+
+	return cmd
+}
+
+// runStandbyChecks runs the checks of a standby leader using exec. The repo
+// and replay checks need repo, the name of the repository it restores from.
+func runStandbyChecks(exec podexec.Executor, repo string, maxReplayLag time.Duration) []readinessCheck {
+	var checks []readinessCheck
+	if repo != "" {
+		stdout, stderr, err := podexec.PGBackRestInfo(exec, strings.TrimPrefix(repo, "repo"))
+		checks = append(checks, checkStandbyRepo(repo, stdout, commandError(err, stderr)))
+	}
+
+	stdout, stderr, err := podexec.PSQL(exec, "", standbyReplaySQL)
+	wal, segment := checkStandbyReplay(stdout, commandError(err, stderr), maxReplayLag)
+	checks = append(checks, wal)
+
+	if repo != "" {
+		replay := readinessCheck{Name: "replay", Subject: repo}
+		if segment == "" {
+			replay.Detail = "the WAL segment being replayed is not known"
+		} else {
+			_, stderr, err := podexec.Bash(exec, standbyArchiveGetScript(repo, segment))
+			if err = commandError(err, stderr); err != nil {
+				replay.Detail = fmt.Sprintf("unable to fetch %s from %s: %s", segment, repo, err)
+			} else {
+				replay.Healthy = true
+				replay.Detail = fmt.Sprintf("fetched %s from %s", segment, repo)
+			}
+		}
+		checks = append(checks, replay)
+	}
+	return checks
+}
+
+// checkStandbyRepo checks the output of "pgbackrest info" for repo.
+func checkStandbyRepo(repo, stdout string, err error) readinessCheck {
+	check := readinessCheck{Name: "repo", Subject: repo}
+	if err != nil {
+		check.Detail = repo + ": " + err.Error()
+		return check
+	}
+	var stanzas []pgBackRestStanza
+	if err := json.Unmarshal([]byte(stdout), &stanzas); err != nil {
+		check.Detail = repo + ": " + err.Error()
+		return check
+	}
+	if len(stanzas) == 0 {
+		check.Detail = repo + ": no stanza found"
+		return check
+	}
+
+	stanza := stanzas[0]
+	if stanza.Status.Code != 0 {
+		check.Detail = fmt.Sprintf("%s: stanza %s: %s", repo, stanza.Name, stanza.Status.Message)
+		return check
+	}
+	var newest string
+	for _, archive := range stanza.Archive {
+		if archive.Max > newest {
+			newest = archive.Max
+		}
+	}
+	if newest == "" {
+		check.Detail = fmt.Sprintf("%s: stanza %s has no archived WAL", repo, stanza.Name)
+		return check
+	}
+	check.Healthy = true
+	check.Detail = fmt.Sprintf("%s: stanza %s ok; WAL archived to %s", repo, stanza.Name, newest)
+	return check
+}
+
+// standbyReplaySQL prints whether the server is in recovery, whether replay is
+// paused, the seconds since the last replayed transaction, the last replayed
+// LSN, the timeline, and the size of WAL segments.
+const standbyReplaySQL = `SELECT pg_catalog.pg_is_in_recovery(),
+  CASE WHEN pg_catalog.pg_is_in_recovery() THEN pg_catalog.pg_is_wal_replay_paused() END,
+  COALESCE(EXTRACT(EPOCH FROM pg_catalog.now() - pg_catalog.pg_last_xact_replay_timestamp())::bigint::text, ''),
+  COALESCE(pg_catalog.pg_last_wal_replay_lsn()::text, ''),
+  (SELECT timeline_id FROM pg_catalog.pg_control_checkpoint()),
+  (SELECT setting FROM pg_catalog.pg_settings WHERE name = 'wal_segment_size');`
+
+// checkStandbyReplay checks the output of [standbyReplaySQL]. It also returns
+// the name of the WAL segment being replayed, when that is known.
+func checkStandbyReplay(stdout string, err error, maxLag time.Duration) (readinessCheck, string) {
+	check := readinessCheck{Name: "wal"}
+	if err != nil {
+		check.Detail = err.Error()
+		return check, ""
+	}
+	rows := parseRows(stdout)
+	if len(rows) != 1 || len(rows[0]) != 6 {
+		check.Detail = "unable to read the replay status"
+		return check, ""
+	}
+	recovering, paused, seconds, lsn := rows[0][0] == "t", rows[0][1] == "t", rows[0][2], rows[0][3]
+	timeline, _ := strconv.ParseUint(rows[0][4], 10, 32)
+	segmentSize, _ := strconv.ParseUint(rows[0][5], 10, 64)
+	segment := walFileName(uint32(timeline), lsn, segmentSize)
+
+	lag, parsed := strconv.ParseInt(seconds, 10, 64)
+	if parsed == nil {
+		check.measured(float64(lag))
+	}
+	age := time.Duration(lag) * time.Second
+
+	switch {
+	case !recovering:
+		check.Detail = "the standby leader is not in recovery; it may have been promoted"
+	case paused:
+		check.Detail = "WAL replay is paused"
+	case parsed != nil:
+		check.Detail = "no transaction has been replayed yet"
+	case age > maxLag:
+		check.Detail = fmt.Sprintf("last transaction replayed %s ago at %s, more than %s", age, lsn, maxLag)
+	default:
+		check.Healthy = true
+		check.Detail = fmt.Sprintf("last transaction replayed %s ago at %s", age, lsn)
+	}
+	return check, segment
+}
+
+// walFileName returns the name of the WAL segment on timeline that contains
+// lsn, or an empty string when lsn or segmentSize is not valid.
+// - https://www.postgresql.org/docs/current/wal-internals.html
+func walFileName(timeline uint32, lsn string, segmentSize uint64) string {
+	high, low, ok := strings.Cut(lsn, "/")
+	if !ok || segmentSize == 0 || timeline == 0 {
+		return ""
+	}
+	hi, err1 := strconv.ParseUint(high, 16, 32)
+	lo, err2 := strconv.ParseUint(low, 16, 32)
+	if err1 != nil || err2 != nil {
+		return ""
+	}
+	segment := (hi<<32 | lo) / segmentSize
+	perID := (uint64(1) << 32) / segmentSize
+	return fmt.Sprintf("%08X%08X%08X", timeline, segment/perID, segment%perID)
+}
+
+// standbyArchiveGetScript fetches segment from repo the way restore_command
+// would, into a temporary file that is removed afterward.
+func standbyArchiveGetScript(repo, segment string) string {
+	return fmt.Sprintf(`target=$(mktemp -d)
+trap 'rm -rf "${target}"' EXIT
+pgbackrest --stanza=db --repo=%s archive-get %s "${target}/%s"
+test -s "${target}/%s"`, strings.TrimPrefix(repo, "repo"), segment, segment, segment)
+}
+
+// standbyCondition returns an intent to apply to the status of cluster that
+// sets the [standbyConditionType] Condition according to checks.
+func standbyCondition(cluster *unstructured.Unstructured, checks []readinessCheck, now time.Time) *unstructured.Unstructured {
+	status, reason, message := "True", "ChecksPassed", "all checks passed"
+	var failed []string
+	for _, check := range checks {
+		if !check.Healthy {
+			failed = append(failed, check.Name+": "+check.Detail)
+		}
+	}
+	if len(failed) > 0 {
+		status, reason, message = "False", "ChecksFailed", strings.Join(failed, "; ")
+	}
+
+	// Keep the transition time while the status stays the same.
+	transition := now.UTC().Format(time.RFC3339)
+	conditions, _, _ := unstructured.NestedSlice(cluster.Object, "status", "conditions")
+	for _, item := range conditions {
+		if condition, ok := item.(map[string]any); ok &&
+			condition["type"] == standbyConditionType && condition["status"] == status {
+			if previous, ok := condition["lastTransitionTime"].(string); ok {
+				transition = previous
+			}
+		}
+	}
+
+	intent := &unstructured.Unstructured{Object: map[string]any{
+		"status": map[string]any{
+			"conditions": []any{map[string]any{
+				"type":               standbyConditionType,
+				"status":             status,
+				"reason":             reason,
+				"message":            message,
+				"lastTransitionTime": transition,
+				"observedGeneration": cluster.GetGeneration(),
+			}},
+		},
+	}}
+	intent.SetAPIVersion(cluster.GetAPIVersion())
+	intent.SetKind(cluster.GetKind())
+	intent.SetNamespace(cluster.GetNamespace())
+	intent.SetName(cluster.GetName())
+	return intent
+}
+
+// standbyGauges returns checks as gauges.
+func standbyGauges(checks []readinessCheck) *promGauge {
+	healthy := &promGauge{
+		Name: "pgo_check_standby_healthy",
+		Help: "Whether a check of pgo check standby passed (1) or failed (0).",
+	}
+	for _, check := range checks {
+		healthy.add(promBool(check.Healthy), "check", check.Name)
+	}
+	return healthy
+}
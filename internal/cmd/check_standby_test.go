@@ -0,0 +1,129 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+)
+
+func TestWALFileName(t *testing.T) {
+	assert.Equal(t, walFileName(1, "0/21000148", 16<<20), "000000010000000000000021")
+	assert.Equal(t, walFileName(3, "2A/FF000000", 16<<20), "000000030000002A000000FF")
+	assert.Equal(t, walFileName(2, "1/40000000", 1<<30), "000000020000000100000001")
+
+	assert.Equal(t, walFileName(1, "", 16<<20), "")
+	assert.Equal(t, walFileName(1, "0/21000148", 0), "")
+	assert.Equal(t, walFileName(0, "0/21000148", 16<<20), "")
+}
+
+func TestCheckStandbyRepo(t *testing.T) {
+	check := checkStandbyRepo("repo2", "", errors.New("connection refused"))
+	assert.Assert(t, !check.Healthy)
+	assert.Equal(t, check.Detail, "repo2: connection refused")
+
+	check = checkStandbyRepo("repo2", `[{"name":"db","status":{"code":1,"message":"missing stanza path"}}]`, nil)
+	assert.Assert(t, !check.Healthy)
+	assert.Equal(t, check.Detail, "repo2: stanza db: missing stanza path")
+
+	check = checkStandbyRepo("repo2", `[{"name":"db","status":{"code":0,"message":"ok"}}]`, nil)
+	assert.Assert(t, !check.Healthy)
+	assert.Equal(t, check.Detail, "repo2: stanza db has no archived WAL")
+
+	check = checkStandbyRepo("repo2", `[{"name":"db","status":{"code":0,"message":"ok"},
+		"archive":[{"id":"16-1","max":"000000010000000000000021"}]}]`, nil)
+	assert.Assert(t, check.Healthy)
+	assert.Equal(t, check.Detail, "repo2: stanza db ok; WAL archived to 000000010000000000000021")
+}
+
+func TestCheckStandbyReplay(t *testing.T) {
+	check, segment := checkStandbyReplay("t\tf\t42\t0/21000148\t1\t16777216\n", nil, time.Minute)
+	assert.Assert(t, check.Healthy)
+	assert.Equal(t, check.Detail, "last transaction replayed 42s ago at 0/21000148")
+	assert.DeepEqual(t, check.Value, measured(42))
+	assert.Equal(t, segment, "000000010000000000000021")
+
+	check, _ = checkStandbyReplay("t\tf\t420\t0/21000148\t1\t16777216\n", nil, 5*time.Minute)
+	assert.Assert(t, !check.Healthy)
+	assert.Equal(t, check.Detail, "last transaction replayed 7m0s ago at 0/21000148, more than 5m0s")
+
+	check, _ = checkStandbyReplay("t\tt\t42\t0/21000148\t1\t16777216\n", nil, time.Minute)
+	assert.Assert(t, !check.Healthy)
+	assert.Equal(t, check.Detail, "WAL replay is paused")
+
+	check, segment = checkStandbyReplay("t\tf\t\t\t1\t16777216\n", nil, time.Minute)
+	assert.Assert(t, !check.Healthy)
+	assert.Equal(t, check.Detail, "no transaction has been replayed yet")
+	assert.Equal(t, segment, "")
+
+	check, _ = checkStandbyReplay("f\t\t\t\t2\t16777216\n", nil, time.Minute)
+	assert.Assert(t, !check.Healthy)
+	assert.Assert(t, strings.Contains(check.Detail, "not in recovery"))
+}
+
+func TestRunStandbyChecks(t *testing.T) {
+	fake := &podexec.Fake{Replies: []podexec.Reply{
+		{Match: "pgbackrest info --output=json --repo=2", Stdout: `[{"name":"db",
+			"status":{"code":0,"message":"ok"},"archive":[{"max":"000000010000000000000021"}]}]`},
+		{Match: "pg_is_in_recovery", Stdout: "t\tf\t42\t0/21000148\t1\t16777216\n"},
+		{Match: "archive-get 000000010000000000000021", Stderr: "unable to find", Err: errors.New("exit 1")},
+	}}
+
+	checks := runStandbyChecks(fake, "repo2", time.Minute)
+	assert.Equal(t, len(checks), 3)
+	assert.Assert(t, checks[0].Healthy)
+	assert.Assert(t, checks[1].Healthy)
+	assert.Assert(t, !checks[2].Healthy)
+	assert.Equal(t, checks[2].Detail,
+		"unable to fetch 000000010000000000000021 from repo2: exit 1: unable to find")
+
+	checks = runStandbyChecks(fake, "", time.Minute)
+	assert.Equal(t, len(checks), 1, "streaming standbys have no repo")
+	assert.Equal(t, checks[0].Name, "wal")
+}
+
+func TestStandbyCondition(t *testing.T) {
+	cluster := &unstructured.Unstructured{Object: map[string]any{}}
+	cluster.SetAPIVersion("postgres-operator.crunchydata.com/v1beta1")
+	cluster.SetKind("PostgresCluster")
+	cluster.SetNamespace("postgres")
+	cluster.SetName("hippo-dr")
+	cluster.SetGeneration(4)
+
+	now := time.Date(2024, 5, 1, 14, 22, 37, 0, time.UTC)
+	healthy := []readinessCheck{{Name: "wal", Healthy: true}}
+	failing := []readinessCheck{{Name: "wal", Healthy: true}, {Name: "replay", Detail: "missing"}}
+
+	condition := func(intent *unstructured.Unstructured) map[string]any {
+		conditions, _, _ := unstructured.NestedSlice(intent.Object, "status", "conditions")
+		assert.Equal(t, len(conditions), 1)
+		return conditions[0].(map[string]any)
+	}
+
+	intent := standbyCondition(cluster, failing, now)
+	assert.Equal(t, intent.GetName(), "hippo-dr")
+	assert.Equal(t, intent.GetKind(), "PostgresCluster")
+	assert.DeepEqual(t, condition(intent), map[string]any{
+		"type": "StandbyPromotable", "status": "False", "reason": "ChecksFailed",
+		"message": "replay: missing", "lastTransitionTime": "2024-05-01T14:22:37Z",
+		"observedGeneration": int64(4),
+	})
+
+	assert.NilError(t, unstructured.SetNestedSlice(cluster.Object, []any{map[string]any{
+		"type": "StandbyPromotable", "status": "True", "lastTransitionTime": "2024-04-30T00:00:00Z",
+	}}, "status", "conditions"))
+
+	assert.Equal(t, condition(standbyCondition(cluster, healthy, now))["lastTransitionTime"],
+		"2024-04-30T00:00:00Z", "status unchanged")
+	assert.Equal(t, condition(standbyCondition(cluster, failing, now))["lastTransitionTime"],
+		"2024-05-01T14:22:37Z", "status changed")
+}
@@ -0,0 +1,407 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newCheckTLSCommand returns the tls subcommand of the check command. It
+// connects to Postgres and pgBouncer with each sslmode that uses TLS.
+func newCheckTLSCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tls CLUSTER_NAME",
+		Short: "Check TLS connections to a PostgresCluster",
+		Long: `Check TLS connections to the primary and pgBouncer of a PostgresCluster the
+way a client using each sslmode would:
+  - require: the connection is encrypted
+  - verify-ca: the certificate is signed by the CA of the cluster
+  - verify-full: the certificate also names the Service, e.g. hippo-primary.NAMESPACE.svc
+
+The CA is read from the Secret that PGO generated or from the custom TLS Secret
+of the cluster. The report shows which modes succeed, the TLS protocol and
+cipher, and the certificate chain the server presented.
+
+Connections go through a port forward to the Pod, so the client does not need
+to reach the network of the Kubernetes cluster.
+
+Use "--output=prom" for gauges in the Prometheus text format, such as for the
+textfile collector of node_exporter.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    pods                                                [list]
+    pods/portforward                                    [create]
+    postgresclusters.postgres-operator.crunchydata.com  [get]
+    secrets                                             [get]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Check TLS connections to the 'hippo' postgrescluster
+pgo check tls hippo
+
+### Example output
+primary: hippo-primary.postgres-operator.svc (pod/hippo-instance1-8x7m-0)
+  protocol: TLS 1.3
+  cipher: TLS_AES_128_GCM_SHA256
+  certificate chain:
+    0: CN=hippo-primary; issued by CN=postgres-operator-ca; expires 2026-05-01T14:22:37Z
+  SSLMODE      RESULT
+  require      ok
+  verify-ca    ok
+  verify-full  ok`)
+
+	outputEnum := util.TextCheck
+	cmd.Flags().VarP(&outputEnum, "output", "o", "output format. types supported: text,prom")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		prom := outputEnum == util.PromCheck
+		healthy, expiry := tlsGauges()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		clientset, err := kubernetes.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		_, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		forward, err := util.NewPodPortForwarder(rest)
+		if err != nil {
+			return err
+		}
+
+		cluster, err := client.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		for i, endpoint := range tlsEndpoints(cluster) {
+			if i > 0 && !prom {
+				cmd.Println()
+			}
+
+			pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+				LabelSelector: endpoint.Selector,
+			})
+			if err != nil {
+				return err
+			}
+			if len(pods.Items) == 0 {
+				if prom {
+					healthy.add(0, "endpoint", endpoint.Name)
+				} else {
+					cmd.Printf("%s: no Pods found\n", endpoint.Name)
+				}
+				continue
+			}
+			pod := &pods.Items[0]
+
+			secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx,
+				endpoint.SecretName, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			roots := x509.NewCertPool()
+			if !roots.AppendCertsFromPEM(secret.Data[endpoint.SecretKey]) {
+				return fmt.Errorf("no CA certificates found in %q of secrets/%s",
+					endpoint.SecretKey, endpoint.SecretName)
+			}
+
+			address, stop, err := forward(namespace, pod.Name,
+				containerPort(pod, endpoint.Container, endpoint.Port, 5432))
+			if err != nil {
+				return err
+			}
+
+			serverName := fmt.Sprintf("%s-%s.%s.svc", args[0], endpoint.Service, namespace)
+			dial := func() (net.Conn, error) {
+				return net.DialTimeout("tcp", address, 10*time.Second)
+			}
+
+			results := checkTLSModes(dial, serverName, roots)
+			stop()
+
+			if prom {
+				addTLSGauges(healthy, expiry, endpoint.Name, results)
+			} else {
+				cmd.Printf("%s: %s (pod/%s)\n", endpoint.Name, serverName, pod.Name)
+				printTLSResults(cmd, results)
+			}
+		}
+
+		if prom {
+			return writePromGauges(cmd.OutOrStdout(),
+				[]string{"cluster", args[0], "namespace", namespace},
+				healthy, expiry, checkTimestampGauge("tls", time.Now()))
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// tlsEndpoint is a Postgres server of a cluster that clients reach through a
+// Service.
+type tlsEndpoint struct {
+	Name      string
+	Service   string
+	Selector  string
+	Container string
+	Port      string
+
+	// SecretName and SecretKey locate the CA that signed the certificate of
+	// the server.
+	SecretName, SecretKey string
+}
+
+// tlsEndpoints returns the endpoints of cluster that accept client connections.
+// The pgBouncer endpoint is included when the cluster has pgBouncer enabled.
+func tlsEndpoints(cluster *unstructured.Unstructured) []tlsEndpoint {
+	name := cluster.GetName()
+
+	primary := tlsEndpoint{
+		Name:       "primary",
+		Service:    "primary",
+		Selector:   util.PrimaryInstanceLabels(name),
+		Container:  util.ContainerDatabase,
+		Port:       "postgres",
+		SecretName: name + "-cluster-cert",
+		SecretKey:  "ca.crt",
+	}
+	if custom, _, _ := unstructured.NestedString(cluster.Object,
+		"spec", "customTLSSecret", "name"); custom != "" {
+		primary.SecretName = custom
+	}
+	endpoints := []tlsEndpoint{primary}
+
+	if _, found, _ := unstructured.NestedMap(cluster.Object, "spec", "proxy", "pgBouncer"); found {
+		bouncer := tlsEndpoint{
+			Name:       "pgbouncer",
+			Service:    "pgbouncer",
+			Selector:   util.PGBouncerLabels(name),
+			Container:  util.ContainerPGBouncer,
+			Port:       "pgbouncer",
+			SecretName: name + "-pgbouncer",
+			SecretKey:  "pgbouncer-frontend.ca-roots",
+		}
+		if custom, _, _ := unstructured.NestedString(cluster.Object,
+			"spec", "proxy", "pgBouncer", "customTLSSecret", "name"); custom != "" {
+			bouncer.SecretName, bouncer.SecretKey = custom, "ca.crt"
+		}
+		endpoints = append(endpoints, bouncer)
+	}
+
+	return endpoints
+}
+
+// containerPort returns the number of the port named name in container of pod,
+// or otherwise when there is no such port.
+func containerPort(pod *corev1.Pod, container, name string, otherwise int32) int32 {
+	for _, c := range pod.Spec.Containers {
+		if c.Name != container {
+			continue
+		}
+		for _, port := range c.Ports {
+			if port.Name == name {
+				return port.ContainerPort
+			}
+		}
+	}
+	return otherwise
+}
+
+// tlsModeResult is the outcome of connecting with one sslmode.
+type tlsModeResult struct {
+	Mode  string
+	Error error
+
+	// State is set when the TLS handshake finished.
+	State *tls.ConnectionState
+}
+
+// checkTLSModes connects using dial once for each sslmode that uses TLS. The
+// server must present a certificate signed by roots and, for verify-full, one
+// that is valid for serverName.
+func checkTLSModes(dial func() (net.Conn, error), serverName string, roots *x509.CertPool) []tlsModeResult {
+	verify := func(hostname string) func([][]byte, [][]*x509.Certificate) error {
+		return func(raw [][]byte, _ [][]*x509.Certificate) error {
+			certificates := make([]*x509.Certificate, len(raw))
+			for i := range raw {
+				var err error
+				if certificates[i], err = x509.ParseCertificate(raw[i]); err != nil {
+					return err
+				}
+			}
+			if len(certificates) == 0 {
+				return errors.New("the server presented no certificate")
+			}
+
+			intermediates := x509.NewCertPool()
+			for _, certificate := range certificates[1:] {
+				intermediates.AddCert(certificate)
+			}
+
+			// Like libpq, do not check the purpose of the certificate.
+			_, err := certificates[0].Verify(x509.VerifyOptions{
+				DNSName:       hostname,
+				Roots:         roots,
+				Intermediates: intermediates,
+				KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+			})
+			return err
+		}
+	}
+
+	modes := []struct {
+		name   string
+		config *tls.Config
+	}{
+		// #nosec G402 -- sslmode=require does not verify the certificate.
+		{"require", &tls.Config{InsecureSkipVerify: true}},
+		// #nosec G402 -- The certificate is verified below.
+		{"verify-ca", &tls.Config{InsecureSkipVerify: true, VerifyPeerCertificate: verify("")}},
+		// #nosec G402 -- The certificate is verified below.
+		{"verify-full", &tls.Config{InsecureSkipVerify: true, VerifyPeerCertificate: verify(serverName)}},
+	}
+
+	results := make([]tlsModeResult, 0, len(modes))
+	for _, mode := range modes {
+		result := tlsModeResult{Mode: mode.name}
+		result.State, result.Error = postgresTLSHandshake(dial, mode.config)
+		results = append(results, result)
+	}
+	return results
+}
+
+// postgresTLSHandshake connects using dial and asks the Postgres server to
+// start TLS. It returns the state of the TLS connection after the handshake.
+// - https://www.postgresql.org/docs/current/protocol-flow.html#PROTOCOL-FLOW-SSL
+func postgresTLSHandshake(dial func() (net.Conn, error), config *tls.Config) (*tls.ConnectionState, error) {
+	conn, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	// SSLRequest is a length followed by a special protocol version.
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:4], 8)
+	binary.BigEndian.PutUint32(request[4:8], 80877103)
+	if _, err := conn.Write(request); err != nil {
+		return nil, err
+	}
+
+	response := make([]byte, 1)
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return nil, err
+	}
+	if response[0] != 'S' {
+		return nil, errors.New("the server does not accept TLS connections")
+	}
+
+	client := tls.Client(conn, config)
+	if err := client.Handshake(); err != nil {
+		return nil, err
+	}
+	state := client.ConnectionState()
+	return &state, nil
+}
+
+// tlsGauges returns empty gauges for the outcome of each sslmode and for the
+// expiration of each certificate that servers present.
+func tlsGauges() (healthy, expiry *promGauge) {
+	healthy = &promGauge{
+		Name: "pgo_check_tls_healthy",
+		Help: "Whether a connection to an endpoint with an sslmode succeeded (1) or failed (0).",
+	}
+	expiry = &promGauge{
+		Name: "pgo_tls_certificate_expiry_timestamp_seconds",
+		Help: "When a certificate that an endpoint presented expires, in seconds since the Unix epoch.",
+	}
+	return healthy, expiry
+}
+
+// addTLSGauges adds the outcome of every sslmode in results to healthy and the
+// certificate chain of the first successful connection to expiry.
+func addTLSGauges(healthy, expiry *promGauge, endpoint string, results []tlsModeResult) {
+	for _, result := range results {
+		healthy.add(promBool(result.Error == nil), "endpoint", endpoint, "sslmode", result.Mode)
+	}
+	for _, result := range results {
+		if result.State == nil {
+			continue
+		}
+		for i, certificate := range result.State.PeerCertificates {
+			expiry.add(float64(certificate.NotAfter.Unix()), "endpoint", endpoint,
+				"position", strconv.Itoa(i), "subject", certificate.Subject.String())
+		}
+		break
+	}
+}
+
+// printTLSResults prints the TLS details of the first successful connection in
+// results followed by the outcome of every sslmode.
+func printTLSResults(cmd *cobra.Command, results []tlsModeResult) {
+	for _, result := range results {
+		if result.State == nil {
+			continue
+		}
+		cmd.Printf("  protocol: %s\n", tls.VersionName(result.State.Version))
+		cmd.Printf("  cipher: %s\n", tls.CipherSuiteName(result.State.CipherSuite))
+		cmd.Println("  certificate chain:")
+		for i, certificate := range result.State.PeerCertificates {
+			cmd.Printf("    %d: %s; issued by %s; expires %s\n", i,
+				certificate.Subject, certificate.Issuer,
+				certificate.NotAfter.UTC().Format(time.RFC3339))
+		}
+		break
+	}
+
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "  SSLMODE\tRESULT")
+	for _, result := range results {
+		outcome := "ok"
+		if result.Error != nil {
+			outcome = "FAILED: " + result.Error.Error()
+		}
+		_, _ = fmt.Fprintf(writer, "  %s\t%s\n", result.Mode, outcome)
+	}
+	_ = writer.Flush()
+}
@@ -0,0 +1,193 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestTLSEndpoints(t *testing.T) {
+	cluster := &unstructured.Unstructured{Object: map[string]any{}}
+	cluster.SetName("hippo")
+
+	endpoints := tlsEndpoints(cluster)
+	assert.Equal(t, len(endpoints), 1)
+	assert.Equal(t, endpoints[0].Name, "primary")
+	assert.Equal(t, endpoints[0].SecretName, "hippo-cluster-cert")
+	assert.Equal(t, endpoints[0].SecretKey, "ca.crt")
+
+	assert.NilError(t, unstructured.SetNestedField(cluster.Object, "my-tls",
+		"spec", "customTLSSecret", "name"))
+	assert.NilError(t, unstructured.SetNestedMap(cluster.Object, map[string]any{},
+		"spec", "proxy", "pgBouncer"))
+
+	endpoints = tlsEndpoints(cluster)
+	assert.Equal(t, len(endpoints), 2)
+	assert.Equal(t, endpoints[0].SecretName, "my-tls")
+	assert.Equal(t, endpoints[1].Name, "pgbouncer")
+	assert.Equal(t, endpoints[1].SecretName, "hippo-pgbouncer")
+	assert.Equal(t, endpoints[1].SecretKey, "pgbouncer-frontend.ca-roots")
+
+	assert.NilError(t, unstructured.SetNestedField(cluster.Object, "bouncer-tls",
+		"spec", "proxy", "pgBouncer", "customTLSSecret", "name"))
+	endpoints = tlsEndpoints(cluster)
+	assert.Equal(t, endpoints[1].SecretName, "bouncer-tls")
+	assert.Equal(t, endpoints[1].SecretKey, "ca.crt")
+}
+
+func TestContainerPort(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{
+		{Name: "other", Ports: []corev1.ContainerPort{{Name: "postgres", ContainerPort: 1}}},
+		{Name: "database", Ports: []corev1.ContainerPort{{Name: "postgres", ContainerPort: 5433}}},
+	}}}
+
+	assert.Equal(t, containerPort(pod, "database", "postgres", 5432), int32(5433))
+	assert.Equal(t, containerPort(pod, "database", "missing", 5432), int32(5432))
+	assert.Equal(t, containerPort(pod, "pgbouncer", "pgbouncer", 5432), int32(5432))
+}
+
+func TestCheckTLSModes(t *testing.T) {
+	now := time.Now()
+
+	// Generate a CA and a server certificate signed by it.
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "postgres-operator-ca"},
+		NotBefore: now.Add(-time.Hour), NotAfter: now.Add(time.Hour),
+		IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	assert.NilError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	assert.NilError(t, err)
+
+	serverKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	serverDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2), Subject: pkix.Name{CommonName: "hippo-primary"},
+		DNSNames:  []string{"hippo-primary.ns.svc"},
+		NotBefore: now.Add(-time.Hour), NotAfter: now.Add(time.Hour),
+	}, caTemplate, &serverKey.PublicKey, caKey)
+	assert.NilError(t, err)
+
+	server := &tls.Config{Certificates: []tls.Certificate{{
+		Certificate: [][]byte{serverDER}, PrivateKey: serverKey,
+	}}}
+
+	// dial returns a connection to a fake Postgres server that answers an
+	// SSLRequest with accept. It listens on loopback rather than using
+	// [net.Pipe] so that writes do not wait for the other side to read.
+	dial := func(accept byte) func() (net.Conn, error) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NilError(t, err)
+		t.Cleanup(func() { _ = listener.Close() })
+
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				go func() {
+					defer conn.Close()
+					request := make([]byte, 8)
+					if _, err := io.ReadFull(conn, request); err != nil {
+						return
+					}
+					if _, err := conn.Write([]byte{accept}); err != nil || accept != 'S' {
+						return
+					}
+					_ = tls.Server(conn, server).Handshake()
+				}()
+			}
+		}()
+
+		return func() (net.Conn, error) {
+			return net.Dial("tcp", listener.Addr().String())
+		}
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	results := checkTLSModes(dial('S'), "hippo-primary.ns.svc", roots)
+	assert.Equal(t, len(results), 3)
+	for _, result := range results {
+		assert.NilError(t, result.Error, result.Mode)
+		assert.Assert(t, result.State != nil)
+	}
+
+	t.Run("WrongHost", func(t *testing.T) {
+		results := checkTLSModes(dial('S'), "hippo-primary.other.svc", roots)
+		assert.NilError(t, results[0].Error)
+		assert.NilError(t, results[1].Error)
+		assert.ErrorContains(t, results[2].Error, "hippo-primary.other.svc")
+	})
+
+	t.Run("WrongCA", func(t *testing.T) {
+		results := checkTLSModes(dial('S'), "hippo-primary.ns.svc", x509.NewCertPool())
+		assert.NilError(t, results[0].Error)
+		assert.ErrorContains(t, results[1].Error, "unknown authority")
+		assert.ErrorContains(t, results[2].Error, "unknown authority")
+	})
+
+	t.Run("NoTLS", func(t *testing.T) {
+		results := checkTLSModes(dial('N'), "hippo-primary.ns.svc", roots)
+		for _, result := range results {
+			assert.ErrorContains(t, result.Error, "does not accept TLS")
+		}
+	})
+
+	t.Run("Print", func(t *testing.T) {
+		var out bytes.Buffer
+		cmd := &cobra.Command{}
+		cmd.SetOut(&out)
+
+		printTLSResults(cmd, checkTLSModes(dial('S'), "hippo-primary.other.svc", roots))
+		assert.Assert(t, strings.Contains(out.String(), "  protocol: TLS 1.3\n"), out.String())
+		assert.Assert(t, strings.Contains(out.String(),
+			"    0: CN=hippo-primary; issued by CN=postgres-operator-ca; expires "), out.String())
+		assert.Assert(t, strings.Contains(out.String(), "  verify-ca    ok\n"), out.String())
+		assert.Assert(t, strings.Contains(out.String(), "  verify-full  FAILED: "), out.String())
+	})
+
+	t.Run("Gauges", func(t *testing.T) {
+		healthy, expiry := tlsGauges()
+		addTLSGauges(healthy, expiry, "primary",
+			checkTLSModes(dial('S'), "hippo-primary.other.svc", roots))
+		healthy.add(0, "endpoint", "pgbouncer")
+
+		var out bytes.Buffer
+		assert.NilError(t, writePromGauges(&out, nil, healthy, expiry))
+		assert.Assert(t, strings.Contains(out.String(),
+			`pgo_check_tls_healthy{endpoint="primary",sslmode="verify-ca"} 1`+"\n"), out.String())
+		assert.Assert(t, strings.Contains(out.String(),
+			`pgo_check_tls_healthy{endpoint="primary",sslmode="verify-full"} 0`+"\n"), out.String())
+		assert.Assert(t, strings.Contains(out.String(),
+			`pgo_check_tls_healthy{endpoint="pgbouncer"} 0`+"\n"), out.String())
+		assert.Assert(t, strings.Contains(out.String(),
+			`pgo_tls_certificate_expiry_timestamp_seconds{endpoint="primary",position="0",subject="CN=hippo-primary"} `+
+				strconv.FormatInt(now.Add(time.Hour).Unix(), 10)+"\n"), out.String())
+	})
+}
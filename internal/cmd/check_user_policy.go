@@ -0,0 +1,212 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newCheckUserPolicyCommand returns the user-policy subcommand of the check
+// command. It compares the policies of "set user-policy" with Postgres.
+func newCheckUserPolicyCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "user-policy CLUSTER_NAME",
+		Short: "Check that Postgres users still have their recorded policies",
+		Long: `Compare the connection limit and role settings of each user in the
+"postgres-operator.crunchydata.com/pgo-user-policies" annotation of a
+PostgresCluster with those in Postgres. The annotation is written by
+"pgo set user-policy"; a difference means someone changed the user since.
+
+Use "--output=json" for checks that scripts can read. Use "--output=prom" for
+gauges in the Prometheus text format, such as for the textfile collector of
+node_exporter.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    pods                                                [list]
+    pods/exec                                           [create]
+    postgresclusters.postgres-operator.crunchydata.com  [get]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Check the user policies of the 'hippo' postgrescluster
+pgo check user-policy hippo
+
+### Example output
+CHECK        RESULT  DETAIL
+user-policy  ok      app: connection limit 50, statement_timeout 30000ms
+user-policy  FAILED  report: connection limit is 80, not 10
+Error: 1 of 2 checks failed`)
+
+	outputEnum := util.TextReadiness
+	cmd.Flags().VarP(&outputEnum, "output", "o", "output format. types supported: text,json,prom")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		_, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		cluster, err := client.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		policies, err := parseUserPolicies(cluster)
+		if err != nil {
+			return err
+		}
+		if len(policies) == 0 {
+			return fmt.Errorf("postgresclusters/%s has no user policies; set them with \"pgo set user-policy\"", args[0])
+		}
+
+		exec, err := getPrimaryExec(config, args)
+		if err != nil {
+			return err
+		}
+		stdout, stderr, err := podexec.PSQL(exec, "", userRolesSQL(sortedKeys(policies)))
+		if err != nil {
+			return commandError(err, stderr)
+		}
+		checks := checkUserPolicies(policies, parseUserRoles(stdout))
+
+		switch outputEnum {
+		case util.JSONReadiness:
+			b, err := json.MarshalIndent(checks, "", "  ")
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(b))
+		case util.PromReadiness:
+			if err := writePromGauges(cmd.OutOrStdout(),
+				[]string{"cluster", args[0], "namespace", namespace},
+				userPolicyGauge(checks), checkTimestampGauge("user-policy", time.Now())); err != nil {
+				return err
+			}
+		default:
+			if err := printReadinessChecks(cmd, checks); err != nil {
+				return err
+			}
+		}
+
+		var failed int
+		for _, check := range checks {
+			if !check.Healthy {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// userRolesSQL returns a query for the connection limit and role settings of
+// users. Settings for every database are in rolconfig.
+func userRolesSQL(users []string) string {
+	quoted := make([]string, len(users))
+	for i, user := range users {
+		quoted[i] = quoteLiteral(user)
+	}
+	return `SELECT rolname, rolconnlimit, COALESCE(pg_catalog.array_to_string(rolconfig, E'\t'), '')
+FROM pg_catalog.pg_roles WHERE rolname IN (` + strings.Join(quoted, ", ") + `) ORDER BY 1;`
+}
+
+// parseUserRoles reads the output of [userRolesSQL] into policies by user.
+func parseUserRoles(stdout string) map[string]userPolicy {
+	roles := map[string]userPolicy{}
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 {
+			continue
+		}
+		limit, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		role := userPolicy{ConnectionLimit: &limit, Settings: map[string]string{}}
+		for _, setting := range fields[2:] {
+			if name, value, ok := strings.Cut(setting, "="); ok {
+				role.Settings[name] = value
+			}
+		}
+		roles[fields[0]] = role
+	}
+	return roles
+}
+
+// checkUserPolicies compares the recorded policies with roles, both by user.
+func checkUserPolicies(policies, roles map[string]userPolicy) []readinessCheck {
+	var checks []readinessCheck
+	for _, user := range sortedKeys(policies) {
+		policy := policies[user]
+		check := readinessCheck{Name: "user-policy", Subject: user}
+
+		role, ok := roles[user]
+		if !ok {
+			check.Detail = user + ": role does not exist"
+			checks = append(checks, check)
+			continue
+		}
+
+		var drift []string
+		if want := policy.ConnectionLimit; want != nil && *role.ConnectionLimit != *want {
+			drift = append(drift, fmt.Sprintf("connection limit is %d, not %d", *role.ConnectionLimit, *want))
+		}
+		for _, name := range sortedKeys(policy.Settings) {
+			want := policy.Settings[name]
+			if got, ok := role.Settings[name]; !ok {
+				drift = append(drift, fmt.Sprintf("%s is unset, not %s", name, want))
+			} else if got != want {
+				drift = append(drift, fmt.Sprintf("%s is %s, not %s", name, got, want))
+			}
+		}
+
+		check.Healthy = len(drift) == 0
+		check.Detail = user + ": " + policy.String()
+		if !check.Healthy {
+			check.Detail = user + ": " + strings.Join(drift, "; ")
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// userPolicyGauge returns checks as a gauge by user.
+func userPolicyGauge(checks []readinessCheck) *promGauge {
+	healthy := &promGauge{
+		Name: "pgo_check_user_policy_healthy",
+		Help: "Whether a user in Postgres has its recorded policy (1) or not (0).",
+	}
+	for _, check := range checks {
+		healthy.add(promBool(check.Healthy), "user", check.Subject)
+	}
+	return healthy
+}
@@ -0,0 +1,60 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestUserRolesSQL(t *testing.T) {
+	assert.Equal(t, userRolesSQL([]string{"app", "o'neil"}), `
+SELECT rolname, rolconnlimit, COALESCE(pg_catalog.array_to_string(rolconfig, E'\t'), '')
+FROM pg_catalog.pg_roles WHERE rolname IN ('app', 'o''neil') ORDER BY 1;`[1:])
+}
+
+func TestCheckUserPolicies(t *testing.T) {
+	roles := parseUserRoles("" +
+		"app\t50\tstatement_timeout=30000ms\tsearch_path=app, public\n" +
+		"report\t80\t\n" +
+		"etl\t-1\tstatement_timeout=5min\n")
+
+	fifty, ten, none := int64(50), int64(10), int64(-1)
+	assert.DeepEqual(t, roles["app"], userPolicy{ConnectionLimit: &fifty, Settings: map[string]string{
+		"search_path": "app, public", "statement_timeout": "30000ms",
+	}})
+
+	checks := checkUserPolicies(map[string]userPolicy{
+		"app":    {ConnectionLimit: &fifty, Settings: map[string]string{"statement_timeout": "30000ms"}},
+		"report": {ConnectionLimit: &ten, Settings: map[string]string{"statement_timeout": "1000ms"}},
+		"etl":    {ConnectionLimit: &none, Settings: map[string]string{"statement_timeout": "3600000ms"}},
+		"gone":   {ConnectionLimit: &ten},
+	}, roles)
+
+	assert.DeepEqual(t, checks, []readinessCheck{
+		{Name: "user-policy", Subject: "app", Healthy: true,
+			Detail: "app: connection limit 50, statement_timeout 30000ms"},
+		{Name: "user-policy", Subject: "etl",
+			Detail: "etl: statement_timeout is 5min, not 3600000ms"},
+		{Name: "user-policy", Subject: "gone", Detail: "gone: role does not exist"},
+		{Name: "user-policy", Subject: "report",
+			Detail: "report: connection limit is 80, not 10; statement_timeout is unset, not 1000ms"},
+	})
+}
+
+func TestUserPolicyGauge(t *testing.T) {
+	var out bytes.Buffer
+	assert.NilError(t, writePromGauges(&out, []string{"cluster", "hippo"}, userPolicyGauge([]readinessCheck{
+		{Name: "user-policy", Subject: "app", Healthy: true},
+		{Name: "user-policy", Subject: "report"},
+	})))
+	assert.Equal(t, out.String(), `# HELP pgo_check_user_policy_healthy Whether a user in Postgres has its recorded policy (1) or not (0).
+# TYPE pgo_check_user_policy_healthy gauge
+pgo_check_user_policy_healthy{cluster="hippo",user="app"} 1
+pgo_check_user_policy_healthy{cluster="hippo",user="report"} 0
+`)
+}
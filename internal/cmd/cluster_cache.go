@@ -0,0 +1,197 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// clusterCache keeps the PostgresClusters and cluster Pods of one namespace in
+// memory and up to date with watches. Commands that look them up again and
+// again, like those with "--watch", read the cache rather than the API.
+// Each kind is listed and watched the first time it is read.
+type clusterCache struct {
+	clusters dynamicinformer.DynamicSharedInformerFactory
+	pods     informers.SharedInformerFactory
+
+	// ctx ends the watches when it is done. Each read waits at most
+	// syncTimeout for the first list of its kind.
+	ctx         context.Context
+	stop        context.CancelFunc
+	syncTimeout time.Duration
+}
+
+// clusterCacheSyncTimeout is how long a read waits for the first list of its
+// kind when the config has no request timeout. Without permission to list
+// or without a reachable API server, that list never finishes.
+const clusterCacheSyncTimeout = 5 * time.Second
+
+// newClusterCache returns a cache of the objects in namespace. Call Stop to
+// end its watches.
+func newClusterCache(kube kubernetes.Interface, client dynamic.Interface, namespace string) *clusterCache {
+	ctx, stop := context.WithCancel(context.Background())
+	return &clusterCache{
+		clusters: dynamicinformer.NewFilteredDynamicSharedInformerFactory(client, 0, namespace, nil),
+
+		// Only Pods of PostgresClusters are kept.
+		pods: informers.NewSharedInformerFactoryWithOptions(kube, 0,
+			informers.WithNamespace(namespace),
+			informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.LabelSelector = util.LabelCluster
+			})),
+		ctx:         ctx,
+		stop:        stop,
+		syncTimeout: clusterCacheSyncTimeout,
+	}
+}
+
+// newConfigClusterCache returns a cache of the namespace of config.
+func newConfigClusterCache(config *internal.Config) (*clusterCache, string, error) {
+	rest, err := config.ToRESTConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	kube, err := kubernetes.NewForConfig(rest)
+	if err != nil {
+		return nil, "", err
+	}
+	client, err := dynamic.NewForConfig(rest)
+	if err != nil {
+		return nil, "", err
+	}
+	namespace, err := config.Namespace()
+	if err != nil {
+		return nil, "", err
+	}
+	cache := newClusterCache(kube, client, namespace)
+	if rest.Timeout > 0 {
+		cache.syncTimeout = rest.Timeout
+	}
+	return cache, namespace, nil
+}
+
+// Stop ends the watches of cache.
+func (cache *clusterCache) Stop() { cache.stop() }
+
+// synced returns a channel that is closed when cache stops or its sync
+// timeout passes, and a function that releases it.
+func (cache *clusterCache) synced() (<-chan struct{}, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(cache.ctx, cache.syncTimeout)
+	return ctx.Done(), cancel
+}
+
+// ClusterNames returns the names of the PostgresClusters, sorted.
+func (cache *clusterCache) ClusterNames() ([]string, error) {
+	informer := cache.clusters.ForResource(v1beta1.GroupVersion.WithResource("postgresclusters"))
+	cache.clusters.Start(cache.ctx.Done())
+
+	deadline, cancel := cache.synced()
+	defer cancel()
+	for _, synced := range cache.clusters.WaitForCacheSync(deadline) {
+		if !synced {
+			return nil, fmt.Errorf("unable to list postgresclusters within %s", cache.syncTimeout)
+		}
+	}
+
+	objects, err := informer.Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(objects))
+	for _, object := range objects {
+		if accessor, err := meta.Accessor(object); err == nil {
+			names = append(names, accessor.GetName())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// InstancePods returns the instance Pods of the cluster named clusterName,
+// sorted by name.
+func (cache *clusterCache) InstancePods(clusterName string) ([]*corev1.Pod, error) {
+	selector, err := labels.Parse(util.DBInstanceLabels(clusterName))
+	if err != nil {
+		return nil, err
+	}
+
+	lister := cache.pods.Core().V1().Pods().Lister()
+	cache.pods.Start(cache.ctx.Done())
+
+	deadline, cancel := cache.synced()
+	defer cancel()
+	for _, synced := range cache.pods.WaitForCacheSync(deadline) {
+		if !synced {
+			return nil, fmt.Errorf("unable to list pods within %s", cache.syncTimeout)
+		}
+	}
+
+	pods, err := lister.List(selector)
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+	return pods, err
+}
+
+// completeClusterNames completes the first argument of a command with the
+// names of PostgresClusters in the namespace of config. There are no
+// completions when they cannot be listed in time.
+func completeClusterNames(config *internal.Config) func(
+	*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		cache, _, err := newConfigClusterCache(config)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		defer cache.Stop()
+
+		names, err := cache.ClusterNames()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		matches := names[:0]
+		for _, name := range names {
+			if strings.HasPrefix(name, toComplete) {
+				matches = append(matches, name)
+			}
+		}
+		return matches, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// addClusterCompletion completes the CLUSTER_NAME argument of the commands of
+// root that do not complete their arguments already.
+func addClusterCompletion(root *cobra.Command, config *internal.Config) {
+	var walk func(*cobra.Command)
+	walk = func(command *cobra.Command) {
+		if command.ValidArgsFunction == nil && len(command.ValidArgs) == 0 &&
+			strings.Contains(command.Use, " CLUSTER_NAME") {
+			command.ValidArgsFunction = completeClusterNames(config)
+		}
+		for _, child := range command.Commands() {
+			walk(child)
+		}
+	}
+	walk(root)
+}
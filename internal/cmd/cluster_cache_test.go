@@ -0,0 +1,126 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+func TestClusterCache(t *testing.T) {
+	cluster := func(namespace, name string) runtime.Object {
+		cluster := &unstructured.Unstructured{}
+		cluster.SetAPIVersion(v1beta1.GroupVersion.String())
+		cluster.SetKind("PostgresCluster")
+		cluster.SetNamespace(namespace)
+		cluster.SetName(name)
+		return cluster
+	}
+	pod := func(name string, labels ...string) *corev1.Pod {
+		pod := &corev1.Pod{}
+		pod.Namespace, pod.Name = "zoo", name
+		pod.Labels = map[string]string{}
+		for i := 0; i+1 < len(labels); i += 2 {
+			pod.Labels[labels[i]] = labels[i+1]
+		}
+		return pod
+	}
+
+	kube := kubefake.NewSimpleClientset(
+		pod("hippo-instance1-pwr2-0", util.LabelCluster, "hippo", util.LabelData, util.DataPostgres),
+		pod("hippo-instance1-8x7m-0", util.LabelCluster, "hippo", util.LabelData, util.DataPostgres),
+		pod("hippo-repo-host-0", util.LabelCluster, "hippo", util.LabelData, "pgbackrest"),
+		pod("rhino-instance1-abcd-0", util.LabelCluster, "rhino", util.LabelData, util.DataPostgres),
+		pod("unrelated"),
+	)
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			v1beta1.GroupVersion.WithResource("postgresclusters"): "PostgresClusterList",
+		},
+		cluster("zoo", "rhino"), cluster("zoo", "hippo"), cluster("elsewhere", "elephant"),
+	)
+
+	cache := newClusterCache(kube, client, "zoo")
+	t.Cleanup(cache.Stop)
+
+	names, err := cache.ClusterNames()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, names, []string{"hippo", "rhino"})
+
+	pods, err := cache.InstancePods("hippo")
+	assert.NilError(t, err)
+	assert.Equal(t, len(pods), 2)
+	assert.Equal(t, pods[0].Name, "hippo-instance1-8x7m-0")
+	assert.Equal(t, pods[1].Name, "hippo-instance1-pwr2-0")
+
+	pods, err = cache.InstancePods("lion")
+	assert.NilError(t, err)
+	assert.Equal(t, len(pods), 0)
+}
+
+func TestClusterCacheTimeout(t *testing.T) {
+	// Without permission to list, the first list never finishes.
+	forbidden := func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(
+			action.GetResource().GroupResource(), "", errors.New("no permission"))
+	}
+	kube := kubefake.NewSimpleClientset()
+	kube.PrependReactor("list", "*", forbidden)
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			v1beta1.GroupVersion.WithResource("postgresclusters"): "PostgresClusterList",
+		})
+	client.PrependReactor("list", "*", forbidden)
+
+	cache := newClusterCache(kube, client, "zoo")
+	cache.syncTimeout = 100 * time.Millisecond
+	t.Cleanup(cache.Stop)
+
+	_, err := cache.ClusterNames()
+	assert.Error(t, err, "unable to list postgresclusters within 100ms")
+
+	_, err = cache.InstancePods("hippo")
+	assert.Error(t, err, "unable to list pods within 100ms")
+}
+
+func TestAddClusterCompletion(t *testing.T) {
+	root := NewPGOCommand(strings.NewReader(""), io.Discard, io.Discard)
+
+	for _, args := range [][]string{
+		{"show", "vacuum-progress"},
+		{"scale", "postgrescluster"},
+		{"delete", "postgrescluster"},
+	} {
+		cmd, _, err := root.Find(args)
+		assert.NilError(t, err)
+		assert.Assert(t, cmd.ValidArgsFunction != nil, "%q", args)
+
+		// Only the first argument is a cluster name.
+		completions, directive := cmd.ValidArgsFunction(cmd, []string{"hippo"}, "")
+		assert.Equal(t, len(completions), 0)
+		assert.Equal(t, directive, cobra.ShellCompDirectiveNoFileComp)
+	}
+
+	cmd, _, err := root.Find([]string{"version"})
+	assert.NilError(t, err)
+	assert.Assert(t, cmd.ValidArgsFunction == nil)
+}
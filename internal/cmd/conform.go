@@ -0,0 +1,408 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newConformCommand returns the conform command. It compares the spec of
+// each PostgresCluster with a baseline that an organization requires.
+func newConformCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "conform",
+		Short: "Check PostgresClusters against an organization baseline",
+		Long: `Check the spec of every PostgresCluster in a namespace against a baseline
+that your organization requires. The baseline is a YAML file of rules:
+  - requiredLabels: labels every cluster must have (required-label)
+  - minReplicas: the fewest instances of each instance set (min-replicas)
+  - offsiteRepo: when true, a cluster must have an S3, GCS, or Azure
+    repository (offsite-repo)
+  - resources: the smallest requests and limits of each instance set
+    (resource-floor)
+
+    requiredLabels: [team, cost-center]
+    minReplicas: 2
+    offsiteRepo: true
+    resources:
+      requests: {cpu: 500m, memory: 1Gi}
+      limits: {memory: 2Gi}
+
+Use "--output=json" for the violations of each cluster, or "--output=sarif"
+for a SARIF 2.1.0 log that policy pipelines and code scanning tools read.
+The command fails when any cluster has a violation.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    postgresclusters.postgres-operator.crunchydata.com  [list]
+
+    Note: Using "--all-namespaces" requires cluster-scoped RBAC.
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Check the postgresclusters of every namespace against a baseline
+pgo conform -A --baseline=org-baseline.yaml
+
+# Write the violations for a policy pipeline
+pgo conform -A --baseline=org-baseline.yaml --output=sarif > conform.sarif
+
+### Example output
+NAMESPACE  CLUSTER  RULE            SUBJECT      DETAIL
+postgres   hippo    required-label  cost-center  label is missing
+postgres   hippo    min-replicas    instance1    1 replica; at least 2 required
+postgres   rhino    offsite-repo    rhino        no S3, GCS, or Azure repository
+Error: 2 of 3 clusters do not conform`)
+
+	var baselineFile string
+	cmd.Flags().StringVar(&baselineFile, "baseline", "", "path to a YAML file of the baseline")
+	cobra.CheckErr(cmd.MarkFlagRequired("baseline"))
+
+	var allNamespaces bool
+	cmd.Flags().BoolVarP(&allNamespaces, "all-namespaces", "A", false,
+		"check clusters in every namespace")
+
+	outputEnum := util.TextConform
+	cmd.Flags().VarP(&outputEnum, "output", "o", "output format. types supported: text,json,sarif")
+
+	cmd.Args = cobra.NoArgs
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		b, err := os.ReadFile(baselineFile)
+		if err != nil {
+			return err
+		}
+		baseline, err := parseConformBaseline(b)
+		if err != nil {
+			return fmt.Errorf("--baseline: %w", err)
+		}
+
+		_, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace := corev1.NamespaceAll
+		if !allNamespaces {
+			if namespace, err = config.Namespace(); err != nil {
+				return err
+			}
+		}
+
+		list, err := client.Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		clusters := list.Items
+		sort.Slice(clusters, func(i, j int) bool {
+			if clusters[i].GetNamespace() != clusters[j].GetNamespace() {
+				return clusters[i].GetNamespace() < clusters[j].GetNamespace()
+			}
+			return clusters[i].GetName() < clusters[j].GetName()
+		})
+
+		results := make([]conformResult, 0, len(clusters))
+		var failed int
+		for i := range clusters {
+			result := conformResult{
+				Namespace:  clusters[i].GetNamespace(),
+				Cluster:    clusters[i].GetName(),
+				Violations: conformViolations(&clusters[i], baseline),
+			}
+			if len(result.Violations) > 0 {
+				failed++
+			}
+			results = append(results, result)
+		}
+
+		switch outputEnum {
+		case util.JSONConform:
+			b, err := json.MarshalIndent(results, "", "  ")
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(b))
+		case util.SARIFConform:
+			b, err := json.MarshalIndent(conformSARIF(results), "", "  ")
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(b))
+		default:
+			if err := printConformResults(cmd, results); err != nil {
+				return err
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d of %d clusters do not conform", failed, len(results))
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// conformRules are the rules of [conformViolations] and what each requires.
+var conformRules = []struct{ ID, Text string }{
+	{"required-label", "A PostgresCluster must have the labels of the baseline."},
+	{"min-replicas", "Each instance set must have at least the replicas of the baseline."},
+	{"offsite-repo", "A PostgresCluster must have an S3, GCS, or Azure repository."},
+	{"resource-floor", "Each instance set must request at least the resources of the baseline."},
+}
+
+// conformBaseline is the file of the --baseline flag.
+type conformBaseline struct {
+	RequiredLabels []string `json:"requiredLabels"`
+	MinReplicas    int64    `json:"minReplicas"`
+	OffsiteRepo    bool     `json:"offsiteRepo"`
+	Resources      struct {
+		Requests corev1.ResourceList `json:"requests"`
+		Limits   corev1.ResourceList `json:"limits"`
+	} `json:"resources"`
+}
+
+// parseConformBaseline reads a baseline file and validates its rules.
+func parseConformBaseline(b []byte) (conformBaseline, error) {
+	var baseline conformBaseline
+	if err := yaml.UnmarshalStrict(b, &baseline); err != nil {
+		return baseline, err
+	}
+	if baseline.MinReplicas < 0 {
+		return baseline, fmt.Errorf("minReplicas must not be negative")
+	}
+	return baseline, nil
+}
+
+// conformViolation is one way a cluster differs from a baseline.
+type conformViolation struct {
+	Rule    string `json:"rule"`
+	Subject string `json:"subject"`
+	Detail  string `json:"detail"`
+}
+
+// conformResult is the violations of one cluster.
+type conformResult struct {
+	Namespace  string             `json:"namespace"`
+	Cluster    string             `json:"cluster"`
+	Violations []conformViolation `json:"violations"`
+}
+
+// conformViolations returns the ways the spec of cluster differs from
+// baseline, in the order of [conformRules].
+func conformViolations(cluster *unstructured.Unstructured, baseline conformBaseline) []conformViolation {
+	violations := []conformViolation{}
+
+	labels := cluster.GetLabels()
+	for _, label := range baseline.RequiredLabels {
+		if _, ok := labels[label]; !ok {
+			violations = append(violations, conformViolation{Rule: "required-label",
+				Subject: label, Detail: "label is missing"})
+		}
+	}
+
+	sets, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "instances")
+	for _, entry := range sets {
+		set, ok := entry.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(set, "name")
+
+		replicas, found, _ := unstructured.NestedInt64(set, "replicas")
+		if !found {
+			replicas = 1
+		}
+		if replicas < baseline.MinReplicas {
+			violations = append(violations, conformViolation{Rule: "min-replicas", Subject: name,
+				Detail: fmt.Sprintf("%d replica; at least %d required", replicas, baseline.MinReplicas)})
+		}
+	}
+
+	if baseline.OffsiteRepo {
+		repos, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "backups", "pgbackrest", "repos")
+		var offsite bool
+		for _, entry := range repos {
+			if repo, ok := entry.(map[string]any); ok {
+				offsite = offsite || repo["s3"] != nil || repo["gcs"] != nil || repo["azure"] != nil
+			}
+		}
+		if !offsite {
+			violations = append(violations, conformViolation{Rule: "offsite-repo",
+				Subject: cluster.GetName(), Detail: "no S3, GCS, or Azure repository"})
+		}
+	}
+
+	for _, entry := range sets {
+		set, ok := entry.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(set, "name")
+
+		for _, floor := range []struct {
+			field string
+			list  corev1.ResourceList
+		}{
+			{"requests", baseline.Resources.Requests},
+			{"limits", baseline.Resources.Limits},
+		} {
+			actual, _, _ := unstructured.NestedStringMap(set, "resources", floor.field)
+			for _, resourceName := range sortedResourceNames(floor.list) {
+				least := floor.list[resourceName]
+				value, ok := actual[string(resourceName)]
+				if !ok {
+					violations = append(violations, conformViolation{Rule: "resource-floor", Subject: name,
+						Detail: fmt.Sprintf("no %s %s; at least %s required", resourceName, floor.field, least.String())})
+					continue
+				}
+				quantity, err := resource.ParseQuantity(value)
+				if err != nil || quantity.Cmp(least) < 0 {
+					violations = append(violations, conformViolation{Rule: "resource-floor", Subject: name,
+						Detail: fmt.Sprintf("%s %s is %s; at least %s required", resourceName, floor.field, value, least.String())})
+				}
+			}
+		}
+	}
+
+	return violations
+}
+
+// sortedResourceNames returns the names of list in order.
+func sortedResourceNames(list corev1.ResourceList) []corev1.ResourceName {
+	names := make([]corev1.ResourceName, 0, len(list))
+	for name := range list {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// printConformResults prints the violations of results as a table.
+func printConformResults(cmd *cobra.Command, results []conformResult) error {
+	if len(results) == 0 {
+		cmd.Println("No PostgresClusters found")
+		return nil
+	}
+	var count int
+	for _, result := range results {
+		count += len(result.Violations)
+	}
+	if count == 0 {
+		cmd.Printf("all %d clusters conform to the baseline\n", len(results))
+		return nil
+	}
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "NAMESPACE\tCLUSTER\tRULE\tSUBJECT\tDETAIL")
+	for _, result := range results {
+		for _, violation := range result.Violations {
+			_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", result.Namespace, result.Cluster,
+				violation.Rule, violation.Subject, violation.Detail)
+		}
+	}
+	return writer.Flush()
+}
+
+// sarifLog is the part of a SARIF 2.1.0 log that [conformSARIF] writes.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool struct {
+		Driver sarifDriver `json:"driver"`
+	} `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+type sarifRule struct {
+	ID               string    `json:"id"`
+	ShortDescription sarifText `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifText       `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// conformSARIF returns the violations of results as one SARIF run. The
+// location of each is the cluster and the subject of the violation.
+func conformSARIF(results []conformResult) sarifLog {
+	run := sarifRun{Results: []sarifResult{}}
+	run.Tool.Driver = sarifDriver{
+		Name:           "pgo conform",
+		Version:        clientVersion,
+		InformationURI: "https://github.com/CrunchyData/postgres-operator-client",
+	}
+	for _, rule := range conformRules {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules,
+			sarifRule{ID: rule.ID, ShortDescription: sarifText{Text: rule.Text}})
+	}
+
+	for _, result := range results {
+		for _, violation := range result.Violations {
+			run.Results = append(run.Results, sarifResult{
+				RuleID: violation.Rule,
+				Level:  "error",
+				Message: sarifText{Text: fmt.Sprintf("%s/%s: %s: %s",
+					result.Namespace, result.Cluster, violation.Subject, violation.Detail)},
+				Locations: []sarifLocation{{LogicalLocations: []sarifLogicalLocation{{
+					Name:               result.Cluster,
+					FullyQualifiedName: result.Namespace + "/" + result.Cluster + "/" + violation.Subject,
+					Kind:               "resource",
+				}}}},
+			})
+		}
+	}
+
+	return sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+)
+
+func TestParseConformBaseline(t *testing.T) {
+	baseline, err := parseConformBaseline([]byte(`
+requiredLabels: [team]
+minReplicas: 2
+offsiteRepo: true
+resources:
+  requests: {cpu: 500m, memory: 1Gi}
+`))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, baseline.RequiredLabels, []string{"team"})
+	assert.Equal(t, baseline.MinReplicas, int64(2))
+	assert.Assert(t, baseline.OffsiteRepo)
+	assert.Equal(t, baseline.Resources.Requests.Memory().String(), "1Gi")
+
+	_, err = parseConformBaseline([]byte(`minReplica: 2`))
+	assert.ErrorContains(t, err, "minReplica")
+
+	_, err = parseConformBaseline([]byte(`minReplicas: -1`))
+	assert.ErrorContains(t, err, "negative")
+}
+
+func TestConformViolations(t *testing.T) {
+	baseline, err := parseConformBaseline([]byte(`
+requiredLabels: [team, cost-center]
+minReplicas: 2
+offsiteRepo: true
+resources:
+  requests: {cpu: 500m, memory: 1Gi}
+  limits: {memory: 2Gi}
+`))
+	assert.NilError(t, err)
+
+	t.Run("Conforms", func(t *testing.T) {
+		cluster := guardrailCluster(t, `
+metadata: {name: hippo, labels: {team: data, cost-center: "42"}}
+spec:
+  instances:
+  - name: instance1
+    replicas: 3
+    resources:
+      requests: {cpu: "1", memory: 1Gi}
+      limits: {memory: 4Gi}
+  backups:
+    pgbackrest:
+      repos:
+      - name: repo1
+        volume: {}
+      - name: repo2
+        s3: {bucket: backups}
+`)
+		assert.DeepEqual(t, conformViolations(cluster, baseline), []conformViolation{})
+	})
+
+	t.Run("Violations", func(t *testing.T) {
+		cluster := guardrailCluster(t, `
+metadata: {name: rhino, labels: {team: data}}
+spec:
+  instances:
+  - name: instance1
+    resources:
+      requests: {cpu: 250m, memory: 1Gi}
+  backups:
+    pgbackrest:
+      repos:
+      - name: repo1
+        volume: {}
+`)
+		assert.DeepEqual(t, conformViolations(cluster, baseline), []conformViolation{
+			{Rule: "required-label", Subject: "cost-center", Detail: "label is missing"},
+			{Rule: "min-replicas", Subject: "instance1", Detail: "1 replica; at least 2 required"},
+			{Rule: "offsite-repo", Subject: "rhino", Detail: "no S3, GCS, or Azure repository"},
+			{Rule: "resource-floor", Subject: "instance1", Detail: "cpu requests is 250m; at least 500m required"},
+			{Rule: "resource-floor", Subject: "instance1", Detail: "no memory limits; at least 2Gi required"},
+		})
+	})
+}
+
+func TestPrintConformResults(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	assert.NilError(t, printConformResults(cmd, []conformResult{
+		{Namespace: "postgres", Cluster: "hippo", Violations: []conformViolation{
+			{Rule: "required-label", Subject: "cost-center", Detail: "label is missing"},
+		}},
+		{Namespace: "postgres", Cluster: "zebra", Violations: []conformViolation{}},
+	}))
+	assert.Equal(t, out.String(), `
+NAMESPACE  CLUSTER  RULE            SUBJECT      DETAIL
+postgres   hippo    required-label  cost-center  label is missing
+`[1:])
+
+	out.Reset()
+	assert.NilError(t, printConformResults(cmd, []conformResult{
+		{Namespace: "postgres", Cluster: "zebra", Violations: []conformViolation{}},
+	}))
+	assert.Equal(t, out.String(), "all 1 clusters conform to the baseline\n")
+}
+
+func TestConformSARIF(t *testing.T) {
+	log := conformSARIF([]conformResult{
+		{Namespace: "postgres", Cluster: "hippo", Violations: []conformViolation{
+			{Rule: "min-replicas", Subject: "instance1", Detail: "1 replica; at least 2 required"},
+		}},
+	})
+	b, err := json.Marshal(log)
+	assert.NilError(t, err)
+
+	var parsed struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []map[string]any `json:"results"`
+		} `json:"runs"`
+	}
+	assert.NilError(t, json.Unmarshal(b, &parsed))
+	assert.Equal(t, parsed.Version, "2.1.0")
+	assert.Equal(t, len(parsed.Runs), 1)
+	assert.Equal(t, len(parsed.Runs[0].Tool.Driver.Rules), len(conformRules))
+	assert.DeepEqual(t, parsed.Runs[0].Results, []map[string]any{{
+		"ruleId":  "min-replicas",
+		"level":   "error",
+		"message": map[string]any{"text": "postgres/hippo: instance1: 1 replica; at least 2 required"},
+		"locations": []any{map[string]any{"logicalLocations": []any{map[string]any{
+			"name":               "hippo",
+			"fullyQualifiedName": "postgres/hippo/instance1",
+			"kind":               "resource",
+		}}}},
+	}})
+}
@@ -0,0 +1,25 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// newCopyCommand returns the copy subcommand of the PGO plugin.
+// Subcommands of copy move data from one PostgresCluster to another.
+func newCopyCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "copy",
+		Short: "Copy data between PostgresClusters",
+		Long:  "Copy data between PostgresClusters",
+	}
+
+	cmd.AddCommand(newCopyTableCommand(config))
+
+	return cmd
+}
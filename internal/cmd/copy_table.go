@@ -0,0 +1,338 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newCopyTableCommand returns the table subcommand of the copy command. It
+// streams the rows of a table from one cluster into a table of another.
+func newCopyTableCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "table TABLE",
+		Short: "Copy the rows of a table from one PostgresCluster to another",
+		Long: `Copy the rows of TABLE, like "public.countries", from the database "--dbname"
+of the PostgresCluster "--from" to the same table of the PostgresCluster "--to".
+Rows are read by "COPY ... TO STDOUT" on the primary of "--from" and written by
+"COPY ... FROM STDIN" on the primary of "--to", through this command; nothing
+is written to a file.
+
+Rows are written in batches of "--batch-rows", each in its own transaction,
+and progress is printed after each batch. When a batch fails, the batches
+before it stay written.
+
+Use "--columns" and "--where" to copy part of the table, or "--query" to copy
+the result of any SELECT into it. The table must already exist in "--to";
+"--truncate" deletes its rows first, after confirmation.
+
+Each of "--from" and "--to" is NAMESPACE/CLUSTER_NAME. Use "--from-context" and
+"--to-context" when the clusters are in different Kubernetes clusters; both
+default to the current context.
+
+### RBAC Requirements
+    Resources  Verbs
+    ---------  -----
+    pods       [list]
+    pods/exec  [create]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Copy the 'public.countries' table of the 'app' database from prod to dev
+pgo copy table public.countries --dbname=app --from=prod/hippo --to=dev/hippo
+
+# Replace the rows of a table with those of another Kubernetes cluster
+pgo copy table public.countries --dbname=app --from=prod/hippo --to=dev/hippo \
+  --from-context=prod --to-context=dev --truncate
+
+### Example output
+copying public.countries from prod/hippo-instance1-8dcl-0 to dev/hippo-instance1-wkq2-0
+copied 10000 rows (1.1MiB)
+copied 20000 rows (2.2MiB)
+copied 24351 rows (2.7MiB)
+copied 24351 rows of public.countries from prod/hippo to dev/hippo`)
+
+	var copying tableCopy
+	var from, to, fromContext, toContext string
+	cmd.Flags().StringVar(&from, "from", "", "the cluster to read, as NAMESPACE/CLUSTER_NAME")
+	cobra.CheckErr(cmd.MarkFlagRequired("from"))
+	cmd.Flags().StringVar(&to, "to", "", "the cluster to write, as NAMESPACE/CLUSTER_NAME")
+	cobra.CheckErr(cmd.MarkFlagRequired("to"))
+	cmd.Flags().StringVar(&fromContext, "from-context", "", "the kubeconfig context of --from")
+	cmd.Flags().StringVar(&toContext, "to-context", "", "the kubeconfig context of --to")
+	cmd.Flags().StringVar(&copying.SourceDatabase, "dbname", "", "database of the table in --from")
+	cobra.CheckErr(cmd.MarkFlagRequired("dbname"))
+	cmd.Flags().StringVar(&copying.TargetDatabase, "to-dbname", "", "database of the table in --to; defaults to --dbname")
+	cmd.Flags().StringSliceVar(&copying.Columns, "columns", nil, "columns to copy; all of them by default")
+	cmd.Flags().StringVar(&copying.Where, "where", "", "SQL condition of the rows to copy")
+	cmd.Flags().StringVar(&copying.Query, "query", "", "SELECT that returns the rows to copy")
+	cmd.MarkFlagsMutuallyExclusive("query", "where")
+	cmd.MarkFlagsMutuallyExclusive("query", "columns")
+	cmd.Flags().IntVar(&copying.BatchRows, "batch-rows", 10000, "rows written in each transaction")
+	cmd.Flags().BoolVar(&copying.Truncate, "truncate", false, "delete the rows of the table in --to first")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		copying.Table = args[0]
+		if copying.TargetDatabase == "" {
+			copying.TargetDatabase = copying.SourceDatabase
+		}
+		if err := copying.Validate(); err != nil {
+			return err
+		}
+		source, err := parseClusterReference("--from", from)
+		if err != nil {
+			return err
+		}
+		target, err := parseClusterReference("--to", to)
+		if err != nil {
+			return err
+		}
+		if source == target && fromContext == toContext && copying.SourceDatabase == copying.TargetDatabase {
+			return errors.New("--from and --to are the same table")
+		}
+
+		sourceExec, sourcePod, err := clusterPrimaryExec(ctx, contextConfig(config, fromContext), source)
+		if err != nil {
+			return err
+		}
+		targetExec, targetPod, err := clusterPrimaryExec(ctx, contextConfig(config, toContext), target)
+		if err != nil {
+			return err
+		}
+
+		if copying.Truncate {
+			_, _ = fmt.Fprint(config.ErrOut, config.Messages.Sprintf("copy.warn-truncate", copying.Table, to)+
+				config.Messages.Sprintf("confirm.continue"))
+			var confirmed *bool
+			for i := 0; confirmed == nil && i < 10; i++ {
+				// retry 10 times or until a confirmation is given or denied,
+				// whichever comes first
+				confirmed = config.Messages.Confirm(config.In, config.ErrOut)
+			}
+			if confirmed == nil || !*confirmed {
+				return ErrCancelled
+			}
+		}
+
+		cmd.PrintErrf("copying %s from %s/%s to %s/%s\n",
+			copying.Table, source.Namespace, sourcePod, target.Namespace, targetPod)
+		rows, err := copying.Run(sourceExec, targetExec, cmd.ErrOrStderr())
+		if err != nil {
+			return err
+		}
+		cmd.Printf("copied %d rows of %s from %s to %s\n", rows, copying.Table, from, to)
+		return nil
+	}
+
+	return cmd
+}
+
+// clusterPrimaryExec returns an Executor for the database container of the
+// ready primary of cluster and the name of its Pod.
+func clusterPrimaryExec(
+	ctx context.Context, config *internal.Config, cluster clusterReference,
+) (podexec.Executor, string, error) {
+	rest, err := config.ToRESTConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	client, err := v1.NewForConfig(rest)
+	if err != nil {
+		return nil, "", err
+	}
+	pods, err := client.Pods(cluster.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: util.PrimaryInstanceLabels(cluster.Name),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	var pod *corev1.Pod
+	for i := range pods.Items {
+		if podIsReady(&pods.Items[i]) {
+			pod = &pods.Items[i]
+		}
+	}
+	if pod == nil {
+		return nil, "", fmt.Errorf("no ready primary instance Pod found for %s/%s",
+			cluster.Namespace, cluster.Name)
+	}
+
+	podExec, err := util.NewPodExecutor(rest)
+	if err != nil {
+		return nil, "", err
+	}
+	return podexec.Container(podExec, cluster.Namespace, pod.GetName(), util.ContainerDatabase),
+		pod.GetName(), nil
+}
+
+// tableCopy is a copy of the rows of one table to another database.
+type tableCopy struct {
+	Table                          string
+	SourceDatabase, TargetDatabase string
+
+	// Columns, Where, and Query choose the rows to copy. Query is used
+	// instead of the others.
+	Columns []string
+	Where   string
+	Query   string
+
+	BatchRows int
+	Truncate  bool
+}
+
+// Validate returns an error when the options are not a complete request.
+func (copying tableCopy) Validate() error {
+	switch {
+	case copying.BatchRows < 1:
+		return errors.New("--batch-rows must be at least 1")
+	case copying.Table == "" || strings.HasPrefix(copying.Table, ".") || strings.HasSuffix(copying.Table, "."):
+		return fmt.Errorf("%q is not a table name", copying.Table)
+	}
+	return nil
+}
+
+// quoteIdentifier quotes name as an SQL identifier.
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// tableName returns Table quoted as a qualified SQL identifier.
+func (copying tableCopy) tableName() string {
+	schema, table, qualified := strings.Cut(copying.Table, ".")
+	if qualified {
+		return quoteIdentifier(schema) + "." + quoteIdentifier(table)
+	}
+	return quoteIdentifier(schema)
+}
+
+// columnList returns Columns quoted and separated by commas.
+func (copying tableCopy) columnList() string {
+	quoted := make([]string, len(copying.Columns))
+	for i, column := range copying.Columns {
+		quoted[i] = quoteIdentifier(strings.TrimSpace(column))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// SourceSQL returns the statement that prints the rows to copy.
+func (copying tableCopy) SourceSQL() string {
+	query := copying.Query
+	if query == "" {
+		columns := "*"
+		if len(copying.Columns) > 0 {
+			columns = copying.columnList()
+		}
+		query = "SELECT " + columns + " FROM " + copying.tableName()
+		if copying.Where != "" {
+			query += " WHERE " + copying.Where
+		}
+	}
+	return "COPY (" + strings.TrimRight(strings.TrimSpace(query), ";") + ") TO STDOUT;\n"
+}
+
+// TargetSQL returns a script that writes batch, rows in the text format of
+// COPY, in one transaction.
+func (copying tableCopy) TargetSQL(batch []byte) io.Reader {
+	target := copying.tableName()
+	if len(copying.Columns) > 0 {
+		target += " (" + copying.columnList() + ")"
+	}
+	return io.MultiReader(
+		strings.NewReader("COPY "+target+" FROM STDIN;\n"),
+		bytes.NewReader(batch),
+		strings.NewReader("\\.\n"))
+}
+
+// psqlCommand runs a script from stdin in database and stops at the first
+// error.
+func psqlCommand(database string) []string {
+	return []string{"psql", "--no-psqlrc", "--quiet", "--set=ON_ERROR_STOP=1",
+		"--dbname=" + database, "--file=-"}
+}
+
+// Run streams the rows from source into target and prints progress to out
+// after each batch. It returns the number of rows written.
+func (copying tableCopy) Run(source, target podexec.Executor, out io.Writer) (int64, error) {
+	if copying.Truncate {
+		if _, stderr, err := podexec.Output(target,
+			strings.NewReader("TRUNCATE "+copying.tableName()+";\n"), psqlCommand(copying.TargetDatabase)...); err != nil {
+			return 0, fmt.Errorf("unable to truncate %s: %w", copying.Table, commandError(err, stderr))
+		}
+	}
+
+	reader, writer := io.Pipe()
+	read := make(chan error, 1)
+	go func() {
+		var stderr bytes.Buffer
+		err := commandError(source.Exec(strings.NewReader(copying.SourceSQL()), writer, &stderr,
+			psqlCommand(copying.SourceDatabase)...), stderr.String())
+		_ = writer.CloseWithError(err)
+		read <- err
+	}()
+
+	var rows, size int64
+	err := copyBatches(bufio.NewReader(reader), copying.BatchRows, func(batch []byte, count int) error {
+		if _, stderr, err := podexec.Output(target, copying.TargetSQL(batch),
+			psqlCommand(copying.TargetDatabase)...); err != nil {
+			return fmt.Errorf("unable to write rows %d to %d: %w",
+				rows+1, rows+int64(count), commandError(err, stderr))
+		}
+		rows, size = rows+int64(count), size+int64(len(batch))
+		_, _ = fmt.Fprintf(out, "copied %d rows (%s)\n", rows, formatBytes(size))
+		return nil
+	})
+
+	// Stop reading when writing fails.
+	_ = reader.CloseWithError(err)
+	if sourceErr := <-read; sourceErr != nil && (err == nil || errors.Is(err, sourceErr)) {
+		err = fmt.Errorf("unable to read %s: %w", copying.Table, sourceErr)
+	}
+	return rows, err
+}
+
+// copyBatches calls write with every size rows of the text format of COPY in
+// r, then with the rest. Each row is one line; COPY escapes newlines in values.
+func copyBatches(r *bufio.Reader, size int, write func(batch []byte, rows int) error) error {
+	var batch []byte
+	var rows int
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			batch, rows = append(batch, line...), rows+1
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if rows > 0 && (rows == size || err != nil) {
+			if err := write(batch, rows); err != nil {
+				return err
+			}
+			batch, rows = batch[:0], 0
+		}
+		if err != nil {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+)
+
+func TestTableCopySQL(t *testing.T) {
+	copying := tableCopy{Table: `public.my"table`}
+	assert.Equal(t, copying.SourceSQL(), `COPY (SELECT * FROM "public"."my""table") TO STDOUT;`+"\n")
+
+	copying = tableCopy{Table: "countries", Columns: []string{"code", " name"}, Where: "active"}
+	assert.Equal(t, copying.SourceSQL(), `COPY (SELECT "code", "name" FROM "countries" WHERE active) TO STDOUT;`+"\n")
+
+	b, err := io.ReadAll(copying.TargetSQL([]byte("us\tUnited States\n")))
+	assert.NilError(t, err)
+	assert.Equal(t, string(b), `
+COPY "countries" ("code", "name") FROM STDIN;
+us	United States
+\.
+`[1:])
+
+	copying = tableCopy{Table: "countries", Query: " SELECT code FROM regions; "}
+	assert.Equal(t, copying.SourceSQL(), `COPY (SELECT code FROM regions) TO STDOUT;`+"\n")
+
+	assert.ErrorContains(t, tableCopy{Table: "x", BatchRows: 0}.Validate(), "--batch-rows")
+	assert.ErrorContains(t, tableCopy{Table: "public.", BatchRows: 1}.Validate(), "not a table name")
+	assert.NilError(t, tableCopy{Table: "public.x", BatchRows: 1}.Validate())
+}
+
+func TestCopyBatches(t *testing.T) {
+	var batches []string
+	write := func(batch []byte, rows int) error {
+		batches = append(batches, string(batch))
+		return nil
+	}
+
+	assert.NilError(t, copyBatches(bufio.NewReader(strings.NewReader("a\nb\nc\nd\ne\n")), 2, write))
+	assert.DeepEqual(t, batches, []string{"a\nb\n", "c\nd\n", "e\n"})
+
+	batches = nil
+	assert.NilError(t, copyBatches(bufio.NewReader(strings.NewReader("")), 2, write))
+	assert.Assert(t, batches == nil)
+
+	// A failed read does not write the rows after the last full batch.
+	batches = nil
+	reader, writer := io.Pipe()
+	go func() {
+		_, _ = writer.Write([]byte("a\nb\nc\n"))
+		_ = writer.CloseWithError(errors.New("boom"))
+	}()
+	assert.ErrorContains(t, copyBatches(bufio.NewReader(reader), 2, write), "boom")
+	assert.DeepEqual(t, batches, []string{"a\nb\n"})
+}
+
+func TestTableCopyRun(t *testing.T) {
+	source := &podexec.Fake{Replies: []podexec.Reply{
+		{Match: "TO STDOUT", Stdout: "1\tone\n2\ttwo\n3\tthree\n"},
+	}}
+	target := &podexec.Fake{Replies: []podexec.Reply{
+		{Match: "TRUNCATE"},
+		{Match: "FROM STDIN"},
+	}}
+
+	var out bytes.Buffer
+	copying := tableCopy{Table: "public.numbers", SourceDatabase: "app", TargetDatabase: "dev",
+		BatchRows: 2, Truncate: true}
+	rows, err := copying.Run(source, target, &out)
+	assert.NilError(t, err)
+	assert.Equal(t, rows, int64(3))
+	assert.Equal(t, out.String(), "copied 2 rows (12B)\ncopied 3 rows (20B)\n")
+
+	assert.Equal(t, len(target.Calls), 3)
+	assert.Equal(t, target.Calls[0].Stdin, `TRUNCATE "public"."numbers";`+"\n")
+	assert.Equal(t, target.Calls[2].Stdin, "COPY \"public\".\"numbers\" FROM STDIN;\n3\tthree\n\\.\n")
+	assert.DeepEqual(t, target.Calls[1].Command, []string{"psql", "--no-psqlrc", "--quiet",
+		"--set=ON_ERROR_STOP=1", "--dbname=dev", "--file=-"})
+
+	t.Run("WriteFails", func(t *testing.T) {
+		target := &podexec.Fake{Replies: []podexec.Reply{
+			{Match: "FROM STDIN", Stderr: "ERROR:  relation does not exist", Err: errors.New("exit 1")},
+		}}
+		copying.Truncate = false
+		rows, err := copying.Run(source, target, io.Discard)
+		assert.ErrorContains(t, err, "unable to write rows 1 to 2: exit 1: ERROR:  relation does not exist")
+		assert.Equal(t, rows, int64(0))
+	})
+
+	t.Run("ReadFails", func(t *testing.T) {
+		source := &podexec.Fake{Replies: []podexec.Reply{
+			{Match: "TO STDOUT", Stderr: "ERROR:  permission denied", Err: errors.New("exit 1")},
+		}}
+		copying.Truncate = false
+		_, err := copying.Run(source, target, io.Discard)
+		assert.ErrorContains(t, err, "unable to read public.numbers: exit 1: ERROR:  permission denied")
+	})
+}
@@ -6,18 +6,20 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
 	"strconv"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes"
 
 	"github.com/crunchydata/postgres-operator-client/internal"
 	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
-	"github.com/crunchydata/postgres-operator-client/internal/util"
 )
 
 // newCreateCommand returns the create subcommand of the PGO plugin.
@@ -44,10 +46,50 @@ func newCreateClusterCommand(config *internal.Config) *cobra.Command {
 		Short:   "Create PostgresCluster with a given name",
 		Long: `Create basic PostgresCluster with a given name.
 
+By default, the cluster has one instance with a 1Gi volume and a 1Gi backup
+repository. The "--replicas", "--disk-size", "--storage-class", and
+"--backup-disk-size" flags change them. The "--cpu" and "--memory" flags set
+the resource requests of each instance; "--memory" sets its limit, too.
+
+The "--sidecar-from-file" flag reads a YAML file with one container or a list
+of them and adds them to "spec.instances[].containers"; PGO runs them only
+when its "InstanceSidecars" feature gate is enabled. The "--init-sql" flag
+stores a SQL file in the ConfigMap "CLUSTER_NAME-init-sql" and points
+"spec.databaseInitSQL" at it; PGO runs it once, as a superuser, in the
+postgres database after the cluster is bootstrapped.
+
+The "--enable-monitoring" flag runs the Crunchy Postgres Exporter sidecar in
+every instance. With it, "--servicemonitor" or "--podmonitor" applies the
+objects the Prometheus operator uses to scrape the exporter, and
+"--grafana-namespace" applies a starter dashboard in a ConfigMap labeled
+"grafana_dashboard" for the Grafana sidecar to load. Metrics are labeled with
+"pg_cluster" as in the PGO monitoring stack so "pgo generate alerts" works too.
+
+The "--from-file" flag reads a full or partial PostgresCluster in YAML or JSON,
+or stdin when it is "-", and merges it with the cluster above. Objects are
+merged field by field; lists and other values in the file replace those from
+the defaults and flags. Instance sets without a "dataVolumeClaimSpec" get the
+data volume of the defaults. The apiVersion, kind, name, and namespace may be
+omitted from the file but must match when present.
+
+With "--dry-run=client", the manifests are printed as YAML rather than created,
+and nothing is sent to the Kubernetes API.
+
 ### RBAC Requirements
     Resources                                           Verbs
     ---------                                           -----
+    configmaps                                          [patch]
+    deployments.apps                                    [list]
+    podmonitors.monitoring.coreos.com                   [patch]
     postgresclusters.postgres-operator.crunchydata.com  [create]
+    servicemonitors.monitoring.coreos.com               [patch]
+    services                                            [patch]
+
+    Note: Deployments of the operator are listed in all namespaces to warn
+    when none of them watches the namespace of the new cluster. The check is
+    skipped without permission to do so. ConfigMaps are only patched with
+    "--init-sql" or "--grafana-namespace", and monitoring objects only with
+    "--servicemonitor" or "--podmonitor".
 
 ### Usage`,
 	}
@@ -55,15 +97,50 @@ func newCreateClusterCommand(config *internal.Config) *cobra.Command {
 	cmd.Args = cobra.ExactArgs(1)
 
 	var pgMajorVersion int
-	cmd.Flags().IntVar(&pgMajorVersion, "pg-major-version", 0, "Set the Postgres major version")
-	cobra.CheckErr(cmd.MarkFlagRequired("pg-major-version"))
+	cmd.Flags().IntVar(&pgMajorVersion, "pg-major-version", 0,
+		"Set the Postgres major version; required unless --from-file sets spec.postgresVersion")
 
 	var backupsDisabled bool
 	cmd.Flags().BoolVar(&backupsDisabled, "disable-backups", false, "Disable backups")
 
+	shape := newClusterShape()
+	shape.AddFlags(cmd.Flags())
+
+	var extras instanceExtras
+	extras.AddFlags(cmd.Flags())
+
+	var monitoring clusterMonitoring
+	monitoring.AddFlags(cmd.Flags())
+
+	var file clusterFile
+	file.AddFlags(cmd.Flags())
+
+	var dryRun string
+	cmd.Flags().StringVar(&dryRun, "dry-run", "none",
+		`"client" prints the manifests to be created rather than creating them`)
+	cmd.Flags().Lookup("dry-run").NoOptDefVal = "client"
+
+	config.Record.AddFlags(cmd.Flags())
+
 	cmd.Example = internal.FormatExample(`# Create a postgrescluster with Postgres 15
 pgo create postgrescluster hippo --pg-major-version 15
 
+# Create a postgrescluster with three instances on fast, larger volumes
+pgo create postgrescluster hippo --pg-major-version 15 --replicas=3 \
+  --disk-size=50Gi --storage-class=fast --cpu=2 --memory=4Gi --backup-disk-size=100Gi
+
+# Create a postgrescluster that runs a bootstrap script and a logging agent
+pgo create postgrescluster hippo --pg-major-version 15 \
+  --init-sql=./bootstrap.sql --sidecar-from-file=sidecar.yaml
+
+# Create a postgrescluster that the Prometheus operator scrapes, with a
+# dashboard in the 'monitoring' namespace
+pgo create postgrescluster hippo --pg-major-version 15 \
+  --enable-monitoring --servicemonitor --grafana-namespace=monitoring
+
+# Print a postgrescluster from a partial spec on stdin without creating it
+cat hippo.yaml | pgo create postgrescluster hippo -f - --dry-run=client
+
 # Create a postgrescluster with backups disabled (only available in CPK v5.7+)
 # Requires confirmation
 pgo create postgrescluster hippo --disable-backups
@@ -76,39 +153,139 @@ postgresclusters/hippo created`)
 
 		clusterName := args[0]
 
+		if err := shape.Validate(); err != nil {
+			return err
+		}
+		if err := extras.Load(); err != nil {
+			return err
+		}
+		if err := monitoring.Validate(); err != nil {
+			return err
+		}
+		if dryRun != "none" && dryRun != "client" {
+			return fmt.Errorf(`--dry-run must be "none" or "client"`)
+		}
+		if err := file.Load(cmd.InOrStdin()); err != nil {
+			return err
+		}
+
 		namespace, err := config.Namespace()
 		if err != nil {
 			return err
 		}
 
-		mapping, client, err := v1beta1.NewPostgresClusterClient(config)
+		cluster, err := generateUnstructuredClusterYaml(clusterName, strconv.Itoa(pgMajorVersion))
+		if err != nil {
+			return err
+		}
+		if err := shape.modifyIntent(cluster); err != nil {
+			return err
+		}
+		if err := file.merge(cluster, namespace); err != nil {
+			return err
+		}
+		if version, _, _ := unstructured.NestedInt64(cluster.Object, "spec", "postgresVersion"); version < 1 {
+			return errors.New("--pg-major-version is required unless --from-file sets spec.postgresVersion")
+		}
+		if err := extras.modifyIntent(cluster, cluster); err != nil {
+			return err
+		}
+		if err := monitoring.modifyIntent(cluster); err != nil {
+			return err
+		}
+		monitoringObjects, err := monitoring.Objects(namespace, clusterName)
 		if err != nil {
 			return err
 		}
 
-		cluster, err := generateUnstructuredClusterYaml(clusterName, strconv.Itoa(pgMajorVersion))
+		// Print what would be created without calling the Kubernetes API.
+		if dryRun == "client" {
+			cluster.SetNamespace(namespace)
+			if backupsDisabled {
+				cmd.PrintErr(config.Messages.Sprintf("create.warn-no-backups"))
+				unstructured.RemoveNestedField(cluster.Object, "spec", "backups")
+			}
+
+			var objects []map[string]any
+			if extras.InitSQL != nil {
+				objects = append(objects, extras.ConfigMap(namespace, clusterName))
+			}
+			objects = append(objects, cluster.Object)
+			for _, object := range monitoringObjects {
+				objects = append(objects, object.Object)
+			}
+			return printManifests(cmd, objects)
+		}
+
+		mapping, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		rest, err := config.ToRESTConfig()
 		if err != nil {
 			return err
 		}
 
+		// An operator that does not watch this namespace never reconciles
+		// the cluster, and nothing reports why.
+		if clientset, err := kubernetes.NewForConfig(rest); err == nil {
+			scopes, _ := detectOperatorScopes(ctx, clientset)
+			if warning := unwatchedNamespaceWarning(&config.Messages, scopes, namespace); warning != "" {
+				_, _ = fmt.Fprint(config.ErrOut, warning+config.Messages.Sprintf("confirm.continue"))
+				var confirmed *bool
+				for i := 0; confirmed == nil && i < 10; i++ {
+					// retry 10 times or until a confirmation is given or denied,
+					// whichever comes first
+					confirmed = config.Messages.Confirm(config.In, config.ErrOut)
+				}
+				if confirmed == nil || !*confirmed {
+					return ErrCancelled
+				}
+			}
+		}
+
 		if backupsDisabled {
-			fmt.Print("WARNING: Running a production postgrescluster without backups " +
-				"is not recommended. \nAre you sure you want " +
-				"to continue without backups? (yes/no): ")
+			_, _ = fmt.Fprint(config.ErrOut, config.Messages.Sprintf("create.warn-no-backups")+
+				config.Messages.Sprintf("confirm.continue-backups"))
 			var confirmed *bool
 			for i := 0; confirmed == nil && i < 10; i++ {
 				// retry 10 times or until a confirmation is given or denied,
 				// whichever comes first
-				confirmed = util.Confirm(os.Stdin, os.Stdout)
+				confirmed = config.Messages.Confirm(config.In, config.ErrOut)
 			}
 
 			if confirmed == nil || !*confirmed {
-				return nil
+				return ErrCancelled
 			}
 
 			unstructured.RemoveNestedField(cluster.Object, "spec", "backups")
 		}
 
+		// Save the manifest for later rather than creating it.
+		if config.Record.Enabled() {
+			if extras.InitSQL != nil {
+				msg, err := recordConfigMap(config, extras.ConfigMap(namespace, clusterName))
+				if err != nil {
+					return err
+				}
+				cmd.Print(msg)
+			}
+			msg, err := recordChange(config, internal.NewRecordedChange(
+				internal.RecordCreate, mapping.Resource, namespace, clusterName, cluster))
+			cmd.Print(msg)
+			if err != nil {
+				return err
+			}
+			return recordMonitoringObjects(config, cmd, monitoringObjects)
+		}
+
+		// PGO looks for the init SQL when the cluster is bootstrapped.
+		if extras.InitSQL != nil {
+			if err := applyConfigMap(ctx, config, cmd, extras.ConfigMap(namespace, clusterName)); err != nil {
+				return err
+			}
+		}
+
 		u, err := client.
 			Namespace(namespace).
 			Create(ctx, cluster, config.Patch.CreateOptions(metav1.CreateOptions{}))
@@ -118,7 +295,9 @@ postgresclusters/hippo created`)
 
 		cmd.Printf("%s/%s created\n", mapping.Resource.Resource, u.GetName())
 
-		return nil
+		// The exporter starts with the cluster; objects that scrape it can
+		// wait until the cluster exists.
+		return applyMonitoringObjects(ctx, config, cmd, monitoringObjects)
 	}
 
 	return cmd
@@ -161,3 +340,101 @@ spec:
 
 	return &cluster, nil
 }
+
+// clusterShape is the size of a new PostgresCluster: its instances, their
+// resources, and its volumes.
+type clusterShape struct {
+	Replicas       int
+	DiskSize       resource.Quantity
+	StorageClass   string
+	CPU, Memory    resource.Quantity
+	BackupDiskSize resource.Quantity
+}
+
+// newClusterShape returns the shape of [generateUnstructuredClusterYaml].
+func newClusterShape() clusterShape {
+	return clusterShape{
+		Replicas:       1,
+		DiskSize:       resource.MustParse("1Gi"),
+		BackupDiskSize: resource.MustParse("1Gi"),
+	}
+}
+
+// AddFlags adds the flags of shape to flags.
+func (shape *clusterShape) AddFlags(flags *pflag.FlagSet) {
+	flags.IntVar(&shape.Replicas, "replicas", shape.Replicas, "number of Postgres instances")
+	flags.Var(&quantityFlag{&shape.DiskSize}, "disk-size", "size of the data volume of each instance")
+	flags.StringVar(&shape.StorageClass, "storage-class", "",
+		"storage class of the data and backup volumes; defaults to that of the Kubernetes cluster")
+	flags.Var(&quantityFlag{&shape.CPU}, "cpu", "CPU request of each instance, e.g. 500m")
+	flags.Var(&quantityFlag{&shape.Memory}, "memory", "memory request and limit of each instance, e.g. 2Gi")
+	flags.Var(&quantityFlag{&shape.BackupDiskSize}, "backup-disk-size", "size of the volume of the backup repository")
+}
+
+// Validate returns an error when PGO would reject the shape.
+func (shape clusterShape) Validate() error {
+	switch {
+	case shape.Replicas < 1:
+		return errors.New("--replicas must be at least 1")
+	case shape.DiskSize.Sign() <= 0:
+		return errors.New("--disk-size must be greater than zero")
+	case shape.BackupDiskSize.Sign() <= 0:
+		return errors.New("--backup-disk-size must be greater than zero")
+	case shape.CPU.Sign() < 0 || shape.Memory.Sign() < 0:
+		return errors.New("--cpu and --memory must not be negative")
+	}
+	return nil
+}
+
+// modifyIntent sets the shape of the first instance set and the first
+// repository of cluster.
+func (shape clusterShape) modifyIntent(cluster *unstructured.Unstructured) error {
+	sets, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "instances")
+	if len(sets) > 0 {
+		set := sets[0].(map[string]any)
+		if shape.Replicas != 1 {
+			set["replicas"] = int64(shape.Replicas)
+		}
+		if err := shape.modifyVolume(set, shape.DiskSize, "dataVolumeClaimSpec"); err != nil {
+			return err
+		}
+		if !shape.CPU.IsZero() {
+			if err := unstructured.SetNestedField(set, shape.CPU.String(), "resources", "requests", "cpu"); err != nil {
+				return err
+			}
+		}
+		if !shape.Memory.IsZero() {
+			if err := unstructured.SetNestedField(set, shape.Memory.String(), "resources", "requests", "memory"); err != nil {
+				return err
+			}
+			if err := unstructured.SetNestedField(set, shape.Memory.String(), "resources", "limits", "memory"); err != nil {
+				return err
+			}
+		}
+		if err := unstructured.SetNestedSlice(cluster.Object, sets, "spec", "instances"); err != nil {
+			return err
+		}
+	}
+
+	repos, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "backups", "pgbackrest", "repos")
+	if len(repos) > 0 {
+		if err := shape.modifyVolume(repos[0].(map[string]any), shape.BackupDiskSize, "volume", "volumeClaimSpec"); err != nil {
+			return err
+		}
+		return unstructured.SetNestedSlice(cluster.Object, repos, "spec", "backups", "pgbackrest", "repos")
+	}
+	return nil
+}
+
+// modifyVolume sets the size and storage class of the volume claim at fields
+// in object.
+func (shape clusterShape) modifyVolume(object map[string]any, size resource.Quantity, fields ...string) error {
+	if err := unstructured.SetNestedField(object, size.String(),
+		append(fields, "resources", "requests", "storage")...); err != nil {
+		return err
+	}
+	if shape.StorageClass != "" {
+		return unstructured.SetNestedField(object, shape.StorageClass, append(fields, "storageClassName")...)
+	}
+	return nil
+}
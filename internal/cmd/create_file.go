@@ -0,0 +1,148 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+)
+
+// clusterFile is a full or partial PostgresCluster that "pgo create
+// postgrescluster" merges with the cluster it generates.
+type clusterFile struct {
+	Path string
+
+	Cluster *unstructured.Unstructured
+}
+
+// AddFlags adds the flag that names the file to flags.
+func (file *clusterFile) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&file.Path, "from-file", "f", "",
+		`path to a YAML or JSON PostgresCluster to merge with the defaults, or "-" to read stdin`)
+}
+
+// Load reads the file named by the flag, or stdin when it is "-".
+func (file *clusterFile) Load(stdin io.Reader) error {
+	var b []byte
+	var err error
+	switch file.Path {
+	case "":
+		return nil
+	case "-":
+		b, err = io.ReadAll(stdin)
+	default:
+		b, err = os.ReadFile(file.Path)
+	}
+	if err != nil {
+		return err
+	}
+	if file.Cluster, err = parseClusterFile(b); err != nil {
+		return fmt.Errorf("--from-file %s: %w", file.Path, err)
+	}
+	return nil
+}
+
+// parseClusterFile reads a PostgresCluster from YAML or JSON. Its apiVersion
+// and kind may be omitted.
+func parseClusterFile(b []byte) (*unstructured.Unstructured, error) {
+	var object map[string]any
+	if err := yaml.Unmarshal(b, &object); err != nil {
+		return nil, err
+	}
+	if len(object) == 0 {
+		return nil, errors.New("no PostgresCluster found")
+	}
+
+	apiVersion := v1beta1.GroupVersion.String()
+	if value, ok := object["apiVersion"]; ok && value != apiVersion {
+		return nil, fmt.Errorf("apiVersion must be %s", apiVersion)
+	}
+	if value, ok := object["kind"]; ok && value != "PostgresCluster" {
+		return nil, fmt.Errorf("kind must be PostgresCluster")
+	}
+	object["apiVersion"], object["kind"] = apiVersion, "PostgresCluster"
+
+	// Decode it again the way the API client does, with integers as int64.
+	b, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	cluster := new(unstructured.Unstructured)
+	return cluster, cluster.UnmarshalJSON(b)
+}
+
+// merge sets the fields of the file in cluster, which is in namespace. Objects
+// are merged; lists and other values in the file replace those in cluster.
+// Instance sets without a data volume get the one of the first instance set
+// in cluster.
+func (file clusterFile) merge(cluster *unstructured.Unstructured, namespace string) error {
+	if file.Cluster == nil {
+		return nil
+	}
+	if name := file.Cluster.GetName(); name != "" && name != cluster.GetName() {
+		return fmt.Errorf("--from-file %s: metadata.name is %q, not %q", file.Path, name, cluster.GetName())
+	}
+	if ns := file.Cluster.GetNamespace(); ns != "" && ns != namespace {
+		return fmt.Errorf("--from-file %s: metadata.namespace is %q, not %q", file.Path, ns, namespace)
+	}
+
+	var volume map[string]any
+	if sets, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "instances"); len(sets) > 0 {
+		volume, _, _ = unstructured.NestedMap(sets[0].(map[string]any), "dataVolumeClaimSpec")
+	}
+
+	cluster.Object = mergeDefaults(cluster.Object, runtime.DeepCopyJSON(file.Cluster.Object)).(map[string]any)
+
+	sets, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "instances")
+	for _, entry := range sets {
+		if set, ok := entry.(map[string]any); ok && set["dataVolumeClaimSpec"] == nil && volume != nil {
+			set["dataVolumeClaimSpec"] = runtime.DeepCopyJSONValue(volume)
+		}
+	}
+	if len(sets) > 0 {
+		return unstructured.SetNestedSlice(cluster.Object, sets, "spec", "instances")
+	}
+	return nil
+}
+
+// mergeDefaults returns defaults with the fields of value. Objects are merged;
+// everything else in value replaces defaults.
+func mergeDefaults(defaults, value any) any {
+	object, ok := value.(map[string]any)
+	current, isObject := defaults.(map[string]any)
+	if !ok || !isObject {
+		return value
+	}
+	for key, field := range object {
+		current[key] = mergeDefaults(current[key], field)
+	}
+	return current
+}
+
+// printManifests prints objects as YAML documents.
+func printManifests(cmd *cobra.Command, objects []map[string]any) error {
+	for i, object := range objects {
+		b, err := yaml.Marshal(object)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			cmd.Println("---")
+		}
+		cmd.Print(string(b))
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+
+	"github.com/crunchydata/postgres-operator-client/internal/testing/cmp"
+)
+
+func TestParseClusterFile(t *testing.T) {
+	t.Run("Partial", func(t *testing.T) {
+		cluster, err := parseClusterFile([]byte(`
+spec:
+  postgresVersion: 16
+  instances:
+  - name: big
+    replicas: 2
+`))
+		assert.NilError(t, err)
+		assert.Equal(t, cluster.GetKind(), "PostgresCluster")
+		assert.Equal(t, cluster.GetAPIVersion(), "postgres-operator.crunchydata.com/v1beta1")
+		assert.DeepEqual(t, cluster.Object["spec"].(map[string]any)["postgresVersion"], int64(16))
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		cluster, err := parseClusterFile([]byte(`{"kind":"PostgresCluster","spec":{"port":5433}}`))
+		assert.NilError(t, err)
+		assert.DeepEqual(t, cluster.Object["spec"], map[string]any{"port": int64(5433)})
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := parseClusterFile([]byte("  \n"))
+		assert.ErrorContains(t, err, "no PostgresCluster")
+
+		_, err = parseClusterFile([]byte("kind: Deployment"))
+		assert.ErrorContains(t, err, "kind must be PostgresCluster")
+
+		_, err = parseClusterFile([]byte("apiVersion: apps/v1"))
+		assert.ErrorContains(t, err, "apiVersion must be")
+	})
+}
+
+func TestClusterFileMerge(t *testing.T) {
+	t.Run("Stdin", func(t *testing.T) {
+		cluster, err := generateUnstructuredClusterYaml("hippo", "15")
+		assert.NilError(t, err)
+
+		file := clusterFile{Path: "-"}
+		assert.NilError(t, file.Load(strings.NewReader(`
+metadata:
+  name: hippo
+  labels:
+    team: data
+spec:
+  postgresVersion: 16
+  instances:
+  - name: big
+    replicas: 2
+  backups:
+    pgbackrest:
+      image: example.com/pgbackrest
+`)))
+		assert.NilError(t, file.merge(cluster, "postgres"))
+		assert.Assert(t, cmp.MarshalMatches(cluster.Object, `
+apiVersion: postgres-operator.crunchydata.com/v1beta1
+kind: PostgresCluster
+metadata:
+  labels:
+    team: data
+  name: hippo
+spec:
+  backups:
+    pgbackrest:
+      image: example.com/pgbackrest
+      repos:
+      - name: repo1
+        volume:
+          volumeClaimSpec:
+            accessModes:
+            - ReadWriteOnce
+            resources:
+              requests:
+                storage: 1Gi
+  instances:
+  - dataVolumeClaimSpec:
+      accessModes:
+      - ReadWriteOnce
+      resources:
+        requests:
+          storage: 1Gi
+    name: big
+    replicas: 2
+  postgresVersion: 16
+`))
+	})
+
+	t.Run("Unset", func(t *testing.T) {
+		cluster, err := generateUnstructuredClusterYaml("hippo", "15")
+		assert.NilError(t, err)
+		expect := cluster.DeepCopy()
+
+		var file clusterFile
+		assert.NilError(t, file.Load(strings.NewReader("ignored")))
+		assert.NilError(t, file.merge(cluster, "postgres"))
+		assert.DeepEqual(t, cluster, expect)
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		cluster, err := generateUnstructuredClusterYaml("hippo", "15")
+		assert.NilError(t, err)
+
+		file := clusterFile{Path: "-"}
+		assert.NilError(t, file.Load(strings.NewReader("metadata: {name: rhino}")))
+		assert.ErrorContains(t, file.merge(cluster, "postgres"), `metadata.name is "rhino", not "hippo"`)
+
+		assert.NilError(t, file.Load(strings.NewReader("metadata: {namespace: other}")))
+		assert.ErrorContains(t, file.merge(cluster, "postgres"), `metadata.namespace is "other"`)
+	})
+}
+
+func TestPrintManifests(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	assert.NilError(t, printManifests(cmd, []map[string]any{
+		{"kind": "ConfigMap"}, {"kind": "PostgresCluster"},
+	}))
+	assert.Equal(t, out.String(), "kind: ConfigMap\n---\nkind: PostgresCluster\n")
+}
@@ -0,0 +1,280 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// exporterPort is the port of the Crunchy Postgres Exporter sidecar. PGO
+// names it "exporter" in every instance Pod.
+const exporterPort = 9187
+
+// clusterMonitoring wires a new PostgresCluster into an existing Prometheus
+// and Grafana: the exporter sidecar, the objects the Prometheus operator
+// scrapes with, and a dashboard for the Grafana sidecar to load.
+type clusterMonitoring struct {
+	Enabled          bool
+	ServiceMonitor   bool
+	PodMonitor       bool
+	GrafanaNamespace string
+}
+
+// monitoringObject is an object to apply along with a PostgresCluster.
+type monitoringObject struct {
+	Resource schema.GroupVersionResource
+	Object   map[string]any
+}
+
+// AddFlags adds the flags of monitoring to flags.
+func (monitoring *clusterMonitoring) AddFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&monitoring.Enabled, "enable-monitoring", false,
+		"run the Crunchy Postgres Exporter sidecar in every instance")
+	flags.BoolVar(&monitoring.ServiceMonitor, "servicemonitor", false,
+		"apply a Service and ServiceMonitor so the Prometheus operator scrapes the exporter")
+	flags.BoolVar(&monitoring.PodMonitor, "podmonitor", false,
+		"apply a PodMonitor so the Prometheus operator scrapes the exporter")
+	flags.StringVar(&monitoring.GrafanaNamespace, "grafana-namespace", "",
+		"namespace of Grafana in which to apply a dashboard ConfigMap for the cluster")
+}
+
+// Validate returns an error when the flags of monitoring do not make sense
+// together.
+func (monitoring clusterMonitoring) Validate() error {
+	switch {
+	case monitoring.ServiceMonitor && monitoring.PodMonitor:
+		return errors.New("--servicemonitor and --podmonitor cannot be used together")
+	case !monitoring.Enabled && (monitoring.ServiceMonitor || monitoring.PodMonitor ||
+		monitoring.GrafanaNamespace != ""):
+		return errors.New("--servicemonitor, --podmonitor, and --grafana-namespace require --enable-monitoring")
+	}
+	return nil
+}
+
+// modifyIntent enables the exporter sidecar in cluster.
+func (monitoring clusterMonitoring) modifyIntent(cluster *unstructured.Unstructured) error {
+	if !monitoring.Enabled {
+		return nil
+	}
+	return unstructured.SetNestedMap(cluster.Object, map[string]any{},
+		"spec", "monitoring", "pgmonitor", "exporter")
+}
+
+// Objects returns the objects to apply for the cluster named clusterName in
+// namespace. Metrics are given the "pg_cluster" label of the PGO monitoring
+// stack so the dashboard and "pgo generate alerts" select them.
+func (monitoring clusterMonitoring) Objects(namespace, clusterName string) ([]monitoringObject, error) {
+	var objects []monitoringObject
+	metadata := func(name, namespace string) map[string]any {
+		return map[string]any{
+			"name":      name,
+			"namespace": namespace,
+			"labels":    map[string]any{util.LabelCluster: clusterName},
+		}
+	}
+	selector := map[string]any{
+		util.LabelCluster: clusterName,
+		util.LabelData:    util.DataPostgres,
+	}
+	endpoint := map[string]any{
+		"port": "exporter",
+		"relabelings": []any{map[string]any{
+			"sourceLabels": []any{"__meta_kubernetes_namespace",
+				"__meta_kubernetes_pod_label_postgres_operator_crunchydata_com_cluster"},
+			"separator":   ":",
+			"targetLabel": "pg_cluster",
+		}},
+	}
+
+	if monitoring.ServiceMonitor {
+		// PGO does not expose the exporter with a Service of its own.
+		objects = append(objects, monitoringObject{
+			Resource: corev1.SchemeGroupVersion.WithResource("services"),
+			Object: map[string]any{
+				"apiVersion": corev1.SchemeGroupVersion.String(),
+				"kind":       "Service",
+				"metadata":   metadata(clusterName+"-exporter", namespace),
+				"spec": map[string]any{
+					"clusterIP": "None",
+					"selector":  selector,
+					"ports": []any{map[string]any{
+						"name": "exporter", "port": int64(exporterPort), "targetPort": "exporter",
+					}},
+				},
+			},
+		}, monitoringObject{
+			Resource: schema.GroupVersionResource{
+				Group: "monitoring.coreos.com", Version: "v1", Resource: "servicemonitors"},
+			Object: map[string]any{
+				"apiVersion": "monitoring.coreos.com/v1",
+				"kind":       "ServiceMonitor",
+				"metadata":   metadata(clusterName, namespace),
+				"spec": map[string]any{
+					"selector": map[string]any{"matchLabels": map[string]any{
+						util.LabelCluster: clusterName,
+					}},
+					"endpoints": []any{endpoint},
+				},
+			},
+		})
+	}
+
+	if monitoring.PodMonitor {
+		objects = append(objects, monitoringObject{
+			Resource: schema.GroupVersionResource{
+				Group: "monitoring.coreos.com", Version: "v1", Resource: "podmonitors"},
+			Object: map[string]any{
+				"apiVersion": "monitoring.coreos.com/v1",
+				"kind":       "PodMonitor",
+				"metadata":   metadata(clusterName, namespace),
+				"spec": map[string]any{
+					"selector":            map[string]any{"matchLabels": selector},
+					"podMetricsEndpoints": []any{endpoint},
+				},
+			},
+		})
+	}
+
+	if monitoring.GrafanaNamespace != "" {
+		dashboard, err := json.MarshalIndent(clusterDashboard(namespace, clusterName), "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		// The Grafana sidecar loads dashboards from ConfigMaps with this
+		// label. The ConfigMap may be in another namespace, so its name
+		// includes the namespace of the cluster.
+		configMap := map[string]any{
+			"apiVersion": corev1.SchemeGroupVersion.String(),
+			"kind":       "ConfigMap",
+			"metadata":   metadata(namespace+"-"+clusterName+"-dashboard", monitoring.GrafanaNamespace),
+			"data":       map[string]any{clusterName + ".json": string(dashboard)},
+		}
+		configMap["metadata"].(map[string]any)["labels"].(map[string]any)["grafana_dashboard"] = "1"
+
+		objects = append(objects, monitoringObject{
+			Resource: corev1.SchemeGroupVersion.WithResource("configmaps"),
+			Object:   configMap,
+		})
+	}
+
+	return objects, nil
+}
+
+// clusterDashboard returns a starter Grafana dashboard of the cluster named
+// clusterName in namespace.
+func clusterDashboard(namespace, clusterName string) map[string]any {
+	selector := fmt.Sprintf(`pg_cluster="%s:%s"`, namespace, clusterName)
+
+	var panels []any
+	panel := func(title, unit, expr, legend string) {
+		i := len(panels)
+		panels = append(panels, map[string]any{
+			"id":    i + 1,
+			"title": title,
+			"type":  "timeseries",
+			"gridPos": map[string]any{
+				"x": (i % 2) * 12, "y": (i / 2) * 8, "w": 12, "h": 8,
+			},
+			"fieldConfig": map[string]any{"defaults": map[string]any{"unit": unit}},
+			"targets": []any{map[string]any{
+				"expr": expr, "legendFormat": legend, "refId": "A",
+			}},
+		})
+	}
+
+	panel("Exporter up", "short",
+		fmt.Sprintf(`up{%s}`, selector), "{{pod}}")
+	panel("Connections", "short",
+		fmt.Sprintf(`ccp_connection_stats_total{%s}`, selector), "{{pod}}")
+	panel("Replication lag", "bytes",
+		fmt.Sprintf(`ccp_replication_lag_size_bytes{%s}`, selector), "{{pod}}")
+	panel("Data volume usage", "percent",
+		fmt.Sprintf(`100 * (1 - ccp_nodemx_data_disk_available_bytes{%[1]s}`+
+			` / ccp_nodemx_data_disk_total_bytes{%[1]s})`, selector), "{{pod}}")
+	panel("Transactions per second", "ops",
+		fmt.Sprintf(`sum by (pod) (rate(ccp_stat_database_xact_commit{%[1]s}[5m])`+
+			` + rate(ccp_stat_database_xact_rollback{%[1]s}[5m]))`, selector), "{{pod}}")
+	panel("Age of last full backup", "s",
+		fmt.Sprintf(`min(ccp_backrest_last_full_backup_time_since_completion_seconds{%s})`, selector),
+		"full backup")
+
+	return map[string]any{
+		"title":         fmt.Sprintf("PostgresCluster %s/%s", namespace, clusterName),
+		"uid":           fmt.Sprintf("pgo-%.36s", namespace+"-"+clusterName),
+		"tags":          []any{"postgres", "pgo"},
+		"schemaVersion": 39,
+		"refresh":       "30s",
+		"time":          map[string]any{"from": "now-6h", "to": "now"},
+		"panels":        panels,
+	}
+}
+
+// applyMonitoringObjects applies objects using server-side apply.
+func applyMonitoringObjects(ctx context.Context, config *internal.Config,
+	cmd *cobra.Command, objects []monitoringObject,
+) error {
+	rest, err := config.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+	client, err := dynamic.NewForConfig(rest)
+	if err != nil {
+		return err
+	}
+
+	for _, object := range objects {
+		patch, err := json.Marshal(object.Object)
+		if err != nil {
+			return err
+		}
+		metadata := object.Object["metadata"].(map[string]any)
+		name := metadata["name"].(string)
+		_, err = client.Resource(object.Resource).Namespace(metadata["namespace"].(string)).Patch(
+			ctx, name, types.ApplyPatchType, patch,
+			config.Patch.PatchOptions(metav1.PatchOptions{}))
+		if apierrors.IsNotFound(err) && object.Resource.Group == "monitoring.coreos.com" {
+			return fmt.Errorf("%s/%s: %w; is the Prometheus operator installed?",
+				object.Resource.Resource, name, err)
+		}
+		if err != nil {
+			return err
+		}
+		cmd.Printf("%s/%s applied\n", object.Resource.Resource, name)
+	}
+	return nil
+}
+
+// recordMonitoringObjects records an apply of each of objects to the file
+// named by the --record flag.
+func recordMonitoringObjects(config *internal.Config, cmd *cobra.Command, objects []monitoringObject) error {
+	for _, object := range objects {
+		metadata := object.Object["metadata"].(map[string]any)
+		msg, err := recordChange(config, internal.NewRecordedChange(internal.RecordApply,
+			object.Resource, metadata["namespace"].(string), metadata["name"].(string),
+			&unstructured.Unstructured{Object: object.Object}))
+		if err != nil {
+			return err
+		}
+		cmd.Print(msg)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crunchydata/postgres-operator-client/internal/testing/cmp"
+)
+
+func TestClusterMonitoringValidate(t *testing.T) {
+	assert.NilError(t, clusterMonitoring{}.Validate())
+	assert.NilError(t, clusterMonitoring{Enabled: true, PodMonitor: true, GrafanaNamespace: "monitoring"}.Validate())
+
+	assert.ErrorContains(t, clusterMonitoring{ServiceMonitor: true}.Validate(),
+		"require --enable-monitoring")
+	assert.ErrorContains(t, clusterMonitoring{GrafanaNamespace: "monitoring"}.Validate(),
+		"require --enable-monitoring")
+	assert.ErrorContains(t, clusterMonitoring{Enabled: true, ServiceMonitor: true, PodMonitor: true}.Validate(),
+		"cannot be used together")
+}
+
+func TestClusterMonitoringModifyIntent(t *testing.T) {
+	cluster, err := generateUnstructuredClusterYaml("hippo", "16")
+	assert.NilError(t, err)
+
+	assert.NilError(t, clusterMonitoring{}.modifyIntent(cluster))
+	_, found, _ := unstructured.NestedMap(cluster.Object, "spec", "monitoring")
+	assert.Assert(t, !found)
+
+	assert.NilError(t, clusterMonitoring{Enabled: true}.modifyIntent(cluster))
+	exporter, found, _ := unstructured.NestedMap(cluster.Object, "spec", "monitoring", "pgmonitor", "exporter")
+	assert.Assert(t, found)
+	assert.Equal(t, len(exporter), 0)
+}
+
+func TestClusterMonitoringObjects(t *testing.T) {
+	t.Run("None", func(t *testing.T) {
+		objects, err := clusterMonitoring{Enabled: true}.Objects("ns1", "hippo")
+		assert.NilError(t, err)
+		assert.Equal(t, len(objects), 0)
+	})
+
+	t.Run("ServiceMonitor", func(t *testing.T) {
+		objects, err := clusterMonitoring{Enabled: true, ServiceMonitor: true}.Objects("ns1", "hippo")
+		assert.NilError(t, err)
+		assert.Equal(t, len(objects), 2)
+		assert.Equal(t, objects[0].Resource.Resource, "services")
+		assert.Equal(t, objects[1].Resource.String(), "monitoring.coreos.com/v1, Resource=servicemonitors")
+
+		assert.Assert(t, cmp.MarshalMatches(objects[0].Object, `
+apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    postgres-operator.crunchydata.com/cluster: hippo
+  name: hippo-exporter
+  namespace: ns1
+spec:
+  clusterIP: None
+  ports:
+  - name: exporter
+    port: 9187
+    targetPort: exporter
+  selector:
+    postgres-operator.crunchydata.com/cluster: hippo
+    postgres-operator.crunchydata.com/data: postgres
+		`))
+		assert.Assert(t, cmp.MarshalMatches(objects[1].Object, `
+apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  labels:
+    postgres-operator.crunchydata.com/cluster: hippo
+  name: hippo
+  namespace: ns1
+spec:
+  endpoints:
+  - port: exporter
+    relabelings:
+    - separator: ':'
+      sourceLabels:
+      - __meta_kubernetes_namespace
+      - __meta_kubernetes_pod_label_postgres_operator_crunchydata_com_cluster
+      targetLabel: pg_cluster
+  selector:
+    matchLabels:
+      postgres-operator.crunchydata.com/cluster: hippo
+		`))
+	})
+
+	t.Run("PodMonitor", func(t *testing.T) {
+		objects, err := clusterMonitoring{Enabled: true, PodMonitor: true}.Objects("ns1", "hippo")
+		assert.NilError(t, err)
+		assert.Equal(t, len(objects), 1)
+		assert.Equal(t, objects[0].Resource.Resource, "podmonitors")
+
+		spec := objects[0].Object["spec"].(map[string]any)
+		assert.DeepEqual(t, spec["selector"], map[string]any{"matchLabels": map[string]any{
+			"postgres-operator.crunchydata.com/cluster": "hippo",
+			"postgres-operator.crunchydata.com/data":    "postgres",
+		}})
+	})
+
+	t.Run("Dashboard", func(t *testing.T) {
+		objects, err := clusterMonitoring{Enabled: true, GrafanaNamespace: "monitoring"}.Objects("ns1", "hippo")
+		assert.NilError(t, err)
+		assert.Equal(t, len(objects), 1)
+		assert.Equal(t, objects[0].Resource.Resource, "configmaps")
+
+		metadata := objects[0].Object["metadata"].(map[string]any)
+		assert.Equal(t, metadata["name"], "ns1-hippo-dashboard")
+		assert.Equal(t, metadata["namespace"], "monitoring")
+		assert.Equal(t, metadata["labels"].(map[string]any)["grafana_dashboard"], "1")
+
+		data := objects[0].Object["data"].(map[string]any)
+		var dashboard struct {
+			Title  string
+			Panels []struct {
+				Targets []struct{ Expr string }
+			}
+		}
+		assert.NilError(t, json.Unmarshal([]byte(data["hippo.json"].(string)), &dashboard))
+		assert.Equal(t, dashboard.Title, "PostgresCluster ns1/hippo")
+		assert.Assert(t, len(dashboard.Panels) > 0)
+		for _, panel := range dashboard.Panels {
+			assert.Assert(t, strings.Contains(panel.Targets[0].Expr, `pg_cluster="ns1:hippo"`),
+				"expr: %s", panel.Targets[0].Expr)
+		}
+	})
+}
@@ -7,7 +7,9 @@ package cmd
 import (
 	"testing"
 
+	"github.com/spf13/pflag"
 	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
 
 	"github.com/crunchydata/postgres-operator-client/internal/testing/cmp"
 )
@@ -49,3 +51,73 @@ spec:
 	))
 
 }
+
+func TestClusterShape(t *testing.T) {
+	shape := newClusterShape()
+	assert.NilError(t, shape.Validate())
+
+	t.Run("Default", func(t *testing.T) {
+		u, err := generateUnstructuredClusterYaml("hippo", "15")
+		assert.NilError(t, err)
+		expect, err := generateUnstructuredClusterYaml("hippo", "15")
+		assert.NilError(t, err)
+
+		assert.NilError(t, shape.modifyIntent(u))
+		assert.DeepEqual(t, u, expect)
+	})
+
+	t.Run("Custom", func(t *testing.T) {
+		flags := pflag.NewFlagSet("create", pflag.ContinueOnError)
+		shape := newClusterShape()
+		shape.AddFlags(flags)
+		assert.NilError(t, flags.Parse([]string{
+			"--replicas=3", "--disk-size=50Gi", "--storage-class=fast",
+			"--cpu=500m", "--memory=2Gi", "--backup-disk-size=100Gi",
+		}))
+		assert.NilError(t, shape.Validate())
+
+		u, err := generateUnstructuredClusterYaml("hippo", "15")
+		assert.NilError(t, err)
+		assert.NilError(t, shape.modifyIntent(u))
+		assert.Assert(t, cmp.MarshalMatches(u.Object["spec"], `
+backups:
+  pgbackrest:
+    repos:
+    - name: repo1
+      volume:
+        volumeClaimSpec:
+          accessModes:
+          - ReadWriteOnce
+          resources:
+            requests:
+              storage: 100Gi
+          storageClassName: fast
+instances:
+- dataVolumeClaimSpec:
+    accessModes:
+    - ReadWriteOnce
+    resources:
+      requests:
+        storage: 50Gi
+    storageClassName: fast
+  replicas: 3
+  resources:
+    limits:
+      memory: 2Gi
+    requests:
+      cpu: 500m
+      memory: 2Gi
+postgresVersion: 15
+`))
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		shape := newClusterShape()
+		shape.Replicas = 0
+		assert.ErrorContains(t, shape.Validate(), "--replicas")
+
+		shape = newClusterShape()
+		shape.DiskSize = resource.MustParse("0")
+		assert.ErrorContains(t, shape.Validate(), "--disk-size")
+	})
+}
@@ -7,10 +7,14 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"os"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
 
 	"github.com/crunchydata/postgres-operator-client/internal"
 	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
@@ -26,7 +30,10 @@ func newDeleteCommand(config *internal.Config) *cobra.Command {
 		Long:  "Delete a resource",
 	}
 
-	cmd.AddCommand(newDeleteClusterCommand(config))
+	cmd.AddCommand(
+		newDeleteBackupScheduleCommand(config),
+		newDeleteClusterCommand(config),
+	)
 
 	return cmd
 }
@@ -39,19 +46,40 @@ func newDeleteClusterCommand(config *internal.Config) *cobra.Command {
 		Short: "Delete a PostgresCluster",
 		Long: `Delete a PostgresCluster with a given name.
 
+Use "--list-orphans" to see what remains after the cluster is deleted: volume
+claims that the cluster does not own, volumes that are retained when their
+claims are deleted, and backups in S3, GCS, or Azure repositories. Use "--yes"
+to delete without being asked for confirmation.
+
 ### RBAC Requirements
     Resources                                           Verbs
     ---------                                           -----
-    postgresclusters.postgres-operator.crunchydata.com  [delete]
+    persistentvolumeclaims                              [list]
+    persistentvolumes                                   [get]
+    postgresclusters.postgres-operator.crunchydata.com  [delete get]
+
+    Note: Only "--list-orphans" reads volumes and volume claims. Getting
+    persistentvolumes requires cluster-scoped RBAC. Without it, a warning
+    says that retained volumes are not listed.
 
 ### Usage`,
 	}
 
 	cmd.Args = cobra.ExactArgs(1)
 
+	config.Record.AddFlags(cmd.Flags())
+
+	var listOrphans, yes bool
+	cmd.Flags().BoolVar(&listOrphans, "list-orphans", false,
+		"list volumes and backups that remain after the postgrescluster is deleted")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "delete without asking for confirmation")
+
 	cmd.Example = internal.FormatExample(`# Delete a postgrescluster
 pgo delete postgrescluster hippo
 
+# Delete a postgrescluster from a script, after listing what will remain
+pgo delete postgrescluster hippo --list-orphans --yes
+
 ### Example output	
 WARNING: Deleting a postgrescluster is destructive and data retention is dependent on PV configuration. 
 Are you sure you want to continue? (yes/no): yes
@@ -62,20 +90,6 @@ postgresclusters/hippo deleted`)
 
 		clusterName := args[0]
 
-		fmt.Print("WARNING: Deleting a postgrescluster is destructive and data " +
-			"retention is dependent on PV configuration. \nAre you sure you want " +
-			"to continue? (yes/no): ")
-		var confirmed *bool
-		for i := 0; confirmed == nil && i < 10; i++ {
-			// retry 10 times or until a confirmation is given or denied,
-			// whichever comes first
-			confirmed = util.Confirm(os.Stdin, os.Stdout)
-		}
-
-		if confirmed == nil || !*confirmed {
-			return nil
-		}
-
 		namespace, err := config.Namespace()
 		if err != nil {
 			return err
@@ -86,6 +100,73 @@ postgresclusters/hippo deleted`)
 			return err
 		}
 
+		// Save the deletion for later rather than sending it. Confirmation
+		// happens when the recording is applied.
+		if config.Record.Enabled() {
+			msg, err := recordChange(config, internal.NewRecordedChange(
+				internal.RecordDelete, mapping.Resource, namespace, clusterName, nil))
+			cmd.Print(msg)
+			return err
+		}
+
+		if listOrphans {
+			cluster, err := client.Namespace(namespace).Get(ctx, clusterName, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			rest, err := config.ToRESTConfig()
+			if err != nil {
+				return err
+			}
+			clientset, err := kubernetes.NewForConfig(rest)
+			if err != nil {
+				return err
+			}
+			pvcs, err := clientset.CoreV1().PersistentVolumeClaims(namespace).List(ctx,
+				metav1.ListOptions{LabelSelector: util.LabelCluster + "=" + clusterName})
+			if err != nil {
+				return err
+			}
+
+			// Reclaim policies are fields of the volumes. Skip them, with a
+			// warning, when volumes cannot be read.
+			reclaim := map[string]corev1.PersistentVolumeReclaimPolicy{}
+			for _, pvc := range pvcs.Items {
+				if pvc.Spec.VolumeName == "" {
+					continue
+				}
+				pv, err := clientset.CoreV1().PersistentVolumes().Get(ctx, pvc.Spec.VolumeName, metav1.GetOptions{})
+				if apierrors.IsForbidden(err) {
+					cmd.PrintErrln("WARNING: Unable to read persistentvolumes; " +
+						"volumes retained after their claims are deleted are not listed.")
+					break
+				}
+				if err != nil {
+					return err
+				}
+				reclaim[pv.Name] = pv.Spec.PersistentVolumeReclaimPolicy
+			}
+
+			if err := printDeleteOrphans(cmd, deleteOrphans(cluster, pvcs.Items, reclaim)); err != nil {
+				return err
+			}
+		}
+
+		if !yes {
+			_, _ = fmt.Fprint(config.ErrOut, config.Messages.Sprintf("delete.warn")+
+				config.Messages.Sprintf("confirm.continue"))
+			var confirmed *bool
+			for i := 0; confirmed == nil && i < 10; i++ {
+				// retry 10 times or until a confirmation is given or denied,
+				// whichever comes first
+				confirmed = config.Messages.Confirm(config.In, config.ErrOut)
+			}
+
+			if confirmed == nil || !*confirmed {
+				return ErrCancelled
+			}
+		}
+
 		err = client.
 			Namespace(namespace).
 			Delete(ctx, clusterName, metav1.DeleteOptions{})
@@ -100,3 +181,64 @@ postgresclusters/hippo deleted`)
 
 	return cmd
 }
+
+// deleteOrphan is something that remains after a cluster is deleted.
+type deleteOrphan struct {
+	Kind, Name, Detail string
+}
+
+// deleteOrphans returns what remains after cluster is deleted. The
+// PersistentVolumeClaims are those of cluster; reclaim is the reclaim policy
+// of their volumes by name, when known.
+func deleteOrphans(
+	cluster *unstructured.Unstructured, pvcs []corev1.PersistentVolumeClaim,
+	reclaim map[string]corev1.PersistentVolumeReclaimPolicy,
+) []deleteOrphan {
+	var orphans []deleteOrphan
+
+	for _, pvc := range pvcs {
+		// Kubernetes deletes the claims that the cluster owns along with it.
+		if owner := metav1.GetControllerOf(&pvc); owner == nil || owner.UID != cluster.GetUID() {
+			orphans = append(orphans, deleteOrphan{Kind: "PersistentVolumeClaim", Name: pvc.Name,
+				Detail: "not owned by the postgrescluster"})
+		} else if reclaim[pvc.Spec.VolumeName] == corev1.PersistentVolumeReclaimRetain {
+			orphans = append(orphans, deleteOrphan{Kind: "PersistentVolume", Name: pvc.Spec.VolumeName,
+				Detail: "retained after its claim " + pvc.Name + " is deleted"})
+		}
+	}
+
+	repos, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "backups", "pgbackrest", "repos")
+	for _, entry := range repos {
+		repo, ok := entry.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(repo, "name")
+		for _, storage := range []struct{ kind, field, label string }{
+			{"s3", "bucket", "S3 bucket"},
+			{"gcs", "bucket", "GCS bucket"},
+			{"azure", "container", "Azure container"},
+		} {
+			if location, found, _ := unstructured.NestedString(repo, storage.kind, storage.field); found {
+				orphans = append(orphans, deleteOrphan{Kind: "repository", Name: name,
+					Detail: "backups stay in " + storage.label + " " + location})
+			}
+		}
+	}
+
+	return orphans
+}
+
+// printDeleteOrphans prints orphans as a table.
+func printDeleteOrphans(cmd *cobra.Command, orphans []deleteOrphan) error {
+	if len(orphans) == 0 {
+		cmd.Println("No volumes or backups remain after the postgrescluster is deleted")
+		return nil
+	}
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "KIND\tNAME\tDETAIL")
+	for _, orphan := range orphans {
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\n", orphan.Kind, orphan.Name, orphan.Detail)
+	}
+	return writer.Flush()
+}
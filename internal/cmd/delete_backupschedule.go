@@ -0,0 +1,94 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// newDeleteBackupScheduleCommand returns the backupschedule subcommand of the
+// delete command. It undoes "pgo ensure backupschedule".
+func newDeleteBackupScheduleCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backupschedule CLUSTER_NAME",
+		Short: "Remove a backup schedule from the spec of a PostgresCluster",
+		Long: `Remove the backup schedules of the pgBackRest repository "--repoName" from a
+PostgresCluster. Use "--type" to remove only the schedule of one type of
+backup. PGO then removes their CronJobs. Schedules that are paused are
+removed as well, so "pgo resume backupschedule" does not put them back.
+
+Only schedules set by pgo can be removed. Those set by another client, such
+as "kubectl apply", must be changed there.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    postgresclusters.postgres-operator.crunchydata.com  [get patch]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Remove the incremental backup schedule of repo1 from the 'hippo' postgrescluster
+pgo delete backupschedule hippo --repoName=repo1 --type=incr
+
+### Example output
+postgresclusters/hippo repo1 incr backup schedule deleted`)
+
+	var selector backupScheduleSelector
+	var forceConflicts bool
+	selector.AddFlags(cmd.Flags())
+	cobra.CheckErr(cmd.MarkFlagRequired("repoName"))
+	cmd.Flags().BoolVar(&forceConflicts, "force-conflicts", false,
+		"take ownership and overwrite the paused schedules annotation")
+	config.Record.AddFlags(cmd.Flags())
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := selector.validate(); err != nil {
+			return err
+		}
+		return ensureClusterPart(cmd, config, args[0], selector.describe(), forceConflicts, selector.delete)
+	}
+
+	return cmd
+}
+
+// delete removes the schedules that selector chooses from the spec and the
+// annotation of intent.
+func (selector backupScheduleSelector) delete(cluster, intent *unstructured.Unstructured) (string, error) {
+	paused, err := pausedBackupSchedules(cluster)
+	if err != nil {
+		return "", err
+	}
+	removed, err := selector.removeSchedules(cluster, intent)
+	if err != nil {
+		return "", err
+	}
+
+	chosen := selector.matches(paused)
+	if len(removed) == 0 && len(chosen) == 0 {
+		return "", fmt.Errorf("postgresclusters/%s has no %s", cluster.GetName(), selector.describe())
+	}
+	for _, repo := range chosen {
+		for _, field := range selector.fields() {
+			delete(paused[repo], field)
+		}
+		if len(paused[repo]) == 0 {
+			delete(paused, repo)
+		}
+	}
+	if len(chosen) > 0 {
+		if err := setPausedBackupSchedules(intent, paused); err != nil {
+			return "", err
+		}
+	}
+	return "deleted", nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDeleteOrphans(t *testing.T) {
+	cluster := guardrailCluster(t, `
+metadata: { name: hippo, uid: abc-123 }
+spec:
+  backups:
+    pgbackrest:
+      repos:
+      - { name: repo1, volume: {} }
+      - { name: repo2, s3: { bucket: backups, endpoint: s3.amazonaws.com, region: us-east-1 } }
+      - { name: repo3, azure: { container: hippo } }
+`)
+
+	controller := true
+	owned := []metav1.OwnerReference{{Name: "hippo", UID: "abc-123", Controller: &controller}}
+	pvc := func(name, volume string, owners []metav1.OwnerReference) corev1.PersistentVolumeClaim {
+		return corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, OwnerReferences: owners},
+			Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: volume},
+		}
+	}
+
+	orphans := deleteOrphans(cluster, []corev1.PersistentVolumeClaim{
+		pvc("hippo-instance1-abcd-pgdata", "pv-1", owned),
+		pvc("hippo-repo1", "pv-2", owned),
+		pvc("hippo-restored-pgdata", "pv-3", nil),
+	}, map[string]corev1.PersistentVolumeReclaimPolicy{
+		"pv-1": corev1.PersistentVolumeReclaimDelete,
+		"pv-2": corev1.PersistentVolumeReclaimRetain,
+	})
+	assert.DeepEqual(t, orphans, []deleteOrphan{
+		{Kind: "PersistentVolume", Name: "pv-2", Detail: "retained after its claim hippo-repo1 is deleted"},
+		{Kind: "PersistentVolumeClaim", Name: "hippo-restored-pgdata", Detail: "not owned by the postgrescluster"},
+		{Kind: "repository", Name: "repo2", Detail: "backups stay in S3 bucket backups"},
+		{Kind: "repository", Name: "repo3", Detail: "backups stay in Azure container hippo"},
+	})
+}
+
+func TestPrintDeleteOrphans(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	assert.NilError(t, printDeleteOrphans(cmd, []deleteOrphan{
+		{Kind: "PersistentVolume", Name: "pv-2", Detail: "retained after its claim hippo-repo1 is deleted"},
+		{Kind: "repository", Name: "repo2", Detail: "backups stay in S3 bucket backups"},
+	}))
+	assert.Equal(t, out.String(), `
+KIND              NAME   DETAIL
+PersistentVolume  pv-2   retained after its claim hippo-repo1 is deleted
+repository        repo2  backups stay in S3 bucket backups
+`[1:])
+
+	out.Reset()
+	assert.NilError(t, printDeleteOrphans(cmd, nil))
+	assert.Equal(t, out.String(), "No volumes or backups remain after the postgrescluster is deleted\n")
+}
@@ -0,0 +1,26 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// newDrillCommand returns the drill subcommand of the PGO plugin.
+// Subcommands of drill disrupt a PostgresCluster on purpose and measure how
+// it recovers.
+func newDrillCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "drill",
+		Short: "Rehearse outages of a PostgresCluster",
+		Long:  "Rehearse outages of a PostgresCluster",
+	}
+
+	cmd.AddCommand(newDrillFailoverCommand(config))
+
+	return cmd
+}
@@ -0,0 +1,459 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newDrillFailoverCommand returns the failover subcommand of the drill
+// command. It moves the primary of a cluster to a replica and measures how
+// long clients cannot write.
+func newDrillFailoverCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "failover CLUSTER_NAME",
+		Short: "Rehearse a failover of a PostgresCluster",
+		Long: `Fail over a PostgresCluster on purpose and measure how it recovers.
+
+A marker row is written on the primary and replicated before the drill. Then,
+depending on "--mode":
+  - switchover: Patroni moves the primary to a ready replica
+  - kill-primary: the primary Pod is deleted without a grace period
+
+The report shows how long it took for Patroni to release the old primary
+(detection), for a replica to become the ready primary (promotion), and for
+writes to succeed again (reconnect). It also shows whether the marker row
+survived. Afterward, the original primary is restored with a switchover unless
+"--no-restore" is set. The marker table is dropped at the end.
+
+Use "--report" to also write the results as JSON.
+
+### RBAC Requirements
+    Resources  Verbs
+    ---------  -----
+    endpoints  [get]
+    pods       [list delete]
+    pods/exec  [create]
+
+    Note: Deleting Pods is only needed with "--mode=kill-primary".
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Rehearse a switchover of the 'hippo' postgrescluster
+pgo drill failover hippo
+
+# Delete the primary Pod and save the results
+pgo drill failover hippo --mode=kill-primary --report=drill.json
+
+### Example output
+WARNING: This drill interrupts connections to the primary. Are you sure you want to continue? (yes/no): yes
+writing the marker row...
+starting the kill-primary...
+restoring hippo-instance1-8x7m-0 as primary...
+mode: kill-primary
+original primary: hippo-instance1-8x7m-0
+new primary: hippo-instance1-2d4n-0
+detection: 1.2s
+promotion: 4.8s
+reconnect: 5.1s
+marker row: found
+original primary restored: yes`)
+
+	var mode, reportFile string
+	var noRestore bool
+	var timeout time.Duration
+	cmd.Flags().StringVar(&mode, "mode", "switchover", "how to fail over. types supported: switchover,kill-primary")
+	cmd.Flags().StringVar(&reportFile, "report", "", "path of a file to write the results to as JSON")
+	cmd.Flags().BoolVar(&noRestore, "no-restore", false, "leave the new primary in place")
+	cmd.Flags().DurationVar(&timeout, "timeout", 5*time.Minute, "how long to wait for each step")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		if mode != "switchover" && mode != "kill-primary" {
+			return fmt.Errorf("unknown mode %q; types supported: switchover,kill-primary", mode)
+		}
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+
+		drill := &failoverDrill{
+			Cluster: args[0],
+			Mode:    mode,
+			Timeout: timeout,
+			Pods: func() ([]corev1.Pod, error) {
+				pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+					LabelSelector: util.DBInstanceLabels(args[0]),
+				})
+				if err != nil {
+					return nil, err
+				}
+				return pods.Items, nil
+			},
+			Leader: func() (string, error) {
+				// Patroni records its leader on the Endpoints that PGO creates
+				// for it.
+				endpoints, err := client.Endpoints(namespace).Get(ctx, args[0]+"-ha", metav1.GetOptions{})
+				if err != nil {
+					return "", err
+				}
+				return endpoints.GetAnnotations()["leader"], nil
+			},
+			Exec: func(pod string) podexec.Executor {
+				return podexec.Container(podExec, namespace, pod, util.ContainerDatabase)
+			},
+			DeletePod: func(pod string) error {
+				var immediately int64
+				return client.Pods(namespace).Delete(ctx, pod, metav1.DeleteOptions{
+					GracePeriodSeconds: &immediately,
+				})
+			},
+		}
+
+		pods, err := drill.Pods()
+		if err != nil {
+			return err
+		}
+		original := drillPrimary(pods)
+		if original == "" {
+			return errors.New("no ready primary instance Pod found")
+		}
+		if drillCandidate(pods, original) == "" {
+			return errors.New("no ready replica instance Pod found; a failover needs at least two instances")
+		}
+
+		_, _ = fmt.Fprint(config.ErrOut, config.Messages.Sprintf("drill.warn-failover")+
+			config.Messages.Sprintf("confirm.continue"))
+		var confirmed *bool
+		for i := 0; confirmed == nil && i < 10; i++ {
+			// retry 10 times or until a confirmation is given or denied,
+			// whichever comes first
+			confirmed = config.Messages.Confirm(config.In, config.ErrOut)
+		}
+		if confirmed == nil || !*confirmed {
+			return ErrCancelled
+		}
+
+		report := drill.Run(cmd, original, !noRestore)
+		printDrillReport(cmd, report)
+
+		if reportFile != "" {
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(reportFile, append(data, '\n'), 0o600); err != nil {
+				return err
+			}
+		}
+
+		if report.Error != "" {
+			return errors.New(report.Error)
+		}
+		if !report.MarkerFound {
+			return errors.New("the marker row was lost during the failover")
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// drillReport is the outcome of a failover drill. Durations are measured
+// from the moment the failover was started.
+type drillReport struct {
+	Cluster         string    `json:"cluster"`
+	Mode            string    `json:"mode"`
+	Started         time.Time `json:"started"`
+	OriginalPrimary string    `json:"originalPrimary"`
+	NewPrimary      string    `json:"newPrimary,omitempty"`
+
+	DetectionSeconds float64 `json:"detectionSeconds,omitempty"`
+	PromotionSeconds float64 `json:"promotionSeconds,omitempty"`
+	ReconnectSeconds float64 `json:"reconnectSeconds,omitempty"`
+
+	MarkerFound bool   `json:"markerFound"`
+	Restored    bool   `json:"restored"`
+	Error       string `json:"error,omitempty"`
+}
+
+// failoverDrill reaches the cluster through functions so the steps of a drill
+// read in order.
+type failoverDrill struct {
+	Cluster, Mode string
+	Timeout       time.Duration
+
+	Pods      func() ([]corev1.Pod, error)
+	Leader    func() (string, error)
+	Exec      func(pod string) podexec.Executor
+	DeletePod func(pod string) error
+}
+
+// drillTable holds the marker rows of a drill.
+const drillTable = "pgo_failover_drill"
+
+// Run fails over the cluster from original and returns what happened. When
+// restore is true, it switches back to original at the end.
+func (drill *failoverDrill) Run(cmd *cobra.Command, original string, restore bool) (report drillReport) {
+	report = drillReport{Cluster: drill.Cluster, Mode: drill.Mode, OriginalPrimary: original}
+	marker := "drill-" + time.Now().UTC().Format("20060102T150405Z")
+
+	fail := func(err error) drillReport {
+		report.Error = err.Error()
+		return report
+	}
+
+	cmd.Println("writing the marker row...")
+	stdout, stderr, err := podexec.PSQL(drill.Exec(original), "", fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %[1]s (marker text PRIMARY KEY, written timestamptz NOT NULL DEFAULT now());
+INSERT INTO %[1]s (marker) VALUES ('%[2]s');
+SELECT pg_current_wal_lsn();`, drillTable, marker))
+	if err != nil {
+		return fail(fmt.Errorf("unable to write the marker row: %w: %s", err, strings.TrimSpace(stderr)))
+	}
+	lsn := strings.TrimSpace(stdout)
+
+	pods, err := drill.Pods()
+	if err != nil {
+		return fail(err)
+	}
+	candidate := drillCandidate(pods, original)
+	if _, err := drill.waitFor(func() (bool, error) {
+		stdout, _, err := podexec.PSQL(drill.Exec(candidate), "",
+			fmt.Sprintf(`SELECT pg_last_wal_replay_lsn() >= '%s'::pg_lsn;`, lsn))
+		return err == nil && strings.TrimSpace(stdout) == "t", nil
+	}); err != nil {
+		return fail(fmt.Errorf("the marker row did not reach %s: %w", candidate, err))
+	}
+
+	cmd.Printf("starting the %s...\n", drill.Mode)
+	report.Started = time.Now().UTC()
+	since := func(t time.Time) float64 {
+		return t.Sub(report.Started).Round(100 * time.Millisecond).Seconds()
+	}
+
+	if drill.Mode == "kill-primary" {
+		err = drill.DeletePod(original)
+	} else {
+		err = drill.switchover(candidate)
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	detected, err := drill.waitFor(func() (bool, error) {
+		leader, err := drill.Leader()
+		return err == nil && leader != original, nil
+	})
+	if err != nil {
+		return fail(fmt.Errorf("patroni did not release %s: %w", original, err))
+	}
+	report.DetectionSeconds = since(detected)
+
+	promoted, err := drill.waitFor(func() (bool, error) {
+		pods, err := drill.Pods()
+		if err != nil {
+			return false, err
+		}
+		// Wait for the labels of the Pods to agree with Patroni.
+		leader, err := drill.Leader()
+		report.NewPrimary = drillPrimary(pods)
+		return err == nil && report.NewPrimary != "" && report.NewPrimary == leader, nil
+	})
+	if err != nil {
+		return fail(fmt.Errorf("no replica became primary: %w", err))
+	}
+	report.PromotionSeconds = since(promoted)
+
+	var found string
+	reconnected, err := drill.waitFor(func() (bool, error) {
+		stdout, _, err := podexec.PSQL(drill.Exec(report.NewPrimary), "", fmt.Sprintf(`
+INSERT INTO %[1]s (marker) VALUES ('%[2]s-after');
+SELECT count(*) FROM %[1]s WHERE marker = '%[2]s';`, drillTable, marker))
+		found = strings.TrimSpace(stdout)
+		return err == nil, nil
+	})
+	if err != nil {
+		return fail(fmt.Errorf("unable to write on %s: %w", report.NewPrimary, err))
+	}
+	report.ReconnectSeconds = since(reconnected)
+	report.MarkerFound = found == "1"
+
+	current := report.NewPrimary
+	if restore && current != original {
+		cmd.Printf("restoring %s as primary...\n", original)
+		if _, err := drill.waitFor(func() (bool, error) {
+			pods, err := drill.Pods()
+			if err != nil || !drillReplicaReady(pods, original) {
+				return false, err
+			}
+			return drill.switchover(original) == nil, nil
+		}); err != nil {
+			return fail(fmt.Errorf("unable to switch back to %s: %w", original, err))
+		}
+		if _, err := drill.waitFor(func() (bool, error) {
+			pods, err := drill.Pods()
+			return err == nil && drillPrimary(pods) == original, nil
+		}); err != nil {
+			return fail(fmt.Errorf("%s did not become primary again: %w", original, err))
+		}
+		current = original
+	}
+	report.Restored = current == original
+
+	if _, stderr, err := podexec.PSQL(drill.Exec(current), "",
+		"DROP TABLE IF EXISTS "+drillTable+";"); err != nil {
+		return fail(fmt.Errorf("unable to drop %s: %w: %s", drillTable, err, strings.TrimSpace(stderr)))
+	}
+	return report
+}
+
+// switchover asks Patroni to make candidate the primary.
+func (drill *failoverDrill) switchover(candidate string) error {
+	pods, err := drill.Pods()
+	if err != nil {
+		return err
+	}
+	primary := drillPrimary(pods)
+	if primary == "" {
+		return errors.New("no ready primary instance Pod found")
+	}
+
+	stdout, stderr, err := podexec.Patronictl(drill.Exec(primary),
+		"switchover --force --candidate="+candidate, "")
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr+stdout))
+	}
+	return nil
+}
+
+// waitFor calls done every second until it returns true, an error, or the
+// timeout of the drill passes. It returns the time done returned true.
+func (drill *failoverDrill) waitFor(done func() (bool, error)) (time.Time, error) {
+	for deadline := time.Now().Add(drill.Timeout); ; time.Sleep(time.Second) {
+		ok, err := done()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if ok {
+			return time.Now(), nil
+		}
+		if time.Now().After(deadline) {
+			return time.Time{}, fmt.Errorf("timed out after %s", drill.Timeout)
+		}
+	}
+}
+
+// drillPrimary returns the name of the ready primary in pods, if any.
+func drillPrimary(pods []corev1.Pod) string {
+	for i := range pods {
+		if pods[i].GetLabels()[util.LabelRole] == util.RolePatroniLeader &&
+			pods[i].GetDeletionTimestamp() == nil && podIsReady(&pods[i]) {
+			return pods[i].GetName()
+		}
+	}
+	return ""
+}
+
+// drillCandidate returns the name of the first ready replica in pods other
+// than primary, if any.
+func drillCandidate(pods []corev1.Pod, primary string) string {
+	var names []string
+	for i := range pods {
+		if pods[i].GetName() != primary && drillReplica(&pods[i]) {
+			names = append(names, pods[i].GetName())
+		}
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return ""
+	}
+	return names[0]
+}
+
+// drillReplicaReady returns whether the Pod named name is a ready replica.
+func drillReplicaReady(pods []corev1.Pod, name string) bool {
+	for i := range pods {
+		if pods[i].GetName() == name {
+			return drillReplica(&pods[i])
+		}
+	}
+	return false
+}
+
+// drillReplica returns whether pod is a ready replica.
+func drillReplica(pod *corev1.Pod) bool {
+	return pod.GetLabels()[util.LabelRole] == util.RolePatroniReplica &&
+		pod.GetDeletionTimestamp() == nil && podIsReady(pod)
+}
+
+// printDrillReport prints report one field per line.
+func printDrillReport(cmd *cobra.Command, report drillReport) {
+	seconds := func(s float64) string {
+		if s == 0 && report.Error != "" {
+			return "-"
+		}
+		return (time.Duration(s * float64(time.Second))).String()
+	}
+	yes := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	}
+
+	cmd.Printf("mode: %s\n", report.Mode)
+	cmd.Printf("original primary: %s\n", report.OriginalPrimary)
+	if report.NewPrimary != "" {
+		cmd.Printf("new primary: %s\n", report.NewPrimary)
+	}
+	cmd.Printf("detection: %s\n", seconds(report.DetectionSeconds))
+	cmd.Printf("promotion: %s\n", seconds(report.PromotionSeconds))
+	cmd.Printf("reconnect: %s\n", seconds(report.ReconnectSeconds))
+	if report.MarkerFound || report.Error == "" {
+		marker := "found"
+		if !report.MarkerFound {
+			marker = "LOST"
+		}
+		cmd.Printf("marker row: %s\n", marker)
+	}
+	cmd.Printf("original primary restored: %s\n", yes(report.Restored))
+	if report.Error != "" {
+		cmd.Printf("error: %s\n", report.Error)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+func TestDrillPods(t *testing.T) {
+	pod := func(name, role string, ready bool) corev1.Pod {
+		pod := corev1.Pod{}
+		pod.Name = name
+		pod.Labels = map[string]string{util.LabelRole: role}
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}
+		return pod
+	}
+
+	pods := []corev1.Pod{
+		pod("c", util.RolePatroniReplica, true),
+		pod("a", util.RolePatroniLeader, true),
+		pod("b", util.RolePatroniReplica, true),
+		pod("d", util.RolePatroniReplica, false),
+	}
+	assert.Equal(t, drillPrimary(pods), "a")
+	assert.Equal(t, drillCandidate(pods, "a"), "b")
+	assert.Assert(t, drillReplicaReady(pods, "c"))
+	assert.Assert(t, !drillReplicaReady(pods, "d"))
+	assert.Assert(t, !drillReplicaReady(pods, "a"))
+
+	assert.Equal(t, drillPrimary(pods[2:]), "")
+	assert.Equal(t, drillCandidate(pods[3:], ""), "")
+}
+
+func TestFailoverDrillRun(t *testing.T) {
+	pod := func(name string, primary bool) corev1.Pod {
+		pod := corev1.Pod{}
+		pod.Name = name
+		pod.Labels = map[string]string{util.LabelRole: util.RolePatroniReplica}
+		if primary {
+			pod.Labels[util.LabelRole] = util.RolePatroniLeader
+		}
+		pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+		return pod
+	}
+
+	// The fake cluster switches over as soon as it is asked to.
+	leader := "one"
+	var markers []string
+	var statements []string
+	drill := &failoverDrill{
+		Cluster: "hippo", Mode: "switchover", Timeout: time.Second,
+		Pods: func() ([]corev1.Pod, error) {
+			return []corev1.Pod{pod("one", leader == "one"), pod("two", leader == "two")}, nil
+		},
+		Leader: func() (string, error) { return leader, nil },
+		Exec: func(name string) podexec.Executor {
+			return podexec.Func(func(stdin io.Reader, stdout, _ io.Writer, command ...string) error {
+				if command[0] != "psql" {
+					script := strings.Join(command, " ")
+					statements = append(statements, name+": "+script)
+					if candidate, ok := strings.CutPrefix(script,
+						"bash -ceu -- patronictl switchover --force --candidate="); ok {
+						leader = candidate
+						return nil
+					}
+					return errors.New("unexpected command")
+				}
+
+				b, _ := io.ReadAll(stdin)
+				sql := string(b)
+				statements = append(statements, name+": "+strings.Fields(sql)[0])
+				switch {
+				case strings.Contains(sql, "pg_current_wal_lsn"):
+					markers = append(markers, "drill")
+					_, _ = io.WriteString(stdout, "0/3000060\n")
+				case strings.Contains(sql, "pg_last_wal_replay_lsn"):
+					_, _ = io.WriteString(stdout, "t\n")
+				case strings.Contains(sql, "count(*)"):
+					_, _ = io.WriteString(stdout, "1\n")
+				}
+				return nil
+			})
+		},
+		DeletePod: func(string) error { return errors.New("unexpected delete") },
+	}
+
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	report := drill.Run(cmd, "one", true)
+	assert.Equal(t, report.Error, "")
+	assert.Equal(t, report.OriginalPrimary, "one")
+	assert.Equal(t, report.NewPrimary, "two")
+	assert.Assert(t, report.MarkerFound)
+	assert.Assert(t, report.Restored)
+	assert.Equal(t, leader, "one")
+	assert.Equal(t, len(markers), 1)
+
+	assert.DeepEqual(t, statements, []string{
+		"one: CREATE",
+		"two: SELECT",
+		"one: bash -ceu -- patronictl switchover --force --candidate=two",
+		"two: INSERT",
+		"two: bash -ceu -- patronictl switchover --force --candidate=one",
+		"one: DROP",
+	})
+
+	printDrillReport(cmd, report)
+	assert.Assert(t, strings.Contains(out.String(), "new primary: two\n"), out.String())
+	assert.Assert(t, strings.Contains(out.String(), "marker row: found\n"), out.String())
+	assert.Assert(t, strings.Contains(out.String(), "original primary restored: yes\n"), out.String())
+}
@@ -0,0 +1,553 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newEditCommand returns the edit subcommand of the PGO plugin.
+// Subcommands of edit change objects that already exist.
+func newEditCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "edit",
+		Short: "Edit a resource",
+		Long:  "Edit a resource",
+	}
+
+	cmd.AddCommand(newEditClusterCommand(config))
+
+	return cmd
+}
+
+// newEditClusterCommand returns the edit cluster subcommand. It adds sidecar
+// containers and bootstrap SQL to an existing PostgresCluster.
+func newEditClusterCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "postgrescluster CLUSTER_NAME",
+		Aliases: []string{"postgresclusters"},
+		Short:   "Add sidecar containers or bootstrap SQL to a PostgresCluster",
+		Long: `Add sidecar containers or bootstrap SQL to a PostgresCluster.
+
+The "--sidecar-from-file" flag reads a YAML file with one container or a list
+of them and adds them to "spec.instances[].containers" of an instance set. A
+container with the name of one already there replaces it. Changing containers
+rolls out the instances of the set.
+
+The "--init-sql" flag stores a SQL file in the ConfigMap "CLUSTER_NAME-init-sql"
+and points "spec.databaseInitSQL" at it. PGO runs it once, as a superuser, in
+the postgres database; a cluster that has already run its init SQL does not
+run it again.
+
+PGO runs the containers of an instance set only when its "InstanceSidecars"
+feature gate is enabled, such as with PGO_FEATURE_GATES="InstanceSidecars=true"
+in the environment of the operator, and ignores them otherwise. After the
+change, this waits briefly for PGO and warns when the sidecars are missing
+from the Pods that the instance set rolls out.
+
+Before changing containers, this lists the instances that restart and whether
+the primary switches over, then asks to continue. Pass "--yes" to skip the
+question.
+
+Overwriting values set by others may require the --force-conflicts flag.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    configmaps                                          [patch]
+    pods                                                [list]
+    postgresclusters.postgres-operator.crunchydata.com  [get patch]
+    statefulsets.apps                                   [list]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Add a logging agent to the instances of the 'hippo' postgrescluster
+pgo edit postgrescluster hippo --sidecar-from-file=sidecar.yaml
+
+# Add the agent to the 'analytics' instance set and run a bootstrap script
+pgo edit postgrescluster hippo --instance-set=analytics \
+  --sidecar-from-file=sidecar.yaml --init-sql=./bootstrap.sql
+
+### Example output
+WARNING: This change to postgrescluster hippo restarts, removes, or adds instances:
+POD                     SET        ROLE     IMPACT
+hippo-instance1-2d4n-0  instance1  replica  restarts
+hippo-instance1-8x7m-0  instance1  primary  switches over, then restarts
+switchover: yes; connections to the primary close and writes pause briefly
+Are you sure you want to continue? (yes/no): yes
+configmaps/hippo-init-sql applied
+postgresclusters/hippo patched`)
+
+	var extras instanceExtras
+	var forceConflicts, yes bool
+	extras.AddFlags(cmd.Flags())
+	cmd.Flags().StringVar(&extras.InstanceSet, "instance-set", "",
+		"instance set to add sidecars to; required when there is more than one")
+	cmd.Flags().BoolVar(&forceConflicts, "force-conflicts", false,
+		"take ownership and overwrite the containers and init SQL settings")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "apply without asking for confirmation")
+	config.Record.AddFlags(cmd.Flags())
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		if err := extras.Load(); err != nil {
+			return err
+		}
+		if len(extras.Sidecars) == 0 && extras.InitSQL == nil {
+			return errors.New("at least one of --sidecar-from-file or --init-sql is required")
+		}
+
+		mapping, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		cluster, err := client.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		intent := new(unstructured.Unstructured)
+		if err := internal.ExtractFieldsInto(cluster, intent, config.Patch.FieldManager); err != nil {
+			return err
+		}
+		if err := extras.modifyIntent(cluster, intent); err != nil {
+			return err
+		}
+		if err := checkClusterChange(cmd.ErrOrStderr(), &config.Messages, cluster, intent); err != nil {
+			return err
+		}
+		if extras.InitSQL != nil {
+			if _, found, _ := unstructured.NestedFieldNoCopy(cluster.Object, "status", "databaseInitSQL"); found {
+				cmd.PrintErrf("WARNING: %s/%s has already run its init SQL; PGO will not run the new SQL.\n",
+					mapping.Resource.Resource, args[0])
+			}
+		}
+
+		// Save the changes for later rather than sending them.
+		if config.Record.Enabled() {
+			if extras.InitSQL != nil {
+				msg, err := recordConfigMap(config, extras.ConfigMap(namespace, args[0]))
+				if err != nil {
+					return err
+				}
+				cmd.Print(msg)
+			}
+			change := internal.NewRecordedChange(internal.RecordApply,
+				mapping.Resource, namespace, args[0], intent)
+			change.Force = forceConflicts
+			msg, err := recordChange(config, change)
+			cmd.Print(msg)
+			return err
+		}
+
+		if err := previewClusterChange(ctx, cmd, config, cluster, mergeIntent(cluster, intent), yes); err != nil {
+			return err
+		}
+
+		if extras.InitSQL != nil {
+			if err := applyConfigMap(ctx, config, cmd, extras.ConfigMap(namespace, args[0])); err != nil {
+				return err
+			}
+		}
+
+		patch, err := intent.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		patchOptions := metav1.PatchOptions{}
+		if forceConflicts {
+			b := true
+			patchOptions.Force = &b
+		}
+		patched, err := client.Namespace(namespace).Patch(ctx, args[0],
+			types.ApplyPatchType, patch, config.Patch.PatchOptions(patchOptions))
+		if err != nil {
+			if apierrors.IsConflict(err) {
+				cmd.PrintErrln("SUGGESTION: The --force-conflicts flag may help in performing this operation.")
+			}
+			return err
+		}
+
+		cmd.Printf("%s/%s patched\n", mapping.Resource.Resource, args[0])
+		if len(extras.Sidecars) == 0 {
+			return nil
+		}
+		return checkSidecarRollout(ctx, cmd, config, client.Namespace(namespace), patched,
+			extras.instanceSet(cluster), extras.Sidecars)
+	}
+
+	return cmd
+}
+
+// sidecarRolloutTimeout is how long edit postgrescluster waits for PGO to
+// reconcile new sidecars before it stops checking them.
+const sidecarRolloutTimeout = 30 * time.Second
+
+// checkSidecarRollout warns when PGO leaves sidecars out of the Pods of the
+// instance set named set after it reconciles cluster. PGO ignores the
+// containers of instance sets unless its InstanceSidecars feature gate is
+// enabled.
+func checkSidecarRollout(
+	ctx context.Context, cmd *cobra.Command, config *internal.Config, client dynamic.ResourceInterface,
+	cluster *unstructured.Unstructured, set string, sidecars []any,
+) error {
+	rest, err := config.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+	clientset, err := kubernetes.NewForConfig(rest)
+	if err != nil {
+		return err
+	}
+
+	// Pods roll out from the StatefulSets of the instance set, which PGO
+	// updates once it has seen this generation of the cluster.
+	for deadline := time.Now().Add(sidecarRolloutTimeout); ; {
+		current, err := client.Get(ctx, cluster.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		observed, _, _ := unstructured.NestedInt64(current.Object, "status", "observedGeneration")
+		if observed >= cluster.GetGeneration() {
+			break
+		}
+		if time.Now().After(deadline) {
+			cmd.PrintErrf("WARNING: PGO has not reconciled postgresclusters/%s within %s; "+
+				"sidecars run only when its InstanceSidecars feature gate is enabled.\n",
+				cluster.GetName(), sidecarRolloutTimeout)
+			return nil
+		}
+		time.Sleep(time.Second)
+	}
+
+	statefulsets, err := clientset.AppsV1().StatefulSets(cluster.GetNamespace()).List(ctx, metav1.ListOptions{
+		LabelSelector: util.LabelCluster + "=" + cluster.GetName() + "," + util.LabelInstanceSet + "=" + set,
+	})
+	if apierrors.IsForbidden(err) {
+		cmd.PrintErrln("WARNING: Unable to read statefulsets; the sidecars are not checked.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	templates := make([]corev1.PodTemplateSpec, 0, len(statefulsets.Items))
+	for _, statefulset := range statefulsets.Items {
+		templates = append(templates, statefulset.Spec.Template)
+	}
+	if missing := missingSidecars(templates, sidecars); len(missing) > 0 {
+		cmd.PrintErrf("WARNING: PGO did not add %s to the Pods of instance set %s. "+
+			"Enable the InstanceSidecars feature gate of PGO to run them.\n",
+			strings.Join(missing, ", "), set)
+	}
+	return nil
+}
+
+// missingSidecars returns the names of sidecars that are not containers of
+// every one of templates, sorted.
+func missingSidecars(templates []corev1.PodTemplateSpec, sidecars []any) []string {
+	var missing []string
+	for _, sidecar := range sidecars {
+		name, _ := sidecar.(map[string]any)["name"].(string)
+		for _, template := range templates {
+			found := false
+			for _, container := range template.Spec.Containers {
+				found = found || container.Name == name
+			}
+			if !found {
+				missing = append(missing, name)
+				break
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// reservedContainerNames are the containers PGO adds to instance Pods.
+var reservedContainerNames = map[string]bool{
+	util.ContainerDatabase:   true,
+	util.ContainerPGBackrest: true,
+	"exporter":               true,
+	"nss-wrapper-init":       true,
+	"pgbackrest-config":      true,
+	"postgres-startup":       true,
+	"replication-cert-copy":  true,
+}
+
+// initSQLKey is the key of the init SQL in its ConfigMap.
+const initSQLKey = "init.sql"
+
+// initSQLConfigMapName returns the name of the ConfigMap that holds the init
+// SQL of the cluster named clusterName.
+func initSQLConfigMapName(clusterName string) string { return clusterName + "-init-sql" }
+
+// instanceExtras are containers and bootstrap SQL to add to a PostgresCluster.
+type instanceExtras struct {
+	SidecarFile string
+	InitSQLFile string
+
+	// InstanceSet is where Sidecars go. It may be empty when there is only
+	// one instance set.
+	InstanceSet string
+
+	Sidecars []any
+	InitSQL  []byte
+}
+
+// AddFlags adds the flags that read extras from files to flags.
+func (extras *instanceExtras) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&extras.SidecarFile, "sidecar-from-file", "",
+		"path to a YAML file with a container or list of containers to run alongside Postgres")
+	flags.StringVar(&extras.InitSQLFile, "init-sql", "",
+		"path to a SQL file to run once when the cluster is bootstrapped")
+}
+
+// Load reads the files named by the flags of extras.
+func (extras *instanceExtras) Load() error {
+	if extras.SidecarFile != "" {
+		b, err := os.ReadFile(extras.SidecarFile)
+		if err != nil {
+			return err
+		}
+		if extras.Sidecars, err = parseSidecars(b); err != nil {
+			return fmt.Errorf("%s: %w", extras.SidecarFile, err)
+		}
+	}
+	if extras.InitSQLFile != "" {
+		b, err := os.ReadFile(extras.InitSQLFile)
+		if err != nil {
+			return err
+		}
+		if len(b) == 0 {
+			return fmt.Errorf("%s is empty", extras.InitSQLFile)
+		}
+		// A ConfigMap holds at most 1MiB.
+		if len(b) > 1<<20 {
+			return fmt.Errorf("%s is larger than the 1MiB a ConfigMap can hold", extras.InitSQLFile)
+		}
+		extras.InitSQL = b
+	}
+	return nil
+}
+
+// parseSidecars parses one container or a list of containers from YAML.
+// Every container needs a name and an image, and names must not be used by
+// PGO or repeated.
+func parseSidecars(b []byte) ([]any, error) {
+	var parsed any
+	if err := yaml.Unmarshal(b, &parsed); err != nil {
+		return nil, err
+	}
+
+	var items []any
+	switch parsed := parsed.(type) {
+	case map[string]any:
+		items = []any{parsed}
+	case []any:
+		items = parsed
+	default:
+		return nil, errors.New("expected a container or a list of containers")
+	}
+	if len(items) == 0 {
+		return nil, errors.New("no containers found")
+	}
+
+	seen := map[string]bool{}
+	for i, item := range items {
+		// Decode strictly into a Container to catch misspelled fields.
+		b, _ := json.Marshal(item)
+		var container corev1.Container
+		if err := yaml.UnmarshalStrict(b, &container); err != nil {
+			return nil, fmt.Errorf("container %d: %w", i+1, err)
+		}
+		switch {
+		case container.Name == "":
+			return nil, fmt.Errorf("container %d has no name", i+1)
+		case container.Image == "":
+			return nil, fmt.Errorf("container %q has no image", container.Name)
+		case reservedContainerNames[container.Name]:
+			return nil, fmt.Errorf("container %q has the name of a container PGO manages", container.Name)
+		case seen[container.Name]:
+			return nil, fmt.Errorf("container %q is defined more than once", container.Name)
+		}
+		seen[container.Name] = true
+	}
+	return items, nil
+}
+
+// mergeContainers returns current with each of sidecars added or, when one
+// has the same name, replaced.
+func mergeContainers(current, sidecars []any) []any {
+	merged := append([]any{}, current...)
+	for _, sidecar := range sidecars {
+		name := sidecar.(map[string]any)["name"]
+
+		replaced := false
+		for i, container := range merged {
+			if container, ok := container.(map[string]any); ok && container["name"] == name {
+				merged[i], replaced = sidecar, true
+			}
+		}
+		if !replaced {
+			merged = append(merged, sidecar)
+		}
+	}
+	return merged
+}
+
+// ConfigMap returns the ConfigMap that holds the init SQL of extras for the
+// cluster named clusterName.
+func (extras instanceExtras) ConfigMap(namespace, clusterName string) map[string]any {
+	return map[string]any{
+		"apiVersion": corev1.SchemeGroupVersion.String(),
+		"kind":       "ConfigMap",
+		"metadata": map[string]any{
+			"name":      initSQLConfigMapName(clusterName),
+			"namespace": namespace,
+			"labels":    map[string]any{util.LabelCluster: clusterName},
+		},
+		"data": map[string]any{initSQLKey: string(extras.InitSQL)},
+	}
+}
+
+// instanceSet returns the name of the instance set of cluster that the
+// sidecars of extras go to, or an empty string when there is none.
+func (extras instanceExtras) instanceSet(cluster *unstructured.Unstructured) string {
+	if extras.InstanceSet != "" {
+		return extras.InstanceSet
+	}
+	sets, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "instances")
+	if len(sets) != 1 {
+		return ""
+	}
+	set, _ := sets[0].(map[string]any)
+	name, _, _ := unstructured.NestedString(set, "name")
+	return name
+}
+
+// modifyIntent adds the sidecars and init SQL of extras to intent. The
+// containers of an instance set are an atomic list, so they start from the
+// ones in cluster.
+func (extras instanceExtras) modifyIntent(cluster, intent *unstructured.Unstructured) error {
+	if extras.InitSQL != nil {
+		if err := unstructured.SetNestedStringMap(intent.Object, map[string]string{
+			"name": initSQLConfigMapName(cluster.GetName()),
+			"key":  initSQLKey,
+		}, "spec", "databaseInitSQL"); err != nil {
+			return err
+		}
+	}
+	if len(extras.Sidecars) == 0 {
+		return nil
+	}
+
+	sets, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "instances")
+	names := make([]string, 0, len(sets))
+	var target map[string]any
+	for _, set := range sets {
+		set, _ := set.(map[string]any)
+		name, _, _ := unstructured.NestedString(set, "name")
+		names = append(names, name)
+		if name == extras.InstanceSet || (extras.InstanceSet == "" && len(sets) == 1) {
+			target = set
+		}
+	}
+	if target == nil {
+		sort.Strings(names)
+		if extras.InstanceSet == "" {
+			return fmt.Errorf("--instance-set is required; instance sets are %q", names)
+		}
+		return fmt.Errorf("instance set %q not found; instance sets are %q", extras.InstanceSet, names)
+	}
+	name, _, _ := unstructured.NestedString(target, "name")
+	current, _, _ := unstructured.NestedSlice(target, "containers")
+
+	// Instance sets are a list keyed by name. Keep any other fields this
+	// field manager already owns.
+	owned, _, _ := unstructured.NestedSlice(intent.Object, "spec", "instances")
+	instance := map[string]any{"name": name}
+	instances := make([]any, 0, len(owned)+1)
+	for _, item := range owned {
+		if item, ok := item.(map[string]any); ok {
+			if owner, _, _ := unstructured.NestedString(item, "name"); owner == name {
+				instance = item
+				continue
+			}
+		}
+		instances = append(instances, item)
+	}
+	instance["containers"] = mergeContainers(current, extras.Sidecars)
+	instances = append(instances, instance)
+
+	return unstructured.SetNestedSlice(intent.Object, instances, "spec", "instances")
+}
+
+// applyConfigMap creates or updates configMap using server-side apply.
+func applyConfigMap(ctx context.Context, config *internal.Config, cmd *cobra.Command, configMap map[string]any) error {
+	rest, err := config.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+	clientset, err := kubernetes.NewForConfig(rest)
+	if err != nil {
+		return err
+	}
+
+	patch, err := json.Marshal(configMap)
+	if err != nil {
+		return err
+	}
+	metadata := configMap["metadata"].(map[string]any)
+	name := metadata["name"].(string)
+	if _, err := clientset.CoreV1().ConfigMaps(metadata["namespace"].(string)).Patch(
+		ctx, name, types.ApplyPatchType, patch,
+		config.Patch.PatchOptions(metav1.PatchOptions{})); err != nil {
+		return err
+	}
+	cmd.Printf("configmaps/%s applied\n", name)
+	return nil
+}
+
+// recordConfigMap records an apply of configMap to the file named by the
+// --record flag.
+func recordConfigMap(config *internal.Config, configMap map[string]any) (string, error) {
+	metadata := configMap["metadata"].(map[string]any)
+	return recordChange(config, internal.NewRecordedChange(internal.RecordApply,
+		corev1.SchemeGroupVersion.WithResource("configmaps"),
+		metadata["namespace"].(string), metadata["name"].(string),
+		&unstructured.Unstructured{Object: configMap}))
+}
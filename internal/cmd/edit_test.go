@@ -0,0 +1,228 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crunchydata/postgres-operator-client/internal/testing/cmp"
+)
+
+func TestParseSidecars(t *testing.T) {
+	sidecars, err := parseSidecars([]byte(`
+name: fluent-bit
+image: fluent/fluent-bit:3.0
+`))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, sidecars, []any{
+		map[string]any{"name": "fluent-bit", "image": "fluent/fluent-bit:3.0"},
+	})
+
+	sidecars, err = parseSidecars([]byte(`
+- name: fluent-bit
+  image: fluent/fluent-bit:3.0
+- name: vector
+  image: timberio/vector:0.38.0
+  args: [--config, /etc/vector/vector.yaml]
+`))
+	assert.NilError(t, err)
+	assert.Equal(t, len(sidecars), 2)
+
+	for _, tt := range []struct{ yaml, message string }{
+		{`[]`, "no containers found"},
+		{`"fluent-bit"`, "expected a container or a list of containers"},
+		{`{image: x}`, "container 1 has no name"},
+		{`{name: x}`, `container "x" has no image`},
+		{`{name: database, image: x}`, `container "database" has the name of a container PGO manages`},
+		{`[{name: x, image: x}, {name: x, image: y}]`, `container "x" is defined more than once`},
+		{`{name: x, image: x, imagePullPolicy: Always, ports: 5}`, "container 1: "},
+		{`{name: x, image: x, comand: [sh]}`, `container 1: error unmarshaling JSON`},
+	} {
+		_, err := parseSidecars([]byte(tt.yaml))
+		assert.ErrorContains(t, err, tt.message, "%s", tt.yaml)
+	}
+}
+
+func TestMergeContainers(t *testing.T) {
+	current := []any{
+		map[string]any{"name": "agent", "image": "agent:1"},
+		map[string]any{"name": "other", "image": "other:1"},
+	}
+	merged := mergeContainers(current, []any{
+		map[string]any{"name": "agent", "image": "agent:2"},
+		map[string]any{"name": "new", "image": "new:1"},
+	})
+	assert.DeepEqual(t, merged, []any{
+		map[string]any{"name": "agent", "image": "agent:2"},
+		map[string]any{"name": "other", "image": "other:1"},
+		map[string]any{"name": "new", "image": "new:1"},
+	})
+	assert.DeepEqual(t, current[0], map[string]any{"name": "agent", "image": "agent:1"})
+}
+
+func TestInstanceExtrasLoad(t *testing.T) {
+	dir := t.TempDir()
+	sql := filepath.Join(dir, "bootstrap.sql")
+	assert.NilError(t, os.WriteFile(sql, []byte("CREATE ROLE app;\n"), 0o600))
+	empty := filepath.Join(dir, "empty.sql")
+	assert.NilError(t, os.WriteFile(empty, nil, 0o600))
+	sidecar := filepath.Join(dir, "sidecar.yaml")
+	assert.NilError(t, os.WriteFile(sidecar, []byte("{name: pgo}"), 0o600))
+
+	extras := instanceExtras{InitSQLFile: sql}
+	assert.NilError(t, extras.Load())
+	assert.Equal(t, string(extras.InitSQL), "CREATE ROLE app;\n")
+
+	extras = instanceExtras{InitSQLFile: empty}
+	assert.ErrorContains(t, extras.Load(), "empty.sql is empty")
+
+	extras = instanceExtras{SidecarFile: sidecar}
+	assert.ErrorContains(t, extras.Load(), `sidecar.yaml: container "pgo" has no image`)
+}
+
+func TestInstanceExtrasModifyIntent(t *testing.T) {
+	extras := instanceExtras{
+		InitSQL:  []byte("CREATE ROLE app;"),
+		Sidecars: []any{map[string]any{"name": "agent", "image": "agent:2"}},
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		cluster, err := generateUnstructuredClusterYaml("hippo", "16")
+		assert.NilError(t, err)
+		assert.NilError(t, extras.modifyIntent(cluster, cluster))
+
+		instances, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "instances")
+		assert.Equal(t, len(instances), 1)
+		assert.Assert(t, cmp.MarshalMatches(instances[0], `
+containers:
+- image: agent:2
+  name: agent
+dataVolumeClaimSpec:
+  accessModes:
+  - ReadWriteOnce
+  resources:
+    requests:
+      storage: 1Gi
+`))
+		assert.Assert(t, cmp.MarshalMatches(cluster.Object["spec"].(map[string]any)["databaseInitSQL"], `
+key: init.sql
+name: hippo-init-sql
+`))
+	})
+
+	cluster := &unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{"name": "hippo"},
+		"spec": map[string]any{"instances": []any{
+			map[string]any{"name": "one", "containers": []any{
+				map[string]any{"name": "agent", "image": "agent:1"},
+				map[string]any{"name": "other", "image": "other:1"},
+			}},
+			map[string]any{"name": "two"},
+		}},
+	}}
+
+	t.Run("InstanceSetRequired", func(t *testing.T) {
+		intent := &unstructured.Unstructured{Object: map[string]any{}}
+		assert.ErrorContains(t, extras.modifyIntent(cluster, intent),
+			`--instance-set is required; instance sets are ["one" "two"]`)
+
+		extras := extras
+		extras.InstanceSet = "three"
+		assert.ErrorContains(t, extras.modifyIntent(cluster, intent),
+			`instance set "three" not found`)
+	})
+
+	t.Run("Edit", func(t *testing.T) {
+		extras := extras
+		extras.InstanceSet = "one"
+
+		intent := &unstructured.Unstructured{Object: map[string]any{
+			"spec": map[string]any{"instances": []any{
+				map[string]any{"name": "two", "minAvailable": int64(1)},
+				map[string]any{"name": "one", "minAvailable": int64(1)},
+			}},
+		}}
+		assert.NilError(t, extras.modifyIntent(cluster, intent))
+		assert.Assert(t, cmp.MarshalMatches(intent.Object, `
+spec:
+  databaseInitSQL:
+    key: init.sql
+    name: hippo-init-sql
+  instances:
+  - minAvailable: 1
+    name: two
+  - containers:
+    - image: agent:2
+      name: agent
+    - image: other:1
+      name: other
+    minAvailable: 1
+    name: one
+`))
+	})
+
+	assert.Assert(t, cmp.MarshalMatches(extras.ConfigMap("postgres-operator", "hippo"), `
+apiVersion: v1
+data:
+  init.sql: CREATE ROLE app;
+kind: ConfigMap
+metadata:
+  labels:
+    postgres-operator.crunchydata.com/cluster: hippo
+  name: hippo-init-sql
+  namespace: postgres-operator
+`))
+}
+
+func TestInstanceExtrasInstanceSet(t *testing.T) {
+	one := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{"instances": []any{map[string]any{"name": "one"}}},
+	}}
+	two := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{"instances": []any{
+			map[string]any{"name": "one"}, map[string]any{"name": "two"},
+		}},
+	}}
+
+	assert.Equal(t, instanceExtras{}.instanceSet(one), "one")
+	assert.Equal(t, instanceExtras{}.instanceSet(two), "")
+	assert.Equal(t, instanceExtras{InstanceSet: "two"}.instanceSet(two), "two")
+}
+
+func TestMissingSidecars(t *testing.T) {
+	template := func(names ...string) corev1.PodTemplateSpec {
+		var template corev1.PodTemplateSpec
+		for _, name := range names {
+			template.Spec.Containers = append(template.Spec.Containers, corev1.Container{Name: name})
+		}
+		return template
+	}
+	sidecars := []any{
+		map[string]any{"name": "fluent-bit", "image": "fluent-bit:3"},
+		map[string]any{"name": "agent", "image": "agent:2"},
+	}
+
+	assert.Equal(t, len(missingSidecars(nil, sidecars)), 0)
+	assert.Equal(t, len(missingSidecars([]corev1.PodTemplateSpec{
+		template("database", "agent", "fluent-bit"),
+	}, sidecars)), 0)
+
+	// Without the InstanceSidecars feature gate, PGO ignores them all.
+	assert.DeepEqual(t, missingSidecars([]corev1.PodTemplateSpec{
+		template("database", "pgbackrest"),
+	}, sidecars), []string{"agent", "fluent-bit"})
+
+	// Every instance of the set needs each one.
+	assert.DeepEqual(t, missingSidecars([]corev1.PodTemplateSpec{
+		template("database", "agent", "fluent-bit"),
+		template("database", "agent"),
+	}, sidecars), []string{"fluent-bit"})
+}
@@ -0,0 +1,133 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+)
+
+// The outcomes of an ensure command.
+const (
+	ensureCreated   = "created"
+	ensureUpdated   = "updated"
+	ensureUnchanged = "unchanged"
+)
+
+// newEnsureCommand returns the ensure subcommand of the PGO plugin.
+// Subcommands of ensure can run again and again with the same flags; they
+// change a PostgresCluster only when it differs from those flags.
+func newEnsureCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ensure",
+		Short: "Make a part of a PostgresCluster match its flags",
+		Long: `Make a part of a PostgresCluster match its flags: create it when it is absent,
+change it when it differs, and do nothing otherwise. Each subcommand prints
+which of "created", "updated", or "unchanged" happened, so configuration
+management tools can run it repeatedly.`,
+	}
+
+	cmd.AddCommand(
+		newEnsureBackupScheduleCommand(config),
+		newEnsureDatabaseCommand(config),
+		newEnsureUserCommand(config),
+	)
+
+	return cmd
+}
+
+// ensureClusterPart changes the cluster called clusterName with ensure, which
+// reads cluster and fills intent, then prints the outcome. Nothing is sent
+// when the outcome is [ensureUnchanged].
+func ensureClusterPart(
+	cmd *cobra.Command, config *internal.Config, clusterName, part string, forceConflicts bool,
+	ensure func(cluster, intent *unstructured.Unstructured) (string, error),
+) error {
+	ctx := context.Background()
+
+	mapping, client, err := v1beta1.NewPostgresClusterClient(config)
+	if err != nil {
+		return err
+	}
+	namespace, err := config.Namespace()
+	if err != nil {
+		return err
+	}
+
+	cluster, err := client.Namespace(namespace).Get(ctx, clusterName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	intent := new(unstructured.Unstructured)
+	if err := internal.ExtractFieldsInto(cluster, intent, config.Patch.FieldManager); err != nil {
+		return err
+	}
+	outcome, err := ensure(cluster, intent)
+	if err != nil {
+		return err
+	}
+	if outcome == ensureUnchanged {
+		cmd.Printf("%s/%s %s %s\n", mapping.Resource.Resource, clusterName, part, outcome)
+		return nil
+	}
+	if err := checkClusterChange(cmd.ErrOrStderr(), &config.Messages, cluster, intent); err != nil {
+		return err
+	}
+
+	// Save the change for later rather than sending it.
+	if config.Record.Enabled() {
+		change := internal.NewRecordedChange(internal.RecordApply,
+			mapping.Resource, namespace, clusterName, intent)
+		change.Force = forceConflicts
+		msg, err := recordChange(config, change)
+		cmd.Print(msg)
+		return err
+	}
+
+	patch, err := intent.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	patchOptions := metav1.PatchOptions{}
+	if forceConflicts {
+		b := true
+		patchOptions.Force = &b
+	}
+
+	_, err = client.Namespace(namespace).Patch(ctx, clusterName,
+		types.ApplyPatchType, patch, config.Patch.PatchOptions(patchOptions))
+	if err != nil {
+		if apierrors.IsConflict(err) {
+			cmd.PrintErrln("SUGGESTION: The --force-conflicts flag may help in performing this operation.")
+		}
+		return err
+	}
+
+	cmd.Printf("%s/%s %s %s\n", mapping.Resource.Resource, clusterName, part, outcome)
+	return nil
+}
+
+// ownedListItem returns the item of the list at fields in intent whose name is
+// name, adding one when there is none. Call [unstructured.SetNestedSlice] with
+// the returned list to keep the change.
+func ownedListItem(intent *unstructured.Unstructured, name string, fields ...string) (map[string]any, []any) {
+	list, _, _ := unstructured.NestedSlice(intent.Object, fields...)
+	for _, entry := range list {
+		if item, ok := entry.(map[string]any); ok && item["name"] == name {
+			return item, list
+		}
+	}
+	item := map[string]any{"name": name}
+	return item, append(list, item)
+}
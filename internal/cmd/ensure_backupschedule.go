@@ -0,0 +1,126 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// newEnsureBackupScheduleCommand returns the backupschedule subcommand of the
+// ensure command.
+func newEnsureBackupScheduleCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backupschedule CLUSTER_NAME",
+		Short: "Make sure a backup schedule is in the spec of a PostgresCluster",
+		Long: `Make sure the pgBackRest repository "--repo" of a PostgresCluster has the
+cron expression "--schedule" for backups of "--type". PGO runs each schedule
+as a CronJob. The repository must already exist.
+Overwriting values set by others may require the --force-conflicts flag.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    postgresclusters.postgres-operator.crunchydata.com  [get patch]
+
+    Note: Patching is only needed when the schedule differs.
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Make sure repo1 of the 'hippo' postgrescluster has a full backup every Sunday
+pgo ensure backupschedule hippo --repo=repo1 --type=full --schedule="0 1 * * 0"
+
+### Example output
+postgresclusters/hippo repo1 full backup schedule updated`)
+
+	var schedule ensureBackupScheduleArgs
+	var forceConflicts bool
+	cmd.Flags().StringVar(&schedule.Repo, "repo", "", "name of the pgBackRest repository, e.g. repo1")
+	cobra.CheckErr(cmd.MarkFlagRequired("repo"))
+	cmd.Flags().StringVar(&schedule.Type, "type", "",
+		"type of backup. types supported: full,diff,incr")
+	cobra.CheckErr(cmd.MarkFlagRequired("type"))
+	cmd.Flags().StringVar(&schedule.Schedule, "schedule", "", "cron expression of the backups")
+	cobra.CheckErr(cmd.MarkFlagRequired("schedule"))
+	cmd.Flags().BoolVar(&forceConflicts, "force-conflicts", false,
+		"take ownership and overwrite the backup schedule")
+	config.Record.AddFlags(cmd.Flags())
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := schedule.validate(); err != nil {
+			return err
+		}
+		return ensureClusterPart(cmd, config, args[0],
+			schedule.Repo+" "+schedule.Type+" backup schedule", forceConflicts, schedule.ensure)
+	}
+
+	return cmd
+}
+
+type ensureBackupScheduleArgs struct {
+	Repo, Type, Schedule string
+}
+
+// backupScheduleFields are the fields of "schedules" in a repository, by the
+// types of "pgbackrest backup".
+var backupScheduleFields = map[string]string{
+	"full": "full", "diff": "differential", "incr": "incremental",
+}
+
+// validate returns an error when PGO would reject the schedule.
+func (schedule ensureBackupScheduleArgs) validate() error {
+	if !repoNamePattern.MatchString(schedule.Repo) {
+		return fmt.Errorf("--repo must be repo1, repo2, repo3, or repo4")
+	}
+	if backupScheduleFields[schedule.Type] == "" {
+		return fmt.Errorf("--type must be one of full, diff, or incr")
+	}
+	if _, err := parseCronSchedule(schedule.Schedule, time.UTC); err != nil {
+		return fmt.Errorf("--schedule: %w", err)
+	}
+	return nil
+}
+
+// ensure sets the schedule in intent when the repository in cluster has a
+// different one.
+func (schedule ensureBackupScheduleArgs) ensure(cluster, intent *unstructured.Unstructured) (string, error) {
+	field := backupScheduleFields[schedule.Type]
+	fields := []string{"spec", "backups", "pgbackrest", "repos"}
+
+	var found bool
+	outcome := ensureCreated
+	repos, _, _ := unstructured.NestedSlice(cluster.Object, fields...)
+	for _, entry := range repos {
+		repo, ok := entry.(map[string]any)
+		if !ok || repo["name"] != schedule.Repo {
+			continue
+		}
+		found = true
+		switch current, _, _ := unstructured.NestedString(repo, "schedules", field); current {
+		case schedule.Schedule:
+			return ensureUnchanged, nil
+		case "":
+		default:
+			outcome = ensureUpdated
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("postgresclusters/%s has no repository %s", cluster.GetName(), schedule.Repo)
+	}
+
+	item, list := ownedListItem(intent, schedule.Repo, fields...)
+	if err := unstructured.SetNestedField(item, schedule.Schedule, "schedules", field); err != nil {
+		return "", err
+	}
+	return outcome, unstructured.SetNestedSlice(intent.Object, list, fields...)
+}
@@ -0,0 +1,100 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// newEnsureDatabaseCommand returns the database subcommand of the ensure
+// command.
+func newEnsureDatabaseCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "database CLUSTER_NAME",
+		Short: "Make sure a database is in the spec of a PostgresCluster",
+		Long: `Make sure the database "--name" is one of the databases of the user "--owner"
+in "spec.users" of a PostgresCluster, adding the user when it is absent. PGO
+creates the database and grants the user all privileges on it.
+Overwriting values set by others may require the --force-conflicts flag.
+
+The outcome is "created" when no user had the database, and "updated" when
+only other users had it.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    postgresclusters.postgres-operator.crunchydata.com  [get patch]
+
+    Note: Patching is only needed when the database is missing.
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Make sure the 'hippo' postgrescluster has the database 'zoo' for the user 'rhino'
+pgo ensure database hippo --name=zoo --owner=rhino
+
+### Example output
+postgresclusters/hippo database zoo unchanged`)
+
+	var database ensureDatabaseArgs
+	var forceConflicts bool
+	cmd.Flags().StringVar(&database.Name, "name", "", "name of the database")
+	cobra.CheckErr(cmd.MarkFlagRequired("name"))
+	cmd.Flags().StringVar(&database.Owner, "owner", "", "user in spec.users that has the database")
+	cobra.CheckErr(cmd.MarkFlagRequired("owner"))
+	cmd.Flags().BoolVar(&forceConflicts, "force-conflicts", false,
+		"take ownership and overwrite the databases of the user")
+	config.Record.AddFlags(cmd.Flags())
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if database.Name == "" {
+			return errors.New("--name cannot be empty")
+		}
+		owner := ensureUserArgs{Name: database.Owner, Databases: []string{database.Name}}
+		if err := owner.validate(); err != nil {
+			return err
+		}
+		return ensureClusterPart(cmd, config, args[0], "database "+database.Name, forceConflicts, database.ensure)
+	}
+
+	return cmd
+}
+
+type ensureDatabaseArgs struct {
+	Name, Owner string
+}
+
+// ensure adds the database to the owner in intent when the owner in cluster
+// does not have it.
+func (database ensureDatabaseArgs) ensure(cluster, intent *unstructured.Unstructured) (string, error) {
+	outcome := ensureCreated
+	users, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "users")
+	for _, entry := range users {
+		user, ok := entry.(map[string]any)
+		if !ok {
+			continue
+		}
+		databases, _, _ := unstructured.NestedStringSlice(user, "databases")
+		if len(missingStrings(databases, []string{database.Name})) == 0 {
+			if user["name"] == database.Owner {
+				return ensureUnchanged, nil
+			}
+			outcome = ensureUpdated
+		}
+	}
+
+	owner := ensureUserArgs{Name: database.Owner, Databases: []string{database.Name}}
+	if _, err := owner.ensure(cluster, intent); err != nil {
+		return "", err
+	}
+	return outcome, nil
+}
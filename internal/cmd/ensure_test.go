@@ -0,0 +1,160 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crunchydata/postgres-operator-client/internal/testing/cmp"
+)
+
+func TestEnsureUser(t *testing.T) {
+	cluster := new(unstructured.Unstructured)
+	assert.NilError(t, yaml.Unmarshal([]byte(`
+spec:
+  users:
+  - name: rhino
+    databases: [zoo]
+    options: CREATEDB
+`), &cluster.Object))
+
+	t.Run("Unchanged", func(t *testing.T) {
+		intent := &unstructured.Unstructured{Object: map[string]any{}}
+		outcome, err := ensureUserArgs{Name: "rhino", Databases: []string{"zoo"}}.ensure(cluster, intent)
+		assert.NilError(t, err)
+		assert.Equal(t, outcome, ensureUnchanged)
+		assert.Assert(t, cmp.MarshalMatches(intent.Object, `{}`))
+
+		user := ensureUserArgs{Name: "rhino", Options: "CREATEDB", SetOptions: true}
+		outcome, err = user.ensure(cluster, intent)
+		assert.NilError(t, err)
+		assert.Equal(t, outcome, ensureUnchanged)
+	})
+
+	t.Run("Updated", func(t *testing.T) {
+		intent := &unstructured.Unstructured{Object: map[string]any{}}
+		assert.NilError(t, yaml.Unmarshal([]byte(`
+spec:
+  users:
+  - name: rhino
+    databases: [app]
+`), &intent.Object))
+
+		user := ensureUserArgs{Name: "rhino", Databases: []string{"zoo", "app", "web"},
+			Options: "", SetOptions: true}
+		outcome, err := user.ensure(cluster, intent)
+		assert.NilError(t, err)
+		assert.Equal(t, outcome, ensureUpdated)
+		assert.Assert(t, cmp.MarshalMatches(intent.Object, `
+spec:
+  users:
+  - databases:
+    - app
+    - zoo
+    - web
+    name: rhino
+    options: ""
+`))
+	})
+
+	t.Run("Created", func(t *testing.T) {
+		intent := &unstructured.Unstructured{Object: map[string]any{}}
+		outcome, err := ensureUserArgs{Name: "hippo"}.ensure(cluster, intent)
+		assert.NilError(t, err)
+		assert.Equal(t, outcome, ensureCreated)
+		assert.Assert(t, cmp.MarshalMatches(intent.Object, `
+spec:
+  users:
+  - name: hippo
+`))
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		assert.NilError(t, ensureUserArgs{Name: "rhino", Databases: []string{"zoo"}}.validate())
+		assert.ErrorContains(t, ensureUserArgs{Name: "Rhino"}.validate(), "not a valid user name")
+		assert.ErrorContains(t, ensureUserArgs{Name: "rhino", Databases: []string{""}}.validate(), "empty")
+		assert.ErrorContains(t, ensureUserArgs{Name: "rhino", Options: "password 'x'"}.validate(), "password")
+	})
+}
+
+func TestEnsureDatabase(t *testing.T) {
+	cluster := new(unstructured.Unstructured)
+	assert.NilError(t, yaml.Unmarshal([]byte(`
+spec:
+  users:
+  - name: rhino
+    databases: [zoo]
+  - name: hippo
+`), &cluster.Object))
+
+	for _, tt := range []struct {
+		database ensureDatabaseArgs
+		outcome  string
+	}{
+		{ensureDatabaseArgs{Name: "zoo", Owner: "rhino"}, ensureUnchanged},
+		{ensureDatabaseArgs{Name: "zoo", Owner: "hippo"}, ensureUpdated},
+		{ensureDatabaseArgs{Name: "app", Owner: "hippo"}, ensureCreated},
+		{ensureDatabaseArgs{Name: "app", Owner: "zebra"}, ensureCreated},
+	} {
+		intent := &unstructured.Unstructured{Object: map[string]any{}}
+		outcome, err := tt.database.ensure(cluster, intent)
+		assert.NilError(t, err)
+		assert.Equal(t, outcome, tt.outcome, "%+v", tt.database)
+
+		if outcome != ensureUnchanged {
+			users, _, _ := unstructured.NestedSlice(intent.Object, "spec", "users")
+			assert.DeepEqual(t, users, []any{map[string]any{
+				"name": tt.database.Owner, "databases": []any{tt.database.Name},
+			}})
+		}
+	}
+}
+
+func TestEnsureBackupSchedule(t *testing.T) {
+	cluster := new(unstructured.Unstructured)
+	cluster.SetName("hippo")
+	assert.NilError(t, unstructured.SetNestedSlice(cluster.Object, []any{
+		map[string]any{"name": "repo1", "schedules": map[string]any{"full": "0 1 * * 0"}},
+	}, "spec", "backups", "pgbackrest", "repos"))
+
+	for _, tt := range []struct {
+		schedule ensureBackupScheduleArgs
+		outcome  string
+	}{
+		{ensureBackupScheduleArgs{Repo: "repo1", Type: "full", Schedule: "0 1 * * 0"}, ensureUnchanged},
+		{ensureBackupScheduleArgs{Repo: "repo1", Type: "full", Schedule: "0 2 * * 0"}, ensureUpdated},
+		{ensureBackupScheduleArgs{Repo: "repo1", Type: "incr", Schedule: "0 1 * * 1-6"}, ensureCreated},
+	} {
+		intent := &unstructured.Unstructured{Object: map[string]any{}}
+		outcome, err := tt.schedule.ensure(cluster, intent)
+		assert.NilError(t, err)
+		assert.Equal(t, outcome, tt.outcome, "%+v", tt.schedule)
+	}
+
+	intent := &unstructured.Unstructured{Object: map[string]any{}}
+	_, err := ensureBackupScheduleArgs{Repo: "repo1", Type: "diff", Schedule: "0 1 * * 3"}.ensure(cluster, intent)
+	assert.NilError(t, err)
+	assert.Assert(t, cmp.MarshalMatches(intent.Object, `
+spec:
+  backups:
+    pgbackrest:
+      repos:
+      - name: repo1
+        schedules:
+          differential: 0 1 * * 3
+`))
+
+	_, err = ensureBackupScheduleArgs{Repo: "repo2", Type: "full", Schedule: "0 1 * * 0"}.ensure(cluster, intent)
+	assert.ErrorContains(t, err, "postgresclusters/hippo has no repository repo2")
+
+	assert.NilError(t, ensureBackupScheduleArgs{Repo: "repo2", Type: "incr", Schedule: "@daily"}.validate())
+	assert.ErrorContains(t, ensureBackupScheduleArgs{Repo: "repo5", Type: "full", Schedule: "@daily"}.validate(), "--repo")
+	assert.ErrorContains(t, ensureBackupScheduleArgs{Repo: "repo1", Type: "weekly", Schedule: "@daily"}.validate(), "--type")
+	assert.ErrorContains(t, ensureBackupScheduleArgs{Repo: "repo1", Type: "full", Schedule: "soon"}.validate(), "--schedule")
+}
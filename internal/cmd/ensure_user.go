@@ -0,0 +1,149 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// newEnsureUserCommand returns the user subcommand of the ensure command.
+func newEnsureUserCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "user CLUSTER_NAME",
+		Short: "Make sure a user is in the spec of a PostgresCluster",
+		Long: `Make sure "spec.users" of a PostgresCluster has the user "--name". The user
+gets the databases of "--databases" that it does not have yet; none are
+removed. With "--options", the role options of the user are set as well.
+PGO creates the role, its databases, and its Secret.
+Overwriting values set by others may require the --force-conflicts flag.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    postgresclusters.postgres-operator.crunchydata.com  [get patch]
+
+    Note: Patching is only needed when the user differs.
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Make sure the 'hippo' postgrescluster has the user 'rhino' with the database 'zoo'
+pgo ensure user hippo --name=rhino --databases=zoo
+
+### Example output
+postgresclusters/hippo user rhino created`)
+
+	var user ensureUserArgs
+	var forceConflicts bool
+	cmd.Flags().StringVar(&user.Name, "name", "", "name of the user")
+	cobra.CheckErr(cmd.MarkFlagRequired("name"))
+	cmd.Flags().StringSliceVar(&user.Databases, "databases", nil,
+		"databases the user has, e.g. zoo,app")
+	cmd.Flags().StringVar(&user.Options, "options", "",
+		`role options of the user, e.g. "CREATEDB CREATEROLE"`)
+	cmd.Flags().BoolVar(&forceConflicts, "force-conflicts", false,
+		"take ownership and overwrite the user settings")
+	config.Record.AddFlags(cmd.Flags())
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		user.SetOptions = cmd.Flags().Changed("options")
+		if err := user.validate(); err != nil {
+			return err
+		}
+		return ensureClusterPart(cmd, config, args[0], "user "+user.Name, forceConflicts, user.ensure)
+	}
+
+	return cmd
+}
+
+type ensureUserArgs struct {
+	Name      string
+	Databases []string
+
+	// Options are set only when SetOptions is true.
+	Options    string
+	SetOptions bool
+}
+
+// validate returns an error when PGO would reject the user.
+func (user ensureUserArgs) validate() error {
+	if problems := validation.IsDNS1123Label(user.Name); len(problems) > 0 {
+		return fmt.Errorf("--name %q is not a valid user name: %s", user.Name, strings.Join(problems, "; "))
+	}
+	for _, database := range user.Databases {
+		if database == "" {
+			return errors.New("--databases cannot have empty names")
+		}
+	}
+	if strings.Contains(strings.ToUpper(user.Options), "PASSWORD") {
+		return errors.New("--options cannot set a password; PGO keeps it in a Secret")
+	}
+	return nil
+}
+
+// ensure adds the user to intent when cluster does not have it as requested.
+func (user ensureUserArgs) ensure(cluster, intent *unstructured.Unstructured) (string, error) {
+	outcome := ensureCreated
+	users, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "users")
+	for _, entry := range users {
+		current, ok := entry.(map[string]any)
+		if !ok || current["name"] != user.Name {
+			continue
+		}
+		databases, _, _ := unstructured.NestedStringSlice(current, "databases")
+		options, _, _ := unstructured.NestedString(current, "options")
+		if len(missingStrings(databases, user.Databases)) == 0 &&
+			(!user.SetOptions || options == user.Options) {
+			return ensureUnchanged, nil
+		}
+		outcome = ensureUpdated
+	}
+
+	// Databases are a set, so keep the ones this field manager already owns
+	// and add the rest.
+	item, list := ownedListItem(intent, user.Name, "spec", "users")
+	owned, _, _ := unstructured.NestedStringSlice(item, "databases")
+	if missing := missingStrings(owned, user.Databases); len(missing) > 0 {
+		item["databases"] = toAnySlice(append(owned, missing...))
+	}
+	if user.SetOptions {
+		item["options"] = user.Options
+	}
+	return outcome, unstructured.SetNestedSlice(intent.Object, list, "spec", "users")
+}
+
+// missingStrings returns the values of wanted that are not in have.
+func missingStrings(have, wanted []string) []string {
+	var missing []string
+	for _, value := range wanted {
+		found := false
+		for _, existing := range append(have, missing...) {
+			found = found || existing == value
+		}
+		if !found {
+			missing = append(missing, value)
+		}
+	}
+	return missing
+}
+
+// toAnySlice returns values as a list of an unstructured object.
+func toAnySlice(values []string) []any {
+	result := make([]any, len(values))
+	for i := range values {
+		result[i] = values[i]
+	}
+	return result
+}
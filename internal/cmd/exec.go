@@ -8,49 +8,12 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"os"
-)
-
-// Executor calls commands
-type Executor func(
-	stdin io.Reader, stdout, stderr io.Writer, command ...string,
-) error
-
-// pgBackRestInfo defines a pgBackRest info command with relevant flags set
-func (exec Executor) pgBackRestInfo(output, repoNum string) (string, string, error) {
-	var stdout, stderr bytes.Buffer
-	var command string
-
-	command = "pgbackrest info --output=" + output
-	if repoNum != "" {
-		command += " --repo=" + repoNum
-	}
-	err := exec(nil, &stdout, &stderr, "bash", "-ceu", "--", command)
-
-	return stdout.String(), stderr.String(), err
-}
-
-// bashCommand defines a one-line bash command to exec in a container
-func (exec Executor) bashCommand(command string) (string, string, error) {
-	var stdout, stderr bytes.Buffer
-	err := exec(nil, &stdout, &stderr, "bash", "-ceu", "--", command)
-	return stdout.String(), stderr.String(), err
-}
 
-// pgBackRestCheck defines a pgBackRest check command
-// Force log-level-console=detail to override if set elsewhere
-func (exec Executor) pgBackRestCheck() (string, string, error) {
-	var stdout, stderr bytes.Buffer
-	command := "pgbackrest check --log-level-console=detail"
-	err := exec(nil, &stdout, &stderr, "bash", "-ceu", "--", command)
-
-	return stdout.String(), stderr.String(), err
-}
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+)
 
 // postgresqlListLogFiles returns the full path of numLogs log files.
-func (exec Executor) listPGLogFiles(numLogs int, hasInstrumentation bool) (string, string, error) {
-	var stdout, stderr bytes.Buffer
-
+func listPGLogFiles(exec podexec.Executor, numLogs int, hasInstrumentation bool) (string, string, error) {
 	location := "pgdata/pg[0-9][0-9]/log/*"
 	if hasInstrumentation {
 		location = "pgdata/logs/postgres/*.*"
@@ -64,107 +27,83 @@ func (exec Executor) listPGLogFiles(numLogs int, hasInstrumentation bool) (strin
 	// which only the collector container has permission to access.
 	command := fmt.Sprintf("ls -1dt %s | head -%d",
 		location, numLogs)
-	err := exec(nil, &stdout, &stderr, "bash", "-ceu", "--", command)
+	return podexec.Bash(exec, command)
+}
 
-	return stdout.String(), stderr.String(), err
+// grepPGLogs searches the numLogs newest Postgres log files for lines that
+// match the extended regular expression pattern, ignoring case. It prints the
+// number of matching lines followed by the last few of them. The pattern
+// cannot contain single quotes.
+func grepPGLogs(exec podexec.Executor, numLogs int, hasInstrumentation bool, pattern string) (string, string, error) {
+	location := "pgdata/pg[0-9][0-9]/log/*"
+	if hasInstrumentation {
+		location = "pgdata/logs/postgres/*.*"
+	}
+	// Exit status 1 means grep found nothing, which is not an error here.
+	command := fmt.Sprintf("files=$(ls -1dt %s 2>/dev/null | head -%d || true); "+
+		"matches=$({ [ -z \"$files\" ] || grep -hiE -- '%s' $files; } || true); "+
+		"printf '%%s\\n' \"$matches\" | grep -c . || true; printf '%%s\\n' \"$matches\" | tail -n 3",
+		location, numLogs, pattern)
+	return podexec.Bash(exec, command)
 }
 
 // listPGConfFiles returns the full path of Postgres conf files.
 // These are the *.conf stored on the Postgres instance
-func (exec Executor) listPGConfFiles() (string, string, error) {
-	var stdout, stderr bytes.Buffer
-
+func listPGConfFiles(exec podexec.Executor) (string, string, error) {
 	command := "ls -1dt pgdata/pg[0-9][0-9]/*.conf"
-	err := exec(nil, &stdout, &stderr, "bash", "-ceu", "--", command)
-
-	return stdout.String(), stderr.String(), err
+	return podexec.Bash(exec, command)
 }
 
 // listBackrestLogFiles returns the full path of pgBackRest log files.
 // These are the pgBackRest logs stored on the Postgres instance
-func (exec Executor) listBackrestLogFiles() (string, string, error) {
-	var stdout, stderr bytes.Buffer
-
+func listBackrestLogFiles(exec podexec.Executor) (string, string, error) {
 	// Note the "*.*" pattern to exclude the `receiver` directory,
 	// which only the collector container has permission to access.
 	command := "ls -1dt pgdata/pgbackrest/log/*.*"
-	err := exec(nil, &stdout, &stderr, "bash", "-ceu", "--", command)
-
-	return stdout.String(), stderr.String(), err
+	return podexec.Bash(exec, command)
 }
 
 // listPatroniLogFiles returns the full path of Patroni log file.
 // These are the Patroni logs stored on the Postgres instance.
-func (exec Executor) listPatroniLogFiles() (string, string, error) {
-	var stdout, stderr bytes.Buffer
-
+func listPatroniLogFiles(exec podexec.Executor) (string, string, error) {
 	// Note the "*.*" pattern to exclude the `receiver` directory,
 	// which only the collector container has permission to access.
 	command := "ls -1dt pgdata/patroni/log/*.*"
-	err := exec(nil, &stdout, &stderr, "bash", "-ceu", "--", command)
-
-	return stdout.String(), stderr.String(), err
+	return podexec.Bash(exec, command)
 }
 
 // listBackrestRepoHostLogFiles returns the full path of pgBackRest log files.
 // These are the pgBackRest logs stored on the repo host
-func (exec Executor) listBackrestRepoHostLogFiles() (string, string, error) {
-	var stdout, stderr bytes.Buffer
-
+func listBackrestRepoHostLogFiles(exec podexec.Executor) (string, string, error) {
 	// Note the "*.*" pattern to exclude the `receiver` directory,
 	// which only the collector container has permission to access.
 	command := "ls -1dt pgbackrest/*/log/*.*"
-	err := exec(nil, &stdout, &stderr, "bash", "-ceu", "--", command)
-
-	return stdout.String(), stderr.String(), err
+	return podexec.Bash(exec, command)
 }
 
 // catFile takes the full path of a file and returns the contents
 // of that file
-func (exec Executor) catFile(filePath string) (string, string, error) {
-	var stdout, stderr bytes.Buffer
-
+func catFile(exec podexec.Executor, filePath string) (string, string, error) {
 	command := fmt.Sprintf("cat %s", filePath)
-	err := exec(nil, &stdout, &stderr, "bash", "-ceu", "--", command)
-
-	return stdout.String(), stderr.String(), err
+	return podexec.Bash(exec, command)
 }
 
 // copyFile takes the full path of a file and a local destination to save the
-// file on disk
-func (exec Executor) copyFile(source string, destination *os.File) (string, error) {
+// file to, on disk or in memory
+func copyFile(exec podexec.Executor, source string, destination io.Writer) (string, error) {
 	var stderr bytes.Buffer
 	command := fmt.Sprintf("cat %s", source)
-	err := exec(nil, destination, &stderr, "bash", "-ceu", "--", command)
+	err := exec.Exec(nil, destination, &stderr, "bash", "-ceu", "--", command)
 	return stderr.String(), err
 }
 
-// patronictl takes a patronictl subcommand and returns the output of that command
-func (exec Executor) patronictl(cmd, output string) (string, string, error) {
-	var stdout, stderr bytes.Buffer
-
-	command := "patronictl " + cmd
-	if output != "" {
-		command += " --format " + output
-	}
-	err := exec(nil, &stdout, &stderr, "bash", "-ceu", "--", command)
-
-	return stdout.String(), stderr.String(), err
-}
-
 // processes returns the output of a ps command
-func (exec Executor) processes() (string, string, error) {
-	var stdout, stderr bytes.Buffer
-
+func processes(exec podexec.Executor) (string, string, error) {
 	command := "ps aux --width 500"
-	err := exec(nil, &stdout, &stderr, "bash", "-ceu", "--", command)
-
-	return stdout.String(), stderr.String(), err
+	return podexec.Bash(exec, command)
 }
 
 // systemTime returns the output of the date command
-func (exec Executor) systemTime() (string, string, error) {
-	var stdout, stderr bytes.Buffer
-	err := exec(nil, &stdout, &stderr, "date")
-	return stdout.String(), stderr.String(), err
+func systemTime(exec podexec.Executor) (string, string, error) {
+	return podexec.Output(exec, nil, "date")
 }
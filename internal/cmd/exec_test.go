@@ -10,55 +10,9 @@ import (
 	"testing"
 
 	"gotest.tools/v3/assert"
-)
-
-func TestPGBackRestInfo(t *testing.T) {
-
-	t.Run("default", func(t *testing.T) {
-		expected := errors.New("pass-through")
-		exec := func(
-			stdin io.Reader, stdout, stderr io.Writer, command ...string,
-		) error {
-			assert.DeepEqual(t, command, []string{"bash", "-ceu", "--", "pgbackrest info --output=text"})
-			assert.Assert(t, stdout != nil, "should capture stdout")
-			assert.Assert(t, stderr != nil, "should capture stderr")
-			return expected
-		}
-		_, _, err := Executor(exec).pgBackRestInfo("text", "")
-		assert.ErrorContains(t, err, "pass-through")
-
-	})
-
-	t.Run("text, repo 1", func(t *testing.T) {
-		expected := errors.New("pass-through")
-		exec := func(
-			stdin io.Reader, stdout, stderr io.Writer, command ...string,
-		) error {
-			assert.DeepEqual(t, command, []string{"bash", "-ceu", "--", "pgbackrest info --output=text --repo=1"})
-			assert.Assert(t, stdout != nil, "should capture stdout")
-			assert.Assert(t, stderr != nil, "should capture stderr")
-			return expected
-		}
-		_, _, err := Executor(exec).pgBackRestInfo("text", "1")
-		assert.ErrorContains(t, err, "pass-through")
-
-	})
-
-	t.Run("json, repo 2", func(t *testing.T) {
-		expected := errors.New("pass-through")
-		exec := func(
-			stdin io.Reader, stdout, stderr io.Writer, command ...string,
-		) error {
-			assert.DeepEqual(t, command, []string{"bash", "-ceu", "--", "pgbackrest info --output=json --repo=2"})
-			assert.Assert(t, stdout != nil, "should capture stdout")
-			assert.Assert(t, stderr != nil, "should capture stderr")
-			return expected
-		}
-		_, _, err := Executor(exec).pgBackRestInfo("json", "2")
-		assert.ErrorContains(t, err, "pass-through")
 
-	})
-}
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+)
 
 func TestListPGLogFiles(t *testing.T) {
 
@@ -72,7 +26,7 @@ func TestListPGLogFiles(t *testing.T) {
 			assert.Assert(t, stderr != nil, "should capture stderr")
 			return expected
 		}
-		_, _, err := Executor(exec).listPGLogFiles(1, false)
+		_, _, err := listPGLogFiles(podexec.Func(exec), 1, false)
 		assert.ErrorContains(t, err, "pass-through")
 
 	})
@@ -87,7 +41,7 @@ func TestListPGLogFiles(t *testing.T) {
 			assert.Assert(t, stderr != nil, "should capture stderr")
 			return expected
 		}
-		_, _, err := Executor(exec).listPGLogFiles(1, true)
+		_, _, err := listPGLogFiles(podexec.Func(exec), 1, true)
 		assert.ErrorContains(t, err, "pass-through")
 
 	})
@@ -106,7 +60,7 @@ func TestListPatroniLogFiles(t *testing.T) {
 			assert.Assert(t, stderr != nil, "should capture stderr")
 			return expected
 		}
-		_, _, err := Executor(exec).listPatroniLogFiles()
+		_, _, err := listPatroniLogFiles(podexec.Func(exec))
 		assert.ErrorContains(t, err, "pass-through")
 
 	})
@@ -125,26 +79,7 @@ func TestCatFile(t *testing.T) {
 			assert.Assert(t, stderr != nil, "should capture stderr")
 			return expected
 		}
-		_, _, err := Executor(exec).catFile("/path/to/file")
-		assert.ErrorContains(t, err, "pass-through")
-
-	})
-
-}
-
-func TestPatronictl(t *testing.T) {
-
-	t.Run("default", func(t *testing.T) {
-		expected := errors.New("pass-through")
-		exec := func(
-			stdin io.Reader, stdout, stderr io.Writer, command ...string,
-		) error {
-			assert.DeepEqual(t, command, []string{"bash", "-ceu", "--", "patronictl sub-command"})
-			assert.Assert(t, stdout != nil, "should capture stdout")
-			assert.Assert(t, stderr != nil, "should capture stderr")
-			return expected
-		}
-		_, _, err := Executor(exec).patronictl("sub-command", "")
+		_, _, err := catFile(podexec.Func(exec), "/path/to/file")
 		assert.ErrorContains(t, err, "pass-through")
 
 	})
@@ -163,7 +98,7 @@ func TestProcesses(t *testing.T) {
 			assert.Assert(t, stderr != nil, "should capture stderr")
 			return expected
 		}
-		_, _, err := Executor(exec).processes()
+		_, _, err := processes(podexec.Func(exec))
 		assert.ErrorContains(t, err, "pass-through")
 
 	})
@@ -0,0 +1,169 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newExplainQueryCommand returns the explain-query subcommand of the PGO plugin.
+// It prints the plan of a query read from a file.
+func newExplainQueryCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "explain-query CLUSTER_NAME",
+		Short: "Show the plan of a query",
+		Long: `Show the plan that Postgres chooses for one query read from a file.
+
+With the "--analyze" flag the query also runs so the plan includes actual
+times and row counts. The query then runs in a transaction that is always
+rolled back, and it is cancelled after "--timeout". Use "--replica" to keep
+the load off the primary.
+
+Use "--output=json" for plan visualizer tools.
+
+### RBAC Requirements
+    Resources  Verbs
+    ---------  -----
+    pods       [list]
+    pods/exec  [create]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Show the plan of a query in the 'app' database of the 'hippo' postgrescluster
+pgo explain-query hippo --dbname=app --file=query.sql
+
+# Run the query on a replica and show actual times as JSON
+pgo explain-query hippo --dbname=app --file=query.sql --analyze --timeout=30s --replica --output=json
+
+### Example output
+Seq Scan on orders  (cost=0.00..35.50 rows=2550 width=4)
+  Filter: (status = 'open'::text)`)
+
+	var dbname, file string
+	var analyze, replica bool
+	var timeout time.Duration
+	var outputEnum = util.TextExplain
+	cmd.Flags().StringVar(&dbname, "dbname", "", "database to run the query in")
+	cmd.Flags().StringVar(&file, "file", "", "path of a file containing one query")
+	cobra.CheckErr(cmd.MarkFlagRequired("file"))
+	cmd.Flags().BoolVar(&analyze, "analyze", false, "run the query and show actual times; changes are rolled back")
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "cancel the query after this long")
+	cmd.Flags().BoolVar(&replica, "replica", false, "run on a replica rather than the primary")
+	cmd.Flags().VarP(&outputEnum, "output", "o", "output format. types supported: text,json")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		// #nosec G304 -- We intentionally read the file supplied by the user.
+		query, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		sql, err := explainSQL(string(query), analyze, timeout, outputEnum.String())
+		if err != nil {
+			return err
+		}
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		selector := util.PrimaryInstanceLabels(args[0])
+		if replica {
+			selector = util.ReplicaInstanceLabels(args[0])
+		}
+		pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: selector,
+		})
+		if err != nil {
+			return err
+		}
+		var pod *corev1.Pod
+		for i := range pods.Items {
+			if podIsReady(&pods.Items[i]) {
+				pod = &pods.Items[i]
+				break
+			}
+		}
+		if pod == nil {
+			return errors.New("no ready instance Pod found")
+		}
+
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+		exec := podexec.Container(podExec, namespace, pod.GetName(), util.ContainerDatabase)
+
+		stdout, stderr, err := podexec.PSQL(exec, dbname, sql)
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr))
+		}
+
+		if outputEnum == util.JSONExplain {
+			var indented bytes.Buffer
+			if err := json.Indent(&indented, []byte(stdout), "", "  "); err != nil {
+				return err
+			}
+			cmd.Println(indented.String())
+			return nil
+		}
+
+		cmd.Print(stdout)
+		return nil
+	}
+
+	return cmd
+}
+
+// explainSQL returns the statements that explain query in format. When
+// analyze is true the query runs in a transaction that is rolled back.
+func explainSQL(query string, analyze bool, timeout time.Duration, format string) (string, error) {
+	query = strings.TrimSpace(query)
+	query = strings.TrimSpace(strings.TrimRight(query, "; \t\n"))
+
+	if query == "" {
+		return "", errors.New("the file does not contain a query")
+	}
+	if strings.Contains(query, ";") {
+		return "", errors.New("the file must contain only one query")
+	}
+
+	options := []string{"FORMAT " + strings.ToUpper(format)}
+	if analyze {
+		options = append([]string{"ANALYZE", "BUFFERS"}, options...)
+	}
+
+	return fmt.Sprintf("BEGIN;\nSET LOCAL statement_timeout = '%dms';\nEXPLAIN (%s) %s;\nROLLBACK;",
+		timeout.Milliseconds(), strings.Join(options, ", "), query), nil
+}
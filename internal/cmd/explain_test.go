@@ -0,0 +1,42 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestExplainSQL(t *testing.T) {
+	t.Run("Explain", func(t *testing.T) {
+		sql, err := explainSQL("SELECT 1;\n", false, 30*time.Second, "text")
+		assert.NilError(t, err)
+		assert.Equal(t, sql, "BEGIN;\n"+
+			"SET LOCAL statement_timeout = '30000ms';\n"+
+			"EXPLAIN (FORMAT TEXT) SELECT 1;\n"+
+			"ROLLBACK;")
+	})
+
+	t.Run("Analyze", func(t *testing.T) {
+		sql, err := explainSQL("  DELETE FROM orders ;; ", true, time.Second, "json")
+		assert.NilError(t, err)
+		assert.Equal(t, sql, "BEGIN;\n"+
+			"SET LOCAL statement_timeout = '1000ms';\n"+
+			"EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) DELETE FROM orders;\n"+
+			"ROLLBACK;")
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		_, err := explainSQL(" ;\n", false, time.Second, "text")
+		assert.ErrorContains(t, err, "does not contain a query")
+	})
+
+	t.Run("Multiple", func(t *testing.T) {
+		_, err := explainSQL("SELECT 1; DROP TABLE orders;", true, time.Second, "text")
+		assert.ErrorContains(t, err, "only one query")
+	})
+}
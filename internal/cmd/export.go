@@ -9,6 +9,9 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -30,6 +33,7 @@ import (
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
@@ -45,6 +49,8 @@ import (
 
 	"github.com/crunchydata/postgres-operator-client/internal"
 	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/flags"
 	"github.com/crunchydata/postgres-operator-client/internal/util"
 )
 
@@ -53,7 +59,7 @@ const (
 	// - https://mathworld.wolfram.com/Mebibyte.html
 	mebibyte float64 = (1 << 20)
 
-	// formatting for CLI log and stdout
+	// formatting for CLI log and stderr
 	preBox  = "┌────────────────────────────────────────────────────────────────"
 	postBox = "└────────────────────────────────────────────────────────────────"
 
@@ -202,6 +208,7 @@ PostgresCluster.
     statefulsets.apps                                   [list]
 
     Note: This RBAC needs to be cluster-scoped to retrieve information on nodes and postgresclusters.
+    Note: The pods/exec permission is not needed with "--collect=k8s-only".
 
 ### Event Capture
     Support export captures all Events in the PostgresCluster's Namespace.
@@ -209,21 +216,28 @@ PostgresCluster.
     API server. Default is 1 hour.
     - https://kubernetes.io/docs/reference/command-line-tools-reference/kube-apiserver/
 
+### Profiles
+    The '--profile' flag chooses what to collect with one word. Flags given
+    on the command line override the profile.
+    - minimal: only what the Kubernetes API provides, with an hour of Pod logs
+    - standard: the defaults
+    - performance: a day of Pod logs and SQL statistics about activity, I/O, and queries
+    - deep: more Postgres logs, all SQL statistics, and whole configuration directories
+
 ### Usage`,
 	}
 
 	// Set output to log and write to buffer for writing to file
 	var cliOutput bytes.Buffer
 	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
-		// error messages should go to both stderr and the CLI log file
-		errMW := io.MultiWriter(os.Stderr, &cliOutput)
-		cmd.SetErr(errMW)
 		// Messages printed with cmd.Print (those from the 'writeDebug' function)
 		// will go only to the CLI log file. To print to the CLI log file and
-		// stdout, the writeInfo function should be used.
+		// stderr, the writeInfo function should be used. Progress on stderr is
+		// silenced by --quiet.
 		cmd.SetOut(&cliOutput)
 
-		return nil
+		profile, _ := cmd.Flags().GetString("profile")
+		return applyExportProfile(cmd.Flags(), profile)
 	}
 
 	var outputDir string
@@ -239,6 +253,47 @@ PostgresCluster.
 	var operatorNamespace string
 	cmd.Flags().StringVarP(&operatorNamespace, "operator-namespace", "", "", "Operator namespace override")
 
+	var collectEnum = util.CollectAll
+	cmd.Flags().Var(&collectEnum, "collect",
+		"What to collect. types supported: all,k8s-only")
+
+	var retries int
+	cmd.Flags().IntVar(&retries, "retries", 3,
+		"Number of times to retry a command in a Pod after a transient error")
+
+	var splitSize resource.Quantity
+	cmd.Flags().Var(&quantityFlag{&splitSize}, "split-size",
+		"Split the export tarball into parts of at most this size, e.g. 2G")
+
+	var delta bool
+	cmd.Flags().BoolVar(&delta, "delta", false,
+		"Collect only files that changed since the export of --baseline")
+	var baselinePath string
+	cmd.Flags().StringVar(&baselinePath, "baseline", "",
+		"Path to the manifest.json of an earlier export to compare with --delta")
+	cmd.MarkFlagsRequiredTogether("delta", "baseline")
+
+	var configDirs bool
+	cmd.Flags().BoolVar(&configDirs, "config-dirs", false,
+		"Capture whole configuration directories with tar in each Pod rather than one file at a time")
+
+	var profile string
+	cmd.Flags().StringVar(&profile, "profile", "standard",
+		"Named set of what to collect. profiles supported: minimal,standard,performance,deep")
+
+	var podLogSince flags.Time
+	cmd.Flags().Var(&podLogSince, "pod-log-since",
+		`Only collect Pod logs newer than this, e.g. "6h" or "2024-05-01 14:03"; empty collects all of them`)
+
+	var sqlStats []string
+	cmd.Flags().StringSliceVar(&sqlStats, "sql-stats", nil,
+		"SQL statistics to collect from each instance. types supported: "+
+			strings.Join(sqlStatisticSetNames(), ","))
+
+	var encryptFor string
+	cmd.Flags().StringVar(&encryptFor, "encrypt-for", "",
+		"Encrypt the export tarball to the age or OpenPGP public key in this file")
+
 	cmd.Args = cobra.ExactArgs(1)
 
 	cmd.Example = internal.FormatExample(`# Short Flags
@@ -256,6 +311,35 @@ kubectl pgo support export daisy --monitoring-namespace another-namespace --outp
 # This is used for getting the logs and specs for the operator pod(s).
 kubectl pgo support export daisy --operator-namespace another-namespace --output .
 
+# Collect only what the Kubernetes API provides, without running commands in Pods.
+# This works when Pods are down or when exec is forbidden.
+kubectl pgo support export daisy --output . --collect k8s-only
+
+# Retry commands in Pods more times when the connection to nodes is unreliable
+kubectl pgo support export daisy --output . --retries 5
+
+# Split the export tarball into parts for upload systems that limit file size.
+# Reassemble the parts with 'cat' before extracting.
+kubectl pgo support export daisy --output . --split-size 2G
+
+# Collect only what changed since an earlier export. Every export writes a
+# manifest next to its tarball for this.
+kubectl pgo support export daisy --output . --delta \
+  --baseline ./crunchy_k8s_support_export_2022-08-08-115726-0400.manifest.json
+
+# Capture /pgconf, /etc/pgbackrest, and /etc/patroni of each instance with tar,
+# keeping permissions and symlinks. Private keys are left out and credentials
+# in configuration files are redacted.
+kubectl pgo support export daisy --output . --config-dirs
+
+# Collect what is needed to troubleshoot slow queries with one flag
+kubectl pgo support export daisy --output . --profile performance
+
+# Encrypt the export tarball before it is written, with age or gpg installed
+# locally. Logs are held in memory rather than written to --output. The manifest
+# next to the tarball has only the names, sizes, and checksums of files.
+kubectl pgo support export daisy --output . --encrypt-for ./support.age.pub
+
 ### Example output
 ┌────────────────────────────────────────────────────────────────
 | PGO CLI Support Export Tool
@@ -322,6 +406,26 @@ Collecting PGO CLI logs...
 		writeDebug(cmd, fmt.Sprintf("Flag - Num Logs: %d\n", numLogs))
 		writeDebug(cmd, fmt.Sprintf("Flag - Monitoring Namespace: %s\n", monitoringNamespace))
 		writeDebug(cmd, fmt.Sprintf("Flag - Operator Namespace: %s\n", operatorNamespace))
+		writeDebug(cmd, fmt.Sprintf("Flag - Collect: %s\n", collectEnum.String()))
+		writeDebug(cmd, fmt.Sprintf("Flag - Retries: %d\n", retries))
+		writeDebug(cmd, fmt.Sprintf("Flag - Baseline: %s\n", baselinePath))
+		writeDebug(cmd, fmt.Sprintf("Flag - Config Dirs: %t\n", configDirs))
+		writeDebug(cmd, fmt.Sprintf("Flag - Profile: %s\n", profile))
+		writeDebug(cmd, fmt.Sprintf("Flag - Pod Log Since: %s\n", podLogSince.String()))
+		writeDebug(cmd, fmt.Sprintf("Flag - SQL Stats: %s\n", strings.Join(sqlStats, ",")))
+		writeDebug(cmd, fmt.Sprintf("Flag - Encrypt For: %s\n", encryptFor))
+
+		statisticSets, err := selectSQLStatisticSets(sqlStats)
+		if err != nil {
+			return err
+		}
+
+		var recipient exportRecipient
+		if encryptFor != "" {
+			if recipient, err = readExportRecipient(encryptFor); err != nil {
+				return err
+			}
+		}
 
 		namespace, err := config.Namespace()
 		if err != nil {
@@ -371,20 +475,57 @@ Collecting PGO CLI logs...
 			return fmt.Errorf("could not find cluster %s in namespace %s: %w", clusterName, namespace, err)
 		}
 
+		// A delta export needs a baseline of the same cluster.
+		var baseline *exportManifest
+		if delta {
+			if baseline, err = readExportManifest(baselinePath, clusterName, namespace); err != nil {
+				return err
+			}
+		}
+
 		// Name file with year-month-day-HrMinSecTimezone suffix
 		// Example: crunchy_k8s_support_export_2022-08-08-115726-0400.tar.gz
 		outputFile := "crunchy_k8s_support_export_" + time.Now().Format("2006-01-02-150405-0700") + ".tar.gz"
-		// #nosec G304 -- We intentionally write to the directory supplied by the user.
-		tarFile, err := os.Create(outputDir + "/" + outputFile)
-		if err != nil {
-			return err
+		if delta {
+			outputFile = "crunchy_k8s_support_export_delta_" + time.Now().Format("2006-01-02-150405-0700") + ".tar.gz"
+		}
+		// Large archives can be written in parts. Concatenating the parts
+		// reproduces the whole archive.
+		// An encrypted archive is encrypted before it is written or split.
+		archivePath := outputDir + "/" + outputFile
+		if encryptFor != "" {
+			archivePath += recipient.Suffix()
+		}
+		var tarFile io.WriteCloser
+		var split *splitFileWriter
+		if splitSize.Value() > 0 {
+			split = newSplitFileWriter(archivePath, splitSize.Value())
+			tarFile = split
+		} else {
+			// #nosec G304 -- We intentionally write to the directory supplied by the user.
+			tarFile, err = os.Create(archivePath)
+			if err != nil {
+				return err
+			}
+		}
+		if encryptFor != "" {
+			encrypted, err := newEncryptWriter(tarFile, recipient.Command()...)
+			if err != nil {
+				_ = tarFile.Close()
+				_ = os.Remove(archivePath)
+				return err
+			}
+			tarFile = encrypted
 		}
 
 		gw, err := gzip.NewWriterLevel(tarFile, gzip.BestCompression)
 		if err != nil {
 			return err
 		}
-		tw := tar.NewWriter(gw)
+		// Every file written to the archive is recorded in its manifest.
+		manifest := newExportManifest(clusterName, namespace, baseline, time.Now())
+		tw := &exportArchive{Writer: tar.NewWriter(gw), manifest: manifest, encrypted: encryptFor != ""}
+
 		defer func() {
 			// ignore any errors from Close functions, the writers will be
 			// closed when the program exits
@@ -475,32 +616,41 @@ Collecting PGO CLI logs...
 			writeInfo(cmd, fmt.Sprintf("Error gathering Events: %s", err))
 		}
 
-		// Logs
-		// All Postgres Logs on the Postgres Instances (primary and replicas)
-		if numLogs > 0 {
-			err = gatherPostgresLogsAndConfigs(ctx, clientset, restConfig,
-				namespace, clusterName, outputDir, outputFile, numLogs, tw, cmd, getCluster)
-			if err != nil {
-				writeInfo(cmd, fmt.Sprintf("Error gathering Postgres Logs and Config: %s", err))
-			}
+		// Collectors that exec into Pods. These fail when Pods are down or when
+		// exec is forbidden, so they can be skipped.
+		execCollectors := collectEnum != util.CollectKubernetesOnly
+		if !execCollectors {
+			writeInfo(cmd, "Skipping logs and information that require exec into Pods...")
 		}
 
-		// All pgBackRest Logs on the Postgres Instances
-		err = gatherDbBackrestLogs(ctx, clientset, restConfig, namespace, clusterName, outputDir, outputFile, tw, cmd)
-		if err != nil {
-			writeInfo(cmd, fmt.Sprintf("Error gathering pgBackRest DB Hosts Logs: %s", err))
-		}
+		if execCollectors {
+			// Logs
+			// All Postgres Logs on the Postgres Instances (primary and replicas)
+			if numLogs > 0 {
+				err = gatherPostgresLogsAndConfigs(ctx, clientset, restConfig,
+					namespace, clusterName, outputDir, outputFile, numLogs, tw, cmd, getCluster)
+				if err != nil {
+					writeInfo(cmd, fmt.Sprintf("Error gathering Postgres Logs and Config: %s", err))
+				}
+			}
 
-		// Patroni Logs that are stored on the Postgres Instances
-		err = gatherPatroniLogs(ctx, clientset, restConfig, namespace, clusterName, tw, cmd)
-		if err != nil {
-			writeInfo(cmd, fmt.Sprintf("Error gathering Patroni Logs from Instance Pods: %s", err))
-		}
+			// All pgBackRest Logs on the Postgres Instances
+			err = gatherDbBackrestLogs(ctx, clientset, restConfig, namespace, clusterName, outputDir, outputFile, tw, cmd)
+			if err != nil {
+				writeInfo(cmd, fmt.Sprintf("Error gathering pgBackRest DB Hosts Logs: %s", err))
+			}
 
-		// All pgBackRest Logs on the Repo Host
-		err = gatherRepoHostLogs(ctx, clientset, restConfig, namespace, clusterName, outputDir, outputFile, tw, cmd)
-		if err != nil {
-			writeInfo(cmd, fmt.Sprintf("Error gathering pgBackRest Repo Host Logs: %s", err))
+			// Patroni Logs that are stored on the Postgres Instances
+			err = gatherPatroniLogs(ctx, clientset, restConfig, namespace, clusterName, tw, cmd)
+			if err != nil {
+				writeInfo(cmd, fmt.Sprintf("Error gathering Patroni Logs from Instance Pods: %s", err))
+			}
+
+			// All pgBackRest Logs on the Repo Host
+			err = gatherRepoHostLogs(ctx, clientset, restConfig, namespace, clusterName, outputDir, outputFile, tw, cmd)
+			if err != nil {
+				writeInfo(cmd, fmt.Sprintf("Error gathering pgBackRest Repo Host Logs: %s", err))
+			}
 		}
 
 		// get PostgresCluster Pod logs
@@ -547,28 +697,38 @@ Collecting PGO CLI logs...
 			writeInfo(cmd, fmt.Sprintf("Error gathering Operator Pod logs: %s", err))
 		}
 
-		// Exec to get Patroni Information
-		err = gatherPatroniInfo(ctx, clientset, restConfig, namespace, clusterName, tw, cmd)
-		if err != nil {
-			writeInfo(cmd, fmt.Sprintf("Error gathering Patroni Info: %s", err))
-		}
+		if execCollectors {
+			// Exec to get Patroni Information
+			err = gatherPatroniInfo(ctx, clientset, restConfig, namespace, clusterName, tw, cmd)
+			if err != nil {
+				writeInfo(cmd, fmt.Sprintf("Error gathering Patroni Info: %s", err))
+			}
 
-		// Exec to get pgBackRest Information
-		err = gatherPgBackRestInfo(ctx, clientset, restConfig, namespace, clusterName, tw, cmd)
-		if err != nil {
-			writeInfo(cmd, fmt.Sprintf("Error gathering pgBackRest Info: %s", err))
-		}
+			// Exec to get pgBackRest Information
+			err = gatherPgBackRestInfo(ctx, clientset, restConfig, namespace, clusterName, tw, cmd)
+			if err != nil {
+				writeInfo(cmd, fmt.Sprintf("Error gathering pgBackRest Info: %s", err))
+			}
 
-		// Exec to get Container processes
-		err = gatherProcessInfo(ctx, clientset, restConfig, namespace, clusterName, tw, cmd)
-		if err != nil {
-			writeInfo(cmd, fmt.Sprintf("Error gathering container processes: %s", err))
-		}
+			// Exec to get Container processes
+			err = gatherProcessInfo(ctx, clientset, restConfig, namespace, clusterName, tw, cmd)
+			if err != nil {
+				writeInfo(cmd, fmt.Sprintf("Error gathering container processes: %s", err))
+			}
 
-		// Exec to get Container system time
-		err = gatherSystemTime(ctx, clientset, restConfig, namespace, clusterName, tw, cmd)
-		if err != nil {
-			writeInfo(cmd, fmt.Sprintf("Error gathering container system time: %s", err))
+			// Exec to get Container system time
+			err = gatherSystemTime(ctx, clientset, restConfig, namespace, clusterName, tw, cmd)
+			if err != nil {
+				writeInfo(cmd, fmt.Sprintf("Error gathering container system time: %s", err))
+			}
+
+			// Exec to get SQL statistics
+			if len(statisticSets) > 0 {
+				err = gatherSQLStatistics(ctx, clientset, restConfig, namespace, clusterName, statisticSets, tw, cmd)
+				if err != nil {
+					writeInfo(cmd, fmt.Sprintf("Error gathering SQL statistics: %s", err))
+				}
+			}
 		}
 
 		// Get kubectl plugins
@@ -655,9 +815,44 @@ Collecting PGO CLI logs...
 			return logErr
 		}
 
+		// Write the manifest into the archive and next to it, where the next
+		// delta export can find it.
+		tw.manifest = nil
+		manifest.finish()
+		manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeTar(tw, manifestJSON, clusterName+"/manifest.json", cmd); err != nil {
+			return err
+		}
+		if err := os.WriteFile(exportManifestPath(outputDir, outputFile), manifestJSON, 0o600); err != nil {
+			return err
+		}
+		if summary := manifest.Summary(); summary != "" {
+			writeInfo(cmd, summary)
+		}
+
+		// Close the archive so its size is final.
+		if err := tw.Close(); err != nil {
+			return err
+		}
+		if err := gw.Close(); err != nil {
+			return err
+		}
+		if err := tarFile.Close(); err != nil {
+			return err
+		}
+		tw, gw, tarFile = nil, nil, nil
+
 		// Print final message
-		info, err := os.Stat(outputDir + "/" + outputFile)
-		fmt.Print(exportSizeReport(float64(info.Size())))
+		parts := []string{archivePath}
+		if split != nil {
+			parts = split.Parts()
+			cmd.PrintErr(splitArchiveMessage(outputFile, parts))
+		}
+		size, err := archiveSize(parts)
+		cmd.PrintErr(exportSizeReport(float64(size)))
 
 		return err
 	}
@@ -669,7 +864,7 @@ func gatherPgadminResources(config *internal.Config,
 	clientset *kubernetes.Clientset,
 	ctx context.Context,
 	namespace string,
-	tw *tar.Writer, cmd *cobra.Command) error {
+	tw *exportArchive, cmd *cobra.Command) error {
 
 	_, pgadminClient, err := v1beta1.NewPgadminClient(config)
 
@@ -733,7 +928,7 @@ func gatherPgadminResources(config *internal.Config,
 	return nil
 }
 
-func gatherPluginList(clusterName string, tw *tar.Writer, cmd *cobra.Command) error {
+func gatherPluginList(clusterName string, tw *exportArchive, cmd *cobra.Command) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel() // Ensure the context is canceled to avoid leaks
 
@@ -752,7 +947,7 @@ func gatherPluginList(clusterName string, tw *tar.Writer, cmd *cobra.Command) er
 	return nil
 }
 
-func gatherPGUpgradeSpec(clusterName, namespace, pgUpgrade string, tw *tar.Writer, cmd *cobra.Command) error {
+func gatherPGUpgradeSpec(clusterName, namespace, pgUpgrade string, tw *exportArchive, cmd *cobra.Command) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel() // Ensure the context is canceled to avoid leaks
 
@@ -775,7 +970,7 @@ There was an error running 'kubectl get pgupgrade'. Verify permissions and that
 	return nil
 }
 
-func runKubectlCommand(tw *tar.Writer, cmd *cobra.Command, path string, cmdArgs ...string) error {
+func runKubectlCommand(tw *exportArchive, cmd *cobra.Command, path string, cmdArgs ...string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel() // Ensure the context is canceled to avoid leaks
 
@@ -817,7 +1012,7 @@ func exportSizeReport(size float64) string {
 // gatherPGOCLIVersion collects the PGO CLI version
 func gatherPGOCLIVersion(_ context.Context,
 	clusterName string,
-	tw *tar.Writer,
+	tw *exportArchive,
 	cmd *cobra.Command,
 ) error {
 	writeInfo(cmd, "Collecting PGO CLI version...")
@@ -829,7 +1024,7 @@ func gatherPGOCLIVersion(_ context.Context,
 	return nil
 }
 
-func gatherPostgresClusterNames(clusterName string, ctx context.Context, cmd *cobra.Command, tw *tar.Writer, client dynamic.NamespaceableResourceInterface) error {
+func gatherPostgresClusterNames(clusterName string, ctx context.Context, cmd *cobra.Command, tw *exportArchive, client dynamic.NamespaceableResourceInterface) error {
 	result, err := client.List(ctx, metav1.ListOptions{})
 
 	if err != nil {
@@ -859,7 +1054,7 @@ func gatherPostgresClusterNames(clusterName string, ctx context.Context, cmd *co
 func gatherKubeContext(_ context.Context,
 	config *internal.Config,
 	clusterName string,
-	tw *tar.Writer,
+	tw *exportArchive,
 	cmd *cobra.Command,
 ) error {
 	writeInfo(cmd, "Collecting current Kubernetes context...")
@@ -880,7 +1075,7 @@ func gatherKubeContext(_ context.Context,
 func gatherKubeServerVersion(_ context.Context,
 	client *discovery.DiscoveryClient,
 	clusterName string,
-	tw *tar.Writer,
+	tw *exportArchive,
 	cmd *cobra.Command,
 ) error {
 	writeInfo(cmd, "Collecting Kubernetes version...")
@@ -901,7 +1096,7 @@ func gatherKubeServerVersion(_ context.Context,
 func gatherNodes(ctx context.Context,
 	clientset *kubernetes.Clientset,
 	clusterName string,
-	tw *tar.Writer,
+	tw *exportArchive,
 	cmd *cobra.Command,
 ) error {
 	writeInfo(cmd, "Collecting nodes...")
@@ -997,7 +1192,7 @@ func gatherCurrentNamespace(ctx context.Context,
 	clientset *kubernetes.Clientset,
 	namespace string,
 	clusterName string,
-	tw *tar.Writer,
+	tw *exportArchive,
 	cmd *cobra.Command,
 ) error {
 	writeInfo(cmd, "Collecting namespace...")
@@ -1024,7 +1219,7 @@ func gatherCurrentNamespace(ctx context.Context,
 
 func gatherClusterSpec(postgresCluster *unstructured.Unstructured,
 	clusterName string,
-	tw *tar.Writer,
+	tw *exportArchive,
 	cmd *cobra.Command,
 ) error {
 	writeInfo(cmd, "Collecting PostgresCluster...")
@@ -1050,7 +1245,7 @@ func gatherNamespacedAPIResources(ctx context.Context,
 	clusterName string,
 	namespacedResources []schema.GroupVersionResource,
 	listOpts metav1.ListOptions,
-	tw *tar.Writer,
+	tw *exportArchive,
 	cmd *cobra.Command,
 ) error {
 	for _, gvr := range namespacedResources {
@@ -1118,7 +1313,7 @@ func gatherNamespacedAPIResources(ctx context.Context,
 func gatherCrds(ctx context.Context,
 	clientset *apiextensionsclientset.Clientset,
 	clusterName string,
-	tw *tar.Writer,
+	tw *exportArchive,
 	cmd *cobra.Command,
 ) error {
 	writeInfo(cmd, "Collecting CRDs...")
@@ -1187,7 +1382,7 @@ func gatherEvents(ctx context.Context,
 	clientset *kubernetes.Clientset,
 	namespace string,
 	clusterName string,
-	tw *tar.Writer,
+	tw *exportArchive,
 	cmd *cobra.Command,
 ) error {
 	writeInfo(cmd, "Collecting events...")
@@ -1261,7 +1456,7 @@ func gatherPostgresLogsAndConfigs(ctx context.Context,
 	outputDir string,
 	outputFile string,
 	numLogs int,
-	tw *tar.Writer,
+	tw *exportArchive,
 	cmd *cobra.Command,
 	cluster *unstructured.Unstructured,
 ) error {
@@ -1286,7 +1481,7 @@ func gatherPostgresLogsAndConfigs(ctx context.Context,
 
 	writeDebug(cmd, fmt.Sprintf("Found %d Pods\n", len(dbPods.Items)))
 
-	podExec, err := util.NewPodExecutor(config)
+	podExec, err := newExportPodExecutor(config, cmd)
 	if err != nil {
 		return err
 	}
@@ -1294,18 +1489,14 @@ func gatherPostgresLogsAndConfigs(ctx context.Context,
 	for _, pod := range dbPods.Items {
 		writeDebug(cmd, fmt.Sprintf("Pod Name is %s\n", pod.Name))
 
-		exec := func(stdin io.Reader, stdout, stderr io.Writer, command ...string,
-		) error {
-			return podExec(namespace, pod.Name, util.ContainerDatabase,
-				stdin, stdout, stderr, command...)
-		}
+		exec := podexec.Container(podExec, namespace, pod.Name, util.ContainerDatabase)
 
 		// Get Postgres Log Files
 		// Pass a boolean based on whether the cluster has instrumentation
 		// since that will determine where the log files are stored
 		_, hasInstrumentation, _ := unstructured.NestedMap(cluster.Object,
 			"spec", "instrumentation")
-		stdout, stderr, err := Executor(exec).listPGLogFiles(numLogs, hasInstrumentation)
+		stdout, stderr, err := listPGLogFiles(exec, numLogs, hasInstrumentation)
 
 		// Depending upon the list* function above:
 		// An error may happen when err is non-nil or stderr is non-empty.
@@ -1361,7 +1552,7 @@ func gatherPostgresLogsAndConfigs(ctx context.Context,
 			writeInfo(cmd, fmt.Sprintf("\tSize of %-85s %v", fileSpecSrc, convertBytes(fileSize)))
 
 			// Stream the file to disk and write the local file to the tar
-			err = streamFileFromPod(config, tw,
+			err = streamFileFromPod(config, tw, cmd,
 				localDirectory, clusterName, namespace, pod.Name, util.ContainerDatabase, logFile, fileSize)
 
 			if err != nil {
@@ -1388,59 +1579,85 @@ func gatherPostgresLogsAndConfigs(ctx context.Context,
 			}
 		}
 
-		// Get Postgres Conf Files
-		stdout, stderr, err = Executor(exec).listPGConfFiles()
-
-		// Depending upon the list* function above:
-		// An error may happen when err is non-nil or stderr is non-empty.
-		// In both cases, we want to print helpful information and continue to the
-		// next iteration.
-		if err != nil || stderr != "" {
-
+		// Capture the configuration directories in one stream when asked.
+		// Their entries keep the paths they have in the container.
+		var captured bool
+		if configDirs, _ := cmd.Flags().GetBool("config-dirs"); configDirs {
+			stderr, err := copyRemoteTar(exec, tw, clusterName+"/pods/"+pod.Name,
+				configDirectories, configExcludes, cmd)
 			if apierrors.IsForbidden(err) {
 				writeInfo(cmd, err.Error())
 				return nil
 			}
-
-			writeDebug(cmd, "Error getting PG Conf files\n")
-
 			if err != nil {
-				writeDebug(cmd, fmt.Sprintf("%s\n", err.Error()))
-			}
-			if stderr != "" {
-				writeDebug(cmd, stderr)
-			}
-
-			if strings.Contains(stderr, "No such file or directory") {
-				writeDebug(cmd, "Cannot find any PG Conf files. This is acceptable in some configurations.\n")
+				writeDebug(cmd, fmt.Sprintf("Error capturing configuration directories: %s\n", err.Error()))
+				if stderr != "" {
+					writeDebug(cmd, stderr)
+				}
+				writeInfo(cmd, fmt.Sprintf("\tError capturing configuration of %s; collecting files one at a time", pod.Name))
+			} else {
+				if stderr != "" {
+					writeDebug(cmd, stderr)
+				}
+				captured = true
 			}
-			continue
 		}
 
-		logFiles = strings.Split(strings.TrimSpace(stdout), "\n")
-		for _, logFile := range logFiles {
-			var buf bytes.Buffer
+		// Get Postgres Conf Files, unless tar captured them above
+		if !captured {
+			stdout, stderr, err = listPGConfFiles(exec)
+
+			// Depending upon the list* function above:
+			// An error may happen when err is non-nil or stderr is non-empty.
+			// In both cases, we want to print helpful information and continue to the
+			// next iteration.
+			if err != nil || stderr != "" {
 
-			stdout, stderr, err := Executor(exec).catFile(logFile)
-			if err != nil {
 				if apierrors.IsForbidden(err) {
 					writeInfo(cmd, err.Error())
-					// Continue and output errors for each log file
-					// Allow the user to see and address all issues at once
-					continue
+					return nil
 				}
-				return err
-			}
 
-			buf.Write([]byte(stdout))
-			if stderr != "" {
-				str := fmt.Sprintf("\nError returned: %s\n", stderr)
-				buf.Write([]byte(str))
+				writeDebug(cmd, "Error getting PG Conf files\n")
+
+				if err != nil {
+					writeDebug(cmd, fmt.Sprintf("%s\n", err.Error()))
+				}
+				if stderr != "" {
+					writeDebug(cmd, stderr)
+				}
+
+				if strings.Contains(stderr, "No such file or directory") {
+					writeDebug(cmd, "Cannot find any PG Conf files. This is acceptable in some configurations.\n")
+				}
+				continue
 			}
 
-			path := clusterName + fmt.Sprintf("/pods/%s/", pod.Name) + logFile
-			if err := writeTar(tw, buf.Bytes(), path, cmd); err != nil {
-				return err
+			logFiles = strings.Split(strings.TrimSpace(stdout), "\n")
+			for _, logFile := range logFiles {
+				var buf bytes.Buffer
+
+				stdout, stderr, err := catFile(exec, logFile)
+				if err != nil {
+					if apierrors.IsForbidden(err) {
+						writeInfo(cmd, err.Error())
+						// Continue and output errors for each log file
+						// Allow the user to see and address all issues at once
+						continue
+					}
+					return err
+				}
+
+				buf.Write([]byte(stdout))
+				if stderr != "" {
+					str := fmt.Sprintf("\nError returned: %s\n", stderr)
+					buf.Write([]byte(str))
+				}
+
+				path := clusterName + fmt.Sprintf("/pods/%s/", pod.Name) + logFile
+				if err := writeTar(tw, buf.Bytes(), path, cmd); err != nil {
+					return err
+				}
 			}
 		}
 
@@ -1462,7 +1679,7 @@ func gatherPostgresLogsAndConfigs(ctx context.Context,
 		var buf bytes.Buffer
 
 		for _, command := range commands {
-			stdout, stderr, err := Executor(exec).bashCommand(command.path)
+			stdout, stderr, err := podexec.Bash(exec, command.path)
 			if err != nil {
 				if apierrors.IsForbidden(err) {
 					writeInfo(cmd, err.Error())
@@ -1500,7 +1717,7 @@ func gatherDbBackrestLogs(ctx context.Context,
 	clusterName string,
 	outputDir string,
 	outputFile string,
-	tw *tar.Writer,
+	tw *exportArchive,
 	cmd *cobra.Command,
 ) error {
 	writeInfo(cmd, "Collecting pgBackRest logs...")
@@ -1524,7 +1741,7 @@ func gatherDbBackrestLogs(ctx context.Context,
 
 	writeDebug(cmd, fmt.Sprintf("Found %d Pods\n", len(dbPods.Items)))
 
-	podExec, err := util.NewPodExecutor(config)
+	podExec, err := newExportPodExecutor(config, cmd)
 	if err != nil {
 		return err
 	}
@@ -1532,14 +1749,10 @@ func gatherDbBackrestLogs(ctx context.Context,
 	for _, pod := range dbPods.Items {
 		writeDebug(cmd, fmt.Sprintf("Pod Name is %s\n", pod.Name))
 
-		exec := func(stdin io.Reader, stdout, stderr io.Writer, command ...string,
-		) error {
-			return podExec(namespace, pod.Name, util.ContainerDatabase,
-				stdin, stdout, stderr, command...)
-		}
+		exec := podexec.Container(podExec, namespace, pod.Name, util.ContainerDatabase)
 
 		// Get pgBackRest Log Files
-		stdout, stderr, err := Executor(exec).listBackrestLogFiles()
+		stdout, stderr, err := listBackrestLogFiles(exec)
 
 		// Depending upon the list* function above:
 		// An error may happen when err is non-nil or stderr is non-empty.
@@ -1596,7 +1809,7 @@ func gatherDbBackrestLogs(ctx context.Context,
 			writeInfo(cmd, fmt.Sprintf("\tSize of %-85s %v", fileSpecSrc, convertBytes(fileSize)))
 
 			// Stream the file to disk and write the local file to the tar
-			err = streamFileFromPod(config, tw,
+			err = streamFileFromPod(config, tw, cmd,
 				localDirectory, clusterName, namespace, pod.Name, util.ContainerDatabase, logFile, fileSize)
 
 			if err != nil {
@@ -1635,7 +1848,7 @@ func gatherPatroniLogs(ctx context.Context,
 	config *rest.Config,
 	namespace string,
 	clusterName string,
-	tw *tar.Writer,
+	tw *exportArchive,
 	cmd *cobra.Command,
 ) error {
 	writeInfo(cmd, "Collecting Patroni logs...")
@@ -1659,7 +1872,7 @@ func gatherPatroniLogs(ctx context.Context,
 
 	writeDebug(cmd, fmt.Sprintf("Found %d Pods\n", len(dbPods.Items)))
 
-	podExec, err := util.NewPodExecutor(config)
+	podExec, err := newExportPodExecutor(config, cmd)
 	if err != nil {
 		return err
 	}
@@ -1667,14 +1880,10 @@ func gatherPatroniLogs(ctx context.Context,
 	for _, pod := range dbPods.Items {
 		writeDebug(cmd, fmt.Sprintf("Pod Name is %s\n", pod.Name))
 
-		exec := func(stdin io.Reader, stdout, stderr io.Writer, command ...string,
-		) error {
-			return podExec(namespace, pod.Name, util.ContainerDatabase,
-				stdin, stdout, stderr, command...)
-		}
+		exec := podexec.Container(podExec, namespace, pod.Name, util.ContainerDatabase)
 
 		// Get Patroni Log Files
-		stdout, stderr, err := Executor(exec).listPatroniLogFiles()
+		stdout, stderr, err := listPatroniLogFiles(exec)
 
 		// Depending upon the list* function above:
 		// An error may happen when err is non-nil or stderr is non-empty.
@@ -1707,7 +1916,7 @@ func gatherPatroniLogs(ctx context.Context,
 			writeDebug(cmd, fmt.Sprintf("LOG FILE: %s\n", logFile))
 			var buf bytes.Buffer
 
-			stdout, stderr, err := Executor(exec).catFile(logFile)
+			stdout, stderr, err := catFile(exec, logFile)
 			if err != nil {
 				if apierrors.IsForbidden(err) {
 					writeInfo(cmd, err.Error())
@@ -1743,7 +1952,7 @@ func gatherRepoHostLogs(ctx context.Context,
 	clusterName string,
 	outputDir string,
 	outputFile string,
-	tw *tar.Writer,
+	tw *exportArchive,
 	cmd *cobra.Command,
 ) error {
 	writeInfo(cmd, "Collecting pgBackRest Repo Host logs...")
@@ -1766,7 +1975,7 @@ func gatherRepoHostLogs(ctx context.Context,
 
 	writeDebug(cmd, fmt.Sprintf("Found %d Repo Host Pod\n", len(repoHostPods.Items)))
 
-	podExec, err := util.NewPodExecutor(config)
+	podExec, err := newExportPodExecutor(config, cmd)
 	if err != nil {
 		return err
 	}
@@ -1774,14 +1983,10 @@ func gatherRepoHostLogs(ctx context.Context,
 	for _, pod := range repoHostPods.Items {
 		writeDebug(cmd, fmt.Sprintf("Pod Name is %s\n", pod.Name))
 
-		exec := func(stdin io.Reader, stdout, stderr io.Writer, command ...string,
-		) error {
-			return podExec(namespace, pod.Name, util.ContainerPGBackrest,
-				stdin, stdout, stderr, command...)
-		}
+		exec := podexec.Container(podExec, namespace, pod.Name, util.ContainerPGBackrest)
 
 		// Get BackRest Repo Host Log Files
-		stdout, stderr, err := Executor(exec).listBackrestRepoHostLogFiles()
+		stdout, stderr, err := listBackrestRepoHostLogFiles(exec)
 
 		// Depending upon the list* function above:
 		// An error may happen when err is non-nil or stderr is non-empty.
@@ -1838,7 +2043,7 @@ func gatherRepoHostLogs(ctx context.Context,
 			writeInfo(cmd, fmt.Sprintf("\tSize of %-85s %v", fileSpecSrc, convertBytes(fileSize)))
 
 			// Stream the file to disk and write the local file to the tar
-			err = streamFileFromPod(config, tw,
+			err = streamFileFromPod(config, tw, cmd,
 				localDirectory, clusterName, namespace, pod.Name, util.ContainerPGBackrest, logFile, fileSize)
 
 			if err != nil {
@@ -1875,7 +2080,7 @@ func gatherPodLogs(ctx context.Context,
 	namespace string,
 	labelSelector string,
 	rootDir string,
-	tw *tar.Writer,
+	tw *exportArchive,
 	cmd *cobra.Command,
 ) error {
 	// Get all Pods that match the given Label
@@ -1895,6 +2100,15 @@ func gatherPodLogs(ctx context.Context,
 		writeInfo(cmd, fmt.Sprintf("%s Pods not found, skipping", rootDir))
 	}
 
+	// A window of time keeps the logs of long-running Pods small.
+	var sinceSeconds *int64
+	if flag := cmd.Flags().Lookup("pod-log-since"); flag != nil {
+		if since, ok := flag.Value.(*flags.Time); ok && !since.IsZero() {
+			seconds := max(int64(since.Ago(time.Now()).Seconds()), 1)
+			sinceSeconds = &seconds
+		}
+	}
+
 	for _, pod := range pods.Items {
 		err = runKubectlCommand(tw, cmd, rootDir+"/describe/"+"pods/"+pod.GetName(), "describe", "pods", pod.GetName(), "-n", namespace)
 		if err != nil {
@@ -1906,7 +2120,8 @@ func gatherPodLogs(ctx context.Context,
 			result := clientset.CoreV1().Pods(namespace).
 				GetLogs(pod.GetName(), &corev1.PodLogOptions{
 					// TODO (jmckulk): we have the option to grab previous logs
-					Container: container.Name,
+					Container:    container.Name,
+					SinceSeconds: sinceSeconds,
 				}).Do(ctx)
 
 			err = result.Error()
@@ -1943,7 +2158,7 @@ func gatherPatroniInfo(ctx context.Context,
 	config *rest.Config,
 	namespace string,
 	clusterName string,
-	tw *tar.Writer,
+	tw *exportArchive,
 	cmd *cobra.Command,
 ) error {
 	writeInfo(cmd, "Collecting Patroni info...")
@@ -1963,21 +2178,17 @@ func gatherPatroniInfo(ctx context.Context,
 		return nil
 	}
 
-	podExec, err := util.NewPodExecutor(config)
+	podExec, err := newExportPodExecutor(config, cmd)
 	if err != nil {
 		return err
 	}
 
-	exec := func(stdin io.Reader, stdout, stderr io.Writer, command ...string,
-	) error {
-		return podExec(namespace, pods.Items[0].GetName(), util.ContainerDatabase,
-			stdin, stdout, stderr, command...)
-	}
+	exec := podexec.Container(podExec, namespace, pods.Items[0].GetName(), util.ContainerDatabase)
 
 	var buf bytes.Buffer
 
 	buf.Write([]byte("patronictl list\n"))
-	stdout, stderr, err := Executor(exec).patronictl("list", "")
+	stdout, stderr, err := podexec.Patronictl(exec, "list", "")
 	if err != nil {
 		if apierrors.IsForbidden(err) {
 			writeInfo(cmd, err.Error())
@@ -1992,7 +2203,7 @@ func gatherPatroniInfo(ctx context.Context,
 	}
 
 	buf.Write([]byte("patronictl history\n"))
-	stdout, stderr, err = Executor(exec).patronictl("history", "")
+	stdout, stderr, err = podexec.Patronictl(exec, "history", "")
 	if err != nil {
 		if apierrors.IsForbidden(err) {
 			writeInfo(cmd, err.Error())
@@ -2021,7 +2232,7 @@ func gatherPgBackRestInfo(ctx context.Context,
 	config *rest.Config,
 	namespace string,
 	clusterName string,
-	tw *tar.Writer,
+	tw *exportArchive,
 	cmd *cobra.Command,
 ) error {
 	writeInfo(cmd, "Collecting pgBackRest info...")
@@ -2041,21 +2252,17 @@ func gatherPgBackRestInfo(ctx context.Context,
 		return nil
 	}
 
-	podExec, err := util.NewPodExecutor(config)
+	podExec, err := newExportPodExecutor(config, cmd)
 	if err != nil {
 		return err
 	}
 
-	exec := func(stdin io.Reader, stdout, stderr io.Writer, command ...string,
-	) error {
-		return podExec(namespace, pods.Items[0].GetName(), util.ContainerDatabase,
-			stdin, stdout, stderr, command...)
-	}
+	exec := podexec.Container(podExec, namespace, pods.Items[0].GetName(), util.ContainerDatabase)
 
 	var buf bytes.Buffer
 
 	buf.Write([]byte("pgbackrest info\n"))
-	stdout, stderr, err := Executor(exec).pgBackRestInfo("text", "")
+	stdout, stderr, err := podexec.PGBackRestInfo(exec, "")
 	if err != nil {
 		if apierrors.IsForbidden(err) {
 			writeInfo(cmd, err.Error())
@@ -2064,13 +2271,16 @@ func gatherPgBackRestInfo(ctx context.Context,
 		writeInfo(cmd, fmt.Sprintf("Error with pgbackrest info: %s: %s", err, strings.TrimSpace(stderr)))
 	}
 
-	buf.Write([]byte(stdout))
+	// Write the JSON as pgBackRest printed it when it cannot be rendered.
+	if stanzas, err := parsePGBackRestInfo(stdout); err != nil || renderPGBackRestInfo(&buf, stanzas) != nil {
+		buf.Write([]byte(stdout))
+	}
 	if stderr != "" {
 		buf.Write([]byte(stderr))
 	}
 
 	buf.Write([]byte("pgbackrest check\n"))
-	stdout, stderr, err = Executor(exec).pgBackRestCheck()
+	stdout, stderr, err = podexec.PGBackRestCheck(exec)
 	if err != nil {
 		if apierrors.IsForbidden(err) {
 			writeInfo(cmd, err.Error())
@@ -2095,7 +2305,7 @@ func gatherSystemTime(ctx context.Context,
 	config *rest.Config,
 	namespace string,
 	clusterName string,
-	tw *tar.Writer,
+	tw *exportArchive,
 	cmd *cobra.Command,
 ) error {
 	writeInfo(cmd, "Collecting system times from containers...")
@@ -2117,7 +2327,7 @@ func gatherSystemTime(ctx context.Context,
 		return nil
 	}
 
-	podExec, err := util.NewPodExecutor(config)
+	podExec, err := newExportPodExecutor(config, cmd)
 	if err != nil {
 		return err
 	}
@@ -2126,13 +2336,9 @@ func gatherSystemTime(ctx context.Context,
 	for _, pod := range pods.Items {
 		for _, container := range pod.Spec.Containers {
 			// Attempt to exec in and run 'date' command in the first available container.
-			exec := func(stdin io.Reader, stdout, stderr io.Writer, command ...string,
-			) error {
-				return podExec(namespace, pod.GetName(), container.Name,
-					stdin, stdout, stderr, command...)
-			}
+			exec := podexec.Container(podExec, namespace, pod.GetName(), container.Name)
 
-			stdout, stderr, err := Executor(exec).systemTime()
+			stdout, stderr, err := systemTime(exec)
 			if err == nil {
 				buf = writeSystemTime(buf, pod, stdout, stderr)
 				break
@@ -2193,7 +2399,7 @@ func gatherProcessInfo(ctx context.Context,
 	config *rest.Config,
 	namespace string,
 	clusterName string,
-	tw *tar.Writer,
+	tw *exportArchive,
 	cmd *cobra.Command,
 ) error {
 	writeInfo(cmd, "Collecting processes...")
@@ -2215,7 +2421,7 @@ func gatherProcessInfo(ctx context.Context,
 		return nil
 	}
 
-	podExec, err := util.NewPodExecutor(config)
+	podExec, err := newExportPodExecutor(config, cmd)
 	if err != nil {
 		return err
 	}
@@ -2227,13 +2433,9 @@ func gatherProcessInfo(ctx context.Context,
 			// be nearly identical because certain Pods use a shared process
 			// namespace, but this function aims to gather as much detail as possible.
 			// - https://kubernetes.io/docs/tasks/configure-pod-container/share-process-namespace/
-			exec := func(stdin io.Reader, stdout, stderr io.Writer, command ...string,
-			) error {
-				return podExec(namespace, pod.GetName(), container.Name,
-					stdin, stdout, stderr, command...)
-			}
+			exec := podexec.Container(podExec, namespace, pod.GetName(), container.Name)
 
-			stdout, stderr, err := Executor(exec).processes()
+			stdout, stderr, err := processes(exec)
 			if err != nil {
 				// If we get an RBAC error, let the user know and try the next pod.
 				// Otherwise, try the next container.
@@ -2273,7 +2475,25 @@ func translateTimestampSince(timestamp metav1.Time) string {
 }
 
 // writeTar takes content as a byte slice and writes the content to a tar writer
-func writeTar(tw *tar.Writer, content []byte, name string, cmd *cobra.Command) error {
+func writeTar(tw *exportArchive, content []byte, name string, cmd *cobra.Command) error {
+	// Leave out files that have not changed since the baseline.
+	if manifest := tw.manifest; manifest != nil {
+		sum := sha256.Sum256(content)
+		if !manifest.include(name, hex.EncodeToString(sum[:]), int64(len(content))) {
+			writeDebug(cmd, fmt.Sprintf("File: %s Unchanged\n", name))
+			return nil
+		}
+	}
+
+	// Compress large files individually so they are smaller once extracted.
+	if shouldCompressFile(name, int64(len(content))) {
+		compressed, err := compressBytes(content)
+		if err != nil {
+			return err
+		}
+		content, name = compressed, name+".zst"
+	}
+
 	hdr := &tar.Header{
 		Name:    name,
 		Mode:    0600,
@@ -2297,14 +2517,14 @@ func writeTar(tw *tar.Writer, content []byte, name string, cmd *cobra.Command) e
 	return nil
 }
 
-// writeInfo logs to both the PGO CLI log file and stdout
+// writeInfo logs to both the PGO CLI log file and stderr
 // TODO(tjmoore4): In the future, should we implement a logger instead?
 func writeInfo(cmd *cobra.Command, s string) {
 	t := time.Now()
 	// write to CLI log buffer
 	cmd.Printf("%s - INFO - %s\n", t.Format(logTimeFormat), s)
-	// write to stdout
-	fmt.Println(s)
+	// write to stderr
+	cmd.PrintErrln(s)
 }
 
 // writeDebug logs to only the PGO CLI log file
@@ -2313,10 +2533,131 @@ func writeDebug(cmd *cobra.Command, s string) {
 	cmd.Printf("%s - DEBUG - %s", t.Format(logTimeFormat), s)
 }
 
+// transientExecErrors are parts of error messages that indicate a command
+// could not reach its container, rather than the command itself failing.
+var transientExecErrors = []string{
+	"error dialing backend",
+	"unable to upgrade connection",
+	"container not found",
+	"connection reset by peer",
+	"i/o timeout",
+	"TLS handshake timeout",
+}
+
+// isTransientExecError returns true when err is worth retrying.
+func isTransientExecError(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, message := range transientExecErrors {
+		if strings.Contains(err.Error(), message) {
+			return true
+		}
+	}
+	return false
+}
+
+// podExecFunc runs command on container in pod in namespace.
+type podExecFunc = func(
+	namespace, pod, container string,
+	stdin io.Reader, stdout, stderr io.Writer, command ...string,
+) error
+
+// newExportPodExecutor returns a Pod executor that retries after transient
+// errors as many times as the "--retries" flag of cmd allows. Every failed
+// attempt is written to the CLI log file.
+func newExportPodExecutor(config *rest.Config, cmd *cobra.Command) (podExecFunc, error) {
+	podExec, err := util.NewPodExecutor(config)
+	if err != nil {
+		return nil, err
+	}
+	retries, _ := cmd.Flags().GetInt("retries")
+
+	return retryPodExec(podExec, retries, time.Second, func(s string) {
+		writeDebug(cmd, s)
+	}), nil
+}
+
+// retryPodExec wraps podExec so that it is tried again, up to retries times,
+// when it fails with a transient error. The delay doubles after each attempt.
+// Commands that read stdin or have written output are not retried because
+// their streams cannot be replayed.
+func retryPodExec(podExec podExecFunc, retries int, delay time.Duration, log func(string)) podExecFunc {
+	return func(
+		namespace, pod, container string,
+		stdin io.Reader, stdout, stderr io.Writer, command ...string,
+	) error {
+		for attempt := 1; ; attempt++ {
+			out := &countingWriter{Writer: stdout}
+			var w io.Writer
+			if stdout != nil {
+				w = out
+			}
+
+			err := podExec(namespace, pod, container, stdin, w, stderr, command...)
+			retry := attempt <= retries && stdin == nil && out.n == 0 &&
+				isTransientExecError(err)
+
+			switch {
+			case err == nil && attempt > 1:
+				log(fmt.Sprintf("Exec in pod/%s container/%s succeeded on attempt %d\n",
+					pod, container, attempt))
+			case retry:
+				log(fmt.Sprintf("Exec in pod/%s container/%s failed on attempt %d, retrying in %s: %s\n",
+					pod, container, attempt, delay, err))
+			case err != nil && attempt > 1:
+				log(fmt.Sprintf("Exec in pod/%s container/%s failed on attempt %d, giving up: %s\n",
+					pod, container, attempt, err))
+			}
+			if !retry {
+				return err
+			}
+
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+}
+
+// countingWriter counts the bytes written to Writer.
+type countingWriter struct {
+	io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
 // streamFileFromPod streams the file from the Kubernetes pod to a local file.
-func streamFileFromPod(config *rest.Config, tw *tar.Writer,
+func streamFileFromPod(config *rest.Config, tw *exportArchive, cmd *cobra.Command,
 	localDirectory, clusterName, namespace, podName, containerName, remotePath string,
 	remoteFileSize int64) error {
+	tarPath := fmt.Sprintf("%s/pods/%s/%s", clusterName, podName, remotePath)
+
+	// Files of an encrypted export are held in memory so that they are never
+	// on disk unencrypted.
+	if tw.encrypted {
+		podExec, err := util.NewPodExecutor(config)
+		if err != nil {
+			return err
+		}
+		var content bytes.Buffer
+		exec := podexec.Container(podExec, namespace, podName, containerName)
+		if _, err := copyFile(exec, remotePath, &content); err != nil {
+			return fmt.Errorf("error during file streaming: %w", err)
+		}
+		if remoteFileSize != int64(content.Len()) {
+			return fmt.Errorf("filesize mismatch: remote size is %v and local size is %v",
+				remoteFileSize, content.Len())
+		}
+		if err := writeTar(tw, content.Bytes(), tarPath, cmd); err != nil {
+			return fmt.Errorf("error writing to tar: %w", err)
+		}
+		return nil
+	}
 
 	// create localPath to write the streamed data from remotePath
 	// use the uniqueness of outputFile to avoid overwriting other files
@@ -2342,13 +2683,9 @@ func streamFileFromPod(config *rest.Config, tw *tar.Writer,
 	if err != nil {
 		return err
 	}
-	exec := func(stdin io.Reader, stdout, stderr io.Writer, command ...string,
-	) error {
-		return podExec(namespace, podName, containerName,
-			stdin, stdout, stderr, command...)
-	}
+	exec := podexec.Container(podExec, namespace, podName, containerName)
 
-	_, err = Executor(exec).copyFile(remotePath, outFile)
+	_, err = copyFile(exec, remotePath, outFile)
 	if err != nil {
 		return fmt.Errorf("error during file streaming: %w", err)
 	}
@@ -2364,7 +2701,6 @@ func streamFileFromPod(config *rest.Config, tw *tar.Writer,
 	}
 
 	// add localPath to the support export tar
-	tarPath := fmt.Sprintf("%s/pods/%s/%s", clusterName, podName, remotePath)
 	err = addFileToTar(tw, localPath, tarPath)
 	if err != nil {
 		return fmt.Errorf("error writing to tar: %w", err)
@@ -2373,8 +2709,30 @@ func streamFileFromPod(config *rest.Config, tw *tar.Writer,
 	return nil
 }
 
-// addFileToTar copies a local file into a tar archive
-func addFileToTar(tw *tar.Writer, localPath, tarPath string) error {
+// addFileToTar copies a local file into a tar archive. Large files are
+// compressed with zstd first and stored with a ".zst" suffix.
+func addFileToTar(tw *exportArchive, localPath, tarPath string) error {
+	// Leave out files that have not changed since the baseline.
+	if manifest := tw.manifest; manifest != nil {
+		file, err := os.Open(filepath.Clean(localPath))
+		if err != nil {
+			return fmt.Errorf("failed to open file: %w", err)
+		}
+		include, err := manifest.includeReader(tarPath, file)
+		_ = file.Close()
+		if err != nil || !include {
+			return err
+		}
+	}
+
+	if info, err := os.Stat(localPath); err == nil && shouldCompressFile(localPath, info.Size()) {
+		compressedPath, err := compressFile(localPath)
+		if err != nil {
+			return fmt.Errorf("failed to compress file: %w", err)
+		}
+		localPath, tarPath = compressedPath, tarPath+".zst"
+	}
+
 	// Open the file to be added to the tar
 	file, err := os.Open(filepath.Clean(localPath))
 	if err != nil {
@@ -2425,15 +2783,11 @@ func getRemoteFileSize(config *rest.Config,
 	if err != nil {
 		return 0, fmt.Errorf("could not create executor: %w", err)
 	}
-	exec := func(stdin io.Reader, stdout, stderr io.Writer, command ...string,
-	) error {
-		return podExec(namespace, podName, containerName,
-			stdin, stdout, stderr, command...)
-	}
+	exec := podexec.Container(podExec, namespace, podName, containerName)
 
 	// Prepare the command to get the file size using "stat -c %s <file>"
 	command := fmt.Sprintf("stat -c %s %s", "%s", filePath)
-	stdout, stderr, err := Executor(exec).bashCommand(command)
+	stdout, stderr, err := podexec.Bash(exec, command)
 	if err != nil {
 		return 0, fmt.Errorf("could not get file size: %w, stderr: %s", err, stderr)
 	}
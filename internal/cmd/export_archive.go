@@ -0,0 +1,167 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// largeFileSize is the size at which text files in a support export are
+// compressed individually with zstd. Such files are stored with a ".zst"
+// suffix so they can be read without extracting the whole archive first.
+const largeFileSize = 16 << 20
+
+// shouldCompressFile returns true when a file of size bytes named name should
+// be compressed with zstd before it is added to the archive.
+func shouldCompressFile(name string, size int64) bool {
+	switch filepath.Ext(name) {
+	case ".gz", ".zst", ".bz2", ".xz", ".lz4":
+		return false
+	}
+	return size >= largeFileSize
+}
+
+// compressBytes returns content compressed with zstd.
+func compressBytes(content []byte) ([]byte, error) {
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer encoder.Close()
+
+	return encoder.EncodeAll(content, make([]byte, 0, len(content)/4)), nil
+}
+
+// compressFile writes a zstd copy of localPath to localPath + ".zst" and
+// returns the path of that copy.
+func compressFile(localPath string) (string, error) {
+	in, err := os.Open(filepath.Clean(localPath))
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	compressedPath := localPath + ".zst"
+	out, err := os.Create(filepath.Clean(compressedPath))
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+
+	encoder, err := zstd.NewWriter(out)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(encoder, in); err != nil {
+		_ = encoder.Close()
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return compressedPath, out.Close()
+}
+
+// splitFileWriter writes to a series of files that are each at most size
+// bytes. The files are named path.001, path.002, and so on; concatenating
+// them in order reproduces the stream.
+type splitFileWriter struct {
+	path  string
+	size  int64
+	parts []string
+
+	current *os.File
+	written int64
+}
+
+func newSplitFileWriter(path string, size int64) *splitFileWriter {
+	return &splitFileWriter{path: path, size: size}
+}
+
+// Write implements [io.Writer] by starting a new file whenever the current
+// one reaches the size limit.
+func (w *splitFileWriter) Write(p []byte) (int, error) {
+	var total int
+	for len(p) > 0 {
+		if w.current == nil || w.written >= w.size {
+			if err := w.next(); err != nil {
+				return total, err
+			}
+		}
+
+		chunk := p
+		if remaining := w.size - w.written; int64(len(chunk)) > remaining {
+			chunk = chunk[:remaining]
+		}
+
+		n, err := w.current.Write(chunk)
+		total += n
+		w.written += int64(n)
+		if err != nil {
+			return total, err
+		}
+		p = p[n:]
+	}
+	return total, nil
+}
+
+func (w *splitFileWriter) next() error {
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s.%03d", w.path, len(w.parts)+1)
+	// #nosec G304 -- We intentionally write to the directory supplied by the user.
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	w.current, w.written = file, 0
+	w.parts = append(w.parts, name)
+	return nil
+}
+
+// Close closes the current file.
+func (w *splitFileWriter) Close() error {
+	if w.current == nil {
+		return nil
+	}
+	err := w.current.Close()
+	w.current = nil
+	return err
+}
+
+// Parts returns the names of the files written so far.
+func (w *splitFileWriter) Parts() []string { return w.parts }
+
+// archiveSize returns the combined size of the files at paths.
+func archiveSize(paths []string) (int64, error) {
+	var total int64
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return 0, err
+		}
+		total += info.Size()
+	}
+	return total, nil
+}
+
+// splitArchiveMessage explains how to reassemble the parts of an archive.
+func splitArchiveMessage(outputFile string, parts []string) string {
+	names := make([]string, len(parts))
+	for i := range parts {
+		names[i] = filepath.Base(parts[i])
+	}
+	return fmt.Sprintf("The archive was split into %d parts: %s\n"+
+		"Reassemble them with: cat %s.* > %s\n",
+		len(parts), strings.Join(names, ", "), outputFile, outputFile)
+}
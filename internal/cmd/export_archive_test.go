@@ -0,0 +1,83 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"gotest.tools/v3/assert"
+)
+
+func TestShouldCompressFile(t *testing.T) {
+	assert.Assert(t, !shouldCompressFile("postgres.log", 10))
+	assert.Assert(t, shouldCompressFile("postgres.log", largeFileSize))
+	assert.Assert(t, !shouldCompressFile("archive.gz", largeFileSize))
+	assert.Assert(t, !shouldCompressFile("archive.zst", largeFileSize))
+}
+
+func TestCompressFile(t *testing.T) {
+	content := []byte(strings.Repeat("LOG:  checkpoint complete\n", 1000))
+
+	path := filepath.Join(t.TempDir(), "postgres.log")
+	assert.NilError(t, os.WriteFile(path, content, 0o600))
+
+	compressedPath, err := compressFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, compressedPath, path+".zst")
+
+	compressed, err := os.ReadFile(compressedPath)
+	assert.NilError(t, err)
+	assert.Assert(t, len(compressed) < len(content))
+
+	decoder, err := zstd.NewReader(nil)
+	assert.NilError(t, err)
+	defer decoder.Close()
+
+	decoded, err := decoder.DecodeAll(compressed, nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, decoded, content)
+
+	fromBytes, err := compressBytes(content)
+	assert.NilError(t, err)
+	decoded, err = decoder.DecodeAll(fromBytes, nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, decoded, content)
+}
+
+func TestSplitFileWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.tar.gz")
+	w := newSplitFileWriter(path, 4)
+
+	for _, chunk := range []string{"abc", "defghij", "k"} {
+		n, err := w.Write([]byte(chunk))
+		assert.NilError(t, err)
+		assert.Equal(t, n, len(chunk))
+	}
+	assert.NilError(t, w.Close())
+
+	assert.DeepEqual(t, w.Parts(), []string{path + ".001", path + ".002", path + ".003"})
+
+	var joined bytes.Buffer
+	for _, part := range w.Parts() {
+		b, err := os.ReadFile(part)
+		assert.NilError(t, err)
+		assert.Assert(t, len(b) <= 4)
+		joined.Write(b)
+	}
+	assert.Equal(t, joined.String(), "abcdefghijk")
+
+	size, err := archiveSize(w.Parts())
+	assert.NilError(t, err)
+	assert.Equal(t, size, int64(11))
+
+	assert.Equal(t, splitArchiveMessage("export.tar.gz", w.Parts()),
+		"The archive was split into 3 parts: export.tar.gz.001, export.tar.gz.002, export.tar.gz.003\n"+
+			"Reassemble them with: cat export.tar.gz.* > export.tar.gz\n")
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// exportRecipient is a public key file that a support export is encrypted to.
+type exportRecipient struct {
+	// Path is the file that holds the public key.
+	Path string
+
+	// Tool is the program that encrypts to the key: "age" or "gpg".
+	Tool string
+}
+
+// readExportRecipient returns the recipient in the public key file at path.
+// Files of "age-keygen" recipients and SSH public keys are for age. Armored
+// and binary OpenPGP keys are for gpg.
+func readExportRecipient(path string) (exportRecipient, error) {
+	// #nosec G304 -- We intentionally read the file supplied by the user.
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return exportRecipient{}, err
+	}
+	recipient := exportRecipient{Path: filepath.Clean(path)}
+
+	// The first byte of a binary OpenPGP packet has its high bit set.
+	if bytes.Contains(content, []byte("-----BEGIN PGP PUBLIC KEY BLOCK-----")) ||
+		(len(content) > 0 && content[0]&0x80 != 0) {
+		recipient.Tool = "gpg"
+		return recipient, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "", strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "age1"), strings.HasPrefix(line, "ssh-"):
+			recipient.Tool = "age"
+			return recipient, nil
+		default:
+			return exportRecipient{}, fmt.Errorf("%s is not an age or OpenPGP public key file", path)
+		}
+	}
+	return exportRecipient{}, fmt.Errorf("%s has no public key", path)
+}
+
+// Suffix returns what is added to the name of an archive encrypted to r.
+func (r exportRecipient) Suffix() string {
+	if r.Tool == "gpg" {
+		return ".gpg"
+	}
+	return ".age"
+}
+
+// Command returns the program and arguments that encrypt stdin to r and write
+// the result to stdout.
+func (r exportRecipient) Command() []string {
+	if r.Tool == "gpg" {
+		return []string{"gpg", "--batch", "--no-tty", "--trust-model=always",
+			"--recipient-file=" + r.Path, "--output=-", "--encrypt"}
+	}
+	return []string{"age", "--encrypt", "--recipients-file=" + r.Path}
+}
+
+// encryptWriter encrypts what is written to it with a program and writes the
+// result to another writer.
+type encryptWriter struct {
+	program *exec.Cmd
+	stdin   io.WriteCloser
+	stderr  bytes.Buffer
+	out     io.WriteCloser
+}
+
+// newEncryptWriter starts command and returns a writer that sends its input
+// to command. The output of command goes to out, which is closed with the
+// writer.
+func newEncryptWriter(out io.WriteCloser, command ...string) (*encryptWriter, error) {
+	if _, err := exec.LookPath(command[0]); err != nil {
+		return nil, fmt.Errorf("%s is needed to encrypt the export: %w", command[0], err)
+	}
+
+	// #nosec G204 -- The program is age or gpg with the file supplied by the user.
+	w := &encryptWriter{program: exec.Command(command[0], command[1:]...), out: out}
+	w.program.Stdout = out
+	w.program.Stderr = &w.stderr
+
+	stdin, err := w.program.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.program.Start(); err != nil {
+		return nil, err
+	}
+	w.stdin = stdin
+	return w, nil
+}
+
+func (w *encryptWriter) Write(p []byte) (int, error) { return w.stdin.Write(p) }
+
+// Close waits for the program to encrypt everything written, then closes the
+// writer of its output.
+func (w *encryptWriter) Close() error {
+	_ = w.stdin.Close()
+	err := w.program.Wait()
+	if err != nil {
+		err = commandError(fmt.Errorf("%s: %w", w.program.Args[0], err), w.stderr.String())
+	}
+	if closeErr := w.out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestReadExportRecipient(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		assert.NilError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	age := write("age.pub", `
+# created: 2025-08-01T12:00:00Z
+age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p
+`)
+	recipient, err := readExportRecipient(age)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, recipient, exportRecipient{Path: age, Tool: "age"})
+	assert.Equal(t, recipient.Suffix(), ".age")
+	assert.DeepEqual(t, recipient.Command(), []string{"age", "--encrypt", "--recipients-file=" + age})
+
+	ssh := write("id_ed25519.pub", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAA support@example.com\n")
+	recipient, err = readExportRecipient(ssh)
+	assert.NilError(t, err)
+	assert.Equal(t, recipient.Tool, "age")
+
+	armored := write("support.asc", "-----BEGIN PGP PUBLIC KEY BLOCK-----\n\nmQINBF...\n")
+	recipient, err = readExportRecipient(armored)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, recipient, exportRecipient{Path: armored, Tool: "gpg"})
+	assert.Equal(t, recipient.Suffix(), ".gpg")
+	assert.DeepEqual(t, recipient.Command(), []string{"gpg", "--batch", "--no-tty",
+		"--trust-model=always", "--recipient-file=" + armored, "--output=-", "--encrypt"})
+
+	recipient, err = readExportRecipient(write("support.gpg", "\x99\x01\x0d\x04"))
+	assert.NilError(t, err)
+	assert.Equal(t, recipient.Tool, "gpg")
+
+	_, err = readExportRecipient(write("private.key", "AGE-SECRET-KEY-1QQQ\n"))
+	assert.ErrorContains(t, err, "is not an age or OpenPGP public key file")
+	_, err = readExportRecipient(write("empty", "# nothing\n"))
+	assert.ErrorContains(t, err, "has no public key")
+	_, err = readExportRecipient(filepath.Join(dir, "missing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+	closed bool
+}
+
+func (w *nopWriteCloser) Close() error { w.closed = true; return nil }
+
+func TestEncryptWriter(t *testing.T) {
+	var out bytes.Buffer
+	closer := &nopWriteCloser{Writer: &out}
+
+	w, err := newEncryptWriter(closer, "tr", "a-z", "A-Z")
+	assert.NilError(t, err)
+	_, err = io.WriteString(w, "support export\n")
+	assert.NilError(t, err)
+	assert.NilError(t, w.Close())
+	assert.Equal(t, out.String(), "SUPPORT EXPORT\n")
+	assert.Assert(t, closer.closed)
+
+	t.Run("Failure", func(t *testing.T) {
+		w, err := newEncryptWriter(&nopWriteCloser{Writer: io.Discard},
+			"sh", "-c", "cat > /dev/null; echo 'no such recipient' >&2; exit 2")
+		assert.NilError(t, err)
+		assert.ErrorContains(t, w.Close(), "sh: exit status 2: no such recipient")
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		_, err := newEncryptWriter(nil, "pgo-no-such-program")
+		assert.ErrorContains(t, err, "pgo-no-such-program is needed to encrypt the export")
+	})
+}
@@ -0,0 +1,154 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// exportManifest lists the files of a support export and their checksums. A
+// later export given this manifest as its baseline leaves out the files that
+// have not changed.
+type exportManifest struct {
+	Cluster   string    `json:"cluster"`
+	Namespace string    `json:"namespace"`
+	Created   time.Time `json:"created"`
+
+	// Baseline is when the export this one is compared to was created. It is
+	// empty for a full export.
+	Baseline *time.Time `json:"baseline,omitempty"`
+
+	Files map[string]exportManifestFile `json:"files"`
+
+	// Removed are the files of the baseline that this export did not collect.
+	Removed []string `json:"removed,omitempty"`
+
+	baseline map[string]exportManifestFile
+}
+
+// exportManifestFile is one file of an [exportManifest]. Checksums are of the
+// file before it is compressed.
+type exportManifestFile struct {
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+
+	// Unchanged files match the baseline and are not in the archive.
+	Unchanged bool `json:"unchanged,omitempty"`
+}
+
+// exportArchive is the archive of a support export as it is written. The
+// functions that write to it consult its manifest.
+type exportArchive struct {
+	*tar.Writer
+
+	// manifest records every file written to the archive. Files are not
+	// recorded when it is nil.
+	manifest *exportManifest
+
+	// encrypted archives hold files in memory rather than writing them to
+	// the output directory on their way into the archive.
+	encrypted bool
+}
+
+// newExportManifest returns an empty manifest for an export of the cluster
+// named clusterName. Files that match baseline, when it is not nil, are
+// left out of the export.
+func newExportManifest(clusterName, namespace string, baseline *exportManifest, now time.Time) *exportManifest {
+	manifest := &exportManifest{
+		Cluster:   clusterName,
+		Namespace: namespace,
+		Created:   now.UTC(),
+		Files:     map[string]exportManifestFile{},
+	}
+	if baseline != nil {
+		created := baseline.Created
+		manifest.Baseline = &created
+		manifest.baseline = baseline.Files
+	}
+	return manifest
+}
+
+// readExportManifest reads the manifest of an earlier export of the cluster
+// named clusterName in namespace from path.
+func readExportManifest(path, clusterName, namespace string) (*exportManifest, error) {
+	// #nosec G304 -- We intentionally read the file supplied by the user.
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest exportManifest
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return nil, fmt.Errorf("%s is not a support export manifest: %w", path, err)
+	}
+	if manifest.Cluster != clusterName || manifest.Namespace != namespace {
+		return nil, fmt.Errorf("%s is the manifest of postgresclusters/%s in namespace %q, not %s",
+			path, manifest.Cluster, manifest.Namespace, clusterName)
+	}
+	return &manifest, nil
+}
+
+// include records a file named name with content summed to sum and returns
+// whether it belongs in the archive.
+func (manifest *exportManifest) include(name, sum string, size int64) bool {
+	file := exportManifestFile{SHA256: sum, Size: size}
+	if previous, ok := manifest.baseline[name]; ok && previous.SHA256 == sum {
+		file.Unchanged = true
+	}
+	manifest.Files[name] = file
+	return !file.Unchanged
+}
+
+// includeReader is [exportManifest.include] for the content of r.
+func (manifest *exportManifest) includeReader(name string, r io.Reader) (bool, error) {
+	hash := sha256.New()
+	size, err := io.Copy(hash, r)
+	if err != nil {
+		return false, err
+	}
+	return manifest.include(name, hex.EncodeToString(hash.Sum(nil)), size), nil
+}
+
+// finish fills in the files of the baseline that were not collected again.
+func (manifest *exportManifest) finish() {
+	manifest.Removed = nil
+	for name := range manifest.baseline {
+		if _, ok := manifest.Files[name]; !ok {
+			manifest.Removed = append(manifest.Removed, name)
+		}
+	}
+	sort.Strings(manifest.Removed)
+}
+
+// Summary describes how this export compares to its baseline.
+func (manifest *exportManifest) Summary() string {
+	if manifest.Baseline == nil {
+		return ""
+	}
+	var changed int
+	for _, file := range manifest.Files {
+		if !file.Unchanged {
+			changed++
+		}
+	}
+	return fmt.Sprintf("Delta export: %d of %d files changed since %s, %d removed",
+		changed, len(manifest.Files), manifest.Baseline.Format(time.RFC3339), len(manifest.Removed))
+}
+
+// exportManifestPath returns where the manifest of the archive outputFile in
+// outputDir is written.
+func exportManifestPath(outputDir, outputFile string) string {
+	return filepath.Join(outputDir, strings.TrimSuffix(outputFile, ".tar.gz")+".manifest.json")
+}
@@ -0,0 +1,95 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+)
+
+func TestExportManifest(t *testing.T) {
+	first := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cmd := &cobra.Command{}
+	cmd.SetOut(io.Discard)
+
+	// write adds files to an archive that has manifest and returns the names
+	// in the archive.
+	write := func(manifest *exportManifest, files map[string]string) []string {
+		var b bytes.Buffer
+		tw := &exportArchive{Writer: tar.NewWriter(&b), manifest: manifest}
+
+		for _, name := range []string{"hippo/events", "hippo/pods/a.log", "hippo/pods/b.log"} {
+			if content, ok := files[name]; ok {
+				assert.NilError(t, writeTar(tw, []byte(content), name, cmd))
+			}
+		}
+		assert.NilError(t, tw.Close())
+
+		var names []string
+		tr := tar.NewReader(&b)
+		for header, err := tr.Next(); err == nil; header, err = tr.Next() {
+			names = append(names, header.Name)
+		}
+		return names
+	}
+
+	full := newExportManifest("hippo", "postgres-operator", nil, first)
+	assert.DeepEqual(t, write(full, map[string]string{
+		"hippo/events": "one", "hippo/pods/a.log": "a", "hippo/pods/b.log": "b",
+	}), []string{"hippo/events", "hippo/pods/a.log", "hippo/pods/b.log"})
+	full.finish()
+	assert.Equal(t, full.Summary(), "")
+
+	// Round trip the manifest through a file.
+	dir := t.TempDir()
+	path := exportManifestPath(dir, "crunchy_k8s_support_export_2024-05-01-120000+0000.tar.gz")
+	assert.Equal(t, filepath.Base(path), "crunchy_k8s_support_export_2024-05-01-120000+0000.manifest.json")
+	b, err := json.Marshal(full)
+	assert.NilError(t, err)
+	assert.NilError(t, os.WriteFile(path, b, 0o600))
+
+	_, err = readExportManifest(path, "other", "postgres-operator")
+	assert.ErrorContains(t, err, `is the manifest of postgresclusters/hippo in namespace "postgres-operator", not other`)
+	baseline, err := readExportManifest(path, "hippo", "postgres-operator")
+	assert.NilError(t, err)
+
+	delta := newExportManifest("hippo", "postgres-operator", baseline, first.Add(time.Hour))
+	assert.DeepEqual(t, write(delta, map[string]string{
+		"hippo/events": "one two", "hippo/pods/a.log": "a",
+	}), []string{"hippo/events"})
+	delta.finish()
+
+	assert.Equal(t, delta.Files["hippo/pods/a.log"].Unchanged, true)
+	assert.Equal(t, delta.Files["hippo/events"].Size, int64(7))
+	assert.DeepEqual(t, delta.Removed, []string{"hippo/pods/b.log"})
+	assert.Equal(t, delta.Summary(),
+		"Delta export: 1 of 2 files changed since 2024-05-01T12:00:00Z, 1 removed")
+
+	// Files added from disk are compared the same way.
+	local := filepath.Join(dir, "a.log")
+	assert.NilError(t, os.WriteFile(local, []byte("a"), 0o600))
+	var archive bytes.Buffer
+	tw := &exportArchive{Writer: tar.NewWriter(&archive),
+		manifest: newExportManifest("hippo", "postgres-operator", baseline, first)}
+	assert.NilError(t, addFileToTar(tw, local, "hippo/pods/a.log"))
+	assert.NilError(t, addFileToTar(tw, local, "hippo/pods/c.log"))
+	assert.NilError(t, tw.Close())
+
+	tr := tar.NewReader(&archive)
+	header, err := tr.Next()
+	assert.NilError(t, err)
+	assert.Equal(t, header.Name, "hippo/pods/c.log")
+	_, err = tr.Next()
+	assert.Equal(t, err, io.EOF)
+}
@@ -0,0 +1,262 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// exportProfile is a named set of "support export" flags. Support engineers
+// can ask for one profile rather than a list of flags.
+type exportProfile struct {
+	Name string
+
+	// Flags are the values of flags, by name, that the profile sets.
+	Flags map[string]string
+}
+
+// exportProfiles are the profiles of "support export" in the order of how
+// much they collect. The "standard" profile keeps the defaults of every flag.
+var exportProfiles = []exportProfile{{
+	Name: "minimal",
+	Flags: map[string]string{
+		"collect":       "k8s-only",
+		"pod-log-since": "1h",
+	},
+}, {
+	Name:  "standard",
+	Flags: map[string]string{},
+}, {
+	Name: "performance",
+	Flags: map[string]string{
+		"pg-logs-count": "4",
+		"pod-log-since": "24h",
+		"sql-stats":     "activity,database,locks,bgwriter,tables,statements",
+	},
+}, {
+	Name: "deep",
+	Flags: map[string]string{
+		"pg-logs-count": "10",
+		"sql-stats":     strings.Join(sqlStatisticSetNames(), ","),
+		"config-dirs":   "true",
+	},
+}}
+
+// applyExportProfile sets the flags of the profile called name. Flags given on
+// the command line keep their value.
+func applyExportProfile(flags *pflag.FlagSet, name string) error {
+	var names []string
+	for _, profile := range exportProfiles {
+		names = append(names, profile.Name)
+		if profile.Name != name {
+			continue
+		}
+
+		flagNames := make([]string, 0, len(profile.Flags))
+		for flag := range profile.Flags {
+			flagNames = append(flagNames, flag)
+		}
+		sort.Strings(flagNames)
+
+		for _, flag := range flagNames {
+			if !flags.Changed(flag) {
+				if err := flags.Set(flag, profile.Flags[flag]); err != nil {
+					return fmt.Errorf("profile %q: --%s: %w", name, flag, err)
+				}
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown profile %q; profiles supported: %s", name, strings.Join(names, ","))
+}
+
+// sqlStatisticSet is SQL that reads one kind of statistics. None of them read
+// the text of queries, which can contain data.
+type sqlStatisticSet struct {
+	Name string
+	SQL  string
+
+	// EachDatabase is whether the SQL runs in every database rather than once.
+	EachDatabase bool
+}
+
+// sqlStatisticSets are the statistics that "--sql-stats" can collect.
+var sqlStatisticSets = []sqlStatisticSet{{
+	Name: "activity",
+	SQL: `SELECT pid, datname, usename, application_name, client_addr, backend_type,
+  state, wait_event_type, wait_event, backend_start, xact_start, query_start,
+  state_change, backend_xid, backend_xmin
+FROM pg_catalog.pg_stat_activity ORDER BY pid;`,
+}, {
+	Name: "database",
+	SQL:  `SELECT * FROM pg_catalog.pg_stat_database ORDER BY datname;`,
+}, {
+	Name: "replication",
+	SQL: `SELECT * FROM pg_catalog.pg_stat_replication ORDER BY application_name;
+SELECT * FROM pg_catalog.pg_replication_slots ORDER BY slot_name;`,
+}, {
+	Name: "locks",
+	SQL: `SELECT locktype, mode, granted, count(*)
+FROM pg_catalog.pg_locks GROUP BY 1, 2, 3 ORDER BY 1, 2, 3;`,
+}, {
+	Name: "bgwriter",
+	SQL:  `SELECT * FROM pg_catalog.pg_stat_bgwriter;`,
+}, {
+	Name:         "tables",
+	EachDatabase: true,
+	SQL: `SELECT current_database(), * FROM pg_catalog.pg_stat_user_tables
+ORDER BY seq_tup_read DESC LIMIT 50;`,
+}, {
+	// The columns of pg_stat_statements vary by version, so drop the query
+	// text from its rows as JSON.
+	Name: "statements",
+	SQL: `SELECT (pg_catalog.to_jsonb(s) - 'query')::text
+FROM pg_stat_statements s
+ORDER BY (pg_catalog.to_jsonb(s) ->> 'calls')::bigint DESC LIMIT 50;`,
+}}
+
+// sqlStatisticSetNames returns the names of [sqlStatisticSets].
+func sqlStatisticSetNames() []string {
+	names := make([]string, len(sqlStatisticSets))
+	for i := range sqlStatisticSets {
+		names[i] = sqlStatisticSets[i].Name
+	}
+	return names
+}
+
+// selectSQLStatisticSets returns the sets called names in the order that they
+// are collected.
+func selectSQLStatisticSets(names []string) ([]sqlStatisticSet, error) {
+	wanted := map[string]bool{}
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	var sets []sqlStatisticSet
+	for _, set := range sqlStatisticSets {
+		if wanted[set.Name] {
+			sets = append(sets, set)
+			delete(wanted, set.Name)
+		}
+	}
+	for name := range wanted {
+		return nil, fmt.Errorf("unknown SQL statistics %q; types supported: %s",
+			name, strings.Join(sqlStatisticSetNames(), ","))
+	}
+	return sets, nil
+}
+
+// sqlStatisticsPSQL runs SQL read from stdin with headers, like the PG Settings
+// of the postgres-info file.
+func sqlStatisticsPSQL(database string) []string {
+	command := []string{"psql", "--no-psqlrc", "--pset=pager=off", "--pset=format=wrapped",
+		"--pset=columns=180", "--set=ON_ERROR_STOP=1", "--file=-"}
+	if database != "" {
+		command = append(command, "--dbname="+database)
+	}
+	return command
+}
+
+// gatherSQLStatistics runs the SQL of sets in every instance Pod and writes
+// one file per set.
+func gatherSQLStatistics(ctx context.Context,
+	clientset *kubernetes.Clientset,
+	config *rest.Config,
+	namespace string,
+	clusterName string,
+	sets []sqlStatisticSet,
+	tw *exportArchive,
+	cmd *cobra.Command,
+) error {
+	writeInfo(cmd, "Collecting SQL statistics...")
+
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: util.DBInstanceLabels(clusterName),
+	})
+	if err != nil {
+		if apierrors.IsForbidden(err) {
+			writeInfo(cmd, err.Error())
+			return nil
+		}
+		return err
+	}
+	if len(pods.Items) == 0 {
+		writeInfo(cmd, "No database instance pod found for SQL statistics")
+		return nil
+	}
+
+	podExec, err := newExportPodExecutor(config, cmd)
+	if err != nil {
+		return err
+	}
+
+	for _, pod := range pods.Items {
+		exec := podexec.Container(podExec, namespace, pod.GetName(), util.ContainerDatabase)
+		files := collectSQLStatistics(exec, sets, cmd)
+		for _, set := range sets {
+			path := clusterName + fmt.Sprintf("/pods/%s/sql-stats/%s", pod.GetName(), set.Name)
+			if err := writeTar(tw, files[set.Name], path, cmd); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// collectSQLStatistics runs the SQL of sets using exec and returns the output
+// of each set by its name. Errors are kept in the output because statistics
+// like pg_stat_statements are not always available.
+func collectSQLStatistics(exec podexec.Executor, sets []sqlStatisticSet, cmd *cobra.Command) map[string][]byte {
+	var databases []string
+	files := map[string][]byte{}
+
+	for _, set := range sets {
+		targets := []string{""}
+		if set.EachDatabase {
+			if databases == nil {
+				stdout, stderr, err := podexec.PSQL(exec, "",
+					"SELECT datname FROM pg_catalog.pg_database WHERE datallowconn AND NOT datistemplate ORDER BY 1;")
+				if err != nil {
+					writeDebug(cmd, fmt.Sprintf("Error listing databases: %s: %s\n", err, strings.TrimSpace(stderr)))
+				}
+				databases = []string{}
+				for _, row := range parseRows(stdout) {
+					databases = append(databases, row[0])
+				}
+			}
+			targets = databases
+		}
+
+		var buf bytes.Buffer
+		for _, database := range targets {
+			stdout, stderr, err := podexec.Output(exec, strings.NewReader(set.SQL), sqlStatisticsPSQL(database)...)
+			if database != "" {
+				fmt.Fprintf(&buf, "database %s\n", database)
+			}
+			buf.WriteString(stdout)
+			if err != nil {
+				writeDebug(cmd, fmt.Sprintf("Error collecting %s statistics: %s\n", set.Name, err))
+				fmt.Fprintf(&buf, "\nError returned: %s %s\n", err, strings.TrimSpace(stderr))
+			}
+			buf.WriteString("\n")
+		}
+		files[set.Name] = buf.Bytes()
+	}
+	return files
+}
@@ -0,0 +1,88 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+)
+
+func TestApplyExportProfile(t *testing.T) {
+	for _, profile := range exportProfiles {
+		flags := newSupportExportCommand(&internal.Config{}).Flags()
+		assert.NilError(t, applyExportProfile(flags, profile.Name), "profile %q", profile.Name)
+	}
+
+	flags := newSupportExportCommand(&internal.Config{}).Flags()
+	assert.NilError(t, flags.Parse([]string{"--pg-logs-count=1"}))
+	assert.NilError(t, applyExportProfile(flags, "deep"))
+
+	count, _ := flags.GetInt("pg-logs-count")
+	assert.Equal(t, count, 1, "the command line wins")
+	configDirs, _ := flags.GetBool("config-dirs")
+	assert.Assert(t, configDirs)
+	stats, _ := flags.GetStringSlice("sql-stats")
+	assert.DeepEqual(t, stats, sqlStatisticSetNames())
+
+	flags = newSupportExportCommand(&internal.Config{}).Flags()
+	assert.NilError(t, applyExportProfile(flags, "standard"))
+	count, _ = flags.GetInt("pg-logs-count")
+	assert.Equal(t, count, 2)
+
+	err := applyExportProfile(flags, "everything")
+	assert.ErrorContains(t, err, `unknown profile "everything"`)
+	assert.ErrorContains(t, err, "minimal,standard,performance,deep")
+}
+
+func TestSelectSQLStatisticSets(t *testing.T) {
+	sets, err := selectSQLStatisticSets(nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(sets), 0)
+
+	sets, err = selectSQLStatisticSets([]string{"statements", "activity"})
+	assert.NilError(t, err)
+	assert.Equal(t, len(sets), 2)
+	assert.Equal(t, sets[0].Name, "activity", "collected in a fixed order")
+	assert.Equal(t, sets[1].Name, "statements")
+
+	_, err = selectSQLStatisticSets([]string{"activity", "vacuum"})
+	assert.ErrorContains(t, err, `unknown SQL statistics "vacuum"`)
+
+	for _, set := range sqlStatisticSets {
+		assert.Assert(t, !strings.Contains(set.SQL, " query,"), "%q reads query text", set.Name)
+	}
+}
+
+func TestCollectSQLStatistics(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetOut(io.Discard)
+
+	fake := &podexec.Fake{Replies: []podexec.Reply{
+		{Match: "datallowconn", Stdout: "app\npostgres\n"},
+		{Match: "--dbname=app", Stdout: "app tables\n"},
+		{Match: "--dbname=postgres", Stdout: "postgres tables\n"},
+		{Match: "pg_stat_statements", Stderr: `relation "pg_stat_statements" does not exist`,
+			Err: errors.New("exit 1")},
+		{Match: "pg_locks", Stdout: "locks\n"},
+	}}
+
+	sets, err := selectSQLStatisticSets([]string{"locks", "tables", "statements"})
+	assert.NilError(t, err)
+
+	files := collectSQLStatistics(fake, sets, cmd)
+	assert.Equal(t, string(files["locks"]), "locks\n\n")
+	assert.Equal(t, string(files["tables"]),
+		"database app\napp tables\n\ndatabase postgres\npostgres tables\n\n")
+	assert.Equal(t, string(files["statements"]),
+		"\nError returned: exit 1 relation \"pg_stat_statements\" does not exist\n\n")
+}
@@ -0,0 +1,136 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+)
+
+// configDirectories are the paths, relative to the root of the database
+// container, that "--config-dirs" captures with tar. Globs are expanded by the
+// shell in the container.
+var configDirectories = []string{
+	"pgconf",
+	"etc/pgbackrest",
+	"etc/patroni",
+	"pgdata/pg[0-9][0-9]/*.conf",
+}
+
+// configExcludes are patterns of files in [configDirectories] that are never
+// captured. The TLS private keys of Postgres and pgBackRest are among them.
+var configExcludes = []string{"*.key", "*.pem", "*.p12"}
+
+// configSecretSetting matches a "name = value" line whose name looks like it
+// holds a credential, such as repo1-s3-key-secret or repo1-cipher-pass.
+var configSecretSetting = regexp.MustCompile(
+	`(?im)^(\s*[a-z0-9_.-]*(?:key|secret|pass|password|token)[a-z0-9_.-]*\s*[=:]\s*)\S.*$`)
+
+// redactConfigSecrets replaces the values of settings that look like
+// credentials in content with "<redacted>".
+func redactConfigSecrets(content []byte) []byte {
+	return configSecretSetting.ReplaceAll(content, []byte("${1}<redacted>"))
+}
+
+// tarPaths runs tar in exec to write an archive of paths, relative to the root
+// of the container, to stdout. Paths that do not exist are left out. Files
+// matching excludes are not read.
+func tarPaths(exec podexec.Executor, stdout, stderr io.Writer, paths, excludes []string) error {
+	args := []string{"--create", "--file=-", "--ignore-failed-read"}
+	for _, exclude := range excludes {
+		args = append(args, "--exclude='"+exclude+"'")
+	}
+	command := fmt.Sprintf("cd / && tar %s -- $(ls -1d %s 2>/dev/null)",
+		strings.Join(args, " "), strings.Join(paths, " "))
+	return exec.Exec(nil, stdout, stderr, "bash", "-ceu", "--", command)
+}
+
+// copyRemoteTar archives paths in exec with tar and copies the entries into tw
+// under prefix as they arrive. Permissions, times, and links are kept. It
+// returns what tar printed to stderr.
+func copyRemoteTar(
+	exec podexec.Executor, tw *exportArchive, prefix string, paths, excludes []string, cmd *cobra.Command,
+) (string, error) {
+	reader, writer := io.Pipe()
+	var stderr bytes.Buffer
+	done := make(chan error, 1)
+	go func() {
+		err := tarPaths(exec, writer, &stderr, paths, excludes)
+		_ = writer.CloseWithError(err)
+		done <- err
+	}()
+
+	err := copyTarEntries(tar.NewReader(reader), tw, prefix, cmd)
+	if err == nil {
+		// Read the padding after the last entry so that tar can exit.
+		_, err = io.Copy(io.Discard, reader)
+	}
+	_ = reader.CloseWithError(err)
+
+	if execErr := <-done; err == nil || execErr != nil {
+		err = execErr
+	}
+	return stderr.String(), err
+}
+
+// copyTarEntries writes every entry of tr to tw with prefix added to its name.
+// Credentials in regular files are redacted with [redactConfigSecrets], and
+// files that have not changed since the baseline of a delta export are left
+// out.
+func copyTarEntries(tr *tar.Reader, tw *exportArchive, prefix string, cmd *cobra.Command) error {
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		hdr.Name = path.Join(prefix, hdr.Name)
+		if hdr.Typeflag == tar.TypeDir {
+			hdr.Name += "/"
+		}
+		if hdr.Typeflag == tar.TypeLink {
+			hdr.Linkname = path.Join(prefix, hdr.Linkname)
+		}
+
+		var content []byte
+		if hdr.Typeflag == tar.TypeReg {
+			if content, err = io.ReadAll(tr); err != nil {
+				return err
+			}
+			content = redactConfigSecrets(content)
+			hdr.Size = int64(len(content))
+
+			if manifest := tw.manifest; manifest != nil {
+				sum := sha256.Sum256(content)
+				if !manifest.include(hdr.Name, hex.EncodeToString(sum[:]), hdr.Size) {
+					writeDebug(cmd, fmt.Sprintf("File: %s Unchanged\n", hdr.Name))
+					continue
+				}
+			}
+		}
+
+		writeDebug(cmd, fmt.Sprintf("File: %s Size: %d\n", hdr.Name, hdr.Size))
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return err
+		}
+	}
+}
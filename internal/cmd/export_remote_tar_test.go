@@ -0,0 +1,119 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+)
+
+func TestRedactConfigSecrets(t *testing.T) {
+	assert.Equal(t, string(redactConfigSecrets([]byte(`[global]
+repo1-s3-bucket=backups
+repo1-s3-key=AKIAEXAMPLE
+repo1-s3-key-secret = c2VjcmV0
+repo1-cipher-pass=hunter2
+log-level-file=off
+`))), `[global]
+repo1-s3-bucket=backups
+repo1-s3-key=<redacted>
+repo1-s3-key-secret = <redacted>
+repo1-cipher-pass=<redacted>
+log-level-file=off
+`)
+}
+
+func TestCopyRemoteTar(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetOut(io.Discard)
+	modified := time.Date(2024, 5, 1, 14, 22, 37, 0, time.UTC)
+
+	// The fake container writes an archive like GNU tar would.
+	var remote bytes.Buffer
+	archive := tar.NewWriter(&remote)
+	for _, entry := range []struct {
+		hdr     tar.Header
+		content string
+	}{
+		{tar.Header{Name: "pgconf/", Typeflag: tar.TypeDir, Mode: 0o755}, ""},
+		{tar.Header{Name: "pgconf/tls/ca.crt", Typeflag: tar.TypeReg, Mode: 0o644}, "CERTIFICATE"},
+		{tar.Header{Name: "pgconf/tls/tls.crt", Typeflag: tar.TypeSymlink, Linkname: "..data/tls.crt", Mode: 0o777}, ""},
+		{tar.Header{Name: "etc/pgbackrest/conf.d/s3.conf", Typeflag: tar.TypeReg, Mode: 0o600},
+			"repo1-s3-key-secret=c2VjcmV0\n"},
+	} {
+		entry.hdr.ModTime, entry.hdr.Size = modified, int64(len(entry.content))
+		assert.NilError(t, archive.WriteHeader(&entry.hdr))
+		_, err := io.WriteString(archive, entry.content)
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, archive.Close())
+
+	var command string
+	exec := podexec.Func(func(_ io.Reader, stdout, _ io.Writer, args ...string) error {
+		command = args[len(args)-1]
+		_, err := stdout.Write(remote.Bytes())
+		return err
+	})
+
+	var local bytes.Buffer
+	tw := &exportArchive{Writer: tar.NewWriter(&local)}
+	stderr, err := copyRemoteTar(exec, tw, "hippo/pods/hippo-instance1-abcd-0",
+		[]string{"pgconf", "etc/pgbackrest"}, []string{"*.key"}, cmd)
+	assert.NilError(t, err)
+	assert.Equal(t, stderr, "")
+	assert.NilError(t, tw.Close())
+	assert.Equal(t, command, "cd / && tar --create --file=- --ignore-failed-read --exclude='*.key'"+
+		" -- $(ls -1d pgconf etc/pgbackrest 2>/dev/null)")
+
+	var names []string
+	tr := tar.NewReader(&local)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NilError(t, err)
+		names = append(names, hdr.Name)
+		assert.Assert(t, hdr.ModTime.Equal(modified), hdr.Name)
+
+		content, err := io.ReadAll(tr)
+		assert.NilError(t, err)
+		switch {
+		case strings.HasSuffix(hdr.Name, "tls.crt"):
+			assert.Equal(t, hdr.Typeflag, byte(tar.TypeSymlink))
+			assert.Equal(t, hdr.Linkname, "..data/tls.crt")
+		case strings.HasSuffix(hdr.Name, "s3.conf"):
+			assert.Equal(t, hdr.Mode, int64(0o600))
+			assert.Equal(t, string(content), "repo1-s3-key-secret=<redacted>\n")
+		}
+	}
+	assert.DeepEqual(t, names, []string{
+		"hippo/pods/hippo-instance1-abcd-0/pgconf/",
+		"hippo/pods/hippo-instance1-abcd-0/pgconf/tls/ca.crt",
+		"hippo/pods/hippo-instance1-abcd-0/pgconf/tls/tls.crt",
+		"hippo/pods/hippo-instance1-abcd-0/etc/pgbackrest/conf.d/s3.conf",
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		exec := podexec.Func(func(_ io.Reader, _, stderr io.Writer, _ ...string) error {
+			_, _ = io.WriteString(stderr, "bash: tar: command not found")
+			return errors.New("exit status 127")
+		})
+		stderr, err := copyRemoteTar(exec, &exportArchive{Writer: tar.NewWriter(io.Discard)}, "hippo",
+			configDirectories, configExcludes, cmd)
+		assert.ErrorContains(t, err, "exit status 127")
+		assert.Equal(t, stderr, "bash: tar: command not found")
+	})
+}
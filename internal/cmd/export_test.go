@@ -5,7 +5,13 @@
 package cmd
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"gotest.tools/v3/assert"
@@ -31,3 +37,82 @@ func TestFileSizeReport(t *testing.T) {
 		})
 	}
 }
+
+func TestRetryPodExec(t *testing.T) {
+	transient := errors.New("error dialing backend: dial tcp 10.0.0.1:10250: i/o timeout")
+
+	run := func(retries int, results ...error) (int, []string, error) {
+		var calls int
+		var logs []string
+		exec := retryPodExec(func(
+			_, _, _ string, _ io.Reader, stdout, _ io.Writer, _ ...string,
+		) error {
+			err := results[calls]
+			calls++
+			if err == nil {
+				_, _ = stdout.Write([]byte("ok"))
+			}
+			return err
+		}, retries, 0, func(s string) { logs = append(logs, s) })
+
+		var stdout strings.Builder
+		err := exec("ns", "pod", "container", nil, &stdout, nil, "true")
+		return calls, logs, err
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		calls, logs, err := run(3, nil)
+		assert.NilError(t, err)
+		assert.Equal(t, calls, 1)
+		assert.Equal(t, len(logs), 0)
+	})
+
+	t.Run("Recovered", func(t *testing.T) {
+		calls, logs, err := run(3, transient, transient, nil)
+		assert.NilError(t, err)
+		assert.Equal(t, calls, 3)
+		assert.Equal(t, len(logs), 3)
+		assert.Assert(t, strings.Contains(logs[0], "failed on attempt 1, retrying"))
+		assert.Assert(t, strings.Contains(logs[2], "succeeded on attempt 3"))
+	})
+
+	t.Run("GiveUp", func(t *testing.T) {
+		calls, logs, err := run(1, transient, transient)
+		assert.Equal(t, err, transient)
+		assert.Equal(t, calls, 2)
+		assert.Assert(t, strings.Contains(logs[1], "failed on attempt 2, giving up"))
+	})
+
+	t.Run("NotTransient", func(t *testing.T) {
+		exit := errors.New("command terminated with exit code 1")
+		calls, logs, err := run(3, exit)
+		assert.Equal(t, err, exit)
+		assert.Equal(t, calls, 1)
+		assert.Equal(t, len(logs), 0)
+	})
+}
+
+func TestSupportExportQuiet(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	assert.NilError(t, os.WriteFile(kubeconfig, nil, 0o600))
+	t.Setenv("KUBECONFIG", kubeconfig)
+
+	run := func(args ...string) (string, string) {
+		var stdout, stderr bytes.Buffer
+		root := NewPGOCommand(nil, &stdout, &stderr)
+		root.SetArgs(append([]string{"support", "export", "hippo", "--output", t.TempDir()}, args...))
+
+		// There is no cluster to export, but progress is printed before
+		// the command connects to one.
+		assert.Assert(t, root.Execute() != nil)
+		return stdout.String(), stderr.String()
+	}
+
+	stdout, stderr := run()
+	assert.Equal(t, stdout, "")
+	assert.Assert(t, strings.Contains(stderr, "| PGO CLI Support Export Tool\n"), "got %q", stderr)
+
+	stdout, stderr = run("--quiet")
+	assert.Equal(t, stdout, "")
+	assert.Equal(t, stderr, "")
+}
@@ -0,0 +1,65 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// TestFakeCluster runs a command against the fixtures of each context in
+// testdata/fake-cluster.
+func TestFakeCluster(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	assert.NilError(t, os.WriteFile(kubeconfig, nil, 0o600))
+	t.Setenv("KUBECONFIG", kubeconfig)
+	t.Setenv(internal.FakeClusterEnv, filepath.Join("testdata", "fake-cluster"))
+
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		root := NewPGOCommand(nil, &out, &out)
+		root.SetOut(&out)
+		root.SetArgs(append(args, "--namespace=postgres-operator"))
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	for _, tt := range []struct {
+		context, output, err string
+	}{{
+		context: "new-repo",
+		output: `
+TYPE  SIZE    MODIFIED              NAME
+path  -       -                     20241001-060000F
+file  1.3KiB  2024-10-01T06:00:41Z  backup.info
+link  -       -                     latest -> 20241001-060000F
+`[1:],
+	}, {
+		context: "old-repo",
+		err:     "exit code 55: ERROR: [055]: unable to load info file",
+	}} {
+		t.Run(tt.context, func(t *testing.T) {
+			output, err := run("browse", "repo", "hippo", "--repoName=repo1",
+				"--path=backup/db", "--context="+tt.context)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+			} else {
+				assert.NilError(t, err)
+			}
+			assert.Equal(t, output, tt.output)
+		})
+	}
+
+	t.Run("NoFixture", func(t *testing.T) {
+		_, err := run("browse", "repo", "rhino", "--repoName=repo1")
+		assert.ErrorContains(t, err, "no fixture for GET /api/v1/namespaces/postgres-operator/pods")
+	})
+}
@@ -0,0 +1,32 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// newGenerateCommand returns the generate subcommand of the PGO plugin.
+// Subcommands of generate print manifests that relate to a PostgresCluster.
+func newGenerateCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate manifests for a PostgresCluster",
+		Long:  "Generate manifests for a PostgresCluster",
+	}
+
+	cmd.AddCommand(
+		newGenerateAlertsCommand(config),
+		newGenerateCertCommand(config),
+		newGenerateMessagesCommand(config),
+		newGenerateNetworkPolicyCommand(config),
+		newGenerateS3SecretCommand(config),
+		newGenerateTerraformCommand(config),
+	)
+
+	return cmd
+}
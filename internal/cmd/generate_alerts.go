@@ -0,0 +1,211 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newGenerateAlertsCommand returns the alerts subcommand of the generate
+// command. It prints Prometheus alerting rules for one PostgresCluster.
+func newGenerateAlertsCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "alerts CLUSTER_NAME",
+		Short: "Generate Prometheus alerting rules for a PostgresCluster",
+		Long: `Generate Prometheus alerting rules for a PostgresCluster. The rules use the
+metrics of the Crunchy Postgres Exporter sidecar that is enabled by
+"spec.monitoring.pgmonitor.exporter" and select the cluster with the
+"pg_cluster" label that the PGO monitoring stack adds to those metrics.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    postgresclusters.postgres-operator.crunchydata.com  [get]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Generate a PrometheusRule for the 'hippo' postgrescluster
+pgo generate alerts hippo
+
+# Generate a plain Prometheus rule file with custom thresholds
+pgo generate alerts hippo --format yaml --max-backup-age 48h --max-disk-usage 90
+
+### Example output
+apiVersion: monitoring.coreos.com/v1
+kind: PrometheusRule
+metadata:
+  labels:
+    postgres-operator.crunchydata.com/cluster: hippo
+  name: hippo-alerts
+  namespace: postgres-operator
+spec:
+  groups:
+  - name: hippo
+    rules:
+    - alert: PostgresReplicationLag
+...`)
+
+	alerts := alertThresholds{
+		MaxBackupAge:       24 * time.Hour,
+		MaxConnectionUsage: 80,
+		MaxDiskUsage:       80,
+		MaxReplicationLag:  resource.MustParse("100Mi"),
+		For:                5 * time.Minute,
+	}
+	var formatEnum = util.PrometheusRuleAlerts
+	cmd.Flags().Var(&formatEnum, "format",
+		"output format. types supported: prometheusrule,yaml")
+	cmd.Flags().DurationVar(&alerts.MaxBackupAge, "max-backup-age", alerts.MaxBackupAge,
+		"alert when the most recent backup is older than this")
+	cmd.Flags().IntVar(&alerts.MaxConnectionUsage, "max-connection-usage", alerts.MaxConnectionUsage,
+		"alert when this percent of max_connections is in use")
+	cmd.Flags().IntVar(&alerts.MaxDiskUsage, "max-disk-usage", alerts.MaxDiskUsage,
+		"alert when this percent of the data volume is in use")
+	cmd.Flags().Var(&quantityFlag{&alerts.MaxReplicationLag}, "max-replication-lag",
+		"alert when a replica is this far behind the primary, e.g. 100Mi")
+	cmd.Flags().DurationVar(&alerts.For, "for", alerts.For,
+		"how long a threshold must be exceeded before an alert fires")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		_, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+
+		cluster, err := client.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if _, found, _ := unstructured.NestedMap(cluster.Object,
+			"spec", "monitoring", "pgmonitor", "exporter"); !found {
+			cmd.PrintErrf("WARNING: postgrescluster/%s does not enable the exporter sidecar; "+
+				"these alerts will have no data\n", args[0])
+		}
+
+		alerts.Format = formatEnum.String()
+		b, err := yaml.Marshal(alerts.Manifest(namespace, args[0]))
+		if err != nil {
+			return err
+		}
+
+		_, err = cmd.OutOrStdout().Write(b)
+		return err
+	}
+
+	return cmd
+}
+
+// alertThresholds are the settings of the rules printed by "generate alerts".
+type alertThresholds struct {
+	Format             string
+	For                time.Duration
+	MaxBackupAge       time.Duration
+	MaxConnectionUsage int
+	MaxDiskUsage       int
+	MaxReplicationLag  resource.Quantity
+}
+
+// Rules returns the alerting rules for the cluster in namespace.
+func (alerts alertThresholds) Rules(namespace, clusterName string) []map[string]any {
+	selector := fmt.Sprintf(`pg_cluster="%s:%s"`, namespace, clusterName)
+	rule := func(name, expr, summary string) map[string]any {
+		return map[string]any{
+			"alert": name,
+			"expr":  expr,
+			"for":   alerts.For.String(),
+			"labels": map[string]any{
+				"severity": "warning",
+			},
+			"annotations": map[string]any{
+				"summary": summary,
+			},
+		}
+	}
+
+	return []map[string]any{
+		rule("PostgresReplicationLag",
+			fmt.Sprintf(`max by (pod) (ccp_replication_lag_size_bytes{%s}) > %d`,
+				selector, alerts.MaxReplicationLag.Value()),
+			fmt.Sprintf("Replica {{ $labels.pod }} of %s/%s is more than %s behind the primary",
+				namespace, clusterName, alerts.MaxReplicationLag.String())),
+		rule("PostgresBackupAge",
+			fmt.Sprintf(`min(ccp_backrest_last_full_backup_time_since_completion_seconds{%s}) > %d`,
+				selector, int64(alerts.MaxBackupAge.Seconds())),
+			fmt.Sprintf("No full backup of %s/%s has completed in %s",
+				namespace, clusterName, alerts.MaxBackupAge)),
+		rule("PostgresDiskUsage",
+			fmt.Sprintf(`100 * (1 - ccp_nodemx_data_disk_available_bytes{%[1]s}`+
+				` / ccp_nodemx_data_disk_total_bytes{%[1]s}) > %[2]d`,
+				selector, alerts.MaxDiskUsage),
+			fmt.Sprintf("The data volume of {{ $labels.pod }} in %s/%s is more than %d%% full",
+				namespace, clusterName, alerts.MaxDiskUsage)),
+		rule("PostgresConnectionSaturation",
+			fmt.Sprintf(`100 * ccp_connection_stats_total{%[1]s}`+
+				` / ccp_connection_stats_max_connections{%[1]s} > %[2]d`,
+				selector, alerts.MaxConnectionUsage),
+			fmt.Sprintf("More than %d%% of connections to {{ $labels.pod }} in %s/%s are in use",
+				alerts.MaxConnectionUsage, namespace, clusterName)),
+	}
+}
+
+// Manifest returns the rules in the requested format.
+func (alerts alertThresholds) Manifest(namespace, clusterName string) map[string]any {
+	groups := []map[string]any{{
+		"name":  clusterName,
+		"rules": alerts.Rules(namespace, clusterName),
+	}}
+
+	if alerts.Format == string(util.YAMLAlerts) {
+		return map[string]any{"groups": groups}
+	}
+
+	return map[string]any{
+		"apiVersion": "monitoring.coreos.com/v1",
+		"kind":       "PrometheusRule",
+		"metadata": map[string]any{
+			"name":      clusterName + "-alerts",
+			"namespace": namespace,
+			"labels": map[string]any{
+				util.LabelCluster: clusterName,
+			},
+		},
+		"spec": map[string]any{"groups": groups},
+	}
+}
+
+// quantityFlag implements [pflag.Value] for a Kubernetes resource quantity.
+type quantityFlag struct{ *resource.Quantity }
+
+func (q quantityFlag) String() string { return q.Quantity.String() }
+func (q quantityFlag) Type() string   { return "quantity" }
+
+func (q quantityFlag) Set(v string) error {
+	parsed, err := resource.ParseQuantity(v)
+	if err == nil {
+		*q.Quantity = parsed
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+func TestAlertThresholds(t *testing.T) {
+	alerts := alertThresholds{
+		For:                time.Minute,
+		MaxBackupAge:       2 * time.Hour,
+		MaxConnectionUsage: 75,
+		MaxDiskUsage:       90,
+		MaxReplicationLag:  resource.MustParse("1Mi"),
+	}
+
+	t.Run("Rules", func(t *testing.T) {
+		rules := alerts.Rules("ns", "hippo")
+		assert.Equal(t, len(rules), 4)
+
+		for _, rule := range rules {
+			assert.Assert(t, strings.Contains(rule["expr"].(string), `pg_cluster="ns:hippo"`),
+				"%v", rule["expr"])
+			assert.Equal(t, rule["for"], "1m0s")
+		}
+
+		assert.Equal(t, rules[0]["expr"],
+			`max by (pod) (ccp_replication_lag_size_bytes{pg_cluster="ns:hippo"}) > 1048576`)
+		assert.Equal(t, rules[1]["expr"],
+			`min(ccp_backrest_last_full_backup_time_since_completion_seconds{pg_cluster="ns:hippo"}) > 7200`)
+	})
+
+	t.Run("PrometheusRule", func(t *testing.T) {
+		alerts := alerts
+		alerts.Format = string(util.PrometheusRuleAlerts)
+
+		manifest := alerts.Manifest("ns", "hippo")
+		assert.Equal(t, manifest["kind"], "PrometheusRule")
+		assert.DeepEqual(t, manifest["metadata"], map[string]any{
+			"name":      "hippo-alerts",
+			"namespace": "ns",
+			"labels": map[string]any{
+				"postgres-operator.crunchydata.com/cluster": "hippo",
+			},
+		})
+	})
+
+	t.Run("YAML", func(t *testing.T) {
+		alerts := alerts
+		alerts.Format = string(util.YAMLAlerts)
+
+		manifest := alerts.Manifest("ns", "hippo")
+		assert.Equal(t, len(manifest), 1)
+		assert.Assert(t, manifest["groups"] != nil)
+	})
+}
@@ -0,0 +1,100 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// newGenerateMessagesCommand returns the messages subcommand of the generate
+// command. It prints a message catalog to start or update a translation.
+func newGenerateMessagesCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "messages",
+		Short: "Generate a message catalog to translate",
+		Long: `Generate a message catalog for the locale chosen by "--locale" or the
+PGO_LOCALE, LC_ALL, LC_MESSAGES, or LANG environment variable. The catalog is
+a JSON object of message IDs and text. Messages that are already translated
+keep their translation; the others are in English.
+
+Save a translated catalog named for its locale, such as "de.json" or
+"pt_BR.json", in the directory named by PGO_MESSAGES_DIR or in
+"kubectl-pgo/messages" of your user configuration directory, such as
+"~/.config/kubectl-pgo/messages". Messages that are missing, or that use a
+different number of arguments than the English text, are printed in English.
+Those are reported as warnings.
+
+The catalog holds prompts, the answers they accept, and warnings. The answers
+"confirm.yes" and "confirm.no" are lists separated by commas; English answers
+are always accepted too. Tables, reports, and errors stay in English so that
+scripts can read them.
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Start a German translation
+pgo generate messages --locale=de > ~/.config/kubectl-pgo/messages/de.json
+
+### Example output
+{
+  "apply.confirm": "\nDo you want to apply these changes? (yes/no): ",
+  "confirm.continue": "Are you sure you want to continue? (yes/no): ",
+  "confirm.continue-backups": "Are you sure you want to continue without backups? (yes/no): ",
+...`)
+
+	cmd.Args = cobra.NoArgs
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		catalog, err := config.Messages.Catalog()
+		if err != nil {
+			return err
+		}
+
+		template, warnings := messageTemplate(internal.Messages, catalog)
+		for _, warning := range warnings {
+			cmd.PrintErrln("WARNING:", warning)
+		}
+
+		b, err := json.MarshalIndent(template, "", "  ")
+		if err != nil {
+			return err
+		}
+		cmd.Println(string(b))
+		return nil
+	}
+
+	return cmd
+}
+
+// messageTemplate returns the messages of catalog that can replace those of
+// english and english for the rest. It also explains the translations that
+// cannot be used.
+func messageTemplate(english, catalog map[string]string) (map[string]string, []string) {
+	template := make(map[string]string, len(english))
+	for id, text := range english {
+		template[id] = text
+	}
+
+	var warnings []string
+	for id, text := range catalog {
+		switch _, known := english[id]; {
+		case !known:
+			warnings = append(warnings, fmt.Sprintf("%q is not a message ID", id))
+		case !internal.UsableTranslation(english[id], text):
+			warnings = append(warnings, fmt.Sprintf(
+				"%q uses a different number of arguments than the English text", id))
+		default:
+			template[id] = text
+		}
+	}
+	sort.Strings(warnings)
+	return template, warnings
+}
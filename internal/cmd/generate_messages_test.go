@@ -0,0 +1,28 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestMessageTemplate(t *testing.T) {
+	english := map[string]string{
+		"one": "one %s",
+		"two": "two",
+	}
+	template, warnings := messageTemplate(english, map[string]string{
+		"one":   "eins",
+		"two":   "zwei",
+		"three": "drei",
+	})
+	assert.DeepEqual(t, template, map[string]string{"one": "one %s", "two": "zwei"})
+	assert.DeepEqual(t, warnings, []string{
+		`"one" uses a different number of arguments than the English text`,
+		`"three" is not a message ID`,
+	})
+}
@@ -0,0 +1,249 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newGenerateNetworkPolicyCommand returns the networkpolicy subcommand of the
+// generate command. It prints NetworkPolicies that allow only the traffic a
+// PostgresCluster needs.
+func newGenerateNetworkPolicyCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "networkpolicy CLUSTER_NAME",
+		Short: "Generate NetworkPolicies for a PostgresCluster",
+		Long: `Generate NetworkPolicies that allow only the traffic a PostgresCluster needs:
+  - between the Pods of the cluster, including replication and pgBackRest
+  - from the PGO operator to Postgres and Patroni
+  - from the sources given by "--allow-from" to pgBouncer and Postgres
+
+Each "--allow-from" is a comma-separated list of "namespace=NAME",
+"label=KEY=VALUE", and "cidr=CIDR" that together describe one source. Repeat
+the flag for more sources. Use "--apply" to create or update the policies
+rather than print them.
+
+### RBAC Requirements
+    Resources                               Verbs
+    ---------                               -----
+    networkpolicies.networking.k8s.io       [create patch]
+
+    Note: These permissions are only needed with "--apply".
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Allow Pods labeled 'app=web' in the 'apps' namespace to reach the 'hippo' postgrescluster
+pgo generate networkpolicy hippo --allow-from=namespace=apps,label=app=web
+
+# Create or update those NetworkPolicies
+pgo generate networkpolicy hippo --allow-from=namespace=apps,label=app=web --apply
+
+### Example output
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  labels:
+    postgres-operator.crunchydata.com/cluster: hippo
+  name: hippo-postgres
+  namespace: postgres-operator
+...`)
+
+	var allowFrom []string
+	var apply bool
+	cmd.Flags().StringArrayVar(&allowFrom, "allow-from", nil,
+		"a source allowed to connect, e.g. namespace=apps,label=app=web; can be used multiple times")
+	cmd.Flags().BoolVar(&apply, "apply", false, "create or update the NetworkPolicies rather than print them")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		var peers []map[string]any
+		for _, value := range allowFrom {
+			peer, err := parseAllowFrom(value)
+			if err != nil {
+				return err
+			}
+			peers = append(peers, peer)
+		}
+		if len(peers) == 0 {
+			cmd.PrintErrln("WARNING: Without --allow-from, clients outside the cluster cannot connect.")
+		}
+
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		policies := clusterNetworkPolicies(namespace, args[0], peers)
+
+		if !apply {
+			for i, policy := range policies {
+				b, err := yaml.Marshal(policy)
+				if err != nil {
+					return err
+				}
+				if i > 0 {
+					cmd.Println("---")
+				}
+				cmd.Print(string(b))
+			}
+			return nil
+		}
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := dynamic.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		resource := networkingv1.SchemeGroupVersion.WithResource("networkpolicies")
+
+		for _, policy := range policies {
+			patch, err := json.Marshal(policy)
+			if err != nil {
+				return err
+			}
+			name := policy["metadata"].(map[string]any)["name"].(string)
+			if _, err := client.Resource(resource).Namespace(namespace).Patch(ctx, name,
+				types.ApplyPatchType, patch,
+				config.Patch.PatchOptions(metav1.PatchOptions{})); err != nil {
+				return err
+			}
+			cmd.Printf("networkpolicies/%s applied\n", name)
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// parseAllowFrom returns the NetworkPolicy peer described by value.
+func parseAllowFrom(value string) (map[string]any, error) {
+	peer := map[string]any{}
+	labels := map[string]any{}
+
+	for _, part := range strings.Split(value, ",") {
+		key, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || v == "" {
+			return nil, fmt.Errorf("invalid --allow-from %q: expected KEY=VALUE", part)
+		}
+		switch key {
+		case "namespace":
+			peer["namespaceSelector"] = map[string]any{
+				"matchLabels": map[string]any{"kubernetes.io/metadata.name": v},
+			}
+		case "label":
+			name, labelValue, ok := strings.Cut(v, "=")
+			if !ok || name == "" {
+				return nil, fmt.Errorf("invalid --allow-from label %q: expected label=KEY=VALUE", v)
+			}
+			labels[name] = labelValue
+		case "cidr":
+			peer["ipBlock"] = map[string]any{"cidr": v}
+		default:
+			return nil, fmt.Errorf("invalid --allow-from %q: unknown key %q", value, key)
+		}
+	}
+
+	if len(labels) > 0 {
+		peer["podSelector"] = map[string]any{"matchLabels": labels}
+	}
+	if _, ok := peer["ipBlock"]; ok && len(peer) > 1 {
+		return nil, fmt.Errorf("invalid --allow-from %q: cidr cannot be combined with other keys", value)
+	}
+	return peer, nil
+}
+
+// clusterNetworkPolicies returns the NetworkPolicies of the cluster named
+// clusterName in namespace. Clients matching any of peers may connect.
+func clusterNetworkPolicies(namespace, clusterName string, peers []map[string]any) []map[string]any {
+	policy := func(suffix string, selector map[string]any, rules ...map[string]any) map[string]any {
+		return map[string]any{
+			"apiVersion": networkingv1.SchemeGroupVersion.String(),
+			"kind":       "NetworkPolicy",
+			"metadata": map[string]any{
+				"name":      clusterName + "-" + suffix,
+				"namespace": namespace,
+				"labels":    map[string]any{util.LabelCluster: clusterName},
+			},
+			"spec": map[string]any{
+				"podSelector": map[string]any{"matchLabels": selector},
+				"policyTypes": []any{"Ingress"},
+				"ingress":     rules,
+			},
+		}
+	}
+	ports := func(numbers ...int64) []any {
+		var result []any
+		for _, number := range numbers {
+			result = append(result, map[string]any{"protocol": "TCP", "port": number})
+		}
+		return result
+	}
+	clients := func(numbers ...int64) []map[string]any {
+		if len(peers) == 0 {
+			return nil
+		}
+		from := make([]any, len(peers))
+		for i := range peers {
+			from[i] = peers[i]
+		}
+		return []map[string]any{{"from": from, "ports": ports(numbers...)}}
+	}
+
+	// Pods of the cluster talk to each other: replication, Patroni, pgBackRest.
+	cluster := map[string]any{
+		"from": []any{map[string]any{
+			"podSelector": map[string]any{
+				"matchLabels": map[string]any{util.LabelCluster: clusterName},
+			},
+		}},
+	}
+	operator := map[string]any{
+		"from": []any{map[string]any{
+			"namespaceSelector": map[string]any{},
+			"podSelector": map[string]any{
+				"matchExpressions": []any{map[string]any{
+					"key": util.LabelOperator, "operator": "Exists",
+				}},
+			},
+		}},
+		"ports": ports(5432, 8008),
+	}
+
+	return []map[string]any{
+		policy("postgres", map[string]any{
+			util.LabelCluster: clusterName,
+			util.LabelData:    util.DataPostgres,
+		}, append([]map[string]any{cluster, operator}, clients(5432)...)...),
+
+		policy("pgbouncer", map[string]any{
+			util.LabelCluster: clusterName,
+			util.LabelRole:    "pgbouncer",
+		}, append([]map[string]any{cluster}, clients(5432)...)...),
+
+		policy("repo-host", map[string]any{
+			util.LabelCluster:             clusterName,
+			util.LabelPGBackRestDedicated: "",
+		}, map[string]any{"from": cluster["from"], "ports": ports(2022)}),
+	}
+}
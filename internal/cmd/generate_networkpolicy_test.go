@@ -0,0 +1,103 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/crunchydata/postgres-operator-client/internal/testing/cmp"
+)
+
+func TestParseAllowFrom(t *testing.T) {
+	peer, err := parseAllowFrom("namespace=apps,label=app=web,label=tier=front")
+	assert.NilError(t, err)
+	assert.Assert(t, cmp.MarshalMatches(peer, `
+namespaceSelector:
+  matchLabels:
+    kubernetes.io/metadata.name: apps
+podSelector:
+  matchLabels:
+    app: web
+    tier: front
+`))
+
+	peer, err = parseAllowFrom("cidr=10.0.0.0/8")
+	assert.NilError(t, err)
+	assert.Assert(t, cmp.MarshalMatches(peer, `
+ipBlock:
+  cidr: 10.0.0.0/8
+`))
+
+	for _, tt := range []struct{ value, message string }{
+		{"namespace", "expected KEY=VALUE"},
+		{"label=app", "expected label=KEY=VALUE"},
+		{"pod=web", `unknown key "pod"`},
+		{"cidr=10.0.0.0/8,namespace=apps", "cannot be combined"},
+	} {
+		_, err := parseAllowFrom(tt.value)
+		assert.ErrorContains(t, err, tt.message, "%q", tt.value)
+	}
+}
+
+func TestClusterNetworkPolicies(t *testing.T) {
+	t.Run("NoClients", func(t *testing.T) {
+		policies := clusterNetworkPolicies("ns", "hippo", nil)
+		assert.Equal(t, len(policies), 3)
+
+		var names []string
+		for _, policy := range policies {
+			names = append(names, policy["metadata"].(map[string]any)["name"].(string))
+		}
+		assert.DeepEqual(t, names, []string{"hippo-postgres", "hippo-pgbouncer", "hippo-repo-host"})
+
+		assert.Assert(t, cmp.MarshalMatches(policies[1]["spec"], `
+ingress:
+- from:
+  - podSelector:
+      matchLabels:
+        postgres-operator.crunchydata.com/cluster: hippo
+podSelector:
+  matchLabels:
+    postgres-operator.crunchydata.com/cluster: hippo
+    postgres-operator.crunchydata.com/role: pgbouncer
+policyTypes:
+- Ingress
+`))
+		assert.Assert(t, cmp.MarshalMatches(policies[2]["spec"], `
+ingress:
+- from:
+  - podSelector:
+      matchLabels:
+        postgres-operator.crunchydata.com/cluster: hippo
+  ports:
+  - port: 2022
+    protocol: TCP
+podSelector:
+  matchLabels:
+    postgres-operator.crunchydata.com/cluster: hippo
+    postgres-operator.crunchydata.com/pgbackrest-dedicated: ""
+policyTypes:
+- Ingress
+`))
+	})
+
+	t.Run("Clients", func(t *testing.T) {
+		peer := map[string]any{"ipBlock": map[string]any{"cidr": "10.0.0.0/8"}}
+		policies := clusterNetworkPolicies("ns", "hippo", []map[string]any{peer})
+
+		rules := policies[0]["spec"].(map[string]any)["ingress"].([]map[string]any)
+		assert.Equal(t, len(rules), 3)
+		assert.Assert(t, cmp.MarshalMatches(rules[2], `
+from:
+- ipBlock:
+    cidr: 10.0.0.0/8
+ports:
+- port: 5432
+  protocol: TCP
+`))
+	})
+}
@@ -0,0 +1,336 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// newGenerateTerraformCommand returns the terraform subcommand of the generate
+// command. It prints what "create postgrescluster" would create as Terraform
+// or Crossplane resources.
+func newGenerateTerraformCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "terraform CLUSTER_NAME",
+		Short: "Generate Terraform or Crossplane resources for a PostgresCluster",
+		Long: `Generate the objects that "pgo create postgrescluster" would create with the
+same flags, for infrastructure as code. The PostgresCluster, the ConfigMap of
+"--init-sql", and the objects of "--enable-monitoring" are printed in order.
+
+With "--format=terraform", each object is a "kubernetes_manifest" resource of
+the Terraform Kubernetes provider. The PostgresCluster depends on the init SQL
+ConfigMap, which PGO reads when the cluster is bootstrapped.
+
+With "--format=crossplane", each object is a resource of a Crossplane
+Composition that wraps it in an Object of provider-kubernetes. Paste them
+under "spec.resources" of a Composition.
+
+Nothing is sent to Kubernetes; the namespace comes from the current context
+or "--namespace".
+
+### RBAC Requirements
+    None required
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Print Terraform for a postgrescluster with Postgres 16
+pgo generate terraform hippo --pg-major-version 16
+
+# Print Crossplane Composition resources for a monitored postgrescluster
+pgo generate terraform hippo --pg-major-version 16 --format=crossplane \
+  --enable-monitoring --podmonitor
+
+### Example output
+resource "kubernetes_manifest" "postgrescluster_hippo" {
+  manifest = {
+    apiVersion = "postgres-operator.crunchydata.com/v1beta1"
+    kind       = "PostgresCluster"
+    metadata = {
+      name      = "hippo"
+      namespace = "postgres-operator"
+    }
+    spec = {
+      backups = {
+...`)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	var pgMajorVersion int
+	cmd.Flags().IntVar(&pgMajorVersion, "pg-major-version", 0, "Set the Postgres major version")
+	cobra.CheckErr(cmd.MarkFlagRequired("pg-major-version"))
+
+	var backupsDisabled bool
+	cmd.Flags().BoolVar(&backupsDisabled, "disable-backups", false, "Disable backups")
+
+	shape := newClusterShape()
+	shape.AddFlags(cmd.Flags())
+
+	var extras instanceExtras
+	extras.AddFlags(cmd.Flags())
+
+	var monitoring clusterMonitoring
+	monitoring.AddFlags(cmd.Flags())
+
+	var format string
+	cmd.Flags().StringVar(&format, "format", "terraform",
+		"what to generate. types supported: terraform,crossplane")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		clusterName := args[0]
+
+		if format != "terraform" && format != "crossplane" {
+			return fmt.Errorf("--format must be one of terraform or crossplane")
+		}
+		if err := shape.Validate(); err != nil {
+			return err
+		}
+		if err := extras.Load(); err != nil {
+			return err
+		}
+		if err := monitoring.Validate(); err != nil {
+			return err
+		}
+
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		cluster, err := generateUnstructuredClusterYaml(clusterName, strconv.Itoa(pgMajorVersion))
+		if err != nil {
+			return err
+		}
+		cluster.SetNamespace(namespace)
+		if err := shape.modifyIntent(cluster); err != nil {
+			return err
+		}
+		if err := extras.modifyIntent(cluster, cluster); err != nil {
+			return err
+		}
+		if err := monitoring.modifyIntent(cluster); err != nil {
+			return err
+		}
+		if backupsDisabled {
+			cmd.PrintErrln("WARNING: Backups are disabled (only available in CPK v5.7+).")
+			unstructured.RemoveNestedField(cluster.Object, "spec", "backups")
+		}
+
+		var objects []map[string]any
+		if extras.InitSQL != nil {
+			objects = append(objects, extras.ConfigMap(namespace, clusterName))
+		}
+		objects = append(objects, cluster.Object)
+
+		monitoringObjects, err := monitoring.Objects(namespace, clusterName)
+		if err != nil {
+			return err
+		}
+		for _, object := range monitoringObjects {
+			objects = append(objects, object.Object)
+		}
+
+		if format == "crossplane" {
+			b, err := yaml.Marshal(crossplaneResources(objects))
+			if err != nil {
+				return err
+			}
+			cmd.Print(string(b))
+			return nil
+		}
+
+		cmd.Print(terraformManifests(objects))
+		return nil
+	}
+
+	return cmd
+}
+
+// resourceLabel returns a name for object that is unique among the objects of
+// a cluster: its kind and name, lowercase, joined by separator.
+func resourceLabel(object map[string]any, separator string) string {
+	u := unstructured.Unstructured{Object: object}
+	name := strings.ToLower(u.GetKind()) + separator + u.GetName()
+	return strings.NewReplacer("-", separator, ".", separator).Replace(name)
+}
+
+// crossplaneResources returns an entry of "spec.resources" in a Crossplane
+// Composition for each of objects.
+func crossplaneResources(objects []map[string]any) []any {
+	resources := make([]any, 0, len(objects))
+	for _, object := range objects {
+		resources = append(resources, map[string]any{
+			"name": resourceLabel(object, "-"),
+			"base": map[string]any{
+				"apiVersion": "kubernetes.crossplane.io/v1alpha2",
+				"kind":       "Object",
+				"spec": map[string]any{
+					"forProvider": map[string]any{"manifest": object},
+				},
+			},
+		})
+	}
+	return resources
+}
+
+// terraformManifests returns a "kubernetes_manifest" resource for each of
+// objects. A PostgresCluster depends on the ConfigMaps before it.
+func terraformManifests(objects []map[string]any) string {
+	var b strings.Builder
+	var configMaps []string
+	for i, object := range objects {
+		label := resourceLabel(object, "_")
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "resource \"kubernetes_manifest\" %s {\n  manifest = ", hclString(label))
+		writeHCLValue(&b, object, 1)
+		b.WriteString("\n")
+
+		switch (&unstructured.Unstructured{Object: object}).GetKind() {
+		case "ConfigMap":
+			configMaps = append(configMaps, "kubernetes_manifest."+label)
+		case "PostgresCluster":
+			if len(configMaps) > 0 {
+				fmt.Fprintf(&b, "\n  depends_on = [%s]\n", strings.Join(configMaps, ", "))
+			}
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// hclIdentifier matches object keys that need no quotes in HCL.
+var hclIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// hclString returns s as a quoted HCL string. Template sequences are escaped
+// so that Terraform does not interpolate them.
+func hclString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		case (r == '$' || r == '%') && strings.HasPrefix(s[i+1:], "{"):
+			b.WriteRune(r)
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// hclInline returns value on one line, or false when it needs more than one.
+func hclInline(value any) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "null", true
+	case string:
+		return hclString(v), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case int:
+		return strconv.Itoa(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case map[string]any:
+		return "{}", len(v) == 0
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := hclInline(item)
+			if _, isMap := item.(map[string]any); !ok || isMap {
+				return "", false
+			}
+			items = append(items, s)
+		}
+		return "[" + strings.Join(items, ", ") + "]", true
+	}
+	return fmt.Sprintf("%q", fmt.Sprint(value)), true
+}
+
+// writeHCLValue writes value to b as an HCL expression at depth. Like
+// "terraform fmt", it aligns the equals signs of consecutive attributes that
+// fit on one line.
+func writeHCLValue(b *strings.Builder, value any, depth int) {
+	if s, ok := hclInline(value); ok {
+		b.WriteString(s)
+		return
+	}
+	indent := strings.Repeat("  ", depth)
+
+	switch v := value.(type) {
+	case []any:
+		b.WriteString("[\n")
+		for _, item := range v {
+			b.WriteString(indent + "  ")
+			writeHCLValue(b, item, depth+1)
+			b.WriteString(",\n")
+		}
+		b.WriteString(indent + "]")
+
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		labels := make([]string, len(keys))
+		inline := make([]bool, len(keys))
+		for i, key := range keys {
+			labels[i] = key
+			if !hclIdentifier.MatchString(key) {
+				labels[i] = hclString(key)
+			}
+			_, inline[i] = hclInline(v[key])
+		}
+
+		b.WriteString("{\n")
+		for i := 0; i < len(keys); {
+			// Find the run of inline attributes that starts here.
+			end, width := i+1, len(labels[i])
+			for inline[i] && end < len(keys) && inline[end] {
+				width = max(width, len(labels[end]))
+				end++
+			}
+			for j := i; j < end; j++ {
+				if inline[j] {
+					fmt.Fprintf(b, "%s  %-*s = ", indent, width, labels[j])
+				} else {
+					fmt.Fprintf(b, "%s  %s = ", indent, labels[j])
+				}
+				writeHCLValue(b, v[keys[j]], depth+1)
+				b.WriteString("\n")
+			}
+			i = end
+		}
+		b.WriteString(indent + "}")
+	}
+}
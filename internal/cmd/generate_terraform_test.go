@@ -0,0 +1,108 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/crunchydata/postgres-operator-client/internal/testing/cmp"
+)
+
+func TestHCLString(t *testing.T) {
+	assert.Equal(t, hclString(`plain`), `"plain"`)
+	assert.Equal(t, hclString("say \"hi\"\\\n\tnow"), `"say \"hi\"\\\n\tnow"`)
+	assert.Equal(t, hclString("${var} %{if} $5 100%"), `"$${var} %%{if} $5 100%"`)
+	assert.Equal(t, hclString("\x01"), `"\u0001"`)
+}
+
+func TestTerraformManifests(t *testing.T) {
+	extras := instanceExtras{InitSQL: []byte("CREATE TABLE t ();\n")}
+	cluster := map[string]any{
+		"apiVersion": "postgres-operator.crunchydata.com/v1beta1",
+		"kind":       "PostgresCluster",
+		"metadata":   map[string]any{"name": "hippo", "namespace": "prod"},
+		"spec": map[string]any{
+			"postgresVersion": float64(16),
+			"instances": []any{map[string]any{
+				"replicas":            int64(2),
+				"dataVolumeClaimSpec": map[string]any{"accessModes": []any{"ReadWriteOnce"}},
+			}},
+			"monitoring": map[string]any{"pgmonitor": map[string]any{"exporter": map[string]any{}}},
+		},
+	}
+
+	assert.Equal(t, terraformManifests([]map[string]any{extras.ConfigMap("prod", "hippo"), cluster}), `
+resource "kubernetes_manifest" "configmap_hippo_init_sql" {
+  manifest = {
+    apiVersion = "v1"
+    data = {
+      "init.sql" = "CREATE TABLE t ();\n"
+    }
+    kind = "ConfigMap"
+    metadata = {
+      labels = {
+        "postgres-operator.crunchydata.com/cluster" = "hippo"
+      }
+      name      = "hippo-init-sql"
+      namespace = "prod"
+    }
+  }
+}
+
+resource "kubernetes_manifest" "postgrescluster_hippo" {
+  manifest = {
+    apiVersion = "postgres-operator.crunchydata.com/v1beta1"
+    kind       = "PostgresCluster"
+    metadata = {
+      name      = "hippo"
+      namespace = "prod"
+    }
+    spec = {
+      instances = [
+        {
+          dataVolumeClaimSpec = {
+            accessModes = ["ReadWriteOnce"]
+          }
+          replicas = 2
+        },
+      ]
+      monitoring = {
+        pgmonitor = {
+          exporter = {}
+        }
+      }
+      postgresVersion = 16
+    }
+  }
+
+  depends_on = [kubernetes_manifest.configmap_hippo_init_sql]
+}
+`[1:])
+}
+
+func TestCrossplaneResources(t *testing.T) {
+	resources := crossplaneResources([]map[string]any{{
+		"apiVersion": "postgres-operator.crunchydata.com/v1beta1",
+		"kind":       "PostgresCluster",
+		"metadata":   map[string]any{"name": "hippo", "namespace": "prod"},
+	}})
+
+	assert.Assert(t, cmp.MarshalMatches(resources, `
+- base:
+    apiVersion: kubernetes.crossplane.io/v1alpha2
+    kind: Object
+    spec:
+      forProvider:
+        manifest:
+          apiVersion: postgres-operator.crunchydata.com/v1beta1
+          kind: PostgresCluster
+          metadata:
+            name: hippo
+            namespace: prod
+  name: postgrescluster-hippo
+	`))
+}
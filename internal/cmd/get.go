@@ -0,0 +1,25 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// newGetCommand returns the get subcommand of the PGO plugin.
+// Subcommands of get list settings of many PostgresClusters at once.
+func newGetCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get",
+		Short: "List settings across PostgresClusters",
+		Long:  "List settings across PostgresClusters",
+	}
+
+	cmd.AddCommand(newGetBackupSchedulesCommand(config))
+
+	return cmd
+}
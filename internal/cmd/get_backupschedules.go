@@ -0,0 +1,434 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newGetBackupSchedulesCommand returns the backupschedules subcommand of the
+// get command. It lists the pgBackRest schedules of every PostgresCluster.
+func newGetBackupSchedulesCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "backupschedules",
+		Aliases: []string{"backupschedule"},
+		Short:   "List the backup schedules of PostgresClusters",
+		Long: `List the pgBackRest backup schedules of the PostgresClusters in a namespace or,
+with "--all-namespaces", in every namespace. Each schedule is shown with its
+repository, type, cron expression, when its CronJob last ran successfully, and
+when it runs next.
+
+Next runs are computed in UTC, or in the time zone of the CronJob when it has
+one. Schedules stopped by "pgo pause backupschedule" show "paused" instead.
+Clusters without a full backup schedule are listed below the table.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    cronjobs                                            [list]
+    postgresclusters.postgres-operator.crunchydata.com  [list]
+
+    Note: "--all-namespaces" requires these permissions in every namespace.
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# List the backup schedules of clusters in the current namespace
+pgo get backupschedules
+
+# List the backup schedules of clusters in every namespace
+pgo get backupschedules --all-namespaces
+
+### Example output
+NAMESPACE  CLUSTER  REPO   TYPE  SCHEDULE     LAST SUCCESS          NEXT RUN
+postgres   hippo    repo1  full  0 1 * * 0    2024-04-28T01:01:10Z  2024-05-05T01:00:00Z
+postgres   hippo    repo1  incr  0 1 * * 1-6  2024-05-01T01:00:48Z  2024-05-02T01:00:00Z
+postgres   rhino    -      -     -            -                     -
+
+WARNING: no full backup is scheduled for postgres/rhino`)
+
+	var allNamespaces bool
+	cmd.Flags().BoolVarP(&allNamespaces, "all-namespaces", "A", false,
+		"list the schedules of clusters in every namespace")
+
+	cmd.Args = cobra.NoArgs
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		clientset, err := kubernetes.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		_, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace := corev1.NamespaceAll
+		if !allNamespaces {
+			if namespace, err = config.Namespace(); err != nil {
+				return err
+			}
+		}
+
+		clusters, err := client.Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		if len(clusters.Items) == 0 {
+			cmd.Println("No PostgresClusters found")
+			return nil
+		}
+		cronJobs, err := clientset.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.LabelCluster,
+		})
+		if err != nil {
+			return err
+		}
+
+		rows, unscheduled := backupSchedules(clusters.Items, cronJobs.Items, time.Now())
+		if err := printBackupSchedules(cmd, rows); err != nil {
+			return err
+		}
+		if len(unscheduled) > 0 {
+			cmd.Println()
+		}
+		for _, cluster := range unscheduled {
+			cmd.PrintErrf("WARNING: no full backup is scheduled for %s\n", cluster)
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// backupSchedule is one pgBackRest schedule of a PostgresCluster.
+type backupSchedule struct {
+	Namespace, Cluster string
+	Repo, Type         string
+	Schedule           string
+
+	// LastSuccess and NextRun are zero when they are not known.
+	LastSuccess, NextRun time.Time
+
+	// Problem explains why NextRun is not known, if it can be.
+	Problem string
+}
+
+// backupSchedules returns the schedules of clusters sorted by namespace,
+// cluster, and repository. Clusters without schedules have one row with only
+// their names. It also returns the clusters that have no full backup scheduled.
+func backupSchedules(
+	clusters []unstructured.Unstructured, cronJobs []batchv1.CronJob, now time.Time,
+) ([]backupSchedule, []string) {
+	jobs := map[string]*batchv1.CronJob{}
+	for i := range cronJobs {
+		jobs[cronJobs[i].GetNamespace()+"/"+cronJobs[i].GetName()] = &cronJobs[i]
+	}
+
+	sort.SliceStable(clusters, func(i, j int) bool {
+		if clusters[i].GetNamespace() != clusters[j].GetNamespace() {
+			return clusters[i].GetNamespace() < clusters[j].GetNamespace()
+		}
+		return clusters[i].GetName() < clusters[j].GetName()
+	})
+
+	// PGO names each CronJob after the cluster, repository, and type.
+	kinds := []struct{ field, suffix string }{
+		{"full", "full"}, {"differential", "diff"}, {"incremental", "incr"},
+	}
+
+	var rows []backupSchedule
+	var unscheduled []string
+	for _, cluster := range clusters {
+		var found, full bool
+		repos, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "backups", "pgbackrest", "repos")
+		for _, entry := range repos {
+			repo, ok := entry.(map[string]any)
+			if !ok {
+				continue
+			}
+			repoName, _, _ := unstructured.NestedString(repo, "name")
+
+			for _, kind := range kinds {
+				expression, _, _ := unstructured.NestedString(repo, "schedules", kind.field)
+				if expression == "" {
+					continue
+				}
+				found, full = true, full || kind.field == "full"
+
+				row := backupSchedule{
+					Namespace: cluster.GetNamespace(), Cluster: cluster.GetName(),
+					Repo: repoName, Type: kind.suffix, Schedule: expression,
+				}
+				job := jobs[cluster.GetNamespace()+"/"+cluster.GetName()+"-"+repoName+"-"+kind.suffix]
+				if job != nil && job.Status.LastSuccessfulTime != nil {
+					row.LastSuccess = job.Status.LastSuccessfulTime.Time
+				}
+
+				location := time.UTC
+				if job != nil && job.Spec.TimeZone != nil {
+					if zone, err := time.LoadLocation(*job.Spec.TimeZone); err == nil {
+						location = zone
+					}
+				}
+				switch schedule, err := parseCronSchedule(expression, location); {
+				case err != nil:
+					row.Problem = err.Error()
+				case job != nil && job.Spec.Suspend != nil && *job.Spec.Suspend:
+					row.Problem = "suspended"
+				default:
+					row.NextRun = schedule.Next(now)
+				}
+				rows = append(rows, row)
+			}
+		}
+
+		// Paused schedules have no CronJob until they are resumed.
+		paused, _ := pausedBackupSchedules(&cluster)
+		for _, repoName := range (backupScheduleSelector{}).matches(paused) {
+			for _, kind := range kinds {
+				if expression, ok := paused[repoName][kind.field]; ok {
+					found = true
+					rows = append(rows, backupSchedule{
+						Namespace: cluster.GetNamespace(), Cluster: cluster.GetName(),
+						Repo: repoName, Type: kind.suffix, Schedule: expression, Problem: "paused",
+					})
+				}
+			}
+		}
+
+		if !found {
+			rows = append(rows, backupSchedule{
+				Namespace: cluster.GetNamespace(), Cluster: cluster.GetName(),
+			})
+		}
+		if !full {
+			unscheduled = append(unscheduled, cluster.GetNamespace()+"/"+cluster.GetName())
+		}
+	}
+	return rows, unscheduled
+}
+
+// printBackupSchedules prints rows as a table.
+func printBackupSchedules(cmd *cobra.Command, rows []backupSchedule) error {
+	orDash := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.UTC().Format("2006-01-02T15:04:05Z")
+	}
+
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "NAMESPACE\tCLUSTER\tREPO\tTYPE\tSCHEDULE\tLAST SUCCESS\tNEXT RUN")
+	for _, row := range rows {
+		next := formatTime(row.NextRun)
+		if row.Problem != "" {
+			next = row.Problem
+		}
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			row.Namespace, row.Cluster, orDash(row.Repo), orDash(row.Type),
+			orDash(row.Schedule), formatTime(row.LastSuccess), next)
+	}
+	return writer.Flush()
+}
+
+// cronSchedule is a cron expression in the syntax of Kubernetes CronJobs. Each
+// field is a set of bits, one for each value that matches.
+// - https://kubernetes.io/docs/concepts/workloads/controllers/cron-jobs/#schedule-syntax
+type cronSchedule struct {
+	minute, hour, day, month, weekday uint64
+
+	// anyDay and anyWeekday are set when that field is "*". When neither is,
+	// a time matches when either field matches.
+	anyDay, anyWeekday bool
+
+	location *time.Location
+}
+
+// parseCronSchedule parses expression and returns a schedule that runs in
+// location, unless the expression sets its own "CRON_TZ=" or "TZ=".
+func parseCronSchedule(expression string, location *time.Location) (cronSchedule, error) {
+	schedule := cronSchedule{location: location}
+	fields := strings.Fields(expression)
+	if len(fields) > 0 {
+		if zone, ok := strings.CutPrefix(fields[0], "CRON_TZ="); ok {
+			fields[0] = "TZ=" + zone
+		}
+		if zone, ok := strings.CutPrefix(fields[0], "TZ="); ok {
+			loaded, err := time.LoadLocation(zone)
+			if err != nil {
+				return schedule, fmt.Errorf("invalid schedule %q: %w", expression, err)
+			}
+			schedule.location, fields = loaded, fields[1:]
+		}
+	}
+	if len(fields) == 1 {
+		macro, ok := map[string]string{
+			"@yearly": "0 0 1 1 *", "@annually": "0 0 1 1 *", "@monthly": "0 0 1 * *",
+			"@weekly": "0 0 * * 0", "@daily": "0 0 * * *", "@midnight": "0 0 * * *",
+			"@hourly": "0 * * * *",
+		}[fields[0]]
+		if !ok {
+			return schedule, fmt.Errorf("invalid schedule %q", expression)
+		}
+		fields = strings.Fields(macro)
+	}
+	if len(fields) != 5 {
+		return schedule, fmt.Errorf("invalid schedule %q: expected 5 fields", expression)
+	}
+
+	months := map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}
+	weekdays := map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+
+	var err error
+	for _, field := range []struct {
+		bits     *uint64
+		min, max int
+		names    map[string]int
+	}{
+		{&schedule.minute, 0, 59, nil},
+		{&schedule.hour, 0, 23, nil},
+		{&schedule.day, 1, 31, nil},
+		{&schedule.month, 1, 12, months},
+		{&schedule.weekday, 0, 7, weekdays},
+	} {
+		if *field.bits, err = parseCronField(fields[0], field.min, field.max, field.names); err != nil {
+			return schedule, fmt.Errorf("invalid schedule %q: %w", expression, err)
+		}
+		fields = fields[1:]
+	}
+
+	// Sunday is both 0 and 7.
+	if schedule.weekday&(1<<7) != 0 {
+		schedule.weekday |= 1
+	}
+	schedule.anyDay = schedule.day == cronBits(1, 31, 1)
+	schedule.anyWeekday = schedule.weekday&cronBits(0, 6, 1) == cronBits(0, 6, 1)
+	return schedule, nil
+}
+
+// parseCronField returns the values in field between min and max as bits.
+func parseCronField(field string, min, max int, names map[string]int) (uint64, error) {
+	value := func(s string) (int, error) {
+		if n, ok := names[strings.ToLower(s)]; ok {
+			return n, nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < min || n > max {
+			return 0, fmt.Errorf("%q is not between %d and %d", s, min, max)
+		}
+		return n, nil
+	}
+
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		span, stepText, stepped := strings.Cut(part, "/")
+		step := 1
+		if stepped {
+			var err error
+			if step, err = strconv.Atoi(stepText); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepText)
+			}
+		}
+
+		low, high := min, max
+		if span != "*" && span != "?" {
+			first, last, ranged := strings.Cut(span, "-")
+			var err error
+			if low, err = value(first); err != nil {
+				return 0, err
+			}
+			high = low
+			if ranged {
+				if high, err = value(last); err != nil {
+					return 0, err
+				}
+			} else if stepped {
+				high = max
+			}
+			if high < low {
+				return 0, fmt.Errorf("invalid range %q", span)
+			}
+		}
+		bits |= cronBits(low, high, step)
+	}
+	return bits, nil
+}
+
+// cronBits returns the bits from low to high, every step.
+func cronBits(low, high, step int) uint64 {
+	var bits uint64
+	for i := low; i <= high; i += step {
+		bits |= 1 << i
+	}
+	return bits
+}
+
+// Next returns the first time after t that schedule matches, or the zero time
+// when it matches nothing in the next five years.
+func (schedule cronSchedule) Next(t time.Time) time.Time {
+	has := func(bits uint64, n int) bool { return bits&(1<<n) != 0 }
+
+	t = t.In(schedule.location).Truncate(time.Minute).Add(time.Minute)
+	for end := t.AddDate(5, 0, 0); t.Before(end); {
+		year, month, day := t.Date()
+
+		if !has(schedule.month, int(month)) {
+			t = time.Date(year, month+1, 1, 0, 0, 0, 0, schedule.location)
+			continue
+		}
+
+		dayOfMonth := has(schedule.day, day)
+		dayOfWeek := has(schedule.weekday, int(t.Weekday()))
+		matches := dayOfMonth && dayOfWeek
+		if !schedule.anyDay && !schedule.anyWeekday {
+			matches = dayOfMonth || dayOfWeek
+		}
+		if !matches {
+			t = time.Date(year, month, day+1, 0, 0, 0, 0, schedule.location)
+			continue
+		}
+
+		if !has(schedule.hour, t.Hour()) {
+			t = time.Date(year, month, day, t.Hour()+1, 0, 0, 0, schedule.location)
+			continue
+		}
+		if !has(schedule.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,108 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	// Wednesday, May 1, 2024
+	now := time.Date(2024, 5, 1, 14, 22, 37, 0, time.UTC)
+
+	for _, tt := range []struct {
+		expression, next string
+	}{
+		{"0 1 * * *", "2024-05-02T01:00:00Z"},
+		{"*/15 * * * *", "2024-05-01T14:30:00Z"},
+		{"30 14-16 * * *", "2024-05-01T14:30:00Z"},
+		{"0 1 * * 0", "2024-05-05T01:00:00Z"},
+		{"0 1 * * 7", "2024-05-05T01:00:00Z"},
+		{"0 1 * * sun", "2024-05-05T01:00:00Z"},
+		{"0 1 * * 1-6", "2024-05-02T01:00:00Z"},
+		{"0 0 15 * *", "2024-05-15T00:00:00Z"},
+		{"0 0 15 * 6", "2024-05-04T00:00:00Z"},
+		{"0 0 1 jan *", "2025-01-01T00:00:00Z"},
+		{"0 0 29 2 *", "2028-02-29T00:00:00Z"},
+		{"@hourly", "2024-05-01T15:00:00Z"},
+		{"@weekly", "2024-05-05T00:00:00Z"},
+		{"CRON_TZ=America/New_York 0 1 * * *", "2024-05-02T05:00:00Z"},
+		{"0 0 31 2 *", "0001-01-01T00:00:00Z"},
+	} {
+		schedule, err := parseCronSchedule(tt.expression, time.UTC)
+		assert.NilError(t, err, tt.expression)
+		assert.Equal(t, schedule.Next(now).UTC().Format(time.RFC3339), tt.next, tt.expression)
+	}
+
+	for _, expression := range []string{"", "0 1 * *", "60 * * * *", "0 1 * * mon-", "*/0 * * * *", "@often"} {
+		_, err := parseCronSchedule(expression, time.UTC)
+		assert.ErrorContains(t, err, "invalid", "%q", expression)
+	}
+}
+
+func TestBackupSchedules(t *testing.T) {
+	now := time.Date(2024, 5, 1, 14, 22, 37, 0, time.UTC)
+	cluster := func(namespace, name string, repos ...any) unstructured.Unstructured {
+		cluster := unstructured.Unstructured{Object: map[string]any{}}
+		cluster.SetNamespace(namespace)
+		cluster.SetName(name)
+		assert.NilError(t, unstructured.SetNestedSlice(cluster.Object, repos,
+			"spec", "backups", "pgbackrest", "repos"))
+		return cluster
+	}
+	cronJob := func(name string, success time.Time) batchv1.CronJob {
+		job := batchv1.CronJob{}
+		job.Namespace, job.Name = "postgres", name
+		job.Status.LastSuccessfulTime = &metav1.Time{Time: success}
+		return job
+	}
+
+	suspended := cronJob("hippo-repo2-full", now.Add(-72*time.Hour))
+	suspended.Spec.Suspend = new(bool)
+	*suspended.Spec.Suspend = true
+
+	paused := cluster("postgres", "rhino", map[string]any{"name": "repo1"})
+	paused.SetAnnotations(map[string]string{
+		"postgres-operator.crunchydata.com/pgo-paused-backup-schedules": `{"repo1":{"full":"0 3 * * 0"}}`,
+	})
+
+	rows, unscheduled := backupSchedules([]unstructured.Unstructured{
+		paused,
+		cluster("postgres", "hippo",
+			map[string]any{"name": "repo1", "schedules": map[string]any{
+				"full": "0 1 * * 0", "incremental": "0 1 * * 1-6"}},
+			map[string]any{"name": "repo2", "schedules": map[string]any{
+				"full": "0 2 * * *", "differential": "bad"}}),
+		cluster("other", "zebra", map[string]any{"name": "repo1", "schedules": map[string]any{
+			"incremental": "@daily"}}),
+	}, []batchv1.CronJob{
+		cronJob("hippo-repo1-full", time.Date(2024, 4, 28, 1, 1, 10, 0, time.UTC)),
+		cronJob("hippo-repo1-incr", time.Date(2024, 5, 1, 1, 0, 48, 0, time.UTC)),
+		suspended,
+	}, now)
+
+	assert.DeepEqual(t, unscheduled, []string{"other/zebra", "postgres/rhino"})
+
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+	assert.NilError(t, printBackupSchedules(cmd, rows))
+	assert.Equal(t, out.String(), `NAMESPACE  CLUSTER  REPO   TYPE  SCHEDULE     LAST SUCCESS          NEXT RUN
+other      zebra    repo1  incr  @daily       -                     2024-05-02T00:00:00Z
+postgres   hippo    repo1  full  0 1 * * 0    2024-04-28T01:01:10Z  2024-05-05T01:00:00Z
+postgres   hippo    repo1  incr  0 1 * * 1-6  2024-05-01T01:00:48Z  2024-05-02T01:00:00Z
+postgres   hippo    repo2  full  0 2 * * *    2024-04-28T14:22:37Z  suspended
+postgres   hippo    repo2  diff  bad          -                     invalid schedule "bad"
+postgres   rhino    repo1  full  0 3 * * 0    -                     paused
+`)
+}
@@ -0,0 +1,338 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// historyLimit is the number of entries kept in the history of a cluster.
+const historyLimit = 100
+
+// historyKey is the key of the entries in the history ConfigMap.
+const historyKey = "history.json"
+
+// ErrCancelled is returned by commands when the user declines to continue at
+// a prompt. Nothing was changed, so the PGO plugin exits successfully.
+var ErrCancelled = errors.New("cancelled")
+
+// historyEntry is one mutating command that ran against a cluster.
+type historyEntry struct {
+	Time time.Time `json:"time"`
+
+	// KubeUser is the user in the kubeconfig; LocalUser is the account and
+	// host that ran the command.
+	KubeUser  string `json:"kubeUser,omitempty"`
+	LocalUser string `json:"localUser,omitempty"`
+
+	Command string `json:"command"`
+	Result  string `json:"result"`
+	Error   string `json:"error,omitempty"`
+}
+
+// historyConfigMaps is the part of a ConfigMap client that keeps history.
+type historyConfigMaps interface {
+	Get(context.Context, string, metav1.GetOptions) (*corev1.ConfigMap, error)
+	Create(context.Context, *corev1.ConfigMap, metav1.CreateOptions) (*corev1.ConfigMap, error)
+	Update(context.Context, *corev1.ConfigMap, metav1.UpdateOptions) (*corev1.ConfigMap, error)
+}
+
+// historyConfigMapName returns the name of the ConfigMap that holds the
+// history of cluster.
+func historyConfigMapName(cluster string) string { return cluster + "-pgo-history" }
+
+// newHistoryCommand returns the history subcommand of the PGO plugin. It
+// prints the mutating commands that were run against a cluster.
+func newHistoryCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history CLUSTER_NAME",
+		Short: "Show the commands that changed a PostgresCluster",
+		Long: fmt.Sprintf(`Show who ran which commands that changed a PostgresCluster, when, and whether
+they succeeded.
+
+Every command that changes a cluster, like backup or restore, adds an entry to
+a ConfigMap named CLUSTER_NAME-pgo-history after it runs, so the history is
+shared by everyone who uses this plugin against the cluster. Commands declined
+at their prompt are "cancelled". The latest %d
+entries are kept. Commands that only record a change with "--record" are not
+added. When recording the history fails, the command still succeeds.
+
+### RBAC Requirements
+    Resources   Verbs
+    ---------   -----
+    configmaps  [get]
+
+    Note: Commands that change a cluster need to create and update
+    configmaps to add to its history. Without that, no entry is added.
+
+### Usage`, historyLimit),
+	}
+
+	cmd.Example = internal.FormatExample(`# Show the history of the 'hippo' postgrescluster
+pgo history hippo
+
+### Example output
+TIME                  WHO                     COMMAND                                      RESULT
+2024-05-01T14:02:11Z  alice@laptop (admin)    backup hippo --repoName=repo1                success
+2024-05-01T15:30:45Z  bob@bastion (oidc:bob)  set pgbouncer hippo --pool-mode=transaction  success
+2024-05-02T09:12:03Z  bob@bastion (oidc:bob)  restore hippo --repoName=repo2               failure: no backup found in repo2`)
+
+	var limit int
+	cmd.Flags().IntVar(&limit, "limit", 20, "number of entries to show; 0 shows all")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		clientset, err := kubernetes.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		configmap, err := clientset.CoreV1().ConfigMaps(namespace).Get(ctx,
+			historyConfigMapName(args[0]), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			cmd.Printf("No history found for cluster %s\n", args[0])
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		entries, err := parseHistory(configmap)
+		if err != nil {
+			return err
+		}
+		if limit > 0 && len(entries) > limit {
+			entries = entries[len(entries)-limit:]
+		}
+		return printHistory(cmd, entries)
+	}
+
+	return cmd
+}
+
+// printHistory prints entries oldest first.
+func printHistory(cmd *cobra.Command, entries []historyEntry) error {
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "TIME\tWHO\tCOMMAND\tRESULT")
+	for _, entry := range entries {
+		who := entry.LocalUser
+		if entry.KubeUser != "" {
+			who = strings.TrimSpace(who + " (" + entry.KubeUser + ")")
+		}
+		result := entry.Result
+		if entry.Error != "" {
+			result += ": " + entry.Error
+		}
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n",
+			entry.Time.UTC().Format(time.RFC3339), who, entry.Command, result)
+	}
+	return writer.Flush()
+}
+
+// parseHistory returns the entries in configmap, oldest first.
+func parseHistory(configmap *corev1.ConfigMap) ([]historyEntry, error) {
+	var entries []historyEntry
+	if data := configmap.Data[historyKey]; data != "" {
+		if err := json.Unmarshal([]byte(data), &entries); err != nil {
+			return nil, fmt.Errorf("unable to read the history in configmaps/%s: %w",
+				configmap.GetName(), err)
+		}
+	}
+	return entries, nil
+}
+
+// appendHistory adds entry to the history of cluster, keeping the latest
+// [historyLimit] entries. It retries when someone else changes the history
+// at the same time.
+func appendHistory(ctx context.Context, configmaps historyConfigMaps, cluster string, entry historyEntry) error {
+	name := historyConfigMapName(cluster)
+
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		if err = tryAppendHistory(ctx, configmaps, name, cluster, entry); !apierrors.IsConflict(err) &&
+			!apierrors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// tryAppendHistory reads the ConfigMap called name, adds entry, and writes it
+// back. It returns a conflict when the ConfigMap changed in the meantime.
+func tryAppendHistory(
+	ctx context.Context, configmaps historyConfigMaps, name, cluster string, entry historyEntry,
+) error {
+	configmap, err := configmaps.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		configmap = &corev1.ConfigMap{}
+		configmap.Name = name
+		configmap.Labels = map[string]string{util.LabelCluster: cluster}
+	} else if err != nil {
+		return err
+	}
+
+	entries, err := parseHistory(configmap)
+	if err != nil {
+		// Start over rather than fail every later command.
+		entries = nil
+	}
+	entries = append(entries, entry)
+	if len(entries) > historyLimit {
+		entries = entries[len(entries)-historyLimit:]
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	if configmap.Data == nil {
+		configmap.Data = map[string]string{}
+	}
+	configmap.Data[historyKey] = string(data)
+
+	if configmap.ResourceVersion == "" {
+		_, err = configmaps.Create(ctx, configmap, metav1.CreateOptions{})
+	} else {
+		_, err = configmaps.Update(ctx, configmap, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+// historyCommandLine returns the command at path with its arguments and the
+// flags that were set on it. Flags of the root command, like credentials, are
+// left out.
+func historyCommandLine(cmd *cobra.Command, path string, args []string) string {
+	parts := append([]string{path}, args...)
+	local := cmd.LocalFlags()
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if local.Lookup(flag.Name) != nil {
+			parts = append(parts, "--"+flag.Name+"="+flag.Value.String())
+		}
+	})
+	return strings.Join(parts, " ")
+}
+
+// localUser returns the name of the account and host running this process.
+func localUser() string {
+	var name string
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		name += "@" + host
+	}
+	return name
+}
+
+// kubeUser returns the name of the user of the current kubeconfig context.
+func kubeUser(config *internal.Config) string {
+	if config.AuthInfoName != nil && *config.AuthInfoName != "" {
+		return *config.AuthInfoName
+	}
+	raw, err := config.ToRawKubeConfigLoader().RawConfig()
+	if err != nil {
+		return ""
+	}
+	current := raw.CurrentContext
+	if config.Context != nil && *config.Context != "" {
+		current = *config.Context
+	}
+	if kubeContext, ok := raw.Contexts[current]; ok {
+		return kubeContext.AuthInfo
+	}
+	return ""
+}
+
+// isDryRun reports whether the "--dry-run" flag of cmd, if any, keeps it from
+// changing anything.
+func isDryRun(cmd *cobra.Command) bool {
+	flag := cmd.Flags().Lookup("dry-run")
+	return flag != nil && flag.Value.String() != "false" && flag.Value.String() != "none"
+}
+
+// addHistory changes the mutating commands of root that act on one cluster to
+// add an entry to the history of that cluster after they run. Commands of
+// [mutatingFlags] add one only when given those flags.
+func addHistory(root *cobra.Command, config *internal.Config) {
+	for _, path := range mutatingPaths() {
+		command, _, err := root.Find(strings.Fields(path))
+		if err != nil || command.RunE == nil || !strings.Contains(command.Use, "CLUSTER_NAME") {
+			continue
+		}
+
+		run, path := command.RunE, path
+		command.RunE = func(cmd *cobra.Command, args []string) error {
+			err := run(cmd, args)
+			if _, ok := mutatingFlag(cmd, path); !ok || len(args) == 0 ||
+				config.Record.Enabled() || isDryRun(cmd) {
+				return err
+			}
+
+			entry := historyEntry{
+				Time:      time.Now().UTC().Truncate(time.Second),
+				KubeUser:  kubeUser(config),
+				LocalUser: localUser(),
+				Command:   historyCommandLine(cmd, path, args),
+				Result:    "success",
+			}
+			switch {
+			case errors.Is(err, ErrCancelled):
+				entry.Result = "cancelled"
+			case err != nil:
+				entry.Result, entry.Error = "failure", err.Error()
+			}
+
+			// Failing to record the history does not fail the command. Users
+			// without permission to write ConfigMaps have no history.
+			if historyErr := func() error {
+				rest, err := config.ToRESTConfig()
+				if err != nil {
+					return err
+				}
+				clientset, err := kubernetes.NewForConfig(rest)
+				if err != nil {
+					return err
+				}
+				namespace, err := config.Namespace()
+				if err != nil {
+					return err
+				}
+				return appendHistory(context.Background(),
+					clientset.CoreV1().ConfigMaps(namespace), args[0], entry)
+			}(); historyErr != nil && !apierrors.IsForbidden(historyErr) {
+				cmd.PrintErrf("WARNING: unable to record history: %v\n", historyErr)
+			}
+			return err
+		}
+	}
+}
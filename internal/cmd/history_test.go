@@ -0,0 +1,195 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// memoryConfigMaps keeps one ConfigMap and can fail the next writes with a
+// conflict.
+type memoryConfigMaps struct {
+	stored    *corev1.ConfigMap
+	conflicts int
+	version   int
+}
+
+func (m *memoryConfigMaps) Get(_ context.Context, name string, _ metav1.GetOptions) (*corev1.ConfigMap, error) {
+	if m.stored == nil {
+		return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, name)
+	}
+	return m.stored.DeepCopy(), nil
+}
+
+func (m *memoryConfigMaps) write(configmap *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+	if m.conflicts > 0 {
+		m.conflicts--
+		return nil, apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"},
+			configmap.Name, fmt.Errorf("changed"))
+	}
+	m.version++
+	m.stored = configmap.DeepCopy()
+	m.stored.ResourceVersion = fmt.Sprint(m.version)
+	return m.stored, nil
+}
+
+func (m *memoryConfigMaps) Create(_ context.Context, configmap *corev1.ConfigMap, _ metav1.CreateOptions) (*corev1.ConfigMap, error) {
+	return m.write(configmap)
+}
+
+func (m *memoryConfigMaps) Update(_ context.Context, configmap *corev1.ConfigMap, _ metav1.UpdateOptions) (*corev1.ConfigMap, error) {
+	return m.write(configmap)
+}
+
+func TestAppendHistory(t *testing.T) {
+	ctx := context.Background()
+	configmaps := &memoryConfigMaps{}
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.NilError(t, appendHistory(ctx, configmaps, "hippo", historyEntry{Time: start, Command: "backup hippo"}))
+	assert.Equal(t, configmaps.stored.Name, "hippo-pgo-history")
+	assert.Equal(t, configmaps.stored.Labels["postgres-operator.crunchydata.com/cluster"], "hippo")
+
+	configmaps.conflicts = 2
+	assert.NilError(t, appendHistory(ctx, configmaps, "hippo", historyEntry{Time: start, Command: "stop hippo"}))
+
+	entries, err := parseHistory(configmaps.stored)
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 2)
+	assert.Equal(t, entries[1].Command, "stop hippo")
+
+	configmaps.conflicts = 3
+	assert.Assert(t, apierrors.IsConflict(appendHistory(ctx, configmaps, "hippo", historyEntry{})))
+
+	for i := 0; i < historyLimit+5; i++ {
+		assert.NilError(t, appendHistory(ctx, configmaps, "hippo", historyEntry{
+			Time: start, Command: fmt.Sprint("start hippo ", i),
+		}))
+	}
+	entries, err = parseHistory(configmaps.stored)
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), historyLimit)
+	assert.Equal(t, entries[len(entries)-1].Command, fmt.Sprint("start hippo ", historyLimit+4))
+
+	configmaps.stored.Data[historyKey] = "{"
+	_, err = parseHistory(configmaps.stored)
+	assert.ErrorContains(t, err, "configmaps/hippo-pgo-history")
+	assert.NilError(t, appendHistory(ctx, configmaps, "hippo", historyEntry{Command: "fresh"}))
+	entries, err = parseHistory(configmaps.stored)
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 1)
+}
+
+func TestHistoryCommandLine(t *testing.T) {
+	root := &cobra.Command{Use: "pgo"}
+	root.PersistentFlags().String("token", "", "")
+	cmd := &cobra.Command{Use: "backup", Run: func(*cobra.Command, []string) {}}
+	cmd.Flags().String("repoName", "", "")
+	cmd.Flags().String("options", "", "")
+	root.AddCommand(cmd)
+
+	root.SetArgs([]string{"backup", "hippo", "--repoName=repo1", "--token=secret"})
+	assert.NilError(t, root.Execute())
+	assert.Equal(t, historyCommandLine(cmd, "backup", []string{"hippo"}), "backup hippo --repoName=repo1")
+}
+
+func TestIsDryRun(t *testing.T) {
+	cmd := &cobra.Command{}
+	assert.Assert(t, !isDryRun(cmd))
+
+	cmd.Flags().String("dry-run", "none", "")
+	assert.Assert(t, !isDryRun(cmd))
+	assert.NilError(t, cmd.Flags().Set("dry-run", "client"))
+	assert.Assert(t, isDryRun(cmd))
+
+	cmd = &cobra.Command{}
+	cmd.Flags().Bool("dry-run", false, "")
+	assert.Assert(t, !isDryRun(cmd))
+	assert.NilError(t, cmd.Flags().Set("dry-run", "true"))
+	assert.Assert(t, isDryRun(cmd))
+}
+
+func TestPrintHistory(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	assert.NilError(t, printHistory(cmd, []historyEntry{
+		{Time: time.Date(2024, 5, 1, 14, 2, 11, 0, time.UTC), LocalUser: "alice@laptop",
+			KubeUser: "admin", Command: "backup hippo", Result: "success"},
+		{Time: time.Date(2024, 5, 2, 9, 12, 3, 0, time.UTC), KubeUser: "bob",
+			Command: "restore hippo", Result: "failure", Error: "no backup"},
+	}))
+	assert.Equal(t, out.String(), strings.Join([]string{
+		"TIME                  WHO                   COMMAND        RESULT",
+		"2024-05-01T14:02:11Z  alice@laptop (admin)  backup hippo   success",
+		"2024-05-02T09:12:03Z  (bob)                 restore hippo  failure: no backup",
+		"",
+	}, "\n"))
+}
+
+func TestAddHistoryCancelled(t *testing.T) {
+	// The API server keeps the ConfigMaps that are created.
+	var created []*corev1.ConfigMap
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost && r.URL.Path == "/api/v1/namespaces/zoo/configmaps" {
+			var configmap corev1.ConfigMap
+			assert.NilError(t, json.NewDecoder(r.Body).Decode(&configmap))
+			created = append(created, &configmap)
+			w.WriteHeader(http.StatusCreated)
+			assert.NilError(t, json.NewEncoder(w).Encode(&configmap))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`))
+	}))
+	t.Cleanup(server.Close)
+
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	assert.NilError(t, os.WriteFile(kubeconfig, []byte(`
+apiVersion: v1
+kind: Config
+clusters: [{name: fake, cluster: {server: `+server.URL+`}}]
+users: [{name: alice, user: {}}]
+contexts: [{name: fake, context: {cluster: fake, user: alice, namespace: zoo}}]
+current-context: fake
+`), 0o600))
+	t.Setenv("KUBECONFIG", kubeconfig)
+
+	var stderr bytes.Buffer
+	root := NewPGOCommand(strings.NewReader("no\n"), io.Discard, &stderr)
+	root.SetArgs([]string{"stop", "hippo"})
+	assert.Assert(t, errors.Is(root.Execute(), ErrCancelled))
+	assert.Assert(t, strings.HasSuffix(stderr.String(), "(yes/no): "), "got %q", stderr.String())
+
+	assert.Equal(t, len(created), 1)
+	entries, err := parseHistory(created[0])
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 1)
+	assert.Equal(t, entries[0].Command, "stop hippo")
+	assert.Equal(t, entries[0].KubeUser, "alice")
+	assert.Equal(t, entries[0].Result, "cancelled")
+	assert.Equal(t, entries[0].Error, "")
+}
@@ -0,0 +1,338 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// podTemplateFields are the fields of a PostgresCluster that PGO copies into
+// the Pod template of every instance. Changing them rolls out every instance.
+var podTemplateFields = []string{"config", "image", "imagePullPolicy", "imagePullSecrets", "metadata"}
+
+// instanceSetOtherFields are the fields of an instance set that PGO does not
+// copy into the Pod template of its instances.
+var instanceSetOtherFields = map[string]bool{
+	"dataVolumeClaimSpec": true,
+	"minAvailable":        true,
+	"name":                true,
+	"replicas":            true,
+	"walVolumeClaimSpec":  true,
+}
+
+// podImpact is what a change to a PostgresCluster does to one instance Pod.
+// Pod is empty for instances the change adds or removes without choosing.
+type podImpact struct {
+	Pod, Set, Role string
+	Impact         string
+}
+
+// changeImpact is the disruption a change to a PostgresCluster causes once
+// PGO reconciles it.
+type changeImpact struct {
+	Pods []podImpact
+
+	// Switchover is true when Patroni moves the primary to a replica before
+	// the primary restarts or is removed.
+	Switchover bool
+
+	// Outage is true when the primary restarts or is removed and no ready
+	// replica can take over.
+	Outage bool
+
+	// Resync is the number of instances that copy the whole database, and
+	// DataBytes is its size or zero when that is not known.
+	Resync    int
+	DataBytes int64
+}
+
+// Disruptive returns whether the change restarts, removes, or adds instances.
+func (impact changeImpact) Disruptive() bool { return len(impact.Pods) > 0 }
+
+// clusterChangeImpact returns what changing current to proposed does to pods,
+// the instance Pods of the cluster. PGO rolls out replicas before the primary,
+// and Patroni switches over when a ready replica is left to take over.
+func clusterChangeImpact(current, proposed *unstructured.Unstructured, pods []corev1.Pod) changeImpact {
+	var impact changeImpact
+
+	everything := false
+	for _, field := range podTemplateFields {
+		was, _, _ := unstructured.NestedFieldNoCopy(current.Object, "spec", field)
+		is, _, _ := unstructured.NestedFieldNoCopy(proposed.Object, "spec", field)
+		if !reflect.DeepEqual(was, is) {
+			everything = true
+		}
+	}
+
+	beforeSets := instanceSets(current)
+	afterSets := instanceSets(proposed)
+	names := make([]string, 0, len(beforeSets)+len(afterSets))
+	for name := range beforeSets {
+		names = append(names, name)
+	}
+	for name := range afterSets {
+		if beforeSets[name] == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	bySet := map[string][]corev1.Pod{}
+	for _, pod := range pods {
+		set := pod.GetLabels()[util.LabelInstanceSet]
+		bySet[set] = append(bySet[set], pod)
+	}
+
+	// The primary needs a ready replica that stays to take over.
+	var primary *corev1.Pod
+	standby := map[string]bool{}
+	for i := range pods {
+		switch {
+		case pods[i].GetLabels()[util.LabelRole] == util.RolePatroniLeader:
+			primary = &pods[i]
+		case podIsReady(&pods[i]):
+			standby[pods[i].GetName()] = true
+		}
+	}
+
+	var replicas, primaries []podImpact
+	for _, name := range names {
+		before, after := beforeSets[name], afterSets[name]
+
+		if after == nil {
+			for _, pod := range bySet[name] {
+				delete(standby, pod.GetName())
+				row := podImpact{Pod: pod.GetName(), Set: name, Role: podRole(pod), Impact: "is removed with its volumes"}
+				if primary != nil && pod.GetName() == primary.GetName() {
+					primaries = append(primaries, row)
+				} else {
+					replicas = append(replicas, row)
+				}
+			}
+			continue
+		}
+
+		was, is := setReplicas(before), setReplicas(after)
+		if before == nil {
+			was = 0
+		}
+		switch {
+		case is > was:
+			for i := was; i < is; i++ {
+				replicas = append(replicas, podImpact{Set: name, Role: "replica",
+					Impact: "created; copies the database"})
+			}
+			impact.Resync += int(is - was)
+		case is < was:
+			// PGO chooses the instances to remove and keeps the primary.
+			for i := is; i < was; i++ {
+				replicas = append(replicas, podImpact{Set: name, Role: "replica",
+					Impact: "is removed with its volumes"})
+			}
+		}
+
+		reason := instanceSetRestart(before, after)
+		if before == nil || (reason == "" && !everything) {
+			continue
+		}
+		if reason == "" {
+			reason = "restarts"
+		}
+		for _, pod := range bySet[name] {
+			row := podImpact{Pod: pod.GetName(), Set: name, Role: podRole(pod), Impact: reason}
+			if primary != nil && pod.GetName() == primary.GetName() {
+				primaries = append(primaries, row)
+			} else {
+				replicas = append(replicas, row)
+			}
+		}
+	}
+
+	for i := range primaries {
+		if len(standby) > 0 {
+			impact.Switchover = true
+			primaries[i].Impact = "switches over, then " + primaries[i].Impact
+		} else {
+			impact.Outage = true
+			primaries[i].Impact += "; writes fail until it is ready"
+		}
+	}
+	impact.Pods = append(replicas, primaries...)
+	return impact
+}
+
+// instanceSetRestart returns why changing an instance set from before to after
+// restarts its instances, or empty when it does not.
+func instanceSetRestart(before, after map[string]any) string {
+	_, hadWAL := before["walVolumeClaimSpec"]
+	_, hasWAL := after["walVolumeClaimSpec"]
+	switch {
+	case !hadWAL && hasWAL:
+		return "restarts while pg_wal moves to its new volume"
+	case hadWAL && !hasWAL:
+		return "restarts while pg_wal moves to the data volume"
+	}
+
+	for key := range mergeKeys(before, after) {
+		if !instanceSetOtherFields[key] && !reflect.DeepEqual(before[key], after[key]) {
+			return "restarts"
+		}
+	}
+	return ""
+}
+
+// mergeKeys returns the keys of a and b.
+func mergeKeys(a, b map[string]any) map[string]bool {
+	keys := make(map[string]bool, len(a)+len(b))
+	for key := range a {
+		keys[key] = true
+	}
+	for key := range b {
+		keys[key] = true
+	}
+	return keys
+}
+
+// setReplicas returns the number of instances of an instance set. PGO runs
+// one when it is not set.
+func setReplicas(set map[string]any) int64 {
+	switch value := set["replicas"].(type) {
+	case int64:
+		return value
+	case float64:
+		return int64(value)
+	}
+	return 1
+}
+
+// podRole returns the role of an instance Pod as Patroni labels it.
+func podRole(pod corev1.Pod) string {
+	if pod.GetLabels()[util.LabelRole] == util.RolePatroniLeader {
+		return "primary"
+	}
+	return "replica"
+}
+
+// printChangeImpact writes impact as a table and a summary to out.
+func printChangeImpact(out io.Writer, impact changeImpact) error {
+	w := tabwriter.NewWriter(out, 0, 2, 2, ' ', 0)
+	fmt.Fprintln(w, "POD\tSET\tROLE\tIMPACT")
+	for _, row := range impact.Pods {
+		pod := row.Pod
+		if pod == "" {
+			pod = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", pod, row.Set, row.Role, row.Impact)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	switchover := "no"
+	switch {
+	case impact.Switchover:
+		switchover = "yes; connections to the primary close and writes pause briefly"
+	case impact.Outage:
+		switchover = "no; no ready replica can take over, so writes fail while the primary restarts"
+	}
+	_, err := fmt.Fprintf(out, "switchover: %s\n", switchover)
+	if err == nil && impact.Resync > 0 {
+		size := "an unknown amount"
+		if impact.DataBytes > 0 {
+			size = "about " + formatBytes(impact.DataBytes)
+		}
+		_, err = fmt.Fprintf(out, "estimated data resync: %d instance(s) copy %s each from the repository or the primary\n",
+			impact.Resync, size)
+	}
+	return err
+}
+
+// previewClusterChange prints what changing current to proposed does to the
+// instances of the cluster and asks to continue when it restarts, removes, or
+// adds any of them. It returns [ErrCancelled] when the user declines. The
+// question and what it is about go to the output of cmd; with yes, there is no
+// question and they go to stderr, where --quiet silences them.
+func previewClusterChange(ctx context.Context, cmd *cobra.Command, config *internal.Config,
+	current, proposed *unstructured.Unstructured, yes bool,
+) error {
+	rest, err := config.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+	client, err := v1.NewForConfig(rest)
+	if err != nil {
+		return err
+	}
+	pods, err := client.Pods(current.GetNamespace()).List(ctx, metav1.ListOptions{
+		LabelSelector: util.DBInstanceLabels(current.GetName()),
+	})
+	if err != nil {
+		return err
+	}
+
+	impact := clusterChangeImpact(current, proposed, pods.Items)
+	if !impact.Disruptive() {
+		return nil
+	}
+
+	// The size of the databases is only worth the exec when instances copy it.
+	if impact.Resync > 0 {
+		for _, pod := range pods.Items {
+			if pod.GetLabels()[util.LabelRole] != util.RolePatroniLeader || !podIsReady(&pod) {
+				continue
+			}
+			podExec, err := util.NewPodExecutor(rest)
+			if err != nil {
+				return err
+			}
+			exec := podexec.Container(podExec, pod.GetNamespace(), pod.GetName(), util.ContainerDatabase)
+			stdout, _, err := podexec.PSQL(exec, "",
+				"SELECT sum(pg_catalog.pg_database_size(oid))::bigint FROM pg_catalog.pg_database")
+			if err == nil {
+				impact.DataBytes, _ = strconv.ParseInt(strings.TrimSpace(stdout), 10, 64)
+			}
+		}
+	}
+
+	out := cmd.ErrOrStderr()
+	if _, err := fmt.Fprint(out, config.Messages.Sprintf("impact.warn", current.GetName())); err != nil {
+		return err
+	}
+	if err := printChangeImpact(out, impact); err != nil {
+		return err
+	}
+	if yes {
+		return nil
+	}
+
+	cmd.PrintErr(config.Messages.Sprintf("confirm.continue"))
+	var confirmed *bool
+	for i := 0; confirmed == nil && i < 10; i++ {
+		// retry 10 times or until a confirmation is given or denied,
+		// whichever comes first
+		confirmed = config.Messages.Confirm(cmd.InOrStdin(), cmd.ErrOrStderr())
+	}
+	if confirmed == nil || !*confirmed {
+		return ErrCancelled
+	}
+	return nil
+}
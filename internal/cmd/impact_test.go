@@ -0,0 +1,221 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+func TestClusterChangeImpact(t *testing.T) {
+	parse := func(t *testing.T, doc string) *unstructured.Unstructured {
+		var cluster unstructured.Unstructured
+		assert.NilError(t, yaml.Unmarshal([]byte(doc), &cluster.Object))
+		return &cluster
+	}
+	pod := func(name, set string, primary, ready bool) corev1.Pod {
+		var pod corev1.Pod
+		pod.Name = name
+		pod.Labels = map[string]string{util.LabelInstanceSet: set, util.LabelRole: util.RolePatroniReplica}
+		if primary {
+			pod.Labels[util.LabelRole] = util.RolePatroniLeader
+		}
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}
+		return pod
+	}
+
+	current := parse(t, `
+spec:
+  image: postgres:16.3
+  instances:
+  - name: one
+    replicas: 2
+    dataVolumeClaimSpec: {resources: {requests: {storage: 1Gi}}}
+  - name: two
+`)
+	pods := []corev1.Pod{
+		pod("hippo-one-aaaa-0", "one", true, true),
+		pod("hippo-one-bbbb-0", "one", false, true),
+		pod("hippo-two-cccc-0", "two", false, true),
+	}
+
+	t.Run("Nothing", func(t *testing.T) {
+		impact := clusterChangeImpact(current, parse(t, `
+spec:
+  image: postgres:16.3
+  instances:
+  - name: one
+    replicas: 2
+    dataVolumeClaimSpec: {resources: {requests: {storage: 5Gi}}}
+    minAvailable: 1
+  - name: two
+`), pods)
+		assert.Assert(t, !impact.Disruptive(), "growing a volume restarts nothing")
+	})
+
+	t.Run("Containers", func(t *testing.T) {
+		impact := clusterChangeImpact(current, parse(t, `
+spec:
+  image: postgres:16.3
+  instances:
+  - name: one
+    replicas: 2
+    containers: [{name: agent, image: agent}]
+  - name: two
+`), pods)
+		assert.DeepEqual(t, impact.Pods, []podImpact{
+			{Pod: "hippo-one-bbbb-0", Set: "one", Role: "replica", Impact: "restarts"},
+			{Pod: "hippo-one-aaaa-0", Set: "one", Role: "primary", Impact: "switches over, then restarts"},
+		})
+		assert.Assert(t, impact.Switchover)
+	})
+
+	t.Run("Image", func(t *testing.T) {
+		impact := clusterChangeImpact(current, parse(t, `
+spec:
+  image: postgres:16.4
+  instances:
+  - name: one
+    replicas: 2
+  - name: two
+`), pods)
+		assert.Equal(t, len(impact.Pods), 3)
+		assert.Equal(t, impact.Pods[2].Role, "primary", "the primary goes last")
+	})
+
+	t.Run("Scale", func(t *testing.T) {
+		impact := clusterChangeImpact(current, parse(t, `
+spec:
+  image: postgres:16.3
+  instances:
+  - name: one
+    replicas: 1
+  - name: two
+    replicas: 3
+`), pods)
+		assert.DeepEqual(t, impact.Pods, []podImpact{
+			{Set: "one", Role: "replica", Impact: "is removed with its volumes"},
+			{Set: "two", Role: "replica", Impact: "created; copies the database"},
+			{Set: "two", Role: "replica", Impact: "created; copies the database"},
+		})
+		assert.Equal(t, impact.Resync, 2)
+		assert.Assert(t, !impact.Switchover)
+	})
+
+	t.Run("Outage", func(t *testing.T) {
+		impact := clusterChangeImpact(current, parse(t, `
+spec:
+  image: postgres:16.3
+  instances:
+  - name: one
+    replicas: 2
+    walVolumeClaimSpec: {resources: {requests: {storage: 1Gi}}}
+`), []corev1.Pod{
+			pod("hippo-one-aaaa-0", "one", true, true),
+			pod("hippo-one-bbbb-0", "one", false, false),
+			pod("hippo-two-cccc-0", "two", false, true),
+		})
+		assert.DeepEqual(t, impact.Pods, []podImpact{
+			{Pod: "hippo-one-bbbb-0", Set: "one", Role: "replica",
+				Impact: "restarts while pg_wal moves to its new volume"},
+			{Pod: "hippo-two-cccc-0", Set: "two", Role: "replica", Impact: "is removed with its volumes"},
+			{Pod: "hippo-one-aaaa-0", Set: "one", Role: "primary",
+				Impact: "restarts while pg_wal moves to its new volume; writes fail until it is ready"},
+		})
+		assert.Assert(t, impact.Outage, "the only ready replica is removed")
+	})
+}
+
+func TestPrintChangeImpact(t *testing.T) {
+	var out bytes.Buffer
+	assert.NilError(t, printChangeImpact(&out, changeImpact{
+		Pods: []podImpact{
+			{Set: "two", Role: "replica", Impact: "created; copies the database"},
+			{Pod: "hippo-one-aaaa-0", Set: "one", Role: "primary", Impact: "switches over, then restarts"},
+		},
+		Switchover: true, Resync: 1, DataBytes: 5 << 30,
+	}))
+	assert.Equal(t, out.String(), `POD               SET  ROLE     IMPACT
+-                 two  replica  created; copies the database
+hippo-one-aaaa-0  one  primary  switches over, then restarts
+switchover: yes; connections to the primary close and writes pause briefly
+estimated data resync: 1 instance(s) copy about 5.0GiB each from the repository or the primary
+`)
+}
+
+func TestPreviewClusterChange(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(internal.FakeClusterEnv, dir)
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "requests.jsonl"), []byte(`{"method":"GET",`+
+		`"path":"/api/v1/namespaces/zoo/pods","query":"labelSelector=`+util.DBInstanceLabels("hippo")+`",`+
+		`"body":{"kind":"PodList","apiVersion":"v1","metadata":{},"items":[`+
+		`{"metadata":{"name":"hippo-one-aaaa-0","labels":{"postgres-operator.crunchydata.com/instance-set":"one",`+
+		`"postgres-operator.crunchydata.com/role":"master"}}}]}}`+"\n"), 0o600))
+
+	var current, proposed unstructured.Unstructured
+	assert.NilError(t, yaml.Unmarshal([]byte(`
+metadata: {name: hippo, namespace: zoo}
+spec:
+  instances: [{name: one}]
+`), &current.Object))
+	proposed.Object = runtime.DeepCopyJSON(current.Object)
+	assert.NilError(t, unstructured.SetNestedSlice(proposed.Object, []any{
+		map[string]any{"name": "one", "containers": []any{map[string]any{"name": "agent"}}},
+	}, "spec", "instances"))
+
+	run := func(stdin string, yes bool) (string, string, error) {
+		var stdout, stderr bytes.Buffer
+		cmd := &cobra.Command{}
+		cmd.SetIn(strings.NewReader(stdin))
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		config := &internal.Config{ConfigFlags: genericclioptions.NewConfigFlags(false)}
+		err := previewClusterChange(context.Background(), cmd, config, &current, &proposed, yes)
+		return stdout.String(), stderr.String(), err
+	}
+
+	t.Run("Declined", func(t *testing.T) {
+		stdout, stderr, err := run("no\n", false)
+		assert.Assert(t, errors.Is(err, ErrCancelled))
+		assert.Equal(t, stdout, "")
+		assert.Equal(t, stderr, `WARNING: This change to postgrescluster hippo restarts, removes, or adds instances:
+POD               SET  ROLE     IMPACT
+hippo-one-aaaa-0  one  primary  restarts; writes fail until it is ready
+switchover: no; no ready replica can take over, so writes fail while the primary restarts
+Are you sure you want to continue? (yes/no): `)
+	})
+
+	t.Run("Confirmed", func(t *testing.T) {
+		_, _, err := run("yes\n", false)
+		assert.NilError(t, err)
+	})
+
+	t.Run("Yes", func(t *testing.T) {
+		stdout, stderr, err := run("", true)
+		assert.NilError(t, err)
+		assert.Equal(t, stdout, "")
+		assert.Assert(t, strings.HasPrefix(stderr, "WARNING: This change to postgrescluster hippo"), "got %q", stderr)
+	})
+}
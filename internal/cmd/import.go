@@ -0,0 +1,404 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// importDumpPath is where the dump is copied on the primary before pg_restore
+// reads it. It is on the pgdata volume so large dumps fit.
+const importDumpPath = "/pgdata/pgo-import.dump"
+
+// newImportCommand returns the import subcommand of the PGO plugin. It loads
+// a custom-format dump from outside the cluster into a new database.
+func newImportCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import CLUSTER_NAME",
+		Short: "Import a pg_dump archive into a PostgresCluster",
+		Long: `Import a database dumped from another PostgreSQL server into a PostgresCluster.
+
+The file must be a custom-format archive from "pg_dump --format=custom". It is
+copied to the pgdata volume of the primary, restored by pg_restore with
+"--jobs" parallel connections, and removed afterward. Progress is printed as
+each table is restored. The database is analyzed when the restore finishes so
+queries are planned well right away.
+
+The database is created when it does not exist. Its owner is "--owner" or
+else the user in "spec.users" that lists the database. That user must have a
+Secret created by PGO; connect to the database with the credentials in it.
+Objects in the dump are owned by that user rather than the roles of the
+original server.
+
+To load plain SQL files, use "pgo seed --file" instead.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    postgresclusters.postgres-operator.crunchydata.com  [get]
+    pods                                                [list]
+    pods/exec                                           [create]
+    secrets                                             [list]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Import app.dump into the 'app' database of the 'hippo' postgrescluster
+pgo import hippo --file=app.dump --dbname=app
+
+### Example output
+database "app" is owned by "app"; credentials are in secrets/hippo-pguser-app
+copying app.dump (1.2GiB) to hippo-instance1-bn7w-0...
+copied 100% (1.2GiB of 1.2GiB)
+[1/3] restoring public.accounts
+[2/3] restoring public.branches
+[3/3] restoring public.history
+analyzing database "app"...
+imported app.dump into database "app" of postgrescluster/hippo`)
+
+	var options importOptions
+	cmd.Flags().StringVar(&options.File, "file", "", "path of a custom-format dump from pg_dump")
+	cmd.Flags().StringVar(&options.Database, "dbname", "", "database to import into")
+	cmd.Flags().StringVar(&options.Owner, "owner", "", "user that owns the database and its objects")
+	cmd.Flags().IntVar(&options.Jobs, "jobs", 4, "parallel connections pg_restore and analyze use")
+	cmd.Flags().BoolVar(&options.NoAnalyze, "no-analyze", false, "skip analyzing the database after the restore")
+	cobra.CheckErr(cmd.MarkFlagRequired("file"))
+	cobra.CheckErr(cmd.MarkFlagRequired("dbname"))
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		if err := options.Validate(); err != nil {
+			return err
+		}
+
+		// #nosec G304 -- We intentionally read the file supplied by the user.
+		file, err := os.Open(options.File)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		stat, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		input := bufio.NewReader(file)
+		if header, _ := input.Peek(len(pgDumpCustomHeader)); !bytes.Equal(header, []byte(pgDumpCustomHeader)) {
+			return fmt.Errorf(`%s is not a custom-format dump; create one with "pg_dump --format=custom"`+
+				` or load plain SQL with "pgo seed --file"`, options.File)
+		}
+
+		_, clusterClient, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		cluster, err := clusterClient.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		owner := options.Owner
+		if owner == "" {
+			owner = importOwner(clusterSpecUsers(cluster), options.Database)
+		}
+		if owner == "" {
+			cmd.PrintErrf("WARNING: no user in spec.users lists database %q; it is owned by postgres\n",
+				options.Database)
+		} else {
+			secrets, err := client.Secrets(namespace).List(ctx, metav1.ListOptions{
+				LabelSelector: util.LabelCluster + "=" + args[0] +
+					",postgres-operator.crunchydata.com/pguser=" + owner,
+			})
+			if err != nil {
+				return err
+			}
+			if len(secrets.Items) == 0 {
+				return fmt.Errorf("user %q has no Secret; add it to spec.users of postgrescluster/%s first",
+					owner, args[0])
+			}
+			cmd.Printf("database %q is owned by %q; credentials are in secrets/%s\n",
+				options.Database, owner, secrets.Items[0].GetName())
+		}
+
+		pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.PrimaryInstanceLabels(args[0]),
+		})
+		if err != nil {
+			return err
+		}
+		var pod *corev1.Pod
+		for i := range pods.Items {
+			if podIsReady(&pods.Items[i]) {
+				pod = &pods.Items[i]
+				break
+			}
+		}
+		if pod == nil {
+			return errors.New("no ready primary instance Pod found")
+		}
+
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+		exec := podexec.Container(podExec, namespace, pod.GetName(), util.ContainerDatabase)
+
+		// The dump has to fit on the volume next to the data it becomes.
+		var stdout, stderr bytes.Buffer
+		if err := exec.Exec(nil, &stdout, &stderr,
+			"df", "--block-size=1", "--output=target,used,size", "/pgdata"); err != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		for _, usage := range parseDiskFree(stdout.String()) {
+			if free := usage.Size - usage.Used; free < stat.Size() {
+				return fmt.Errorf("%s needs %s but only %s is free on the pgdata volume",
+					options.File, formatBytes(stat.Size()), formatBytes(free))
+			}
+		}
+
+		stdout.Reset()
+		stderr.Reset()
+		if err := exec.Exec(strings.NewReader(importCreateDatabaseSQL), &stdout, &stderr,
+			"psql", "--no-psqlrc", "--quiet", "--no-align", "--tuples-only",
+			"--set=ON_ERROR_STOP=1", "--set=dbname="+options.Database,
+			"--set=owner="+importRoleOrDefault(owner), "--file=-"); err != nil {
+			return fmt.Errorf("unable to create database %q: %w: %s",
+				options.Database, err, strings.TrimSpace(stderr.String()))
+		}
+		if tables, _ := strconv.Atoi(strings.TrimSpace(stdout.String())); tables > 0 {
+			_, _ = fmt.Fprint(config.ErrOut, config.Messages.Sprintf("import.warn-tables", options.Database, tables)+
+				config.Messages.Sprintf("confirm.continue"))
+			var confirmed *bool
+			for i := 0; confirmed == nil && i < 10; i++ {
+				// retry 10 times or until a confirmation is given or denied,
+				// whichever comes first
+				confirmed = config.Messages.Confirm(config.In, config.ErrOut)
+			}
+			if confirmed == nil || !*confirmed {
+				return ErrCancelled
+			}
+		}
+
+		cmd.Printf("copying %s (%s) to %s...\n", options.File, formatBytes(stat.Size()), pod.GetName())
+		defer func() {
+			_ = exec.Exec(nil, io.Discard, io.Discard, "rm", "-f", importDumpPath)
+		}()
+		stderr.Reset()
+		upload := &progressReader{Reader: input, Size: stat.Size(), Out: cmd.OutOrStdout()}
+		if err := exec.Exec(upload, io.Discard, &stderr,
+			"bash", "-ceu", "--", `cat > "$1"`, "-", importDumpPath); err != nil {
+			return fmt.Errorf("unable to copy %s: %w: %s", options.File, err, strings.TrimSpace(stderr.String()))
+		}
+		upload.Finish()
+
+		stdout.Reset()
+		stderr.Reset()
+		if err := exec.Exec(nil, &stdout, &stderr, "pg_restore", "--list", importDumpPath); err != nil {
+			return fmt.Errorf("unable to read %s: %w: %s", options.File, err, strings.TrimSpace(stderr.String()))
+		}
+
+		progress := &restoreProgress{Out: cmd.OutOrStdout(), Errors: cmd.ErrOrStderr(),
+			Tables: countDumpTables(stdout.String())}
+		err = exec.Exec(nil, cmd.OutOrStdout(), progress,
+			pgRestoreImportCommand(options.Database, owner, options.Jobs, importDumpPath)...)
+		progress.Flush()
+		if err != nil {
+			return fmt.Errorf("unable to restore %s: %w", options.File, err)
+		}
+
+		if !options.NoAnalyze {
+			cmd.Printf("analyzing database %q...\n", options.Database)
+			if err := exec.Exec(nil, io.Discard, cmd.ErrOrStderr(), "vacuumdb", "--analyze-only", "--quiet",
+				"--jobs="+strconv.Itoa(options.Jobs), "--dbname="+options.Database); err != nil {
+				return fmt.Errorf("unable to analyze database %q: %w", options.Database, err)
+			}
+		}
+
+		cmd.Printf("imported %s into database %q of postgrescluster/%s\n",
+			options.File, options.Database, args[0])
+		return nil
+	}
+
+	return cmd
+}
+
+// importOptions are the flags of the import command.
+type importOptions struct {
+	File      string
+	Database  string
+	Owner     string
+	Jobs      int
+	NoAnalyze bool
+}
+
+// Validate returns an error when the options are not a complete request.
+func (options importOptions) Validate() error {
+	switch {
+	case options.File == "-":
+		return errors.New("--file must be a file; pg_restore cannot run in parallel from stdin")
+	case options.Jobs < 1:
+		return errors.New("--jobs must be at least 1")
+	}
+	return nil
+}
+
+// importOwner returns the first user that lists database, if any.
+func importOwner(users []specUser, database string) string {
+	for _, user := range users {
+		for _, name := range user.Databases {
+			if name == database {
+				return user.Name
+			}
+		}
+	}
+	return ""
+}
+
+// importRoleOrDefault returns owner or the postgres superuser when it is empty.
+func importRoleOrDefault(owner string) string {
+	if owner == "" {
+		return "postgres"
+	}
+	return owner
+}
+
+// importCreateDatabaseSQL creates the database named by the "dbname" variable
+// when it does not exist, then prints the number of tables already in it.
+// The psql variables are quoted by psql so any name is safe.
+const importCreateDatabaseSQL = `SELECT format('CREATE DATABASE %I OWNER %I', :'dbname', :'owner')
+ WHERE NOT EXISTS (SELECT FROM pg_database WHERE datname = :'dbname') \gexec
+\connect :"dbname"
+SELECT count(*) FROM pg_catalog.pg_tables
+ WHERE schemaname NOT IN ('pg_catalog', 'information_schema');`
+
+// pgRestoreImportCommand returns the command that restores the dump at path
+// into database with jobs connections. Objects are owned by owner, when set,
+// rather than the roles of the server that made the dump.
+func pgRestoreImportCommand(database, owner string, jobs int, path string) []string {
+	command := []string{"pg_restore", "--verbose", "--no-owner", "--no-privileges",
+		"--jobs=" + strconv.Itoa(jobs), "--dbname=" + database}
+	if owner != "" {
+		command = append(command, "--role="+owner)
+	}
+	return append(command, path)
+}
+
+// countDumpTables returns the number of tables with data in the output of
+// "pg_restore --list".
+func countDumpTables(list string) int {
+	var count int
+	for _, line := range strings.Split(list, "\n") {
+		if !strings.HasPrefix(line, ";") && strings.Contains(line, " TABLE DATA ") {
+			count++
+		}
+	}
+	return count
+}
+
+// restoreProgress reads the verbose output of pg_restore. It prints a line to
+// Out as each table is restored and passes errors and warnings to Errors.
+type restoreProgress struct {
+	Out, Errors io.Writer
+	Tables      int
+
+	done    int
+	partial []byte
+}
+
+func (progress *restoreProgress) Write(p []byte) (int, error) {
+	progress.partial = append(progress.partial, p...)
+	for {
+		i := bytes.IndexByte(progress.partial, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		progress.line(string(progress.partial[:i]))
+		progress.partial = progress.partial[i+1:]
+	}
+}
+
+// Flush handles output that did not end with a newline.
+func (progress *restoreProgress) Flush() {
+	if len(progress.partial) > 0 {
+		progress.line(string(progress.partial))
+		progress.partial = nil
+	}
+}
+
+func (progress *restoreProgress) line(line string) {
+	const table = "pg_restore: processing data for table "
+	switch {
+	case strings.HasPrefix(line, table):
+		progress.done++
+		name := strings.Trim(strings.TrimPrefix(line, table), `"`)
+		_, _ = fmt.Fprintf(progress.Out, "[%d/%d] restoring %s\n", progress.done, progress.Tables, name)
+	case strings.Contains(line, "error:"), strings.Contains(line, "warning:"),
+		!strings.HasPrefix(line, "pg_restore: "):
+		_, _ = fmt.Fprintln(progress.Errors, line)
+	}
+}
+
+// progressReader prints how much of Size has been read to Out every 10%.
+type progressReader struct {
+	io.Reader
+	Size int64
+	Out  io.Writer
+
+	read, reported int64
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.read += int64(n)
+	if r.Size > 0 && r.read*10/r.Size > r.reported && r.read < r.Size {
+		r.reported = r.read * 10 / r.Size
+		r.print()
+	}
+	return n, err
+}
+
+// Finish prints the final amount read.
+func (r *progressReader) Finish() { r.print() }
+
+func (r *progressReader) print() {
+	percent := int64(100)
+	if r.Size > 0 {
+		percent = r.read * 100 / r.Size
+	}
+	_, _ = fmt.Fprintf(r.Out, "copied %d%% (%s of %s)\n",
+		percent, formatBytes(r.read), formatBytes(r.Size))
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestImportOptionsValidate(t *testing.T) {
+	assert.NilError(t, importOptions{File: "app.dump", Database: "app", Jobs: 1}.Validate())
+	assert.ErrorContains(t, importOptions{File: "-", Database: "app", Jobs: 1}.Validate(), "stdin")
+	assert.ErrorContains(t, importOptions{File: "app.dump", Database: "app"}.Validate(), "--jobs")
+}
+
+func TestImportOwner(t *testing.T) {
+	users := []specUser{
+		{Name: "hippo", Databases: []string{"hippo"}},
+		{Name: "app", Databases: []string{"app", "reports"}},
+	}
+	assert.Equal(t, importOwner(users, "reports"), "app")
+	assert.Equal(t, importOwner(users, "hippo"), "hippo")
+	assert.Equal(t, importOwner(users, "other"), "")
+}
+
+func TestPGRestoreImportCommand(t *testing.T) {
+	assert.DeepEqual(t, pgRestoreImportCommand("app", "", 2, "/tmp/x"), []string{
+		"pg_restore", "--verbose", "--no-owner", "--no-privileges",
+		"--jobs=2", "--dbname=app", "/tmp/x",
+	})
+	assert.DeepEqual(t, pgRestoreImportCommand("app", "owner", 8, "/tmp/x"), []string{
+		"pg_restore", "--verbose", "--no-owner", "--no-privileges",
+		"--jobs=8", "--dbname=app", "--role=owner", "/tmp/x",
+	})
+}
+
+func TestCountDumpTables(t *testing.T) {
+	list := strings.TrimSpace(`
+;
+; Archive created at 2024-05-01 10:00:00 UTC
+;     TOC Entries: 12
+;
+215; 1259 16390 TABLE public accounts postgres
+3350; 0 16390 TABLE DATA public accounts postgres
+3351; 0 16393 TABLE DATA public branches postgres
+;3352; 0 16396 TABLE DATA public history postgres
+3200; 2606 16400 CONSTRAINT public accounts accounts_pkey postgres
+`)
+	assert.Equal(t, countDumpTables(list), 2)
+	assert.Equal(t, countDumpTables(""), 0)
+}
+
+func TestRestoreProgress(t *testing.T) {
+	var out, errs bytes.Buffer
+	progress := &restoreProgress{Out: &out, Errors: &errs, Tables: 2}
+
+	_, _ = io.WriteString(progress, "pg_restore: connecting to database for restore\n"+
+		`pg_restore: processing data for table "public.accounts"`+"\n"+
+		`pg_restore: processing data for table "public.bran`)
+	_, _ = io.WriteString(progress, `ches"`+"\n"+
+		"pg_restore: error: could not execute query: ERROR:  relation exists")
+	progress.Flush()
+
+	assert.Equal(t, out.String(), ""+
+		"[1/2] restoring public.accounts\n"+
+		"[2/2] restoring public.branches\n")
+	assert.Equal(t, errs.String(),
+		"pg_restore: error: could not execute query: ERROR:  relation exists\n")
+}
+
+func TestProgressReader(t *testing.T) {
+	var out bytes.Buffer
+	reader := &progressReader{Reader: strings.NewReader(strings.Repeat("x", 2000)), Size: 2000, Out: &out}
+
+	buffer := make([]byte, 500)
+	for {
+		if _, err := reader.Read(buffer); err != nil {
+			break
+		}
+	}
+	reader.Finish()
+
+	assert.Equal(t, out.String(), ""+
+		"copied 25% (500B of 2.0KiB)\n"+
+		"copied 50% (1000B of 2.0KiB)\n"+
+		"copied 75% (1.5KiB of 2.0KiB)\n"+
+		"copied 100% (2.0KiB of 2.0KiB)\n")
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// promGauge is a gauge in the Prometheus text exposition format, which the
+// textfile collector of node_exporter reads.
+// - https://prometheus.io/docs/instrumenting/exposition_formats/
+type promGauge struct {
+	Name, Help string
+	Samples    []promSample
+}
+
+// promSample is one value of a [promGauge].
+type promSample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// add appends a sample of value with labels, given as pairs of name and value.
+// Labels with empty values are left out, as Prometheus does.
+func (gauge *promGauge) add(value float64, labels ...string) {
+	sample := promSample{Labels: map[string]string{}, Value: value}
+	for i := 0; i+1 < len(labels); i += 2 {
+		if labels[i+1] != "" {
+			sample.Labels[labels[i]] = labels[i+1]
+		}
+	}
+	gauge.Samples = append(gauge.Samples, sample)
+}
+
+// checkTimestampGauge returns a gauge of when the check command named check
+// ran. It lets alerts tell a passing check from one that stopped running.
+func checkTimestampGauge(check string, now time.Time) *promGauge {
+	gauge := &promGauge{
+		Name: "pgo_check_timestamp_seconds",
+		Help: "When a pgo check command last ran, in seconds since the Unix epoch.",
+	}
+	gauge.add(float64(now.Unix()), "check", check)
+	return gauge
+}
+
+// writePromGauges writes the gauges that have samples to w. Every sample gets
+// the labels in common, given as pairs of name and value.
+func writePromGauges(w io.Writer, common []string, gauges ...*promGauge) error {
+	var b strings.Builder
+	for _, gauge := range gauges {
+		if len(gauge.Samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "# HELP %s %s\n", gauge.Name, gauge.Help)
+		fmt.Fprintf(&b, "# TYPE %s gauge\n", gauge.Name)
+
+		for _, sample := range gauge.Samples {
+			labels := map[string]string{}
+			for i := 0; i+1 < len(common); i += 2 {
+				labels[common[i]] = common[i+1]
+			}
+			for name, value := range sample.Labels {
+				labels[name] = value
+			}
+			names := make([]string, 0, len(labels))
+			for name := range labels {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			pairs := make([]string, 0, len(names))
+			for _, name := range names {
+				pairs = append(pairs, name+`="`+promEscape(labels[name])+`"`)
+			}
+			fmt.Fprintf(&b, "%s{%s} %s\n", gauge.Name, strings.Join(pairs, ","),
+				strconv.FormatFloat(sample.Value, 'f', -1, 64))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// promEscape escapes a label value for the Prometheus text format.
+func promEscape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// promBool returns 1 when b is true and 0 otherwise.
+func promBool(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
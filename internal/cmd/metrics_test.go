@@ -0,0 +1,34 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestWritePromGauges(t *testing.T) {
+	up := &promGauge{Name: "example_up", Help: "Whether an example is up."}
+	up.add(1, "name", "one", "zone", "")
+	up.add(0.25, "name", "two \"quoted\" \\\nlines")
+
+	var out bytes.Buffer
+	assert.NilError(t, writePromGauges(&out, []string{"cluster", "hippo"},
+		up, &promGauge{Name: "example_empty"}, checkTimestampGauge("example", time.Unix(1714560000, 0))))
+	assert.Equal(t, out.String(), `# HELP example_up Whether an example is up.
+# TYPE example_up gauge
+example_up{cluster="hippo",name="one"} 1
+example_up{cluster="hippo",name="two \"quoted\" \\\nlines"} 0.25
+# HELP pgo_check_timestamp_seconds When a pgo check command last ran, in seconds since the Unix epoch.
+# TYPE pgo_check_timestamp_seconds gauge
+pgo_check_timestamp_seconds{check="example",cluster="hippo"} 1714560000
+`)
+
+	assert.Equal(t, promBool(true), float64(1))
+	assert.Equal(t, promBool(false), float64(0))
+}
@@ -0,0 +1,26 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// newMigrateCommand returns the migrate subcommand of the PGO plugin.
+// Subcommands of migrate move a PostgresCluster from one setup to another.
+func newMigrateCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Migrate a PostgresCluster to a new setup",
+		Long:  "Migrate a PostgresCluster to a new setup",
+	}
+
+	cmd.AddCommand(newMigrateAuthCommand(config))
+	cmd.AddCommand(newMigrateNamespaceCommand(config))
+
+	return cmd
+}
@@ -0,0 +1,352 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// scramSHA256 is the only authentication method "migrate auth" migrates to.
+const scramSHA256 = "scram-sha-256"
+
+// newMigrateAuthCommand returns the auth subcommand of the migrate command.
+// It moves the roles of a PostgresCluster from MD5 to SCRAM password
+// verifiers and then requires SCRAM in pg_hba.
+func newMigrateAuthCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth CLUSTER_NAME",
+		Short: "Migrate the passwords of a PostgresCluster from MD5 to SCRAM",
+		Long: `Migrate the roles of a PostgresCluster from MD5 to SCRAM-SHA-256 passwords.
+
+This command:
+  1. Lists the roles that can log in and still have an MD5 password.
+  2. Removes the verifier from the user Secret of each such role that PGO
+     manages, so PGO stores a SCRAM verifier of the same password.
+  3. Waits for PGO to update those roles.
+  4. Sets "password_encryption" and adds a SCRAM rule to pg_hba in
+     "spec.patroni.dynamicConfiguration". MD5 rules there become SCRAM rules.
+  5. Shows the clients now connected to the primary.
+
+Roles that PGO does not manage need a new password. The command lists them
+and stops before step 4 until they have one. Use "--dry-run" to only list
+what would change. Changing settings owned by others may require the
+--force-conflicts flag.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    pods                                                [list]
+    pods/exec                                           [create]
+    postgresclusters.postgres-operator.crunchydata.com  [get patch]
+    secrets                                             [list patch]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# See which roles of the 'hippo' postgrescluster still use MD5
+pgo migrate auth hippo --to=scram-sha-256 --dry-run
+
+# Migrate the 'hippo' postgrescluster to SCRAM
+pgo migrate auth hippo --to=scram-sha-256
+
+### Example output
+Roles with MD5 passwords:
+  hippo (Secret hippo-pguser-hippo)
+WARNING: This changes the password verifiers of 1 role(s) and requires SCRAM for every connection.
+Are you sure you want to continue? (yes/no): yes
+secrets/hippo-pguser-hippo verifier removed
+Waiting for PGO to update roles... done
+postgresclusters/hippo password_encryption and pg_hba set to scram-sha-256
+
+Client connections to the primary:
+  hippo  3`)
+
+	var to string
+	var dryRun, forceConflicts bool
+	var timeout time.Duration
+	cmd.Flags().StringVar(&to, "to", scramSHA256, "authentication method to migrate to: scram-sha-256")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "list what would change without changing anything")
+	cmd.Flags().BoolVar(&forceConflicts, "force-conflicts", false,
+		"take ownership and overwrite the authentication settings")
+	cmd.Flags().DurationVar(&timeout, "timeout", 2*time.Minute,
+		"how long to wait for PGO to update roles")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		if to != scramSHA256 {
+			return fmt.Errorf("--to must be %q", scramSHA256)
+		}
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		mapping, clusterClient, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+
+		pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.PrimaryInstanceLabels(args[0]),
+		})
+		if err != nil {
+			return err
+		}
+		if len(pods.Items) != 1 {
+			return fmt.Errorf("primary instance Pod not found")
+		}
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+		exec := podexec.Container(podExec, namespace, pods.Items[0].GetName(), util.ContainerDatabase)
+
+		secrets, err := client.Secrets(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.PostgresUserSecretLabels(args[0]),
+		})
+		if err != nil {
+			return err
+		}
+
+		md5Roles, err := listMD5Roles(exec)
+		if err != nil {
+			return err
+		}
+		managed, unmanaged := partitionMD5Roles(md5Roles, secrets.Items)
+
+		if len(md5Roles) == 0 {
+			cmd.Println("No roles have MD5 passwords.")
+		} else {
+			cmd.Println("Roles with MD5 passwords:")
+			for _, role := range md5Roles {
+				if secret, ok := managed[role]; ok {
+					cmd.Printf("  %s (Secret %s)\n", role, secret)
+				} else {
+					cmd.Printf("  %s (not managed by PGO; needs a new password)\n", role)
+				}
+			}
+		}
+		if dryRun {
+			return nil
+		}
+
+		_, _ = fmt.Fprint(config.ErrOut, config.Messages.Sprintf("migrate.warn-auth", len(managed))+
+			config.Messages.Sprintf("confirm.continue"))
+		var confirmed *bool
+		for i := 0; confirmed == nil && i < 10; i++ {
+			// retry 10 times or until a confirmation is given or denied,
+			// whichever comes first
+			confirmed = config.Messages.Confirm(config.In, config.ErrOut)
+		}
+		if confirmed == nil || !*confirmed {
+			return ErrCancelled
+		}
+
+		// PGO stores a new verifier when the Secret has a password but no verifier.
+		// By default, PGO generates SCRAM verifiers.
+		remove := []byte(`[{"op":"remove","path":"/data/verifier"}]`)
+		for _, secret := range secrets.Items {
+			if _, ok := managed[string(secret.Data["user"])]; !ok || len(secret.Data["verifier"]) == 0 {
+				continue
+			}
+			if _, err := client.Secrets(namespace).Patch(ctx, secret.GetName(),
+				types.JSONPatchType, remove, config.Patch.PatchOptions(metav1.PatchOptions{})); err != nil {
+				return err
+			}
+			cmd.Printf("secrets/%s verifier removed\n", secret.GetName())
+		}
+
+		if len(managed) > 0 {
+			cmd.PrintErr("Waiting for PGO to update roles...")
+			for deadline := time.Now().Add(timeout); ; time.Sleep(5 * time.Second) {
+				if md5Roles, err = listMD5Roles(exec); err != nil {
+					return err
+				}
+				if managed, _ = partitionMD5Roles(md5Roles, secrets.Items); len(managed) == 0 {
+					cmd.PrintErrln(" done")
+					break
+				}
+				if time.Now().After(deadline) {
+					cmd.PrintErrln()
+					return fmt.Errorf("roles still have MD5 passwords after %s: %s",
+						timeout, strings.Join(sortedKeys(managed), ", "))
+				}
+			}
+		}
+
+		if len(unmanaged) > 0 {
+			cmd.Println("\nThese roles need a new password before SCRAM can be required:")
+			for _, role := range unmanaged {
+				cmd.Printf("  %s\n", role)
+			}
+			cmd.Println("Set one in psql with: SET password_encryption = 'scram-sha-256'; \\password ROLE")
+			return errors.New("roles with MD5 passwords remain; run this command again after changing them")
+		}
+
+		cluster, err := clusterClient.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		intent := new(unstructured.Unstructured)
+		if err := internal.ExtractFieldsInto(cluster, intent, config.Patch.FieldManager); err != nil {
+			return err
+		}
+		if err := setSCRAMAuthentication(cluster, intent); err != nil {
+			return err
+		}
+		if err := checkClusterChange(cmd.ErrOrStderr(), &config.Messages, cluster, intent); err != nil {
+			return err
+		}
+
+		patch, err := intent.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		patchOptions := metav1.PatchOptions{}
+		if forceConflicts {
+			b := true
+			patchOptions.Force = &b
+		}
+		if _, err := clusterClient.Namespace(namespace).Patch(ctx, args[0],
+			types.ApplyPatchType, patch, config.Patch.PatchOptions(patchOptions)); err != nil {
+			if apierrors.IsConflict(err) {
+				cmd.PrintErrln("SUGGESTION: The --force-conflicts flag may help in performing this operation.")
+			}
+			return err
+		}
+		cmd.Printf("%s/%s password_encryption and pg_hba set to %s\n",
+			mapping.Resource.Resource, args[0], scramSHA256)
+
+		// Patroni reloads pg_hba shortly after the change. Show who is
+		// connected so clients that fail to reconnect stand out.
+		stdout, stderr, err := podexec.PSQL(exec, "", `SELECT usename, count(*) FROM pg_catalog.pg_stat_activity
+WHERE backend_type = 'client backend' AND client_addr IS NOT NULL
+GROUP BY 1 ORDER BY 1`)
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr))
+		}
+		cmd.Println("\nClient connections to the primary:")
+		for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+			if fields := strings.Split(line, "\t"); len(fields) == 2 {
+				cmd.Printf("  %s  %s\n", fields[0], fields[1])
+			}
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// listMD5Roles returns the roles that can log in and have an MD5 password.
+func listMD5Roles(exec podexec.Executor) ([]string, error) {
+	stdout, stderr, err := podexec.PSQL(exec, "", `SELECT rolname FROM pg_catalog.pg_authid
+WHERE rolcanlogin AND rolpassword LIKE 'md5%' ORDER BY 1`)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr))
+	}
+
+	var roles []string
+	for _, line := range strings.Split(stdout, "\n") {
+		if role := strings.TrimSpace(line); role != "" {
+			roles = append(roles, role)
+		}
+	}
+	return roles, nil
+}
+
+// partitionMD5Roles splits roles into those with a PGO user Secret, mapped to
+// the name of that Secret, and those without one.
+func partitionMD5Roles(roles []string, secrets []corev1.Secret) (map[string]string, []string) {
+	bySecret := map[string]string{}
+	for _, secret := range secrets {
+		if user := string(secret.Data["user"]); user != "" && len(secret.Data["password"]) > 0 {
+			bySecret[user] = secret.GetName()
+		}
+	}
+
+	managed := map[string]string{}
+	var unmanaged []string
+	for _, role := range roles {
+		if secret, ok := bySecret[role]; ok {
+			managed[role] = secret
+		} else {
+			unmanaged = append(unmanaged, role)
+		}
+	}
+	return managed, unmanaged
+}
+
+// setSCRAMAuthentication sets password_encryption and a SCRAM pg_hba rule in
+// the dynamic configuration of intent. Rules from cluster that use MD5 are
+// kept with SCRAM instead.
+func setSCRAMAuthentication(cluster, intent *unstructured.Unstructured) error {
+	path := []string{"spec", "patroni", "dynamicConfiguration", "postgresql"}
+
+	rules, _, err := unstructured.NestedStringSlice(cluster.Object, append(path, "pg_hba")...)
+	if err != nil {
+		return err
+	}
+
+	// Rules are checked in order and PGO puts them before its own, which
+	// accept MD5. End with a rule that requires SCRAM for everything else.
+	catchAll := "hostssl all all all " + scramSHA256
+	var hba []any
+	for _, rule := range rules {
+		fields := strings.Fields(rule)
+		if n := len(fields); n > 0 && fields[n-1] == "md5" {
+			fields[n-1] = scramSHA256
+			rule = strings.Join(fields, " ")
+		}
+		if rule != catchAll {
+			hba = append(hba, rule)
+		}
+	}
+	hba = append(hba, catchAll)
+
+	if err := unstructured.SetNestedSlice(intent.Object, hba, append(path, "pg_hba")...); err != nil {
+		return err
+	}
+	return unstructured.SetNestedField(intent.Object, scramSHA256,
+		append(path, "parameters", "password_encryption")...)
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
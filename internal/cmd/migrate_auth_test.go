@@ -0,0 +1,73 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/testing/cmp"
+)
+
+func TestListMD5Roles(t *testing.T) {
+	exec := &podexec.Fake{Replies: []podexec.Reply{
+		{Match: "rolpassword LIKE 'md5%'", Stdout: "app\nhippo\n"},
+	}}
+
+	roles, err := listMD5Roles(exec)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, roles, []string{"app", "hippo"})
+	assert.Equal(t, len(exec.Calls), 1)
+}
+
+func TestPartitionMD5Roles(t *testing.T) {
+	secret := func(name, user, password string) corev1.Secret {
+		var s corev1.Secret
+		s.Name = name
+		s.Data = map[string][]byte{"user": []byte(user), "password": []byte(password)}
+		return s
+	}
+
+	managed, unmanaged := partitionMD5Roles([]string{"app", "hippo", "legacy"}, []corev1.Secret{
+		secret("hippo-pguser-hippo", "hippo", "secret"),
+		secret("hippo-pguser-app", "app", ""),
+	})
+	assert.DeepEqual(t, managed, map[string]string{"hippo": "hippo-pguser-hippo"})
+	assert.DeepEqual(t, unmanaged, []string{"app", "legacy"})
+}
+
+func TestSetSCRAMAuthentication(t *testing.T) {
+	var cluster unstructured.Unstructured
+	assert.NilError(t, yaml.Unmarshal([]byte(`
+spec:
+  patroni:
+    dynamicConfiguration:
+      postgresql:
+        pg_hba:
+        - host all app 10.0.0.0/8 md5
+        - hostssl all all all scram-sha-256
+        - local all all peer
+`), &cluster.Object))
+	intent := unstructured.Unstructured{Object: map[string]any{}}
+
+	assert.NilError(t, setSCRAMAuthentication(&cluster, &intent))
+	assert.Assert(t, cmp.MarshalMatches(intent.Object, `
+spec:
+  patroni:
+    dynamicConfiguration:
+      postgresql:
+        parameters:
+          password_encryption: scram-sha-256
+        pg_hba:
+        - host all app 10.0.0.0/8 scram-sha-256
+        - local all all peer
+        - hostssl all all all scram-sha-256
+`))
+}
@@ -0,0 +1,594 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// migratedFromAnnotation is the annotation "migrate namespace" puts on the
+// cluster it creates. Its value is the NAMESPACE/CLUSTER_NAME of the source,
+// so a later run knows it can resume.
+const migratedFromAnnotation = "postgres-operator.crunchydata.com/migrated-from"
+
+// newMigrateNamespaceCommand returns the namespace subcommand of the migrate
+// command. It moves a PostgresCluster to another namespace by restoring a
+// backup there.
+func newMigrateNamespaceCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "namespace CLUSTER_NAME",
+		Short: "Move a PostgresCluster to another namespace",
+		Long: `Move a PostgresCluster to the namespace given by "--to" by restoring a backup
+there. Kubernetes cannot move objects between namespaces, so this command:
+  1. Copies the Secrets of the cluster to the new namespace: its user
+     Secrets, so passwords stay the same, the Secrets in
+     "spec.backups.pgbackrest.configuration", and its custom TLS Secrets.
+  2. Switches to a new WAL file on the primary so recent changes are archived,
+     then creates a cluster of the same name and spec in the new namespace.
+     It restores the latest backup and WAL of "--repoName" through
+     "spec.dataSource.pgbackrest", and keeps its own backups at a new path
+     in the same storage.
+  3. Waits for every instance of the new cluster to be ready.
+  4. Compares the databases, tables, and roles of the two clusters.
+  5. With "--delete-source", deletes the source cluster when every check
+     passes, after asking to continue.
+
+The repository must be in S3, GCS, or Azure storage; a repository volume
+cannot be read from another namespace. The new namespace must exist. Changes
+written to the source after step 2 are not in the new cluster, so stop the
+applications that write to it first.
+
+Running the command again resumes the move: Secrets that exist are kept and
+a cluster that an earlier run created is not created again.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    jobs                                                [list]
+    namespaces                                          [get]
+    pods                                                [list]
+    pods/exec                                           [create]
+    postgresclusters.postgres-operator.crunchydata.com  [get create delete]
+    secrets                                             [get list create]
+
+    Note: Deleting postgresclusters is only needed with "--delete-source".
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Move the 'hippo' postgrescluster to the 'zoo-prod' namespace
+pgo migrate namespace hippo --to=zoo-prod --repoName=repo2
+
+# Resume the move and delete the source when the new cluster checks out
+pgo migrate namespace hippo --to=zoo-prod --repoName=repo2 --delete-source
+
+### Example output
+step 1 of 5: copy Secrets to namespace zoo-prod
+secrets/hippo-pguser-hippo created
+secrets/hippo-s3-creds exists
+step 2 of 5: create postgresclusters/hippo in namespace zoo-prod from repo2
+postgresclusters/hippo created
+step 3 of 5: wait for the new cluster
+2 of 2 instances ready after 6m40s
+step 4 of 5: compare the clusters
+CHECK      RESULT  DETAIL
+databases  ok      2 databases in both clusters: hippo, postgres
+tables     ok      hippo: 14 tables in both clusters
+tables     ok      postgres: 0 tables in both clusters
+roles      ok      3 roles in both clusters
+step 5 of 5: delete the source
+WARNING: This deletes postgrescluster hippo in namespace zoo and the volumes it owns. Are you sure you want to continue? (yes/no): yes
+postgresclusters/hippo deleted from namespace zoo`)
+
+	var to, repoName string
+	var deleteSource, yes bool
+	var timeout time.Duration
+	cmd.Flags().StringVar(&to, "to", "", "namespace to move the postgrescluster to")
+	cobra.CheckErr(cmd.MarkFlagRequired("to"))
+	cmd.Flags().StringVar(&repoName, "repoName", "",
+		"repository to restore from; defaults to the first one in S3, GCS, or Azure")
+	cmd.Flags().BoolVar(&deleteSource, "delete-source", false,
+		"delete the source postgrescluster when the new one checks out")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "delete the source without asking for confirmation")
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Minute,
+		"how long to wait for the new cluster")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		clientset, err := kubernetes.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+		mapping, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		if to == namespace {
+			return fmt.Errorf("postgresclusters/%s is already in namespace %s", args[0], to)
+		}
+		if _, err := clientset.CoreV1().Namespaces().Get(ctx, to, metav1.GetOptions{}); err != nil {
+			if apierrors.IsNotFound(err) {
+				return fmt.Errorf("namespace %q not found; create it first", to)
+			}
+			return err
+		}
+
+		from := namespace + "/" + args[0]
+		target, err := client.Namespace(to).Get(ctx, args[0], metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			target = nil
+		case err != nil:
+			return err
+		case target.GetAnnotations()[migratedFromAnnotation] != from:
+			return fmt.Errorf("%s/%s already exists in namespace %s and was not migrated from %s",
+				mapping.Resource.Resource, args[0], to, from)
+		}
+
+		source, err := client.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if apierrors.IsNotFound(err) && target != nil {
+			cmd.Printf("%s/%s is in namespace %s and the source is gone; nothing to do\n",
+				mapping.Resource.Resource, args[0], to)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		intent, repo, err := migratedCluster(source, to, repoName)
+		if err != nil {
+			return err
+		}
+
+		cmd.PrintErrf("step 1 of 5: copy Secrets to namespace %s\n", to)
+		secrets, err := migrationSecrets(ctx, clientset.CoreV1().Secrets(namespace), source)
+		if err != nil {
+			return err
+		}
+		for _, secret := range secrets {
+			_, err := clientset.CoreV1().Secrets(to).Create(ctx, migratedSecret(secret, to),
+				metav1.CreateOptions{FieldManager: config.Patch.FieldManager})
+			switch {
+			case apierrors.IsAlreadyExists(err):
+				cmd.Printf("secrets/%s exists\n", secret.Name)
+			case err != nil:
+				return err
+			default:
+				cmd.Printf("secrets/%s created\n", secret.Name)
+			}
+		}
+
+		cmd.PrintErrf("step 2 of 5: create %s/%s in namespace %s from %s\n",
+			mapping.Resource.Resource, args[0], to, repo)
+		sourcePrimary, err := migrationPrimary(ctx, clientset, namespace, args[0])
+		if err != nil {
+			return err
+		}
+		sourceExec := podexec.Container(podExec, namespace, sourcePrimary, util.ContainerDatabase)
+		if target != nil {
+			cmd.Printf("%s/%s exists; resuming\n", mapping.Resource.Resource, args[0])
+		} else {
+			if _, stderr, err := podexec.PSQL(sourceExec, "", "SELECT pg_catalog.pg_switch_wal()"); err != nil {
+				return fmt.Errorf("unable to switch WAL files on %s: %w", sourcePrimary, commandError(err, stderr))
+			}
+			if _, err := client.Namespace(to).Create(ctx, intent,
+				metav1.CreateOptions{FieldManager: config.Patch.FieldManager}); err != nil {
+				return err
+			}
+			cmd.Printf("%s/%s created\n", mapping.Resource.Resource, args[0])
+		}
+
+		cmd.PrintErrln("step 3 of 5: wait for the new cluster")
+		started := time.Now()
+		expected := clusterInstanceCount(intent)
+		var targetPrimary string
+		for {
+			pods, err := clientset.CoreV1().Pods(to).List(ctx, metav1.ListOptions{
+				LabelSelector: util.DBInstanceLabels(args[0]),
+			})
+			if err != nil {
+				return err
+			}
+			jobs, err := clientset.BatchV1().Jobs(to).List(ctx, metav1.ListOptions{
+				LabelSelector: util.LabelCluster + "=" + args[0] + "," + util.LabelPGBackRestRestore,
+			})
+			if err != nil {
+				return err
+			}
+			var ready int
+			if targetPrimary, ready, err = migrationProgress(pods.Items, jobs.Items); err != nil {
+				return err
+			}
+			if targetPrimary != "" && ready >= expected {
+				cmd.PrintErrf("%d of %d instances ready after %s\n",
+					ready, expected, time.Since(started).Round(time.Second))
+				break
+			}
+			if time.Since(started) > timeout {
+				return fmt.Errorf("%d of %d instances ready after %s; run the command again to keep waiting",
+					ready, expected, timeout)
+			}
+			time.Sleep(10 * time.Second)
+		}
+
+		cmd.PrintErrln("step 4 of 5: compare the clusters")
+		before, err := takeMigrationSnapshot(sourceExec)
+		if err != nil {
+			return fmt.Errorf("%s: %w", sourcePrimary, err)
+		}
+		after, err := takeMigrationSnapshot(
+			podexec.Container(podExec, to, targetPrimary, util.ContainerDatabase))
+		if err != nil {
+			return fmt.Errorf("%s: %w", targetPrimary, err)
+		}
+		checks := compareMigration(before, after)
+		if err := printReadinessChecks(cmd, checks); err != nil {
+			return err
+		}
+		var failed int
+		for _, check := range checks {
+			if !check.Healthy {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d checks failed; the source was not deleted", failed, len(checks))
+		}
+
+		if !deleteSource {
+			cmd.PrintErrln("step 5 of 5: skipped; pass --delete-source to delete the source")
+			return nil
+		}
+		cmd.PrintErrln("step 5 of 5: delete the source")
+		if !yes {
+			_, _ = fmt.Fprint(config.ErrOut, config.Messages.Sprintf("migrate.warn-namespace", args[0], namespace)+
+				config.Messages.Sprintf("confirm.continue"))
+			var confirmed *bool
+			for i := 0; confirmed == nil && i < 10; i++ {
+				// retry 10 times or until a confirmation is given or denied,
+				// whichever comes first
+				confirmed = config.Messages.Confirm(config.In, config.ErrOut)
+			}
+			if confirmed == nil || !*confirmed {
+				return ErrCancelled
+			}
+		}
+		if err := client.Namespace(namespace).Delete(ctx, args[0], metav1.DeleteOptions{}); err != nil {
+			return err
+		}
+		cmd.Printf("%s/%s deleted from namespace %s\n", mapping.Resource.Resource, args[0], namespace)
+		return nil
+	}
+
+	return cmd
+}
+
+// migratedCluster returns a PostgresCluster like source in namespace that
+// restores the latest backup of repoName, or of the first cloud repository
+// when that is empty. It returns the name of the repository, too.
+func migratedCluster(source *unstructured.Unstructured, namespace, repoName string) (
+	*unstructured.Unstructured, string, error,
+) {
+	if enabled, _, _ := unstructured.NestedBool(source.Object, "spec", "standby", "enabled"); enabled {
+		return nil, "", errors.New("a standby cluster cannot be migrated; promote it first")
+	}
+
+	spec, _, _ := unstructured.NestedMap(source.Object, "spec")
+	delete(spec, "dataSource")
+	delete(spec, "shutdown")
+	unstructured.RemoveNestedField(spec, "backups", "pgbackrest", "restore")
+	unstructured.RemoveNestedField(spec, "backups", "pgbackrest", "manual")
+
+	repos, _, _ := unstructured.NestedSlice(spec, "backups", "pgbackrest", "repos")
+	var repo map[string]any
+	for _, item := range repos {
+		item, _ := item.(map[string]any)
+		name, _ := item["name"].(string)
+		cloud := item["s3"] != nil || item["gcs"] != nil || item["azure"] != nil
+		switch {
+		case repoName == "" && cloud && repo == nil, name == repoName && cloud:
+			repo, repoName = item, name
+		case name == repoName:
+			return nil, "", fmt.Errorf("%s is a volume; only S3, GCS, or Azure repositories can be read from another namespace", name)
+		}
+	}
+	if repo == nil {
+		if repoName != "" {
+			return nil, "", fmt.Errorf("repository %q not found", repoName)
+		}
+		return nil, "", errors.New("no repository in S3, GCS, or Azure storage; add one and take a backup first")
+	}
+
+	// The new cluster reads the path of the source and writes its own, so the
+	// two never share a stanza.
+	global, _, _ := unstructured.NestedStringMap(spec, "backups", "pgbackrest", "global")
+	restoreGlobal := map[string]any{}
+	for key, value := range global {
+		if strings.HasPrefix(key, repoName+"-") {
+			restoreGlobal[key] = value
+		}
+	}
+	if restoreGlobal[repoName+"-path"] == nil {
+		restoreGlobal[repoName+"-path"] = "/pgbackrest/" + repoName
+	}
+	if global == nil {
+		global = map[string]string{}
+	}
+	for _, item := range repos {
+		item, _ := item.(map[string]any)
+		if name, _ := item["name"].(string); item["s3"] != nil || item["gcs"] != nil || item["azure"] != nil {
+			global[name+"-path"] = "/pgbackrest/" + namespace + "/" + source.GetName() + "/" + name
+		}
+	}
+	if err := unstructured.SetNestedStringMap(spec, global, "backups", "pgbackrest", "global"); err != nil {
+		return nil, "", err
+	}
+
+	dataSource := map[string]any{
+		"stanza": "db",
+		"repo":   runtime.DeepCopyJSONValue(repo),
+		"global": restoreGlobal,
+	}
+	if configuration, found, _ := unstructured.NestedSlice(spec,
+		"backups", "pgbackrest", "configuration"); found {
+		dataSource["configuration"] = configuration
+	}
+	if err := unstructured.SetNestedMap(spec, dataSource, "dataSource", "pgbackrest"); err != nil {
+		return nil, "", err
+	}
+
+	metadata := map[string]any{
+		"name":        source.GetName(),
+		"namespace":   namespace,
+		"annotations": map[string]any{migratedFromAnnotation: source.GetNamespace() + "/" + source.GetName()},
+	}
+	if labels := source.GetLabels(); len(labels) > 0 {
+		copied := map[string]any{}
+		for key, value := range labels {
+			copied[key] = value
+		}
+		metadata["labels"] = copied
+	}
+
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": source.GetAPIVersion(),
+		"kind":       source.GetKind(),
+		"metadata":   metadata,
+		"spec":       spec,
+	}}, repoName, nil
+}
+
+// migrationSecretNames returns the names of the Secrets that cluster refers to
+// and that PGO does not generate.
+func migrationSecretNames(cluster *unstructured.Unstructured) []string {
+	names := configurationSecretNames(cluster)
+	for _, path := range [][]string{
+		{"spec", "customTLSSecret", "name"},
+		{"spec", "customReplicationTLSSecret", "name"},
+		{"spec", "proxy", "pgBouncer", "customTLSSecret", "name"},
+	} {
+		if name, _, _ := unstructured.NestedString(cluster.Object, path...); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// migrationSecrets returns the user Secrets of cluster and the Secrets it
+// refers to, sorted by name.
+func migrationSecrets(ctx context.Context, client v1.SecretInterface, cluster *unstructured.Unstructured) (
+	[]corev1.Secret, error,
+) {
+	users, err := client.List(ctx, metav1.ListOptions{
+		LabelSelector: util.PostgresUserSecretLabels(cluster.GetName()),
+	})
+	if err != nil {
+		return nil, err
+	}
+	secrets := users.Items
+	for _, name := range migrationSecretNames(cluster) {
+		secret, err := client.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, *secret)
+	}
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].Name < secrets[j].Name })
+	return secrets, nil
+}
+
+// migratedSecret returns a copy of secret in namespace. Only labels, type,
+// and data are copied; PGO owns the user Secrets it finds.
+func migratedSecret(secret corev1.Secret, namespace string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secret.Name,
+			Namespace: namespace,
+			Labels:    secret.Labels,
+		},
+		Type: secret.Type,
+		Data: secret.Data,
+	}
+}
+
+// migrationPrimary returns the name of the ready primary of a cluster.
+func migrationPrimary(ctx context.Context, clientset kubernetes.Interface, namespace, name string) (string, error) {
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: util.PrimaryInstanceLabels(name),
+	})
+	if err != nil {
+		return "", err
+	}
+	for i := range pods.Items {
+		if podIsReady(&pods.Items[i]) {
+			return pods.Items[i].Name, nil
+		}
+	}
+	return "", fmt.Errorf("no ready primary instance Pod found for cluster %s in namespace %s", name, namespace)
+}
+
+// migrationProgress returns the ready primary of the new cluster, or empty,
+// and how many of its instance Pods are ready. It fails when a restore Job
+// in jobs failed or a Pod is failing.
+func migrationProgress(pods []corev1.Pod, jobs []batchv1.Job) (string, int, error) {
+	if _, err := restoreTestProgress(nil, jobs); err != nil {
+		return "", 0, err
+	}
+	var primary string
+	var ready int
+	for i := range pods {
+		if err := podFailing(&pods[i]); err != nil {
+			return "", 0, err
+		}
+		if podIsReady(&pods[i]) {
+			ready++
+			if pods[i].GetLabels()[util.LabelRole] == util.RolePatroniLeader {
+				primary = pods[i].Name
+			}
+		}
+	}
+	return primary, ready, nil
+}
+
+// migrationSnapshot is what "migrate namespace" compares between clusters.
+type migrationSnapshot struct {
+	Databases []string
+
+	// Tables is the number of tables in each database.
+	Tables map[string]string
+
+	Roles []string
+}
+
+// takeMigrationSnapshot reads the databases, the number of tables in each,
+// and the roles of the cluster that exec runs in.
+func takeMigrationSnapshot(exec podexec.Executor) (migrationSnapshot, error) {
+	var snapshot migrationSnapshot
+	query := func(database, sql string) ([]string, error) {
+		stdout, stderr, err := podexec.PSQL(exec, database, sql)
+		if err != nil {
+			return nil, commandError(err, stderr)
+		}
+		var lines []string
+		for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+		return lines, nil
+	}
+
+	var err error
+	if snapshot.Databases, err = query("",
+		"SELECT datname FROM pg_catalog.pg_database WHERE datallowconn AND NOT datistemplate ORDER BY 1"); err != nil {
+		return snapshot, err
+	}
+	if snapshot.Roles, err = query("",
+		"SELECT rolname FROM pg_catalog.pg_roles WHERE rolname !~ '^pg_' ORDER BY 1"); err != nil {
+		return snapshot, err
+	}
+	snapshot.Tables = map[string]string{}
+	for _, database := range snapshot.Databases {
+		count, err := query(database, `SELECT count(*) FROM pg_catalog.pg_class c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind IN ('r', 'p') AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+  AND n.nspname !~ '^pg_toast'`)
+		if err != nil {
+			return snapshot, fmt.Errorf("%s: %w", database, err)
+		}
+		snapshot.Tables[database] = strings.Join(count, "")
+	}
+	return snapshot, nil
+}
+
+// compareMigration returns checks that the new cluster, after, has what the
+// source, before, has.
+func compareMigration(before, after migrationSnapshot) []readinessCheck {
+	same := func(name string, a, b []string) readinessCheck {
+		check := readinessCheck{Name: name, Healthy: true}
+		if missing := missingFrom(b, a); len(missing) > 0 {
+			check.Healthy = false
+			check.Detail = "not in the new cluster: " + strings.Join(missing, ", ")
+		}
+		if extra := missingFrom(a, b); len(extra) > 0 {
+			check.Healthy = false
+			check.Detail = strings.TrimPrefix(check.Detail+"; only in the new cluster: "+strings.Join(extra, ", "), "; ")
+		}
+		if check.Healthy {
+			check.Detail = fmt.Sprintf("%d %s in both clusters", len(a), name)
+		}
+		return check
+	}
+
+	databases := same("databases", before.Databases, after.Databases)
+	if databases.Healthy {
+		databases.Detail += ": " + strings.Join(before.Databases, ", ")
+	}
+	checks := []readinessCheck{databases}
+	for _, database := range before.Databases {
+		check := readinessCheck{Name: "tables", Subject: database,
+			Healthy: before.Tables[database] == after.Tables[database]}
+		if check.Healthy {
+			check.Detail = fmt.Sprintf("%s: %s tables in both clusters", database, before.Tables[database])
+		} else {
+			check.Detail = fmt.Sprintf("%s: source has %s tables; new cluster has %s",
+				database, before.Tables[database], after.Tables[database])
+			if after.Tables[database] == "" {
+				check.Detail = database + ": not in the new cluster"
+			}
+		}
+		checks = append(checks, check)
+	}
+	return append(checks, same("roles", before.Roles, after.Roles))
+}
+
+// missingFrom returns the values of want that are not in have.
+func missingFrom(have, want []string) []string {
+	found := make(map[string]bool, len(have))
+	for _, value := range have {
+		found[value] = true
+	}
+	var missing []string
+	for _, value := range want {
+		if !found[value] {
+			missing = append(missing, value)
+		}
+	}
+	return missing
+}
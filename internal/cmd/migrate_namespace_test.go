@@ -0,0 +1,233 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/yaml"
+
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/testing/cmp"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+func TestMigratedCluster(t *testing.T) {
+	var source unstructured.Unstructured
+	assert.NilError(t, yaml.Unmarshal([]byte(strings.TrimSpace(`
+apiVersion: postgres-operator.crunchydata.com/v1beta1
+kind: PostgresCluster
+metadata:
+  name: hippo
+  namespace: zoo
+  labels: {team: payments}
+  resourceVersion: "123"
+spec:
+  postgresVersion: 16
+  dataSource:
+    postgresCluster: {clusterName: elephant, repoName: repo1}
+  instances:
+  - name: instance1
+    replicas: 2
+  backups:
+    pgbackrest:
+      configuration:
+      - secret: {name: hippo-s3-creds}
+      global:
+        repo2-s3-uri-style: path
+        repo1-retention-full: "2"
+      restore: {enabled: true, repoName: repo2}
+      repos:
+      - name: repo1
+        volume: {volumeClaimSpec: {}}
+      - name: repo2
+        s3: {bucket: backups, endpoint: s3.example.com, region: us-east-1}
+status:
+  conditions: []
+	`)), &source.Object))
+
+	cluster, repo, err := migratedCluster(&source, "zoo-prod", "")
+	assert.NilError(t, err)
+	assert.Equal(t, repo, "repo2")
+	assert.Assert(t, cmp.MarshalMatches(cluster.Object, strings.TrimSpace(`
+apiVersion: postgres-operator.crunchydata.com/v1beta1
+kind: PostgresCluster
+metadata:
+  annotations:
+    postgres-operator.crunchydata.com/migrated-from: zoo/hippo
+  labels:
+    team: payments
+  name: hippo
+  namespace: zoo-prod
+spec:
+  backups:
+    pgbackrest:
+      configuration:
+      - secret:
+          name: hippo-s3-creds
+      global:
+        repo1-retention-full: "2"
+        repo2-path: /pgbackrest/zoo-prod/hippo/repo2
+        repo2-s3-uri-style: path
+      repos:
+      - name: repo1
+        volume:
+          volumeClaimSpec: {}
+      - name: repo2
+        s3:
+          bucket: backups
+          endpoint: s3.example.com
+          region: us-east-1
+  dataSource:
+    pgbackrest:
+      configuration:
+      - secret:
+          name: hippo-s3-creds
+      global:
+        repo2-path: /pgbackrest/repo2
+        repo2-s3-uri-style: path
+      repo:
+        name: repo2
+        s3:
+          bucket: backups
+          endpoint: s3.example.com
+          region: us-east-1
+      stanza: db
+  instances:
+  - name: instance1
+    replicas: 2
+  postgresVersion: 16
+	`)))
+
+	_, _, err = migratedCluster(&source, "zoo-prod", "repo1")
+	assert.ErrorContains(t, err, "repo1 is a volume")
+
+	_, _, err = migratedCluster(&source, "zoo-prod", "repo3")
+	assert.ErrorContains(t, err, `repository "repo3" not found`)
+
+	assert.NilError(t, unstructured.SetNestedField(source.Object, true, "spec", "standby", "enabled"))
+	_, _, err = migratedCluster(&source, "zoo-prod", "")
+	assert.ErrorContains(t, err, "standby cluster cannot be migrated")
+}
+
+func TestMigrationSecrets(t *testing.T) {
+	ctx := context.Background()
+	secret := func(name string, labels map[string]string) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name: name, Namespace: "zoo", Labels: labels,
+			OwnerReferences: []metav1.OwnerReference{{Name: "hippo"}},
+		}, Data: map[string][]byte{"password": []byte(name)}}
+	}
+	client := fake.NewSimpleClientset(
+		secret("hippo-pguser-rhino", map[string]string{
+			util.LabelCluster: "hippo", util.LabelRole: util.RolePostgresUser}),
+		secret("hippo-s3-creds", nil),
+		secret("hippo-tls", nil),
+		secret("elephant-pguser-elephant", map[string]string{
+			util.LabelCluster: "elephant", util.LabelRole: util.RolePostgresUser}),
+	).CoreV1().Secrets("zoo")
+
+	cluster := &unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{"name": "hippo"},
+		"spec": map[string]any{
+			"customTLSSecret": map[string]any{"name": "hippo-tls"},
+			"backups": map[string]any{"pgbackrest": map[string]any{"configuration": []any{
+				map[string]any{"secret": map[string]any{"name": "hippo-s3-creds"}},
+			}}},
+		},
+	}}
+
+	secrets, err := migrationSecrets(ctx, client, cluster)
+	assert.NilError(t, err)
+	var names []string
+	for _, secret := range secrets {
+		names = append(names, secret.Name)
+	}
+	assert.DeepEqual(t, names, []string{"hippo-pguser-rhino", "hippo-s3-creds", "hippo-tls"})
+
+	copied := migratedSecret(secrets[0], "zoo-prod")
+	assert.Equal(t, copied.Namespace, "zoo-prod")
+	assert.Equal(t, len(copied.OwnerReferences), 0, "PGO adopts user Secrets")
+	assert.Equal(t, string(copied.Data["password"]), "hippo-pguser-rhino")
+
+	cluster.Object["spec"] = map[string]any{"customTLSSecret": map[string]any{"name": "missing"}}
+	_, err = migrationSecrets(ctx, client, cluster)
+	assert.ErrorContains(t, err, `"missing" not found`)
+}
+
+func TestMigrationProgress(t *testing.T) {
+	pod := func(name string, primary bool) corev1.Pod {
+		pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{}}}
+		if primary {
+			pod.Labels[util.LabelRole] = util.RolePatroniLeader
+		}
+		pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+		return pod
+	}
+
+	primary, ready, err := migrationProgress([]corev1.Pod{pod("a", false), pod("b", true)}, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, primary, "b")
+	assert.Equal(t, ready, 2)
+
+	_, _, err = migrationProgress(nil, []batchv1.Job{{
+		ObjectMeta: metav1.ObjectMeta{Name: "hippo-pgbackrest-restore"},
+		Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{{
+			Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded",
+		}}},
+	}})
+	assert.ErrorContains(t, err, "restore Job hippo-pgbackrest-restore failed: BackoffLimitExceeded")
+}
+
+func TestTakeMigrationSnapshot(t *testing.T) {
+	fake := &podexec.Fake{Replies: []podexec.Reply{
+		{Match: "pg_database", Stdout: "hippo\npostgres\n"},
+		{Match: "pg_roles", Stdout: "hippo\npostgres\n_crunchyrepl\n"},
+		{Match: "pg_class", Stdout: "14\n"},
+	}}
+	snapshot, err := takeMigrationSnapshot(fake)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, snapshot, migrationSnapshot{
+		Databases: []string{"hippo", "postgres"},
+		Tables:    map[string]string{"hippo": "14", "postgres": "14"},
+		Roles:     []string{"hippo", "postgres", "_crunchyrepl"},
+	})
+}
+
+func TestCompareMigration(t *testing.T) {
+	before := migrationSnapshot{
+		Databases: []string{"hippo", "postgres"},
+		Tables:    map[string]string{"hippo": "14", "postgres": "0"},
+		Roles:     []string{"hippo", "postgres", "rhino"},
+	}
+
+	checks := compareMigration(before, before)
+	assert.DeepEqual(t, checks, []readinessCheck{
+		{Name: "databases", Healthy: true, Detail: "2 databases in both clusters: hippo, postgres"},
+		{Name: "tables", Subject: "hippo", Healthy: true, Detail: "hippo: 14 tables in both clusters"},
+		{Name: "tables", Subject: "postgres", Healthy: true, Detail: "postgres: 0 tables in both clusters"},
+		{Name: "roles", Healthy: true, Detail: "3 roles in both clusters"},
+	})
+
+	checks = compareMigration(before, migrationSnapshot{
+		Databases: []string{"postgres"},
+		Tables:    map[string]string{"postgres": "1"},
+		Roles:     []string{"hippo", "postgres", "zebra"},
+	})
+	assert.DeepEqual(t, checks, []readinessCheck{
+		{Name: "databases", Detail: "not in the new cluster: hippo"},
+		{Name: "tables", Subject: "hippo", Detail: "hippo: not in the new cluster"},
+		{Name: "tables", Subject: "postgres", Detail: "postgres: source has 0 tables; new cluster has 1"},
+		{Name: "roles", Detail: "not in the new cluster: rhino; only in the new cluster: zebra"},
+	})
+}
@@ -0,0 +1,136 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// operatorScope is the namespaces that one deployment of PGO reconciles.
+type operatorScope struct {
+	Namespace, Name string
+
+	// Watched is empty when the operator watches every namespace.
+	Watched []string
+}
+
+// ClusterWide returns whether the operator watches every namespace.
+func (scope operatorScope) ClusterWide() bool { return len(scope.Watched) == 0 }
+
+// Watches returns whether the operator reconciles clusters in namespace.
+func (scope operatorScope) Watches(namespace string) bool {
+	if scope.ClusterWide() {
+		return true
+	}
+	for _, watched := range scope.Watched {
+		if watched == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// operatorScopeOf reads the namespaces that deployment watches from the
+// environment of its containers. PGO watches every namespace unless
+// PGO_TARGET_NAMESPACE or PGO_TARGET_NAMESPACES is set.
+func operatorScopeOf(deployment *appsv1.Deployment) operatorScope {
+	scope := operatorScope{Namespace: deployment.Namespace, Name: deployment.Name}
+	seen := map[string]bool{}
+
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		for _, variable := range container.Env {
+			if variable.Name != "PGO_TARGET_NAMESPACE" && variable.Name != "PGO_TARGET_NAMESPACES" {
+				continue
+			}
+			values := strings.Split(variable.Value, ",")
+			if ref := variable.ValueFrom; ref != nil && ref.FieldRef != nil &&
+				ref.FieldRef.FieldPath == "metadata.namespace" {
+				// Single namespace installers point the operator at its own
+				// namespace this way.
+				values = []string{deployment.Namespace}
+			}
+			for _, value := range values {
+				if value = strings.TrimSpace(value); value != "" && !seen[value] {
+					seen[value] = true
+					scope.Watched = append(scope.Watched, value)
+				}
+			}
+		}
+	}
+
+	sort.Strings(scope.Watched)
+	return scope
+}
+
+// detectOperatorScopes returns the scopes of every deployment of PGO that
+// the user can see. Deployments are found by the control-plane label.
+func detectOperatorScopes(ctx context.Context, clientset kubernetes.Interface) ([]operatorScope, error) {
+	deployments, err := clientset.AppsV1().Deployments(corev1.NamespaceAll).List(ctx,
+		metav1.ListOptions{LabelSelector: util.LabelOperator})
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := make([]operatorScope, 0, len(deployments.Items))
+	for i := range deployments.Items {
+		scopes = append(scopes, operatorScopeOf(&deployments.Items[i]))
+	}
+	return scopes, nil
+}
+
+// operatorNamespaceFor returns the namespace of the operator that reconciles
+// clusters in namespace, or namespace itself when that cannot be found. An
+// operator that names namespace is preferred over one that watches them all.
+func operatorNamespaceFor(ctx context.Context, clientset kubernetes.Interface, namespace string) string {
+	scopes, err := detectOperatorScopes(ctx, clientset)
+	if err != nil {
+		return namespace
+	}
+	found := namespace
+	for _, scope := range scopes {
+		if scope.Watches(namespace) {
+			if !scope.ClusterWide() {
+				return scope.Namespace
+			}
+			found = scope.Namespace
+		}
+	}
+	return found
+}
+
+// unwatchedNamespaceWarning explains that no operator in scopes reconciles
+// clusters in namespace and where they could go instead. It returns nothing
+// when some operator watches namespace or none was found.
+func unwatchedNamespaceWarning(
+	messages *internal.MessageConfig, scopes []operatorScope, namespace string,
+) string {
+	if len(scopes) == 0 {
+		return ""
+	}
+	for _, scope := range scopes {
+		if scope.Watches(namespace) {
+			return ""
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(messages.Sprintf("scope.warn-unwatched", namespace))
+	for _, scope := range scopes {
+		b.WriteString(messages.Sprintf("scope.watches",
+			scope.Name, scope.Namespace, strings.Join(scope.Watched, ", ")))
+	}
+	b.WriteString(messages.Sprintf("scope.hint-unwatched", scopes[0].Watched[0], namespace))
+	return b.String()
+}
@@ -0,0 +1,87 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+func operatorDeployment(namespace string, env ...corev1.EnvVar) *appsv1.Deployment {
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Namespace: namespace, Name: "pgo",
+		Labels: map[string]string{util.LabelOperator: "postgres-operator"},
+	}}
+	deployment.Spec.Template.Spec.Containers = []corev1.Container{{Name: "operator", Env: env}}
+	return deployment
+}
+
+func TestOperatorScopeOf(t *testing.T) {
+	t.Run("ClusterWide", func(t *testing.T) {
+		scope := operatorScopeOf(operatorDeployment("pgo",
+			corev1.EnvVar{Name: "CRUNCHY_DEBUG", Value: "true"}))
+		assert.Assert(t, scope.ClusterWide())
+		assert.Assert(t, scope.Watches("anything"))
+	})
+
+	t.Run("List", func(t *testing.T) {
+		scope := operatorScopeOf(operatorDeployment("pgo",
+			corev1.EnvVar{Name: "PGO_TARGET_NAMESPACES", Value: "zoo, apps,,zoo"}))
+		assert.DeepEqual(t, scope.Watched, []string{"apps", "zoo"})
+		assert.Assert(t, scope.Watches("apps"))
+		assert.Assert(t, !scope.Watches("pgo"))
+	})
+
+	t.Run("OwnNamespace", func(t *testing.T) {
+		scope := operatorScopeOf(operatorDeployment("pgo", corev1.EnvVar{
+			Name: "PGO_TARGET_NAMESPACE",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"},
+			},
+		}))
+		assert.DeepEqual(t, scope.Watched, []string{"pgo"})
+	})
+}
+
+func TestUnwatchedNamespaceWarning(t *testing.T) {
+	single := operatorScope{Namespace: "pgo", Name: "pgo", Watched: []string{"apps", "pgo"}}
+
+	assert.Equal(t, unwatchedNamespaceWarning(&internal.MessageConfig{}, nil, "default"), "")
+	assert.Equal(t, unwatchedNamespaceWarning(&internal.MessageConfig{}, []operatorScope{single}, "apps"), "")
+	assert.Equal(t, unwatchedNamespaceWarning(&internal.MessageConfig{}, []operatorScope{
+		single, {Namespace: "other", Name: "pgo"},
+	}, "default"), "")
+
+	assert.Equal(t, unwatchedNamespaceWarning(&internal.MessageConfig{}, []operatorScope{single}, "default"), ""+
+		`WARNING: No operator watches namespace "default"; a PostgresCluster there will never be reconciled.`+"\n"+
+		`  deployments/pgo in namespace "pgo" watches: apps, pgo`+"\n"+
+		`Use --namespace=apps to create it in a watched namespace, or add "default" to PGO_TARGET_NAMESPACES of the operator.`+"\n")
+}
+
+func TestOperatorNamespaceFor(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset(
+		operatorDeployment("pgo",
+			corev1.EnvVar{Name: "PGO_TARGET_NAMESPACES", Value: "apps"}),
+		operatorDeployment("pgo-all"),
+	)
+
+	scopes, err := detectOperatorScopes(ctx, clientset)
+	assert.NilError(t, err)
+	assert.Equal(t, len(scopes), 2)
+
+	assert.Equal(t, operatorNamespaceFor(ctx, clientset, "apps"), "pgo")
+	assert.Equal(t, operatorNamespaceFor(ctx, clientset, "other"), "pgo-all")
+	assert.Equal(t, operatorNamespaceFor(ctx, fake.NewSimpleClientset(), "other"), "other")
+}
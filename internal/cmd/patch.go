@@ -0,0 +1,235 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+)
+
+// newPatchCommand returns the patch subcommand of the PGO plugin. It changes
+// fields of a PostgresCluster that have no command of their own.
+func newPatchCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "patch CLUSTER_NAME",
+		Short: "Patch a PostgresCluster after validating the change",
+		Long: `Patch a PostgresCluster with a JSON patch or a JSON merge patch, like
+"kubectl patch", for fields that have no command of their own.
+
+The patch is first sent as a dry run with strict field validation, so the
+Kubernetes API checks it against the schema of the PostgresCluster CRD and
+reports unknown fields, which it otherwise drops without an error. The patched
+cluster it returns is checked against the rules of PGO, and the difference
+between the cluster and the patched cluster is printed and must be confirmed.
+The patch fails when the cluster changes in the meantime.
+
+Patches are added to the history of the cluster; see "pgo history".
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    postgresclusters.postgres-operator.crunchydata.com  [get patch]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Set a field without a dedicated command
+pgo patch hippo --type=json \
+  -p '[{"op":"add","path":"/spec/instances/0/priorityClassName","value":"database"}]'
+
+# Read a merge patch from a file
+pgo patch hippo --type=merge --patch-file=patch.yaml
+
+### Example output
+--- postgresclusters/hippo
++++ postgresclusters/hippo (patched)
+@@ -29,6 +29,7 @@
+     metadata: {}
+     minAvailable: 1
+     name: "00"
++    priorityClassName: database
+     replicas: 1
+   port: 5432
+   postgresVersion: 16
+Are you sure you want to continue? (yes/no): yes
+postgresclusters/hippo patched`)
+
+	var patchType, patchText, patchFile string
+	cmd.Flags().StringVar(&patchType, "type", "json", "type of patch. types supported: json,merge")
+	cmd.Flags().StringVarP(&patchText, "patch", "p", "", "the patch, as JSON or YAML")
+	cmd.Flags().StringVar(&patchFile, "patch-file", "", "path to a file that contains the patch")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		if patchType != "json" && patchType != "merge" {
+			return fmt.Errorf("unknown type %q; types supported: json,merge", patchType)
+		}
+		patch := []byte(patchText)
+		switch {
+		case patchText != "" && patchFile != "":
+			return errors.New("only one of --patch or --patch-file can be used")
+		case patchFile != "":
+			b, err := os.ReadFile(patchFile)
+			if err != nil {
+				return err
+			}
+			patch = b
+		case patchText == "":
+			return errors.New("one of --patch or --patch-file is required")
+		}
+		patch, err := yaml.YAMLToJSON(patch)
+		if err != nil {
+			return fmt.Errorf("unable to parse the patch: %w", err)
+		}
+
+		mapping, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		current, err := client.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		body, kind, err := preconditionPatch(patchType, patch, current.GetResourceVersion())
+		if err != nil {
+			return err
+		}
+		send := func(opts metav1.PatchOptions) (*unstructured.Unstructured, error) {
+			// Kubernetes drops unknown fields without an error unless
+			// validation is strict.
+			opts.FieldValidation = metav1.FieldValidationStrict
+			patched, err := client.Namespace(namespace).Patch(ctx, args[0], kind, body,
+				config.Patch.PatchOptions(opts))
+			if err != nil && (apierrors.IsConflict(err) ||
+				apierrors.IsInvalid(err) && strings.Contains(err.Error(), "resourceVersion")) {
+				cmd.Printf("%s/%s changed since it was read; run the command again to see the new difference\n",
+					mapping.Resource.Resource, args[0])
+			}
+			return patched, err
+		}
+
+		// The Kubernetes API patches, defaults, and validates the cluster
+		// without storing it.
+		patched, err := send(metav1.PatchOptions{DryRun: []string{metav1.DryRunAll}})
+		if err != nil {
+			return err
+		}
+
+		diff, err := clusterPatchDiff(mapping.Resource.Resource+"/"+args[0], current, patched)
+		if err != nil {
+			return err
+		}
+		if diff == "" {
+			cmd.Printf("%s/%s unchanged\n", mapping.Resource.Resource, args[0])
+			return nil
+		}
+		if err := checkClusterProposal(cmd.ErrOrStderr(), &config.Messages, current, patched); err != nil {
+			return err
+		}
+
+		cmd.Print(diff)
+		_, _ = fmt.Fprint(config.ErrOut, config.Messages.Sprintf("confirm.continue"))
+		var confirmed *bool
+		for i := 0; confirmed == nil && i < 10; i++ {
+			// retry 10 times or until a confirmation is given or denied,
+			// whichever comes first
+			confirmed = config.Messages.Confirm(config.In, config.ErrOut)
+		}
+		if confirmed == nil || !*confirmed {
+			return ErrCancelled
+		}
+
+		if _, err := send(metav1.PatchOptions{}); err != nil {
+			return err
+		}
+
+		cmd.Printf("%s/%s patched\n", mapping.Resource.Resource, args[0])
+		return nil
+	}
+
+	return cmd
+}
+
+// preconditionPatch returns patch changed so the Kubernetes API rejects it
+// when the cluster is no longer at resourceVersion. This ensures the patch
+// sent is the one whose difference was confirmed.
+func preconditionPatch(patchType string, patch []byte, resourceVersion string) ([]byte, types.PatchType, error) {
+	if patchType == "merge" {
+		var object map[string]any
+		if err := json.Unmarshal(patch, &object); err != nil {
+			return nil, "", err
+		}
+		if object == nil {
+			object = map[string]any{}
+		}
+		metadata, _ := object["metadata"].(map[string]any)
+		if metadata == nil {
+			metadata = map[string]any{}
+		}
+		metadata["resourceVersion"] = resourceVersion
+		object["metadata"] = metadata
+		b, err := json.Marshal(object)
+		return b, types.MergePatchType, err
+	}
+
+	var operations []any
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, "", err
+	}
+	operations = append([]any{map[string]any{
+		"op": "test", "path": "/metadata/resourceVersion", "value": resourceVersion,
+	}}, operations...)
+	b, err := json.Marshal(operations)
+	return b, types.JSONPatchType, err
+}
+
+// clusterPatchDiff returns the difference between current and patched as a
+// unified diff of their YAML, or an empty string when they are the same.
+// Fields that Kubernetes changes on every write are left out.
+func clusterPatchDiff(name string, current, patched *unstructured.Unstructured) (string, error) {
+	text := func(object *unstructured.Unstructured) ([]string, error) {
+		object = object.DeepCopy()
+		unstructured.RemoveNestedField(object.Object, "metadata", "generation")
+		unstructured.RemoveNestedField(object.Object, "metadata", "managedFields")
+		b, err := yaml.Marshal(object.Object)
+		return difflib.SplitLines(string(b)), err
+	}
+
+	before, err := text(current)
+	if err != nil {
+		return "", err
+	}
+	after, err := text(patched)
+	if err != nil {
+		return "", err
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A: before, FromFile: name,
+		B: after, ToFile: name + " (patched)",
+		Context: 3,
+	})
+}
@@ -0,0 +1,51 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestPreconditionPatch(t *testing.T) {
+	body, kind, err := preconditionPatch("json", []byte(`[{"op":"remove","path":"/spec/port"}]`), "123")
+	assert.NilError(t, err)
+	assert.Equal(t, kind, types.JSONPatchType)
+	assert.Equal(t, string(body),
+		`[{"op":"test","path":"/metadata/resourceVersion","value":"123"},{"op":"remove","path":"/spec/port"}]`)
+
+	body, kind, err = preconditionPatch("merge", []byte(`{"spec":{"port":5433}}`), "123")
+	assert.NilError(t, err)
+	assert.Equal(t, kind, types.MergePatchType)
+	assert.Equal(t, string(body), `{"metadata":{"resourceVersion":"123"},"spec":{"port":5433}}`)
+}
+
+func TestClusterPatchDiff(t *testing.T) {
+	current, err := generateUnstructuredClusterYaml("hippo", "16")
+	assert.NilError(t, err)
+	assert.NilError(t, unstructured.SetNestedField(current.Object, []any{map[string]any{"manager": "kubectl"}},
+		"metadata", "managedFields"))
+
+	diff, err := clusterPatchDiff("postgresclusters/hippo", current, current)
+	assert.NilError(t, err)
+	assert.Equal(t, diff, "")
+
+	// Kubernetes increments the generation of a patched cluster.
+	patched := current.DeepCopy()
+	patched.SetGeneration(current.GetGeneration() + 1)
+	assert.NilError(t, unstructured.SetNestedField(patched.Object, int64(17), "spec", "postgresVersion"))
+
+	diff, err = clusterPatchDiff("postgresclusters/hippo", current, patched)
+	assert.NilError(t, err)
+	assert.Assert(t, strings.HasPrefix(diff,
+		"--- postgresclusters/hippo\n+++ postgresclusters/hippo (patched)\n"), "diff:\n%s", diff)
+	assert.Assert(t, strings.Contains(diff, "\n-  postgresVersion: 16\n+  postgresVersion: 17\n"), "diff:\n%s", diff)
+	assert.Assert(t, !strings.Contains(diff, "managedFields"), "diff:\n%s", diff)
+	assert.Assert(t, !strings.Contains(diff, "generation"), "diff:\n%s", diff)
+}
@@ -0,0 +1,229 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+)
+
+// newPauseCommand returns the pause subcommand of the PGO plugin.
+func newPauseCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pause",
+		Short: "Pause PGO activity on a PostgresCluster",
+		Long:  "Pause PGO activity on a PostgresCluster until it is resumed.",
+	}
+
+	cmd.AddCommand(
+		newPauseBackupScheduleCommand(config),
+		newPauseReconcileCommand(config),
+	)
+
+	return cmd
+}
+
+// newPauseReconcileCommand returns the reconcile subcommand of the pause
+// command. It stops PGO from reconciling a cluster.
+func newPauseReconcileCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reconcile CLUSTER_NAME",
+		Short: "Stop PGO from reconciling a PostgresCluster",
+		Long: `Pause reconciliation sets the spec.paused field to true. PGO then applies no
+changes to the PostgresCluster and its objects, such as while you repair
+something by hand. Postgres keeps running.
+
+Resume with "pgo resume reconcile". Until then, "pgo check ready-for-release"
+fails and reports how long reconciliation has been paused.
+The --force-conflicts flag may be required if the spec.paused field has been
+set by another client.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    postgresclusters.postgres-operator.crunchydata.com  [get patch]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Pause reconciliation of the 'hippo' postgrescluster
+pgo pause reconcile hippo
+
+### Example output
+postgresclusters/hippo reconciliation paused`)
+
+	var forceConflicts bool
+	cmd.Flags().BoolVar(&forceConflicts, "force-conflicts", false, "take ownership and overwrite the paused setting")
+	config.Record.AddFlags(cmd.Flags())
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return applyClusterPaused(cmd, config, args[0], true, forceConflicts)
+	}
+
+	return cmd
+}
+
+// applyClusterPaused sets spec.paused of the cluster called clusterName to
+// paused, or records that change, and prints the outcome.
+func applyClusterPaused(
+	cmd *cobra.Command, config *internal.Config, clusterName string, paused, forceConflicts bool,
+) error {
+	ctx := context.Background()
+	now := time.Now()
+
+	mapping, client, err := v1beta1.NewPostgresClusterClient(config)
+	if err != nil {
+		return err
+	}
+	namespace, err := config.Namespace()
+	if err != nil {
+		return err
+	}
+
+	cluster, err := client.Namespace(namespace).Get(ctx, clusterName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	pause := clusterPaused(cluster)
+	if pause.Paused == paused {
+		if paused {
+			cmd.Printf("Reconciliation already %s. Nothing to do.\n", pause.describe(now))
+		} else {
+			cmd.Println("Reconciliation is not paused. Nothing to do.")
+		}
+		return nil
+	}
+
+	intent := new(unstructured.Unstructured)
+	if err := internal.ExtractFieldsInto(cluster, intent, config.Patch.FieldManager); err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedField(intent.Object, paused, "spec", "paused"); err != nil {
+		return err
+	}
+	if err := checkClusterChange(cmd.ErrOrStderr(), &config.Messages, cluster, intent); err != nil {
+		return err
+	}
+
+	// Save the change for later rather than sending it.
+	if config.Record.Enabled() {
+		change := internal.NewRecordedChange(internal.RecordApply,
+			mapping.Resource, namespace, clusterName, intent)
+		change.Force = forceConflicts
+		msg, err := recordChange(config, change)
+		cmd.Print(msg)
+		return err
+	}
+
+	patch, err := intent.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	patchOptions := metav1.PatchOptions{}
+	if forceConflicts {
+		b := true
+		patchOptions.Force = &b
+	}
+
+	_, err = client.Namespace(namespace).Patch(ctx, clusterName,
+		types.ApplyPatchType, patch, config.Patch.PatchOptions(patchOptions))
+	if err != nil {
+		if apierrors.IsConflict(err) {
+			cmd.PrintErrln("SUGGESTION: The --force-conflicts flag may help in performing this operation.")
+		}
+		return err
+	}
+
+	if paused {
+		cmd.Printf("%s/%s reconciliation paused\n", mapping.Resource.Resource, clusterName)
+	} else if pause.Since.IsZero() {
+		cmd.Printf("%s/%s reconciliation resumed\n", mapping.Resource.Resource, clusterName)
+	} else {
+		cmd.Printf("%s/%s reconciliation resumed after %s\n", mapping.Resource.Resource, clusterName,
+			duration.HumanDuration(now.Sub(pause.Since)))
+	}
+	return nil
+}
+
+// clusterPause is whether reconciliation of a PostgresCluster is paused.
+type clusterPause struct {
+	Paused bool
+
+	// Since is when reconciliation was paused, or zero when that is unknown.
+	Since time.Time
+}
+
+// clusterPaused reads spec.paused of cluster and when it was set. PGO reports
+// a pause as a Progressing condition with reason Paused. Until it does, the
+// time comes from the field manager that set spec.paused.
+func clusterPaused(cluster *unstructured.Unstructured) clusterPause {
+	var pause clusterPause
+	pause.Paused, _, _ = unstructured.NestedBool(cluster.Object, "spec", "paused")
+	if !pause.Paused {
+		return pause
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(cluster.Object, "status", "conditions")
+	for _, item := range conditions {
+		if condition, ok := item.(map[string]any); ok &&
+			condition["type"] == "Progressing" && condition["reason"] == "Paused" {
+			if value, ok := condition["lastTransitionTime"].(string); ok {
+				if since, err := time.Parse(time.RFC3339, value); err == nil {
+					pause.Since = since
+					return pause
+				}
+			}
+		}
+	}
+
+	for _, entry := range cluster.GetManagedFields() {
+		var fields struct {
+			Spec map[string]any `json:"f:spec"`
+		}
+		if entry.FieldsV1 == nil || entry.Time == nil ||
+			json.Unmarshal(entry.FieldsV1.Raw, &fields) != nil {
+			continue
+		}
+		if _, ok := fields.Spec["f:paused"]; ok && entry.Time.After(pause.Since) {
+			pause.Since = entry.Time.UTC()
+		}
+	}
+	return pause
+}
+
+// describe returns a phrase such as "paused for 2h, since 2024-05-01T10:00:00Z".
+func (pause clusterPause) describe(now time.Time) string {
+	switch {
+	case !pause.Paused:
+		return "not paused"
+	case pause.Since.IsZero():
+		return "paused"
+	}
+	return fmt.Sprintf("paused for %s, since %s", duration.HumanDuration(now.Sub(pause.Since)),
+		pause.Since.UTC().Format(time.RFC3339))
+}
+
+// status returns a sentence such as "reconciliation is not paused" that also
+// says how to resume a paused cluster.
+func (pause clusterPause) status(now time.Time) string {
+	if !pause.Paused {
+		return "reconciliation is not paused"
+	}
+	return "reconciliation " + pause.describe(now) + `; resume with "pgo resume reconcile"`
+}
@@ -0,0 +1,289 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newPauseBackupScheduleCommand returns the backupschedule subcommand of the
+// pause command. It stops the scheduled backups of a cluster.
+func newPauseBackupScheduleCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backupschedule CLUSTER_NAME",
+		Short: "Stop the scheduled backups of a PostgresCluster",
+		Long: `Pause backup schedules moves them from the spec of a PostgresCluster to an
+annotation. PGO then removes their CronJobs, and no scheduled backups run
+until you resume them with "pgo resume backupschedule".
+
+Choose schedules with "--repoName" and "--type"; without them, every schedule
+is paused. Only schedules set by pgo can be paused. Those set by another
+client, such as "kubectl apply", must be changed there.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    postgresclusters.postgres-operator.crunchydata.com  [get patch]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Pause every backup schedule of the 'hippo' postgrescluster
+pgo pause backupschedule hippo
+
+# Pause only the incremental backups to repo1
+pgo pause backupschedule hippo --repoName=repo1 --type=incr
+
+### Example output
+postgresclusters/hippo backup schedules paused`)
+
+	var selector backupScheduleSelector
+	var forceConflicts bool
+	selector.AddFlags(cmd.Flags())
+	cmd.Flags().BoolVar(&forceConflicts, "force-conflicts", false,
+		"take ownership and overwrite the paused schedules annotation")
+	config.Record.AddFlags(cmd.Flags())
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := selector.validate(); err != nil {
+			return err
+		}
+		return ensureClusterPart(cmd, config, args[0], selector.describe(), forceConflicts, selector.pause)
+	}
+
+	return cmd
+}
+
+// backupScheduleSelector chooses backup schedules by the "--repoName" and
+// "--type" flags. An empty field matches every repository or type.
+type backupScheduleSelector struct {
+	Repo, Type string
+}
+
+func (selector *backupScheduleSelector) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&selector.Repo, "repoName", "", "name of the pgBackRest repository, e.g. repo1")
+	flags.StringVar(&selector.Type, "type", "", "type of backup. types supported: full,diff,incr")
+}
+
+// validate returns an error when a flag names no repository or type.
+func (selector backupScheduleSelector) validate() error {
+	if selector.Repo != "" && !repoNamePattern.MatchString(selector.Repo) {
+		return fmt.Errorf("--repoName must be repo1, repo2, repo3, or repo4")
+	}
+	if selector.Type != "" && backupScheduleFields[selector.Type] == "" {
+		return fmt.Errorf("--type must be one of full, diff, or incr")
+	}
+	return nil
+}
+
+// describe returns what selector chooses, e.g. "repo1 incr backup schedule".
+func (selector backupScheduleSelector) describe() string {
+	switch {
+	case selector.Repo != "" && selector.Type != "":
+		return selector.Repo + " " + selector.Type + " backup schedule"
+	case selector.Repo != "":
+		return selector.Repo + " backup schedules"
+	case selector.Type != "":
+		return selector.Type + " backup schedules"
+	}
+	return "backup schedules"
+}
+
+// fields returns the fields of "schedules" that selector chooses.
+func (selector backupScheduleSelector) fields() []string {
+	if selector.Type != "" {
+		return []string{backupScheduleFields[selector.Type]}
+	}
+	return []string{"full", "differential", "incremental"}
+}
+
+// backupScheduleRepos are schedules by repository and field of "schedules".
+type backupScheduleRepos map[string]map[string]string
+
+// pausedBackupSchedules returns the schedules in the annotation of cluster.
+func pausedBackupSchedules(cluster *unstructured.Unstructured) (backupScheduleRepos, error) {
+	paused := backupScheduleRepos{}
+	if value, ok := cluster.GetAnnotations()[util.AnnotationPausedBackupSchedules]; ok {
+		if err := json.Unmarshal([]byte(value), &paused); err != nil {
+			return nil, fmt.Errorf("unable to read annotation %s: %w",
+				util.AnnotationPausedBackupSchedules, err)
+		}
+	}
+	return paused, nil
+}
+
+// setPausedBackupSchedules sets the annotation of intent to paused, removing
+// it when paused is empty.
+func setPausedBackupSchedules(intent *unstructured.Unstructured, paused backupScheduleRepos) error {
+	annotations := internal.MergeStringMaps(intent.GetAnnotations())
+	delete(annotations, util.AnnotationPausedBackupSchedules)
+	if len(paused) > 0 {
+		b, err := json.Marshal(paused)
+		if err != nil {
+			return err
+		}
+		annotations[util.AnnotationPausedBackupSchedules] = string(b)
+	}
+	if len(annotations) == 0 {
+		unstructured.RemoveNestedField(intent.Object, "metadata", "annotations")
+		return nil
+	}
+	intent.SetAnnotations(annotations)
+	return nil
+}
+
+// removeSchedules removes the schedules that selector chooses from intent and
+// returns them. It returns an error when one of them was set by another client.
+func (selector backupScheduleSelector) removeSchedules(
+	cluster, intent *unstructured.Unstructured,
+) (backupScheduleRepos, error) {
+	fields := []string{"spec", "backups", "pgbackrest", "repos"}
+	removed := backupScheduleRepos{}
+
+	repos, _, _ := unstructured.NestedSlice(cluster.Object, fields...)
+	owned, _, _ := unstructured.NestedSlice(intent.Object, fields...)
+	for _, entry := range repos {
+		repo, ok := entry.(map[string]any)
+		name, _ := repo["name"].(string)
+		if !ok || (selector.Repo != "" && name != selector.Repo) {
+			continue
+		}
+		for _, field := range selector.fields() {
+			schedule, _, _ := unstructured.NestedString(repo, "schedules", field)
+			if schedule == "" {
+				continue
+			}
+
+			var item map[string]any
+			for _, entry := range owned {
+				if candidate, ok := entry.(map[string]any); ok && candidate["name"] == name {
+					item = candidate
+				}
+			}
+			if mine, _, _ := unstructured.NestedString(item, "schedules", field); mine != schedule {
+				return nil, fmt.Errorf(
+					"the %s %s backup schedule of postgresclusters/%s was set by another client, such as kubectl apply; change it there",
+					name, field, cluster.GetName())
+			}
+
+			unstructured.RemoveNestedField(item, "schedules", field)
+			if schedules, _, _ := unstructured.NestedMap(item, "schedules"); len(schedules) == 0 {
+				delete(item, "schedules")
+			}
+			if removed[name] == nil {
+				removed[name] = map[string]string{}
+			}
+			removed[name][field] = schedule
+		}
+	}
+
+	if len(removed) > 0 {
+		return removed, unstructured.SetNestedSlice(intent.Object, owned, fields...)
+	}
+	return removed, nil
+}
+
+// pause moves the schedules that selector chooses from the spec of intent to
+// its annotation.
+func (selector backupScheduleSelector) pause(cluster, intent *unstructured.Unstructured) (string, error) {
+	paused, err := pausedBackupSchedules(cluster)
+	if err != nil {
+		return "", err
+	}
+	removed, err := selector.removeSchedules(cluster, intent)
+	if err != nil {
+		return "", err
+	}
+	if len(removed) == 0 {
+		if len(selector.matches(paused)) > 0 {
+			return ensureUnchanged, nil
+		}
+		return "", fmt.Errorf("postgresclusters/%s has no %s", cluster.GetName(), selector.describe())
+	}
+
+	for repo, schedules := range removed {
+		if paused[repo] == nil {
+			paused[repo] = map[string]string{}
+		}
+		for field, schedule := range schedules {
+			paused[repo][field] = schedule
+		}
+	}
+	return "paused", setPausedBackupSchedules(intent, paused)
+}
+
+// resume moves the schedules that selector chooses from the annotation of
+// cluster back to the spec of intent.
+func (selector backupScheduleSelector) resume(cluster, intent *unstructured.Unstructured) (string, error) {
+	paused, err := pausedBackupSchedules(cluster)
+	if err != nil {
+		return "", err
+	}
+	chosen := selector.matches(paused)
+	if len(chosen) == 0 {
+		return ensureUnchanged, nil
+	}
+
+	fields := []string{"spec", "backups", "pgbackrest", "repos"}
+	repos, _, _ := unstructured.NestedSlice(cluster.Object, fields...)
+	for _, repo := range chosen {
+		var found bool
+		for _, entry := range repos {
+			if item, ok := entry.(map[string]any); ok && item["name"] == repo {
+				found = true
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("postgresclusters/%s no longer has repository %s", cluster.GetName(), repo)
+		}
+
+		item, list := ownedListItem(intent, repo, fields...)
+		for _, field := range selector.fields() {
+			if schedule, ok := paused[repo][field]; ok {
+				if err := unstructured.SetNestedField(item, schedule, "schedules", field); err != nil {
+					return "", err
+				}
+				delete(paused[repo], field)
+			}
+		}
+		if len(paused[repo]) == 0 {
+			delete(paused, repo)
+		}
+		if err := unstructured.SetNestedSlice(intent.Object, list, fields...); err != nil {
+			return "", err
+		}
+	}
+	return "resumed", setPausedBackupSchedules(intent, paused)
+}
+
+// matches returns the repositories in schedules that have a schedule that
+// selector chooses, in order.
+func (selector backupScheduleSelector) matches(schedules backupScheduleRepos) []string {
+	var repos []string
+	for repo := range schedules {
+		if selector.Repo != "" && repo != selector.Repo {
+			continue
+		}
+		for _, field := range selector.fields() {
+			if _, ok := schedules[repo][field]; ok {
+				repos = append(repos, repo)
+				break
+			}
+		}
+	}
+	sort.Strings(repos)
+	return repos
+}
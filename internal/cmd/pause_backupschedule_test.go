@@ -0,0 +1,132 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+func TestBackupScheduleSelector(t *testing.T) {
+	assert.NilError(t, backupScheduleSelector{}.validate())
+	assert.ErrorContains(t, backupScheduleSelector{Repo: "repo5"}.validate(), "--repoName")
+	assert.ErrorContains(t, backupScheduleSelector{Type: "weekly"}.validate(), "--type")
+
+	assert.Equal(t, backupScheduleSelector{}.describe(), "backup schedules")
+	assert.Equal(t, backupScheduleSelector{Repo: "repo1"}.describe(), "repo1 backup schedules")
+	assert.Equal(t, backupScheduleSelector{Repo: "repo1", Type: "incr"}.describe(),
+		"repo1 incr backup schedule")
+
+	assert.DeepEqual(t, backupScheduleSelector{Type: "diff"}.fields(), []string{"differential"})
+	assert.DeepEqual(t, backupScheduleSelector{Repo: "repo2"}.matches(backupScheduleRepos{
+		"repo1": {"full": "@daily"}, "repo2": {"incremental": "@hourly"},
+	}), []string{"repo2"})
+}
+
+func TestPauseResumeBackupSchedules(t *testing.T) {
+	spec := `
+metadata: { name: hippo }
+spec:
+  backups:
+    pgbackrest:
+      repos:
+      - name: repo1
+        volume: {}
+        schedules: { full: "0 1 * * 0", incremental: "0 1 * * 1-6" }
+      - name: repo2
+        s3: { bucket: backups }
+        schedules: { full: "0 2 * * *" }
+`
+	cluster := guardrailCluster(t, spec)
+
+	// This client set the schedules of repo1 but not those of repo2.
+	owned := func() *unstructured.Unstructured {
+		return guardrailCluster(t, `
+metadata: { name: hippo }
+spec:
+  backups:
+    pgbackrest:
+      repos:
+      - name: repo1
+        volume: {}
+        schedules: { full: "0 1 * * 0", incremental: "0 1 * * 1-6" }
+`)
+	}
+
+	t.Run("Pause", func(t *testing.T) {
+		intent := owned()
+		outcome, err := backupScheduleSelector{Repo: "repo1", Type: "incr"}.pause(cluster, intent)
+		assert.NilError(t, err)
+		assert.Equal(t, outcome, "paused")
+
+		repos, _, _ := unstructured.NestedSlice(intent.Object, "spec", "backups", "pgbackrest", "repos")
+		assert.DeepEqual(t, repos, []any{map[string]any{
+			"name": "repo1", "volume": map[string]any{},
+			"schedules": map[string]any{"full": "0 1 * * 0"},
+		}})
+		assert.DeepEqual(t, intent.GetAnnotations(), map[string]string{
+			util.AnnotationPausedBackupSchedules: `{"repo1":{"incremental":"0 1 * * 1-6"}}`,
+		})
+	})
+
+	t.Run("PauseOtherClient", func(t *testing.T) {
+		_, err := backupScheduleSelector{Repo: "repo2"}.pause(cluster, owned())
+		assert.ErrorContains(t, err, "repo2 full backup schedule of postgresclusters/hippo was set by another client")
+	})
+
+	t.Run("PauseNothing", func(t *testing.T) {
+		_, err := backupScheduleSelector{Repo: "repo3"}.pause(cluster, owned())
+		assert.ErrorContains(t, err, "has no repo3 backup schedules")
+	})
+
+	t.Run("Resume", func(t *testing.T) {
+		paused := guardrailCluster(t, spec)
+		paused.SetAnnotations(map[string]string{
+			util.AnnotationPausedBackupSchedules: `{"repo1":{"differential":"0 4 * * *","incremental":"0 1 * * 1-6"}}`,
+		})
+		intent := owned()
+		intent.SetAnnotations(paused.GetAnnotations())
+
+		outcome, err := backupScheduleSelector{Type: "incr"}.resume(paused, intent)
+		assert.NilError(t, err)
+		assert.Equal(t, outcome, "resumed")
+
+		schedules, _, _ := unstructured.NestedSlice(intent.Object, "spec", "backups", "pgbackrest", "repos")
+		assert.DeepEqual(t, schedules[0].(map[string]any)["schedules"], map[string]any{
+			"full": "0 1 * * 0", "incremental": "0 1 * * 1-6",
+		})
+		assert.DeepEqual(t, intent.GetAnnotations(), map[string]string{
+			util.AnnotationPausedBackupSchedules: `{"repo1":{"differential":"0 4 * * *"}}`,
+		})
+
+		outcome, err = backupScheduleSelector{Type: "incr"}.resume(cluster, owned())
+		assert.NilError(t, err)
+		assert.Equal(t, outcome, ensureUnchanged)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		paused := guardrailCluster(t, spec)
+		paused.SetAnnotations(map[string]string{
+			util.AnnotationPausedBackupSchedules: `{"repo1":{"incremental":"0 1 * * 1-6"}}`,
+		})
+		intent := owned()
+		intent.SetAnnotations(paused.GetAnnotations())
+
+		outcome, err := backupScheduleSelector{Repo: "repo1"}.delete(paused, intent)
+		assert.NilError(t, err)
+		assert.Equal(t, outcome, "deleted")
+
+		repos, _, _ := unstructured.NestedSlice(intent.Object, "spec", "backups", "pgbackrest", "repos")
+		assert.DeepEqual(t, repos, []any{map[string]any{"name": "repo1", "volume": map[string]any{}}})
+		assert.Assert(t, intent.GetAnnotations() == nil)
+
+		_, err = backupScheduleSelector{Repo: "repo1", Type: "diff"}.delete(cluster, owned())
+		assert.ErrorContains(t, err, "has no repo1 diff backup schedule")
+	})
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestClusterPaused(t *testing.T) {
+	since := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	now := since.Add(135 * time.Minute)
+
+	t.Run("NotPaused", func(t *testing.T) {
+		cluster := &unstructured.Unstructured{Object: map[string]any{
+			"spec": map[string]any{"paused": false},
+		}}
+		pause := clusterPaused(cluster)
+		assert.Equal(t, pause, clusterPause{})
+		assert.Equal(t, pause.describe(now), "not paused")
+		assert.Equal(t, pause.status(now), "reconciliation is not paused")
+	})
+
+	t.Run("Condition", func(t *testing.T) {
+		cluster := &unstructured.Unstructured{Object: map[string]any{
+			"spec": map[string]any{"paused": true},
+			"status": map[string]any{"conditions": []any{
+				map[string]any{"type": "Ready", "reason": "Paused", "lastTransitionTime": "2024-04-01T00:00:00Z"},
+				map[string]any{"type": "Progressing", "status": "False", "reason": "Paused",
+					"lastTransitionTime": "2024-05-01T10:00:00Z"},
+			}},
+		}}
+		pause := clusterPaused(cluster)
+		assert.Equal(t, pause, clusterPause{Paused: true, Since: since})
+		assert.Equal(t, pause.describe(now), "paused for 135m, since 2024-05-01T10:00:00Z")
+		assert.Equal(t, pause.status(now),
+			`reconciliation paused for 135m, since 2024-05-01T10:00:00Z; resume with "pgo resume reconcile"`)
+	})
+
+	t.Run("ManagedFields", func(t *testing.T) {
+		// PGO has not reported the pause yet.
+		cluster := &unstructured.Unstructured{Object: map[string]any{
+			"spec": map[string]any{"paused": true},
+		}}
+		cluster.SetManagedFields([]metav1.ManagedFieldsEntry{{
+			Manager: "kubectl-pgo", Operation: metav1.ManagedFieldsOperationApply,
+			Time:     &metav1.Time{Time: since},
+			FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:paused":{}}}`)},
+		}, {
+			Manager: "postgres-operator", Operation: metav1.ManagedFieldsOperationUpdate,
+			Time:     &metav1.Time{Time: now},
+			FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:status":{}}`)},
+		}})
+		assert.Equal(t, clusterPaused(cluster), clusterPause{Paused: true, Since: since})
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		cluster := &unstructured.Unstructured{Object: map[string]any{
+			"spec": map[string]any{"paused": true},
+		}}
+		pause := clusterPaused(cluster)
+		assert.Equal(t, pause, clusterPause{Paused: true})
+		assert.Equal(t, pause.describe(now), "paused")
+		assert.Equal(t, pause.status(now), `reconciliation paused; resume with "pgo resume reconcile"`)
+	})
+}
+
+func TestCheckReconciliation(t *testing.T) {
+	since := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	now := since.Add(3 * time.Hour)
+
+	assert.DeepEqual(t, checkReconciliation(clusterPause{}, now), readinessCheck{
+		Name: "reconcile", Healthy: true, Detail: "reconciliation is not paused",
+	})
+	assert.DeepEqual(t, checkReconciliation(clusterPause{Paused: true, Since: since}, now), readinessCheck{
+		Name:   "reconcile",
+		Detail: `reconciliation paused for 3h, since 2024-05-01T10:00:00Z; resume with "pgo resume reconcile"`,
+		Value:  measured(10800),
+	})
+}
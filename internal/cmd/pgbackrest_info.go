@@ -0,0 +1,385 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// pgBackRestStanza is the part of one stanza of "pgbackrest info --output=json"
+// that is rendered as text.
+// - https://pgbackrest.org/command.html#command-info
+type pgBackRestStanza struct {
+	Name   string `json:"name"`
+	Cipher string `json:"cipher"`
+	Status struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Lock    struct {
+			Backup struct {
+				Held bool `json:"held"`
+			} `json:"backup"`
+		} `json:"lock"`
+	} `json:"status"`
+
+	Archive []struct {
+		ID       string `json:"id"`
+		Min      string `json:"min"`
+		Max      string `json:"max"`
+		Database struct {
+			RepoKey int `json:"repo-key"`
+		} `json:"database"`
+	} `json:"archive"`
+
+	Backup []pgBackRestBackup `json:"backup"`
+
+	Repo []struct {
+		Key    int    `json:"key"`
+		Cipher string `json:"cipher"`
+		Status struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"status"`
+	} `json:"repo"`
+}
+
+// pgBackRestBackup is one backup in a [pgBackRestStanza].
+type pgBackRestBackup struct {
+	Label string `json:"label"`
+	Type  string `json:"type"`
+	Error bool   `json:"error"`
+
+	Database struct {
+		RepoKey int `json:"repo-key"`
+	} `json:"database"`
+
+	Timestamp struct {
+		Start int64 `json:"start"`
+		Stop  int64 `json:"stop"`
+	} `json:"timestamp"`
+
+	Info struct {
+		Size       int64 `json:"size"`
+		Delta      int64 `json:"delta"`
+		Repository struct {
+			Size  int64 `json:"size"`
+			Delta int64 `json:"delta"`
+		} `json:"repository"`
+	} `json:"info"`
+}
+
+// pgBackRestInfoFilter selects and orders the backups of "pgbackrest info".
+type pgBackRestInfoFilter struct {
+	// Descending lists the most recent backup first.
+	Descending bool
+
+	// Since, when not zero, drops backups that started longer ago.
+	Since time.Duration
+
+	// Types, when not empty, keeps only backups of these types.
+	Types []string
+}
+
+// IsZero returns true when filter keeps every backup in the order pgBackRest
+// prints them.
+func (filter pgBackRestInfoFilter) IsZero() bool {
+	return !filter.Descending && filter.Since == 0 && len(filter.Types) == 0
+}
+
+// Apply filters and sorts the backups of every stanza in stanzas. The stanzas
+// are generic so that fields not in [pgBackRestStanza] are kept.
+func (filter pgBackRestInfoFilter) Apply(stanzas []map[string]any, now time.Time) {
+	start := func(backup any) int64 {
+		timestamp, _ := backup.(map[string]any)["timestamp"].(map[string]any)
+		number, _ := timestamp["start"].(json.Number)
+		value, _ := number.Int64()
+		return value
+	}
+	keep := func(backup any) bool {
+		if len(filter.Types) > 0 {
+			kind, _ := backup.(map[string]any)["type"].(string)
+			found := false
+			for _, t := range filter.Types {
+				found = found || t == kind
+			}
+			if !found {
+				return false
+			}
+		}
+		return filter.Since == 0 || start(backup) >= now.Add(-filter.Since).Unix()
+	}
+
+	for _, stanza := range stanzas {
+		backups, _ := stanza["backup"].([]any)
+		kept := make([]any, 0, len(backups))
+		for _, backup := range backups {
+			if _, ok := backup.(map[string]any); ok && keep(backup) {
+				kept = append(kept, backup)
+			}
+		}
+		sort.SliceStable(kept, func(i, j int) bool {
+			if filter.Descending {
+				return start(kept[i]) > start(kept[j])
+			}
+			return start(kept[i]) < start(kept[j])
+		})
+		stanza["backup"] = kept
+	}
+}
+
+// parsePGBackRestInfo decodes the output of "pgbackrest info --output=json".
+// Numbers are kept as written so that large identifiers are not rounded.
+func parsePGBackRestInfo(stdout string) ([]map[string]any, error) {
+	var stanzas []map[string]any
+	decoder := json.NewDecoder(strings.NewReader(stdout))
+	decoder.UseNumber()
+	if err := decoder.Decode(&stanzas); err != nil {
+		return nil, fmt.Errorf("unable to read pgbackrest info: %w", err)
+	}
+	return stanzas, nil
+}
+
+// typedPGBackRestInfo converts the generic stanzas of [parsePGBackRestInfo].
+func typedPGBackRestInfo(stanzas []map[string]any) ([]pgBackRestStanza, error) {
+	b, err := json.Marshal(stanzas)
+	if err != nil {
+		return nil, err
+	}
+	var typed []pgBackRestStanza
+	if err := json.Unmarshal(b, &typed); err != nil {
+		return nil, fmt.Errorf("unable to read pgbackrest info: %w", err)
+	}
+	return typed, nil
+}
+
+// renderPGBackRestInfo writes one section per stanza with a table of its
+// backups.
+func renderPGBackRestInfo(out io.Writer, stanzas []map[string]any) error {
+	typed, err := typedPGBackRestInfo(stanzas)
+	if err != nil {
+		return err
+	}
+
+	var buffer bytes.Buffer
+	for i, stanza := range typed {
+		if i > 0 {
+			buffer.WriteString("\n")
+		}
+
+		status := "ok"
+		if stanza.Status.Code != 0 {
+			status = "error (" + stanza.Status.Message + ")"
+		}
+		if stanza.Status.Lock.Backup.Held {
+			status += " (backup/expire running)"
+		}
+		fmt.Fprintf(&buffer, "stanza: %s\n    status: %s\n    cipher: %s\n",
+			stanza.Name, status, stanza.Cipher)
+
+		for _, archive := range stanza.Archive {
+			fmt.Fprintf(&buffer, "    wal archive min/max (%s, repo%d): %s/%s\n",
+				archive.ID, archive.Database.RepoKey, archive.Min, archive.Max)
+		}
+
+		buffer.WriteString("\n")
+		if len(stanza.Backup) == 0 {
+			buffer.WriteString("    no backups\n")
+			continue
+		}
+
+		writer := tabwriter.NewWriter(&buffer, 0, 2, 2, ' ', 0)
+		_, _ = fmt.Fprintln(writer, "    LABEL\tTYPE\tREPO\tSTART\tSTOP\tDATABASE SIZE\tBACKUP SIZE\tREPO SIZE")
+		for _, backup := range stanza.Backup {
+			kind := backup.Type
+			if backup.Error {
+				kind += " (error)"
+			}
+			_, _ = fmt.Fprintf(writer, "    %s\t%s\trepo%d\t%s\t%s\t%s\t%s\t%s\n",
+				backup.Label, kind, backup.Database.RepoKey,
+				formatUnixTime(backup.Timestamp.Start), formatUnixTime(backup.Timestamp.Stop),
+				formatBytes(backup.Info.Size), formatBytes(backup.Info.Delta),
+				formatBytes(backup.Info.Repository.Delta))
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+	}
+
+	_, err = out.Write(buffer.Bytes())
+	return err
+}
+
+// pgBackRestInfoDiff is what changed in one stanza between two snapshots of
+// "pgbackrest info".
+type pgBackRestInfoDiff struct {
+	Name    string             `json:"name"`
+	Added   []pgBackRestBackup `json:"added"`
+	Expired []pgBackRestBackup `json:"expired"`
+	Repos   []repoSizeChange   `json:"repo"`
+}
+
+// repoSizeChange is the total size of the backups in one repository before
+// and after.
+type repoSizeChange struct {
+	Key    int   `json:"key"`
+	Before int64 `json:"before"`
+	After  int64 `json:"after"`
+}
+
+// diffPGBackRestInfo compares the stanzas of before and after. A stanza in
+// only one of them is compared to one without backups.
+func diffPGBackRestInfo(before, after []pgBackRestStanza) []pgBackRestInfoDiff {
+	type key struct {
+		repo  int
+		label string
+	}
+	backups := func(stanza pgBackRestStanza) map[key]bool {
+		result := make(map[key]bool, len(stanza.Backup))
+		for _, backup := range stanza.Backup {
+			result[key{backup.Database.RepoKey, backup.Label}] = true
+		}
+		return result
+	}
+	sizes := func(stanza pgBackRestStanza, into map[int]*repoSizeChange, after bool) {
+		for _, backup := range stanza.Backup {
+			change := into[backup.Database.RepoKey]
+			if change == nil {
+				change = &repoSizeChange{Key: backup.Database.RepoKey}
+				into[change.Key] = change
+			}
+			if after {
+				change.After += backup.Info.Repository.Delta
+			} else {
+				change.Before += backup.Info.Repository.Delta
+			}
+		}
+	}
+
+	var names []string
+	previous := map[string]pgBackRestStanza{}
+	for _, stanza := range before {
+		previous[stanza.Name] = stanza
+		names = append(names, stanza.Name)
+	}
+	current := map[string]pgBackRestStanza{}
+	for _, stanza := range after {
+		if _, ok := previous[stanza.Name]; !ok {
+			names = append(names, stanza.Name)
+		}
+		current[stanza.Name] = stanza
+	}
+
+	diffs := make([]pgBackRestInfoDiff, 0, len(names))
+	for _, name := range names {
+		then, now := previous[name], current[name]
+		diff := pgBackRestInfoDiff{Name: name}
+
+		kept := backups(then)
+		for _, backup := range now.Backup {
+			if !kept[key{backup.Database.RepoKey, backup.Label}] {
+				diff.Added = append(diff.Added, backup)
+			}
+		}
+		kept = backups(now)
+		for _, backup := range then.Backup {
+			if !kept[key{backup.Database.RepoKey, backup.Label}] {
+				diff.Expired = append(diff.Expired, backup)
+			}
+		}
+
+		repos := map[int]*repoSizeChange{}
+		sizes(then, repos, false)
+		sizes(now, repos, true)
+		for _, change := range repos {
+			diff.Repos = append(diff.Repos, *change)
+		}
+		sort.Slice(diff.Repos, func(i, j int) bool { return diff.Repos[i].Key < diff.Repos[j].Key })
+
+		diffs = append(diffs, diff)
+	}
+	return diffs
+}
+
+// renderPGBackRestInfoDiff writes one section per stanza with a table of the
+// backups added and expired and a table of repository sizes.
+func renderPGBackRestInfoDiff(out io.Writer, diffs []pgBackRestInfoDiff) error {
+	var buffer bytes.Buffer
+	for i, diff := range diffs {
+		if i > 0 {
+			buffer.WriteString("\n")
+		}
+		fmt.Fprintf(&buffer, "stanza: %s\n\n", diff.Name)
+
+		writer := tabwriter.NewWriter(&buffer, 0, 2, 2, ' ', 0)
+		if len(diff.Added)+len(diff.Expired) == 0 {
+			buffer.WriteString("    no backups added or expired\n")
+		} else {
+			_, _ = fmt.Fprintln(writer, "    CHANGE\tLABEL\tTYPE\tREPO\tSTART\tREPO SIZE")
+			for _, change := range []struct {
+				name    string
+				backups []pgBackRestBackup
+			}{{"added", diff.Added}, {"expired", diff.Expired}} {
+				for _, backup := range change.backups {
+					_, _ = fmt.Fprintf(writer, "    %s\t%s\t%s\trepo%d\t%s\t%s\n",
+						change.name, backup.Label, backup.Type, backup.Database.RepoKey,
+						formatUnixTime(backup.Timestamp.Start), formatBytes(backup.Info.Repository.Delta))
+				}
+			}
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+		}
+
+		if len(diff.Repos) > 0 {
+			buffer.WriteString("\n")
+			_, _ = fmt.Fprintln(writer, "    REPO\tBEFORE\tAFTER\tCHANGE")
+			for _, repo := range diff.Repos {
+				change := formatBytes(repo.After - repo.Before)
+				if repo.After < repo.Before {
+					change = "-" + formatBytes(repo.Before-repo.After)
+				} else if repo.After > repo.Before {
+					change = "+" + change
+				}
+				_, _ = fmt.Fprintf(writer, "    repo%d\t%s\t%s\t%s\n",
+					repo.Key, formatBytes(repo.Before), formatBytes(repo.After), change)
+			}
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := out.Write(buffer.Bytes())
+	return err
+}
+
+// formatUnixTime returns seconds since the epoch in RFC 3339 format in UTC.
+func formatUnixTime(seconds int64) string {
+	return time.Unix(seconds, 0).UTC().Format("2006-01-02T15:04:05Z")
+}
+
+// formatBytes returns size in the largest binary unit that keeps it at or
+// above one, with one decimal.
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	value, suffix := float64(size)/unit, "KiB"
+	for _, next := range []string{"MiB", "GiB", "TiB", "PiB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return fmt.Sprintf("%.1f%s", value, suffix)
+}
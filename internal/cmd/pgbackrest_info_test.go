@@ -0,0 +1,160 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+// pgBackRestInfoJSON has one stanza with three backups in the order pgBackRest
+// prints them: oldest first.
+const pgBackRestInfoJSON = `[{"archive":[{"database":{"id":1,"repo-key":1},"id":"16-1",
+"max":"000000010000000000000004","min":"000000010000000000000001"}],
+"backup":[
+{"database":{"id":1,"repo-key":1},"error":false,"label":"20231023-201416F",
+ "info":{"delta":33554432,"repository":{"delta":4194304,"size":4194304},"size":33554432},
+ "timestamp":{"start":1698092056,"stop":1698092072},"type":"full"},
+{"database":{"id":1,"repo-key":1},"error":false,"label":"20231023-201416F_20231024-130000D",
+ "info":{"delta":1258291,"repository":{"delta":314572,"size":4508876},"size":33973862},
+ "timestamp":{"start":1698152400,"stop":1698152409},"type":"diff"},
+{"database":{"id":1,"repo-key":2},"error":true,"label":"20231025-000000F",
+ "info":{"delta":512,"repository":{"delta":100,"size":100},"size":512},
+ "timestamp":{"start":1698192000,"stop":1698192001},"type":"full"}],
+"cipher":"none","db":[{"id":1,"repo-key":1,"system-id":7293418154175127615,"version":"16"}],
+"name":"db","repo":[{"cipher":"none","key":1,"status":{"code":0,"message":"ok"}}],
+"status":{"code":0,"lock":{"backup":{"held":false}},"message":"ok"}}]`
+
+func TestParsePGBackRestInfo(t *testing.T) {
+	_, err := parsePGBackRestInfo("not json")
+	assert.ErrorContains(t, err, "unable to read pgbackrest info")
+
+	stanzas, err := parsePGBackRestInfo(pgBackRestInfoJSON)
+	assert.NilError(t, err)
+	assert.Equal(t, len(stanzas), 1)
+
+	// Large numbers survive a round trip.
+	b, err := json.Marshal(stanzas)
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(string(b), `"system-id":7293418154175127615`))
+}
+
+func TestPGBackRestInfoFilter(t *testing.T) {
+	now := time.Unix(1698192001, 0)
+
+	labels := func(filter pgBackRestInfoFilter) []string {
+		stanzas, err := parsePGBackRestInfo(pgBackRestInfoJSON)
+		assert.NilError(t, err)
+		filter.Apply(stanzas, now)
+
+		var out []string
+		for _, backup := range stanzas[0]["backup"].([]any) {
+			out = append(out, backup.(map[string]any)["label"].(string))
+		}
+		return out
+	}
+
+	assert.Assert(t, pgBackRestInfoFilter{}.IsZero())
+	assert.Assert(t, !pgBackRestInfoFilter{Descending: true}.IsZero())
+
+	assert.DeepEqual(t, labels(pgBackRestInfoFilter{}), []string{
+		"20231023-201416F", "20231023-201416F_20231024-130000D", "20231025-000000F",
+	})
+	assert.DeepEqual(t, labels(pgBackRestInfoFilter{Descending: true}), []string{
+		"20231025-000000F", "20231023-201416F_20231024-130000D", "20231023-201416F",
+	})
+	assert.DeepEqual(t, labels(pgBackRestInfoFilter{Types: []string{"full"}}), []string{
+		"20231023-201416F", "20231025-000000F",
+	})
+	assert.DeepEqual(t, labels(pgBackRestInfoFilter{Since: 12 * time.Hour}), []string{
+		"20231023-201416F_20231024-130000D", "20231025-000000F",
+	})
+	assert.Assert(t, labels(pgBackRestInfoFilter{Since: time.Hour, Types: []string{"diff"}}) == nil)
+}
+
+func TestRenderPGBackRestInfo(t *testing.T) {
+	stanzas, err := parsePGBackRestInfo(pgBackRestInfoJSON)
+	assert.NilError(t, err)
+
+	var out bytes.Buffer
+	assert.NilError(t, renderPGBackRestInfo(&out, stanzas))
+	assert.Equal(t, out.String(), `stanza: db
+    status: ok
+    cipher: none
+    wal archive min/max (16-1, repo1): 000000010000000000000001/000000010000000000000004
+
+    LABEL                              TYPE          REPO   START                 STOP                  DATABASE SIZE  BACKUP SIZE  REPO SIZE
+    20231023-201416F                   full          repo1  2023-10-23T20:14:16Z  2023-10-23T20:14:32Z  32.0MiB        32.0MiB      4.0MiB
+    20231023-201416F_20231024-130000D  diff          repo1  2023-10-24T13:00:00Z  2023-10-24T13:00:09Z  32.4MiB        1.2MiB       307.2KiB
+    20231025-000000F                   full (error)  repo2  2023-10-25T00:00:00Z  2023-10-25T00:00:01Z  512B           512B         100B
+`)
+
+	t.Run("NoBackups", func(t *testing.T) {
+		stanzas, err := parsePGBackRestInfo(`[{"name":"db","cipher":"none",
+"status":{"code":2,"message":"no valid backups","lock":{"backup":{"held":true}}}}]`)
+		assert.NilError(t, err)
+
+		var out bytes.Buffer
+		assert.NilError(t, renderPGBackRestInfo(&out, stanzas))
+		assert.Equal(t, out.String(), `stanza: db
+    status: error (no valid backups) (backup/expire running)
+    cipher: none
+
+    no backups
+`)
+	})
+}
+
+func TestDiffPGBackRestInfo(t *testing.T) {
+	stanzas, err := parsePGBackRestInfo(pgBackRestInfoJSON)
+	assert.NilError(t, err)
+	before, err := typedPGBackRestInfo(stanzas)
+	assert.NilError(t, err)
+
+	// The oldest full backup expired and an incremental one was taken.
+	after, err := typedPGBackRestInfo(stanzas)
+	assert.NilError(t, err)
+	incr := after[0].Backup[1]
+	incr.Label, incr.Type = "20231023-201416F_20231026-010000I", "incr"
+	incr.Timestamp.Start, incr.Info.Repository.Delta = 1698282000, 1048576
+	after[0].Backup = append(after[0].Backup[1:], incr)
+	after = append(after, pgBackRestStanza{Name: "other"})
+
+	diffs := diffPGBackRestInfo(before, after)
+	assert.Equal(t, len(diffs), 2)
+	assert.DeepEqual(t, diffs[0].Repos, []repoSizeChange{
+		{Key: 1, Before: 4508876, After: 1363148},
+		{Key: 2, Before: 100, After: 100},
+	})
+
+	var out bytes.Buffer
+	assert.NilError(t, renderPGBackRestInfoDiff(&out, diffs))
+	assert.Equal(t, out.String(), `stanza: db
+
+    CHANGE   LABEL                              TYPE  REPO   START                 REPO SIZE
+    added    20231023-201416F_20231026-010000I  incr  repo1  2023-10-26T01:00:00Z  1.0MiB
+    expired  20231023-201416F                   full  repo1  2023-10-23T20:14:16Z  4.0MiB
+
+    REPO   BEFORE  AFTER   CHANGE
+    repo1  4.3MiB  1.3MiB  -3.0MiB
+    repo2  100B    100B    0B
+
+stanza: other
+
+    no backups added or expired
+`)
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, formatBytes(0), "0B")
+	assert.Equal(t, formatBytes(1023), "1023B")
+	assert.Equal(t, formatBytes(1536), "1.5KiB")
+	assert.Equal(t, formatBytes(5<<30), "5.0GiB")
+}
@@ -25,10 +25,15 @@ import (
 func NewPGOCommand(stdin io.Reader, stdout, stderr io.Writer) *cobra.Command {
 	config := &internal.Config{
 		ConfigFlags: genericclioptions.NewConfigFlags(true),
-		IOStreams:   genericclioptions.IOStreams{In: stdin, Out: stdout, ErrOut: stderr},
+		IOStreams:   genericclioptions.IOStreams{In: stdin, Out: stdout},
 		Patch:       internal.PatchConfig{FieldManager: filepath.Base(os.Args[0])},
 	}
 
+	// Data goes to stdout; warnings, suggestions, and progress go to stderr
+	// unless --quiet is set.
+	config.ErrOut = config.Quiet.Writer(stderr)
+	config.Messages.ErrOut = config.ErrOut
+
 	root := &cobra.Command{
 		// When this executable is named `kubectl-pgo`, it can be invoked as
 		// either `kubectl-pgo` or `kubectl pgo`.
@@ -108,21 +113,69 @@ Use "{{.CommandPath}} [command] --help" for more information about a command.{{e
 	// every subcommand.
 	// - https://docs.k8s.io/concepts/configuration/organize-cluster-access-kubeconfig/
 	config.AddFlags(root.PersistentFlags())
+	config.Messages.AddFlags(root.PersistentFlags())
+	config.Quiet.AddFlags(root.PersistentFlags())
 
 	// Defined command output. If not set, it falls back to [os.Stderr].
 	// - https://pkg.go.dev/github.com/spf13/cobra#Command.Print
+	root.SetIn(stdin)
 	root.SetOut(stdout)
+	root.SetErr(config.ErrOut)
 
+	root.AddCommand(newAPICommand(config))
+	root.AddCommand(newApplyCommand(config))
+	root.AddCommand(newAttachCommand(config))
+	root.AddCommand(newAttachJobCommand(config))
 	root.AddCommand(newBackupCommand(config))
+	root.AddCommand(newBenchCommand(config))
+	root.AddCommand(newBrowseCommand(config))
+	root.AddCommand(newCheckCommand(config))
+	root.AddCommand(newConformCommand(config))
+	root.AddCommand(newCopyCommand(config))
 	root.AddCommand(newCreateCommand(config))
 	root.AddCommand(newDeleteCommand(config))
+	root.AddCommand(newDrillCommand(config))
+	root.AddCommand(newEditCommand(config))
+	root.AddCommand(newEnsureCommand(config))
+	root.AddCommand(newExplainQueryCommand(config))
+	root.AddCommand(newGenerateCommand(config))
+	root.AddCommand(newGetCommand(config))
+	root.AddCommand(newHistoryCommand(config))
+	root.AddCommand(newImportCommand(config))
+	root.AddCommand(newMigrateCommand(config))
+	root.AddCommand(newPatchCommand(config))
+	root.AddCommand(newPauseCommand(config))
+	root.AddCommand(newPlanCommand(config))
+	root.AddCommand(newPluginsCommand(config))
+	root.AddCommand(newPruneCommand(config))
+	root.AddCommand(newPSQLCommand(config))
+	root.AddCommand(newRebuildCommand(config))
+	root.AddCommand(newReportCommand(config))
+	root.AddCommand(newRestartCommand(config))
 	root.AddCommand(newRestoreCommand(config))
+	root.AddCommand(newResumeCommand(config))
+	root.AddCommand(newRotateCommand(config))
+	root.AddCommand(newScaleCommand(config))
+	root.AddCommand(newSeedCommand(config))
+	root.AddCommand(newSetCommand(config))
 	root.AddCommand(newShowCommand(config))
 	root.AddCommand(newSupportCommand(config))
+	root.AddCommand(newSyncCommand(config))
+	root.AddCommand(newTestCommand(config))
 	root.AddCommand(newVersionCommand(config))
 	root.AddCommand(newStopCommand(config))
 	root.AddCommand(newStartCommand(config))
 
+	// Commands that take a cluster name complete it from the PostgresClusters
+	// in the namespace. This needs list and watch on postgresclusters.
+	addClusterCompletion(root, config)
+
+	// Commands that change a cluster add to its history.
+	addHistory(root, config)
+
+	// Read-only mode disables every command that changes a cluster.
+	addReadOnly(root, config)
+
 	return root
 }
 
@@ -0,0 +1,25 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// newPlanCommand returns the plan subcommand of the PGO plugin.
+// Subcommands of plan report what an action would do without taking it.
+func newPlanCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Preview an action on a PostgresCluster",
+		Long:  "Preview an action on a PostgresCluster",
+	}
+
+	cmd.AddCommand(newPlanSwitchoverCommand(config))
+
+	return cmd
+}
@@ -0,0 +1,350 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+)
+
+// newPlanSwitchoverCommand returns the switchover subcommand of the plan
+// command. It reports whether Patroni would promote a replica and what
+// clients would notice, without changing anything.
+func newPlanSwitchoverCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "switchover CLUSTER_NAME",
+		Short: "Preview a switchover of a PostgresCluster to a replica",
+		Long: `Preview a switchover of a PostgresCluster to the replica named by "--target"
+without changing anything. The target is an instance Pod, or the end of its
+name when that is unique.
+
+This reports the role, state, timeline, and lag of the target as Patroni sees
+them, and its Patroni tags. A switchover is blocked when Patroni would refuse
+the target: it is not a replica, is not streaming, is on another timeline, is
+tagged "nofailover", or is not a synchronous standby in synchronous mode.
+
+It also lists the client Services that the switchover interrupts and for
+roughly how long. Writes fail from when the primary stops until the target is
+promoted, which Patroni does in its next cycle of "spec.patroni.syncPeriodSeconds".
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    pods                                                [list]
+    pods/exec                                           [create]
+    postgresclusters.postgres-operator.crunchydata.com  [get]
+    services                                            [get]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Preview a switchover of the 'hippo' postgrescluster to the instance ending in '2d4n'
+pgo plan switchover hippo --target=2d4n
+
+### Example output
+primary: hippo-instance1-8x7m-0 (timeline 3)
+target: hippo-instance1-2d4n-0
+  role: Sync Standby
+  state: streaming
+  timeline: 3
+  lag: 0 MB
+  tags: none
+estimated write outage: up to 10s
+
+SERVICE          PURPOSE            IMPACT
+hippo-primary    read-write         connections close; new ones fail for up to 10s
+hippo-replicas   read-only          connections to hippo-instance1-2d4n-0 close when it is promoted
+hippo-pgbouncer  pooled read-write  server connections reset; clients wait up to 10s for the new primary
+
+No blockers found.`)
+
+	var target string
+	cmd.Flags().StringVar(&target, "target", "", "replica to promote")
+	cobra.CheckErr(cmd.MarkFlagRequired("target"))
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		_, clusterClient, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		cluster, err := clusterClient.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		exec, err := getPrimaryExec(config, args)
+		if err != nil {
+			return err
+		}
+		stdout, stderr, err := podexec.Patronictl(exec, "list", "json")
+		if err != nil {
+			return commandError(err, stderr)
+		}
+		members, err := parsePatroniMembers(stdout)
+		if err != nil {
+			return err
+		}
+
+		var services []clusterService
+		for _, service := range clusterServices(args[0]) {
+			_, err := client.Services(namespace).Get(ctx, service.Name, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			services = append(services, service)
+		}
+
+		plan, err := planSwitchover(members, target, services, patroniSyncPeriod(cluster))
+		if err != nil {
+			return err
+		}
+		if err := printSwitchoverPlan(cmd, plan); err != nil {
+			return err
+		}
+		if len(plan.Blockers) > 0 {
+			return fmt.Errorf("a switchover to %s would be refused", plan.Target)
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// switchoverPlan is what a switchover to Target would do.
+type switchoverPlan struct {
+	Primary, Target string
+	PrimaryTimeline int64
+
+	Member patroniMember
+
+	// Outage is roughly how long writes fail.
+	Outage time.Duration
+
+	Impacts  []switchoverImpact
+	Warnings []string
+	Blockers []string
+}
+
+// switchoverImpact is how a switchover affects clients of one Service.
+type switchoverImpact struct {
+	Service, Purpose, Impact string
+}
+
+// patroniSyncPeriod returns how often Patroni runs its cycle in cluster.
+func patroniSyncPeriod(cluster *unstructured.Unstructured) time.Duration {
+	seconds, found, _ := unstructured.NestedInt64(cluster.Object, "spec", "patroni", "syncPeriodSeconds")
+	if !found || seconds <= 0 {
+		// This is the default of the field.
+		seconds = 10
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// resolveSwitchoverTarget returns the member whose name is target, when there
+// is one, or the only member whose name ends with target.
+func resolveSwitchoverTarget(members []patroniMember, target string) (patroniMember, error) {
+	var matches []patroniMember
+	for _, member := range members {
+		switch {
+		case member.Member == target, member.Member == target+"-0":
+			return member, nil
+		case strings.HasSuffix(member.Member, "-"+target),
+			strings.HasSuffix(member.Member, "-"+target+"-0"):
+			matches = append(matches, member)
+		}
+	}
+
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		names := make([]string, 0, len(members))
+		for _, member := range members {
+			names = append(names, member.Member)
+		}
+		sort.Strings(names)
+		return patroniMember{}, fmt.Errorf("no member matches %q; members are %q", target, names)
+	}
+	names := make([]string, 0, len(matches))
+	for _, member := range matches {
+		names = append(names, member.Member)
+	}
+	sort.Strings(names)
+	return patroniMember{}, fmt.Errorf("%q matches more than one member: %q", target, names)
+}
+
+// planSwitchover explains a switchover to target among members, the output of
+// "patronictl list --format json". Clients reach the cluster through services.
+func planSwitchover(
+	members []patroniMember, target string, services []clusterService, syncPeriod time.Duration,
+) (switchoverPlan, error) {
+	member, err := resolveSwitchoverTarget(members, target)
+	if err != nil {
+		return switchoverPlan{}, err
+	}
+	plan := switchoverPlan{Target: member.Member, Member: member, Outage: syncPeriod}
+
+	var synchronous bool
+	for _, other := range members {
+		switch other.Role {
+		case "Leader":
+			plan.Primary = other.Member
+			plan.PrimaryTimeline, _ = patroniNumber(other.Timeline)
+		case "Sync Standby", "Quorum Standby":
+			synchronous = true
+		}
+	}
+	if plan.Primary == "" {
+		return plan, errors.New("the cluster has no leader; use a failover instead")
+	}
+
+	block := func(format string, a ...any) {
+		plan.Blockers = append(plan.Blockers, fmt.Sprintf(format, a...))
+	}
+	warn := func(format string, a ...any) {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf(format, a...))
+	}
+
+	switch {
+	case member.Member == plan.Primary:
+		block("%s is already the primary", member.Member)
+	case member.Role != "Replica" && member.Role != "Sync Standby" && member.Role != "Quorum Standby":
+		block("%s is a %s, not a replica", member.Member, member.Role)
+	case synchronous && member.Role == "Replica":
+		block("the cluster is in synchronous mode and %s is not a synchronous standby", member.Member)
+	}
+	if member.State != "streaming" && member.State != "running" {
+		block("%s is %s, not streaming", member.Member, member.State)
+	}
+	if timeline, ok := patroniNumber(member.Timeline); ok && timeline != plan.PrimaryTimeline {
+		block("%s is on timeline %d, but the primary is on timeline %d",
+			member.Member, timeline, plan.PrimaryTimeline)
+	}
+	if value, ok := member.Tags["nofailover"].(bool); ok && value {
+		block("%s is tagged nofailover", member.Member)
+	}
+	if value, ok := patroniNumber(member.Tags["failover_priority"]); ok && value <= 0 {
+		block("%s has failover_priority %d", member.Member, value)
+	}
+
+	if lag, ok := patroniNumber(member.Lag); !ok {
+		warn("the lag of %s is unknown", member.Member)
+	} else if lag > 1 {
+		// Patroni refuses candidates that are more than maximum_lag_on_failover
+		// behind, which is 1MB by default.
+		warn("%s is %d MB behind; Patroni may refuse it, and promotion waits for it to replay that WAL",
+			member.Member, lag)
+	}
+	if member.PendingRestart != "" {
+		warn("%s has a pending restart; its settings change when it is promoted", member.Member)
+	}
+
+	outage := plan.Outage.String()
+	for _, service := range services {
+		impact := switchoverImpact{Service: service.Name, Purpose: service.Purpose}
+		switch {
+		case service.Primary == nil:
+			impact.Impact = "server connections reset; clients wait up to " + outage + " for the new primary"
+		case *service.Primary:
+			impact.Impact = "connections close; new ones fail for up to " + outage
+		default:
+			impact.Impact = "connections to " + member.Member + " close when it is promoted"
+		}
+		plan.Impacts = append(plan.Impacts, impact)
+	}
+
+	return plan, nil
+}
+
+// printSwitchoverPlan prints plan followed by its Services, warnings, and
+// blockers.
+func printSwitchoverPlan(cmd *cobra.Command, plan switchoverPlan) error {
+	member := plan.Member
+
+	cmd.Printf("primary: %s (timeline %d)\n", plan.Primary, plan.PrimaryTimeline)
+	cmd.Printf("target: %s\n", plan.Target)
+	cmd.Printf("  role: %s\n", member.Role)
+	cmd.Printf("  state: %s\n", member.State)
+	if timeline, ok := patroniNumber(member.Timeline); ok {
+		cmd.Printf("  timeline: %d\n", timeline)
+	} else {
+		cmd.Println("  timeline: unknown")
+	}
+	if lag, ok := patroniNumber(member.Lag); ok {
+		cmd.Printf("  lag: %d MB\n", lag)
+	} else {
+		cmd.Println("  lag: unknown")
+	}
+
+	tags := make([]string, 0, len(member.Tags))
+	for name, value := range member.Tags {
+		tags = append(tags, fmt.Sprintf("%s=%v", name, value))
+	}
+	sort.Strings(tags)
+	if len(tags) == 0 {
+		tags = []string{"none"}
+	}
+	cmd.Printf("  tags: %s\n", strings.Join(tags, ", "))
+	cmd.Printf("estimated write outage: up to %s\n", plan.Outage)
+
+	if len(plan.Impacts) > 0 {
+		cmd.Println()
+		writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+		_, _ = fmt.Fprintln(writer, "SERVICE\tPURPOSE\tIMPACT")
+		for _, impact := range plan.Impacts {
+			_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\n", impact.Service, impact.Purpose, impact.Impact)
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+	}
+
+	cmd.Println()
+	for _, warning := range plan.Warnings {
+		cmd.PrintErrf("WARNING: %s\n", warning)
+	}
+	for _, blocker := range plan.Blockers {
+		cmd.Printf("BLOCKED: %s\n", blocker)
+	}
+	if len(plan.Blockers) == 0 {
+		cmd.Println("No blockers found.")
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestResolveSwitchoverTarget(t *testing.T) {
+	members := []patroniMember{
+		{Member: "hippo-instance1-8x7m-0"},
+		{Member: "hippo-instance1-2d4n-0"},
+		{Member: "hippo-instance2-2d4n-0"},
+	}
+
+	member, err := resolveSwitchoverTarget(members, "hippo-instance1-2d4n")
+	assert.NilError(t, err)
+	assert.Equal(t, member.Member, "hippo-instance1-2d4n-0")
+
+	member, err = resolveSwitchoverTarget(members, "8x7m")
+	assert.NilError(t, err)
+	assert.Equal(t, member.Member, "hippo-instance1-8x7m-0")
+
+	_, err = resolveSwitchoverTarget(members, "2d4n")
+	assert.ErrorContains(t, err, `"2d4n" matches more than one member: ["hippo-instance1-2d4n-0" "hippo-instance2-2d4n-0"]`)
+
+	_, err = resolveSwitchoverTarget(members, "zzzz")
+	assert.ErrorContains(t, err, `no member matches "zzzz"`)
+}
+
+func TestPatroniSyncPeriod(t *testing.T) {
+	cluster := &unstructured.Unstructured{Object: map[string]any{}}
+	assert.Equal(t, patroniSyncPeriod(cluster), 10*time.Second)
+
+	assert.NilError(t, unstructured.SetNestedField(cluster.Object, int64(5), "spec", "patroni", "syncPeriodSeconds"))
+	assert.Equal(t, patroniSyncPeriod(cluster), 5*time.Second)
+}
+
+func TestPlanSwitchover(t *testing.T) {
+	members, err := parsePatroniMembers(`[
+{"Cluster":"hippo-ha","Member":"hippo-instance1-8x7m-0","Role":"Leader","State":"running","TL":3},
+{"Cluster":"hippo-ha","Member":"hippo-instance1-2d4n-0","Role":"Sync Standby","State":"streaming","TL":3,"Lag in MB":0},
+{"Cluster":"hippo-ha","Member":"hippo-instance1-wkq2-0","Role":"Replica","State":"streaming","TL":2,"Lag in MB":40,
+ "Pending restart":"*","Tags":{"nofailover":true}},
+{"Cluster":"hippo-ha","Member":"hippo-instance1-q9zr-0","Role":"Replica","State":"starting","TL":"","Lag in MB":"unknown",
+ "Tags":{"failover_priority":0}}
+]`)
+	assert.NilError(t, err)
+	services := clusterServices("hippo")
+
+	t.Run("Ready", func(t *testing.T) {
+		plan, err := planSwitchover(members, "2d4n", services, 10*time.Second)
+		assert.NilError(t, err)
+		assert.Equal(t, plan.Primary, "hippo-instance1-8x7m-0")
+		assert.Equal(t, plan.PrimaryTimeline, int64(3))
+		assert.Assert(t, len(plan.Blockers) == 0, "%q", plan.Blockers)
+		assert.Assert(t, len(plan.Warnings) == 0, "%q", plan.Warnings)
+
+		var out bytes.Buffer
+		cmd := &cobra.Command{}
+		cmd.SetOut(&out)
+		assert.NilError(t, printSwitchoverPlan(cmd, plan))
+		assert.Equal(t, out.String(), `primary: hippo-instance1-8x7m-0 (timeline 3)
+target: hippo-instance1-2d4n-0
+  role: Sync Standby
+  state: streaming
+  timeline: 3
+  lag: 0 MB
+  tags: none
+estimated write outage: up to 10s
+
+SERVICE          PURPOSE            IMPACT
+hippo-primary    read-write         connections close; new ones fail for up to 10s
+hippo-replicas   read-only          connections to hippo-instance1-2d4n-0 close when it is promoted
+hippo-pgbouncer  pooled read-write  server connections reset; clients wait up to 10s for the new primary
+
+No blockers found.
+`)
+	})
+
+	t.Run("Blocked", func(t *testing.T) {
+		plan, err := planSwitchover(members, "wkq2", services[:2], 10*time.Second)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, plan.Blockers, []string{
+			"the cluster is in synchronous mode and hippo-instance1-wkq2-0 is not a synchronous standby",
+			"hippo-instance1-wkq2-0 is on timeline 2, but the primary is on timeline 3",
+			"hippo-instance1-wkq2-0 is tagged nofailover",
+		})
+		assert.DeepEqual(t, plan.Warnings, []string{
+			"hippo-instance1-wkq2-0 is 40 MB behind; Patroni may refuse it, and promotion waits for it to replay that WAL",
+			"hippo-instance1-wkq2-0 has a pending restart; its settings change when it is promoted",
+		})
+
+		var out, errOut bytes.Buffer
+		cmd := &cobra.Command{}
+		cmd.SetOut(&out)
+		cmd.SetErr(&errOut)
+		assert.NilError(t, printSwitchoverPlan(cmd, plan))
+		assert.Assert(t, bytes.Contains(out.Bytes(), []byte("  tags: nofailover=true\n")))
+		assert.Assert(t, bytes.HasPrefix(errOut.Bytes(), []byte(
+			"WARNING: hippo-instance1-wkq2-0 is 40 MB behind;")))
+		assert.Assert(t, bytes.HasSuffix(out.Bytes(), []byte(
+			"BLOCKED: hippo-instance1-wkq2-0 is tagged nofailover\n")))
+
+		plan, err = planSwitchover(members, "q9zr", nil, 10*time.Second)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, plan.Blockers, []string{
+			"the cluster is in synchronous mode and hippo-instance1-q9zr-0 is not a synchronous standby",
+			"hippo-instance1-q9zr-0 is starting, not streaming",
+			"hippo-instance1-q9zr-0 has failover_priority 0",
+		})
+		assert.DeepEqual(t, plan.Warnings, []string{"the lag of hippo-instance1-q9zr-0 is unknown"})
+
+		plan, err = planSwitchover(members, "8x7m", nil, 10*time.Second)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, plan.Blockers[:1], []string{"hippo-instance1-8x7m-0 is already the primary"})
+	})
+
+	t.Run("NoLeader", func(t *testing.T) {
+		_, err := planSwitchover(members[1:], "2d4n", nil, 10*time.Second)
+		assert.ErrorContains(t, err, "the cluster has no leader")
+	})
+}
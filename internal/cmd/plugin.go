@@ -0,0 +1,497 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// pluginPrefix is the start of the name of executables that extend the PGO
+// plugin, just as "kubectl-" is the start of kubectl plugins.
+const pluginPrefix = "kubectl-pgo-"
+
+// pluginConfigEnv names the environment variable that overrides the location
+// of the plugin config.
+const pluginConfigEnv = "PGO_PLUGIN_CONFIG"
+
+// mutatingCommands are the commands, without the root, that change objects in
+// Kubernetes. Hooks run around these. The psql command is among them because
+// its superuser session can change anything in Postgres.
+var mutatingCommands = []string{
+	"apply",
+	"backup",
+	"bench",
+	"copy table",
+	"create postgrescluster",
+	"delete backupschedule",
+	"delete postgrescluster",
+	"drill failover",
+	"edit postgrescluster",
+	"ensure backupschedule",
+	"ensure database",
+	"ensure user",
+	"import",
+	"migrate auth",
+	"migrate namespace",
+	"patch",
+	"pause backupschedule",
+	"pause reconcile",
+	"prune",
+	"psql",
+	"rebuild replica",
+	"restart",
+	"restore",
+	"restore disable",
+	"resume backupschedule",
+	"resume reconcile",
+	"rotate repo-cipher",
+	"scale pgbouncer",
+	"scale postgrescluster",
+	"seed",
+	"set delayed-replica",
+	"set parameter",
+	"set pdb",
+	"set pgbouncer",
+	"set user-policy",
+	"set wal-volume",
+	"start",
+	"stop",
+	"sync repo-credentials",
+	"test restore",
+}
+
+// mutatingFlags are the commands, without the root, that change objects in
+// Kubernetes or Postgres only with one of these flags. Hooks, history, and
+// read-only mode treat them as mutating commands when those flags are set.
+var mutatingFlags = map[string][]string{
+	"generate cert":           {"apply"},
+	"generate networkpolicy":  {"apply"},
+	"generate s3-secret":      {"apply"},
+	"show pg-stat-statements": {"reset"},
+	"show replication-slots":  {"drop"},
+	"show settings":           {"apply-plan"},
+}
+
+// mutatingPaths returns [mutatingCommands] followed by the commands of
+// [mutatingFlags] in order.
+func mutatingPaths() []string {
+	paths := make([]string, 0, len(mutatingFlags))
+	for path := range mutatingFlags {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return append(append([]string{}, mutatingCommands...), paths...)
+}
+
+// mutatingFlag returns whether cmd, the command at path, changes objects with
+// the flags it was given. The flag is the one that makes it do so, or empty
+// when the command always does.
+func mutatingFlag(cmd *cobra.Command, path string) (string, bool) {
+	names, ok := mutatingFlags[path]
+	if !ok {
+		return "", true
+	}
+	for _, name := range names {
+		if flag := cmd.Flags().Lookup(name); flag != nil && flag.Changed &&
+			flag.Value.String() != "" && flag.Value.String() != "false" {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// pluginConfig is the file that declares extra subcommands and hooks.
+type pluginConfig struct {
+	// Commands are more subcommands of the root command.
+	Commands []pluginCommand `json:"commands,omitempty"`
+
+	Hooks struct {
+		// Pre hooks run before a command. When one fails, the command does
+		// not run.
+		Pre []pluginHook `json:"pre,omitempty"`
+
+		// Post hooks run after a command, whether or not it succeeded.
+		Post []pluginHook `json:"post,omitempty"`
+	} `json:"hooks"`
+}
+
+// pluginCommand is a subcommand that runs an external program.
+type pluginCommand struct {
+	Name    string   `json:"name"`
+	Short   string   `json:"short,omitempty"`
+	Command []string `json:"command"`
+
+	// ReadOnly commands do not change objects, so they run in read-only mode.
+	ReadOnly bool `json:"readOnly,omitempty"`
+}
+
+// pluginHook is an external program that runs around mutating commands.
+type pluginHook struct {
+	Command []string `json:"command"`
+
+	// Commands limits the hook to these commands, e.g. "backup" or
+	// "set pgbouncer". When empty, the hook runs around every mutating
+	// command.
+	Commands []string `json:"commands,omitempty"`
+}
+
+// appliesTo returns whether hook should run around the command at path.
+func (hook pluginHook) appliesTo(path string) bool {
+	if len(hook.Commands) == 0 {
+		return true
+	}
+	for _, command := range hook.Commands {
+		if command == path {
+			return true
+		}
+	}
+	return false
+}
+
+// PluginConfigPath returns the location of the plugin config: the value of
+// [pluginConfigEnv] or "kubectl-pgo/plugins.yaml" in the user config directory.
+func PluginConfigPath() string {
+	if path := os.Getenv(pluginConfigEnv); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "kubectl-pgo", "plugins.yaml")
+}
+
+// loadPluginConfig reads the plugin config at path. A missing file is an empty
+// config.
+func loadPluginConfig(path string) (pluginConfig, error) {
+	var config pluginConfig
+	if path == "" {
+		return config, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil
+	}
+	if err == nil {
+		err = yaml.UnmarshalStrict(b, &config)
+	}
+	if err != nil {
+		return config, fmt.Errorf("unable to read plugin config %s: %w", path, err)
+	}
+
+	for _, command := range config.Commands {
+		if command.Name == "" || len(command.Command) == 0 {
+			return config, fmt.Errorf("invalid plugin config %s: commands need a name and a command", path)
+		}
+	}
+	for _, hook := range append(config.Hooks.Pre, config.Hooks.Post...) {
+		if len(hook.Command) == 0 {
+			return config, fmt.Errorf("invalid plugin config %s: hooks need a command", path)
+		}
+	}
+	return config, nil
+}
+
+// discoverPlugins returns the executables in the directories of searchPath
+// whose names start with [pluginPrefix], keyed by the rest of their name.
+// Like a shell, the first directory with a match wins.
+func discoverPlugins(searchPath string) map[string]string {
+	plugins := map[string]string{}
+	for _, dir := range filepath.SplitList(searchPath) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := strings.TrimPrefix(entry.Name(), pluginPrefix)
+			if name == entry.Name() || name == "" || plugins[name] != "" {
+				continue
+			}
+			if info, err := entry.Info(); err != nil || info.IsDir() || info.Mode()&0o111 == 0 {
+				continue
+			}
+			plugins[name] = filepath.Join(dir, entry.Name())
+		}
+	}
+	return plugins
+}
+
+// AddPlugins adds subcommands to root for the executables on searchPath and the
+// commands in the plugin config at configPath, then wraps mutating commands with
+// the hooks of that config. Built-in commands are never replaced.
+func AddPlugins(root *cobra.Command, searchPath, configPath string) error {
+	config, err := loadPluginConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	taken := func(name string) bool {
+		found, _, err := root.Find([]string{name})
+		return err == nil && found != root
+	}
+	for _, command := range config.Commands {
+		if !taken(command.Name) {
+			root.AddCommand(newPluginCommand(command))
+		}
+	}
+	for name, path := range discoverPlugins(searchPath) {
+		if !taken(name) {
+			root.AddCommand(newPluginCommand(pluginCommand{
+				Name: name, Short: "Plugin " + path, Command: []string{path},
+			}))
+		}
+	}
+
+	for _, path := range mutatingPaths() {
+		var pre, post []pluginHook
+		for _, hook := range config.Hooks.Pre {
+			if hook.appliesTo(path) {
+				pre = append(pre, hook)
+			}
+		}
+		for _, hook := range config.Hooks.Post {
+			if hook.appliesTo(path) {
+				post = append(post, hook)
+			}
+		}
+		if len(pre)+len(post) == 0 {
+			continue
+		}
+		if command, _, err := root.Find(strings.Fields(path)); err == nil && command.RunE != nil {
+			wrapWithHooks(command, path, pre, post)
+		}
+	}
+	return nil
+}
+
+// newPluginCommand returns a subcommand that passes its arguments and flags to
+// the external program of plugin.
+func newPluginCommand(plugin pluginCommand) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                plugin.Name,
+		Short:              plugin.Short,
+		DisableFlagParsing: true,
+		Hidden:             !plugin.ReadOnly && internal.ReadOnlyFromEnv(),
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		// Flags are not parsed, so look for the one of read-only mode.
+		if !plugin.ReadOnly && (internal.ReadOnlyFromEnv() || pluginReadOnlyFlag(args)) {
+			return fmt.Errorf("%q may change objects in Kubernetes and cannot run in read-only mode",
+				"pgo "+plugin.Name)
+		}
+
+		argv := append(append([]string{}, plugin.Command[1:]...), args...)
+
+		// #nosec G204 -- We intentionally run the program configured by the user.
+		program := exec.Command(plugin.Command[0], argv...)
+		program.Stdin = cmd.InOrStdin()
+		program.Stdout = cmd.OutOrStdout()
+		program.Stderr = cmd.ErrOrStderr()
+		return program.Run()
+	}
+
+	return cmd
+}
+
+// pluginReadOnlyFlag returns whether args, unparsed, turn on read-only mode.
+func pluginReadOnlyFlag(args []string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if value, ok := strings.CutPrefix(arg, "--read-only"); ok && (value == "" || value == "=true") {
+			return true
+		}
+	}
+	return false
+}
+
+// wrapWithHooks changes cmd to run the pre hooks before it and the post hooks
+// after it. The hooks learn about the command from environment variables.
+func wrapWithHooks(cmd *cobra.Command, path string, pre, post []pluginHook) {
+	run := cmd.RunE
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if _, ok := mutatingFlag(cmd, path); !ok {
+			return run(cmd, args)
+		}
+
+		var namespace string
+		if flag := cmd.Flags().Lookup("namespace"); flag != nil {
+			namespace = flag.Value.String()
+		}
+		env := hookEnvironment("pre", path, namespace, args, nil)
+
+		for _, hook := range pre {
+			if err := runHook(cmd, hook, env); err != nil {
+				return fmt.Errorf("pre hook %q failed; %s did not run: %w",
+					strings.Join(hook.Command, " "), path, err)
+			}
+		}
+
+		err := run(cmd, args)
+
+		env = hookEnvironment("post", path, namespace, args, err)
+		for _, hook := range post {
+			if hookErr := runHook(cmd, hook, env); hookErr != nil {
+				cmd.PrintErrf("WARNING: post hook %q failed: %v\n",
+					strings.Join(hook.Command, " "), hookErr)
+			}
+		}
+		return err
+	}
+}
+
+// hookEnvironment returns the variables that describe a command to its hooks.
+// The result of the command is included in post hooks only.
+func hookEnvironment(stage, path, namespace string, args []string, err error) []string {
+	env := []string{
+		"PGO_HOOK=" + stage,
+		"PGO_COMMAND=" + path,
+		"PGO_ARGS=" + strings.Join(args, " "),
+		"PGO_NAMESPACE=" + namespace,
+	}
+	if stage == "post" {
+		switch {
+		case errors.Is(err, ErrCancelled):
+			env = append(env, "PGO_RESULT=cancelled")
+		case err != nil:
+			env = append(env, "PGO_RESULT=failure", "PGO_ERROR="+err.Error())
+		default:
+			env = append(env, "PGO_RESULT=success")
+		}
+	}
+	return env
+}
+
+// runHook runs hook with env added to the environment of this process. Its
+// output goes to stderr so that it does not mix with the output of the command.
+// It reads nothing: input is left for the command and its confirmation prompts.
+func runHook(cmd *cobra.Command, hook pluginHook, env []string) error {
+	// #nosec G204 -- We intentionally run the program configured by the user.
+	program := exec.Command(hook.Command[0], hook.Command[1:]...)
+	program.Env = append(os.Environ(), env...)
+	program.Stdin = nil
+	program.Stdout = cmd.ErrOrStderr()
+	program.Stderr = cmd.ErrOrStderr()
+	return program.Run()
+}
+
+// newPluginsCommand returns the plugins command of the PGO plugin. It lists the
+// extensions that are in effect.
+func newPluginsCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plugins",
+		Short: "List plugin commands and hooks",
+		Long: `List the commands and hooks that extend the PGO plugin.
+
+Executables on PATH named "kubectl-pgo-NAME" become the command "pgo NAME",
+unless a built-in command has that name. The plugin config can declare more
+commands and hooks. It is read from the file named by the PGO_PLUGIN_CONFIG
+environment variable or from "kubectl-pgo/plugins.yaml" in the user config
+directory, e.g. "~/.config/kubectl-pgo/plugins.yaml" on Linux:
+
+    commands:
+    - name: ticket
+      short: Open a change ticket
+      command: [/usr/local/bin/ticket, open]
+    - name: report
+      command: [/usr/local/bin/report]
+      readOnly: true
+    hooks:
+      pre:
+      - command: [/usr/local/bin/ticket, check]
+        commands: [backup, restore]
+      post:
+      - command: [/usr/local/bin/notify]
+
+In read-only mode, only commands that declare "readOnly" run.
+
+Hooks run around commands that change objects in Kubernetes: every such
+command, or only those listed in "commands". Commands such as
+"show replication-slots" are among them only with flags like "--drop". A pre hook that fails stops the
+command. Hooks read nothing from stdin, which is left for the command and its
+prompts, and their output goes to stderr. Hooks get these environment variables:
+  - PGO_HOOK: pre or post
+  - PGO_COMMAND: the command, e.g. "set pgbouncer"
+  - PGO_ARGS: the arguments of the command
+  - PGO_NAMESPACE: the value of the --namespace flag
+  - PGO_RESULT: success, failure, or cancelled, in post hooks only
+  - PGO_ERROR: the error of a failed command, in post hooks only
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# List plugin commands and hooks
+pgo plugins
+
+### Example output
+COMMAND  RUNS
+ticket   /usr/local/bin/ticket open
+tail     /usr/local/bin/kubectl-pgo-tail
+
+HOOK  COMMANDS         RUNS
+pre   backup, restore  /usr/local/bin/ticket check
+post  (mutating)       /usr/local/bin/notify`)
+
+	cmd.Args = cobra.NoArgs
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		path := PluginConfigPath()
+		plugins, err := loadPluginConfig(path)
+		if err != nil {
+			return err
+		}
+		if path != "" {
+			cmd.Printf("Plugin config: %s\n\n", path)
+		}
+
+		commands := append([]pluginCommand{}, plugins.Commands...)
+		executables := discoverPlugins(os.Getenv("PATH"))
+		names := make([]string, 0, len(executables))
+		for name := range executables {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			commands = append(commands, pluginCommand{Name: name, Command: []string{executables[name]}})
+		}
+
+		writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+		_, _ = fmt.Fprintln(writer, "COMMAND\tRUNS")
+		for _, command := range commands {
+			_, _ = fmt.Fprintf(writer, "%s\t%s\n", command.Name, strings.Join(command.Command, " "))
+		}
+		_, _ = fmt.Fprintln(writer, "\nHOOK\tCOMMANDS\tRUNS")
+		for stage, hooks := range [][]pluginHook{plugins.Hooks.Pre, plugins.Hooks.Post} {
+			for _, hook := range hooks {
+				applies := strings.Join(hook.Commands, ", ")
+				if applies == "" {
+					applies = "(mutating)"
+				}
+				_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\n", []string{"pre", "post"}[stage],
+					applies, strings.Join(hook.Command, " "))
+			}
+		}
+		return writer.Flush()
+	}
+
+	return cmd
+}
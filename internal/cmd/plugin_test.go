@@ -0,0 +1,227 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+func TestLoadPluginConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	config, err := loadPluginConfig(filepath.Join(dir, "missing.yaml"))
+	assert.NilError(t, err)
+	assert.Equal(t, len(config.Commands)+len(config.Hooks.Pre)+len(config.Hooks.Post), 0)
+
+	path := filepath.Join(dir, "plugins.yaml")
+	assert.NilError(t, os.WriteFile(path, []byte(`
+commands:
+- name: ticket
+  command: [/usr/local/bin/ticket, open]
+hooks:
+  pre:
+  - command: [/usr/local/bin/ticket, check]
+    commands: [backup]
+`), 0o600))
+
+	config, err = loadPluginConfig(path)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, config.Commands, []pluginCommand{
+		{Name: "ticket", Command: []string{"/usr/local/bin/ticket", "open"}},
+	})
+	assert.DeepEqual(t, config.Hooks.Pre, []pluginHook{
+		{Command: []string{"/usr/local/bin/ticket", "check"}, Commands: []string{"backup"}},
+	})
+
+	for _, tt := range []struct{ content, message string }{
+		{"commands: [{name: x}]", "commands need a name and a command"},
+		{"hooks: {post: [{commands: [backup]}]}", "hooks need a command"},
+		{"hook: {}", "unable to read plugin config"},
+	} {
+		assert.NilError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+		_, err := loadPluginConfig(path)
+		assert.ErrorContains(t, err, tt.message, "%q", tt.content)
+	}
+}
+
+func TestDiscoverPlugins(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	write := func(dir, name string, mode os.FileMode) {
+		assert.NilError(t, os.WriteFile(filepath.Join(dir, name), nil, mode))
+	}
+
+	write(first, "kubectl-pgo-tail", 0o755)
+	write(first, "kubectl-pgo-notes", 0o644)
+	write(first, "kubectl-other", 0o755)
+	write(second, "kubectl-pgo-tail", 0o755)
+	write(second, "kubectl-pgo-ticket", 0o755)
+	assert.NilError(t, os.Mkdir(filepath.Join(second, "kubectl-pgo-dir"), 0o755))
+
+	plugins := discoverPlugins(strings.Join(
+		[]string{first, filepath.Join(first, "missing"), second}, string(os.PathListSeparator)))
+
+	assert.DeepEqual(t, plugins, map[string]string{
+		"tail":   filepath.Join(first, "kubectl-pgo-tail"),
+		"ticket": filepath.Join(second, "kubectl-pgo-ticket"),
+	})
+}
+
+func TestMutatingCommands(t *testing.T) {
+	root := NewPGOCommand(nil, nil, nil)
+
+	for _, path := range mutatingCommands {
+		command, _, err := root.Find(strings.Fields(path))
+		assert.NilError(t, err, "%q", path)
+		assert.Equal(t, command.CommandPath(), "pgo "+path)
+		assert.Assert(t, command.RunE != nil, "%q", path)
+	}
+
+	for path, flags := range mutatingFlags {
+		command, _, err := root.Find(strings.Fields(path))
+		assert.NilError(t, err, "%q", path)
+		assert.Equal(t, command.CommandPath(), "pgo "+path)
+		for _, name := range flags {
+			assert.Assert(t, command.Flags().Lookup(name) != nil, "%q --%s", path, name)
+		}
+
+		flag, ok := mutatingFlag(command, path)
+		assert.Assert(t, !ok, "%q mutates without flags", path)
+		assert.NilError(t, command.Flags().Set(flags[0], "true"))
+		flag, ok = mutatingFlag(command, path)
+		assert.Assert(t, ok, "%q", path)
+		assert.Equal(t, flag, flags[0])
+	}
+
+	_, ok := mutatingFlag(&cobra.Command{}, "stop")
+	assert.Assert(t, ok, "mutating commands always mutate")
+}
+
+func TestPluginReadOnlyFlag(t *testing.T) {
+	assert.Assert(t, pluginReadOnlyFlag([]string{"-n", "zoo", "--read-only"}))
+	assert.Assert(t, pluginReadOnlyFlag([]string{"--read-only=true"}))
+	assert.Assert(t, !pluginReadOnlyFlag([]string{"--read-only=false"}))
+	assert.Assert(t, !pluginReadOnlyFlag([]string{"--", "--read-only"}))
+}
+
+func TestPluginHookAppliesTo(t *testing.T) {
+	assert.Assert(t, pluginHook{}.appliesTo("backup"))
+	assert.Assert(t, pluginHook{Commands: []string{"set pgbouncer"}}.appliesTo("set pgbouncer"))
+	assert.Assert(t, !pluginHook{Commands: []string{"backup"}}.appliesTo("restore"))
+}
+
+func TestHookEnvironment(t *testing.T) {
+	assert.DeepEqual(t,
+		hookEnvironment("pre", "set pdb", "ns", []string{"hippo"}, nil),
+		[]string{"PGO_HOOK=pre", "PGO_COMMAND=set pdb", "PGO_ARGS=hippo", "PGO_NAMESPACE=ns"})
+
+	assert.DeepEqual(t,
+		hookEnvironment("post", "backup", "", []string{"hippo"}, nil)[4:],
+		[]string{"PGO_RESULT=success"})
+
+	assert.DeepEqual(t,
+		hookEnvironment("post", "backup", "", []string{"hippo"}, errors.New("boom"))[4:],
+		[]string{"PGO_RESULT=failure", "PGO_ERROR=boom"})
+
+	assert.DeepEqual(t,
+		hookEnvironment("post", "stop", "", []string{"hippo"}, ErrCancelled)[4:],
+		[]string{"PGO_RESULT=cancelled"})
+}
+
+func TestRunHook(t *testing.T) {
+	shell, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("requires the sh command")
+	}
+
+	// The hook reads nothing, so the answer is left for the prompt.
+	stdin := strings.NewReader("yes\n")
+	var stderr bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetIn(stdin)
+	cmd.SetErr(&stderr)
+
+	assert.NilError(t, runHook(cmd, pluginHook{Command: []string{shell, "-c", `cat; echo "$PGO_HOOK"`}},
+		[]string{"PGO_HOOK=pre"}))
+	assert.Equal(t, stderr.String(), "pre\n")
+	assert.Equal(t, stdin.Len(), 4)
+}
+
+func TestAddPlugins(t *testing.T) {
+	succeed, err := exec.LookPath("true")
+	if err != nil {
+		t.Skip("requires the true command")
+	}
+	fail, err := exec.LookPath("false")
+	if err != nil {
+		t.Skip("requires the false command")
+	}
+
+	dir := t.TempDir()
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "kubectl-pgo-backup"), nil, 0o755))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "kubectl-pgo-tail"), nil, 0o755))
+
+	config := filepath.Join(dir, "plugins.yaml")
+	assert.NilError(t, os.WriteFile(config, []byte(`
+hooks:
+  pre:
+  - command: [`+fail+`]
+    commands: [stop]
+  post:
+  - command: [`+succeed+`]
+`), 0o600))
+
+	build := func() *cobra.Command {
+		root := NewPGOCommand(nil, nil, nil)
+		assert.NilError(t, AddPlugins(root, dir, config))
+		return root
+	}
+
+	t.Run("Commands", func(t *testing.T) {
+		root := build()
+
+		tail, _, err := root.Find([]string{"tail"})
+		assert.NilError(t, err)
+		assert.Equal(t, tail.Short, "Plugin "+filepath.Join(dir, "kubectl-pgo-tail"))
+
+		// Built-in commands are not replaced.
+		backup, _, err := root.Find([]string{"backup"})
+		assert.NilError(t, err)
+		assert.Equal(t, backup.Short, "Backup cluster")
+	})
+
+	t.Run("PreHookFails", func(t *testing.T) {
+		root := build()
+		var stderr bytes.Buffer
+		root.SetErr(&stderr)
+		root.SetArgs([]string{"stop", "hippo"})
+
+		err := root.Execute()
+		assert.ErrorContains(t, err, "stop did not run")
+	})
+
+	t.Run("ReadOnly", func(t *testing.T) {
+		t.Setenv(internal.ReadOnlyEnv, "true")
+		root := build()
+		root.SetArgs([]string{"tail", "hippo"})
+
+		err := root.Execute()
+		assert.ErrorContains(t, err, `"pgo tail" may change objects in Kubernetes and cannot run in read-only mode`)
+
+		tail, _, err := root.Find([]string{"tail"})
+		assert.NilError(t, err)
+		assert.Assert(t, tail.Hidden)
+	})
+}
@@ -0,0 +1,286 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	"github.com/crunchydata/postgres-operator-client/internal/flags"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newPruneCommand returns the prune command of the PGO plugin. It deletes
+// objects that were left behind by PostgresClusters that no longer exist.
+func newPruneCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prune [NAMESPACE]",
+		Short: "Delete objects left behind by deleted PostgresClusters",
+		Long: `Find and delete objects that have a PostgresCluster label but no
+PostgresCluster of that name in the namespace. These are PersistentVolumeClaims,
+Secrets, finished Jobs, and ConfigMaps that remain after a cluster is deleted,
+such as volumes that were retained on purpose or objects that were orphaned
+when their cluster was deleted.
+
+Only objects that PGO made are considered: those owned by a PostgresCluster and
+those with the labels PGO puts on the volumes of instances. Objects that only
+have the cluster label, like Secrets prepared before their cluster is created
+and the history of "pgo history", are never deleted.
+
+The objects are listed before anything is deleted. Use the "--older-than" flag
+to only consider objects created more than that long ago.
+
+The namespace defaults to the one in the "--namespace" flag or the current
+context.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    configmaps                                          [list delete]
+    jobs.batch                                          [list delete]
+    persistentvolumeclaims                              [list delete]
+    postgresclusters.postgres-operator.crunchydata.com  [list]
+    secrets                                             [list delete]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Delete objects of deleted postgresclusters in the 'postgres-operator' namespace
+pgo prune postgres-operator
+
+# Only delete objects that are more than a week old
+pgo prune postgres-operator --older-than 168h
+
+### Example output
+KIND                   NAME                         CLUSTER  AGE
+ConfigMap              rhino-config                 rhino    12d
+Job                    rhino-backup-8hkd            rhino    12d
+PersistentVolumeClaim  rhino-instance1-4f9s-pgdata  rhino    12d
+Secret                 rhino-pguser-rhino           rhino    12d
+
+WARNING: This will delete 4 objects. Deleted volumes cannot be recovered.
+Are you sure you want to continue? (yes/no): yes
+configmaps/rhino-config deleted
+jobs/rhino-backup-8hkd deleted
+persistentvolumeclaims/rhino-instance1-4f9s-pgdata deleted
+secrets/rhino-pguser-rhino deleted`)
+
+	var olderThan flags.Time
+	cmd.Flags().Var(&olderThan, "older-than",
+		`only delete objects created before this, e.g. "168h", "7d", or "2024-05-01 14:03"`)
+
+	cmd.Args = cobra.MaximumNArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		if len(args) > 0 {
+			namespace = args[0]
+		}
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		clientset, err := kubernetes.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		_, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+
+		clusterList, err := client.Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		clusters := map[string]bool{}
+		for _, cluster := range clusterList.Items {
+			clusters[cluster.GetName()] = true
+		}
+
+		listOptions := metav1.ListOptions{LabelSelector: util.LabelCluster}
+		var objects []prunable
+
+		pvcs, err := clientset.CoreV1().PersistentVolumeClaims(namespace).List(ctx, listOptions)
+		if err != nil {
+			return err
+		}
+		for i := range pvcs.Items {
+			objects = append(objects, prunable{"PersistentVolumeClaim", &pvcs.Items[i]})
+		}
+
+		secrets, err := clientset.CoreV1().Secrets(namespace).List(ctx, listOptions)
+		if err != nil {
+			return err
+		}
+		for i := range secrets.Items {
+			objects = append(objects, prunable{"Secret", &secrets.Items[i]})
+		}
+
+		configMaps, err := clientset.CoreV1().ConfigMaps(namespace).List(ctx, listOptions)
+		if err != nil {
+			return err
+		}
+		for i := range configMaps.Items {
+			objects = append(objects, prunable{"ConfigMap", &configMaps.Items[i]})
+		}
+
+		jobs, err := clientset.BatchV1().Jobs(namespace).List(ctx, listOptions)
+		if err != nil {
+			return err
+		}
+		for i := range jobs.Items {
+			// Leave Jobs that are still running alone.
+			if status := jobStatus(&jobs.Items[i]); status == "Complete" || status == "Failed" {
+				objects = append(objects, prunable{"Job", &jobs.Items[i]})
+			}
+		}
+
+		now := time.Now()
+		candidates := pruneCandidates(objects, clusters, olderThan.Ago(now), now)
+		if len(candidates) == 0 {
+			cmd.Printf("No objects to prune in namespace %s\n", namespace)
+			return nil
+		}
+
+		writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+		_, _ = fmt.Fprintln(writer, "KIND\tNAME\tCLUSTER\tAGE")
+		for _, candidate := range candidates {
+			_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", candidate.Kind,
+				candidate.Name, candidate.Cluster, duration.HumanDuration(candidate.Age))
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+
+		_, _ = fmt.Fprint(config.ErrOut, config.Messages.Sprintf("prune.warn", len(candidates))+
+			config.Messages.Sprintf("confirm.continue"))
+		var confirmed *bool
+		for i := 0; confirmed == nil && i < 10; i++ {
+			// retry 10 times or until a confirmation is given or denied,
+			// whichever comes first
+			confirmed = config.Messages.Confirm(config.In, config.ErrOut)
+		}
+		if confirmed == nil || !*confirmed {
+			return ErrCancelled
+		}
+
+		// Delete the Pods of Jobs along with them.
+		background := metav1.DeletePropagationBackground
+		deleteOptions := metav1.DeleteOptions{PropagationPolicy: &background}
+
+		for _, candidate := range candidates {
+			var resource string
+			switch candidate.Kind {
+			case "ConfigMap":
+				resource = "configmaps"
+				err = clientset.CoreV1().ConfigMaps(namespace).Delete(ctx, candidate.Name, deleteOptions)
+			case "Job":
+				resource = "jobs"
+				err = clientset.BatchV1().Jobs(namespace).Delete(ctx, candidate.Name, deleteOptions)
+			case "PersistentVolumeClaim":
+				resource = "persistentvolumeclaims"
+				err = clientset.CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, candidate.Name, deleteOptions)
+			case "Secret":
+				resource = "secrets"
+				err = clientset.CoreV1().Secrets(namespace).Delete(ctx, candidate.Name, deleteOptions)
+			}
+			if err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+			cmd.Printf("%s/%s deleted\n", resource, candidate.Name)
+		}
+
+		return nil
+	}
+
+	return cmd
+}
+
+// pruneCandidate is an object left behind by a deleted PostgresCluster.
+type pruneCandidate struct {
+	Kind    string
+	Name    string
+	Cluster string
+	Age     time.Duration
+}
+
+// prunable is an object that "prune" may delete.
+type prunable struct {
+	Kind string
+	metav1.Object
+}
+
+// pgoManagedLabels are labels that PGO puts on the objects it makes for the
+// instances of a cluster. They remain when an object loses its owner.
+var pgoManagedLabels = []string{util.LabelData, util.LabelInstance, util.LabelInstanceSet}
+
+// madeByPGO returns whether object is owned by a PostgresCluster or has one of
+// [pgoManagedLabels].
+func madeByPGO(object metav1.Object) bool {
+	for _, owner := range object.GetOwnerReferences() {
+		if owner.Kind == "PostgresCluster" &&
+			strings.HasPrefix(owner.APIVersion, v1beta1.GroupVersion.Group+"/") {
+			return true
+		}
+	}
+	for _, label := range pgoManagedLabels {
+		if _, ok := object.GetLabels()[label]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneCandidates returns the objects made by PGO whose cluster label does not
+// name one of clusters and that were created more than olderThan before now.
+// The result is sorted by kind and then name.
+func pruneCandidates(
+	objects []prunable, clusters map[string]bool, olderThan time.Duration, now time.Time,
+) []pruneCandidate {
+	var candidates []pruneCandidate
+
+	for _, object := range objects {
+		cluster, ok := object.GetLabels()[util.LabelCluster]
+		if !ok || clusters[cluster] || !madeByPGO(object) {
+			continue
+		}
+		age := now.Sub(object.GetCreationTimestamp().Time)
+		if age < olderThan {
+			continue
+		}
+
+		candidates = append(candidates, pruneCandidate{
+			Kind: object.Kind, Name: object.GetName(), Cluster: cluster, Age: age,
+		})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Kind != candidates[j].Kind {
+			return candidates[i].Kind < candidates[j].Kind
+		}
+		return candidates[i].Name < candidates[j].Name
+	})
+
+	return candidates
+}
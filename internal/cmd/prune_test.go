@@ -0,0 +1,82 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+func TestPruneCandidates(t *testing.T) {
+	now := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	object := func(name, cluster string, age time.Duration) *corev1.Secret {
+		meta := metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "postgres-operator.crunchydata.com/v1beta1",
+				Kind:       "PostgresCluster", Name: cluster,
+			}},
+		}
+		if cluster != "" {
+			meta.Labels = map[string]string{util.LabelCluster: cluster}
+		}
+		return &corev1.Secret{ObjectMeta: meta}
+	}
+
+	// Volumes that were retained have no owner but keep the labels of PGO.
+	retained := object("rhino-instance1-4f9s-pgdata", "rhino", 48*time.Hour)
+	retained.OwnerReferences = nil
+	retained.Labels[util.LabelData] = "postgres"
+
+	// Secrets prepared for a cluster and its history have only the cluster label.
+	prepared := object("rhino-s3-creds", "rhino", 48*time.Hour)
+	prepared.OwnerReferences = nil
+	history := object("rhino-pgo-history", "rhino", 48*time.Hour)
+	history.OwnerReferences = nil
+
+	// Objects owned by a different kind are left alone.
+	other := object("rhino-other", "rhino", 48*time.Hour)
+	other.OwnerReferences[0].Kind = "Deployment"
+
+	objects := []prunable{
+		{"Secret", object("rhino-pguser-rhino", "rhino", 48*time.Hour)},
+		{"PersistentVolumeClaim", retained},
+		{"ConfigMap", object("rhino-config", "rhino", time.Hour)},
+		{"Secret", object("hippo-pguser-hippo", "hippo", 48*time.Hour)},
+		{"Secret", object("unlabeled", "", 48*time.Hour)},
+		{"Secret", prepared},
+		{"ConfigMap", history},
+		{"Secret", other},
+	}
+	clusters := map[string]bool{"hippo": true}
+
+	t.Run("All", func(t *testing.T) {
+		assert.DeepEqual(t, pruneCandidates(objects, clusters, 0, now), []pruneCandidate{
+			{Kind: "ConfigMap", Name: "rhino-config", Cluster: "rhino", Age: time.Hour},
+			{Kind: "PersistentVolumeClaim", Name: "rhino-instance1-4f9s-pgdata", Cluster: "rhino", Age: 48 * time.Hour},
+			{Kind: "Secret", Name: "rhino-pguser-rhino", Cluster: "rhino", Age: 48 * time.Hour},
+		})
+	})
+
+	t.Run("OlderThan", func(t *testing.T) {
+		candidates := pruneCandidates(objects, clusters, 24*time.Hour, now)
+		assert.Equal(t, len(candidates), 2)
+		assert.Equal(t, candidates[0].Name, "rhino-instance1-4f9s-pgdata")
+		assert.Equal(t, candidates[1].Name, "rhino-pguser-rhino")
+	})
+
+	t.Run("NoOrphans", func(t *testing.T) {
+		clusters := map[string]bool{"hippo": true, "rhino": true}
+		assert.Equal(t, len(pruneCandidates(objects, clusters, 0, now)), 0)
+	})
+}
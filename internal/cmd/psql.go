@@ -0,0 +1,286 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/remotecommand"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newPSQLCommand returns the psql command. It runs psql in the primary of a
+// PostgresCluster, interactively or with SQL from the command line or a file.
+func newPSQLCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "psql CLUSTER_NAME",
+		Short: "Run psql in the primary of a PostgresCluster",
+		Long: `Run psql in the database container of the primary instance of a
+PostgresCluster. Without "--command" or "--file", psql is interactive and gets
+a terminal when stdin is one; otherwise it reads SQL from stdin.
+
+By default, psql connects as the postgres superuser over the local socket. With
+"--user", it connects as that user with the password in its pguser Secret, and
+"--database" defaults to the database in that Secret. The password is sent on
+stdin so it never appears in a process list.
+
+### RBAC Requirements
+    Resources  Verbs
+    ---------  -----
+    pods       [list]
+    pods/exec  [create]
+    secrets    [list]
+
+    Note: Secrets are only listed with "--user".
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Open psql in the primary of the 'hippo' postgrescluster
+pgo psql hippo
+
+# Run one query as the user 'rhino'
+pgo psql hippo --user=rhino --command='SELECT current_user'
+
+# Run a script in the 'zoo' database, as in a CI job
+pgo psql hippo --database=zoo --file=./migrate.sql
+
+### Example output
+ current_user
+--------------
+ rhino
+(1 row)`)
+
+	var options psqlOptions
+	cmd.Flags().StringVarP(&options.Database, "database", "d", "", "database to connect to")
+	cmd.Flags().StringVarP(&options.User, "user", "U", "",
+		"user to connect as, with the password in its Secret; defaults to postgres")
+	cmd.Flags().StringVarP(&options.Command, "command", "c", "", "run only this SQL")
+	cmd.Flags().StringVarP(&options.File, "file", "f", "", "run the SQL in this local file")
+	cmd.MarkFlagsMutuallyExclusive("command", "file")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		var script []byte
+		if options.File != "" {
+			if script, err = os.ReadFile(options.File); err != nil {
+				return err
+			}
+		}
+
+		pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.PrimaryInstanceLabels(args[0]),
+		})
+		if err != nil {
+			return err
+		}
+		var primary *corev1.Pod
+		for i := range pods.Items {
+			if podIsReady(&pods.Items[i]) {
+				primary = &pods.Items[i]
+			}
+		}
+		if primary == nil {
+			return fmt.Errorf("no ready primary instance Pod found for cluster %s", args[0])
+		}
+
+		var password string
+		if options.User != "" {
+			secrets, err := getUsers(client, config, args[0], []string{options.User})
+			if err != nil {
+				return err
+			}
+			if len(secrets.Items) == 0 {
+				return fmt.Errorf("user %q has no Secret; add it to spec.users of postgrescluster/%s first",
+					options.User, args[0])
+			}
+			info := newConnectionInfo(secrets.Items[0])
+			if options.Database == "" {
+				options.Database = string(secrets.Items[0].Data["dbname"])
+			}
+			options.Port, password = info.Port, info.Password
+		}
+
+		var stdin io.Reader = cmd.InOrStdin()
+		switch {
+		case script != nil:
+			stdin = bytes.NewReader(script)
+		case options.Command != "":
+			stdin = strings.NewReader("")
+		}
+
+		// Give psql a terminal when a person is typing into one.
+		fd := int(os.Stdin.Fd())
+		if options.Command == "" && script == nil && term.IsTerminal(fd) && term.IsTerminal(int(os.Stdout.Fd())) {
+			terminal, err := util.NewPodTerminal(rest)
+			if err != nil {
+				return err
+			}
+			width, height, err := term.GetSize(fd)
+			if err != nil {
+				return err
+			}
+			state, err := term.MakeRaw(fd)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = term.Restore(fd, state) }()
+
+			// The terminal echoes what it reads, so the password waits until
+			// the remote shell has turned that off.
+			stdout := cmd.OutOrStdout()
+			if options.User != "" {
+				gate := newPasswordGate(stdout, password, stdin)
+				stdin, stdout = gate, gate
+			}
+			options.Terminal = true
+
+			return terminal(namespace, primary.GetName(), util.ContainerDatabase,
+				stdin, stdout,
+				remotecommand.TerminalSize{Width: uint16(width), Height: uint16(height)},
+				options.Args()...)
+		}
+
+		// The SQL, if any, comes after the password.
+		if options.User != "" {
+			stdin = io.MultiReader(strings.NewReader(password+"\n"), stdin)
+		}
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+		return podExec(namespace, primary.GetName(), util.ContainerDatabase,
+			stdin, cmd.OutOrStdout(), cmd.ErrOrStderr(), options.Args()...)
+	}
+
+	return cmd
+}
+
+// psqlOptions are the flags of "pgo psql".
+type psqlOptions struct {
+	Database, User string
+	Command, File  string
+
+	// Port is where psql connects as User; it comes from the Secret of User.
+	Port string
+
+	// Terminal is whether psql runs in a terminal.
+	Terminal bool
+}
+
+// Args returns the command that runs psql with options. As User, it reads the
+// password from the first line of stdin and connects over TCP, because only
+// postgres may use the local socket without one.
+func (options psqlOptions) Args() []string {
+	psql := []string{"psql"}
+	if options.Database != "" {
+		psql = append(psql, "--dbname="+options.Database)
+	}
+	if options.Command != "" {
+		psql = append(psql, "--command="+options.Command)
+	}
+	if options.File != "" {
+		psql = append(psql, "--set=ON_ERROR_STOP=1", "--file=-")
+	}
+	if options.User == "" {
+		return psql
+	}
+
+	psql = append(psql, "--host=localhost", "--username="+options.User)
+	if options.Port != "" {
+		psql = append(psql, "--port="+options.Port)
+	}
+	read := `IFS= read -r PGPASSWORD; export PGPASSWORD; exec "$@"`
+	if options.Terminal {
+		read = `stty -echo; printf '%s' '` + passwordPrompt + `'; IFS= read -r PGPASSWORD; stty echo
+export PGPASSWORD; exec "$@"`
+	}
+	return append([]string{"bash", "-ceu", read, "-"}, psql...)
+}
+
+// passwordPrompt is what the remote shell prints when it is ready to read a
+// password from a terminal without echoing it.
+const passwordPrompt = "[pgo-password]"
+
+// passwordGate holds back a password and the rest of stdin until the remote
+// shell prints [passwordPrompt] to out, which it removes.
+type passwordGate struct {
+	out     io.Writer
+	pending []byte
+	ready   chan struct{}
+	opened  bool
+
+	stdin io.Reader
+}
+
+func newPasswordGate(out io.Writer, password string, stdin io.Reader) *passwordGate {
+	return &passwordGate{
+		out:   out,
+		ready: make(chan struct{}),
+		stdin: io.MultiReader(strings.NewReader(password+"\n"), stdin),
+	}
+}
+
+// Read waits for the prompt, then reads the password and stdin.
+func (gate *passwordGate) Read(p []byte) (int, error) {
+	<-gate.ready
+	return gate.stdin.Read(p)
+}
+
+// Write passes p to out once the prompt has been removed. Output that does
+// not start with the prompt opens the gate without sending the password.
+func (gate *passwordGate) Write(p []byte) (int, error) {
+	if gate.opened {
+		return gate.out.Write(p)
+	}
+
+	gate.pending = append(gate.pending, p...)
+	prompt := []byte(passwordPrompt)
+	if len(gate.pending) < len(prompt) && bytes.HasPrefix(prompt, gate.pending) {
+		return len(p), nil
+	}
+
+	gate.opened = true
+	rest := gate.pending
+	if bytes.HasPrefix(rest, prompt) {
+		rest = rest[len(prompt):]
+	} else {
+		gate.stdin = strings.NewReader("")
+	}
+	close(gate.ready)
+
+	if _, err := gate.out.Write(rest); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestPSQLOptionsArgs(t *testing.T) {
+	assert.DeepEqual(t, psqlOptions{}.Args(), []string{"psql"})
+
+	assert.DeepEqual(t, psqlOptions{Database: "zoo", File: "migrate.sql"}.Args(),
+		[]string{"psql", "--dbname=zoo", "--set=ON_ERROR_STOP=1", "--file=-"})
+
+	args := psqlOptions{User: "rhino", Port: "5432", Command: "SELECT 1"}.Args()
+	assert.DeepEqual(t, args[3:], []string{"-",
+		"psql", "--command=SELECT 1", "--host=localhost", "--username=rhino", "--port=5432"})
+	assert.Assert(t, strings.Contains(args[2], "read -r PGPASSWORD"))
+	assert.Assert(t, !strings.Contains(args[2], passwordPrompt))
+
+	args = psqlOptions{User: "rhino", Terminal: true}.Args()
+	assert.Assert(t, strings.Contains(args[2], "stty -echo"))
+	assert.Assert(t, strings.Contains(args[2], passwordPrompt))
+}
+
+func TestPasswordGate(t *testing.T) {
+	t.Run("Prompt", func(t *testing.T) {
+		var out bytes.Buffer
+		gate := newPasswordGate(&out, "secret", strings.NewReader("\\q\n"))
+
+		// The prompt can arrive in pieces.
+		_, err := gate.Write([]byte(passwordPrompt[:3]))
+		assert.NilError(t, err)
+		assert.Equal(t, out.Len(), 0)
+		_, err = gate.Write([]byte(passwordPrompt[3:] + "psql (16.4)\r\n"))
+		assert.NilError(t, err)
+		_, err = gate.Write([]byte("zoo=> "))
+		assert.NilError(t, err)
+		assert.Equal(t, out.String(), "psql (16.4)\r\nzoo=> ")
+
+		stdin, err := io.ReadAll(gate)
+		assert.NilError(t, err)
+		assert.Equal(t, string(stdin), "secret\n\\q\n")
+	})
+
+	t.Run("NoPrompt", func(t *testing.T) {
+		var out bytes.Buffer
+		gate := newPasswordGate(&out, "secret", strings.NewReader("typed"))
+
+		_, err := gate.Write([]byte("bash: stty: not found\r\n"))
+		assert.NilError(t, err)
+		assert.Equal(t, out.String(), "bash: stty: not found\r\n")
+
+		stdin, err := io.ReadAll(gate)
+		assert.NilError(t, err)
+		assert.Equal(t, string(stdin), "", "the password is not sent")
+	})
+}
@@ -0,0 +1,49 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// addReadOnly changes the mutating commands of root to refuse to run in
+// read-only mode, as well as commands given flags that make them mutate. The
+// check runs before hooks and history, so a refused command does nothing at
+// all. When [internal.ReadOnlyEnv] is set, mutating commands are also hidden
+// from help.
+func addReadOnly(root *cobra.Command, config *internal.Config) {
+	config.ReadOnly.AddFlags(root.PersistentFlags())
+	hidden := internal.ReadOnlyFromEnv()
+
+	for _, path := range mutatingPaths() {
+		command, _, err := root.Find(strings.Fields(path))
+		if err != nil || command.RunE == nil {
+			continue
+		}
+		if _, some := mutatingFlags[path]; !some {
+			command.Hidden = command.Hidden || hidden
+		}
+
+		preRun, path := command.PreRunE, path
+		command.PreRunE = func(cmd *cobra.Command, args []string) error {
+			if flag, ok := mutatingFlag(cmd, path); ok && config.ReadOnly.Enabled() {
+				name := "pgo " + path
+				if flag != "" {
+					name += " --" + flag
+				}
+				return fmt.Errorf("%q changes objects in Kubernetes or Postgres and cannot run in read-only mode", name)
+			}
+			if preRun != nil {
+				return preRun(cmd, args)
+			}
+			return nil
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+func TestReadOnly(t *testing.T) {
+	t.Run("Flag", func(t *testing.T) {
+		root := NewPGOCommand(nil, nil, nil)
+		root.SetArgs([]string{"--read-only", "stop", "hippo"})
+
+		err := root.Execute()
+		assert.ErrorContains(t, err, `"pgo stop" changes objects in Kubernetes or Postgres and cannot run in read-only mode`)
+
+		stop, _, err := root.Find([]string{"stop"})
+		assert.NilError(t, err)
+		assert.Assert(t, !stop.Hidden)
+	})
+
+	t.Run("Flags", func(t *testing.T) {
+		root := NewPGOCommand(nil, nil, nil)
+		root.SetArgs([]string{"--read-only", "show", "replication-slots", "hippo", "--drop=old_slot"})
+
+		err := root.Execute()
+		assert.ErrorContains(t, err,
+			`"pgo show replication-slots --drop" changes objects in Kubernetes or Postgres and cannot run in read-only mode`)
+
+		// Without the flag, the command only reads.
+		root = NewPGOCommand(nil, nil, nil)
+		assert.NilError(t, root.PersistentFlags().Set("read-only", "true"))
+		slots, _, err := root.Find([]string{"show", "replication-slots"})
+		assert.NilError(t, err)
+		assert.NilError(t, slots.PreRunE(slots, []string{"hippo"}))
+		assert.Assert(t, !slots.Hidden)
+	})
+
+	t.Run("PSQL", func(t *testing.T) {
+		root := NewPGOCommand(nil, nil, nil)
+		root.SetArgs([]string{"--read-only", "psql", "hippo", "--command=DROP DATABASE app"})
+
+		err := root.Execute()
+		assert.ErrorContains(t, err, `"pgo psql" changes objects in Kubernetes or Postgres and cannot run in read-only mode`)
+	})
+
+	t.Run("Env", func(t *testing.T) {
+		t.Setenv(internal.ReadOnlyEnv, "true")
+		root := NewPGOCommand(nil, nil, nil)
+
+		for _, path := range mutatingCommands {
+			command, _, err := root.Find(strings.Fields(path))
+			assert.NilError(t, err)
+			assert.Assert(t, command.Hidden, "%q", path)
+			assert.ErrorContains(t, command.PreRunE(command, nil), "read-only mode")
+		}
+
+		// The flag cannot turn it off.
+		root.SetArgs([]string{"--read-only=false", "set", "pdb", "hippo", "--min-available=1"})
+		assert.ErrorContains(t, root.Execute(), `"pgo set pdb"`)
+	})
+
+	t.Run("EnvValues", func(t *testing.T) {
+		for value, expected := range map[string]bool{
+			"": false, "false": false, "0": false, "true": true, "1": true, "yes": true,
+		} {
+			t.Setenv(internal.ReadOnlyEnv, value)
+			assert.Equal(t, internal.ReadOnlyFromEnv(), expected, "%q", value)
+		}
+	})
+}
@@ -0,0 +1,189 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newRebuildCommand returns the rebuild subcommand of the PGO plugin.
+// Subcommands of rebuild re-initialize parts of a PostgresCluster.
+func newRebuildCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rebuild",
+		Short: "Rebuild part of a PostgresCluster",
+		Long:  "Rebuild part of a PostgresCluster",
+	}
+
+	cmd.AddCommand(newRebuildReplicaCommand(config))
+
+	return cmd
+}
+
+// newRebuildReplicaCommand returns the rebuild replica subcommand. It deletes
+// the volumes and Pod of one replica so that it is re-initialized.
+func newRebuildReplicaCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replica CLUSTER_NAME",
+		Short: "Rebuild a replica of a PostgresCluster",
+		Long: `Rebuild a replica of a PostgresCluster by deleting its data and WAL volumes
+and its Pod. PGO recreates the volumes and Patroni re-initializes the replica
+from the pgBackRest repository or from the primary.
+
+The instance must be a replica, the primary must be ready, and at least one
+other replica must be ready.
+
+### RBAC Requirements
+    Resources               Verbs
+    ---------               -----
+    persistentvolumeclaims  [list delete]
+    pods                    [list delete]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Rebuild the replica 'hippo-instance1-pwr2' of the 'hippo' postgrescluster
+pgo rebuild replica hippo --instance=hippo-instance1-pwr2
+
+### Example output
+WARNING: Rebuilding a replica deletes its data. Are you sure you want to continue? (yes/no): yes
+persistentvolumeclaims/hippo-instance1-pwr2-pgdata deleted
+pods/hippo-instance1-pwr2-0 deleted`)
+
+	var instance string
+	cmd.Flags().StringVar(&instance, "instance", "",
+		"the instance or Pod name of the replica to rebuild")
+	cobra.CheckErr(cmd.MarkFlagRequired("instance"))
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.DBInstanceLabels(args[0]),
+		})
+		if err != nil {
+			return err
+		}
+
+		replica, err := findReplicaToRebuild(pods.Items, instance)
+		if err != nil {
+			return err
+		}
+
+		instanceName := replica.GetLabels()[util.LabelInstance]
+		volumes, err := client.PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.InstanceVolumeLabels(args[0], instanceName),
+		})
+		if err != nil {
+			return err
+		}
+
+		_, _ = fmt.Fprint(config.ErrOut, config.Messages.Sprintf("rebuild.warn")+
+			config.Messages.Sprintf("confirm.continue"))
+		var confirmed *bool
+		for i := 0; confirmed == nil && i < 10; i++ {
+			// retry 10 times or until a confirmation is given or denied,
+			// whichever comes first
+			confirmed = config.Messages.Confirm(config.In, config.ErrOut)
+		}
+		if confirmed == nil || !*confirmed {
+			return ErrCancelled
+		}
+
+		// The volumes are not removed until the Pod using them is deleted.
+		for _, volume := range volumes.Items {
+			err := client.PersistentVolumeClaims(namespace).
+				Delete(ctx, volume.GetName(), metav1.DeleteOptions{})
+			if err != nil {
+				return err
+			}
+			cmd.Printf("persistentvolumeclaims/%s deleted\n", volume.GetName())
+		}
+
+		err = client.Pods(namespace).Delete(ctx, replica.GetName(), metav1.DeleteOptions{})
+		if err != nil {
+			return err
+		}
+		cmd.Printf("pods/%s deleted\n", replica.GetName())
+
+		return nil
+	}
+
+	return cmd
+}
+
+// findReplicaToRebuild returns the Pod in pods that matches instance by Pod
+// name or instance name. It returns an error when that Pod is the primary or
+// when rebuilding it would leave no ready primary or no other ready replica.
+func findReplicaToRebuild(pods []corev1.Pod, instance string) (*corev1.Pod, error) {
+	var target *corev1.Pod
+	var primaries, replicas int
+
+	for i := range pods {
+		pod := &pods[i]
+		if pod.GetName() == instance || pod.GetLabels()[util.LabelInstance] == instance {
+			target = pod
+			continue
+		}
+		if !podIsReady(pod) {
+			continue
+		}
+		switch pod.GetLabels()[util.LabelRole] {
+		case util.RolePatroniLeader:
+			primaries++
+		case util.RolePatroniReplica:
+			replicas++
+		}
+	}
+
+	switch {
+	case target == nil:
+		return nil, fmt.Errorf("instance %q not found", instance)
+	case target.GetLabels()[util.LabelRole] == util.RolePatroniLeader:
+		return nil, fmt.Errorf("%s is the primary; only replicas can be rebuilt", target.GetName())
+	case primaries == 0:
+		return nil, errors.New("no ready primary found; a replica cannot be rebuilt without one")
+	case replicas == 0:
+		return nil, fmt.Errorf("%s is the only ready replica; rebuild it after another replica is ready",
+			target.GetName())
+	}
+
+	return target, nil
+}
+
+// podIsReady returns true when the Ready condition of pod is true.
+func podIsReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+func TestFindReplicaToRebuild(t *testing.T) {
+	pod := func(instance, role string, ready bool) corev1.Pod {
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		var p corev1.Pod
+		p.Name = instance + "-0"
+		p.Labels = map[string]string{util.LabelInstance: instance, util.LabelRole: role}
+		p.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}
+		return p
+	}
+
+	t.Run("Replica", func(t *testing.T) {
+		pods := []corev1.Pod{
+			pod("hippo-a", util.RolePatroniLeader, true),
+			pod("hippo-b", util.RolePatroniReplica, false),
+			pod("hippo-c", util.RolePatroniReplica, true),
+		}
+
+		replica, err := findReplicaToRebuild(pods, "hippo-b")
+		assert.NilError(t, err)
+		assert.Equal(t, replica.Name, "hippo-b-0")
+
+		replica, err = findReplicaToRebuild(pods, "hippo-b-0")
+		assert.NilError(t, err)
+		assert.Equal(t, replica.Name, "hippo-b-0")
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := findReplicaToRebuild(nil, "hippo-x")
+		assert.ErrorContains(t, err, `instance "hippo-x" not found`)
+	})
+
+	t.Run("Primary", func(t *testing.T) {
+		_, err := findReplicaToRebuild([]corev1.Pod{
+			pod("hippo-a", util.RolePatroniLeader, true),
+			pod("hippo-b", util.RolePatroniReplica, true),
+		}, "hippo-a")
+		assert.ErrorContains(t, err, "hippo-a-0 is the primary")
+	})
+
+	t.Run("NoPrimary", func(t *testing.T) {
+		_, err := findReplicaToRebuild([]corev1.Pod{
+			pod("hippo-a", util.RolePatroniLeader, false),
+			pod("hippo-b", util.RolePatroniReplica, true),
+			pod("hippo-c", util.RolePatroniReplica, true),
+		}, "hippo-b")
+		assert.ErrorContains(t, err, "no ready primary")
+	})
+
+	t.Run("LastReplica", func(t *testing.T) {
+		_, err := findReplicaToRebuild([]corev1.Pod{
+			pod("hippo-a", util.RolePatroniLeader, true),
+			pod("hippo-b", util.RolePatroniReplica, true),
+			pod("hippo-c", util.RolePatroniReplica, false),
+		}, "hippo-b")
+		assert.ErrorContains(t, err, "only ready replica")
+	})
+}
@@ -0,0 +1,30 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// newReportCommand returns the report subcommand of the PGO plugin.
+// Subcommands of report analyze what already happened to a PostgresCluster.
+func newReportCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Report on the history of a PostgresCluster",
+		Long:  "Report on the history of a PostgresCluster",
+	}
+
+	cmd.AddCommand(
+		newReportAvailabilityCommand(config),
+		newReportCapacityCommand(config),
+		newReportStartupCommand(config),
+		newReportVersionsCommand(config),
+	)
+
+	return cmd
+}
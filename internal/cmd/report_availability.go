@@ -0,0 +1,409 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/flags"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newReportAvailabilityCommand returns the availability subcommand of the
+// report command. It reconstructs when the primary of a cluster was down.
+func newReportAvailabilityCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "availability CLUSTER_NAME",
+		Short: "Report when the primary of a PostgresCluster was unavailable",
+		Long: `Report the incidents in which the primary of a PostgresCluster was
+unavailable, how many times it failed over, and the total downtime within
+"--since".
+
+Incidents are reconstructed from the Patroni history of leader changes, the
+status of instance Pods, and their Events:
+  - a leader change ends an incident; it starts when the old leader last went
+    down within a few minutes before, or at the change when nothing shows that
+  - a restart of the database container of the leader
+  - the leader not being ready now
+  - failed probes of the leader reported by Events
+
+Kubernetes keeps Events for one hour by default and Pods report only their last
+restart, so older incidents may be missing or shorter than they were. Leader
+changes are always found.
+
+### RBAC Requirements
+    Resources  Verbs
+    ---------  -----
+    events     [list]
+    pods       [list]
+    pods/exec  [create]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Report the availability of the 'hippo' postgrescluster in the last 30 days
+pgo report availability hippo --since=30d
+
+### Example output
+START                 END                   DURATION  INSTANCE                CAUSE
+2024-04-12T03:13:52Z  2024-04-12T03:14:41Z  49s       hippo-instance1-8x7m-0  database container restarted (OOMKilled); leader changed to hippo-instance1-2bc9-0 (timeline 3)
+2024-04-28T11:02:10Z  2024-04-28T11:02:10Z  0s        hippo-instance1-2bc9-0  leader changed to hippo-instance1-8x7m-0 (timeline 4)
+
+since: 2024-04-01T12:00:00Z
+incidents: 2
+failovers: 2
+downtime: 49s
+availability: 99.9981%`)
+
+	since := 30 * 24 * time.Hour
+	cmd.Flags().Var(daysDuration{&since}, "since", "how far back to report, e.g. 30d or 12h")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		now := time.Now().UTC().Truncate(time.Second)
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		clientset, err := kubernetes.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+
+		pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.DBInstanceLabels(args[0]),
+		})
+		if err != nil {
+			return err
+		}
+		if len(pods.Items) == 0 {
+			return fmt.Errorf("no instance Pods found for cluster %s", args[0])
+		}
+		events, err := clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+			FieldSelector: "involvedObject.kind=Pod",
+		})
+		if err != nil {
+			return err
+		}
+
+		// Any running instance can read the history from the DCS; prefer the
+		// leader.
+		var leader string
+		var candidate *corev1.Pod
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			if pod.GetLabels()[util.LabelRole] == util.RolePatroniLeader {
+				leader = pod.GetName()
+			}
+			if podIsReady(pod) && (candidate == nil || pod.GetName() == leader) {
+				candidate = pod
+			}
+		}
+		if candidate == nil {
+			return fmt.Errorf("no ready instance Pod found for cluster %s", args[0])
+		}
+		exec := podexec.Container(podExec, namespace, candidate.GetName(), util.ContainerDatabase)
+		stdout, stderr, err := podexec.Patronictl(exec, "history", "json")
+		if err != nil {
+			return commandError(err, stderr)
+		}
+		changes, err := parseLeaderChanges(stdout)
+		if err != nil {
+			return err
+		}
+
+		report := reportAvailability(now.Add(-since), now, leader, changes,
+			instanceOutages(pods.Items, events.Items, now))
+		return printAvailability(cmd, report)
+	}
+
+	return cmd
+}
+
+// failoverWindow is how long before a leader change an outage of the old
+// leader is considered the start of the failover. It is a few times the
+// default Patroni TTL of 30 seconds.
+const failoverWindow = 5 * time.Minute
+
+// leaderChange is one entry of the Patroni history: a new timeline.
+type leaderChange struct {
+	Time     time.Time
+	Timeline int64
+	Leader   string
+}
+
+// parseLeaderChanges reads the output of "patronictl history --format json".
+// Older versions of Patroni do not report the new leader.
+func parseLeaderChanges(stdout string) ([]leaderChange, error) {
+	var rows []map[string]any
+	if err := json.Unmarshal([]byte(stdout), &rows); err != nil {
+		return nil, fmt.Errorf("unable to parse the Patroni history: %w", err)
+	}
+
+	var changes []leaderChange
+	for _, row := range rows {
+		stamp, _ := row["Timestamp"].(string)
+		t, err := time.Parse(time.RFC3339Nano, stamp)
+		if err != nil {
+			continue
+		}
+		change := leaderChange{Time: t.UTC()}
+		change.Leader, _ = row["New Leader"].(string)
+		// Each row is the timeline that ended; the new leader starts the next.
+		switch tl := row["TL"].(type) {
+		case float64:
+			change.Timeline = int64(tl) + 1
+		case string:
+			n, _ := strconv.ParseInt(tl, 10, 64)
+			change.Timeline = n + 1
+		}
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Time.Before(changes[j].Time) })
+	return changes, nil
+}
+
+// availabilityIncident is a time when the primary was, or may have been,
+// unavailable.
+type availabilityIncident struct {
+	Start, End time.Time
+	Instance   string
+	Causes     []string
+}
+
+// instanceOutages returns the times that instances in pods were down
+// according to their status and their events.
+func instanceOutages(pods []corev1.Pod, events []corev1.Event, now time.Time) []availabilityIncident {
+	var outages []availabilityIncident
+	names := map[string]bool{}
+
+	for _, pod := range pods {
+		names[pod.GetName()] = true
+
+		for _, status := range pod.Status.ContainerStatuses {
+			terminated := status.LastTerminationState.Terminated
+			if status.Name != util.ContainerDatabase || terminated == nil {
+				continue
+			}
+			end := now
+			if running := status.State.Running; running != nil {
+				end = running.StartedAt.Time
+			}
+			outages = append(outages, availabilityIncident{
+				Start: terminated.FinishedAt.Time, End: end, Instance: pod.GetName(),
+				Causes: []string{fmt.Sprintf("database container restarted (%s)", terminated.Reason)},
+			})
+		}
+
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status != corev1.ConditionTrue {
+				outages = append(outages, availabilityIncident{
+					Start: condition.LastTransitionTime.Time, End: now, Instance: pod.GetName(),
+					Causes: []string{"not ready"},
+				})
+			}
+		}
+	}
+
+	// Pods of a StatefulSet keep their name when they are replaced.
+	for _, event := range events {
+		if !names[event.InvolvedObject.Name] || event.Type != corev1.EventTypeWarning ||
+			event.Reason != "Unhealthy" {
+			continue
+		}
+		probe, _, _ := strings.Cut(event.Message, " ")
+		outages = append(outages, availabilityIncident{
+			Start: event.FirstTimestamp.Time, End: event.LastTimestamp.Time,
+			Instance: event.InvolvedObject.Name,
+			Causes:   []string{fmt.Sprintf("%s probe failed %d times", strings.ToLower(probe), event.Count)},
+		})
+	}
+	return outages
+}
+
+// availabilityReport summarizes the incidents between Since and Until.
+type availabilityReport struct {
+	Since, Until time.Time
+	Incidents    []availabilityIncident
+	Failovers    int
+	Downtime     time.Duration
+}
+
+// Availability returns the fraction of time that the primary was available.
+func (report availabilityReport) Availability() float64 {
+	window := report.Until.Sub(report.Since)
+	if window <= 0 {
+		return 1
+	}
+	return 1 - float64(report.Downtime)/float64(window)
+}
+
+// reportAvailability combines leader changes and the outages of instances into
+// incidents of the primary between since and now. Outages of instances that
+// were not the leader at the time are ignored; leader is the current one.
+func reportAvailability(since, now time.Time, leader string,
+	changes []leaderChange, outages []availabilityIncident,
+) availabilityReport {
+	// The leader at t is the new leader of the last change before it. When
+	// that is not recorded, or t is before the first change, the leader is
+	// unknown and every outage counts.
+	leaderAt := func(t time.Time) string {
+		last := -1
+		for i := range changes {
+			if !changes[i].Time.After(t) {
+				last = i
+			}
+		}
+		switch {
+		case last == len(changes)-1:
+			if last >= 0 && changes[last].Leader != "" {
+				return changes[last].Leader
+			}
+			return leader
+		case last >= 0:
+			return changes[last].Leader
+		}
+		return ""
+	}
+
+	var incidents []availabilityIncident
+	for _, outage := range outages {
+		if holder := leaderAt(outage.Start); holder == "" || holder == outage.Instance {
+			incidents = append(incidents, outage)
+		}
+	}
+
+	report := availabilityReport{Since: since, Until: now}
+	for _, change := range changes {
+		if change.Time.Before(since) || change.Time.After(now) {
+			continue
+		}
+		report.Failovers++
+
+		incident := availabilityIncident{Start: change.Time, End: change.Time,
+			Instance: leaderAt(change.Time.Add(-time.Nanosecond))}
+		for _, outage := range outages {
+			if outage.Instance == incident.Instance && outage.Start.Before(incident.Start) &&
+				!outage.Start.Before(change.Time.Add(-failoverWindow)) {
+				incident.Start = outage.Start
+			}
+		}
+		cause := "leader changed"
+		if change.Leader != "" {
+			cause += " to " + change.Leader
+		}
+		incident.Causes = []string{fmt.Sprintf("%s (timeline %d)", cause, change.Timeline)}
+		incidents = append(incidents, incident)
+	}
+
+	// Clip to the window, then merge incidents that overlap.
+	var clipped []availabilityIncident
+	for _, incident := range incidents {
+		if incident.Start.Before(since) {
+			incident.Start = since
+		}
+		if incident.End.After(now) {
+			incident.End = now
+		}
+		if !incident.End.Before(incident.Start) {
+			clipped = append(clipped, incident)
+		}
+	}
+	sort.SliceStable(clipped, func(i, j int) bool { return clipped[i].Start.Before(clipped[j].Start) })
+
+	for _, incident := range clipped {
+		if n := len(report.Incidents); n > 0 && !incident.Start.After(report.Incidents[n-1].End) {
+			last := &report.Incidents[n-1]
+			if incident.End.After(last.End) {
+				last.End = incident.End
+			}
+			if last.Instance == "" {
+				last.Instance = incident.Instance
+			}
+			last.Causes = append(last.Causes, incident.Causes...)
+			continue
+		}
+		report.Incidents = append(report.Incidents, incident)
+	}
+	for _, incident := range report.Incidents {
+		report.Downtime += incident.End.Sub(incident.Start)
+	}
+	return report
+}
+
+// printAvailability prints the incidents and summary of report.
+func printAvailability(cmd *cobra.Command, report availabilityReport) error {
+	if len(report.Incidents) > 0 {
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+		fmt.Fprintln(w, "START\tEND\tDURATION\tINSTANCE\tCAUSE")
+		for _, incident := range report.Incidents {
+			instance := incident.Instance
+			if instance == "" {
+				instance = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				incident.Start.UTC().Format(time.RFC3339), incident.End.UTC().Format(time.RFC3339),
+				incident.End.Sub(incident.Start).Round(time.Second), instance,
+				strings.Join(incident.Causes, "; "))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		cmd.Println()
+	}
+
+	cmd.Printf("since: %s\n", report.Since.UTC().Format(time.RFC3339))
+	cmd.Printf("incidents: %d\n", len(report.Incidents))
+	cmd.Printf("failovers: %d\n", report.Failovers)
+	cmd.Printf("downtime: %s\n", report.Downtime.Round(time.Second))
+	cmd.Printf("availability: %.4f%%\n", 100*report.Availability())
+	return nil
+}
+
+// daysDuration implements [pflag.Value] for a duration that may be in days,
+// such as "30d".
+type daysDuration struct{ *time.Duration }
+
+func (d daysDuration) Type() string { return "duration" }
+
+func (d daysDuration) String() string {
+	if *d.Duration > 0 && *d.Duration%(24*time.Hour) == 0 {
+		return strconv.FormatInt(int64(*d.Duration/(24*time.Hour)), 10) + "d"
+	}
+	return d.Duration.String()
+}
+
+func (d daysDuration) Set(v string) error {
+	parsed, err := flags.ParseDuration(v)
+	if err == nil {
+		*d.Duration = parsed
+	}
+	return err
+}
@@ -0,0 +1,187 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseLeaderChanges(t *testing.T) {
+	changes, err := parseLeaderChanges(`[
+{"TL": 2, "LSN": 50331808, "Reason": "no recovery target specified", "Timestamp": "2024-04-28T11:02:10.101626+00:00", "New Leader": "hippo-b-0"},
+{"TL": 1, "LSN": 25165984, "Reason": "no recovery target specified", "Timestamp": "2024-04-12T03:14:41.000000+00:00"},
+{"TL": 3, "Timestamp": "unknown"}
+]`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, changes, []leaderChange{
+		{Time: time.Date(2024, 4, 12, 3, 14, 41, 0, time.UTC), Timeline: 2},
+		{Time: time.Date(2024, 4, 28, 11, 2, 10, 101626000, time.UTC), Timeline: 3, Leader: "hippo-b-0"},
+	})
+
+	_, err = parseLeaderChanges("+----+")
+	assert.ErrorContains(t, err, "unable to parse")
+}
+
+func TestInstanceOutages(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) metav1.Time { return metav1.NewTime(now.Add(time.Duration(minutes) * time.Minute)) }
+
+	pods := []corev1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Name: "hippo-a-0"},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name: "database",
+				State: corev1.ContainerState{
+					Running: &corev1.ContainerStateRunning{StartedAt: at(-58)},
+				},
+				LastTerminationState: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", FinishedAt: at(-59)},
+				},
+			}, {
+				Name: "pgbackrest",
+				LastTerminationState: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{Reason: "Error", FinishedAt: at(-30)},
+				},
+			}},
+		},
+	}, {
+		ObjectMeta: metav1.ObjectMeta{Name: "hippo-b-0"},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{
+				Type: corev1.PodReady, Status: corev1.ConditionFalse, LastTransitionTime: at(-2),
+			}},
+		},
+	}}
+	events := []corev1.Event{{
+		InvolvedObject: corev1.ObjectReference{Name: "hippo-a-0"},
+		Type:           corev1.EventTypeWarning, Reason: "Unhealthy", Count: 3,
+		Message:        "Readiness probe failed: HTTP probe failed with statuscode: 503",
+		FirstTimestamp: at(-10), LastTimestamp: at(-9),
+	}, {
+		InvolvedObject: corev1.ObjectReference{Name: "other-0"},
+		Type:           corev1.EventTypeWarning, Reason: "Unhealthy",
+	}, {
+		InvolvedObject: corev1.ObjectReference{Name: "hippo-a-0"},
+		Type:           corev1.EventTypeNormal, Reason: "Pulled",
+	}}
+
+	assert.DeepEqual(t, instanceOutages(pods, events, now), []availabilityIncident{
+		{Start: at(-59).Time, End: at(-58).Time, Instance: "hippo-a-0",
+			Causes: []string{"database container restarted (OOMKilled)"}},
+		{Start: at(-2).Time, End: now, Instance: "hippo-b-0", Causes: []string{"not ready"}},
+		{Start: at(-10).Time, End: at(-9).Time, Instance: "hippo-a-0",
+			Causes: []string{"readiness probe failed 3 times"}},
+	})
+}
+
+func TestReportAvailability(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	since := now.Add(-24 * time.Hour)
+	at := func(minutes int) time.Time { return now.Add(time.Duration(minutes) * time.Minute) }
+
+	t.Run("NoChanges", func(t *testing.T) {
+		report := reportAvailability(since, now, "hippo-a-0", nil, []availabilityIncident{
+			{Start: at(-60), End: at(-59), Instance: "hippo-a-0", Causes: []string{"restarted"}},
+			{Start: at(-30), End: at(-20), Instance: "hippo-b-0", Causes: []string{"replica"}},
+			{Start: at(-25 * 60), End: at(-24*60 + 1), Instance: "hippo-a-0", Causes: []string{"old"}},
+		})
+		assert.Equal(t, report.Failovers, 0)
+		assert.DeepEqual(t, report.Incidents, []availabilityIncident{
+			{Start: since, End: at(-24*60 + 1), Instance: "hippo-a-0", Causes: []string{"old"}},
+			{Start: at(-60), End: at(-59), Instance: "hippo-a-0", Causes: []string{"restarted"}},
+		})
+		assert.Equal(t, report.Downtime, 2*time.Minute)
+	})
+
+	t.Run("Failover", func(t *testing.T) {
+		changes := []leaderChange{
+			{Time: at(-48 * 60), Timeline: 2, Leader: "hippo-a-0"},
+			{Time: at(-60), Timeline: 3, Leader: "hippo-b-0"},
+		}
+		report := reportAvailability(since, now, "hippo-b-0", changes, []availabilityIncident{
+			// The old leader went down two minutes before the change.
+			{Start: at(-62), End: at(-50), Instance: "hippo-a-0", Causes: []string{"restarted"}},
+			// The old leader as a replica, and too long before the change.
+			{Start: at(-30), End: at(-29), Instance: "hippo-a-0", Causes: []string{"replica"}},
+			{Start: at(-120), End: at(-119), Instance: "hippo-b-0", Causes: []string{"replica"}},
+			// The new leader.
+			{Start: at(-5), End: at(-4), Instance: "hippo-b-0", Causes: []string{"probe"}},
+		})
+		assert.Equal(t, report.Failovers, 1)
+		assert.DeepEqual(t, report.Incidents, []availabilityIncident{
+			{Start: at(-62), End: at(-50), Instance: "hippo-a-0",
+				Causes: []string{"restarted", "leader changed to hippo-b-0 (timeline 3)"}},
+			{Start: at(-5), End: at(-4), Instance: "hippo-b-0", Causes: []string{"probe"}},
+		})
+		assert.Equal(t, report.Downtime, 13*time.Minute)
+		assert.Assert(t, report.Availability() > 0.99 && report.Availability() < 1)
+	})
+
+	t.Run("UnknownLeaders", func(t *testing.T) {
+		// Older versions of Patroni do not record the new leader.
+		report := reportAvailability(since, now, "hippo-b-0",
+			[]leaderChange{{Time: at(-60), Timeline: 2}}, []availabilityIncident{
+				{Start: at(-120), End: at(-119), Instance: "hippo-b-0", Causes: []string{"replica?"}},
+			})
+		assert.Equal(t, report.Failovers, 1)
+		assert.DeepEqual(t, report.Incidents, []availabilityIncident{
+			{Start: at(-120), End: at(-119), Instance: "hippo-b-0", Causes: []string{"replica?"}},
+			{Start: at(-60), End: at(-60), Causes: []string{"leader changed (timeline 2)"}},
+		})
+	})
+}
+
+func TestPrintAvailability(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	report := availabilityReport{
+		Since: now.Add(-24 * time.Hour), Until: now, Failovers: 1, Downtime: 36 * time.Second,
+		Incidents: []availabilityIncident{{
+			Start: now.Add(-time.Hour), End: now.Add(-time.Hour + 36*time.Second),
+			Causes: []string{"leader changed (timeline 2)", "not ready"},
+		}},
+	}
+
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+	assert.NilError(t, printAvailability(cmd, report))
+	assert.Equal(t, out.String(), `
+START                 END                   DURATION  INSTANCE  CAUSE
+2024-05-01T11:00:00Z  2024-05-01T11:00:36Z  36s       -         leader changed (timeline 2); not ready
+
+since: 2024-04-30T12:00:00Z
+incidents: 1
+failovers: 1
+downtime: 36s
+availability: 99.9583%
+`[1:])
+}
+
+func TestDaysDuration(t *testing.T) {
+	var d time.Duration
+	flag := daysDuration{&d}
+
+	assert.NilError(t, flag.Set("30d"))
+	assert.Equal(t, d, 30*24*time.Hour)
+	assert.Equal(t, flag.String(), "30d")
+
+	assert.NilError(t, flag.Set("1.5d"))
+	assert.Equal(t, d, 36*time.Hour)
+	assert.Equal(t, flag.String(), "36h0m0s")
+
+	assert.NilError(t, flag.Set("90m"))
+	assert.Equal(t, d, 90*time.Minute)
+
+	assert.ErrorContains(t, flag.Set("xd"), "invalid duration")
+	assert.ErrorContains(t, flag.Set("30"), "missing unit")
+}
@@ -0,0 +1,489 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newReportCapacityCommand returns the capacity subcommand of the report
+// command. It records how full the volumes of a cluster are and forecasts when
+// they will be full.
+func newReportCapacityCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "capacity CLUSTER_NAME",
+		Short: "Forecast when the volumes of a PostgresCluster will be full",
+		Long: `Record the used space of every PersistentVolumeClaim of a PostgresCluster along
+with the size of its databases and WAL, then report how fast each volume grows
+and how many days remain until it is full.
+
+Every run adds one sample. Samples are kept in a ConfigMap named
+CLUSTER_NAME-capacity so that everyone running this command shares them. Use
+"--state-file" to keep them in a local file instead. Run this command
+regularly, e.g. daily from a CronJob, for a useful forecast. The growth rate is
+a linear fit of the samples within "--retention".
+
+Use "--no-sample" to report on the samples already recorded.
+
+### RBAC Requirements
+    Resources   Verbs
+    ---------   -----
+    configmaps  [get patch]
+    pods        [list]
+    pods/exec   [create]
+
+    Note: ConfigMap permissions are not needed with "--state-file".
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Record a sample and forecast the volumes of the 'hippo' postgrescluster
+pgo report capacity hippo
+
+# Keep samples on this machine rather than in a ConfigMap
+pgo report capacity hippo --state-file=hippo-capacity.json
+
+### Example output
+PVC                          MOUNT        USED     SIZE      GROWTH/DAY  DAYS UNTIL FULL
+hippo-instance1-8x7m-pgdata  /pgdata      12.0GiB  50.0GiB   256.0MiB    152
+hippo-instance1-8x7m-pgwal   /pgwal       1.2GiB   10.0GiB   -           -
+hippo-repo1                  /pgbackrest  30.4GiB  100.0GiB  1.1GiB      63
+
+database size: 10.8GiB (230.4MiB/day)
+WAL size: 1.1GiB
+samples: 14 from 2024-04-17T06:00:00Z to 2024-05-01T06:00:00Z`)
+
+	var noSample bool
+	var retention time.Duration
+	var stateFile string
+	cmd.Flags().BoolVar(&noSample, "no-sample", false, "report without recording a new sample")
+	cmd.Flags().DurationVar(&retention, "retention", 30*24*time.Hour, "how long samples are kept")
+	cmd.Flags().StringVar(&stateFile, "state-file", "", "keep samples in this local file rather than a ConfigMap")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		clientset, err := kubernetes.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		store := capacityStore{
+			ConfigMaps: clientset.CoreV1().ConfigMaps(namespace),
+			Config:     config,
+			Cluster:    args[0],
+			File:       stateFile,
+		}
+		samples, err := store.Load(ctx)
+		if err != nil {
+			return err
+		}
+
+		if !noSample {
+			podExec, err := util.NewPodExecutor(rest)
+			if err != nil {
+				return err
+			}
+			pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+				LabelSelector: util.LabelCluster + "=" + args[0],
+			})
+			if err != nil {
+				return err
+			}
+
+			sample, err := takeCapacitySample(pods.Items, func(pod *corev1.Pod, container string) podexec.Executor {
+				return podexec.Container(podExec, namespace, pod.Name, container)
+			}, time.Now())
+			if err != nil {
+				return err
+			}
+
+			samples = pruneCapacitySamples(append(samples, sample), retention, sample.Time)
+			if err := store.Save(ctx, samples); err != nil {
+				return err
+			}
+		}
+
+		if len(samples) == 0 {
+			return errors.New("no samples recorded; run without --no-sample first")
+		}
+		return printCapacityForecast(cmd, samples)
+	}
+
+	return cmd
+}
+
+// capacitySample is the usage of the volumes and databases of a cluster at
+// one time.
+type capacitySample struct {
+	Time time.Time `json:"time"`
+
+	// Volumes is keyed by the name of the PersistentVolumeClaim.
+	Volumes map[string]volumeUsage `json:"volumes"`
+
+	// DatabaseBytes and WALBytes are measured on the primary. They are zero
+	// when it could not be reached.
+	DatabaseBytes int64 `json:"databaseBytes,omitempty"`
+	WALBytes      int64 `json:"walBytes,omitempty"`
+}
+
+// volumeUsage is the space of one mounted volume in bytes.
+type volumeUsage struct {
+	Mount string `json:"mount"`
+	Used  int64  `json:"used"`
+	Size  int64  `json:"size"`
+}
+
+// capacityMountedClaim is a PersistentVolumeClaim mounted in a container.
+type capacityMountedClaim struct {
+	Claim, Mount string
+}
+
+// capacityClaims returns the PersistentVolumeClaims mounted in container of pod.
+func capacityClaims(pod *corev1.Pod, container string) []capacityMountedClaim {
+	claims := map[string]string{}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim != nil {
+			claims[volume.Name] = volume.PersistentVolumeClaim.ClaimName
+		}
+	}
+
+	var mounted []capacityMountedClaim
+	for _, c := range pod.Spec.Containers {
+		if c.Name != container {
+			continue
+		}
+		for _, mount := range c.VolumeMounts {
+			if claim, ok := claims[mount.Name]; ok {
+				mounted = append(mounted, capacityMountedClaim{Claim: claim, Mount: mount.MountPath})
+			}
+		}
+	}
+	return mounted
+}
+
+// parseDiskFree reads the output of "df --block-size=1 --output=target,used,size"
+// and returns the usage keyed by mount point.
+func parseDiskFree(stdout string) map[string]volumeUsage {
+	usage := map[string]volumeUsage{}
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		used, err1 := strconv.ParseInt(fields[1], 10, 64)
+		size, err2 := strconv.ParseInt(fields[2], 10, 64)
+		if err1 == nil && err2 == nil {
+			usage[fields[0]] = volumeUsage{Mount: fields[0], Used: used, Size: size}
+		}
+	}
+	return usage
+}
+
+// capacitySQL returns the total size of all databases and of the WAL directory.
+const capacitySQL = `SELECT
+  (SELECT sum(pg_database_size(oid)) FROM pg_database)::bigint,
+  (SELECT coalesce(sum(size), 0) FROM pg_ls_waldir())::bigint;`
+
+// takeCapacitySample measures the volumes of the instance and repository host
+// pods and the databases of the primary using exec.
+func takeCapacitySample(
+	pods []corev1.Pod, exec func(*corev1.Pod, string) podexec.Executor, now time.Time,
+) (capacitySample, error) {
+	sample := capacitySample{Time: now.UTC().Truncate(time.Second), Volumes: map[string]volumeUsage{}}
+
+	for i := range pods {
+		pod := &pods[i]
+		labels := pod.GetLabels()
+
+		container := util.ContainerDatabase
+		if _, ok := labels[util.LabelPGBackRestDedicated]; ok {
+			container = util.ContainerPGBackrest
+		} else if labels[util.LabelData] != util.DataPostgres {
+			continue
+		}
+
+		claims := capacityClaims(pod, container)
+		if len(claims) == 0 || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		mounts := make([]string, len(claims))
+		for i := range claims {
+			mounts[i] = claims[i].Mount
+		}
+
+		stdout, stderr, err := podexec.Bash(exec(pod, container),
+			"df --block-size=1 --output=target,used,size "+strings.Join(mounts, " "))
+		if err != nil {
+			return sample, fmt.Errorf("unable to measure the volumes of %s: %w: %s", pod.Name, err, stderr)
+		}
+		usage := parseDiskFree(stdout)
+		for _, claim := range claims {
+			if found, ok := usage[claim.Mount]; ok {
+				sample.Volumes[claim.Claim] = found
+			}
+		}
+
+		if labels[util.LabelRole] == util.RolePatroniLeader {
+			stdout, _, err := podexec.PSQL(exec(pod, container), "", capacitySQL)
+			if fields := strings.Fields(stdout); err == nil && len(fields) == 2 {
+				sample.DatabaseBytes, _ = strconv.ParseInt(fields[0], 10, 64)
+				sample.WALBytes, _ = strconv.ParseInt(fields[1], 10, 64)
+			}
+		}
+	}
+
+	if len(sample.Volumes) == 0 {
+		return sample, errors.New("no running Pods with volumes found")
+	}
+	return sample, nil
+}
+
+// pruneCapacitySamples returns the samples within retention of now, oldest
+// first.
+func pruneCapacitySamples(samples []capacitySample, retention time.Duration, now time.Time) []capacitySample {
+	kept := make([]capacitySample, 0, len(samples))
+	for _, sample := range samples {
+		if !sample.Time.Before(now.Add(-retention)) {
+			kept = append(kept, sample)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].Time.Before(kept[j].Time) })
+	return kept
+}
+
+// growthPerDay fits a line to the points and returns its slope in units per
+// day. It returns false when the points do not span any time.
+func growthPerDay(times []time.Time, values []int64) (float64, bool) {
+	if len(times) < 2 {
+		return 0, false
+	}
+
+	var meanX, meanY float64
+	for i := range times {
+		meanX += times[i].Sub(times[0]).Hours() / 24
+		meanY += float64(values[i])
+	}
+	meanX /= float64(len(times))
+	meanY /= float64(len(times))
+
+	var numerator, denominator float64
+	for i := range times {
+		dx := times[i].Sub(times[0]).Hours()/24 - meanX
+		numerator += dx * (float64(values[i]) - meanY)
+		denominator += dx * dx
+	}
+	if denominator == 0 {
+		return 0, false
+	}
+	return numerator / denominator, true
+}
+
+// capacityForecast is the outlook for one volume.
+type capacityForecast struct {
+	Claim  string
+	Latest volumeUsage
+
+	// Growth is in bytes per day. It is false when there are too few samples.
+	Growth   float64
+	HasTrend bool
+}
+
+// DaysUntilFull returns how many days of growth are left, or false when the
+// volume is not growing.
+func (forecast capacityForecast) DaysUntilFull() (int, bool) {
+	if !forecast.HasTrend || forecast.Growth <= 0 {
+		return 0, false
+	}
+	free := float64(forecast.Latest.Size - forecast.Latest.Used)
+	return int(math.Max(0, math.Floor(free/forecast.Growth))), true
+}
+
+// forecastCapacity returns a forecast for every volume in the latest of
+// samples, sorted by name.
+func forecastCapacity(samples []capacitySample) []capacityForecast {
+	if len(samples) == 0 {
+		return nil
+	}
+	latest := samples[len(samples)-1]
+
+	forecasts := make([]capacityForecast, 0, len(latest.Volumes))
+	for claim, usage := range latest.Volumes {
+		var times []time.Time
+		var used []int64
+		for _, sample := range samples {
+			if volume, ok := sample.Volumes[claim]; ok {
+				times = append(times, sample.Time)
+				used = append(used, volume.Used)
+			}
+		}
+
+		forecast := capacityForecast{Claim: claim, Latest: usage}
+		forecast.Growth, forecast.HasTrend = growthPerDay(times, used)
+		forecasts = append(forecasts, forecast)
+	}
+
+	sort.Slice(forecasts, func(i, j int) bool { return forecasts[i].Claim < forecasts[j].Claim })
+	return forecasts
+}
+
+// printCapacityForecast prints a table of the volumes in samples followed by
+// the size of the databases.
+func printCapacityForecast(cmd *cobra.Command, samples []capacitySample) error {
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "PVC\tMOUNT\tUSED\tSIZE\tGROWTH/DAY\tDAYS UNTIL FULL")
+
+	for _, forecast := range forecastCapacity(samples) {
+		growth, days := "-", "-"
+		if forecast.HasTrend && forecast.Growth > 0 {
+			growth = formatBytes(int64(forecast.Growth))
+		}
+		if n, ok := forecast.DaysUntilFull(); ok {
+			days = strconv.Itoa(n)
+		}
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n", forecast.Claim,
+			forecast.Latest.Mount, formatBytes(forecast.Latest.Used),
+			formatBytes(forecast.Latest.Size), growth, days)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	latest := samples[len(samples)-1]
+	if latest.DatabaseBytes > 0 {
+		var times []time.Time
+		var sizes []int64
+		for _, sample := range samples {
+			if sample.DatabaseBytes > 0 {
+				times = append(times, sample.Time)
+				sizes = append(sizes, sample.DatabaseBytes)
+			}
+		}
+		line := "\ndatabase size: " + formatBytes(latest.DatabaseBytes)
+		if growth, ok := growthPerDay(times, sizes); ok && growth > 0 {
+			line += " (" + formatBytes(int64(growth)) + "/day)"
+		}
+		cmd.Println(line)
+		cmd.Printf("WAL size: %s\n", formatBytes(latest.WALBytes))
+	} else {
+		cmd.Println()
+	}
+
+	cmd.Printf("samples: %d from %s to %s\n", len(samples),
+		samples[0].Time.Format(time.RFC3339), latest.Time.Format(time.RFC3339))
+	return nil
+}
+
+// capacitySamplesKey is the key of the samples in their ConfigMap or file.
+const capacitySamplesKey = "samples.json"
+
+// capacityStore keeps samples in File when it is set and otherwise in a
+// ConfigMap of Cluster.
+type capacityStore struct {
+	ConfigMaps interface {
+		Get(context.Context, string, metav1.GetOptions) (*corev1.ConfigMap, error)
+		Patch(context.Context, string, types.PatchType, []byte, metav1.PatchOptions,
+			...string) (*corev1.ConfigMap, error)
+	}
+	Config  *internal.Config
+	Cluster string
+	File    string
+}
+
+// Name returns the name of the ConfigMap of the store.
+func (store capacityStore) Name() string { return store.Cluster + "-capacity" }
+
+// Load returns the samples in the store. A store that does not exist yet has
+// no samples.
+func (store capacityStore) Load(ctx context.Context) ([]capacitySample, error) {
+	var data []byte
+	if store.File != "" {
+		// #nosec G304 -- We intentionally read the file supplied by the user.
+		b, err := os.ReadFile(store.File)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		data = b
+	} else {
+		configmap, err := store.ConfigMaps.Get(ctx, store.Name(), metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		if err == nil {
+			data = []byte(configmap.Data[capacitySamplesKey])
+		}
+	}
+
+	var samples []capacitySample
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &samples); err != nil {
+			return nil, fmt.Errorf("unable to read capacity samples: %w", err)
+		}
+	}
+	return samples, nil
+}
+
+// Save replaces the samples in the store.
+func (store capacityStore) Save(ctx context.Context, samples []capacitySample) error {
+	data, err := json.Marshal(samples)
+	if err != nil {
+		return err
+	}
+	if store.File != "" {
+		// #nosec G304 -- We intentionally write to the file supplied by the user.
+		return os.WriteFile(store.File, data, 0o600)
+	}
+
+	patch, err := json.Marshal(map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]any{
+			"name":   store.Name(),
+			"labels": map[string]any{util.LabelCluster: store.Cluster},
+		},
+		"data": map[string]any{capacitySamplesKey: string(data)},
+	})
+	if err != nil {
+		return err
+	}
+
+	// This command is the only writer, so take ownership of the samples.
+	force := true
+	_, err = store.ConfigMaps.Patch(ctx, store.Name(), types.ApplyPatchType, patch,
+		store.Config.Patch.PatchOptions(metav1.PatchOptions{Force: &force}))
+	return err
+}
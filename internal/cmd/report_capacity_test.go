@@ -0,0 +1,144 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestCapacityClaims(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{
+		Volumes: []corev1.Volume{
+			{Name: "postgres-data", VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "hippo-pgdata"},
+			}},
+			{Name: "postgres-wal", VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "hippo-pgwal"},
+			}},
+			{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		},
+		Containers: []corev1.Container{
+			{Name: "database", VolumeMounts: []corev1.VolumeMount{
+				{Name: "postgres-data", MountPath: "/pgdata"},
+				{Name: "postgres-wal", MountPath: "/pgwal"},
+				{Name: "tmp", MountPath: "/tmp"},
+			}},
+			{Name: "replication-cert-copy", VolumeMounts: []corev1.VolumeMount{
+				{Name: "postgres-data", MountPath: "/pgdata"},
+			}},
+		},
+	}}
+
+	assert.DeepEqual(t, capacityClaims(pod, "database"), []capacityMountedClaim{
+		{Claim: "hippo-pgdata", Mount: "/pgdata"},
+		{Claim: "hippo-pgwal", Mount: "/pgwal"},
+	})
+	assert.Equal(t, len(capacityClaims(pod, "missing")), 0)
+}
+
+func TestParseDiskFree(t *testing.T) {
+	usage := parseDiskFree(`Mounted on         Used        1B-blocks
+/pgdata      1073741824      5368709120
+/pgwal        104857600      1073741824
+`)
+	assert.DeepEqual(t, usage, map[string]volumeUsage{
+		"/pgdata": {Mount: "/pgdata", Used: 1073741824, Size: 5368709120},
+		"/pgwal":  {Mount: "/pgwal", Used: 104857600, Size: 1073741824},
+	})
+}
+
+func TestPruneCapacitySamples(t *testing.T) {
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	samples := pruneCapacitySamples([]capacitySample{
+		{Time: now.Add(-time.Hour)},
+		{Time: now.Add(-48 * time.Hour)},
+		{Time: now},
+		{Time: now.Add(-2 * time.Hour)},
+	}, 24*time.Hour, now)
+
+	assert.Equal(t, len(samples), 3)
+	assert.Equal(t, samples[0].Time, now.Add(-2*time.Hour))
+	assert.Equal(t, samples[2].Time, now)
+}
+
+func TestForecastCapacity(t *testing.T) {
+	day := 24 * time.Hour
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	sample := func(days int, data, wal int64) capacitySample {
+		return capacitySample{
+			Time: start.Add(time.Duration(days) * day),
+			Volumes: map[string]volumeUsage{
+				"pgdata": {Mount: "/pgdata", Used: data, Size: 1000},
+				"pgwal":  {Mount: "/pgwal", Used: wal, Size: 1000},
+			},
+		}
+	}
+
+	_, ok := growthPerDay([]time.Time{start}, []int64{1})
+	assert.Assert(t, !ok, "one sample has no trend")
+	_, ok = growthPerDay([]time.Time{start, start}, []int64{1, 2})
+	assert.Assert(t, !ok, "samples at one time have no trend")
+
+	forecasts := forecastCapacity([]capacitySample{
+		sample(0, 100, 500), sample(1, 110, 400), sample(2, 120, 500), sample(4, 140, 400),
+	})
+	assert.Equal(t, len(forecasts), 2)
+
+	assert.Equal(t, forecasts[0].Claim, "pgdata")
+	assert.Assert(t, forecasts[0].HasTrend)
+	assert.Assert(t, forecasts[0].Growth > 9.99 && forecasts[0].Growth < 10.01, forecasts[0].Growth)
+	days, ok := forecasts[0].DaysUntilFull()
+	assert.Assert(t, ok)
+	assert.Equal(t, days, 86)
+
+	assert.Equal(t, forecasts[1].Claim, "pgwal")
+	_, ok = forecasts[1].DaysUntilFull()
+	assert.Assert(t, !ok, "shrinking volumes do not fill")
+
+	t.Run("Print", func(t *testing.T) {
+		var out bytes.Buffer
+		cmd := &cobra.Command{}
+		cmd.SetOut(&out)
+
+		samples := []capacitySample{sample(0, 100, 500), sample(1, 110, 500)}
+		samples[1].DatabaseBytes, samples[1].WALBytes = 2048, 1024
+		assert.NilError(t, printCapacityForecast(cmd, samples))
+
+		assert.Assert(t, strings.Contains(out.String(), "pgdata  /pgdata  110B"), out.String())
+		assert.Assert(t, strings.Contains(out.String(), "10B         89\n"), out.String())
+		assert.Assert(t, strings.Contains(out.String(), "database size: 2.0KiB\n"), out.String())
+		assert.Assert(t, strings.Contains(out.String(),
+			"samples: 2 from 2024-05-01T00:00:00Z to 2024-05-02T00:00:00Z"), out.String())
+	})
+}
+
+func TestCapacityStoreFile(t *testing.T) {
+	ctx := context.Background()
+	store := capacityStore{File: filepath.Join(t.TempDir(), "samples.json")}
+
+	samples, err := store.Load(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, len(samples), 0)
+
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	assert.NilError(t, store.Save(ctx, []capacitySample{{
+		Time: now, Volumes: map[string]volumeUsage{"pgdata": {Mount: "/pgdata", Used: 1, Size: 2}},
+	}}))
+
+	samples, err = store.Load(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, len(samples), 1)
+	assert.Equal(t, samples[0].Time, now)
+	assert.Equal(t, samples[0].Volumes["pgdata"].Used, int64(1))
+}
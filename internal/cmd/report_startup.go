@@ -0,0 +1,318 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newReportStartupCommand returns the startup subcommand of the report
+// command. It shows how long each phase of the last start of every instance
+// took.
+func newReportStartupCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "startup CLUSTER_NAME",
+		Short: "Report how long the last start of each instance took",
+		Long: `Report how long each phase of the last start of every instance of a
+PostgresCluster took. This helps find out why a failover or restart was slow.
+
+The phases are reconstructed from Pod status, Events, and the Patroni log of the
+database container:
+  - scheduling: from Pod creation until it is assigned a node
+  - image pull: from the first "Pulling" Event until the last "Pulled" Event
+  - init containers: from the first init container start until the last one stops
+  - pgBackRest restore (Job): the most recent restore Job of the cluster
+  - Patroni bootstrap: initializing a new cluster
+  - pgBackRest restore/delta: creating a replica from a backup or the leader
+  - recovery replay: from when Postgres starts until the Pod is ready
+
+Kubernetes keeps Events for one hour by default, so image pull times may be
+missing for instances that started earlier.
+
+### RBAC Requirements
+    Resources   Verbs
+    ---------   -----
+    events      [list]
+    jobs.batch  [list]
+    pods        [list]
+    pods/log    [get]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Report on the last start of the 'hippo' postgrescluster
+pgo report startup hippo
+
+### Example output
+POD hippo-instance1-8x7m-0 (ready after 2m47s)
+PHASE                     START   DURATION
+scheduling                +0s     1s
+image pull                +2s     38s
+init containers           +41s    4s
+pgBackRest restore/delta  +49s    1m31s
+recovery replay           +2m20s  27s`)
+
+	// Limit the number of args, that is, only one cluster name
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		clientset, err := kubernetes.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.DBInstanceLabels(args[0]),
+		})
+		if err != nil {
+			return err
+		}
+		if len(pods.Items) == 0 {
+			return fmt.Errorf("no instance Pods found for cluster %s", args[0])
+		}
+		sort.Slice(pods.Items, func(i, j int) bool {
+			return pods.Items[i].Name < pods.Items[j].Name
+		})
+
+		events, err := clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+			FieldSelector: "involvedObject.kind=Pod",
+		})
+		if err != nil {
+			return err
+		}
+
+		jobs, err := clientset.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.LabelCluster + "=" + args[0] + "," + util.LabelPGBackRestRestore,
+		})
+		if err != nil {
+			return err
+		}
+		restore := latestRestoreJobPhase(jobs.Items)
+
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+
+			// The Patroni log is needed for the middle phases only; report
+			// the others when it cannot be read.
+			logs, err := clientset.CoreV1().Pods(namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+				Container:  util.ContainerDatabase,
+				Timestamps: true,
+			}).DoRaw(ctx)
+			if err != nil {
+				cmd.PrintErrf("WARNING: unable to read the log of %s: %v\n", pod.Name, err)
+			}
+
+			phases := podStartupPhases(pod, events.Items, string(logs))
+			if restore != nil && restore.End.After(pod.CreationTimestamp.Add(-restoreJobWindow)) {
+				phases = append(phases, *restore)
+			}
+			sort.SliceStable(phases, func(i, j int) bool {
+				return phases[i].Start.Before(phases[j].Start)
+			})
+
+			if i > 0 {
+				cmd.Println()
+			}
+			printStartupPhases(cmd, pod, phases)
+		}
+
+		return nil
+	}
+
+	return cmd
+}
+
+// restoreJobWindow is how long before an instance Pod was created a restore Job
+// can finish and still be considered part of the start of that Pod. PGO creates
+// instance Pods again right after an in-place restore finishes.
+const restoreJobWindow = 10 * time.Minute
+
+// startupPhase is one step in the start of an instance.
+type startupPhase struct {
+	Name       string
+	Start, End time.Time
+}
+
+// latestRestoreJobPhase returns the run of the most recently started restore
+// Job that finished, or nil when there is none.
+func latestRestoreJobPhase(jobs []batchv1.Job) *startupPhase {
+	var latest *startupPhase
+	for _, job := range jobs {
+		if job.Status.StartTime == nil || job.Status.CompletionTime == nil {
+			continue
+		}
+		if latest == nil || job.Status.StartTime.After(latest.Start) {
+			latest = &startupPhase{
+				Name:  "pgBackRest restore (Job)",
+				Start: job.Status.StartTime.Time,
+				End:   job.Status.CompletionTime.Time,
+			}
+		}
+	}
+	return latest
+}
+
+// timestampedLine is one line of a container log requested with timestamps.
+type timestampedLine struct {
+	Time    time.Time
+	Message string
+}
+
+// parseTimestampedLog reads a container log in which every line begins with an
+// RFC 3339 timestamp. Lines without one are skipped.
+func parseTimestampedLog(logs string) []timestampedLine {
+	var lines []timestampedLine
+	for _, line := range strings.Split(logs, "\n") {
+		stamp, message, _ := strings.Cut(line, " ")
+		if t, err := time.Parse(time.RFC3339Nano, stamp); err == nil {
+			lines = append(lines, timestampedLine{Time: t, Message: message})
+		}
+	}
+	return lines
+}
+
+// firstLogTime returns the time of the first line that contains substring.
+func firstLogTime(lines []timestampedLine, substring string) (time.Time, bool) {
+	for _, line := range lines {
+		if strings.Contains(line.Message, substring) {
+			return line.Time, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// podStartupPhases reconstructs the phases of the current start of pod from its
+// status, its events, and the timestamped log of its database container.
+// Phases that did not happen or left no trace are omitted.
+func podStartupPhases(pod *corev1.Pod, events []corev1.Event, logs string) []startupPhase {
+	var phases []startupPhase
+	add := func(name string, start, end time.Time) {
+		if !start.IsZero() && !end.IsZero() && !end.Before(start) {
+			phases = append(phases, startupPhase{Name: name, Start: start, End: end})
+		}
+	}
+
+	conditionTime := func(kind corev1.PodConditionType) time.Time {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == kind && condition.Status == corev1.ConditionTrue {
+				return condition.LastTransitionTime.Time
+			}
+		}
+		return time.Time{}
+	}
+	ready := conditionTime(corev1.PodReady)
+
+	add("scheduling", pod.CreationTimestamp.Time, conditionTime(corev1.PodScheduled))
+
+	var pullStart, pullEnd time.Time
+	for _, event := range events {
+		if event.InvolvedObject.Name != pod.Name || event.InvolvedObject.UID != pod.UID {
+			continue
+		}
+		switch event.Reason {
+		case "Pulling":
+			if first := event.FirstTimestamp.Time; pullStart.IsZero() || first.Before(pullStart) {
+				pullStart = first
+			}
+		case "Pulled":
+			if last := event.LastTimestamp.Time; last.After(pullEnd) {
+				pullEnd = last
+			}
+		}
+	}
+	add("image pull", pullStart, pullEnd)
+
+	var initStart, initEnd time.Time
+	for _, status := range pod.Status.InitContainerStatuses {
+		if terminated := status.State.Terminated; terminated != nil {
+			if initStart.IsZero() || terminated.StartedAt.Time.Before(initStart) {
+				initStart = terminated.StartedAt.Time
+			}
+			if terminated.FinishedAt.Time.After(initEnd) {
+				initEnd = terminated.FinishedAt.Time
+			}
+		}
+	}
+	add("init containers", initStart, initEnd)
+
+	lines := parseTimestampedLog(logs)
+	postgresStart := time.Time{}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == util.ContainerDatabase && status.State.Running != nil {
+			postgresStart = status.State.Running.StartedAt.Time
+		}
+	}
+
+	if start, ok := firstLogTime(lines, "trying to bootstrap a new cluster"); ok {
+		end, _ := firstLogTime(lines, "initialized a new cluster")
+		add("Patroni bootstrap", start, end)
+		if end.After(postgresStart) {
+			postgresStart = end
+		}
+	}
+	if start, ok := firstLogTime(lines, "trying to bootstrap from"); ok {
+		end, _ := firstLogTime(lines, "replica has been created using")
+		add("pgBackRest restore/delta", start, end)
+		if end.After(postgresStart) {
+			postgresStart = end
+		}
+	}
+	if start, ok := firstLogTime(lines, "postmaster pid="); ok && start.After(postgresStart) {
+		postgresStart = start
+	}
+	add("recovery replay", postgresStart, ready)
+
+	return phases
+}
+
+// printStartupPhases prints phases relative to the creation of pod.
+func printStartupPhases(cmd *cobra.Command, pod *corev1.Pod, phases []startupPhase) {
+	created := pod.CreationTimestamp.Time
+
+	status := "not ready"
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+			status = "ready after " + condition.LastTransitionTime.Sub(created).Round(time.Second).String()
+		}
+	}
+	cmd.Printf("POD %s (%s)\n", pod.Name, status)
+
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "PHASE\tSTART\tDURATION")
+	for _, phase := range phases {
+		offset := phase.Start.Sub(created).Round(time.Second)
+		sign := "+"
+		if offset < 0 {
+			sign = ""
+		}
+		_, _ = fmt.Fprintf(writer, "%s\t%s%s\t%s\n", phase.Name, sign, offset,
+			phase.End.Sub(phase.Start).Round(time.Second))
+	}
+	_ = writer.Flush()
+}
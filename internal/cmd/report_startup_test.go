@@ -0,0 +1,117 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseTimestampedLog(t *testing.T) {
+	lines := parseTimestampedLog("" +
+		"2025-01-02T03:04:05.123456789Z 2025-01-02 03:04:05,123 INFO: Lock owner: None\n" +
+		"not a timestamp\n" +
+		"2025-01-02T03:04:06Z done\n")
+
+	assert.Equal(t, len(lines), 2)
+	assert.Equal(t, lines[0].Time, time.Date(2025, 1, 2, 3, 4, 5, 123456789, time.UTC))
+	assert.Equal(t, lines[0].Message, "2025-01-02 03:04:05,123 INFO: Lock owner: None")
+	assert.Equal(t, lines[1].Message, "done")
+
+	at, ok := firstLogTime(lines, "Lock owner")
+	assert.Assert(t, ok)
+	assert.Equal(t, at, lines[0].Time)
+
+	_, ok = firstLogTime(lines, "missing")
+	assert.Assert(t, !ok)
+}
+
+func TestLatestRestoreJobPhase(t *testing.T) {
+	at := func(minute int) *metav1.Time {
+		t := metav1.NewTime(time.Date(2025, 1, 2, 3, minute, 0, 0, time.UTC))
+		return &t
+	}
+
+	assert.Assert(t, latestRestoreJobPhase(nil) == nil)
+
+	phase := latestRestoreJobPhase([]batchv1.Job{
+		{Status: batchv1.JobStatus{StartTime: at(1), CompletionTime: at(5)}},
+		{Status: batchv1.JobStatus{StartTime: at(20), CompletionTime: at(22)}},
+		{Status: batchv1.JobStatus{StartTime: at(30)}},
+	})
+	assert.Assert(t, phase != nil)
+	assert.Equal(t, phase.Start, at(20).Time)
+	assert.Equal(t, phase.End, at(22).Time)
+}
+
+func TestPodStartupPhases(t *testing.T) {
+	created := time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC)
+	at := func(seconds int) metav1.Time {
+		return metav1.NewTime(created.Add(time.Duration(seconds) * time.Second))
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "hippo-instance1-8x7m-0", UID: "uid1", CreationTimestamp: at(0),
+		},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodScheduled, Status: corev1.ConditionTrue, LastTransitionTime: at(1)},
+				{Type: corev1.PodReady, Status: corev1.ConditionTrue, LastTransitionTime: at(167)},
+			},
+			InitContainerStatuses: []corev1.ContainerStatus{
+				{Name: "postgres-startup", State: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{StartedAt: at(41), FinishedAt: at(43)},
+				}},
+				{Name: "nss-wrapper-init", State: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{StartedAt: at(44), FinishedAt: at(45)},
+				}},
+			},
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "database", State: corev1.ContainerState{
+					Running: &corev1.ContainerStateRunning{StartedAt: at(46)},
+				}},
+			},
+		},
+	}
+
+	involved := corev1.ObjectReference{Kind: "Pod", Name: pod.Name, UID: pod.UID}
+	events := []corev1.Event{
+		{InvolvedObject: involved, Reason: "Pulling", FirstTimestamp: at(2), LastTimestamp: at(2)},
+		{InvolvedObject: involved, Reason: "Pulled", FirstTimestamp: at(30), LastTimestamp: at(40)},
+		{InvolvedObject: corev1.ObjectReference{Name: pod.Name, UID: "old"},
+			Reason: "Pulling", FirstTimestamp: at(-600)},
+	}
+
+	logs := "" +
+		at(48).UTC().Format(time.RFC3339Nano) + " INFO: trying to bootstrap from leader 'hippo-instance1-pwr2-0'\n" +
+		at(139).UTC().Format(time.RFC3339Nano) + " INFO: replica has been created using pgbackrest\n" +
+		at(140).UTC().Format(time.RFC3339Nano) + " INFO: postmaster pid=97\n"
+
+	phases := podStartupPhases(pod, events, logs)
+	assert.DeepEqual(t, phases, []startupPhase{
+		{Name: "scheduling", Start: at(0).Time, End: at(1).Time},
+		{Name: "image pull", Start: at(2).Time, End: at(40).Time},
+		{Name: "init containers", Start: at(41).Time, End: at(45).Time},
+		{Name: "pgBackRest restore/delta", Start: at(48).Time, End: at(139).Time},
+		{Name: "recovery replay", Start: at(140).Time, End: at(167).Time},
+	})
+
+	t.Run("NotReady", func(t *testing.T) {
+		pod := pod.DeepCopy()
+		pod.Status.Conditions = pod.Status.Conditions[:1]
+
+		phases := podStartupPhases(pod, nil, "")
+		assert.DeepEqual(t, phases, []startupPhase{
+			{Name: "scheduling", Start: at(0).Time, End: at(1).Time},
+			{Name: "init containers", Start: at(41).Time, End: at(45).Time},
+		})
+	})
+}
@@ -0,0 +1,377 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newReportVersionsCommand returns the versions subcommand of the report
+// command. It compares the versions that clusters run to the latest ones.
+func newReportVersionsCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "versions",
+		Short: "Report PostgresClusters that run outdated versions",
+		Long: `Report the Postgres, pgBackRest, and pgBouncer versions that the Pods of the
+PostgresClusters in a namespace or, with "--all-namespaces", in every namespace
+are running. Each version is read from the tag of its image and compared to
+the latest tag of the same image in its registry:
+  - current: no newer version was found
+  - outdated: a newer version exists; for Postgres, of the same major version
+  - EOL: the major version of Postgres is no longer supported by the
+    PostgreSQL Global Development Group
+  - unknown: the tag does not have a version, such as a digest
+
+When the registry cannot be read, or with "--matrix", the latest versions come
+from a compatibility matrix built into this release of the CLI instead. Use
+"--mirror" the same way as "pgo check images" when nodes pull through a mirror.
+
+### RBAC Requirements
+    Resources  Verbs
+    ---------  -----
+    pods       [list]
+
+    Note: "--all-namespaces" requires these permissions in every namespace.
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Report the versions of clusters in every namespace
+pgo report versions --all-namespaces
+
+# Report only what needs updating, without reading registries
+pgo report versions -A --outdated --matrix
+
+### Example output
+NAMESPACE  CLUSTER  COMPONENT   VERSION  LATEST  SOURCE    STATUS
+postgres   hippo    postgres    16.3     16.10   registry  outdated
+postgres   hippo    pgbackrest  2.56.0   2.56.0  registry  current
+postgres   rhino    postgres    13.14    13.22   registry  EOL
+
+2 of 3 components are outdated or EOL`)
+
+	var allNamespaces, outdatedOnly, matrixOnly bool
+	var mirrors []string
+	cmd.Flags().BoolVarP(&allNamespaces, "all-namespaces", "A", false,
+		"report on clusters in every namespace")
+	cmd.Flags().BoolVar(&outdatedOnly, "outdated", false,
+		"list only components that are outdated or EOL")
+	cmd.Flags().BoolVar(&matrixOnly, "matrix", false,
+		"use only the built-in compatibility matrix, not registries")
+	cmd.Flags().StringArrayVar(&mirrors, "mirror", nil,
+		"a registry mirror as SOURCE=MIRROR; can be used multiple times")
+
+	cmd.Args = cobra.NoArgs
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rewrites, err := parseImageMirrors(mirrors)
+		if err != nil {
+			return err
+		}
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		clientset, err := kubernetes.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace := corev1.NamespaceAll
+		if !allNamespaces {
+			if namespace, err = config.Namespace(); err != nil {
+				return err
+			}
+		}
+
+		pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.LabelCluster,
+		})
+		if err != nil {
+			return err
+		}
+		running := runningVersions(pods.Items)
+		if len(running) == 0 {
+			cmd.Println("No PostgresClusters found")
+			return nil
+		}
+
+		// Look up each repository and version once.
+		finder := latestVersionFinder{Now: time.Now()}
+		if !matrixOnly {
+			finder.Registry = &registryClient{Client: &http.Client{Timeout: 30 * time.Second}}
+		}
+		latest := map[string]componentVersion{}
+		for i, version := range running {
+			key := version.Component + " " + imageRepository(version.Image) + " " + version.Version
+			if _, ok := latest[key]; !ok {
+				latest[key] = finder.Latest(ctx, version, rewriteImage(version.Image, rewrites))
+			}
+			found := latest[key]
+			running[i].Latest, running[i].Source, running[i].Status = found.Latest, found.Source, found.Status
+		}
+
+		return printComponentVersions(cmd, running, outdatedOnly)
+	}
+
+	return cmd
+}
+
+// Statuses of a [componentVersion].
+const (
+	versionCurrent  = "current"
+	versionOutdated = "outdated"
+	versionEOL      = "EOL"
+	versionUnknown  = "unknown"
+)
+
+// componentVersion is the version of one component of a cluster and how it
+// compares to the latest one.
+type componentVersion struct {
+	Namespace, Cluster string
+	Component, Image   string
+	Version            string
+
+	Latest, Source, Status string
+}
+
+// versionComponents are the components reported, by the name of the
+// container that runs them.
+var versionComponents = map[string]string{
+	util.ContainerDatabase:   "postgres",
+	util.ContainerPGBackrest: "pgbackrest",
+	util.ContainerPGBouncer:  "pgbouncer",
+}
+
+// runningVersions returns each distinct image that the pods of each cluster
+// run, sorted by namespace, cluster, and component.
+func runningVersions(pods []corev1.Pod) []componentVersion {
+	seen := map[componentVersion]bool{}
+	var versions []componentVersion
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			component, ok := versionComponents[container.Name]
+			if !ok {
+				continue
+			}
+			version := componentVersion{
+				Namespace: pod.Namespace, Cluster: pod.Labels[util.LabelCluster],
+				Component: component, Image: container.Image,
+				Version: imageTagVersion(container.Image).Version,
+			}
+			if !seen[version] {
+				seen[version] = true
+				versions = append(versions, version)
+			}
+		}
+	}
+
+	order := map[string]int{"postgres": 0, "pgbackrest": 1, "pgbouncer": 2}
+	sort.Slice(versions, func(i, j int) bool {
+		a, b := versions[i], versions[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Cluster != b.Cluster {
+			return a.Cluster < b.Cluster
+		}
+		if a.Component != b.Component {
+			return order[a.Component] < order[b.Component]
+		}
+		return compareVersions(a.Version, b.Version) < 0
+	})
+	return versions
+}
+
+// imageTag is the tag of an image split around its version. The tags of
+// Crunchy Data images are like "ubi8-16.3-1": a base, a version, and a build.
+type imageTag struct {
+	Prefix  string
+	Version string
+}
+
+// versionPattern matches a dotted version number.
+var versionPattern = regexp.MustCompile(`^\d+(\.\d+)+$`)
+
+// imageTagVersion returns the tag of image split around its first dotted
+// version number, or an empty Version when it has none.
+func imageTagVersion(image string) imageTag {
+	ref, err := parseImageReference(image)
+	if err != nil || strings.Contains(ref.Reference, ":") {
+		return imageTag{}
+	}
+	return splitImageTag(ref.Reference)
+}
+
+// splitImageTag splits tag around its first dotted version number.
+func splitImageTag(tag string) imageTag {
+	fields := strings.Split(tag, "-")
+	for i, field := range fields {
+		if versionPattern.MatchString(field) {
+			return imageTag{Prefix: strings.Join(fields[:i], "-"), Version: field}
+		}
+	}
+	return imageTag{}
+}
+
+// imageRepository returns image without its tag or digest.
+func imageRepository(image string) string {
+	name, _, _ := strings.Cut(image, "@")
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+	return name
+}
+
+// compareVersions returns -1, 0, or 1 when a is older than, the same as, or
+// newer than b. Missing parts are zero.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionMatrix is the latest release of each component when this matrix was
+// last updated, in August 2025. Postgres is keyed by its major version.
+var versionMatrix = map[string]string{
+	"pgbackrest":  "2.56.0",
+	"pgbouncer":   "1.24.1",
+	"postgres-13": "13.22",
+	"postgres-14": "14.19",
+	"postgres-15": "15.14",
+	"postgres-16": "16.10",
+	"postgres-17": "17.6",
+}
+
+// postgresEOL is when each major version of Postgres stopped or stops getting
+// fixes. Older major versions are no longer supported.
+// - https://www.postgresql.org/support/versioning/
+var postgresEOL = map[int]time.Time{
+	13: time.Date(2025, time.November, 13, 0, 0, 0, 0, time.UTC),
+	14: time.Date(2026, time.November, 12, 0, 0, 0, 0, time.UTC),
+	15: time.Date(2027, time.November, 11, 0, 0, 0, 0, time.UTC),
+	16: time.Date(2028, time.November, 9, 0, 0, 0, 0, time.UTC),
+	17: time.Date(2029, time.November, 8, 0, 0, 0, 0, time.UTC),
+}
+
+// latestVersionFinder finds the latest version of a component in its registry
+// or in [versionMatrix].
+type latestVersionFinder struct {
+	Now time.Time
+
+	// Registry is nil to use only the matrix.
+	Registry interface {
+		Tags(ctx context.Context, image string) ([]string, error)
+	}
+}
+
+// Latest returns the Latest, Source, and Status of version. The tags of image
+// are read, which may be a mirror of the image of version.
+func (finder latestVersionFinder) Latest(ctx context.Context, version componentVersion, image string) componentVersion {
+	result := componentVersion{Status: versionUnknown}
+	if version.Version == "" {
+		return result
+	}
+
+	key := version.Component
+	major, _ := strconv.Atoi(strings.Split(version.Version, ".")[0])
+	if version.Component == "postgres" {
+		key = fmt.Sprintf("postgres-%d", major)
+	}
+
+	if finder.Registry != nil {
+		// Any error falls back to the matrix.
+		if tags, err := finder.Registry.Tags(ctx, image); err == nil {
+			prefix := imageTagVersion(version.Image).Prefix
+			for _, tag := range tags {
+				found := splitImageTag(tag)
+				if found.Version == "" || found.Prefix != prefix ||
+					(version.Component == "postgres" && !strings.HasPrefix(found.Version, strconv.Itoa(major)+".")) {
+					continue
+				}
+				if compareVersions(found.Version, result.Latest) > 0 {
+					result.Latest, result.Source = found.Version, "registry"
+				}
+			}
+		}
+	}
+	if result.Latest == "" && versionMatrix[key] != "" {
+		result.Latest, result.Source = versionMatrix[key], "matrix"
+	}
+
+	eol, supported := postgresEOL[major]
+	switch {
+	case version.Component == "postgres" && major < 13,
+		version.Component == "postgres" && supported && !finder.Now.Before(eol):
+		result.Status = versionEOL
+	case result.Latest == "":
+	case compareVersions(version.Version, result.Latest) < 0:
+		result.Status = versionOutdated
+	default:
+		result.Status = versionCurrent
+	}
+	return result
+}
+
+// printComponentVersions prints versions as a table followed by how many are
+// outdated or EOL. With outdatedOnly, current versions are not listed.
+func printComponentVersions(cmd *cobra.Command, versions []componentVersion, outdatedOnly bool) error {
+	var behind int
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "NAMESPACE\tCLUSTER\tCOMPONENT\tVERSION\tLATEST\tSOURCE\tSTATUS")
+	for _, version := range versions {
+		if version.Status == versionOutdated || version.Status == versionEOL {
+			behind++
+		} else if outdatedOnly {
+			continue
+		}
+		dash := func(value string) string {
+			if value == "" {
+				return "-"
+			}
+			return value
+		}
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			version.Namespace, version.Cluster, version.Component, dash(version.Version),
+			dash(version.Latest), dash(version.Source), version.Status)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	cmd.Printf("\n%d of %d components are outdated or EOL\n", behind, len(versions))
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+func TestImageTagVersion(t *testing.T) {
+	for image, expected := range map[string]imageTag{
+		"registry.developers.crunchydata.com/crunchydata/crunchy-postgres:ubi8-16.3-1":         {"ubi8", "16.3"},
+		"registry.developers.crunchydata.com/crunchydata/crunchy-postgres-gis:ubi8-16.3-3.4-0": {"ubi8", "16.3"},
+		"registry.developers.crunchydata.com/crunchydata/crunchy-pgbackrest:ubi9-2.54.2-2520":  {"ubi9", "2.54.2"},
+		"postgres:17.2":      {"", "17.2"},
+		"postgres:latest":    {},
+		"postgres@sha256:ab": {},
+	} {
+		assert.Equal(t, imageTagVersion(image), expected, image)
+	}
+
+	assert.Equal(t, imageRepository("localhost:5000/crunchy-postgres:ubi8-16.3-1"), "localhost:5000/crunchy-postgres")
+	assert.Equal(t, imageRepository("localhost:5000/crunchy-postgres@sha256:ab"), "localhost:5000/crunchy-postgres")
+}
+
+func TestCompareVersions(t *testing.T) {
+	assert.Equal(t, compareVersions("16.3", "16.10"), -1)
+	assert.Equal(t, compareVersions("2.54.2", "2.54"), 1)
+	assert.Equal(t, compareVersions("2.54.0", "2.54"), 0)
+}
+
+func TestRunningVersions(t *testing.T) {
+	pod := func(namespace, cluster string, containers ...corev1.Container) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace,
+				Labels: map[string]string{util.LabelCluster: cluster}},
+			Spec: corev1.PodSpec{Containers: containers},
+		}
+	}
+	pg, backrest := "crunchy-postgres:ubi8-16.3-1", "crunchy-pgbackrest:ubi8-2.51-1"
+
+	versions := runningVersions([]corev1.Pod{
+		pod("zoo", "rhino", corev1.Container{Name: "database", Image: pg}),
+		pod("postgres", "hippo",
+			corev1.Container{Name: "pgbackrest", Image: backrest},
+			corev1.Container{Name: "database", Image: pg},
+			corev1.Container{Name: "replication-cert-copy", Image: pg}),
+		pod("postgres", "hippo",
+			corev1.Container{Name: "pgbackrest", Image: backrest},
+			corev1.Container{Name: "database", Image: pg}),
+	})
+	assert.DeepEqual(t, versions, []componentVersion{
+		{Namespace: "postgres", Cluster: "hippo", Component: "postgres", Image: pg, Version: "16.3"},
+		{Namespace: "postgres", Cluster: "hippo", Component: "pgbackrest", Image: backrest, Version: "2.51"},
+		{Namespace: "zoo", Cluster: "rhino", Component: "postgres", Image: pg, Version: "16.3"},
+	})
+}
+
+type fakeTags map[string][]string
+
+func (tags fakeTags) Tags(_ context.Context, image string) ([]string, error) {
+	if found, ok := tags[image]; ok {
+		return found, nil
+	}
+	return nil, errors.New("unreachable")
+}
+
+func TestLatestVersionFinder(t *testing.T) {
+	ctx := context.Background()
+	finder := latestVersionFinder{
+		Now: time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC),
+		Registry: fakeTags{"pg:ubi8-16.3-1": {
+			"ubi8-16.3-1", "ubi8-16.9-2", "ubi9-16.11-1", "ubi8-17.6-1", "latest",
+		}},
+	}
+
+	latest := finder.Latest(ctx, componentVersion{Component: "postgres", Image: "pg:ubi8-16.3-1", Version: "16.3"}, "pg:ubi8-16.3-1")
+	assert.DeepEqual(t, latest, componentVersion{Latest: "16.9", Source: "registry", Status: versionOutdated})
+
+	// The registry cannot be read; the matrix is used instead.
+	latest = finder.Latest(ctx, componentVersion{Component: "postgres", Image: "other:ubi8-17.6-1", Version: "17.6"}, "other:ubi8-17.6-1")
+	assert.DeepEqual(t, latest, componentVersion{Latest: versionMatrix["postgres-17"], Source: "matrix", Status: versionCurrent})
+
+	latest = finder.Latest(ctx, componentVersion{Component: "postgres", Image: "other:ubi8-13.22-1", Version: "13.22"}, "other:ubi8-13.22-1")
+	assert.Equal(t, latest.Status, versionEOL)
+	latest = finder.Latest(ctx, componentVersion{Component: "postgres", Image: "other:ubi8-11.9-1", Version: "11.9"}, "other:ubi8-11.9-1")
+	assert.DeepEqual(t, latest, componentVersion{Status: versionEOL})
+
+	latest = finder.Latest(ctx, componentVersion{Component: "pgbouncer", Image: "bouncer@sha256:ab"}, "bouncer@sha256:ab")
+	assert.DeepEqual(t, latest, componentVersion{Status: versionUnknown})
+}
+
+func TestPrintComponentVersions(t *testing.T) {
+	versions := []componentVersion{
+		{Namespace: "postgres", Cluster: "hippo", Component: "postgres", Version: "16.3",
+			Latest: "16.10", Source: "registry", Status: versionOutdated},
+		{Namespace: "postgres", Cluster: "hippo", Component: "pgbouncer", Status: versionUnknown},
+	}
+
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+	assert.NilError(t, printComponentVersions(cmd, versions, true))
+	assert.Equal(t, out.String(), `
+NAMESPACE  CLUSTER  COMPONENT  VERSION  LATEST  SOURCE    STATUS
+postgres   hippo    postgres   16.3     16.10   registry  outdated
+
+1 of 2 components are outdated or EOL
+`[1:])
+}
@@ -0,0 +1,603 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// restartAnnotation is the annotation of spec.metadata that PGO copies to
+// every instance Pod. Changing it makes PGO restart the instances one at a
+// time, replicas first.
+const restartAnnotation = "restarted"
+
+// newRestartCommand returns the restart subcommand of the PGO plugin. It
+// restarts the instances of one or many clusters, a few clusters at a time,
+// or only some instances of one cluster.
+func newRestartCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restart [CLUSTER_NAME]",
+		Short: "Restart the instances of PostgresClusters",
+		Long: `Restart every instance of a PostgresCluster, or of every PostgresCluster chosen
+by "--selector". PGO restarts the instances of each cluster one at a time,
+replicas first, when the "restarted" annotation of spec.metadata changes.
+
+Clusters restart in batches of "--max-parallel". A cluster is done when every
+instance has restarted and is ready, and every replica is streaming within
+"--max-replica-lag-mb" of the primary. The next batch starts when every
+cluster of the batch is done. The restart halts when a Pod is failing, such as
+in CrashLoopBackOff, or when a cluster is not done within "--timeout"; later
+batches are not restarted.
+Overwriting the annotation set by others may require the --force-conflicts flag.
+
+With "--instance-set", only the instances of that set restart, one at a time,
+when the "restarted" annotation of its metadata changes. With "--pod", Patroni
+restarts each named instance in place with "patronictl restart", replicas
+first. Patroni does not switch over first, so writes stop while the primary
+restarts. A name can be the end of an instance Pod name when that is unique.
+
+Restarts wait for instances to be done as above. With "--wait=false", the
+command returns once every restart is initiated; every cluster must then fit in
+one batch.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    pods                                                [list]
+    pods/exec                                           [create]
+    postgresclusters.postgres-operator.crunchydata.com  [get list patch]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Restart the 'hippo' postgrescluster
+pgo restart hippo
+
+# Restart every postgrescluster of the team, two at a time
+pgo restart --selector=team=payments --max-parallel=2
+
+# Restart the 'analytics' instance set without waiting for it
+pgo restart hippo --instance-set=analytics --wait=false
+
+# Restart two instances of the 'hippo' postgrescluster
+pgo restart hippo --pod=2d4n --pod=8x7m
+
+### Example output
+batch 1 of 2: hippo, rhino
+postgresclusters/hippo restart initiated
+postgresclusters/rhino restart initiated
+hippo: restarted and ready
+rhino: restarted and ready
+batch 2 of 2: zebra
+postgresclusters/zebra restart initiated
+zebra: restarted and ready
+restarted 3 postgresclusters`)
+
+	var selector, instanceSet string
+	var podNames []string
+	var forceConflicts bool
+	wait := true
+	orchestrator := restartOrchestrator{Interval: 5 * time.Second}
+	var maxLag int64
+	cmd.Flags().StringVarP(&selector, "selector", "l", "",
+		"restart every postgrescluster matching this label selector")
+	cmd.Flags().IntVar(&orchestrator.MaxParallel, "max-parallel", 1,
+		"how many postgresclusters restart at the same time")
+	cmd.Flags().DurationVar(&orchestrator.Timeout, "timeout", 15*time.Minute,
+		"how long a batch can take before the restart halts")
+	cmd.Flags().Int64Var(&maxLag, "max-replica-lag-mb", 16,
+		"megabytes a replica can be behind the primary for its cluster to be done")
+	cmd.Flags().BoolVar(&forceConflicts, "force-conflicts", false,
+		"take ownership and overwrite the restarted annotation")
+	cmd.Flags().StringVar(&instanceSet, "instance-set", "",
+		"restart only the instances of this instance set")
+	cmd.Flags().StringSliceVar(&podNames, "pod", nil,
+		"restart only this instance Pod; may be repeated")
+	cmd.Flags().BoolVar(&wait, "wait", wait,
+		"wait until restarted instances are ready and replicas have caught up")
+	cmd.MarkFlagsMutuallyExclusive("instance-set", "pod")
+
+	cmd.Args = cobra.MaximumNArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		if (len(args) == 0) == (selector == "") {
+			return errors.New("specify either a CLUSTER_NAME or --selector")
+		}
+		if orchestrator.MaxParallel < 1 {
+			return errors.New("--max-parallel must be at least 1")
+		}
+		if selector != "" && (instanceSet != "" || len(podNames) > 0) {
+			return errors.New("--instance-set and --pod need a CLUSTER_NAME rather than --selector")
+		}
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		mapping, clusterClient, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+
+		clusters := args
+		if selector != "" {
+			list, err := clusterClient.Namespace(namespace).List(ctx,
+				metav1.ListOptions{LabelSelector: selector})
+			if err != nil {
+				return err
+			}
+			clusters = nil
+			for _, item := range list.Items {
+				clusters = append(clusters, item.GetName())
+			}
+			if len(clusters) == 0 {
+				return fmt.Errorf("no postgresclusters match %q", selector)
+			}
+		}
+
+		if !wait && len(clusters) > orchestrator.MaxParallel {
+			return fmt.Errorf("--wait=false restarts every postgrescluster at once; set --max-parallel to %d",
+				len(clusters))
+		}
+
+		// Choose the instances before asking, so a bad name asks nothing.
+		var members []string
+		if len(podNames) > 0 {
+			pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+				LabelSelector: util.DBInstanceLabels(args[0]),
+			})
+			if err != nil {
+				return err
+			}
+			var primary string
+			if members, primary, err = resolveRestartPods(pods.Items, podNames); err != nil {
+				return err
+			}
+			if primary != "" {
+				cmd.PrintErrf("WARNING: %s is the primary; writes stop while it restarts.\n", primary)
+			}
+		}
+
+		warning := config.Messages.Sprintf("restart.warn", len(clusters), orchestrator.MaxParallel)
+		switch {
+		case len(members) > 0:
+			warning = config.Messages.Sprintf("restart.warn-pods", len(members), args[0])
+		case instanceSet != "":
+			warning = config.Messages.Sprintf("restart.warn-set", instanceSet, args[0])
+		}
+		_, _ = fmt.Fprint(config.ErrOut, warning+config.Messages.Sprintf("confirm.continue"))
+		var confirmed *bool
+		for i := 0; confirmed == nil && i < 10; i++ {
+			// retry 10 times or until a confirmation is given or denied,
+			// whichever comes first
+			confirmed = config.Messages.Confirm(config.In, config.ErrOut)
+		}
+		if confirmed == nil || !*confirmed {
+			return ErrCancelled
+		}
+
+		restarted := time.Now().UTC().Format(time.RFC3339)
+		orchestrator.Restart = func(ctx context.Context, cluster string) error {
+			err := requestClusterRestart(ctx, config, clusterClient.Namespace(namespace),
+				cluster, instanceSet, restarted, forceConflicts)
+			if err == nil {
+				cmd.Printf("%s/%s restart initiated\n", mapping.Resource.Resource, cluster)
+			}
+			if apierrors.IsConflict(err) {
+				cmd.PrintErrln("SUGGESTION: The --force-conflicts flag may help in performing this operation.")
+			}
+			return err
+		}
+		orchestrator.Progress = func(ctx context.Context, cluster string) (bool, string, error) {
+			object, err := clusterClient.Namespace(namespace).Get(ctx, cluster, metav1.GetOptions{})
+			if err != nil {
+				return false, "", err
+			}
+			selector, expected := util.DBInstanceLabels(cluster), clusterInstanceCount(object)
+			if instanceSet != "" {
+				selector = util.InstanceSetLabels(cluster, instanceSet)
+				expected = int(setReplicas(instanceSets(object)[instanceSet]))
+			}
+			pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+			if err != nil {
+				return false, "", err
+			}
+			return restartProgress(expected, pods.Items, restarted)
+		}
+		orchestrator.Gate = func(ctx context.Context, cluster string) error {
+			pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+				LabelSelector: util.DBInstanceLabels(cluster),
+			})
+			if err != nil {
+				return err
+			}
+			var primary string
+			for _, pod := range pods.Items {
+				if pod.GetLabels()[util.LabelRole] == util.RolePatroniLeader {
+					primary = pod.GetName()
+				}
+			}
+			if primary == "" {
+				return errors.New("no primary")
+			}
+			exec := podexec.Container(podExec, namespace, primary, util.ContainerDatabase)
+			stdout, stderr, err := podexec.Patronictl(exec, "list", "json")
+			return replicationGate(checkReplication(pods.Items, stdout, commandError(err, stderr), maxLag))
+		}
+
+		// Patroni restarts members in place, one at a time.
+		items := clusters
+		if len(members) > 0 {
+			items, orchestrator.MaxParallel, orchestrator.Kind = members, 1, "instances"
+			orchestrator.Restart = func(ctx context.Context, member string) error {
+				exec := podexec.Container(podExec, namespace, member, util.ContainerDatabase)
+				stdout, stderr, err := podexec.Patronictl(exec,
+					fmt.Sprintf("restart --force %s-ha %s", args[0], member), "")
+				if err != nil {
+					return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr+stdout))
+				}
+				cmd.Printf("pods/%s restart initiated\n", member)
+				return nil
+			}
+			orchestrator.Progress = func(ctx context.Context, member string) (bool, string, error) {
+				pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+					LabelSelector: util.DBInstanceLabels(args[0]),
+				})
+				if err != nil {
+					return false, "", err
+				}
+				return memberRestartProgress(pods.Items, member)
+			}
+			gate := orchestrator.Gate
+			orchestrator.Gate = func(ctx context.Context, _ string) error { return gate(ctx, args[0]) }
+		}
+		if !wait {
+			orchestrator.Progress = nil
+		}
+
+		return orchestrator.Run(ctx, cmd.OutOrStdout(), cmd.ErrOrStderr(), items)
+	}
+
+	return cmd
+}
+
+// requestClusterRestart sets the restart annotation of spec.metadata of the
+// cluster called name to value. When instanceSet is not empty, it sets the
+// annotation in the metadata of that instance set instead.
+func requestClusterRestart(
+	ctx context.Context, config *internal.Config, client dynamic.ResourceInterface,
+	name, instanceSet, value string, forceConflicts bool,
+) error {
+	cluster, err := client.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	intent := new(unstructured.Unstructured)
+	if err := internal.ExtractFieldsInto(cluster, intent, config.Patch.FieldManager); err != nil {
+		return err
+	}
+	if err := setRestartAnnotation(cluster, intent, instanceSet, value); err != nil {
+		return err
+	}
+
+	patch, err := intent.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	patchOptions := metav1.PatchOptions{}
+	if forceConflicts {
+		b := true
+		patchOptions.Force = &b
+	}
+	_, err = client.Patch(ctx, name, types.ApplyPatchType, patch, config.Patch.PatchOptions(patchOptions))
+	return err
+}
+
+// setRestartAnnotation sets the restart annotation in intent to value, in
+// spec.metadata or in the metadata of instanceSet when that is not empty.
+func setRestartAnnotation(cluster, intent *unstructured.Unstructured, instanceSet, value string) error {
+	if instanceSet == "" {
+		return unstructured.SetNestedField(intent.Object, value,
+			"spec", "metadata", "annotations", restartAnnotation)
+	}
+	if instanceSets(cluster)[instanceSet] == nil {
+		return fmt.Errorf("instance set %q not found", instanceSet)
+	}
+
+	// Instance sets are a list keyed by name. Keep any other fields and sets
+	// this field manager already owns.
+	owned, _, _ := unstructured.NestedSlice(intent.Object, "spec", "instances")
+	instance := map[string]any{"name": instanceSet}
+	instances := make([]any, 0, len(owned)+1)
+	for _, item := range owned {
+		if item, ok := item.(map[string]any); ok && item["name"] == instanceSet {
+			instance = item
+			continue
+		}
+		instances = append(instances, item)
+	}
+	if err := unstructured.SetNestedField(instance, value,
+		"metadata", "annotations", restartAnnotation); err != nil {
+		return err
+	}
+	instances = append(instances, instance)
+
+	return unstructured.SetNestedSlice(intent.Object, instances, "spec", "instances")
+}
+
+// clusterInstanceCount returns the number of instances in the spec of cluster.
+func clusterInstanceCount(cluster *unstructured.Unstructured) int {
+	sets, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "instances")
+	var count int
+	for _, set := range sets {
+		replicas, found, _ := unstructured.NestedInt64(set.(map[string]any), "replicas")
+		if !found {
+			replicas = 1
+		}
+		count += int(replicas)
+	}
+	return count
+}
+
+// failingPodReasons are the waiting reasons of containers that do not recover
+// without help.
+var failingPodReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"CreateContainerConfigError": true,
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+}
+
+// restartProgress returns whether all expected instance Pods of a cluster have
+// the restart annotation value and are ready. It returns an error when one
+// of pods is failing.
+func restartProgress(expected int, pods []corev1.Pod, value string) (bool, string, error) {
+	var ready int
+	for i := range pods {
+		pod := &pods[i]
+		if err := podFailing(pod); err != nil {
+			return false, "", err
+		}
+		if pod.GetAnnotations()[restartAnnotation] == value && podIsReady(pod) {
+			ready++
+		}
+	}
+	detail := fmt.Sprintf("%d of %d instances restarted and ready", ready, expected)
+	return ready == expected && len(pods) == expected, detail, nil
+}
+
+// memberRestartProgress returns whether the Pod called member, which Patroni
+// restarts in place, is ready. It returns an error when the Pod is failing.
+func memberRestartProgress(pods []corev1.Pod, member string) (bool, string, error) {
+	for i := range pods {
+		if pod := &pods[i]; pod.GetName() == member {
+			if err := podFailing(pod); err != nil {
+				return false, "", err
+			}
+			if podIsReady(pod) {
+				return true, "ready", nil
+			}
+			return false, "not ready", nil
+		}
+	}
+	return false, "pod not found", nil
+}
+
+// podFailing returns an error when pod has failed or one of its containers is
+// failing in a way it does not recover from.
+func podFailing(pod *corev1.Pod) error {
+	if pod.Status.Phase == corev1.PodFailed {
+		return fmt.Errorf("pod %s failed: %s", pod.GetName(), pod.Status.Reason)
+	}
+	for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		if waiting := status.State.Waiting; waiting != nil && failingPodReasons[waiting.Reason] {
+			return fmt.Errorf("pod %s is failing: container %s is in %s",
+				pod.GetName(), status.Name, waiting.Reason)
+		}
+	}
+	return nil
+}
+
+// resolveRestartPods returns the names of the instance Pods that targets
+// name, replicas first, and which of them is the primary, if any. A target
+// is the name of a Pod or the end of the name of only one.
+func resolveRestartPods(pods []corev1.Pod, targets []string) ([]string, string, error) {
+	chosen := map[string]bool{}
+	for _, target := range targets {
+		var matches []string
+		for _, pod := range pods {
+			name := pod.GetName()
+			if name == target || name == target+"-0" {
+				matches = []string{name}
+				break
+			}
+			if strings.HasSuffix(name, "-"+target) || strings.HasSuffix(name, "-"+target+"-0") {
+				matches = append(matches, name)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			return nil, "", fmt.Errorf("no instance Pod matches %q", target)
+		case 1:
+			chosen[matches[0]] = true
+		default:
+			return nil, "", fmt.Errorf("%q matches more than one instance Pod: %s",
+				target, strings.Join(matches, ", "))
+		}
+	}
+
+	var names []string
+	var primary string
+	for _, pod := range pods {
+		switch {
+		case !chosen[pod.GetName()]:
+		case pod.GetLabels()[util.LabelRole] == util.RolePatroniLeader:
+			primary = pod.GetName()
+		default:
+			names = append(names, pod.GetName())
+		}
+	}
+	if primary != "" {
+		names = append(names, primary)
+	}
+	return names, primary, nil
+}
+
+// replicationGate returns an error describing the checks that failed, if any.
+func replicationGate(checks []readinessCheck) error {
+	var failed []string
+	for _, check := range checks {
+		if !check.Healthy {
+			failed = append(failed, check.Detail)
+		}
+	}
+	if len(failed) > 0 {
+		return errors.New(strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// restartOrchestrator restarts clusters in batches and stops at the first
+// cluster that does not come back.
+type restartOrchestrator struct {
+	MaxParallel       int
+	Timeout, Interval time.Duration
+
+	// Kind is what Run restarts, in the plural. It is postgresclusters when
+	// empty.
+	Kind string
+
+	// Restart asks PGO to restart the instances of a cluster.
+	Restart func(ctx context.Context, cluster string) error
+
+	// Progress returns whether the instances of a cluster have restarted and
+	// are ready, how far along they are, or an error when one is failing.
+	// When it is nil, Run does not wait for restarts.
+	Progress func(ctx context.Context, cluster string) (bool, string, error)
+
+	// Gate returns an error while a restarted cluster is not healthy enough
+	// for the next batch, such as while replicas catch up.
+	Gate func(ctx context.Context, cluster string) error
+}
+
+// Run restarts clusters and reports the result to out. Batches and the state
+// of each cluster go to progress.
+func (o restartOrchestrator) Run(ctx context.Context, out, progress io.Writer, clusters []string) error {
+	batches := (len(clusters) + o.MaxParallel - 1) / o.MaxParallel
+
+	for start, number := 0, 1; start < len(clusters); start, number = start+o.MaxParallel, number+1 {
+		batch := clusters[start:min(start+o.MaxParallel, len(clusters))]
+		halt := func(err error) error {
+			if rest := clusters[start+len(batch):]; len(rest) > 0 {
+				fmt.Fprintf(progress, "halted; not restarted: %s\n", strings.Join(rest, ", "))
+			}
+			return err
+		}
+
+		fmt.Fprintf(progress, "batch %d of %d: %s\n", number, batches, strings.Join(batch, ", "))
+		for _, cluster := range batch {
+			if err := o.Restart(ctx, cluster); err != nil {
+				return halt(fmt.Errorf("%s: %w", cluster, err))
+			}
+		}
+		if o.Progress == nil {
+			continue
+		}
+
+		// Poll every cluster of the batch until each is done or one fails.
+		deadline := time.Now().Add(o.Timeout)
+		pending := batch
+		var failed error
+		for len(pending) > 0 {
+			var waiting []string
+			details := map[string]string{}
+			for _, cluster := range pending {
+				done, detail, err := o.Progress(ctx, cluster)
+				if err == nil && done {
+					if err := o.Gate(ctx, cluster); err != nil {
+						done, detail = false, err.Error()
+					}
+				}
+				switch {
+				case err != nil:
+					fmt.Fprintf(progress, "%s: %v\n", cluster, err)
+					if failed == nil {
+						failed = fmt.Errorf("%s: %w", cluster, err)
+					}
+				case done:
+					fmt.Fprintf(progress, "%s: restarted and ready\n", cluster)
+				default:
+					waiting = append(waiting, cluster)
+					details[cluster] = detail
+				}
+			}
+			if failed != nil || len(waiting) == 0 {
+				break
+			}
+			if !time.Now().Before(deadline) {
+				for _, cluster := range waiting {
+					fmt.Fprintf(progress, "%s: not ready after %s: %s\n", cluster, o.Timeout, details[cluster])
+				}
+				failed = fmt.Errorf("%s did not return to ready within %s", strings.Join(waiting, ", "), o.Timeout)
+				break
+			}
+
+			pending = waiting
+			select {
+			case <-ctx.Done():
+				return halt(ctx.Err())
+			case <-time.After(o.Interval):
+			}
+		}
+		if failed != nil {
+			return halt(failed)
+		}
+	}
+
+	kind := o.Kind
+	if kind == "" {
+		kind = "postgresclusters"
+	}
+	if o.Progress == nil {
+		_, err := fmt.Fprintf(out, "initiated the restart of %d %s\n", len(clusters), kind)
+		return err
+	}
+	_, err := fmt.Fprintf(out, "restarted %d %s\n", len(clusters), kind)
+	return err
+}
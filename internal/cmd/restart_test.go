@@ -0,0 +1,257 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+func TestClusterInstanceCount(t *testing.T) {
+	cluster := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{"instances": []any{
+			map[string]any{"name": "one"},
+			map[string]any{"name": "two", "replicas": int64(2)},
+		}},
+	}}
+	assert.Equal(t, clusterInstanceCount(cluster), 3)
+}
+
+func TestRestartProgress(t *testing.T) {
+	pod := func(name, restarted string, ready bool) corev1.Pod {
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Annotations: map[string]string{restartAnnotation: restarted},
+			},
+			Status: corev1.PodStatus{Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: status},
+			}},
+		}
+	}
+
+	t.Run("Restarting", func(t *testing.T) {
+		done, detail, err := restartProgress(2, []corev1.Pod{
+			pod("a", "new", true), pod("b", "old", true),
+		}, "new")
+		assert.NilError(t, err)
+		assert.Assert(t, !done)
+		assert.Equal(t, detail, "1 of 2 instances restarted and ready")
+	})
+
+	t.Run("NotReady", func(t *testing.T) {
+		done, _, err := restartProgress(1, []corev1.Pod{pod("a", "new", false)}, "new")
+		assert.NilError(t, err)
+		assert.Assert(t, !done)
+	})
+
+	t.Run("Done", func(t *testing.T) {
+		done, detail, err := restartProgress(2, []corev1.Pod{
+			pod("a", "new", true), pod("b", "new", true),
+		}, "new")
+		assert.NilError(t, err)
+		assert.Assert(t, done)
+		assert.Equal(t, detail, "2 of 2 instances restarted and ready")
+	})
+
+	t.Run("Failing", func(t *testing.T) {
+		failing := pod("b", "new", false)
+		failing.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			Name: "database",
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+				Reason: "CrashLoopBackOff",
+			}},
+		}}
+		_, _, err := restartProgress(2, []corev1.Pod{pod("a", "new", true), failing}, "new")
+		assert.ErrorContains(t, err, "pod b is failing: container database is in CrashLoopBackOff")
+	})
+}
+
+func TestRestartOrchestrator(t *testing.T) {
+	ctx := context.Background()
+
+	// polls counts the calls to Progress per cluster.
+	polls := map[string]int{}
+	var restarted []string
+	orchestrator := restartOrchestrator{
+		MaxParallel: 2,
+		Timeout:     time.Minute,
+		Restart: func(_ context.Context, cluster string) error {
+			restarted = append(restarted, cluster)
+			return nil
+		},
+		Progress: func(_ context.Context, cluster string) (bool, string, error) {
+			polls[cluster]++
+			return polls[cluster] > 1, "restarting", nil
+		},
+		Gate: func(context.Context, string) error { return nil },
+	}
+
+	t.Run("Batches", func(t *testing.T) {
+		var out, progress strings.Builder
+		assert.NilError(t, orchestrator.Run(ctx, &out, &progress, []string{"a", "b", "c"}))
+		assert.DeepEqual(t, restarted, []string{"a", "b", "c"})
+		assert.Equal(t, out.String(), "restarted 3 postgresclusters\n")
+		assert.Equal(t, progress.String(), `
+batch 1 of 2: a, b
+a: restarted and ready
+b: restarted and ready
+batch 2 of 2: c
+c: restarted and ready
+`[1:])
+	})
+
+	t.Run("HaltOnFailure", func(t *testing.T) {
+		restarted = nil
+		failing := orchestrator
+		failing.Progress = func(_ context.Context, cluster string) (bool, string, error) {
+			if cluster == "b" {
+				return false, "", errors.New("pod b-0 is failing")
+			}
+			return true, "", nil
+		}
+
+		var out, progress strings.Builder
+		err := failing.Run(ctx, &out, &progress, []string{"a", "b", "c", "d"})
+		assert.ErrorContains(t, err, "b: pod b-0 is failing")
+		assert.DeepEqual(t, restarted, []string{"a", "b"})
+		assert.Equal(t, out.String(), "")
+		assert.Equal(t, progress.String(), `
+batch 1 of 2: a, b
+a: restarted and ready
+b: pod b-0 is failing
+halted; not restarted: c, d
+`[1:])
+	})
+
+	t.Run("GateTimeout", func(t *testing.T) {
+		restarted = nil
+		lagging := orchestrator
+		lagging.Timeout = 0
+		lagging.Progress = func(context.Context, string) (bool, string, error) { return true, "", nil }
+		lagging.Gate = func(context.Context, string) error { return errors.New("replica a-1 lags 40MiB") }
+
+		var out, progress strings.Builder
+		err := lagging.Run(ctx, &out, &progress, []string{"a", "b", "c"})
+		assert.ErrorContains(t, err, "a, b did not return to ready within 0s")
+		assert.Equal(t, out.String(), "")
+		assert.Equal(t, progress.String(), `
+batch 1 of 2: a, b
+a: not ready after 0s: replica a-1 lags 40MiB
+b: not ready after 0s: replica a-1 lags 40MiB
+halted; not restarted: c
+`[1:])
+	})
+
+	t.Run("NoWait", func(t *testing.T) {
+		restarted = nil
+		quick := orchestrator
+		quick.Progress = nil
+		quick.Kind = "instances"
+
+		var out, progress strings.Builder
+		assert.NilError(t, quick.Run(ctx, &out, &progress, []string{"a", "b", "c"}))
+		assert.DeepEqual(t, restarted, []string{"a", "b", "c"})
+		assert.Equal(t, out.String(), "initiated the restart of 3 instances\n")
+		assert.Equal(t, progress.String(), "batch 1 of 2: a, b\nbatch 2 of 2: c\n")
+	})
+}
+
+func TestSetRestartAnnotation(t *testing.T) {
+	cluster := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{"instances": []any{
+			map[string]any{"name": "one"},
+			map[string]any{"name": "two"},
+		}},
+	}}
+
+	t.Run("Cluster", func(t *testing.T) {
+		intent := &unstructured.Unstructured{Object: map[string]any{}}
+		assert.NilError(t, setRestartAnnotation(cluster, intent, "", "now"))
+		value, _, _ := unstructured.NestedString(intent.Object,
+			"spec", "metadata", "annotations", restartAnnotation)
+		assert.Equal(t, value, "now")
+	})
+
+	t.Run("InstanceSet", func(t *testing.T) {
+		intent := &unstructured.Unstructured{Object: map[string]any{
+			"spec": map[string]any{"instances": []any{
+				map[string]any{"name": "one", "replicas": int64(2)},
+				map[string]any{"name": "two", "replicas": int64(3)},
+			}},
+		}}
+		assert.NilError(t, setRestartAnnotation(cluster, intent, "two", "now"))
+		assert.DeepEqual(t, intent.Object["spec"], map[string]any{"instances": []any{
+			map[string]any{"name": "one", "replicas": int64(2)},
+			map[string]any{"name": "two", "replicas": int64(3),
+				"metadata": map[string]any{"annotations": map[string]any{restartAnnotation: "now"}}},
+		}})
+
+		err := setRestartAnnotation(cluster, intent, "three", "now")
+		assert.ErrorContains(t, err, `instance set "three" not found`)
+	})
+}
+
+func TestResolveRestartPods(t *testing.T) {
+	pod := func(name, role string) corev1.Pod {
+		return corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name: name, Labels: map[string]string{util.LabelRole: role},
+		}}
+	}
+	pods := []corev1.Pod{
+		pod("hippo-one-2d4n-0", util.RolePatroniLeader),
+		pod("hippo-one-8x7m-0", util.RolePatroniReplica),
+		pod("hippo-two-8x7m-0", util.RolePatroniReplica),
+		pod("hippo-two-q9z5-0", util.RolePatroniReplica),
+	}
+
+	names, primary, err := resolveRestartPods(pods, []string{"2d4n", "hippo-two-q9z5-0", "one-8x7m"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, names, []string{"hippo-one-8x7m-0", "hippo-two-q9z5-0", "hippo-one-2d4n-0"})
+	assert.Equal(t, primary, "hippo-one-2d4n-0")
+
+	_, _, err = resolveRestartPods(pods, []string{"8x7m"})
+	assert.ErrorContains(t, err, `"8x7m" matches more than one instance Pod`)
+
+	_, _, err = resolveRestartPods(pods, []string{"zzzz"})
+	assert.ErrorContains(t, err, `no instance Pod matches "zzzz"`)
+}
+
+func TestMemberRestartProgress(t *testing.T) {
+	ready := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "a"},
+		Status: corev1.PodStatus{Conditions: []corev1.PodCondition{
+			{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+		}},
+	}
+	done, _, err := memberRestartProgress([]corev1.Pod{ready}, "a")
+	assert.NilError(t, err)
+	assert.Assert(t, done)
+
+	done, detail, err := memberRestartProgress([]corev1.Pod{ready}, "b")
+	assert.NilError(t, err)
+	assert.Assert(t, !done)
+	assert.Equal(t, detail, "pod not found")
+
+	failed := ready
+	failed.Status.Phase = corev1.PodFailed
+	failed.Status.Reason = "Evicted"
+	_, _, err = memberRestartProgress([]corev1.Pod{failed}, "a")
+	assert.ErrorContains(t, err, "pod a failed: Evicted")
+}
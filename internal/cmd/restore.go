@@ -6,7 +6,9 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,7 +20,7 @@ import (
 
 	"github.com/crunchydata/postgres-operator-client/internal"
 	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
-	"github.com/crunchydata/postgres-operator-client/internal/util"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
 )
 
 func newRestoreCommand(config *internal.Config) *cobra.Command {
@@ -30,11 +32,26 @@ using the current "spec.backups.pgbackrest.restore" settings on the PostgreSQL
 cluster or by using flags to write your settings. Overwriting those settings
 may require the --force-conflicts flags.
 
+Use the "--target-time" flag to restore to a point in time, such as
+"2024-05-01 14:03 Europe/Berlin", "2024-05-01T12:03:00Z", or "45 minutes ago".
+Times without a zone are in the local time zone. The target is checked against
+the backups and archived WAL in the repository first; targets before the oldest
+backup or after the newest archived WAL are refused with the earliest or latest
+possible target.
+
+Use the "--wait" flag to watch the restore until it finishes. Use the
+"--detach" flag to print a token instead; "pgo attach" watches the restore
+later using that token.
+
 ### RBAC Requirements
     Resources                                           Verbs
     ---------                                           -----
+    pods                                                [list]
+    pods/exec                                           [create]
     postgresclusters.postgres-operator.crunchydata.com  [get patch]
-	
+
+    Note: Pod permissions are only needed with "--target-time".
+
 ### Usage`,
 	}
 
@@ -44,6 +61,9 @@ pgo restore hippo --repoName repo1
 # Restore the 'hippo' cluster to a specific point in time
 pgo restore hippo --repoName repo1 --options '--type=time --target="2021-06-09 14:15:11-04"'
 
+# Restore the 'hippo' cluster to 45 minutes ago
+pgo restore hippo --repoName repo1 --target-time="45 minutes ago"
+
 ### Example output
 WARNING: You are about to restore from pgBackRest with {options:[] repoName:repo1}
 WARNING: This action is destructive and PostgreSQL will be unavailable while its data is restored.
@@ -53,6 +73,12 @@ postgresclusters/hippo patched
 
 # Resolve ownership conflict
 pgo restore hippo --force-conflicts
+
+# Record the restore to review and apply later with "pgo apply"
+pgo restore hippo --repoName repo1 --record changes.yaml
+
+# Restore the 'hippo' cluster and watch until the restore finishes
+pgo restore hippo --repoName repo1 --wait
 `)
 
 	restore := pgBackRestRestore{Config: config}
@@ -63,8 +89,21 @@ pgo restore hippo --force-conflicts
 	cmd.Flags().StringVar(&restore.RepoName, "repoName", "",
 		"repository to restore from")
 
+	cmd.Flags().StringVar(&restore.TargetTime, "target-time", "",
+		`point in time to restore to, e.g. "2024-05-01 14:03 Europe/Berlin" or "45 minutes ago"`)
+
 	cmd.Flags().BoolVar(&restore.ForceConflicts, "force-conflicts", false, "take ownership and overwrite the restore settings")
 
+	cmd.Flags().BoolVar(&restore.Wait, "wait", false,
+		"watch the restore until it finishes")
+	cmd.Flags().BoolVar(&restore.Detach, "detach", false,
+		`print a token to watch the restore later with "pgo attach"`)
+	cmd.Flags().DurationVar(&restore.Heartbeat, "heartbeat", 30*time.Second,
+		`how often to print progress with "--wait"`)
+	cmd.MarkFlagsMutuallyExclusive("wait", "detach")
+
+	config.Record.AddFlags(cmd.Flags())
+
 	// Only one positional argument: the PostgresCluster name.
 	cmd.Args = cobra.ExactArgs(1)
 
@@ -100,6 +139,8 @@ This is recommended after your restore is complete. Running "pgo restore" will e
 
 	disable := pgBackRestRestoreDisable{Config: config}
 
+	config.Record.AddFlags(cmd.Flags())
+
 	// Only one positional argument: the PostgresCluster name.
 	cmd.Args = cobra.ExactArgs(1)
 
@@ -124,6 +165,14 @@ type pgBackRestRestore struct {
 	RepoName       string
 	ForceConflicts bool
 
+	// TargetTime is a point in time in a form [parseTargetTime] reads.
+	TargetTime string
+
+	// Wait and Detach control what happens after the restore is requested.
+	Wait      bool
+	Detach    bool
+	Heartbeat time.Duration
+
 	PostgresCluster string
 }
 
@@ -158,6 +207,16 @@ func (config pgBackRestRestore) Run(ctx context.Context) error {
 		return err
 	}
 
+	if config.TargetTime != "" {
+		repoName := config.RepoName
+		if repoName == "" {
+			repoName = details(cluster).repoName
+		}
+		if config.Options, err = config.targetOptions(repoName, time.Now()); err != nil {
+			return err
+		}
+	}
+
 	intent := new(unstructured.Unstructured)
 	if err := internal.ExtractFieldsInto(cluster, intent, config.Patch.FieldManager); err != nil {
 		return err
@@ -165,6 +224,21 @@ func (config pgBackRestRestore) Run(ctx context.Context) error {
 	if err := config.modifyIntent(intent, time.Now()); err != nil {
 		return err
 	}
+	if err := checkClusterChange(config.ErrOut, &config.Messages, cluster, intent); err != nil {
+		return err
+	}
+
+	// Save the change for later rather than sending it. Confirmation happens
+	// when the recording is applied.
+	if config.Record.Enabled() {
+		change := internal.NewRecordedChange(internal.RecordApply,
+			mapping.Resource, namespace, config.PostgresCluster, intent)
+		change.Force = config.ForceConflicts
+
+		msg, err := recordChange(config.Config, change)
+		_, _ = fmt.Fprint(config.Out, msg)
+		return err
+	}
 
 	patch, err := intent.MarshalJSON()
 	if err != nil {
@@ -186,20 +260,16 @@ func (config pgBackRestRestore) Run(ctx context.Context) error {
 		config.Patch.PatchOptions(patchOptions))
 	if err != nil {
 		if apierrors.IsConflict(err) {
-			_, _ = fmt.Fprintf(config.Out, "SUGGESTION: The --force-conflicts flag may help in performing this operation.\n")
+			_, _ = fmt.Fprintf(config.ErrOut, "SUGGESTION: The --force-conflicts flag may help in performing this operation.\n")
 		}
 		return err
 	}
 
-	_, _ = fmt.Fprintf(config.Out,
-		"WARNING: You are about to restore from pgBackRest with %+v\n"+
-			"WARNING: This action is destructive and PostgreSQL will be"+
-			" unavailable while its data is restored.\n\n"+
-			"Do you want to continue? (yes/no): ",
-		details(cluster))
+	_, _ = fmt.Fprint(config.ErrOut, config.Messages.Sprintf("restore.warn", details(cluster))+
+		config.Messages.Sprintf("confirm.do-continue"))
 
 	if confirmed := config.confirm(5); confirmed == nil || !*confirmed {
-		return nil
+		return ErrCancelled
 	}
 
 	patchOptions = metav1.PatchOptions{}
@@ -209,21 +279,90 @@ func (config pgBackRestRestore) Run(ctx context.Context) error {
 	}
 
 	// They agreed to continue. Send the patch again without dry-run.
-	_, err = client.Namespace(namespace).Patch(ctx,
+	cluster, err = client.Namespace(namespace).Patch(ctx,
 		config.PostgresCluster, types.ApplyPatchType, patch,
 		config.Patch.PatchOptions(patchOptions))
+	if err != nil {
+		return err
+	}
 
-	if err == nil {
-		_, _ = fmt.Fprintf(config.Out, "%s/%s patched\n",
-			mapping.Resource.Resource, config.PostgresCluster)
+	_, _ = fmt.Fprintf(config.Out, "%s/%s patched\n",
+		mapping.Resource.Resource, config.PostgresCluster)
+
+	if !config.Wait && !config.Detach {
+		return nil
 	}
 
-	return err
+	token := watchToken{
+		Operation: "restore",
+		Namespace: namespace,
+		Name:      config.PostgresCluster,
+		ID:        cluster.GetAnnotations()["postgres-operator.crunchydata.com/pgbackrest-restore"],
+	}
+	_, _ = fmt.Fprintf(config.Out, "To watch this restore, run: pgo attach %s\n", token)
+
+	if config.Detach {
+		return nil
+	}
+	return watchOperation(ctx, config.Out, client, token, config.Heartbeat)
+}
+
+// targetOptions returns Options with those that restore to TargetTime. It
+// fails when repoName cannot restore to that time.
+func (config pgBackRestRestore) targetOptions(repoName string, now time.Time) ([]string, error) {
+	target, err := parseTargetTime(config.TargetTime, now, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	options, err := restoreTargetOptions(config.Options, target)
+	if err != nil {
+		return nil, err
+	}
+	if !repoNamePattern.MatchString(repoName) {
+		return nil, errors.New("--target-time requires --repoName")
+	}
+
+	exec, err := getPrimaryExec(config.Config, []string{config.PostgresCluster})
+	if err != nil {
+		return nil, fmt.Errorf("unable to check --target-time against the backups: %w", err)
+	}
+	repoNum := strings.TrimPrefix(repoName, "repo")
+	stdout, stderr, err := podexec.PGBackRestInfo(exec, repoNum)
+	if err != nil {
+		return nil, commandError(err, stderr)
+	}
+	repoKey, _ := strconv.Atoi(repoNum)
+
+	// Find when the newest WAL segment was archived. Targets after it cannot
+	// be reached.
+	var walTime time.Time
+	dir, segment, err := newestArchivedWAL(stdout, repoKey)
+	if err != nil {
+		return nil, err
+	}
+	if segment != "" {
+		listing, stderr, err := podexec.Output(exec, nil,
+			"pgbackrest", "repo-ls", "--repo="+repoNum, "--output=json", dir)
+		if err != nil {
+			return nil, commandError(err, stderr)
+		}
+		if walTime, err = archivedWALTime(listing, segment); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkRestoreTarget(stdout, repoKey, target, walTime, now); err != nil {
+		return nil, err
+	}
+
+	_, _ = fmt.Fprintf(config.Out, "target time: %s (%s)\n",
+		target.UTC().Format(pgBackRestTimeLayout), target.Format("2006-01-02 15:04:05 MST"))
+	return options, nil
 }
 
 func (config pgBackRestRestore) confirm(attempts int) *bool {
 	for i := 0; i < attempts; i++ {
-		if confirmed := util.Confirm(config.In, config.Out); confirmed != nil {
+		if confirmed := config.Messages.Confirm(config.In, config.ErrOut); confirmed != nil {
 			return confirmed
 		}
 	}
@@ -299,6 +438,13 @@ func (config pgBackRestRestoreDisable) Run(ctx context.Context) error {
 		return err
 	}
 
+	if config.Record.Enabled() {
+		msg, err := recordChange(config.Config, internal.NewRecordedChange(
+			internal.RecordApply, mapping.Resource, namespace, config.PostgresCluster, intent))
+		_, _ = fmt.Fprint(config.Out, msg)
+		return err
+	}
+
 	patch, err := intent.MarshalJSON()
 
 	if err == nil {
@@ -0,0 +1,150 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/crunchydata/postgres-operator-client/internal/flags"
+)
+
+// pgBackRestTimeLayout is how a target time is passed to pgBackRest and then
+// to the recovery_target_time setting of Postgres.
+const pgBackRestTimeLayout = "2006-01-02 15:04:05-07"
+
+// parseTargetTime reads a point in time such as "2024-05-01 14:03 Europe/Berlin",
+// "2024-05-01T12:03:00Z", "2021-06-09 14:15:11-04", or "45 minutes ago". Times
+// without a zone are in local.
+func parseTargetTime(value string, now time.Time, local *time.Location) (time.Time, error) {
+	parsed, err := flags.ParseTime(value, now, local)
+	if errors.Is(err, flags.ErrUnreadableTime) {
+		return time.Time{}, fmt.Errorf(
+			`unable to read target time %q; use a form like "2024-05-01 14:03 Europe/Berlin" or "45 minutes ago"`,
+			strings.TrimSpace(value))
+	}
+	return parsed, err
+}
+
+// checkRestoreTarget returns an error when the output of "pgbackrest info
+// --output=json" shows that repoKey cannot restore to target: it is before the
+// oldest backup, after the newest archived WAL, or the repository has no
+// backups or WAL. The newest WAL was archived at walTime; a zero walTime means
+// the WAL reaches as far as the newest backup.
+func checkRestoreTarget(stdout string, repoKey int, target, walTime, now time.Time) error {
+	var stanzas []pgBackRestStanza
+	if err := json.Unmarshal([]byte(stdout), &stanzas); err != nil {
+		return fmt.Errorf("unable to read pgbackrest info: %w", err)
+	}
+
+	var stops []int64
+	var archived bool
+	for _, stanza := range stanzas {
+		for _, backup := range stanza.Backup {
+			if !backup.Error && backup.Database.RepoKey == repoKey {
+				stops = append(stops, backup.Timestamp.Stop)
+			}
+		}
+		for _, archive := range stanza.Archive {
+			if archive.Database.RepoKey == repoKey && archive.Max != "" {
+				archived = true
+			}
+		}
+	}
+	sort.Slice(stops, func(i, j int) bool { return stops[i] < stops[j] })
+
+	format := func(t time.Time) string { return t.UTC().Format(pgBackRestTimeLayout) }
+	switch {
+	case len(stops) == 0:
+		return fmt.Errorf("repo%d has no successful backups, so there is no point in time to restore", repoKey)
+	case !archived:
+		return fmt.Errorf("repo%d has no archived WAL, so there is no point in time to restore", repoKey)
+	case target.Before(time.Unix(stops[0], 0)):
+		return fmt.Errorf("target time %s is before the oldest backup in repo%d finished; "+
+			"the earliest target is %s", format(target), repoKey, format(time.Unix(stops[0], 0)))
+	case target.After(now):
+		return fmt.Errorf("target time %s is in the future; the latest target is now, %s",
+			format(target), format(now))
+	}
+
+	// A backup finishes only after the WAL it needs is archived, so the WAL
+	// reaches at least the end of the newest backup.
+	latest := time.Unix(stops[len(stops)-1], 0)
+	if walTime.After(latest) {
+		latest = walTime
+	}
+	if target.After(latest) {
+		return fmt.Errorf("target time %s is after the newest WAL archived to repo%d; "+
+			"the latest target is %s", format(target), repoKey, format(latest))
+	}
+	return nil
+}
+
+// newestArchivedWAL returns the directory in the repository and the name of
+// the newest WAL segment archived to repoKey, according to the output of
+// "pgbackrest info --output=json". Both are empty when there is none.
+func newestArchivedWAL(stdout string, repoKey int) (string, string, error) {
+	var stanzas []pgBackRestStanza
+	if err := json.Unmarshal([]byte(stdout), &stanzas); err != nil {
+		return "", "", fmt.Errorf("unable to read pgbackrest info: %w", err)
+	}
+
+	// Archives are listed oldest database first, and "max" is the newest
+	// segment of each. Segments are stored in directories named by their
+	// first 16 characters.
+	var dir, segment string
+	for _, stanza := range stanzas {
+		for _, archive := range stanza.Archive {
+			if archive.Database.RepoKey == repoKey && len(archive.Max) > 16 {
+				dir = path.Join("archive", stanza.Name, archive.ID, archive.Max[:16])
+				segment = archive.Max
+			}
+		}
+	}
+	return dir, segment, nil
+}
+
+// archivedWALTime returns when segment was archived, according to the output
+// of "pgbackrest repo-ls --output=json" for its directory.
+func archivedWALTime(stdout, segment string) (time.Time, error) {
+	var entries map[string]repoEntry
+	if err := json.Unmarshal([]byte(stdout), &entries); err != nil {
+		return time.Time{}, fmt.Errorf("unable to read pgbackrest repo-ls: %w", err)
+	}
+
+	// Archived segments are named with their checksum and compression,
+	// such as "000000010000000000000009-2b8c64e3a6a5f6c9.gz".
+	var newest int64
+	for name, entry := range entries {
+		if entry.Type == "file" && entry.Time != nil &&
+			strings.HasPrefix(name, segment) && *entry.Time > newest {
+			newest = *entry.Time
+		}
+	}
+	if newest == 0 {
+		return time.Time{}, fmt.Errorf("WAL segment %s not found in the repository", segment)
+	}
+	return time.Unix(newest, 0), nil
+}
+
+// restoreTargetOptions returns the options of "pgbackrest restore" that stop
+// recovery at target. It fails when options already choose a target.
+func restoreTargetOptions(options []string, target time.Time) ([]string, error) {
+	for _, option := range options {
+		for _, field := range strings.Fields(option) {
+			name, _, _ := strings.Cut(field, "=")
+			if name == "--type" || name == "--target" {
+				return nil, errors.New(`--target-time cannot be used with "--type" or "--target" in --options`)
+			}
+		}
+	}
+	return append(append([]string(nil), options...), "--type=time",
+		fmt.Sprintf("--target=%q", target.UTC().Format(pgBackRestTimeLayout))), nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseTargetTime(t *testing.T) {
+	now := time.Date(2024, 5, 1, 15, 0, 0, 0, time.UTC)
+	local := time.FixedZone("EST", -5*60*60)
+
+	for _, tt := range []struct {
+		value    string
+		expected time.Time
+	}{
+		{"2024-05-01 14:03 Europe/Berlin", time.Date(2024, 5, 1, 12, 3, 0, 0, time.UTC)},
+		{"2024-05-01 14:03:30.5 UTC", time.Date(2024, 5, 1, 14, 3, 30, 500000000, time.UTC)},
+		{"2024-05-01T12:03:00Z", time.Date(2024, 5, 1, 12, 3, 0, 0, time.UTC)},
+		{"2021-06-09 14:15:11-04", time.Date(2021, 6, 9, 18, 15, 11, 0, time.UTC)},
+		{"2024-05-01 09:00", time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC)},
+		{"45 minutes ago", now.Add(-45 * time.Minute)},
+		{"2h ago", now.Add(-2 * time.Hour)},
+		{"1.5 days ago", now.Add(-36 * time.Hour)},
+	} {
+		parsed, err := parseTargetTime(tt.value, now, local)
+		assert.NilError(t, err, "%q", tt.value)
+		assert.Assert(t, parsed.Equal(tt.expected), "%q: %v", tt.value, parsed)
+	}
+
+	_, err := parseTargetTime("2024-05-01 14:03 Mars/Olympus", now, local)
+	assert.ErrorContains(t, err, `unknown time zone "Mars/Olympus"`)
+
+	_, err = parseTargetTime("yesterday", now, local)
+	assert.ErrorContains(t, err, `unable to read target time "yesterday"`)
+}
+
+func TestCheckRestoreTarget(t *testing.T) {
+	now := time.Unix(1714560000, 0)
+	info := `[{"name":"db",
+"archive":[{"id":"15-1","min":"000000010000000000000002","max":"000000010000000000000009","database":{"repo-key":1}}],
+"backup":[
+{"label":"20240430-000000F","error":true,"database":{"repo-key":1},"timestamp":{"start":1714430000,"stop":1714435000}},
+{"label":"20240501-060001F","error":false,"database":{"repo-key":1},"timestamp":{"start":1714520000,"stop":1714520100}},
+{"label":"20240420-000000F","error":false,"database":{"repo-key":2},"timestamp":{"start":1713571200,"stop":1713571300}}
+]}]`
+
+	walTime := time.Unix(1714540000, 0)
+
+	assert.NilError(t, checkRestoreTarget(info, 1, time.Unix(1714530000, 0), walTime, now))
+
+	assert.Error(t, checkRestoreTarget(info, 1, time.Unix(1714520000, 0), walTime, now),
+		"target time 2024-04-30 23:33:20+00 is before the oldest backup in repo1 finished;"+
+			" the earliest target is 2024-04-30 23:35:00+00")
+	assert.ErrorContains(t, checkRestoreTarget(info, 1, now.Add(time.Minute), walTime, now),
+		"is in the future; the latest target is now, 2024-05-01 10:40:00+00")
+	assert.Error(t, checkRestoreTarget(info, 1, walTime.Add(time.Second), walTime, now),
+		"target time 2024-05-01 05:06:41+00 is after the newest WAL archived to repo1;"+
+			" the latest target is 2024-05-01 05:06:40+00")
+	assert.ErrorContains(t, checkRestoreTarget(info, 2, now, now, now), "repo2 has no archived WAL")
+	assert.ErrorContains(t, checkRestoreTarget(info, 3, now, now, now), "repo3 has no successful backups")
+	assert.ErrorContains(t, checkRestoreTarget("ERROR", 1, now, now, now), "unable to read")
+
+	t.Run("NoWALTime", func(t *testing.T) {
+		// The WAL reaches at least the end of the newest backup.
+		assert.NilError(t, checkRestoreTarget(info, 1, time.Unix(1714520100, 0), time.Time{}, now))
+		assert.ErrorContains(t, checkRestoreTarget(info, 1, time.Unix(1714520101, 0), time.Time{}, now),
+			"the latest target is 2024-04-30 23:35:00+00")
+	})
+}
+
+func TestNewestArchivedWAL(t *testing.T) {
+	info := `[{"name":"db","archive":[
+{"id":"13-1","max":"000000030000000200000011","database":{"repo-key":1}},
+{"id":"15-2","max":"000000010000000500000009","database":{"repo-key":1}},
+{"id":"15-2","max":"","database":{"repo-key":2}}
+]}]`
+
+	dir, segment, err := newestArchivedWAL(info, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, dir, "archive/db/15-2/0000000100000005")
+	assert.Equal(t, segment, "000000010000000500000009")
+
+	dir, segment, err = newestArchivedWAL(info, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, dir+segment, "")
+
+	_, _, err = newestArchivedWAL("ERROR", 1)
+	assert.ErrorContains(t, err, "unable to read")
+}
+
+func TestArchivedWALTime(t *testing.T) {
+	listing := `{".":{"type":"path"},
+"000000010000000500000008-5b1d6e0c2f4a9e8d.gz":{"type":"file","size":1234,"time":1714539000},
+"000000010000000500000009-2b8c64e3a6a5f6c9.gz":{"type":"file","size":5678,"time":1714540000}}`
+
+	archived, err := archivedWALTime(listing, "000000010000000500000009")
+	assert.NilError(t, err)
+	assert.Assert(t, archived.Equal(time.Unix(1714540000, 0)))
+
+	_, err = archivedWALTime(listing, "00000001000000050000000A")
+	assert.ErrorContains(t, err, "WAL segment 00000001000000050000000A not found")
+
+	_, err = archivedWALTime("ERROR", "000000010000000500000009")
+	assert.ErrorContains(t, err, "unable to read pgbackrest repo-ls")
+}
+
+func TestRestoreTargetOptions(t *testing.T) {
+	target := time.Date(2024, 5, 1, 14, 3, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	options, err := restoreTargetOptions([]string{"--target-action=promote"}, target)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, options, []string{
+		"--target-action=promote", "--type=time", `--target="2024-05-01 12:03:00+00"`})
+
+	_, err = restoreTargetOptions([]string{`--type=time --target="2024-05-01"`}, target)
+	assert.ErrorContains(t, err, "cannot be used with")
+}
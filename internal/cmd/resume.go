@@ -0,0 +1,113 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// newResumeCommand returns the resume subcommand of the PGO plugin. It undoes
+// the pause command.
+func newResumeCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resume",
+		Short: "Resume PGO activity on a PostgresCluster",
+		Long:  "Resume PGO activity on a PostgresCluster that was paused.",
+	}
+
+	cmd.AddCommand(
+		newResumeBackupScheduleCommand(config),
+		newResumeReconcileCommand(config),
+	)
+
+	return cmd
+}
+
+// newResumeBackupScheduleCommand returns the backupschedule subcommand of the
+// resume command. It puts back the schedules that "pgo pause backupschedule"
+// removed.
+func newResumeBackupScheduleCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backupschedule CLUSTER_NAME",
+		Short: "Start the paused backup schedules of a PostgresCluster again",
+		Long: `Resume backup schedules moves them from the annotation that "pgo pause
+backupschedule" set back to the spec of a PostgresCluster. PGO then creates
+their CronJobs again.
+
+Choose schedules with "--repoName" and "--type"; without them, every paused
+schedule is resumed.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    postgresclusters.postgres-operator.crunchydata.com  [get patch]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Resume every paused backup schedule of the 'hippo' postgrescluster
+pgo resume backupschedule hippo
+
+### Example output
+postgresclusters/hippo backup schedules resumed`)
+
+	var selector backupScheduleSelector
+	var forceConflicts bool
+	selector.AddFlags(cmd.Flags())
+	cmd.Flags().BoolVar(&forceConflicts, "force-conflicts", false,
+		"take ownership and overwrite the backup schedules")
+	config.Record.AddFlags(cmd.Flags())
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := selector.validate(); err != nil {
+			return err
+		}
+		return ensureClusterPart(cmd, config, args[0], selector.describe(), forceConflicts, selector.resume)
+	}
+
+	return cmd
+}
+
+// newResumeReconcileCommand returns the reconcile subcommand of the resume
+// command. It lets PGO reconcile a cluster again.
+func newResumeReconcileCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reconcile CLUSTER_NAME",
+		Short: "Let PGO reconcile a paused PostgresCluster again",
+		Long: `Resume reconciliation sets the spec.paused field to false. PGO then applies any
+changes made to the PostgresCluster while it was paused.
+The --force-conflicts flag may be required if the spec.paused field has been
+set by another client.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    postgresclusters.postgres-operator.crunchydata.com  [get patch]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Resume reconciliation of the 'hippo' postgrescluster
+pgo resume reconcile hippo
+
+### Example output
+postgresclusters/hippo reconciliation resumed after 47m`)
+
+	var forceConflicts bool
+	cmd.Flags().BoolVar(&forceConflicts, "force-conflicts", false, "take ownership and overwrite the paused setting")
+	config.Record.AddFlags(cmd.Flags())
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return applyClusterPaused(cmd, config, args[0], false, forceConflicts)
+	}
+
+	return cmd
+}
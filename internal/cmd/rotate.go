@@ -0,0 +1,346 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newRotateCommand returns the rotate command of the PGO plugin.
+func newRotateCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rotate",
+		Short: "Rotate credentials and keys of a PostgresCluster",
+		Long: `Rotate credentials and keys of a PostgresCluster.
+
+### Usage`,
+	}
+
+	cmd.AddCommand(newRotateRepoCipherCommand(config))
+
+	return cmd
+}
+
+// newRotateRepoCipherCommand returns the repo-cipher subcommand of the rotate
+// command. It encrypts an empty pgBackRest repository with a new passphrase
+// and fills it with a full backup.
+func newRotateRepoCipherCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "repo-cipher CLUSTER_NAME",
+		Short: "Encrypt a pgBackRest repository with a new passphrase",
+		Long: `Encrypt a pgBackRest repository of a PostgresCluster with a new passphrase.
+
+pgBackRest cannot change the cipher of a stanza, so the new passphrase goes to
+another repository that is defined but has no stanza, WAL, or backups yet.
+PGO creates the stanza of a repository soon after it is added, so add it while
+reconciliation is paused:
+  1. pgo pause reconcile CLUSTER_NAME
+  2. add the repository to spec.backups.pgbackrest.repos
+  3. pgo rotate repo-cipher CLUSTER_NAME --to=REPO --detach
+  4. pgo resume reconcile CLUSTER_NAME
+
+PGO then creates the stanza with the new passphrase and takes the backup.
+This command:
+  1. creates the Secret "CLUSTER_NAME-pgbackrest-cipher-REPO" with a random
+     "REPO-cipher-pass"
+  2. adds that Secret to spec.backups.pgbackrest.configuration and sets
+     "REPO-cipher-type" in spec.backups.pgbackrest.global
+  3. starts a full backup to the repository
+
+Use the "--wait" flag to watch the backup until it finishes. Use the
+"--detach" flag to print a token instead; "pgo attach" watches the backup
+later using that token. When the backup succeeds, the old repository can be
+removed from spec.backups.pgbackrest.repos.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    pods                                                [list]
+    pods/exec                                           [create]
+    postgresclusters.postgres-operator.crunchydata.com  [get patch]
+    secrets                                             [patch]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Encrypt repo2 of the 'hippo' postgrescluster with a new passphrase and watch the backup
+pgo rotate repo-cipher hippo --to=repo2 --wait
+
+### Example output
+WARNING: repo2 will be encrypted with a new passphrase and a full backup will be taken to it.
+Are you sure you want to continue? (yes/no): yes
+secrets/hippo-pgbackrest-cipher-repo2 applied
+postgresclusters/hippo patched
+To watch this backup, run: pgo attach eyJvcGVyYXRpb24iOiJiYWNrdXAiLC...
+[0s] backup of postgresclusters/hippo: starting
+[4m10s] backup of postgresclusters/hippo: succeeded
+When you have verified the backups in repo2, remove the old repository from spec.backups.pgbackrest.repos.`)
+
+	rotate := repoCipherRotation{Config: config}
+
+	cmd.Flags().StringVar(&rotate.Repo, "to", "",
+		"empty repository to encrypt with the new passphrase")
+	cobra.CheckErr(cmd.MarkFlagRequired("to"))
+	cmd.Flags().StringVar(&rotate.CipherType, "cipher-type", "aes-256-cbc",
+		"pgBackRest cipher type of the repository")
+	cmd.Flags().BoolVar(&rotate.ForceConflicts, "force-conflicts", false,
+		"take ownership and overwrite the pgBackRest settings")
+
+	cmd.Flags().BoolVar(&rotate.Wait, "wait", false,
+		"watch the backup until it finishes")
+	cmd.Flags().BoolVar(&rotate.Detach, "detach", false,
+		`print a token to watch the backup later with "pgo attach"`)
+	cmd.Flags().DurationVar(&rotate.Heartbeat, "heartbeat", 30*time.Second,
+		`how often to print progress with "--wait"`)
+	cmd.MarkFlagsMutuallyExclusive("wait", "detach")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		rotate.PostgresCluster = args[0]
+		return rotate.Run(context.Background(), cmd, args)
+	}
+
+	return cmd
+}
+
+type repoCipherRotation struct {
+	*internal.Config
+
+	Repo           string
+	CipherType     string
+	ForceConflicts bool
+
+	// Wait and Detach control what happens after the backup is requested.
+	Wait      bool
+	Detach    bool
+	Heartbeat time.Duration
+
+	PostgresCluster string
+}
+
+// SecretName returns the name of the Secret that holds the new passphrase.
+func (rotate repoCipherRotation) SecretName() string {
+	return rotate.PostgresCluster + "-pgbackrest-cipher-" + rotate.Repo
+}
+
+// newCipherPass returns a random passphrase for pgBackRest.
+func newCipherPass() (string, error) {
+	b := make([]byte, 48)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// cipherPassConfig returns a pgBackRest configuration file that sets the
+// passphrase of repo.
+func cipherPassConfig(repo, pass string) []byte {
+	return []byte("[global]\n" + repo + "-cipher-pass=" + pass + "\n")
+}
+
+// pgBackRestMissingStanza is the status code "pgbackrest info" reports for a
+// repository that has no stanza.
+// - https://pgbackrest.org/command.html#command-info
+const pgBackRestMissingStanza = 1
+
+// checkEmptyRepo returns an error when repo in stanzas has backups, archived
+// WAL, or a stanza. pgBackRest cannot change the cipher of any of those.
+func checkEmptyRepo(stanzas []pgBackRestStanza, repo string) error {
+	key, _ := strconv.Atoi(strings.TrimPrefix(repo, "repo"))
+
+	var backups int
+	var archived, stanzaCreated bool
+	for _, stanza := range stanzas {
+		for _, backup := range stanza.Backup {
+			if backup.Database.RepoKey == key {
+				backups++
+			}
+		}
+		for _, archive := range stanza.Archive {
+			archived = archived || (archive.Database.RepoKey == key && archive.Max != "")
+		}
+		for _, status := range stanza.Repo {
+			stanzaCreated = stanzaCreated || (status.Key == key && status.Status.Code != pgBackRestMissingStanza)
+		}
+	}
+
+	switch {
+	case backups > 0:
+		return fmt.Errorf("%s has %d backups; choose an empty repository with --to", repo, backups)
+	case archived:
+		return fmt.Errorf("%s has archived WAL; choose a repository without a stanza with --to", repo)
+	case stanzaCreated:
+		return fmt.Errorf("%s already has a stanza; choose a repository without a stanza with --to", repo)
+	}
+	return nil
+}
+
+// modifyIntent adds the Secret of rotate to the pgBackRest configuration of
+// intent and requests a full backup to the repository. The configuration is
+// an atomic list, so it starts from the one in current.
+func (rotate repoCipherRotation) modifyIntent(
+	intent, current *unstructured.Unstructured, now time.Time,
+) error {
+	path := []string{"spec", "backups", "pgbackrest", "configuration"}
+	configuration, _, _ := unstructured.NestedSlice(current.Object, path...)
+
+	var found bool
+	for _, name := range configurationSecretNames(current) {
+		found = found || name == rotate.SecretName()
+	}
+	if !found {
+		configuration = append(configuration, map[string]any{
+			"secret": map[string]any{"name": rotate.SecretName()},
+		})
+	}
+	if err := unstructured.SetNestedSlice(intent.Object, configuration, path...); err != nil {
+		return err
+	}
+
+	if err := unstructured.SetNestedField(intent.Object, rotate.CipherType,
+		"spec", "backups", "pgbackrest", "global", rotate.Repo+"-cipher-type"); err != nil {
+		return err
+	}
+
+	backup := pgBackRestBackupArgs{RepoName: rotate.Repo, Options: []string{"--type=full"}}
+	return backup.modifyIntent(intent, now)
+}
+
+func (rotate repoCipherRotation) Run(ctx context.Context, cmd *cobra.Command, args []string) error {
+	mapping, client, err := v1beta1.NewPostgresClusterClient(rotate)
+	if err != nil {
+		return err
+	}
+
+	namespace, err := rotate.Namespace()
+	if err != nil {
+		return err
+	}
+
+	cluster, err := client.Namespace(namespace).Get(ctx,
+		rotate.PostgresCluster, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	var defined bool
+	for _, name := range clusterRepoNames(cluster) {
+		defined = defined || name == rotate.Repo
+	}
+	if !defined {
+		return fmt.Errorf("%s is not in spec.backups.pgbackrest.repos of %s",
+			rotate.Repo, rotate.PostgresCluster)
+	}
+
+	// pgBackRest refuses to read a repository with a passphrase other than
+	// the one its stanza, WAL, and backups were written with.
+	exec, err := getPrimaryExec(rotate.Config, args)
+	if err != nil {
+		return err
+	}
+	stdout, stderr, err := podexec.PGBackRestInfo(exec, "")
+	if err != nil {
+		return commandError(err, stderr)
+	}
+	var stanzas []pgBackRestStanza
+	if err := json.Unmarshal([]byte(stdout), &stanzas); err != nil {
+		return err
+	}
+	if err := checkEmptyRepo(stanzas, rotate.Repo); err != nil {
+		return err
+	}
+
+	intent := new(unstructured.Unstructured)
+	if err := internal.ExtractFieldsInto(cluster, intent, rotate.Patch.FieldManager); err != nil {
+		return err
+	}
+	if err := rotate.modifyIntent(intent, cluster, time.Now()); err != nil {
+		return err
+	}
+	if err := checkClusterChange(rotate.ErrOut, &rotate.Messages, cluster, intent); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprint(rotate.ErrOut, rotate.Messages.Sprintf("rotate.warn-cipher", rotate.Repo)+
+		rotate.Messages.Sprintf("confirm.continue"))
+	var confirmed *bool
+	for i := 0; confirmed == nil && i < 10; i++ {
+		// retry 10 times or until a confirmation is given or denied,
+		// whichever comes first
+		confirmed = rotate.Messages.Confirm(rotate.In, rotate.ErrOut)
+	}
+	if confirmed == nil || !*confirmed {
+		return ErrCancelled
+	}
+
+	pass, err := newCipherPass()
+	if err != nil {
+		return err
+	}
+	secret := generatedSecret(namespace, rotate.PostgresCluster, rotate.SecretName(),
+		map[string][]byte{"cipher.conf": cipherPassConfig(rotate.Repo, pass)})
+	if err := outputSecret(rotate.Config, cmd, true, secret); err != nil {
+		return err
+	}
+
+	patch, err := intent.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	patchOptions := metav1.PatchOptions{}
+	if rotate.ForceConflicts {
+		b := true
+		patchOptions.Force = &b
+	}
+	if _, err := client.Namespace(namespace).Patch(ctx,
+		rotate.PostgresCluster, types.ApplyPatchType, patch,
+		rotate.Patch.PatchOptions(patchOptions)); err != nil {
+		if apierrors.IsConflict(err) {
+			_, _ = fmt.Fprintf(rotate.ErrOut, "SUGGESTION: The --force-conflicts flag may help in performing this operation.\n")
+		}
+		return err
+	}
+	_, _ = fmt.Fprintf(rotate.Out, "%s/%s patched\n",
+		mapping.Resource.Resource, rotate.PostgresCluster)
+
+	if rotate.Wait || rotate.Detach {
+		token := watchToken{
+			Operation: "backup",
+			Namespace: namespace,
+			Name:      rotate.PostgresCluster,
+			ID:        intent.GetAnnotations()[util.AnnotationPGBackRestBackup],
+		}
+		_, _ = fmt.Fprintf(rotate.Out, "To watch this backup, run: pgo attach %s\n", token)
+
+		if rotate.Wait {
+			if err := watchOperation(ctx, rotate.Out, client, token, rotate.Heartbeat); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, _ = fmt.Fprintf(rotate.Out, "When you have verified the backups in %s, "+
+		"remove the old repository from spec.backups.pgbackrest.repos.\n", rotate.Repo)
+	return nil
+}
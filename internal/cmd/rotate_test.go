@@ -0,0 +1,110 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+func TestNewCipherPass(t *testing.T) {
+	pass, err := newCipherPass()
+	assert.NilError(t, err)
+	assert.Equal(t, len(pass), 64)
+
+	other, err := newCipherPass()
+	assert.NilError(t, err)
+	assert.Assert(t, pass != other)
+
+	// The passphrase fits on one line of a pgBackRest configuration file.
+	secret := corev1.Secret{Data: map[string][]byte{"cipher.conf": cipherPassConfig("repo2", pass)}}
+	secret.Name = "hippo-pgbackrest-cipher-repo2"
+	assert.Equal(t, string(secret.Data["cipher.conf"]), "[global]\nrepo2-cipher-pass="+pass+"\n")
+	assert.Assert(t, !strings.ContainsAny(pass, "\n="))
+	assert.DeepEqual(t, cipherPassSources([]corev1.Secret{secret}), map[string]string{
+		"repo2": "secrets/hippo-pgbackrest-cipher-repo2 (cipher.conf)",
+	})
+}
+
+func TestCheckEmptyRepo(t *testing.T) {
+	var stanzas []pgBackRestStanza
+	assert.NilError(t, json.Unmarshal([]byte(`[{"name":"db",
+"archive":[
+{"id":"16-1","max":"000000010000000000000009","database":{"repo-key":1}},
+{"id":"16-1","max":"000000010000000000000004","database":{"repo-key":4}}
+],
+"backup":[
+{"label":"20240501-060001F","database":{"repo-key":1}},
+{"label":"20240501-060001F_20240501-120002I","database":{"repo-key":1}}
+],
+"repo":[
+{"key":1,"status":{"code":0,"message":"ok"}},
+{"key":2,"status":{"code":1,"message":"missing stanza path"}},
+{"key":3,"status":{"code":2,"message":"no valid backups"}},
+{"key":4,"status":{"code":2,"message":"no valid backups"}}
+]}]`), &stanzas))
+
+	assert.Error(t, checkEmptyRepo(stanzas, "repo1"),
+		"repo1 has 2 backups; choose an empty repository with --to")
+	assert.NilError(t, checkEmptyRepo(stanzas, "repo2"))
+
+	// A stanza without backups cannot change its cipher either.
+	assert.Error(t, checkEmptyRepo(stanzas, "repo3"),
+		"repo3 already has a stanza; choose a repository without a stanza with --to")
+	assert.Error(t, checkEmptyRepo(stanzas, "repo4"),
+		"repo4 has archived WAL; choose a repository without a stanza with --to")
+
+	// A repository that info does not list yet has no stanza.
+	assert.NilError(t, checkEmptyRepo(stanzas, "repo5"))
+}
+
+func TestRepoCipherRotationModifyIntent(t *testing.T) {
+	rotate := repoCipherRotation{PostgresCluster: "hippo", Repo: "repo2", CipherType: "aes-256-cbc"}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	current := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{"backups": map[string]any{"pgbackrest": map[string]any{
+			"configuration": []any{
+				map[string]any{"secret": map[string]any{"name": "hippo-s3"}},
+			},
+		}}},
+	}}
+
+	intent := &unstructured.Unstructured{Object: map[string]any{}}
+	assert.NilError(t, rotate.modifyIntent(intent, current, now))
+	assert.DeepEqual(t, intent.Object, map[string]any{
+		"metadata": map[string]any{"annotations": map[string]any{
+			util.AnnotationPGBackRestBackup: "2024-05-01T12:00:00Z",
+		}},
+		"spec": map[string]any{"backups": map[string]any{"pgbackrest": map[string]any{
+			"configuration": []any{
+				map[string]any{"secret": map[string]any{"name": "hippo-s3"}},
+				map[string]any{"secret": map[string]any{"name": "hippo-pgbackrest-cipher-repo2"}},
+			},
+			"global": map[string]any{"repo2-cipher-type": "aes-256-cbc"},
+			"manual": map[string]any{"repoName": "repo2", "options": []any{"--type=full"}},
+		}}},
+	})
+
+	// Running it again does not add the Secret twice.
+	intent = &unstructured.Unstructured{Object: map[string]any{}}
+	assert.NilError(t, rotate.modifyIntent(intent, &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{"backups": map[string]any{"pgbackrest": map[string]any{
+			"configuration": []any{
+				map[string]any{"secret": map[string]any{"name": "hippo-pgbackrest-cipher-repo2"}},
+			},
+		}}},
+	}}, now))
+	configuration, _, _ := unstructured.NestedSlice(intent.Object, "spec", "backups", "pgbackrest", "configuration")
+	assert.Equal(t, len(configuration), 1)
+}
@@ -0,0 +1,25 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// newScaleCommand returns the scale subcommand of the PGO plugin.
+func newScaleCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "scale",
+		Short: "Change the number of Pods of a PostgresCluster",
+		Long:  "Change the number of Pods of a PostgresCluster",
+	}
+
+	cmd.AddCommand(newScalePGBouncerCommand(config))
+	cmd.AddCommand(newScaleClusterCommand(config))
+
+	return cmd
+}
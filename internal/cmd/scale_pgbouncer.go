@@ -0,0 +1,222 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newScalePGBouncerCommand returns the pgbouncer subcommand of the scale
+// command. It changes the number of pgBouncer Pods or prints an autoscaler
+// for them.
+func newScalePGBouncerCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pgbouncer CLUSTER_NAME",
+		Short: "Change the number of pgBouncer Pods",
+		Long: `Set "spec.proxy.pgBouncer.replicas" of a PostgresCluster to "--replicas".
+Overwriting a value set by others may require the --force-conflicts flag.
+
+With "--generate-hpa", print a HorizontalPodAutoscaler for the pgBouncer
+Deployment instead, between "--min-replicas" and "--max-replicas" Pods at
+"--target-cpu" percent of their CPU request. Nothing in the cluster changes;
+apply the autoscaler with kubectl. The autoscaler needs a CPU request in
+"spec.proxy.pgBouncer.resources". PGO also sets the replicas of the
+Deployment, so keep "spec.proxy.pgBouncer.replicas" at "--min-replicas".
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    postgresclusters.postgres-operator.crunchydata.com  [get patch]
+
+    Note: Patching is not needed with "--generate-hpa".
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Run four pgBouncer Pods for the 'hippo' postgrescluster
+pgo scale pgbouncer hippo --replicas=4
+
+# Print an autoscaler that keeps pgBouncer at 70% of its CPU request
+pgo scale pgbouncer hippo --generate-hpa --target-cpu=70 --min-replicas=2 --max-replicas=8
+
+### Example output
+postgresclusters/hippo pgBouncer scaled to 4 replicas`)
+
+	var scale scalePGBouncerArgs
+	var generateHPA, forceConflicts bool
+	cmd.Flags().Int32Var(&scale.Replicas, "replicas", 0, "number of pgBouncer Pods")
+	cmd.Flags().BoolVar(&generateHPA, "generate-hpa", false,
+		"print a HorizontalPodAutoscaler for pgBouncer rather than scaling it")
+	cmd.Flags().Int32Var(&scale.TargetCPU, "target-cpu", 70,
+		"with --generate-hpa, percent of the CPU request to keep pgBouncer at")
+	cmd.Flags().Int32Var(&scale.MinReplicas, "min-replicas", 1,
+		"with --generate-hpa, the fewest pgBouncer Pods")
+	cmd.Flags().Int32Var(&scale.MaxReplicas, "max-replicas", 0,
+		"with --generate-hpa, the most pgBouncer Pods")
+	cmd.Flags().BoolVar(&forceConflicts, "force-conflicts", false,
+		"take ownership and overwrite the pgBouncer replicas")
+	config.Record.AddFlags(cmd.Flags())
+
+	cmd.MarkFlagsMutuallyExclusive("replicas", "generate-hpa")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		if generateHPA {
+			if err := scale.validateHPA(); err != nil {
+				return err
+			}
+		} else if !cmd.Flags().Changed("replicas") || scale.Replicas < 0 {
+			return errors.New("--replicas is required and cannot be negative")
+		}
+
+		mapping, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		cluster, err := client.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if _, found, _ := unstructured.NestedMap(cluster.Object,
+			"spec", "proxy", "pgBouncer"); !found {
+			return fmt.Errorf("postgresclusters/%s does not have pgBouncer enabled", args[0])
+		}
+
+		if generateHPA {
+			if _, found, _ := unstructured.NestedFieldNoCopy(cluster.Object,
+				"spec", "proxy", "pgBouncer", "resources", "requests", "cpu"); !found {
+				cmd.PrintErrln("WARNING: pgBouncer has no CPU request, so the autoscaler cannot measure its CPU utilization.")
+			}
+			b, err := yaml.Marshal(scale.autoscaler(namespace, args[0]))
+			if err != nil {
+				return err
+			}
+			cmd.Print(string(b))
+			return nil
+		}
+
+		intent := new(unstructured.Unstructured)
+		if err := internal.ExtractFieldsInto(cluster, intent, config.Patch.FieldManager); err != nil {
+			return err
+		}
+		if err := unstructured.SetNestedField(intent.Object, int64(scale.Replicas),
+			"spec", "proxy", "pgBouncer", "replicas"); err != nil {
+			return err
+		}
+		if err := checkClusterChange(cmd.ErrOrStderr(), &config.Messages, cluster, intent); err != nil {
+			return err
+		}
+
+		// Save the change for later rather than sending it.
+		if config.Record.Enabled() {
+			change := internal.NewRecordedChange(internal.RecordApply,
+				mapping.Resource, namespace, args[0], intent)
+			change.Force = forceConflicts
+			msg, err := recordChange(config, change)
+			cmd.Print(msg)
+			return err
+		}
+
+		patch, err := intent.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		patchOptions := metav1.PatchOptions{}
+		if forceConflicts {
+			b := true
+			patchOptions.Force = &b
+		}
+
+		_, err = client.Namespace(namespace).Patch(ctx, args[0],
+			types.ApplyPatchType, patch, config.Patch.PatchOptions(patchOptions))
+		if err != nil {
+			if apierrors.IsConflict(err) {
+				cmd.PrintErrln("SUGGESTION: The --force-conflicts flag may help in performing this operation.")
+			}
+			return err
+		}
+
+		cmd.Printf("%s/%s pgBouncer scaled to %d replicas\n",
+			mapping.Resource.Resource, args[0], scale.Replicas)
+		return nil
+	}
+
+	return cmd
+}
+
+type scalePGBouncerArgs struct {
+	Replicas int32
+
+	// The bounds and target of a HorizontalPodAutoscaler.
+	MinReplicas, MaxReplicas, TargetCPU int32
+}
+
+// validateHPA returns an error when the autoscaler flags do not make sense.
+func (scale scalePGBouncerArgs) validateHPA() error {
+	switch {
+	case scale.MinReplicas < 1:
+		return errors.New("--min-replicas must be at least 1")
+	case scale.MaxReplicas < scale.MinReplicas:
+		return errors.New("--max-replicas is required and cannot be less than --min-replicas")
+	case scale.TargetCPU < 1:
+		return errors.New("--target-cpu must be a positive percent")
+	}
+	return nil
+}
+
+// autoscaler returns a HorizontalPodAutoscaler for the pgBouncer Deployment
+// of the cluster called clusterName.
+func (scale scalePGBouncerArgs) autoscaler(namespace, clusterName string) map[string]any {
+	return map[string]any{
+		"apiVersion": autoscalingv2.SchemeGroupVersion.String(),
+		"kind":       "HorizontalPodAutoscaler",
+		"metadata": map[string]any{
+			"name":      clusterName + "-pgbouncer",
+			"namespace": namespace,
+			"labels":    map[string]any{util.LabelCluster: clusterName},
+		},
+		"spec": map[string]any{
+			"scaleTargetRef": map[string]any{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"name":       clusterName + "-pgbouncer",
+			},
+			"minReplicas": scale.MinReplicas,
+			"maxReplicas": scale.MaxReplicas,
+			"metrics": []any{map[string]any{
+				"type": "Resource",
+				"resource": map[string]any{
+					"name": "cpu",
+					"target": map[string]any{
+						"type":               "Utilization",
+						"averageUtilization": scale.TargetCPU,
+					},
+				},
+			}},
+		},
+	}
+}
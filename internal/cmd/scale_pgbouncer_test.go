@@ -0,0 +1,55 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/crunchydata/postgres-operator-client/internal/testing/cmp"
+)
+
+func TestScalePGBouncerValidateHPA(t *testing.T) {
+	assert.NilError(t, scalePGBouncerArgs{MinReplicas: 2, MaxReplicas: 2, TargetCPU: 70}.validateHPA())
+
+	for _, tt := range []struct {
+		scale   scalePGBouncerArgs
+		message string
+	}{
+		{scalePGBouncerArgs{MinReplicas: 0, MaxReplicas: 4, TargetCPU: 70}, "--min-replicas"},
+		{scalePGBouncerArgs{MinReplicas: 2, TargetCPU: 70}, "--max-replicas is required"},
+		{scalePGBouncerArgs{MinReplicas: 2, MaxReplicas: 4}, "--target-cpu"},
+	} {
+		assert.ErrorContains(t, tt.scale.validateHPA(), tt.message, "%+v", tt.scale)
+	}
+}
+
+func TestScalePGBouncerAutoscaler(t *testing.T) {
+	scale := scalePGBouncerArgs{MinReplicas: 2, MaxReplicas: 8, TargetCPU: 70}
+	assert.Assert(t, cmp.MarshalMatches(scale.autoscaler("postgres-operator", "hippo"), `
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  labels:
+    postgres-operator.crunchydata.com/cluster: hippo
+  name: hippo-pgbouncer
+  namespace: postgres-operator
+spec:
+  maxReplicas: 8
+  metrics:
+  - resource:
+      name: cpu
+      target:
+        averageUtilization: 70
+        type: Utilization
+    type: Resource
+  minReplicas: 2
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: hippo-pgbouncer
+`))
+}
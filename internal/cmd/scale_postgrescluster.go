@@ -0,0 +1,196 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+)
+
+// newScaleClusterCommand returns the postgrescluster subcommand of the scale
+// command. It changes the number of instances in an instance set.
+func newScaleClusterCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "postgrescluster CLUSTER_NAME",
+		Aliases: []string{"postgresclusters"},
+		Short:   "Change the number of Postgres instances",
+		Long: `Set "spec.instances[].replicas" of an instance set to "--replicas". The
+"--instance-set" flag is required when there is more than one.
+Overwriting a value set by others may require the --force-conflicts flag.
+
+Before scaling, this lists the instances that are added or removed, whether
+the primary switches over, and how much data each new instance copies from the
+pgBackRest repository or the primary. Then it asks to continue. Pass "--yes"
+to skip the question. PGO chooses which instances to remove and never removes
+the primary this way.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    pods                                                [list]
+    pods/exec                                           [create]
+    postgresclusters.postgres-operator.crunchydata.com  [get patch]
+
+    Note: Pods are only exec'd into to measure the data new instances copy.
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Run three instances in the 'instance1' set of the 'hippo' postgrescluster
+pgo scale postgrescluster hippo --instance-set=instance1 --replicas=3
+
+### Example output
+WARNING: This change to postgrescluster hippo restarts, removes, or adds instances:
+POD  SET        ROLE     IMPACT
+-    instance1  replica  created; copies the database
+switchover: no
+estimated data resync: 1 instance(s) copy about 12.4GiB each from the repository or the primary
+Are you sure you want to continue? (yes/no): yes
+postgresclusters/hippo instance1 scaled to 3 replicas`)
+
+	var scale scaleClusterArgs
+	var forceConflicts, yes bool
+	cmd.Flags().Int64Var(&scale.Replicas, "replicas", 0, "number of Postgres instances in the set")
+	cobra.CheckErr(cmd.MarkFlagRequired("replicas"))
+	cmd.Flags().StringVar(&scale.InstanceSet, "instance-set", "",
+		"instance set to scale; required when there is more than one")
+	cmd.Flags().BoolVar(&forceConflicts, "force-conflicts", false,
+		"take ownership and overwrite the replicas of the instance set")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "apply without asking for confirmation")
+	config.Record.AddFlags(cmd.Flags())
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		if scale.Replicas < 1 {
+			return errors.New("--replicas must be at least 1")
+		}
+
+		mapping, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		cluster, err := client.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		intent := new(unstructured.Unstructured)
+		if err := internal.ExtractFieldsInto(cluster, intent, config.Patch.FieldManager); err != nil {
+			return err
+		}
+		name, err := scale.modifyIntent(cluster, intent)
+		if err != nil {
+			return err
+		}
+		if err := checkClusterChange(cmd.ErrOrStderr(), &config.Messages, cluster, intent); err != nil {
+			return err
+		}
+
+		// Save the change for later rather than sending it.
+		if config.Record.Enabled() {
+			change := internal.NewRecordedChange(internal.RecordApply,
+				mapping.Resource, namespace, args[0], intent)
+			change.Force = forceConflicts
+			msg, err := recordChange(config, change)
+			cmd.Print(msg)
+			return err
+		}
+
+		if err := previewClusterChange(ctx, cmd, config, cluster, mergeIntent(cluster, intent), yes); err != nil {
+			return err
+		}
+
+		patch, err := intent.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		patchOptions := metav1.PatchOptions{}
+		if forceConflicts {
+			b := true
+			patchOptions.Force = &b
+		}
+
+		_, err = client.Namespace(namespace).Patch(ctx, args[0],
+			types.ApplyPatchType, patch, config.Patch.PatchOptions(patchOptions))
+		if err != nil {
+			if apierrors.IsConflict(err) {
+				cmd.PrintErrln("SUGGESTION: The --force-conflicts flag may help in performing this operation.")
+			}
+			return err
+		}
+
+		cmd.Printf("%s/%s %s scaled to %d replicas\n",
+			mapping.Resource.Resource, args[0], name, scale.Replicas)
+		return nil
+	}
+
+	return cmd
+}
+
+type scaleClusterArgs struct {
+	// InstanceSet is the set to scale. It may be empty when there is only
+	// one instance set.
+	InstanceSet string
+	Replicas    int64
+}
+
+// modifyIntent sets the replicas of the chosen instance set of cluster in
+// intent. It returns the name of that set.
+func (scale scaleClusterArgs) modifyIntent(cluster, intent *unstructured.Unstructured) (string, error) {
+	sets, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "instances")
+	names := make([]string, 0, len(sets))
+	target := ""
+	for _, set := range sets {
+		set, _ := set.(map[string]any)
+		name, _, _ := unstructured.NestedString(set, "name")
+		names = append(names, name)
+		if name == scale.InstanceSet || (scale.InstanceSet == "" && len(sets) == 1) {
+			target = name
+		}
+	}
+	if target == "" {
+		sort.Strings(names)
+		if scale.InstanceSet == "" {
+			return "", fmt.Errorf("--instance-set is required; instance sets are %q", names)
+		}
+		return "", fmt.Errorf("instance set %q not found; instance sets are %q", scale.InstanceSet, names)
+	}
+
+	// Instance sets are a list keyed by name. Keep any other fields and sets
+	// this field manager already owns.
+	owned, _, _ := unstructured.NestedSlice(intent.Object, "spec", "instances")
+	instance := map[string]any{"name": target}
+	instances := make([]any, 0, len(owned)+1)
+	for _, item := range owned {
+		if item, ok := item.(map[string]any); ok && item["name"] == target {
+			instance = item
+			continue
+		}
+		instances = append(instances, item)
+	}
+	instance["replicas"] = scale.Replicas
+	instances = append(instances, instance)
+
+	return target, unstructured.SetNestedSlice(intent.Object, instances, "spec", "instances")
+}
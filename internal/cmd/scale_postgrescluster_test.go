@@ -0,0 +1,49 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crunchydata/postgres-operator-client/internal/testing/cmp"
+)
+
+func TestScaleClusterArgsModifyIntent(t *testing.T) {
+	var current unstructured.Unstructured
+	assert.NilError(t, yaml.Unmarshal([]byte(strings.TrimSpace(`
+spec:
+  instances:
+  - name: one
+    replicas: 2
+  - name: two
+	`)), &current.Object))
+
+	intent := unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{"instances": []any{
+			map[string]any{"name": "two", "minAvailable": int64(1)},
+		}},
+	}}
+	name, err := scaleClusterArgs{InstanceSet: "two", Replicas: 3}.modifyIntent(&current, &intent)
+	assert.NilError(t, err)
+	assert.Equal(t, name, "two")
+	assert.Assert(t, cmp.MarshalMatches(intent.Object, strings.TrimSpace(`
+spec:
+  instances:
+  - minAvailable: 1
+    name: two
+    replicas: 3
+	`)))
+
+	_, err = scaleClusterArgs{Replicas: 3}.modifyIntent(&current, &intent)
+	assert.ErrorContains(t, err, `--instance-set is required; instance sets are ["one" "two"]`)
+
+	_, err = scaleClusterArgs{InstanceSet: "three", Replicas: 3}.modifyIntent(&current, &intent)
+	assert.ErrorContains(t, err, `instance set "three" not found`)
+}
@@ -0,0 +1,248 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newSeedCommand returns the seed subcommand of the PGO plugin. It loads test
+// data into a PostgresCluster and optionally runs a benchmark against it.
+func newSeedCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "seed CLUSTER_NAME",
+		Short: "Load test data into a PostgresCluster",
+		Long: `Load test data into the primary of a PostgresCluster so it can be exercised
+for load and failover testing.
+
+The "pgbench" dataset creates the pgbench tables; "--scale" multiplies their
+size, where each unit is 100,000 accounts or about 15MiB. Existing pgbench
+tables are replaced after confirmation.
+
+Use "--file" to load your own data instead. The file is streamed to the primary:
+plain SQL is run by psql and a custom-format dump from "pg_dump --format=custom"
+is restored by pg_restore. Use "--file=-" to read from stdin.
+
+With "--benchmark" pgbench then runs its default workload for that long with
+"--clients" connections.
+
+### RBAC Requirements
+    Resources  Verbs
+    ---------  -----
+    pods       [list]
+    pods/exec  [create]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Create the pgbench tables in the 'hippo' postgrescluster
+pgo seed hippo --dataset=pgbench --scale=50
+
+# Load a dump into the 'app' database then run a one minute benchmark
+pgo seed hippo --dbname=app --file=app.dump --benchmark=1m
+
+### Example output
+dropping old tables...
+creating tables...
+generating data (client-side)...
+5000000 of 5000000 tuples (100%) done (elapsed 9.41 s, remaining 0.00 s)
+vacuuming...
+creating primary keys...
+done in 14.02 s (drop tables 0.00 s, create tables 0.01 s, client-side generate 9.53 s, vacuum 1.71 s, primary keys 2.77 s).`)
+
+	var options seedOptions
+	cmd.Flags().StringVar(&options.Dataset, "dataset", "", "dataset to create. types supported: pgbench")
+	cmd.Flags().IntVar(&options.Scale, "scale", 1, "size of the pgbench dataset")
+	cmd.Flags().StringVar(&options.File, "file", "", "path of a SQL file or custom-format dump to load, or - for stdin")
+	cmd.Flags().StringVar(&options.Database, "dbname", "postgres", "database to load data into")
+	cmd.Flags().DurationVar(&options.Benchmark, "benchmark", 0, "run pgbench for this long after loading")
+	cmd.Flags().IntVar(&options.Clients, "clients", 4, "connections the benchmark uses")
+	cmd.MarkFlagsMutuallyExclusive("dataset", "file")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		if err := options.Validate(); err != nil {
+			return err
+		}
+
+		var input *bufio.Reader
+		if options.File == "-" {
+			input = bufio.NewReader(config.In)
+		} else if options.File != "" {
+			// #nosec G304 -- We intentionally read the file supplied by the user.
+			file, err := os.Open(options.File)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			input = bufio.NewReader(file)
+		}
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.PrimaryInstanceLabels(args[0]),
+		})
+		if err != nil {
+			return err
+		}
+		var pod *corev1.Pod
+		for i := range pods.Items {
+			if podIsReady(&pods.Items[i]) {
+				pod = &pods.Items[i]
+				break
+			}
+		}
+		if pod == nil {
+			return errors.New("no ready primary instance Pod found")
+		}
+
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+		exec := podexec.Container(podExec, namespace, pod.GetName(), util.ContainerDatabase)
+
+		switch {
+		case options.Dataset == "pgbench":
+			stdout, stderr, err := podexec.PSQL(exec, options.Database,
+				`SELECT to_regclass('pgbench_accounts') IS NOT NULL;`)
+			if err != nil {
+				return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr))
+			}
+			if strings.TrimSpace(stdout) == "t" {
+				_, _ = fmt.Fprint(config.ErrOut, config.Messages.Sprintf("seed.warn-replace")+
+					config.Messages.Sprintf("confirm.continue"))
+				var confirmed *bool
+				for i := 0; confirmed == nil && i < 10; i++ {
+					// retry 10 times or until a confirmation is given or denied,
+					// whichever comes first
+					confirmed = config.Messages.Confirm(config.In, config.ErrOut)
+				}
+				if confirmed == nil || !*confirmed {
+					return ErrCancelled
+				}
+			}
+
+			err = exec.Exec(nil, cmd.OutOrStdout(), cmd.ErrOrStderr(),
+				pgbenchInitializeCommand(options.Database, options.Scale)...)
+			if err != nil {
+				return fmt.Errorf("unable to create the pgbench tables: %w", err)
+			}
+
+		case input != nil:
+			header, _ := input.Peek(len(pgDumpCustomHeader))
+			err = exec.Exec(input, cmd.OutOrStdout(), cmd.ErrOrStderr(),
+				seedFileCommand(header, options.Database)...)
+			if err != nil {
+				return fmt.Errorf("unable to load %s: %w", options.File, err)
+			}
+		}
+
+		if options.Benchmark > 0 {
+			cmd.Printf("running pgbench for %s...\n", options.Benchmark)
+			err = exec.Exec(nil, cmd.OutOrStdout(), cmd.ErrOrStderr(),
+				pgbenchRunCommand(options.Database, options.Benchmark, options.Clients)...)
+			if err != nil {
+				return fmt.Errorf("unable to run pgbench: %w", err)
+			}
+		}
+
+		return nil
+	}
+
+	return cmd
+}
+
+// seedOptions are the flags of the seed command.
+type seedOptions struct {
+	Dataset   string
+	Scale     int
+	File      string
+	Database  string
+	Benchmark time.Duration
+	Clients   int
+}
+
+// Validate returns an error when the options are not a complete request.
+func (options seedOptions) Validate() error {
+	switch {
+	case options.Dataset == "" && options.File == "" && options.Benchmark == 0:
+		return errors.New("one of --dataset, --file, or --benchmark is required")
+	case options.Dataset != "" && options.Dataset != "pgbench":
+		return fmt.Errorf("unknown dataset %q; types supported: pgbench", options.Dataset)
+	case options.Scale < 1:
+		return errors.New("--scale must be at least 1")
+	case options.Benchmark < 0 || (options.Benchmark > 0 && options.Benchmark < time.Second):
+		return errors.New("--benchmark must be at least 1s")
+	case options.Clients < 1:
+		return errors.New("--clients must be at least 1")
+	}
+	return nil
+}
+
+// pgDumpCustomHeader begins every custom-format archive of pg_dump.
+const pgDumpCustomHeader = "PGDMP"
+
+// seedFileCommand returns the command that loads a file that begins with
+// header into database from stdin.
+func seedFileCommand(header []byte, database string) []string {
+	if bytes.HasPrefix(header, []byte(pgDumpCustomHeader)) {
+		// Objects are owned by the postgres user rather than roles that may
+		// not exist in this cluster.
+		return []string{"pg_restore", "--no-owner", "--no-privileges",
+			"--exit-on-error", "--dbname=" + database}
+	}
+
+	return []string{"psql", "--no-psqlrc", "--set=ON_ERROR_STOP=1",
+		"--file=-", "--dbname=" + database}
+}
+
+// pgbenchInitializeCommand returns the command that creates the pgbench
+// tables in database at scale.
+func pgbenchInitializeCommand(database string, scale int) []string {
+	return []string{"pgbench", "--initialize", "--quiet",
+		"--scale=" + strconv.Itoa(scale), database}
+}
+
+// pgbenchRunCommand returns the command that runs the default pgbench
+// workload in database for duration using clients connections.
+func pgbenchRunCommand(database string, duration time.Duration, clients int) []string {
+	return []string{"pgbench", "--progress=10",
+		"--time=" + strconv.Itoa(int(duration.Seconds())),
+		"--client=" + strconv.Itoa(clients),
+		"--jobs=" + strconv.Itoa(clients), database}
+}
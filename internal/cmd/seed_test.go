@@ -0,0 +1,52 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestSeedOptionsValidate(t *testing.T) {
+	valid := seedOptions{Dataset: "pgbench", Scale: 1, Database: "postgres", Clients: 4}
+	assert.NilError(t, valid.Validate())
+
+	benchmark := seedOptions{Scale: 1, Database: "postgres", Clients: 4, Benchmark: time.Minute}
+	assert.NilError(t, benchmark.Validate())
+
+	for _, tt := range []struct {
+		change func(*seedOptions)
+		expect string
+	}{
+		{func(o *seedOptions) { o.Dataset = "" }, "one of --dataset"},
+		{func(o *seedOptions) { o.Dataset = "tpcc" }, `unknown dataset "tpcc"`},
+		{func(o *seedOptions) { o.Scale = 0 }, "--scale"},
+		{func(o *seedOptions) { o.Benchmark = time.Millisecond }, "--benchmark"},
+		{func(o *seedOptions) { o.Clients = 0 }, "--clients"},
+	} {
+		options := valid
+		tt.change(&options)
+		assert.ErrorContains(t, options.Validate(), tt.expect)
+	}
+}
+
+func TestSeedCommands(t *testing.T) {
+	assert.DeepEqual(t, seedFileCommand([]byte("PGDMP\x01"), "app"), []string{
+		"pg_restore", "--no-owner", "--no-privileges", "--exit-on-error", "--dbname=app",
+	})
+	assert.DeepEqual(t, seedFileCommand([]byte("CREATE"), "app"), []string{
+		"psql", "--no-psqlrc", "--set=ON_ERROR_STOP=1", "--file=-", "--dbname=app",
+	})
+	assert.DeepEqual(t, seedFileCommand(nil, "app")[0], "psql")
+
+	assert.DeepEqual(t, pgbenchInitializeCommand("postgres", 50), []string{
+		"pgbench", "--initialize", "--quiet", "--scale=50", "postgres",
+	})
+	assert.DeepEqual(t, pgbenchRunCommand("postgres", 90*time.Second, 8), []string{
+		"pgbench", "--progress=10", "--time=90", "--client=8", "--jobs=8", "postgres",
+	})
+}
@@ -0,0 +1,32 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// newSetCommand returns the set subcommand of the PGO plugin.
+// Subcommands of set change one setting of a PostgresCluster.
+func newSetCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set",
+		Short: "Change a setting of a PostgresCluster",
+		Long:  "Change a setting of a PostgresCluster",
+	}
+
+	cmd.AddCommand(
+		newSetDelayedReplicaCommand(config),
+		newSetParameterCommand(config),
+		newSetPDBCommand(config),
+		newSetPGBouncerCommand(config),
+		newSetUserPolicyCommand(config),
+		newSetWALVolumeCommand(config),
+	)
+
+	return cmd
+}
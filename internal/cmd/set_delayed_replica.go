@@ -0,0 +1,133 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newSetDelayedReplicaCommand returns the delayed-replica subcommand of the set
+// command. It sets recovery_min_apply_delay on the replicas of an instance set.
+func newSetDelayedReplicaCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delayed-replica CLUSTER_NAME",
+		Short: "Delay replay on the replicas of an instance set",
+		Long: `Set "recovery_min_apply_delay" on every replica of one instance set so that
+changes reach those replicas only after the delay. A delayed replica keeps a
+recent copy of the data from before a mistake, such as a dropped table.
+
+PGO applies Postgres parameters to every instance of a cluster, so the delay
+is set with ALTER SYSTEM in each replica of the set. Set it again after a
+replica of the set is rebuilt. A delay of zero removes it.
+
+The current delay and replay position of each replica appear in "pgo show ha".
+
+### RBAC Requirements
+    Resources  Verbs
+    ---------  -----
+    pods       [list]
+    pods/exec  [create]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Delay replay by four hours on the 'delayed' instance set of the 'hippo' postgrescluster
+pgo set delayed-replica hippo --instance-set=delayed --delay=4h
+
+# Remove the delay
+pgo set delayed-replica hippo --instance-set=delayed --delay=0
+
+### Example output
+hippo-delayed-x7k2-0: recovery_min_apply_delay set to 4h0m0s`)
+
+	var instanceSet string
+	var delay time.Duration
+	cmd.Flags().StringVar(&instanceSet, "instance-set", "", "name of the instance set to delay")
+	cobra.CheckErr(cmd.MarkFlagRequired("instance-set"))
+	cmd.Flags().DurationVar(&delay, "delay", 0, "how long replicas wait before replaying changes")
+	cobra.CheckErr(cmd.MarkFlagRequired("delay"))
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		if delay < 0 {
+			return fmt.Errorf("--delay must not be negative")
+		}
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+
+		pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.InstanceSetLabels(args[0], instanceSet),
+		})
+		if err != nil {
+			return err
+		}
+		if len(pods.Items) == 0 {
+			return fmt.Errorf("no instances found in instance set %q", instanceSet)
+		}
+
+		sql := delayedReplicaSQL(delay)
+		for _, pod := range pods.Items {
+			if pod.GetLabels()[util.LabelRole] == util.RolePatroniLeader {
+				cmd.PrintErrf("WARNING: %s is the primary; skipping it\n", pod.GetName())
+				continue
+			}
+
+			exec := podexec.Container(podExec, namespace, pod.GetName(), util.ContainerDatabase)
+			if _, stderr, err := podexec.PSQL(exec, "", sql); err != nil {
+				return fmt.Errorf("%s: %w: %s", pod.GetName(), err, strings.TrimSpace(stderr))
+			}
+
+			if delay == 0 {
+				cmd.Printf("%s: recovery_min_apply_delay removed\n", pod.GetName())
+			} else {
+				cmd.Printf("%s: recovery_min_apply_delay set to %s\n", pod.GetName(), delay)
+			}
+		}
+
+		return nil
+	}
+
+	return cmd
+}
+
+// delayedReplicaSQL returns the statements that set recovery_min_apply_delay
+// to delay, or reset it when delay is zero, and reload the configuration.
+func delayedReplicaSQL(delay time.Duration) string {
+	statement := "ALTER SYSTEM RESET recovery_min_apply_delay;"
+	if delay > 0 {
+		statement = fmt.Sprintf("ALTER SYSTEM SET recovery_min_apply_delay = '%dms';",
+			delay.Milliseconds())
+	}
+	return statement + "\nSELECT pg_catalog.pg_reload_conf();"
+}
@@ -0,0 +1,22 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestDelayedReplicaSQL(t *testing.T) {
+	assert.Equal(t, delayedReplicaSQL(4*time.Hour),
+		"ALTER SYSTEM SET recovery_min_apply_delay = '14400000ms';\n"+
+			"SELECT pg_catalog.pg_reload_conf();")
+
+	assert.Equal(t, delayedReplicaSQL(0),
+		"ALTER SYSTEM RESET recovery_min_apply_delay;\n"+
+			"SELECT pg_catalog.pg_reload_conf();")
+}
@@ -0,0 +1,416 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// canaryInterval is how often a canary replica is sampled while it is observed.
+const canaryInterval = 15 * time.Second
+
+// newSetParameterCommand returns the parameter subcommand of the set command.
+// It changes Postgres parameters, optionally on one replica first.
+func newSetParameterCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "parameter CLUSTER_NAME NAME=VALUE...",
+		Short: "Set Postgres parameters, optionally on a canary replica first",
+		Long: `Set Postgres parameters in "spec.patroni.dynamicConfiguration" of a
+PostgresCluster. PGO and Patroni apply them to every instance of the cluster.
+Overwriting values set by others may require the --force-conflicts flag.
+
+With "--canary=1", the parameters are first set on one ready replica with
+ALTER SYSTEM and watched for the duration of --observe. The canary regresses
+when it stops being ready, when its rate of rollbacks, conflicts, and
+deadlocks doubles, or when its replay falls behind. A regression resets
+the parameters on the replica and leaves the PostgresCluster unchanged. When
+the replica stays healthy, the PostgresCluster is changed and the replica
+goes back to following it.
+
+Parameters that need a restart cannot be tried on a canary.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    pods                                                [get list]
+    pods/exec                                           [create]
+    postgresclusters.postgres-operator.crunchydata.com  [get patch]
+
+    Note: pods are only needed with --canary.
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Set work_mem for every instance of the 'hippo' postgrescluster
+pgo set parameter hippo work_mem=64MB
+
+# Try a change on one replica for ten minutes before the rest of the cluster
+pgo set parameter hippo random_page_cost=1.1 effective_io_concurrency=200 --canary=1 --observe=10m
+
+### Example output
+Setting parameters on canary hippo-instance1-9w2x-0... done
+Observing hippo-instance1-9w2x-0 for 10m0s... healthy
+postgresclusters/hippo parameters updated`)
+
+	var canary int
+	var forceConflicts bool
+	var observe time.Duration
+	cmd.Flags().IntVar(&canary, "canary", 0, "number of replicas to try the parameters on first: 0 or 1")
+	cmd.Flags().DurationVar(&observe, "observe", 10*time.Minute, "how long to watch the canary before changing the cluster")
+	cmd.Flags().BoolVar(&forceConflicts, "force-conflicts", false, "take ownership and overwrite the parameters")
+	config.Record.AddFlags(cmd.Flags())
+
+	cmd.Args = cobra.MinimumNArgs(2)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		parameters, err := parseParameterArgs(args[1:])
+		if err != nil {
+			return err
+		}
+		switch {
+		case canary < 0 || canary > 1:
+			return errors.New("--canary must be 0 or 1")
+		case canary == 0 && cmd.Flags().Changed("observe"):
+			return errors.New("--observe requires --canary=1")
+		case canary > 0 && observe <= 0:
+			return errors.New("--observe must be positive")
+		case canary > 0 && config.Record.Enabled():
+			return errors.New("--canary cannot be used when recording a change")
+		}
+
+		mapping, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		cluster, err := client.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		intent := new(unstructured.Unstructured)
+		if err := internal.ExtractFieldsInto(cluster, intent, config.Patch.FieldManager); err != nil {
+			return err
+		}
+		for name, value := range parameters {
+			if err := unstructured.SetNestedField(intent.Object, value,
+				"spec", "patroni", "dynamicConfiguration", "postgresql", "parameters", name); err != nil {
+				return err
+			}
+		}
+		if err := checkClusterChange(cmd.ErrOrStderr(), &config.Messages, cluster, intent); err != nil {
+			return err
+		}
+
+		// Save the change for later rather than sending it.
+		if config.Record.Enabled() {
+			change := internal.NewRecordedChange(internal.RecordApply,
+				mapping.Resource, namespace, args[0], intent)
+			change.Force = forceConflicts
+			msg, err := recordChange(config, change)
+			cmd.Print(msg)
+			return err
+		}
+
+		var release func() error
+		if canary > 0 {
+			release, err = runParameterCanary(ctx, cmd, config, namespace, args[0], parameters, observe)
+			if err != nil {
+				return err
+			}
+		}
+
+		patch, err := intent.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		patchOptions := metav1.PatchOptions{}
+		if forceConflicts {
+			b := true
+			patchOptions.Force = &b
+		}
+
+		_, err = client.Namespace(namespace).Patch(ctx, args[0],
+			types.ApplyPatchType, patch, config.Patch.PatchOptions(patchOptions))
+		if err != nil {
+			if apierrors.IsConflict(err) {
+				cmd.PrintErrln("SUGGESTION: The --force-conflicts flag may help in performing this operation.")
+			}
+			if release != nil {
+				err = errors.Join(err, release())
+			}
+			return err
+		}
+
+		cmd.Printf("%s/%s parameters updated\n", mapping.Resource.Resource, args[0])
+
+		// The canary would otherwise keep its ALTER SYSTEM values over
+		// later changes to the cluster.
+		if release != nil {
+			return release()
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// parameterNamePattern matches the names of Postgres parameters, including
+// those of extensions, such as "pg_stat_statements.max".
+var parameterNamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*(\.[a-z_][a-z0-9_]*)?$`)
+
+// parseParameterArgs returns the NAME=VALUE pairs of args by name.
+func parseParameterArgs(args []string) (map[string]string, error) {
+	parameters := map[string]string{}
+	for _, arg := range args {
+		name, value, ok := strings.Cut(arg, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || !parameterNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("%q is not NAME=VALUE", arg)
+		}
+		if _, duplicate := parameters[name]; duplicate {
+			return nil, fmt.Errorf("parameter %q is given more than once", name)
+		}
+		parameters[name] = value
+	}
+	return parameters, nil
+}
+
+// canaryChangeSQL returns the statements that set parameters on one instance
+// and reload its configuration. The values are quoted as literals, which
+// Postgres accepts for every type of parameter.
+func canaryChangeSQL(parameters map[string]string) string {
+	var sql strings.Builder
+	for _, name := range sortedKeys(parameters) {
+		fmt.Fprintf(&sql, "ALTER SYSTEM SET %s = '%s';\n",
+			name, strings.ReplaceAll(parameters[name], "'", "''"))
+	}
+	sql.WriteString("SELECT pg_catalog.pg_reload_conf();")
+	return sql.String()
+}
+
+// canaryResetSQL returns the statements that undo [canaryChangeSQL].
+func canaryResetSQL(parameters map[string]string) string {
+	var sql strings.Builder
+	for _, name := range sortedKeys(parameters) {
+		fmt.Fprintf(&sql, "ALTER SYSTEM RESET %s;\n", name)
+	}
+	sql.WriteString("SELECT pg_catalog.pg_reload_conf();")
+	return sql.String()
+}
+
+// canaryRestartSQL returns a query for those parameters that take effect
+// only after a restart.
+func canaryRestartSQL(parameters map[string]string) string {
+	names := sortedKeys(parameters)
+	for i := range names {
+		names[i] = "'" + names[i] + "'"
+	}
+	return "SELECT name FROM pg_catalog.pg_settings WHERE context = 'postmaster'" +
+		" AND name IN (" + strings.Join(names, ", ") + ") ORDER BY name;"
+}
+
+// canarySampleSQL is a query for the error count and replay backlog of a
+// replica, in that order.
+const canarySampleSQL = `SELECT
+  (SELECT COALESCE(sum(xact_rollback + conflicts + deadlocks), 0) FROM pg_catalog.pg_stat_database),
+  COALESCE(pg_catalog.pg_wal_lsn_diff(pg_catalog.pg_last_wal_receive_lsn(), pg_catalog.pg_last_wal_replay_lsn()), 0);`
+
+// canarySample is the health of a canary replica at one time.
+type canarySample struct {
+	Time  time.Time
+	Ready bool
+
+	// Errors is the number of rollbacks, conflicts, and deadlocks since
+	// statistics were reset.
+	Errors int64
+
+	// ReplayBytes is how far replay is behind the WAL the replica received.
+	ReplayBytes int64
+}
+
+// parseCanarySample reads the output of [canarySampleSQL] into sample.
+func parseCanarySample(sample *canarySample, stdout string) error {
+	fields := strings.Split(strings.TrimSpace(stdout), "\t")
+	if len(fields) != 2 {
+		return fmt.Errorf("unexpected output: %q", stdout)
+	}
+	errs, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return fmt.Errorf("unexpected output: %q", stdout)
+	}
+	replay, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return fmt.Errorf("unexpected output: %q", stdout)
+	}
+	sample.Errors, sample.ReplayBytes = int64(errs), int64(replay)
+	return nil
+}
+
+// canaryRegression compares current to the samples taken before and at the
+// start of a canary. It returns why current is worse, or empty when it is not.
+// Errors are a regression when their rate doubles, plus one each minute so
+// that a quiet replica is not failed by a single rollback. Replay is a
+// regression when it falls more than one WAL segment behind and twice as far
+// behind as at the start.
+func canaryRegression(before, start, current canarySample) string {
+	if !current.Ready {
+		return "replica is not ready"
+	}
+
+	rate := func(from, to canarySample) float64 {
+		if seconds := to.Time.Sub(from.Time).Seconds(); seconds > 0 {
+			return float64(to.Errors-from.Errors) / seconds
+		}
+		return 0
+	}
+	if baseline, observed := rate(before, start), rate(start, current); observed > 2*baseline+1.0/60 {
+		return fmt.Sprintf("errors increased from %.2f/s to %.2f/s", baseline, observed)
+	}
+
+	const segment = 16 << 20
+	if current.ReplayBytes > segment && current.ReplayBytes > 2*start.ReplayBytes {
+		return fmt.Sprintf("replay fell behind from %s to %s",
+			formatBytes(start.ReplayBytes), formatBytes(current.ReplayBytes))
+	}
+	return ""
+}
+
+// runParameterCanary sets parameters on one ready replica of the cluster and
+// watches it for observe. It returns a function that resets the replica
+// after the cluster is changed, or an error after resetting the replica when
+// it regressed.
+func runParameterCanary(
+	ctx context.Context, cmd *cobra.Command, config *internal.Config,
+	namespace, clusterName string, parameters map[string]string, observe time.Duration,
+) (func() error, error) {
+	rest, err := config.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := v1.NewForConfig(rest)
+	if err != nil {
+		return nil, err
+	}
+	podExec, err := util.NewPodExecutor(rest)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: util.ReplicaInstanceLabels(clusterName),
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(pods.Items, func(i, j int) bool { return pods.Items[i].Name < pods.Items[j].Name })
+
+	var podName string
+	for i := range pods.Items {
+		if podIsReady(&pods.Items[i]) {
+			podName = pods.Items[i].Name
+			break
+		}
+	}
+	if podName == "" {
+		return nil, fmt.Errorf("postgresclusters/%s has no ready replica for --canary", clusterName)
+	}
+
+	exec := podexec.Container(podExec, namespace, podName, util.ContainerDatabase)
+	psql := func(sql string) (string, error) {
+		stdout, stderr, err := podexec.PSQL(exec, "", sql)
+		return stdout, commandError(err, stderr)
+	}
+	sample := func() (canarySample, error) {
+		s := canarySample{Time: time.Now()}
+		pod, err := client.Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+		if err != nil {
+			return s, err
+		}
+		if s.Ready = podIsReady(pod) && pod.GetLabels()[util.LabelRole] == util.RolePatroniReplica; !s.Ready {
+			return s, nil
+		}
+		stdout, err := psql(canarySampleSQL)
+		if err == nil {
+			err = parseCanarySample(&s, stdout)
+		}
+		return s, err
+	}
+	reset := func() error {
+		if _, err := psql(canaryResetSQL(parameters)); err != nil {
+			return fmt.Errorf("unable to reset parameters on %s: %w", podName, err)
+		}
+		return nil
+	}
+
+	if stdout, err := psql(canaryRestartSQL(parameters)); err != nil {
+		return nil, err
+	} else if restart := strings.Fields(stdout); len(restart) > 0 {
+		return nil, fmt.Errorf("%s cannot be tried on a canary because Postgres must restart to change it",
+			strings.Join(restart, ", "))
+	}
+
+	before, err := sample()
+	if err != nil {
+		return nil, err
+	}
+	time.Sleep(canaryInterval)
+	start, err := sample()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd.Printf("Setting parameters on canary %s...", podName)
+	if _, err := psql(canaryChangeSQL(parameters)); err != nil {
+		cmd.Println(" failed")
+		return nil, errors.Join(err, reset())
+	}
+	cmd.Println(" done")
+
+	cmd.Printf("Observing %s for %s...", podName, observe)
+	for deadline := start.Time.Add(observe); time.Now().Before(deadline); {
+		time.Sleep(min(canaryInterval, time.Until(deadline)))
+
+		current, err := sample()
+		reason := canaryRegression(before, start, current)
+		if err != nil {
+			reason = err.Error()
+		}
+		if reason != "" {
+			cmd.Println(" regressed")
+			return nil, errors.Join(
+				fmt.Errorf("canary %s regressed: %s; parameters were reset and the cluster was not changed",
+					podName, reason), reset())
+		}
+	}
+	cmd.Println(" healthy")
+
+	return reset, nil
+}
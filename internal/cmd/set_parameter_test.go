@@ -0,0 +1,90 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseParameterArgs(t *testing.T) {
+	parameters, err := parseParameterArgs([]string{
+		"work_mem=64MB", "Search_Path=app, public", "pg_stat_statements.max=10000", "application_name=",
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, parameters, map[string]string{
+		"application_name":       "",
+		"pg_stat_statements.max": "10000",
+		"search_path":            "app, public",
+		"work_mem":               "64MB",
+	})
+
+	for _, arg := range []string{"work_mem", "=1", "work mem=1", "a;b=1", "a.b.c=1"} {
+		_, err := parseParameterArgs([]string{arg})
+		assert.ErrorContains(t, err, "is not NAME=VALUE", "%q", arg)
+	}
+
+	_, err = parseParameterArgs([]string{"work_mem=1MB", "WORK_MEM=2MB"})
+	assert.ErrorContains(t, err, `"work_mem" is given more than once`)
+}
+
+func TestCanarySQL(t *testing.T) {
+	parameters := map[string]string{"work_mem": "64MB", "search_path": "'$user', public"}
+
+	assert.Equal(t, canaryChangeSQL(parameters), `
+ALTER SYSTEM SET search_path = '''$user'', public';
+ALTER SYSTEM SET work_mem = '64MB';
+SELECT pg_catalog.pg_reload_conf();`[1:])
+
+	assert.Equal(t, canaryResetSQL(parameters), `
+ALTER SYSTEM RESET search_path;
+ALTER SYSTEM RESET work_mem;
+SELECT pg_catalog.pg_reload_conf();`[1:])
+
+	assert.Equal(t, canaryRestartSQL(parameters),
+		"SELECT name FROM pg_catalog.pg_settings WHERE context = 'postmaster'"+
+			" AND name IN ('search_path', 'work_mem') ORDER BY name;")
+}
+
+func TestParseCanarySample(t *testing.T) {
+	var sample canarySample
+	assert.NilError(t, parseCanarySample(&sample, "42\t8192\n"))
+	assert.Equal(t, sample.Errors, int64(42))
+	assert.Equal(t, sample.ReplayBytes, int64(8192))
+
+	assert.ErrorContains(t, parseCanarySample(&sample, "42\n"), "unexpected output")
+	assert.ErrorContains(t, parseCanarySample(&sample, "x\t0\n"), "unexpected output")
+}
+
+func TestCanaryRegression(t *testing.T) {
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	sample := func(offset time.Duration, errors, replay int64) canarySample {
+		return canarySample{Time: now.Add(offset), Ready: true, Errors: errors, ReplayBytes: replay}
+	}
+
+	// One error each second before the canary started.
+	before, start := sample(-10*time.Second, 100, 0), sample(0, 110, 1<<20)
+
+	assert.Equal(t, canaryRegression(before, start, sample(time.Minute, 170, 1<<20)), "")
+	assert.Equal(t, canaryRegression(before, start, sample(time.Minute, 220, 1<<20)), "")
+	assert.Equal(t, canaryRegression(before, start, sample(time.Minute, 300, 1<<20)),
+		"errors increased from 1.00/s to 3.17/s")
+
+	// A quiet replica can have one error each minute.
+	quiet := sample(-10*time.Second, 110, 0)
+	assert.Equal(t, canaryRegression(quiet, start, sample(time.Minute, 111, 0)), "")
+	assert.Equal(t, canaryRegression(quiet, start, sample(time.Minute, 112, 0)),
+		"errors increased from 0.00/s to 0.03/s")
+
+	assert.Equal(t, canaryRegression(before, start, sample(time.Minute, 110, 12<<20)), "")
+	assert.Equal(t, canaryRegression(before, start, sample(time.Minute, 110, 20<<20)),
+		"replay fell behind from 1.0MiB to 20.0MiB")
+
+	notReady := sample(time.Minute, 110, 0)
+	notReady.Ready = false
+	assert.Equal(t, canaryRegression(before, start, notReady), "replica is not ready")
+}
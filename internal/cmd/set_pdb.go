@@ -0,0 +1,182 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+)
+
+// newSetPDBCommand returns the pdb subcommand of the set command. It changes
+// the minAvailable setting that PGO uses for its PodDisruptionBudgets.
+func newSetPDBCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pdb CLUSTER_NAME",
+		Short: "Set the PodDisruptionBudget minAvailable of a PostgresCluster",
+		Long: `Set "minAvailable" of every instance set and of pgBouncer, when it is enabled.
+PGO uses these values for the PodDisruptionBudgets that limit how many Pods
+of a PostgresCluster can be disrupted at once, during node drains and the like.
+Overwriting values set by others may require the --force-conflicts flag.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    postgresclusters.postgres-operator.crunchydata.com  [get patch]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Keep at least one Pod of each instance set of the 'hippo' postgrescluster available
+pgo set pdb hippo --min-available=1
+
+# Keep half of the Pods available
+pgo set pdb hippo --min-available=50%
+
+### Example output
+postgresclusters/hippo minAvailable set to 1`)
+
+	var pdb setPDBArgs
+	var minAvailable string
+	cmd.Flags().StringVar(&minAvailable, "min-available", "",
+		"number or percent of Pods that must remain available")
+	cobra.CheckErr(cmd.MarkFlagRequired("min-available"))
+	cmd.Flags().BoolVar(&pdb.ForceConflicts, "force-conflicts", false,
+		"take ownership and overwrite the minAvailable settings")
+	config.Record.AddFlags(cmd.Flags())
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		pdb.MinAvailable = intstr.Parse(minAvailable)
+		if minAvailable == "0" || minAvailable == "0%" {
+			cmd.PrintErrln("WARNING: A minAvailable of zero allows every Pod " +
+				"of the cluster to be disrupted at the same time.")
+		}
+
+		mapping, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		cluster, err := client.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		intent := new(unstructured.Unstructured)
+		if err := internal.ExtractFieldsInto(cluster, intent, config.Patch.FieldManager); err != nil {
+			return err
+		}
+		if err := pdb.modifyIntent(cluster, intent); err != nil {
+			return err
+		}
+		if err := checkClusterChange(cmd.ErrOrStderr(), &config.Messages, cluster, intent); err != nil {
+			return err
+		}
+
+		// Save the change for later rather than sending it.
+		if config.Record.Enabled() {
+			change := internal.NewRecordedChange(internal.RecordApply,
+				mapping.Resource, namespace, args[0], intent)
+			change.Force = pdb.ForceConflicts
+			msg, err := recordChange(config, change)
+			cmd.Print(msg)
+			return err
+		}
+
+		patch, err := intent.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		patchOptions := metav1.PatchOptions{}
+		if pdb.ForceConflicts {
+			b := true
+			patchOptions.Force = &b
+		}
+
+		_, err = client.Namespace(namespace).Patch(ctx, args[0],
+			types.ApplyPatchType, patch, config.Patch.PatchOptions(patchOptions))
+		if err != nil {
+			if apierrors.IsConflict(err) {
+				cmd.PrintErrln("SUGGESTION: The --force-conflicts flag may help in performing this operation.")
+			}
+			return err
+		}
+
+		cmd.Printf("%s/%s minAvailable set to %s\n",
+			mapping.Resource.Resource, args[0], pdb.MinAvailable.String())
+		return nil
+	}
+
+	return cmd
+}
+
+type setPDBArgs struct {
+	ForceConflicts bool
+	MinAvailable   intstr.IntOrString
+}
+
+// modifyIntent sets minAvailable in intent for every instance set of cluster
+// and for pgBouncer when cluster has it.
+func (pdb setPDBArgs) modifyIntent(cluster, intent *unstructured.Unstructured) error {
+	var value any = pdb.MinAvailable.StrVal
+	if pdb.MinAvailable.Type == intstr.Int {
+		value = int64(pdb.MinAvailable.IntVal)
+	}
+
+	sets, _, err := unstructured.NestedSlice(cluster.Object, "spec", "instances")
+	if err != nil {
+		return err
+	}
+	if len(sets) == 0 {
+		return errors.New("cluster has no instance sets")
+	}
+
+	// Instance sets are a list keyed by name. Keep any other fields this
+	// field manager already owns.
+	owned, _, _ := unstructured.NestedSlice(intent.Object, "spec", "instances")
+	instances := make([]any, 0, len(sets))
+	for _, set := range sets {
+		name, _, _ := unstructured.NestedString(set.(map[string]any), "name")
+
+		instance := map[string]any{"name": name}
+		for _, item := range owned {
+			if item, ok := item.(map[string]any); ok && item["name"] == name {
+				instance = item
+			}
+		}
+		instance["minAvailable"] = value
+		instances = append(instances, instance)
+	}
+	if err := unstructured.SetNestedSlice(intent.Object, instances, "spec", "instances"); err != nil {
+		return err
+	}
+
+	if _, found, _ := unstructured.NestedMap(cluster.Object,
+		"spec", "proxy", "pgBouncer"); found {
+		if err := unstructured.SetNestedField(intent.Object, value,
+			"spec", "proxy", "pgBouncer", "minAvailable"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
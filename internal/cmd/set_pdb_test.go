@@ -0,0 +1,108 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crunchydata/postgres-operator-client/internal/testing/cmp"
+)
+
+func TestSetPDBArgsModifyIntent(t *testing.T) {
+	for _, tt := range []struct {
+		Name, Cluster, Before, After string
+		PDB                          setPDBArgs
+	}{
+		{
+			Name: "Instances",
+			Cluster: strings.TrimSpace(`
+spec:
+  instances:
+  - name: one
+    replicas: 2
+  - name: two
+			`),
+			PDB: setPDBArgs{MinAvailable: intstr.FromInt(1)},
+			After: strings.TrimSpace(`
+spec:
+  instances:
+  - minAvailable: 1
+    name: one
+  - minAvailable: 1
+    name: two
+			`),
+		},
+		{
+			Name: "PGBouncer",
+			Cluster: strings.TrimSpace(`
+spec:
+  instances:
+  - name: one
+  proxy:
+    pgBouncer:
+      replicas: 2
+			`),
+			PDB: setPDBArgs{MinAvailable: intstr.FromString("50%")},
+			After: strings.TrimSpace(`
+spec:
+  instances:
+  - minAvailable: 50%
+    name: one
+  proxy:
+    pgBouncer:
+      minAvailable: 50%
+			`),
+		},
+		{
+			Name: "KeepOwnedFields",
+			Cluster: strings.TrimSpace(`
+spec:
+  instances:
+  - name: one
+    replicas: 3
+			`),
+			Before: strings.TrimSpace(`
+spec:
+  instances:
+  - name: one
+    replicas: 3
+  shutdown: false
+			`),
+			PDB: setPDBArgs{MinAvailable: intstr.FromInt(2)},
+			After: strings.TrimSpace(`
+spec:
+  instances:
+  - minAvailable: 2
+    name: one
+    replicas: 3
+  shutdown: false
+			`),
+		},
+	} {
+		t.Run(tt.Name, func(t *testing.T) {
+			var cluster, intent unstructured.Unstructured
+			assert.NilError(t, yaml.Unmarshal([]byte(tt.Cluster), &cluster.Object))
+			assert.NilError(t, yaml.Unmarshal([]byte(tt.Before), &intent.Object))
+			if intent.Object == nil {
+				intent.Object = map[string]any{}
+			}
+
+			assert.NilError(t, tt.PDB.modifyIntent(&cluster, &intent))
+			assert.Assert(t, cmp.MarshalMatches(intent.Object, tt.After))
+		})
+	}
+
+	t.Run("NoInstances", func(t *testing.T) {
+		cluster := unstructured.Unstructured{Object: map[string]any{}}
+		intent := unstructured.Unstructured{Object: map[string]any{}}
+		assert.ErrorContains(t, setPDBArgs{}.modifyIntent(&cluster, &intent), "no instance sets")
+	})
+}
@@ -0,0 +1,433 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newSetPGBouncerCommand returns the pgbouncer subcommand of the set command.
+// It changes the connection pool settings of pgBouncer.
+func newSetPGBouncerCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pgbouncer CLUSTER_NAME",
+		Short: "Set the connection pool settings of pgBouncer",
+		Long: `Set connection pool settings in "spec.proxy.pgBouncer.config.global" of a
+PostgresCluster. Only the settings of the flags given are changed. Overwriting
+values set by others may require the --force-conflicts flag.
+
+After the change, this waits for every pgBouncer Pod to load the new settings
+and prints them. Use "--timeout=0" to not wait.
+
+Every pgBouncer Pod reads the same settings, so a change cannot be tried on
+one Pod first. Instead, "--observe" watches every pgBouncer Pod for a while
+after the change. When one stops being ready or restarts, the settings this
+command owned before the change are applied again.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    pods                                                [list]
+    pods/exec                                           [create]
+    postgresclusters.postgres-operator.crunchydata.com  [get patch]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Use transaction pooling with 20 server connections per pool
+pgo set pgbouncer hippo --pool-mode=transaction --default-pool-size=20
+
+# Allow more clients to connect
+pgo set pgbouncer hippo --max-client-conn=500
+
+# Revert the change when pgBouncer fails within ten minutes
+pgo set pgbouncer hippo --pool-mode=transaction --observe=10m
+
+### Example output
+postgresclusters/hippo pgBouncer settings updated
+Waiting for pgBouncer to reload... done
+POD                              SETTING            VALUE
+hippo-pgbouncer-6b4f9c7d5-x2kqp  default_pool_size  20
+hippo-pgbouncer-6b4f9c7d5-x2kqp  pool_mode          transaction`)
+
+	var bouncer setPGBouncerArgs
+	var defaultPoolSize, maxClientConn int
+	var poolMode string
+	var observe, timeout time.Duration
+	cmd.Flags().IntVar(&defaultPoolSize, "default-pool-size", 0,
+		"server connections to allow per user and database")
+	cmd.Flags().IntVar(&maxClientConn, "max-client-conn", 0,
+		"client connections to allow in total")
+	cmd.Flags().StringVar(&poolMode, "pool-mode", "",
+		"when a server connection is returned to the pool. types supported: session,transaction,statement")
+	cmd.Flags().DurationVar(&timeout, "timeout", 2*time.Minute,
+		"how long to wait for pgBouncer to load the settings")
+	cmd.Flags().DurationVar(&observe, "observe", 0,
+		"how long to watch pgBouncer after the change, reverting it when a Pod fails")
+	cmd.Flags().BoolVar(&bouncer.ForceConflicts, "force-conflicts", false,
+		"take ownership and overwrite the pgBouncer settings")
+	config.Record.AddFlags(cmd.Flags())
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		bouncer.Settings = map[string]string{}
+		if cmd.Flags().Changed("default-pool-size") {
+			bouncer.Settings["default_pool_size"] = strconv.Itoa(defaultPoolSize)
+		}
+		if cmd.Flags().Changed("max-client-conn") {
+			bouncer.Settings["max_client_conn"] = strconv.Itoa(maxClientConn)
+		}
+		if cmd.Flags().Changed("pool-mode") {
+			bouncer.Settings["pool_mode"] = poolMode
+		}
+		if err := bouncer.validate(); err != nil {
+			return err
+		}
+		if observe > 0 && config.Record.Enabled() {
+			return errors.New("--observe cannot be used when recording a change")
+		}
+
+		mapping, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		cluster, err := client.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if _, found, _ := unstructured.NestedMap(cluster.Object,
+			"spec", "proxy", "pgBouncer"); !found {
+			return fmt.Errorf("postgresclusters/%s does not have pgBouncer enabled", args[0])
+		}
+
+		intent := new(unstructured.Unstructured)
+		if err := internal.ExtractFieldsInto(cluster, intent, config.Patch.FieldManager); err != nil {
+			return err
+		}
+		previous := intent.DeepCopy()
+		if err := bouncer.modifyIntent(intent); err != nil {
+			return err
+		}
+		if err := checkClusterChange(cmd.ErrOrStderr(), &config.Messages, cluster, intent); err != nil {
+			return err
+		}
+
+		// Save the change for later rather than sending it.
+		if config.Record.Enabled() {
+			change := internal.NewRecordedChange(internal.RecordApply,
+				mapping.Resource, namespace, args[0], intent)
+			change.Force = bouncer.ForceConflicts
+			msg, err := recordChange(config, change)
+			cmd.Print(msg)
+			return err
+		}
+
+		patch, err := intent.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		patchOptions := metav1.PatchOptions{}
+		if bouncer.ForceConflicts {
+			b := true
+			patchOptions.Force = &b
+		}
+
+		_, err = client.Namespace(namespace).Patch(ctx, args[0],
+			types.ApplyPatchType, patch, config.Patch.PatchOptions(patchOptions))
+		if err != nil {
+			if apierrors.IsConflict(err) {
+				cmd.PrintErrln("SUGGESTION: The --force-conflicts flag may help in performing this operation.")
+			}
+			return err
+		}
+
+		cmd.Printf("%s/%s pgBouncer settings updated\n", mapping.Resource.Resource, args[0])
+
+		if timeout > 0 {
+			if err := waitForPGBouncerSettings(ctx, cmd, config,
+				namespace, args[0], bouncer.Settings, timeout); err != nil {
+				return err
+			}
+		}
+		if observe <= 0 {
+			return nil
+		}
+
+		reason, err := observePGBouncer(ctx, cmd, config, namespace, args[0], observe)
+		if err != nil || reason == "" {
+			return err
+		}
+
+		patch, err = previous.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		_, err = client.Namespace(namespace).Patch(ctx, args[0],
+			types.ApplyPatchType, patch, config.Patch.PatchOptions(patchOptions))
+		if err != nil {
+			return fmt.Errorf("pgBouncer regressed: %s; unable to revert the settings: %w", reason, err)
+		}
+		return fmt.Errorf("pgBouncer regressed: %s; the previous settings were applied again", reason)
+	}
+
+	return cmd
+}
+
+type setPGBouncerArgs struct {
+	ForceConflicts bool
+
+	// Settings are pgBouncer settings by name.
+	Settings map[string]string
+}
+
+// validate returns an error when a setting has a value pgBouncer rejects.
+func (bouncer setPGBouncerArgs) validate() error {
+	if len(bouncer.Settings) == 0 {
+		return errors.New("at least one of --default-pool-size, --max-client-conn, or --pool-mode is required")
+	}
+	for _, name := range []string{"default_pool_size", "max_client_conn"} {
+		if value, ok := bouncer.Settings[name]; ok {
+			if number, err := strconv.Atoi(value); err != nil || number < 1 {
+				return fmt.Errorf("--%s must be a positive number", strings.ReplaceAll(name, "_", "-"))
+			}
+		}
+	}
+	if mode, ok := bouncer.Settings["pool_mode"]; ok {
+		switch mode {
+		case "session", "transaction", "statement":
+		default:
+			return fmt.Errorf("--pool-mode must be one of session, transaction, or statement")
+		}
+	}
+	return nil
+}
+
+// modifyIntent sets the settings in the pgBouncer global config of intent.
+func (bouncer setPGBouncerArgs) modifyIntent(intent *unstructured.Unstructured) error {
+	for name, value := range bouncer.Settings {
+		if err := unstructured.SetNestedField(intent.Object, value,
+			"spec", "proxy", "pgBouncer", "config", "global", name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parsePGBouncerINI returns the settings of the [pgbouncer] section of the
+// configuration files in stdout. Later files override earlier ones, as they do
+// when pgBouncer follows its %include directives.
+func parsePGBouncerINI(stdout string) map[string]string {
+	settings := map[string]string{}
+
+	var section string
+	for _, line := range strings.Split(stdout, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "", line[0] == ';', line[0] == '#', line[0] == '%':
+		case line[0] == '[':
+			section = strings.Trim(line, "[]")
+		case section == "pgbouncer":
+			if name, value, ok := strings.Cut(line, "="); ok {
+				settings[strings.TrimSpace(name)] = strings.TrimSpace(value)
+			}
+		}
+	}
+
+	return settings
+}
+
+// pgbouncerRegression returns why pods are worse than when they had restarts,
+// or empty when they are not. Pods that were not there before are only
+// required to be ready.
+func pgbouncerRegression(restarts map[string]int32, pods []corev1.Pod) string {
+	if len(pods) == 0 {
+		return "there are no pgBouncer Pods"
+	}
+	for i := range pods {
+		pod := &pods[i]
+		if !podIsReady(pod) {
+			return pod.GetName() + " is not ready"
+		}
+		if before, ok := restarts[pod.GetName()]; ok && pgbouncerRestarts(pod) > before {
+			return pod.GetName() + " restarted"
+		}
+	}
+	return ""
+}
+
+// pgbouncerRestarts returns how many times the pgBouncer container of pod
+// has restarted.
+func pgbouncerRestarts(pod *corev1.Pod) int32 {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == util.ContainerPGBouncer {
+			return status.RestartCount
+		}
+	}
+	return 0
+}
+
+// observePGBouncer watches the pgBouncer Pods of the cluster for observe. It
+// returns why they regressed, or empty when they did not.
+func observePGBouncer(
+	ctx context.Context, cmd *cobra.Command, config *internal.Config,
+	namespace, clusterName string, observe time.Duration,
+) (string, error) {
+	rest, err := config.ToRESTConfig()
+	if err != nil {
+		return "", err
+	}
+	client, err := v1.NewForConfig(rest)
+	if err != nil {
+		return "", err
+	}
+	list := func() ([]corev1.Pod, error) {
+		pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.PGBouncerLabels(clusterName),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return pods.Items, nil
+	}
+
+	pods, err := list()
+	if err != nil {
+		return "", err
+	}
+	restarts := map[string]int32{}
+	for i := range pods {
+		restarts[pods[i].GetName()] = pgbouncerRestarts(&pods[i])
+	}
+
+	cmd.Printf("Observing pgBouncer for %s...", observe)
+	for deadline := time.Now().Add(observe); time.Now().Before(deadline); {
+		time.Sleep(min(canaryInterval, time.Until(deadline)))
+
+		if pods, err = list(); err != nil {
+			cmd.Println(" failed")
+			return "", err
+		}
+		if reason := pgbouncerRegression(restarts, pods); reason != "" {
+			cmd.Println(" regressed")
+			return reason, nil
+		}
+	}
+	cmd.Println(" healthy")
+	return "", nil
+}
+
+// waitForPGBouncerSettings waits until every pgBouncer Pod of the cluster has
+// settings in its configuration files, then prints them. PGO updates those
+// files and tells pgBouncer to reload them.
+func waitForPGBouncerSettings(
+	ctx context.Context, cmd *cobra.Command, config *internal.Config,
+	namespace, clusterName string, settings map[string]string, timeout time.Duration,
+) error {
+	rest, err := config.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+	client, err := v1.NewForConfig(rest)
+	if err != nil {
+		return err
+	}
+	podExec, err := util.NewPodExecutor(rest)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	cmd.PrintErr("Waiting for pgBouncer to reload...")
+	deadline := time.Now().Add(timeout)
+	var loaded map[string]map[string]string
+
+	for {
+		loaded = map[string]map[string]string{}
+		pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.PGBouncerLabels(clusterName),
+		})
+		if err != nil {
+			return err
+		}
+
+		pending := len(pods.Items) == 0
+		for _, pod := range pods.Items {
+			var stdout, stderr bytes.Buffer
+			exec := podexec.Container(podExec, namespace, pod.GetName(), util.ContainerPGBouncer)
+			if err := exec.Exec(nil, &stdout, &stderr, "sh", "-c", "cat /etc/pgbouncer/*.ini"); err != nil {
+				pending = true
+				continue
+			}
+
+			current := parsePGBouncerINI(stdout.String())
+			loaded[pod.GetName()] = current
+			for _, name := range names {
+				if current[name] != settings[name] {
+					pending = true
+				}
+			}
+		}
+
+		if !pending {
+			break
+		}
+		if time.Now().After(deadline) {
+			cmd.PrintErrln(" timed out")
+			cmd.PrintErrln("WARNING: Not every pgBouncer Pod has the new settings yet.")
+			return nil
+		}
+		time.Sleep(5 * time.Second)
+	}
+	cmd.PrintErrln(" done")
+
+	pods := make([]string, 0, len(loaded))
+	for pod := range loaded {
+		pods = append(pods, pod)
+	}
+	sort.Strings(pods)
+
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "POD\tSETTING\tVALUE")
+	for _, pod := range pods {
+		for _, name := range names {
+			_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\n", pod, name, loaded[pod][name])
+		}
+	}
+	return writer.Flush()
+}
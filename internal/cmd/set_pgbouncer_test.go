@@ -0,0 +1,132 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crunchydata/postgres-operator-client/internal/testing/cmp"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+func TestSetPGBouncerArgsValidate(t *testing.T) {
+	for _, tt := range []struct {
+		Name     string
+		Settings map[string]string
+		Error    string
+	}{
+		{Name: "Empty", Error: "at least one"},
+		{Name: "Valid", Settings: map[string]string{
+			"default_pool_size": "20", "max_client_conn": "500", "pool_mode": "transaction",
+		}},
+		{Name: "PoolSize", Error: "--default-pool-size must be a positive number",
+			Settings: map[string]string{"default_pool_size": "0"}},
+		{Name: "ClientConn", Error: "--max-client-conn must be a positive number",
+			Settings: map[string]string{"max_client_conn": "-5"}},
+		{Name: "PoolMode", Error: "--pool-mode must be one of",
+			Settings: map[string]string{"pool_mode": "sometimes"}},
+	} {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := setPGBouncerArgs{Settings: tt.Settings}.validate()
+			if tt.Error == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.Error)
+			}
+		})
+	}
+}
+
+func TestSetPGBouncerArgsModifyIntent(t *testing.T) {
+	intent := new(unstructured.Unstructured)
+	assert.NilError(t, yaml.Unmarshal([]byte(strings.TrimSpace(`
+spec:
+  proxy:
+    pgBouncer:
+      config:
+        global:
+          pool_mode: session
+          server_idle_timeout: "60"
+	`)), &intent.Object))
+
+	bouncer := setPGBouncerArgs{Settings: map[string]string{
+		"default_pool_size": "20", "pool_mode": "transaction",
+	}}
+	assert.NilError(t, bouncer.modifyIntent(intent))
+	assert.Assert(t, cmp.MarshalMatches(intent.Object, strings.TrimSpace(`
+spec:
+  proxy:
+    pgBouncer:
+      config:
+        global:
+          default_pool_size: "20"
+          pool_mode: transaction
+          server_idle_timeout: "60"
+	`)+"\n"))
+}
+
+func TestParsePGBouncerINI(t *testing.T) {
+	settings := parsePGBouncerINI(`
+# Generated by postgres-operator. DO NOT EDIT.
+[pgbouncer]
+%include /etc/pgbouncer/pgbouncer.ini
+pool_mode = session
+listen_port = 5432
+
+[databases]
+* = host=hippo-primary port=5432
+
+[pgbouncer]
+; these come from spec.proxy.pgBouncer.config.global
+pool_mode = transaction
+default_pool_size=20
+`)
+
+	assert.DeepEqual(t, settings, map[string]string{
+		"default_pool_size": "20",
+		"listen_port":       "5432",
+		"pool_mode":         "transaction",
+	})
+}
+
+func TestPGBouncerRegression(t *testing.T) {
+	pod := func(name string, ready corev1.ConditionStatus, restarts int32) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}},
+				ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "pgbouncer-config", RestartCount: 9},
+					{Name: util.ContainerPGBouncer, RestartCount: restarts},
+				},
+			},
+		}
+	}
+	restarts := map[string]int32{"a": 1, "b": 0}
+
+	assert.Equal(t, pgbouncerRegression(restarts, nil), "there are no pgBouncer Pods")
+	assert.Equal(t, pgbouncerRegression(restarts, []corev1.Pod{
+		pod("a", corev1.ConditionTrue, 1), pod("b", corev1.ConditionTrue, 0),
+	}), "")
+
+	// Replaced Pods need only be ready.
+	assert.Equal(t, pgbouncerRegression(restarts, []corev1.Pod{
+		pod("a", corev1.ConditionTrue, 1), pod("c", corev1.ConditionTrue, 3),
+	}), "")
+
+	assert.Equal(t, pgbouncerRegression(restarts, []corev1.Pod{
+		pod("a", corev1.ConditionTrue, 2), pod("b", corev1.ConditionTrue, 0),
+	}), "a restarted")
+	assert.Equal(t, pgbouncerRegression(restarts, []corev1.Pod{
+		pod("a", corev1.ConditionTrue, 1), pod("b", corev1.ConditionFalse, 0),
+	}), "b is not ready")
+}
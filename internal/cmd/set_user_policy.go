@@ -0,0 +1,268 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newSetUserPolicyCommand returns the user-policy subcommand of the set
+// command. It limits the connections and statements of one Postgres user.
+func newSetUserPolicyCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "user-policy CLUSTER_NAME",
+		Short: "Limit the connections and statements of a Postgres user",
+		Long: `Set the connection limit and timeouts of one Postgres user with ALTER ROLE on
+the primary. Only the settings of the flags given are changed. A timeout of
+zero resets it to the default of the cluster, and a connection limit of -1
+removes the limit.
+
+Postgres keeps these settings in its catalog rather than in the spec, so they
+are also recorded in the "postgres-operator.crunchydata.com/pgo-user-policies"
+annotation of the PostgresCluster. "pgo check user-policy" compares Postgres
+with that annotation to find changes made by others.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    pods                                                [list]
+    pods/exec                                           [create]
+    postgresclusters.postgres-operator.crunchydata.com  [get patch]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Allow the 'app' user 50 connections and statements of 30 seconds
+pgo set user-policy hippo --user=app --connection-limit=50 --statement-timeout=30s
+
+# Remove the statement timeout
+pgo set user-policy hippo --user=app --statement-timeout=0
+
+### Example output
+app: connection limit 50, statement_timeout 30000ms
+postgresclusters/hippo user policy recorded`)
+
+	var user string
+	var connectionLimit int64
+	var statementTimeout, idleTimeout time.Duration
+	var forceConflicts bool
+	cmd.Flags().StringVar(&user, "user", "", "name of the Postgres user")
+	cobra.CheckErr(cmd.MarkFlagRequired("user"))
+	cmd.Flags().Int64Var(&connectionLimit, "connection-limit", 0,
+		"connections the user can have at once; -1 for no limit")
+	cmd.Flags().DurationVar(&statementTimeout, "statement-timeout", 0,
+		"how long a statement of the user can run")
+	cmd.Flags().DurationVar(&idleTimeout, "idle-in-transaction-timeout", 0,
+		"how long a session of the user can be idle in a transaction")
+	cmd.Flags().BoolVar(&forceConflicts, "force-conflicts", false,
+		"take ownership and overwrite the user policies annotation")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		// An empty setting is one to reset.
+		change := userPolicy{Settings: map[string]string{}}
+		if cmd.Flags().Changed("connection-limit") {
+			if connectionLimit < -1 {
+				return errors.New("--connection-limit must be -1 or more")
+			}
+			change.ConnectionLimit = &connectionLimit
+		}
+		for flag, setting := range map[string]struct {
+			Name  string
+			Value time.Duration
+		}{
+			"statement-timeout":           {"statement_timeout", statementTimeout},
+			"idle-in-transaction-timeout": {"idle_in_transaction_session_timeout", idleTimeout},
+		} {
+			if !cmd.Flags().Changed(flag) {
+				continue
+			}
+			if setting.Value < 0 {
+				return fmt.Errorf("--%s must not be negative", flag)
+			}
+			change.Settings[setting.Name] = ""
+			if setting.Value > 0 {
+				change.Settings[setting.Name] = fmt.Sprintf("%dms", setting.Value.Milliseconds())
+			}
+		}
+		if change.ConnectionLimit == nil && len(change.Settings) == 0 {
+			return errors.New("at least one of --connection-limit, --statement-timeout, or --idle-in-transaction-timeout is required")
+		}
+
+		mapping, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		cluster, err := client.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		policies, err := parseUserPolicies(cluster)
+		if err != nil {
+			return err
+		}
+
+		exec, err := getPrimaryExec(config, args)
+		if err != nil {
+			return err
+		}
+		if _, stderr, err := podexec.PSQL(exec, "", userPolicySQL(user, change)); err != nil {
+			return commandError(err, stderr)
+		}
+		cmd.Printf("%s: %s\n", user, change)
+
+		policies[user] = policies[user].merge(change)
+		b, err := json.Marshal(policies)
+		if err != nil {
+			return err
+		}
+
+		intent := new(unstructured.Unstructured)
+		if err := internal.ExtractFieldsInto(cluster, intent, config.Patch.FieldManager); err != nil {
+			return err
+		}
+		intent.SetAnnotations(internal.MergeStringMaps(
+			intent.GetAnnotations(), map[string]string{
+				util.AnnotationUserPolicies: string(b),
+			}))
+
+		patch, err := intent.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		patchOptions := metav1.PatchOptions{}
+		if forceConflicts {
+			b := true
+			patchOptions.Force = &b
+		}
+
+		_, err = client.Namespace(namespace).Patch(ctx, args[0],
+			types.ApplyPatchType, patch, config.Patch.PatchOptions(patchOptions))
+		if err != nil {
+			cmd.PrintErrln("WARNING: The user was changed but the policy was not recorded.")
+			if apierrors.IsConflict(err) {
+				cmd.PrintErrln("SUGGESTION: The --force-conflicts flag may help in performing this operation.")
+			}
+			return err
+		}
+
+		cmd.Printf("%s/%s user policy recorded\n", mapping.Resource.Resource, args[0])
+		return nil
+	}
+
+	return cmd
+}
+
+// userPolicy is what "set user-policy" applied to one Postgres user.
+type userPolicy struct {
+	ConnectionLimit *int64 `json:"connectionLimit,omitempty"`
+
+	// Settings are the values of role settings by name.
+	Settings map[string]string `json:"settings,omitempty"`
+}
+
+// String describes policy on one line.
+func (policy userPolicy) String() string {
+	var parts []string
+	if policy.ConnectionLimit != nil {
+		parts = append(parts, fmt.Sprintf("connection limit %d", *policy.ConnectionLimit))
+	}
+	for _, name := range sortedKeys(policy.Settings) {
+		if value := policy.Settings[name]; value == "" {
+			parts = append(parts, name+" reset")
+		} else {
+			parts = append(parts, name+" "+value)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// merge returns policy with the changes in change. Settings that change
+// resets are removed.
+func (policy userPolicy) merge(change userPolicy) userPolicy {
+	merged := userPolicy{ConnectionLimit: policy.ConnectionLimit}
+	if change.ConnectionLimit != nil {
+		merged.ConnectionLimit = change.ConnectionLimit
+	}
+	for name, value := range policy.Settings {
+		if merged.Settings == nil {
+			merged.Settings = map[string]string{}
+		}
+		merged.Settings[name] = value
+	}
+	for name, value := range change.Settings {
+		if value == "" {
+			delete(merged.Settings, name)
+			continue
+		}
+		if merged.Settings == nil {
+			merged.Settings = map[string]string{}
+		}
+		merged.Settings[name] = value
+	}
+	if len(merged.Settings) == 0 {
+		merged.Settings = nil
+	}
+	return merged
+}
+
+// parseUserPolicies returns the policies recorded in the annotation of
+// cluster by user.
+func parseUserPolicies(cluster *unstructured.Unstructured) (map[string]userPolicy, error) {
+	policies := map[string]userPolicy{}
+	if value := cluster.GetAnnotations()[util.AnnotationUserPolicies]; value != "" {
+		if err := json.Unmarshal([]byte(value), &policies); err != nil {
+			return nil, fmt.Errorf("unable to read the %s annotation: %w", util.AnnotationUserPolicies, err)
+		}
+	}
+	return policies, nil
+}
+
+// quoteLiteral returns value as a string literal for Postgres.
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// userPolicySQL returns the statements that apply change to user.
+func userPolicySQL(user string, change userPolicy) string {
+	role := quoteIdentifier(user)
+
+	var sql strings.Builder
+	if change.ConnectionLimit != nil {
+		fmt.Fprintf(&sql, "ALTER ROLE %s CONNECTION LIMIT %d;\n", role, *change.ConnectionLimit)
+	}
+	for _, name := range sortedKeys(change.Settings) {
+		if value := change.Settings[name]; value == "" {
+			fmt.Fprintf(&sql, "ALTER ROLE %s RESET %s;\n", role, name)
+		} else {
+			fmt.Fprintf(&sql, "ALTER ROLE %s SET %s = %s;\n", role, name, quoteLiteral(value))
+		}
+	}
+	return sql.String()
+}
@@ -0,0 +1,71 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+func TestUserPolicySQL(t *testing.T) {
+	limit := int64(50)
+	assert.Equal(t, userPolicySQL(`app"s`, userPolicy{
+		ConnectionLimit: &limit,
+		Settings: map[string]string{
+			"statement_timeout":                   "30000ms",
+			"idle_in_transaction_session_timeout": "",
+		},
+	}), `
+ALTER ROLE "app""s" CONNECTION LIMIT 50;
+ALTER ROLE "app""s" RESET idle_in_transaction_session_timeout;
+ALTER ROLE "app""s" SET statement_timeout = '30000ms';
+`[1:])
+}
+
+func TestUserPolicyMerge(t *testing.T) {
+	fifty, ten := int64(50), int64(10)
+	policy := userPolicy{ConnectionLimit: &fifty, Settings: map[string]string{
+		"statement_timeout": "30000ms",
+	}}
+
+	merged := policy.merge(userPolicy{Settings: map[string]string{
+		"idle_in_transaction_session_timeout": "60000ms",
+	}})
+	assert.DeepEqual(t, merged, userPolicy{ConnectionLimit: &fifty, Settings: map[string]string{
+		"idle_in_transaction_session_timeout": "60000ms",
+		"statement_timeout":                   "30000ms",
+	}})
+	assert.Equal(t, merged.String(),
+		"connection limit 50, idle_in_transaction_session_timeout 60000ms, statement_timeout 30000ms")
+
+	merged = policy.merge(userPolicy{ConnectionLimit: &ten, Settings: map[string]string{"statement_timeout": ""}})
+	assert.DeepEqual(t, merged, userPolicy{ConnectionLimit: &ten})
+	assert.Equal(t, userPolicy{Settings: map[string]string{"statement_timeout": ""}}.String(),
+		"statement_timeout reset")
+
+	// The policy of a new user is empty.
+	assert.DeepEqual(t, userPolicy{}.merge(userPolicy{ConnectionLimit: &ten}), userPolicy{ConnectionLimit: &ten})
+}
+
+func TestParseUserPolicies(t *testing.T) {
+	cluster := &unstructured.Unstructured{Object: map[string]any{}}
+	policies, err := parseUserPolicies(cluster)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, policies, map[string]userPolicy{})
+
+	limit := int64(5)
+	cluster.SetAnnotations(map[string]string{util.AnnotationUserPolicies: `{"app":{"connectionLimit":5}}`})
+	policies, err = parseUserPolicies(cluster)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, policies, map[string]userPolicy{"app": {ConnectionLimit: &limit}})
+
+	cluster.SetAnnotations(map[string]string{util.AnnotationUserPolicies: `[]`})
+	_, err = parseUserPolicies(cluster)
+	assert.ErrorContains(t, err, "unable to read the postgres-operator.crunchydata.com/pgo-user-policies annotation")
+}
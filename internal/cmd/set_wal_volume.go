@@ -0,0 +1,249 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+)
+
+// newSetWALVolumeCommand returns the wal-volume subcommand of the set command.
+// It gives instance sets a dedicated volume for pg_wal or resizes that volume.
+func newSetWALVolumeCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "wal-volume CLUSTER_NAME",
+		Short: "Add or resize the WAL volume of a PostgresCluster",
+		Long: `Set "walVolumeClaimSpec" of every instance set, or of the one chosen by
+"--instance-set". Without a WAL volume, pg_wal is on the data volume, and WAL
+that cannot be archived or is held by a replication slot can fill it.
+
+Adding a WAL volume restarts the instances of the set while PGO moves pg_wal
+to the new volume, so it lists the instances that restart and whether the
+primary switches over, then asks to continue. Pass "--yes" to skip the question. Growing an existing WAL volume
+requires a storage class that allows volume expansion. Volumes cannot shrink,
+so a "--size" smaller than the current one is refused,
+and the storage class of an existing volume cannot change.
+Overwriting values set by others may require the --force-conflicts flag.
+
+See "pgo show wal-usage" for what is in pg_wal.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    pods                                                [list]
+    postgresclusters.postgres-operator.crunchydata.com  [get patch]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Give the instances of the 'hippo' postgrescluster a 20Gi WAL volume
+pgo set wal-volume hippo --size=20Gi --storage-class=fast
+
+# Grow the WAL volume of one instance set
+pgo set wal-volume hippo --instance-set=instance1 --size=40Gi
+
+### Example output
+WARNING: This change to postgrescluster hippo restarts, removes, or adds instances:
+POD                     SET        ROLE     IMPACT
+hippo-instance1-2d4n-0  instance1  replica  restarts while pg_wal moves to its new volume
+hippo-instance1-8x7m-0  instance1  primary  switches over, then restarts while pg_wal moves to its new volume
+switchover: yes; connections to the primary close and writes pause briefly
+Are you sure you want to continue? (yes/no): yes
+postgresclusters/hippo WAL volume of instance1 set to 20Gi`)
+
+	var wal setWALVolumeArgs
+	cmd.Flags().Var(&quantityFlag{&wal.Size}, "size", "size of the WAL volume, e.g. 20Gi")
+	cobra.CheckErr(cmd.MarkFlagRequired("size"))
+	cmd.Flags().StringVar(&wal.StorageClass, "storage-class", "",
+		"storage class of a new WAL volume; the default storage class when empty")
+	cmd.Flags().StringVar(&wal.InstanceSet, "instance-set", "",
+		"change only this instance set")
+	cmd.Flags().BoolVar(&wal.ForceConflicts, "force-conflicts", false,
+		"take ownership and overwrite the WAL volume settings")
+	cmd.Flags().BoolVarP(&wal.Yes, "yes", "y", false, "apply without asking for confirmation")
+	config.Record.AddFlags(cmd.Flags())
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		if wal.Size.Sign() <= 0 {
+			return fmt.Errorf("--size must be more than zero")
+		}
+
+		mapping, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		cluster, err := client.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		intent := new(unstructured.Unstructured)
+		if err := internal.ExtractFieldsInto(cluster, intent, config.Patch.FieldManager); err != nil {
+			return err
+		}
+		sets, _, err := wal.modifyIntent(cluster, intent)
+		if err != nil {
+			return err
+		}
+		if err := checkClusterChange(cmd.ErrOrStderr(), &config.Messages, cluster, intent); err != nil {
+			return err
+		}
+
+		// Save the change for later rather than sending it.
+		if config.Record.Enabled() {
+			change := internal.NewRecordedChange(internal.RecordApply,
+				mapping.Resource, namespace, args[0], intent)
+			change.Force = wal.ForceConflicts
+			msg, err := recordChange(config, change)
+			cmd.Print(msg)
+			return err
+		}
+
+		if err := previewClusterChange(ctx, cmd, config, cluster, mergeIntent(cluster, intent), wal.Yes); err != nil {
+			return err
+		}
+
+		patch, err := intent.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		patchOptions := metav1.PatchOptions{}
+		if wal.ForceConflicts {
+			b := true
+			patchOptions.Force = &b
+		}
+
+		_, err = client.Namespace(namespace).Patch(ctx, args[0],
+			types.ApplyPatchType, patch, config.Patch.PatchOptions(patchOptions))
+		if err != nil {
+			if apierrors.IsConflict(err) {
+				cmd.PrintErrln("SUGGESTION: The --force-conflicts flag may help in performing this operation.")
+			}
+			return err
+		}
+
+		cmd.Printf("%s/%s WAL volume of %s set to %s\n",
+			mapping.Resource.Resource, args[0], strings.Join(sets, ", "), wal.Size.String())
+		return nil
+	}
+
+	return cmd
+}
+
+type setWALVolumeArgs struct {
+	ForceConflicts bool
+	InstanceSet    string
+	Size           resource.Quantity
+	StorageClass   string
+	Yes            bool
+}
+
+// modifyIntent sets walVolumeClaimSpec in intent for the chosen instance sets
+// of cluster. It returns the names of those sets and of the ones that do not
+// have a WAL volume yet.
+func (wal setWALVolumeArgs) modifyIntent(cluster, intent *unstructured.Unstructured) (
+	sets, added []string, err error,
+) {
+	instances, _, err := unstructured.NestedSlice(cluster.Object, "spec", "instances")
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(instances) == 0 {
+		return nil, nil, fmt.Errorf("cluster has no instance sets")
+	}
+
+	// Instance sets are a list keyed by name. Keep any other fields and sets
+	// this field manager already owns.
+	owned, _, _ := unstructured.NestedSlice(intent.Object, "spec", "instances")
+	changed := make([]any, 0, len(instances))
+	for _, item := range instances {
+		set, _ := item.(map[string]any)
+		name, _, _ := unstructured.NestedString(set, "name")
+
+		var instance map[string]any
+		for _, item := range owned {
+			if item, ok := item.(map[string]any); ok && item["name"] == name {
+				instance = item
+			}
+		}
+		if wal.InstanceSet != "" && name != wal.InstanceSet {
+			if instance != nil {
+				changed = append(changed, instance)
+			}
+			continue
+		}
+		if instance == nil {
+			instance = map[string]any{"name": name}
+		}
+
+		current, found, _ := unstructured.NestedMap(set, "walVolumeClaimSpec")
+		class, _, _ := unstructured.NestedString(current, "storageClassName")
+		if found && wal.StorageClass != "" && class != wal.StorageClass {
+			return nil, nil, fmt.Errorf("the WAL volume of instance set %q uses storage class %q, which cannot change",
+				name, class)
+		}
+
+		if size, found, _ := unstructured.NestedString(current, "resources", "requests", "storage"); found {
+			size, err := resource.ParseQuantity(size)
+			if err != nil {
+				return nil, nil, fmt.Errorf("the WAL volume of instance set %q: %w", name, err)
+			}
+			if wal.Size.Cmp(size) < 0 {
+				return nil, nil, fmt.Errorf("the WAL volume of instance set %q is %s and cannot shrink to %s",
+					name, size.String(), wal.Size.String())
+			}
+		}
+
+		// PGO requires the access modes of a new volume. Keep those of an
+		// existing one.
+		claim := map[string]any{
+			"accessModes": []any{"ReadWriteOnce"},
+			"resources": map[string]any{
+				"requests": map[string]any{"storage": wal.Size.String()},
+			},
+		}
+		if modes, found, _ := unstructured.NestedSlice(current, "accessModes"); found {
+			claim["accessModes"] = modes
+		}
+		if class == "" {
+			class = wal.StorageClass
+		}
+		if class != "" {
+			claim["storageClassName"] = class
+		}
+		instance["walVolumeClaimSpec"] = claim
+
+		sets = append(sets, name)
+		if !found {
+			added = append(added, name)
+		}
+		changed = append(changed, instance)
+	}
+	if len(sets) == 0 {
+		return nil, nil, fmt.Errorf("instance set %q not found", wal.InstanceSet)
+	}
+
+	return sets, added, unstructured.SetNestedSlice(intent.Object, changed, "spec", "instances")
+}
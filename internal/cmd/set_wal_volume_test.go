@@ -0,0 +1,123 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crunchydata/postgres-operator-client/internal/testing/cmp"
+)
+
+func TestSetWALVolumeArgsModifyIntent(t *testing.T) {
+	cluster := strings.TrimSpace(`
+spec:
+  instances:
+  - name: one
+    walVolumeClaimSpec:
+      accessModes: [ReadWriteOncePod]
+      resources: {requests: {storage: 10Gi}}
+      storageClassName: fast
+  - name: two
+	`)
+
+	for _, tt := range []struct {
+		Name, Before, After string
+		Sets, Added         []string
+		WAL                 setWALVolumeArgs
+	}{
+		{
+			Name: "Every",
+			WAL:  setWALVolumeArgs{Size: resource.MustParse("20Gi"), StorageClass: "fast"},
+			Sets: []string{"one", "two"}, Added: []string{"two"},
+			After: strings.TrimSpace(`
+spec:
+  instances:
+  - name: one
+    walVolumeClaimSpec:
+      accessModes:
+      - ReadWriteOncePod
+      resources:
+        requests:
+          storage: 20Gi
+      storageClassName: fast
+  - name: two
+    walVolumeClaimSpec:
+      accessModes:
+      - ReadWriteOnce
+      resources:
+        requests:
+          storage: 20Gi
+      storageClassName: fast
+			`),
+		},
+		{
+			Name: "InstanceSet",
+			WAL:  setWALVolumeArgs{Size: resource.MustParse("5Gi"), InstanceSet: "two"},
+			Sets: []string{"two"}, Added: []string{"two"},
+			Before: strings.TrimSpace(`
+spec:
+  instances:
+  - name: one
+    replicas: 2
+			`),
+			After: strings.TrimSpace(`
+spec:
+  instances:
+  - name: one
+    replicas: 2
+  - name: two
+    walVolumeClaimSpec:
+      accessModes:
+      - ReadWriteOnce
+      resources:
+        requests:
+          storage: 5Gi
+			`),
+		},
+	} {
+		t.Run(tt.Name, func(t *testing.T) {
+			var current, intent unstructured.Unstructured
+			assert.NilError(t, yaml.Unmarshal([]byte(cluster), &current.Object))
+			assert.NilError(t, yaml.Unmarshal([]byte(tt.Before), &intent.Object))
+			if intent.Object == nil {
+				intent.Object = map[string]any{}
+			}
+
+			sets, added, err := tt.WAL.modifyIntent(&current, &intent)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, sets, tt.Sets)
+			assert.DeepEqual(t, added, tt.Added)
+			assert.Assert(t, cmp.MarshalMatches(intent.Object, tt.After))
+		})
+	}
+
+	t.Run("Errors", func(t *testing.T) {
+		var current unstructured.Unstructured
+		assert.NilError(t, yaml.Unmarshal([]byte(cluster), &current.Object))
+		intent := unstructured.Unstructured{Object: map[string]any{}}
+
+		_, _, err := setWALVolumeArgs{Size: resource.MustParse("1Gi"), InstanceSet: "three"}.
+			modifyIntent(&current, &intent)
+		assert.ErrorContains(t, err, `instance set "three" not found`)
+
+		_, _, err = setWALVolumeArgs{Size: resource.MustParse("1Gi"), StorageClass: "slow"}.
+			modifyIntent(&current, &intent)
+		assert.ErrorContains(t, err, `uses storage class "fast", which cannot change`)
+
+		_, _, err = setWALVolumeArgs{Size: resource.MustParse("5Gi")}.
+			modifyIntent(&current, &intent)
+		assert.ErrorContains(t, err, `instance set "one" is 10Gi and cannot shrink to 5Gi`)
+
+		empty := unstructured.Unstructured{Object: map[string]any{}}
+		_, _, err = setWALVolumeArgs{}.modifyIntent(&empty, &intent)
+		assert.ErrorContains(t, err, "no instance sets")
+	})
+}
@@ -7,11 +7,12 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
-	"io"
 	"os"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
@@ -19,6 +20,9 @@ import (
 	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
 
 	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/flags"
 	"github.com/crunchydata/postgres-operator-client/internal/util"
 )
 
@@ -30,20 +34,27 @@ func newShowCommand(config *internal.Config) *cobra.Command {
 		Use:   "show",
 		Short: "Show PostgresCluster details",
 		Long: `Show allows you to display particular details related to the PostgresCluster.
+Without a subcommand, it shows whether reconciliation of the cluster is paused
+and for how long, its backups, and its HA status.
 
 ### RBAC Requirements
-    Resources  Verbs
-    ---------  -----
-    pods       [list]
-    pods/exec  [create]
+    Resources                                           Verbs
+    ---------                                           -----
+    pods                                                [list]
+    pods/exec                                           [create]
+    postgresclusters.postgres-operator.crunchydata.com  [get]
 
 ### Usage`,
 	}
 
-	cmdShow.Example = internal.FormatExample(`# Show the backup and HA output of the 'hippo' postgrescluster
+	cmdShow.Example = internal.FormatExample(`# Show the reconciliation, backup, and HA output of the 'hippo' postgrescluster
 pgo show hippo
 
 ### Example output
+RECONCILE
+
+reconciliation paused for 2h, since 2024-05-01T10:00:00Z; resume with "pgo resume reconcile"
+
 BACKUP
 
 stanza: db
@@ -70,8 +81,24 @@ HA
 
 	cmdShow.AddCommand(
 		newShowBackupCommand(config),
+		newShowConnectCommand(config),
+		newShowCrashesCommand(config),
+		newShowEncryptionCommand(config),
+		newShowEndpointsCommand(config),
 		newShowHACommand(config),
+		newShowJobsCommand(config),
+		newShowOperatorLogsCommand(config),
+		newShowPGBackRestProcessesCommand(config),
+		newShowPGStatStatementsCommand(config),
+		newShowPGBouncerUsersCommand(config),
+		newShowPGUpgradePlanCommand(config),
+		newShowPGUpgradePreflightCommand(config),
+		newShowReplicationSlotsCommand(config),
+		newShowResourcesCommand(config),
+		newShowSettingsCommand(config),
 		newShowUserCommand(config),
+		newShowVacuumProgressCommand(config),
+		newShowWALUsageCommand(config),
 	)
 
 	// Limit the number of args, that is, only one cluster name
@@ -79,13 +106,31 @@ HA
 
 	// Define the 'show backup' command
 	cmdShow.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		// Print whether reconciliation is paused and for how long.
+		_, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		cluster, err := client.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		cmd.Printf("RECONCILE\n\n%s\n\n", clusterPaused(cluster).status(time.Now()))
 
 		// Print the pgbackrest info output received.
 		cmd.Printf("BACKUP\n\n")
-		if stdout, stderr, err := getBackup(config, args, "text", ""); err != nil {
+		if stdout, stderr, err := getBackup(config, args, ""); err != nil {
 			return err
 		} else {
-			cmd.Printf("%s", stdout)
+			if err := printBackup(cmd, stdout, string(util.TextPGBackRest), pgBackRestInfoFilter{}); err != nil {
+				return err
+			}
 			if stderr != "" {
 				cmd.Printf("\nError returned: %s\n", stderr)
 			}
@@ -118,6 +163,15 @@ func newShowBackupCommand(config *internal.Config) *cobra.Command {
 		Short:   "Show backup information for a PostgresCluster",
 		Long: `Show backup information for a PostgresCluster from 'pgbackrest info' command.
 
+The text output lists the backups of each stanza in a table. Use the "--sort",
+"--since", and "--type" flags to choose which backups are shown and in what
+order. These flags apply to the JSON output too.
+
+Use "--save" to write the backup information to a file as a snapshot. Later,
+"--diff" with that file shows the backups that were added and expired and how
+the size of each repository changed since. Both flags can be used together to
+compare against one snapshot while saving the next.
+
 ### RBAC Requirements
     Resources  Verbs
     ---------  -----
@@ -136,19 +190,22 @@ pgo show backup hippo --output=json
 # Show one repository of the 'hippo' postgrescluster
 pgo show backup hippo --repoName=repo1
 
+# Show the full backups of the last week, most recent first
+pgo show backup hippo --type=full --since=168h --sort=desc
+
+# Save a snapshot, then show what changed since it was saved
+pgo show backup hippo --save=snap1.json
+pgo show backup hippo --diff=snap1.json
+
 ### Example output
 stanza: db
     status: ok
     cipher: none
+    wal archive min/max (16-1, repo1): 000000010000000000000001/000000010000000000000004
 
-    db (current)
-        wal archive min/max (14): 000000010000000000000001/000000010000000000000004
-
-        full backup: 20231023-201416F
-            timestamp start/stop: 2023-10-23 20:14:16+00 / 2023-10-23 20:14:32+00
-            wal start/stop: 000000010000000000000002 / 000000010000000000000002
-            database size: 33.5MB, database backup size: 33.5MB
-            repo1: backup set size: 4.2MB, backup size: 4.2MB`)
+    LABEL                              TYPE  REPO   START                 STOP                  DATABASE SIZE  BACKUP SIZE  REPO SIZE
+    20231023-201416F                   full  repo1  2023-10-23T20:14:16Z  2023-10-23T20:14:32Z  32.0MiB        32.0MiB      4.0MiB
+    20231023-201416F_20231024-130000D  diff  repo1  2023-10-24T13:00:00Z  2023-10-24T13:00:09Z  32.4MiB        1.2MiB       307.2KiB`)
 
 	// Define the command flags.
 	// - https://pgbackrest.org/command.html
@@ -161,6 +218,22 @@ stanza: db
 	cmdShowBackup.Flags().StringVar(&repoName, "repoName", "",
 		"Set the repository name for the command. example: repo1")
 
+	var filter pgBackRestInfoFilter
+	var sortOrder string
+	cmdShowBackup.Flags().StringVar(&sortOrder, "sort", "asc",
+		"order of backups by start time. types supported: asc,desc")
+	var since flags.Time
+	cmdShowBackup.Flags().Var(&since, "since",
+		`only show backups that started more recently than this, e.g. "24h", "7d", or "2024-05-01 14:03"`)
+	cmdShowBackup.Flags().StringSliceVar(&filter.Types, "type", nil,
+		"only show backups of these types. types supported: full,diff,incr")
+
+	var saveFile, diffFile string
+	cmdShowBackup.Flags().StringVar(&saveFile, "save", "",
+		"write the backup information to this file as a snapshot")
+	cmdShowBackup.Flags().StringVar(&diffFile, "diff", "",
+		"show what changed since the snapshot in this file")
+
 	// Limit the number of args, that is, only one cluster name
 	cmdShowBackup.Args = cobra.ExactArgs(1)
 
@@ -172,10 +245,40 @@ stanza: db
 		// handle validation.
 		repoNum := strings.TrimPrefix(repoName, "repo")
 
-		stdout, stderr, err := getBackup(config, args, outputEnum.String(), repoNum)
+		switch sortOrder {
+		case "asc":
+		case "desc":
+			filter.Descending = true
+		default:
+			return fmt.Errorf(`--sort must be one of "asc", "desc"`)
+		}
+		filter.Since = since.Ago(time.Now())
+		for _, kind := range filter.Types {
+			if kind != "full" && kind != "diff" && kind != "incr" {
+				return fmt.Errorf(`--type must be one of "full", "diff", "incr"`)
+			}
+		}
+
+		// Read the snapshot first in case it is also the file to save.
+		var snapshot []byte
+		if diffFile != "" {
+			var err error
+			if snapshot, err = os.ReadFile(diffFile); err != nil {
+				return err
+			}
+		}
+
+		stdout, stderr, err := getBackup(config, args, repoNum)
 
+		if err == nil && saveFile != "" {
+			err = os.WriteFile(saveFile, []byte(stdout), 0o600)
+		}
 		if err == nil {
-			cmd.Printf("%s", stdout)
+			if diffFile != "" {
+				err = printBackupDiff(cmd, string(snapshot), stdout, outputEnum.String(), filter)
+			} else {
+				err = printBackup(cmd, stdout, outputEnum.String(), filter)
+			}
 			if stderr != "" {
 				cmd.Printf("\nError returned: %s\n", stderr)
 			}
@@ -188,11 +291,10 @@ stanza: db
 }
 
 // getBackup execs into the primary Pod, runs the 'pgbackrest info' command and
-// returns the command output and/or error
+// returns the JSON output and/or error
 func getBackup(
 	config *internal.Config,
 	args []string,
-	output string,
 	repoNum string) (string, string, error) {
 
 	exec, err := getPrimaryExec(config, args)
@@ -200,7 +302,67 @@ func getBackup(
 		return "", "", err
 	}
 
-	return Executor(exec).pgBackRestInfo(output, repoNum)
+	return podexec.PGBackRestInfo(exec, repoNum)
+}
+
+// printBackup prints the JSON output of 'pgbackrest info' in output format
+// after applying filter. JSON is printed as pgBackRest wrote it when filter
+// changes nothing.
+func printBackup(cmd *cobra.Command, stdout, output string, filter pgBackRestInfoFilter) error {
+
+	if output == string(util.JSONPGBackRest) && filter.IsZero() {
+		cmd.Printf("%s", stdout)
+		return nil
+	}
+
+	stanzas, err := parsePGBackRestInfo(stdout)
+	if err != nil {
+		return err
+	}
+	filter.Apply(stanzas, time.Now())
+
+	if output == string(util.JSONPGBackRest) {
+		b, err := json.Marshal(stanzas)
+		if err == nil {
+			cmd.Printf("%s\n", b)
+		}
+		return err
+	}
+	return renderPGBackRestInfo(cmd.OutOrStdout(), stanzas)
+}
+
+// printBackupDiff prints what changed between the snapshot and stdout, both
+// JSON output of 'pgbackrest info', in output format. The filter applies to
+// both.
+func printBackupDiff(cmd *cobra.Command, snapshot, stdout, output string, filter pgBackRestInfoFilter) error {
+	now := time.Now()
+	read := func(stdout string) ([]pgBackRestStanza, error) {
+		stanzas, err := parsePGBackRestInfo(stdout)
+		if err != nil {
+			return nil, err
+		}
+		filter.Apply(stanzas, now)
+		return typedPGBackRestInfo(stanzas)
+	}
+
+	before, err := read(snapshot)
+	if err != nil {
+		return fmt.Errorf("--diff: %w", err)
+	}
+	after, err := read(stdout)
+	if err != nil {
+		return err
+	}
+
+	diffs := diffPGBackRestInfo(before, after)
+	if output == string(util.JSONPGBackRest) {
+		b, err := json.Marshal(diffs)
+		if err == nil {
+			cmd.Printf("%s\n", b)
+		}
+		return err
+	}
+	return renderPGBackRestInfoDiff(cmd.OutOrStdout(), diffs)
 }
 
 // newShowHACommand returns the output of the 'patronictl list' command.
@@ -212,6 +374,9 @@ func newShowHACommand(config *internal.Config) *cobra.Command {
 		Short: "Show 'patronictl list' for a PostgresCluster.",
 		Long: `Show 'patronictl list' for a PostgresCluster.
 
+When replicas delay replay with "recovery_min_apply_delay", the pretty output
+also shows their delay and how far they have replayed.
+
 #### RBAC Requirements
     Resources  Verbs
     ---------  -----
@@ -253,12 +418,93 @@ pgo show ha hippo --output json
 			}
 		}
 
+		// Other formats are meant for programs; leave them as Patroni prints them.
+		if err == nil && outputEnum == util.PrettyPatroni {
+			err = printDelayedReplicas(cmd, config, args[0])
+		}
+
 		return err
 	}
 
 	return cmdShowHA
 }
 
+// replicaReplay is the replay progress of one replica.
+type replicaReplay struct {
+	Member       string
+	Delay        string
+	ReplayLSN    string
+	LastReplayed string
+}
+
+// parseReplicaReplay reads the output of [replicaReplaySQL].
+func parseReplicaReplay(member, stdout string) replicaReplay {
+	replay := replicaReplay{Member: member}
+	fields := strings.Split(strings.TrimRight(stdout, "\n"), "\t")
+	if len(fields) == 3 {
+		replay.Delay, replay.ReplayLSN, replay.LastReplayed = fields[0], fields[1], fields[2]
+	}
+	return replay
+}
+
+// replicaReplaySQL reports the delay and replay position of a replica.
+const replicaReplaySQL = `SELECT pg_catalog.current_setting('recovery_min_apply_delay'),
+pg_catalog.pg_last_wal_replay_lsn(), pg_catalog.pg_last_xact_replay_timestamp()`
+
+// printDelayedReplicas prints the replicas of the cluster that delay replay.
+// It prints nothing when there are none.
+func printDelayedReplicas(cmd *cobra.Command, config *internal.Config, clusterName string) error {
+	ctx := context.Background()
+
+	rest, err := config.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+	client, err := v1.NewForConfig(rest)
+	if err != nil {
+		return err
+	}
+	namespace, err := config.Namespace()
+	if err != nil {
+		return err
+	}
+	podExec, err := util.NewPodExecutor(rest)
+	if err != nil {
+		return err
+	}
+
+	pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: util.ReplicaInstanceLabels(clusterName),
+	})
+	if err != nil {
+		return err
+	}
+
+	var delayed []replicaReplay
+	for _, pod := range pods.Items {
+		exec := podexec.Container(podExec, namespace, pod.GetName(), util.ContainerDatabase)
+
+		// Replicas that cannot be reached are already reported by Patroni.
+		if stdout, _, err := podexec.PSQL(exec, "", replicaReplaySQL); err == nil {
+			if replay := parseReplicaReplay(pod.GetName(), stdout); replay.Delay != "" && replay.Delay != "0" {
+				delayed = append(delayed, replay)
+			}
+		}
+	}
+	if len(delayed) == 0 {
+		return nil
+	}
+
+	cmd.Println("\nDelayed replicas:")
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "MEMBER\tDELAY\tREPLAY LSN\tLAST REPLAYED")
+	for _, replay := range delayed {
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n",
+			replay.Member, replay.Delay, replay.ReplayLSN, replay.LastReplayed)
+	}
+	return writer.Flush()
+}
+
 // getHA execs into the primary Pod, runs the 'patronictl list' command and
 // returns the command output and/or error
 func getHA(
@@ -270,7 +516,7 @@ func getHA(
 		return "", "", err
 	}
 
-	return Executor(exec).patronictl("list", output)
+	return podexec.Patronictl(exec, "list", output)
 }
 
 // newShowUserCommand returns the decoded contents of the cluster's user Secrets.
@@ -283,7 +529,8 @@ func newShowUserCommand(config *internal.Config) *cobra.Command {
 details for the default user for a PostgresCluster
 or for users defined on the PostgresCluster spec.
 Use the "--show-connection-info" flag to get the
-connection info, including password.
+connection info, including password, or use
+"pgo show connect" to see it without the password.
 
 #### RBAC Requirements
     Resources  Verbs
@@ -316,7 +563,9 @@ Connection information for hippo for hippo cluster
 Connection info string:
     dbname=hippo host=hippo-primary.postgres-operator.svc port=5432 user=hippo password=<password>
 Connection URL:
-    postgres://<password>@hippo-primary.postgres-operator.svc:5432/hippo`)
+    postgres://<password>@hippo-primary.postgres-operator.svc:5432/hippo
+JDBC connection URL:
+    jdbc:postgresql://hippo-primary.postgres-operator.svc:5432/hippo?password=<password>&user=hippo`)
 
 	var fields bool
 	cmdShowUser.Flags().BoolVar(&fields, "show-connection-info", false, "show sensitive user fields")
@@ -357,7 +606,7 @@ Connection URL:
 		}
 
 		// If user info found, print
-		return printUsers(cmd, secretList, fields, cluster)
+		return printUsers(cmd, config, secretList, fields, cluster)
 	}
 
 	return cmdShowUser
@@ -391,6 +640,7 @@ func getUsers(client *v1.CoreV1Client,
 }
 
 func printUsers(cmd *cobra.Command,
+	config *internal.Config,
 	secretList *corev1.SecretList,
 	showSensitive bool,
 	clusterName string,
@@ -398,7 +648,7 @@ func printUsers(cmd *cobra.Command,
 
 	// If the user is asking for connection strings, we use the alternate printer.
 	if showSensitive {
-		return printUserConnectionStrings(cmd, secretList, clusterName)
+		return printUserConnectionStrings(cmd, config, secretList, clusterName)
 	}
 
 	// Set up a tabwriter that writes to stdout
@@ -421,17 +671,18 @@ func printUsers(cmd *cobra.Command,
 }
 
 func printUserConnectionStrings(cmd *cobra.Command,
+	config *internal.Config,
 	secretList *corev1.SecretList,
 	clusterName string,
 ) error {
-	fmt.Print("WARNING: This command will show sensitive password information." +
-		"\nAre you sure you want to continue? (yes/no): ")
+	_, _ = fmt.Fprint(config.ErrOut, config.Messages.Sprintf("show.user.warn-sensitive")+
+		config.Messages.Sprintf("confirm.continue"))
 
 	var confirmed *bool
 	for i := 0; confirmed == nil && i < 10; i++ {
 		// retry 10 times or until a confirmation is given or denied,
 		// whichever comes first
-		confirmed = util.Confirm(os.Stdin, os.Stdout)
+		confirmed = config.Messages.Confirm(config.In, config.ErrOut)
 	}
 
 	if confirmed == nil || !*confirmed {
@@ -457,6 +708,10 @@ func printUserConnectionStrings(cmd *cobra.Command,
 			"@" + string(secret.Data["host"]) +
 			":" + string(secret.Data["port"]) +
 			"/" + dbname)
+		if uri, ok := secret.Data["jdbc-uri"]; ok {
+			cmd.Println("JDBC connection URL:")
+			cmd.Println("    " + string(uri))
+		}
 		if uri, ok := secret.Data["pgbouncer-uri"]; ok {
 			cmd.Println("PgBouncer connection URL:")
 			cmd.Println("    " + string(uri))
@@ -470,12 +725,9 @@ func printUserConnectionStrings(cmd *cobra.Command,
 	return nil
 }
 
-// getPrimaryExec returns a executor function for the primary Pod to allow for
+// getPrimaryExec returns an Executor for the primary Pod to allow for
 // commands to be run against it.
-func getPrimaryExec(config *internal.Config, args []string) (
-	func(stdin io.Reader, stdout io.Writer, stderr io.Writer, command ...string) error,
-	error,
-) {
+func getPrimaryExec(config *internal.Config, args []string) (podexec.Executor, error) {
 
 	// configure client
 	ctx := context.Background()
@@ -517,11 +769,6 @@ func getPrimaryExec(config *internal.Config, args []string) (
 	}
 
 	// Create an executor and attempt to get the pgBackRest info output.
-	exec := func(stdin io.Reader, stdout, stderr io.Writer,
-		command ...string) error {
-		return PodExec(pods.Items[0].GetNamespace(), pods.Items[0].GetName(),
-			util.ContainerDatabase, stdin, stdout, stderr, command...)
-	}
-
-	return exec, err
+	return podexec.Container(PodExec, pods.Items[0].GetNamespace(), pods.Items[0].GetName(),
+		util.ContainerDatabase), nil
 }
@@ -0,0 +1,202 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// newShowConnectCommand returns the connect subcommand of the show command.
+// It prints how to connect as the users of a PostgresCluster without
+// decoding their Secrets by hand.
+func newShowConnectCommand(config *internal.Config) *cobra.Command {
+
+	cmdShowConnect := &cobra.Command{
+		Use:   "connect CLUSTER_NAME",
+		Short: "Show how to connect to a PostgresCluster",
+		Long: `Show the host, port, database, and user in the pguser Secrets of a
+PostgresCluster, with a connection URI and a JDBC URL for each user. Users
+with PgBouncer access get PgBouncer URLs, too. Use "--user" to show only one
+user.
+
+Passwords are hidden unless "--show-password" is set; then they are printed
+in plain text and included in the URLs.
+
+### RBAC Requirements
+    Resources  Verbs
+    ---------  -----
+    secrets    [list]
+
+### Usage`,
+	}
+
+	cmdShowConnect.Example = internal.FormatExample(`# Show how to connect to the 'hippo' postgrescluster
+pgo show connect hippo
+
+# Show the password and URLs with it for the user 'rhino'
+pgo show connect hippo --user=rhino --show-password
+
+### Example output
+user rhino of postgrescluster hippo
+  host:      hippo-primary.postgres-operator.svc
+  port:      5432
+  dbname:    zoo
+  user:      rhino
+  password:  <hidden, use --show-password>
+  uri:       postgresql://rhino@hippo-primary.postgres-operator.svc:5432/zoo
+  jdbc-uri:  jdbc:postgresql://hippo-primary.postgres-operator.svc:5432/zoo?user=rhino`)
+
+	var user string
+	cmdShowConnect.Flags().StringVar(&user, "user", "", "show only this user")
+
+	var showPassword bool
+	cmdShowConnect.Flags().BoolVar(&showPassword, "show-password", false,
+		"print passwords and include them in the URLs")
+
+	// Limit the number of args, that is, only one cluster name
+	cmdShowConnect.Args = cobra.ExactArgs(1)
+
+	cmdShowConnect.RunE = func(cmd *cobra.Command, args []string) error {
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+
+		var users []string
+		if user != "" {
+			users = []string{user}
+		}
+		secretList, err := getUsers(client, config, args[0], users)
+		if err != nil {
+			return err
+		}
+
+		if len(secretList.Items) == 0 {
+			notFoundMessage := "No user information found for cluster " + args[0]
+			if user != "" {
+				notFoundMessage = notFoundMessage + " / user " + user
+			}
+			cmd.Print(notFoundMessage + "\n")
+			return nil
+		}
+
+		if showPassword {
+			cmd.PrintErr(config.Messages.Sprintf("show.user.warn-sensitive"))
+		}
+		for i, secret := range secretList.Items {
+			if i > 0 {
+				cmd.Println()
+			}
+			if err := newConnectionInfo(secret).print(cmd.OutOrStdout(), args[0], showPassword); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return cmdShowConnect
+}
+
+// connectionInfo is what a pguser Secret says about connecting as its user.
+type connectionInfo struct {
+	User, Password string
+	Host, Port     string
+	Database       string
+
+	PGBouncerHost, PGBouncerPort string
+}
+
+// newConnectionInfo reads a pguser Secret. Like "pgo show user", it uses the
+// name of the user when the Secret has no database.
+func newConnectionInfo(secret corev1.Secret) connectionInfo {
+	info := connectionInfo{
+		User:          string(secret.Data["user"]),
+		Password:      string(secret.Data["password"]),
+		Host:          string(secret.Data["host"]),
+		Port:          string(secret.Data["port"]),
+		Database:      string(secret.Data["dbname"]),
+		PGBouncerHost: string(secret.Data["pgbouncer-host"]),
+		PGBouncerPort: string(secret.Data["pgbouncer-port"]),
+	}
+	if info.Database == "" {
+		info.Database = info.User
+	}
+	return info
+}
+
+// URI returns a libpq connection URI to host and port. The password is only
+// included when showPassword is true.
+func (info connectionInfo) URI(host, port string, showPassword bool) string {
+	uri := url.URL{
+		Scheme: "postgresql",
+		User:   url.User(info.User),
+		Host:   net.JoinHostPort(host, port),
+		Path:   "/" + info.Database,
+	}
+	if showPassword {
+		uri.User = url.UserPassword(info.User, info.Password)
+	}
+	return uri.String()
+}
+
+// JDBC returns a JDBC URL to host and port. The password is only included
+// when showPassword is true.
+func (info connectionInfo) JDBC(host, port string, showPassword bool) string {
+	query := url.Values{"user": {info.User}}
+	if showPassword {
+		query.Set("password", info.Password)
+	}
+	uri := url.URL{
+		Scheme:   "postgresql",
+		Host:     net.JoinHostPort(host, port),
+		Path:     "/" + info.Database,
+		RawQuery: query.Encode(),
+	}
+	return "jdbc:" + uri.String()
+}
+
+// print writes info for the user of clusterName to out.
+func (info connectionInfo) print(out io.Writer, clusterName string, showPassword bool) error {
+	password := "<hidden, use --show-password>"
+	if showPassword {
+		password = info.Password
+	}
+
+	lines := [][2]string{
+		{"host", info.Host},
+		{"port", info.Port},
+		{"dbname", info.Database},
+		{"user", info.User},
+		{"password", password},
+		{"uri", info.URI(info.Host, info.Port, showPassword)},
+		{"jdbc-uri", info.JDBC(info.Host, info.Port, showPassword)},
+	}
+	if info.PGBouncerHost != "" {
+		lines = append(lines,
+			[2]string{"pgbouncer-uri", info.URI(info.PGBouncerHost, info.PGBouncerPort, showPassword)},
+			[2]string{"pgbouncer-jdbc-uri", info.JDBC(info.PGBouncerHost, info.PGBouncerPort, showPassword)})
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintf(writer, "user %s of postgrescluster %s\n", info.User, clusterName)
+	for _, line := range lines {
+		_, _ = fmt.Fprintf(writer, "  %s:\t%s\n", line[0], line[1])
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,258 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// memoryLogPattern is an extended regular expression for Postgres log lines
+// about memory. The kernel OOM killer appears as a backend killed by signal 9.
+const memoryLogPattern = `out of memory|terminated by signal 9|failed on request of size|` +
+	`cannot allocate memory|could not (fork|resize shared memory|map anonymous shared memory)`
+
+// newShowCrashesCommand returns the crashes subcommand of the show command.
+// It lists container restarts and the Postgres log lines about memory around
+// them.
+func newShowCrashesCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "crashes CLUSTER_NAME",
+		Short: "Show container restarts and OOM kills of a PostgresCluster",
+		Long: `Show every container of a PostgresCluster that has restarted, with its exit
+code, whether it was OOMKilled, when it stopped, and its memory limit.
+Kubernetes keeps only the last termination of each container; RESTARTS counts
+all of them.
+
+For instances, the Postgres log lines about memory from --window before to
+--window after each termination follow the table. Those lines include
+backends killed by the kernel OOM killer and allocations that failed.
+
+### RBAC Requirements
+    Resources  Verbs
+    ---------  -----
+    pods       [list]
+    pods/exec  [create]
+
+    Note: pods/exec is not needed with --window=0.
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Show the restarts of the 'hippo' postgrescluster
+pgo show crashes hippo
+
+### Example output
+POD                     CONTAINER   RESTARTS  EXIT  REASON     OOM  FINISHED              MEMORY LIMIT
+hippo-repo-host-0       pgbackrest  1         1     Error      no   2024-04-30T08:00:00Z  none
+hippo-instance1-8x7m-0  database    3         137   OOMKilled  yes  2024-05-01T12:00:00Z  1Gi
+
+hippo-instance1-8x7m-0/database at 2024-05-01T12:00:00Z:
+  2024-05-01 11:59:58.120 UTC [88] LOG:  server process (PID 1234) was terminated by signal 9: Killed`)
+
+	var window time.Duration
+	cmd.Flags().DurationVar(&window, "window", 5*time.Minute,
+		"how far around each termination to look for Postgres log lines; zero reads no logs")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.LabelCluster + "=" + args[0],
+		})
+		if err != nil {
+			return err
+		}
+
+		crashes := containerCrashes(pods.Items)
+		if len(crashes) == 0 {
+			cmd.Printf("No containers of postgresclusters/%s have restarted.\n", args[0])
+			return nil
+		}
+
+		logs := map[string][]string{}
+		if window > 0 {
+			podExec, err := util.NewPodExecutor(rest)
+			if err != nil {
+				return err
+			}
+			for _, crash := range crashes {
+				if _, done := logs[crash.Pod]; done || !crash.Instance || crash.Finished.IsZero() {
+					continue
+				}
+				exec := podexec.Container(podExec, namespace, crash.Pod, util.ContainerDatabase)
+				stdout, stderr, err := podexec.Bash(exec, memoryLogCommand())
+				if err != nil {
+					cmd.PrintErrf("WARNING: unable to read the Postgres logs of %s: %v\n",
+						crash.Pod, commandError(err, stderr))
+				}
+				logs[crash.Pod] = strings.Split(stdout, "\n")
+			}
+		}
+
+		return printContainerCrashes(cmd, crashes, logs, window)
+	}
+
+	return cmd
+}
+
+// containerCrash is the last termination of a container that restarted.
+type containerCrash struct {
+	Pod, Container string
+	Instance       bool
+	Restarts       int32
+	ExitCode       int32
+	Reason         string
+	Finished       time.Time
+	MemoryLimit    string
+}
+
+// containerCrashes returns the last termination of every container in pods
+// that restarted, oldest first.
+func containerCrashes(pods []corev1.Pod) []containerCrash {
+	var crashes []containerCrash
+	for i := range pods {
+		pod := &pods[i]
+
+		limits := map[string]string{}
+		for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+			limits[container.Name] = "none"
+			if memory, ok := container.Resources.Limits[corev1.ResourceMemory]; ok {
+				limits[container.Name] = memory.String()
+			}
+		}
+
+		for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+			if status.RestartCount == 0 {
+				continue
+			}
+			crash := containerCrash{
+				Pod: pod.Name, Container: status.Name,
+				Instance:    status.Name == util.ContainerDatabase && pod.Labels[util.LabelInstance] != "",
+				Restarts:    status.RestartCount,
+				MemoryLimit: limits[status.Name],
+			}
+			if terminated := status.LastTerminationState.Terminated; terminated != nil {
+				crash.ExitCode = terminated.ExitCode
+				crash.Reason = terminated.Reason
+				crash.Finished = terminated.FinishedAt.Time.UTC()
+			}
+			crashes = append(crashes, crash)
+		}
+	}
+
+	sort.SliceStable(crashes, func(i, j int) bool {
+		if !crashes[i].Finished.Equal(crashes[j].Finished) {
+			return crashes[i].Finished.Before(crashes[j].Finished)
+		}
+		return crashes[i].Pod+"/"+crashes[i].Container < crashes[j].Pod+"/"+crashes[j].Container
+	})
+	return crashes
+}
+
+// memoryLogCommand returns a script that prints the lines of the Postgres log
+// files in a database container that match [memoryLogPattern].
+func memoryLogCommand() string {
+	return fmt.Sprintf(
+		"grep --no-filename --ignore-case --extended-regexp -- '%s' "+
+			"pgdata/pg[0-9][0-9]/log/* pgdata/logs/postgres/* 2>/dev/null || true", memoryLogPattern)
+}
+
+// postgresLogTime returns the time at the start of line, as written by the
+// "%m" and "%t" escapes of log_line_prefix.
+func postgresLogTime(line string) (time.Time, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05.999 MST", "2006-01-02 15:04:05 MST"} {
+		if t, err := time.Parse(layout, strings.Join(fields[:3], " ")); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// linesAround returns the lines with a time within window of at.
+func linesAround(lines []string, at time.Time, window time.Duration) []string {
+	var matched []string
+	for _, line := range lines {
+		if t, ok := postgresLogTime(line); ok && !t.Before(at.Add(-window)) && !t.After(at.Add(window)) {
+			matched = append(matched, strings.TrimSpace(line))
+		}
+	}
+	return matched
+}
+
+// printContainerCrashes prints a table of crashes followed by the log lines of
+// each one that are within window of it.
+func printContainerCrashes(
+	cmd *cobra.Command, crashes []containerCrash, logs map[string][]string, window time.Duration,
+) error {
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "POD\tCONTAINER\tRESTARTS\tEXIT\tREASON\tOOM\tFINISHED\tMEMORY LIMIT")
+	for _, crash := range crashes {
+		exit, reason, oom, finished := "-", "-", "-", "-"
+		if !crash.Finished.IsZero() {
+			exit = strconv.Itoa(int(crash.ExitCode))
+			reason = crash.Reason
+			oom = "no"
+			if crash.Reason == "OOMKilled" {
+				oom = "yes"
+			}
+			finished = crash.Finished.Format(time.RFC3339)
+		}
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n", crash.Pod, crash.Container,
+			crash.Restarts, exit, reason, oom, finished, crash.MemoryLimit)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	for _, crash := range crashes {
+		lines, ok := logs[crash.Pod]
+		if !ok || !crash.Instance || crash.Finished.IsZero() {
+			continue
+		}
+		cmd.Printf("\n%s/%s at %s:\n", crash.Pod, crash.Container, crash.Finished.Format(time.RFC3339))
+		matched := linesAround(lines, crash.Finished, window)
+		if len(matched) == 0 {
+			cmd.Printf("  no Postgres log lines about memory within %s\n", window)
+		}
+		for _, line := range matched {
+			cmd.Printf("  %s\n", line)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+func TestContainerCrashes(t *testing.T) {
+	oom := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	limited := corev1.ResourceRequirements{Limits: corev1.ResourceList{
+		corev1.ResourceMemory: resource.MustParse("1Gi"),
+	}}
+	terminated := func(code int32, reason string, at time.Time) corev1.ContainerState {
+		return corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+			ExitCode: code, Reason: reason, FinishedAt: metav1.NewTime(at),
+		}}
+	}
+
+	pods := []corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "hippo-instance1-8x7m-0",
+				Labels: map[string]string{util.LabelInstance: "hippo-instance1-8x7m"}},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: util.ContainerDatabase, Resources: limited},
+				{Name: "replication-cert-copy"},
+			}},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				{Name: util.ContainerDatabase, RestartCount: 3, LastTerminationState: terminated(137, "OOMKilled", oom)},
+				{Name: "replication-cert-copy"},
+			}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "hippo-repo-host-0"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: util.ContainerPGBackrest}}},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				{Name: util.ContainerPGBackrest, RestartCount: 1,
+					LastTerminationState: terminated(1, "Error", oom.Add(-28*time.Hour))},
+			}},
+		},
+		{
+			// The kubelet can forget the termination after a node restart.
+			ObjectMeta: metav1.ObjectMeta{Name: "hippo-pgbouncer-6b4f9c7d5-x2kqp"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: util.ContainerPGBouncer}}},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				{Name: util.ContainerPGBouncer, RestartCount: 2},
+			}},
+		},
+	}
+
+	crashes := containerCrashes(pods)
+	assert.DeepEqual(t, crashes, []containerCrash{
+		{Pod: "hippo-pgbouncer-6b4f9c7d5-x2kqp", Container: util.ContainerPGBouncer, Restarts: 2, MemoryLimit: "none"},
+		{Pod: "hippo-repo-host-0", Container: util.ContainerPGBackrest, Restarts: 1,
+			ExitCode: 1, Reason: "Error", Finished: oom.Add(-28 * time.Hour), MemoryLimit: "none"},
+		{Pod: "hippo-instance1-8x7m-0", Container: util.ContainerDatabase, Instance: true, Restarts: 3,
+			ExitCode: 137, Reason: "OOMKilled", Finished: oom, MemoryLimit: "1Gi"},
+	})
+
+	logs := strings.Split(`
+2024-05-01 11:20:00.001 UTC [90] ERROR:  out of memory
+2024-05-01 11:59:58.120 UTC [88] LOG:  server process (PID 1234) was terminated by signal 9: Killed
+2024-05-01 12:00:03 UTC [88] DETAIL:  Failed on request of size 1048576 in memory context "ExecutorState".
+	`, "\n")
+
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+	assert.NilError(t, printContainerCrashes(cmd, crashes,
+		map[string][]string{"hippo-instance1-8x7m-0": logs}, 5*time.Minute))
+	assert.Equal(t, out.String(), `
+POD                              CONTAINER   RESTARTS  EXIT  REASON     OOM  FINISHED              MEMORY LIMIT
+hippo-pgbouncer-6b4f9c7d5-x2kqp  pgbouncer   2         -     -          -    -                     none
+hippo-repo-host-0                pgbackrest  1         1     Error      no   2024-04-30T08:00:00Z  none
+hippo-instance1-8x7m-0           database    3         137   OOMKilled  yes  2024-05-01T12:00:00Z  1Gi
+
+hippo-instance1-8x7m-0/database at 2024-05-01T12:00:00Z:
+  2024-05-01 11:59:58.120 UTC [88] LOG:  server process (PID 1234) was terminated by signal 9: Killed
+  2024-05-01 12:00:03 UTC [88] DETAIL:  Failed on request of size 1048576 in memory context "ExecutorState".
+`[1:])
+
+	out.Reset()
+	assert.NilError(t, printContainerCrashes(cmd, crashes[2:],
+		map[string][]string{"hippo-instance1-8x7m-0": nil}, time.Minute))
+	assert.Assert(t, strings.HasSuffix(out.String(),
+		"\n  no Postgres log lines about memory within 1m0s\n"), "%q", out.String())
+}
+
+func TestPostgresLogTime(t *testing.T) {
+	at, ok := postgresLogTime("2024-05-01 11:59:58.120 UTC [88] LOG:  x")
+	assert.Assert(t, ok)
+	assert.Equal(t, at, time.Date(2024, 5, 1, 11, 59, 58, 120e6, time.UTC))
+
+	at, ok = postgresLogTime("2024-05-01 11:59:58 UTC [88] LOG:  x")
+	assert.Assert(t, ok)
+	assert.Equal(t, at, time.Date(2024, 5, 1, 11, 59, 58, 0, time.UTC))
+
+	_, ok = postgresLogTime("\tcontinued from above")
+	assert.Assert(t, !ok)
+}
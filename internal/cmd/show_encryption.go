@@ -0,0 +1,249 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+)
+
+// newShowEncryptionCommand returns the encryption subcommand of the show
+// command. It summarizes how each pgBackRest repository is encrypted.
+func newShowEncryptionCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "encryption CLUSTER_NAME",
+		Short: "Show how the pgBackRest repositories of a PostgresCluster are encrypted",
+		Long: `Show the cipher of each pgBackRest repository of a PostgresCluster:
+  - CIPHER is the "repoN-cipher-type" in spec.backups.pgbackrest.global
+  - PASSPHRASE is the Secret in spec.backups.pgbackrest.configuration that has
+    "repoN-cipher-pass"; the passphrase itself is not shown
+  - CONTENTS is the cipher pgBackRest reports for what is in the repository
+
+A warning explains any repository whose settings cannot work or do not match
+its contents. Use "pgo rotate repo-cipher" to move to a new passphrase.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    pods                                                [list]
+    pods/exec                                           [create]
+    postgresclusters.postgres-operator.crunchydata.com  [get]
+    secrets                                             [get]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Show the encryption of the repositories of the 'hippo' postgrescluster
+pgo show encryption hippo
+
+### Example output
+REPO   CIPHER       PASSPHRASE                                           CONTENTS
+repo1  none         -                                                    none
+repo2  aes-256-cbc  secrets/hippo-pgbackrest-cipher-repo2 (cipher.conf)  aes-256-cbc
+
+WARNING: repo1 is not encrypted`)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		_, clusterClient, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		cluster, err := clusterClient.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		var secrets []corev1.Secret
+		for _, name := range configurationSecretNames(cluster) {
+			secret, err := client.Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			secrets = append(secrets, *secret)
+		}
+
+		// The contents are unknown when pgBackRest cannot be reached.
+		var stanzas []pgBackRestStanza
+		if exec, err := getPrimaryExec(config, args); err == nil {
+			if stdout, _, err := podexec.PGBackRestInfo(exec, ""); err == nil {
+				_ = json.Unmarshal([]byte(stdout), &stanzas)
+			}
+		}
+
+		repos := summarizeEncryption(clusterRepoNames(cluster),
+			repoCipherTypes(cluster), cipherPassSources(secrets), stanzas)
+		return printEncryption(cmd, repos)
+	}
+
+	return cmd
+}
+
+// repoEncryption is how one pgBackRest repository is encrypted.
+type repoEncryption struct {
+	Repo       string
+	Cipher     string
+	Passphrase string
+	Contents   string
+	Warning    string
+}
+
+// repoCipherTypes returns the "repoN-cipher-type" options of cluster by repository.
+func repoCipherTypes(cluster *unstructured.Unstructured) map[string]string {
+	global, _, _ := unstructured.NestedStringMap(cluster.Object, "spec", "backups", "pgbackrest", "global")
+	types := map[string]string{}
+	for key, value := range global {
+		if repo, ok := strings.CutSuffix(key, "-cipher-type"); ok {
+			types[repo] = value
+		}
+	}
+	return types
+}
+
+// configurationSecretNames returns the Secrets projected into the pgBackRest
+// configuration of cluster.
+func configurationSecretNames(cluster *unstructured.Unstructured) []string {
+	projections, _, _ := unstructured.NestedSlice(cluster.Object,
+		"spec", "backups", "pgbackrest", "configuration")
+	var names []string
+	for _, projection := range projections {
+		if projection, ok := projection.(map[string]any); ok {
+			if name, _, _ := unstructured.NestedString(projection, "secret", "name"); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// cipherPassSources returns where the "repoN-cipher-pass" option of each
+// repository is set among secrets, as "secrets/NAME (KEY)".
+func cipherPassSources(secrets []corev1.Secret) map[string]string {
+	sources := map[string]string{}
+	for _, secret := range secrets {
+		keys := make([]string, 0, len(secret.Data))
+		for key := range secret.Data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			for _, line := range strings.Split(string(secret.Data[key]), "\n") {
+				option, _, found := strings.Cut(strings.TrimSpace(line), "=")
+				repo, isPass := strings.CutSuffix(strings.TrimSpace(option), "-cipher-pass")
+				if found && isPass && sources[repo] == "" {
+					sources[repo] = fmt.Sprintf("secrets/%s (%s)", secret.Name, key)
+				}
+			}
+		}
+	}
+	return sources
+}
+
+// summarizeEncryption combines the settings of each of repos with what
+// pgBackRest reports in stanzas.
+func summarizeEncryption(
+	repos []string, types, sources map[string]string, stanzas []pgBackRestStanza,
+) []repoEncryption {
+	contents := map[int]string{}
+	for _, stanza := range stanzas {
+		for _, repo := range stanza.Repo {
+			if repo.Status.Code == 0 {
+				contents[repo.Key] = repo.Cipher
+			}
+		}
+	}
+
+	summary := make([]repoEncryption, 0, len(repos))
+	for _, name := range repos {
+		repo := repoEncryption{Repo: name, Cipher: "none", Passphrase: "-", Contents: "unknown"}
+		if value := types[name]; value != "" {
+			repo.Cipher = value
+		}
+		if value := sources[name]; value != "" {
+			repo.Passphrase = value
+		}
+		key, _ := strconv.Atoi(strings.TrimPrefix(name, "repo"))
+		if value, ok := contents[key]; ok {
+			repo.Contents = value
+		}
+
+		switch {
+		case repo.Cipher != "none" && repo.Passphrase == "-":
+			repo.Warning = fmt.Sprintf("%s has cipher %s but no %s-cipher-pass; pgBackRest cannot use it",
+				name, repo.Cipher, name)
+		case repo.Cipher == "none" && repo.Passphrase != "-":
+			repo.Warning = fmt.Sprintf("%s has a passphrase in %s but no %s-cipher-type; it is not encrypted",
+				name, repo.Passphrase, name)
+		case repo.Contents != "unknown" && repo.Contents != repo.Cipher:
+			repo.Warning = fmt.Sprintf("%s contains %s backups but is configured for %s;"+
+				" existing backups cannot be read", name, repo.Contents, repo.Cipher)
+		case repo.Cipher == "none":
+			repo.Warning = name + " is not encrypted"
+		}
+		summary = append(summary, repo)
+	}
+	return summary
+}
+
+// printEncryption prints repos as a table followed by their warnings.
+func printEncryption(cmd *cobra.Command, repos []repoEncryption) error {
+	if len(repos) == 0 {
+		cmd.Println("No pgBackRest repositories are defined")
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "REPO\tCIPHER\tPASSPHRASE\tCONTENTS")
+	for _, repo := range repos {
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n",
+			repo.Repo, repo.Cipher, repo.Passphrase, repo.Contents)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	for _, repo := range repos {
+		if repo.Warning != "" {
+			cmd.PrintErrf("WARNING: %s\n", repo.Warning)
+		}
+	}
+	return nil
+}
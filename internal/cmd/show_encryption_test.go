@@ -0,0 +1,109 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCipherPassSources(t *testing.T) {
+	secrets := []corev1.Secret{{}, {}}
+	secrets[0].Name = "hippo-pgbackrest-cipher-repo2"
+	secrets[0].Data = map[string][]byte{
+		"cipher.conf": []byte("[global]\nrepo2-cipher-pass=secret\n"),
+	}
+	secrets[1].Name = "hippo-s3"
+	secrets[1].Data = map[string][]byte{
+		"b.conf": []byte("[global]\nrepo3-s3-key=abc\n repo3-cipher-pass = other\n"),
+		"a.conf": []byte("[global]\nrepo2-cipher-pass=again\n"),
+	}
+
+	assert.DeepEqual(t, cipherPassSources(secrets), map[string]string{
+		"repo2": "secrets/hippo-pgbackrest-cipher-repo2 (cipher.conf)",
+		"repo3": "secrets/hippo-s3 (b.conf)",
+	})
+}
+
+func TestRepoCipherTypes(t *testing.T) {
+	cluster := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{"backups": map[string]any{"pgbackrest": map[string]any{
+			"global": map[string]any{
+				"repo1-retention-full": "2",
+				"repo2-cipher-type":    "aes-256-cbc",
+			},
+			"configuration": []any{
+				map[string]any{"secret": map[string]any{"name": "hippo-s3"}},
+				map[string]any{"configMap": map[string]any{"name": "hippo-conf"}},
+			},
+		}}},
+	}}
+
+	assert.DeepEqual(t, repoCipherTypes(cluster), map[string]string{"repo2": "aes-256-cbc"})
+	assert.DeepEqual(t, configurationSecretNames(cluster), []string{"hippo-s3"})
+}
+
+func TestSummarizeEncryption(t *testing.T) {
+	var stanzas []pgBackRestStanza
+	assert.NilError(t, json.Unmarshal([]byte(`[{"name":"db","repo":[
+{"key":1,"cipher":"none","status":{"code":0}},
+{"key":2,"cipher":"aes-256-cbc","status":{"code":0}},
+{"key":3,"cipher":"none","status":{"code":0}},
+{"key":4,"cipher":"none","status":{"code":99,"message":"other"}}
+]}]`), &stanzas))
+
+	repos := summarizeEncryption(
+		[]string{"repo1", "repo2", "repo3", "repo4", "repo5"},
+		map[string]string{"repo2": "aes-256-cbc", "repo3": "aes-256-cbc", "repo5": "aes-256-cbc"},
+		map[string]string{"repo2": "secrets/a (cipher.conf)", "repo3": "secrets/b (cipher.conf)",
+			"repo4": "secrets/c (cipher.conf)"},
+		stanzas)
+
+	assert.DeepEqual(t, repos, []repoEncryption{
+		{Repo: "repo1", Cipher: "none", Passphrase: "-", Contents: "none",
+			Warning: "repo1 is not encrypted"},
+		{Repo: "repo2", Cipher: "aes-256-cbc", Passphrase: "secrets/a (cipher.conf)", Contents: "aes-256-cbc"},
+		{Repo: "repo3", Cipher: "aes-256-cbc", Passphrase: "secrets/b (cipher.conf)", Contents: "none",
+			Warning: "repo3 contains none backups but is configured for aes-256-cbc; existing backups cannot be read"},
+		{Repo: "repo4", Cipher: "none", Passphrase: "secrets/c (cipher.conf)", Contents: "unknown",
+			Warning: "repo4 has a passphrase in secrets/c (cipher.conf) but no repo4-cipher-type; it is not encrypted"},
+		{Repo: "repo5", Cipher: "aes-256-cbc", Passphrase: "-", Contents: "unknown",
+			Warning: "repo5 has cipher aes-256-cbc but no repo5-cipher-pass; pgBackRest cannot use it"},
+	})
+}
+
+func TestPrintEncryption(t *testing.T) {
+	var out, errOut bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+	cmd.SetErr(&errOut)
+
+	var stanzas []pgBackRestStanza
+	assert.NilError(t, json.Unmarshal([]byte(`[{"name":"db","repo":[
+{"key":1,"cipher":"none","status":{"code":0}},
+{"key":2,"cipher":"aes-256-cbc","status":{"code":0}}
+]}]`), &stanzas))
+
+	assert.NilError(t, printEncryption(cmd, summarizeEncryption(
+		[]string{"repo1", "repo2"},
+		map[string]string{"repo2": "aes-256-cbc"},
+		map[string]string{"repo2": "secrets/hippo-pgbackrest-cipher-repo2 (cipher.conf)"},
+		stanzas)))
+	assert.Equal(t, out.String(), `REPO   CIPHER       PASSPHRASE                                           CONTENTS
+repo1  none         -                                                    none
+repo2  aes-256-cbc  secrets/hippo-pgbackrest-cipher-repo2 (cipher.conf)  aes-256-cbc
+`)
+	assert.Equal(t, errOut.String(), "WARNING: repo1 is not encrypted\n")
+
+	out.Reset()
+	assert.NilError(t, printEncryption(cmd, nil))
+	assert.Equal(t, out.String(), "No pgBackRest repositories are defined\n")
+}
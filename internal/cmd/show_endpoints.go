@@ -0,0 +1,275 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// clusterService describes one of the Services that PGO creates for client
+// traffic to a PostgresCluster.
+type clusterService struct {
+	Name    string
+	Purpose string
+
+	// Primary is true when every endpoint should be the Patroni leader and
+	// false when every endpoint should be a replica. It is nil when endpoints
+	// are not Postgres instances, such as pgBouncer.
+	Primary *bool
+}
+
+// clusterServices returns the client Services of the cluster named clusterName.
+func clusterServices(clusterName string) []clusterService {
+	primary, replica := true, false
+	return []clusterService{
+		{Name: clusterName + "-primary", Purpose: "read-write", Primary: &primary},
+		{Name: clusterName + "-replicas", Purpose: "read-only", Primary: &replica},
+		{Name: clusterName + "-pgbouncer", Purpose: "pooled read-write"},
+	}
+}
+
+// newShowEndpointsCommand returns the endpoints subcommand of the show command.
+// It explains which Service to use for read-write and read-only traffic.
+func newShowEndpointsCommand(config *internal.Config) *cobra.Command {
+
+	cmdShowEndpoints := &cobra.Command{
+		Use:   "endpoints CLUSTER_NAME",
+		Short: "Show which Services to use for read-write and read-only traffic",
+		Long: `Show the Services of a PostgresCluster that clients connect to, what kind of
+traffic each one is for, and whether each one has ready endpoints.
+
+Use the "--check" flag to verify that the read-write Service routes to the
+primary and the read-only Service routes to replicas by querying the Postgres
+instance behind each endpoint.
+
+### RBAC Requirements
+    Resources  Verbs
+    ---------  -----
+    endpoints  [get]
+    pods       [list]
+    pods/exec  [create]
+    services   [get]
+
+### Usage`,
+	}
+
+	cmdShowEndpoints.Example = internal.FormatExample(`# Show the Services of the 'hippo' postgrescluster
+pgo show endpoints hippo
+
+# Also check where each endpoint routes
+pgo show endpoints hippo --check
+
+### Example output
+SERVICE          PURPOSE            HOST                                   PORT  READY  NOT READY
+hippo-primary    read-write         hippo-primary.postgres-operator.svc    5432  1      0
+hippo-replicas   read-only          hippo-replicas.postgres-operator.svc   5432  1      0
+
+hippo-primary -> hippo-instance1-8x7m-0: primary (ok)
+hippo-replicas -> hippo-instance1-pwr2-0: replica (ok)`)
+
+	var check bool
+	cmdShowEndpoints.Flags().BoolVar(&check, "check", false,
+		"query the instance behind each endpoint to verify routing")
+
+	// Limit the number of args, that is, only one cluster name
+	cmdShowEndpoints.Args = cobra.ExactArgs(1)
+
+	cmdShowEndpoints.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		var services []serviceEndpoints
+		var warnings []string
+
+		writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+		_, _ = fmt.Fprintln(writer, "SERVICE\tPURPOSE\tHOST\tPORT\tREADY\tNOT READY")
+
+		for _, service := range clusterServices(args[0]) {
+			svc, err := client.Services(namespace).Get(ctx, service.Name, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				// pgBouncer is optional; the others are not.
+				if service.Primary != nil {
+					warnings = append(warnings, fmt.Sprintf(
+						"WARNING: Service %s not found", service.Name))
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			endpoints, err := client.Endpoints(namespace).Get(ctx, service.Name, metav1.GetOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+			if endpoints == nil {
+				endpoints = &corev1.Endpoints{}
+			}
+
+			ready, notReady := countEndpoints(endpoints)
+			if ready == 0 {
+				warnings = append(warnings, fmt.Sprintf(
+					"WARNING: %s has no ready endpoints; %s traffic will fail",
+					service.Name, service.Purpose))
+			}
+
+			var port int32
+			if len(svc.Spec.Ports) > 0 {
+				port = svc.Spec.Ports[0].Port
+			}
+			_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%d\t%d\t%d\n",
+				service.Name, service.Purpose,
+				service.Name+"."+namespace+".svc", port, ready, notReady)
+
+			services = append(services, serviceEndpoints{service, endpoints})
+		}
+
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		for _, warning := range warnings {
+			cmd.PrintErrln(warning)
+		}
+
+		if !check {
+			return nil
+		}
+
+		pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.DBInstanceLabels(args[0]),
+		})
+		if err != nil {
+			return err
+		}
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+
+		cmd.Println()
+		misrouted := checkEndpointRoutes(cmd, services, pods.Items,
+			func(pod string) (string, string, error) {
+				exec := podexec.Container(podExec, namespace, pod, util.ContainerDatabase)
+				return podexec.PSQL(exec, "", "SELECT pg_catalog.pg_is_in_recovery()")
+			})
+
+		if misrouted > 0 {
+			return errors.New("one or more endpoints do not route to the intended role")
+		}
+		return nil
+	}
+
+	return cmdShowEndpoints
+}
+
+// countEndpoints returns the number of ready and not ready addresses.
+func countEndpoints(endpoints *corev1.Endpoints) (ready, notReady int) {
+	for _, subset := range endpoints.Subsets {
+		ready += len(subset.Addresses)
+		notReady += len(subset.NotReadyAddresses)
+	}
+	return
+}
+
+// serviceEndpoints is a client Service of a cluster and its endpoints.
+type serviceEndpoints struct {
+	clusterService
+	endpoints *corev1.Endpoints
+}
+
+// endpointInstance returns the name of the instance Pod behind address using
+// instances, which are indexed by both name and IP. Addresses without a
+// TargetRef to a Pod are matched by IP. It returns empty when address is not
+// an instance.
+func endpointInstance(address corev1.EndpointAddress, instances map[string]string) string {
+	if address.TargetRef != nil && address.TargetRef.Kind == "Pod" {
+		return instances[address.TargetRef.Name]
+	}
+	return instances[address.IP]
+}
+
+// checkEndpointRoutes prints the role of the instance behind each endpoint of
+// services that route to Postgres instances, using inRecovery to query
+// pg_is_in_recovery() in a Pod. It returns the number of endpoints that do not
+// route to the intended role.
+func checkEndpointRoutes(
+	cmd *cobra.Command, services []serviceEndpoints, pods []corev1.Pod,
+	inRecovery func(pod string) (string, string, error),
+) int {
+	// Index instance Pods by name and IP so endpoints without a TargetRef
+	// can be matched too.
+	instances := map[string]string{}
+	for _, pod := range pods {
+		instances[pod.Name] = pod.Name
+		if pod.Status.PodIP != "" {
+			instances[pod.Status.PodIP] = pod.Name
+		}
+	}
+
+	var misrouted int
+	for _, service := range services {
+		// Only Postgres instances have a role; pgBouncer does not.
+		if service.Primary == nil {
+			continue
+		}
+		for _, subset := range service.endpoints.Subsets {
+			for _, address := range subset.Addresses {
+				name := endpointInstance(address, instances)
+				if name == "" {
+					cmd.Printf("%s -> %s: not a Postgres instance (MISROUTED)\n",
+						service.Name, address.IP)
+					misrouted++
+					continue
+				}
+
+				stdout, stderr, err := inRecovery(name)
+				if err != nil {
+					cmd.Printf("%s -> %s: unknown (%s)\n", service.Name, name,
+						strings.TrimSpace(stderr+" "+err.Error()))
+					misrouted++
+					continue
+				}
+
+				isPrimary := strings.TrimSpace(stdout) == "f"
+				role, result := "replica", "ok"
+				if isPrimary {
+					role = "primary"
+				}
+				if isPrimary != *service.Primary {
+					result = "MISROUTED"
+					misrouted++
+				}
+				cmd.Printf("%s -> %s: %s (%s)\n", service.Name, name, role, result)
+			}
+		}
+	}
+	return misrouted
+}
@@ -0,0 +1,168 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestCountEndpoints(t *testing.T) {
+	for _, tt := range []struct {
+		subsets         []corev1.EndpointSubset
+		ready, notReady int
+	}{
+		{subsets: nil, ready: 0, notReady: 0},
+		{
+			subsets: []corev1.EndpointSubset{{
+				Addresses:         []corev1.EndpointAddress{{IP: "10.0.0.1"}},
+				NotReadyAddresses: []corev1.EndpointAddress{{IP: "10.0.0.2"}},
+			}},
+			ready: 1, notReady: 1,
+		},
+		{
+			subsets: []corev1.EndpointSubset{
+				{Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}}},
+				{NotReadyAddresses: []corev1.EndpointAddress{{IP: "10.0.0.3"}}},
+			},
+			ready: 2, notReady: 1,
+		},
+	} {
+		ready, notReady := countEndpoints(&corev1.Endpoints{Subsets: tt.subsets})
+		assert.Equal(t, ready, tt.ready, "%v", tt.subsets)
+		assert.Equal(t, notReady, tt.notReady, "%v", tt.subsets)
+	}
+}
+
+func TestEndpointInstance(t *testing.T) {
+	instances := map[string]string{
+		"hippo-instance1-8x7m-0": "hippo-instance1-8x7m-0",
+		"10.0.0.1":               "hippo-instance1-8x7m-0",
+	}
+
+	for _, tt := range []struct {
+		address corev1.EndpointAddress
+		expect  string
+	}{
+		{address: corev1.EndpointAddress{IP: "10.0.0.1"}, expect: "hippo-instance1-8x7m-0"},
+		{address: corev1.EndpointAddress{IP: "10.0.0.9"}, expect: ""},
+		{
+			address: corev1.EndpointAddress{IP: "10.0.0.9",
+				TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "hippo-instance1-8x7m-0"}},
+			expect: "hippo-instance1-8x7m-0",
+		},
+		{
+			// A TargetRef to a Pod wins over the IP.
+			address: corev1.EndpointAddress{IP: "10.0.0.1",
+				TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "hippo-pgbouncer-abc"}},
+			expect: "",
+		},
+		{
+			// Other kinds of TargetRef are matched by IP.
+			address: corev1.EndpointAddress{IP: "10.0.0.1",
+				TargetRef: &corev1.ObjectReference{Kind: "Node", Name: "worker"}},
+			expect: "hippo-instance1-8x7m-0",
+		},
+	} {
+		assert.Equal(t, endpointInstance(tt.address, instances), tt.expect, "%+v", tt.address)
+	}
+}
+
+func TestCheckEndpointRoutes(t *testing.T) {
+	pod := func(name, ip string) corev1.Pod {
+		var pod corev1.Pod
+		pod.Name, pod.Status.PodIP = name, ip
+		return pod
+	}
+	pods := []corev1.Pod{
+		pod("hippo-instance1-8x7m-0", "10.0.0.1"),
+		pod("hippo-instance1-pwr2-0", "10.0.0.2"),
+		pod("hippo-instance1-zzzz-0", ""),
+	}
+	recovery := map[string]string{
+		"hippo-instance1-8x7m-0": "f\n",
+		"hippo-instance1-pwr2-0": "t\n",
+	}
+	inRecovery := func(pod string) (string, string, error) {
+		if stdout, ok := recovery[pod]; ok {
+			return stdout, "", nil
+		}
+		return "", "connection refused", errors.New("exit status 2")
+	}
+	endpoints := func(addresses ...corev1.EndpointAddress) *corev1.Endpoints {
+		return &corev1.Endpoints{Subsets: []corev1.EndpointSubset{{Addresses: addresses}}}
+	}
+	services := clusterServices("hippo")
+
+	for _, tt := range []struct {
+		name      string
+		services  []serviceEndpoints
+		misrouted int
+		output    string
+	}{
+		{
+			name: "Routed",
+			services: []serviceEndpoints{
+				{services[0], endpoints(corev1.EndpointAddress{IP: "10.0.0.1"})},
+				{services[1], endpoints(corev1.EndpointAddress{IP: "10.0.0.9",
+					TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "hippo-instance1-pwr2-0"}})},
+			},
+			output: "" +
+				"hippo-primary -> hippo-instance1-8x7m-0: primary (ok)\n" +
+				"hippo-replicas -> hippo-instance1-pwr2-0: replica (ok)\n",
+		},
+		{
+			name: "Misrouted",
+			services: []serviceEndpoints{
+				{services[0], endpoints(corev1.EndpointAddress{IP: "10.0.0.2"})},
+				{services[1], endpoints(corev1.EndpointAddress{IP: "10.0.0.1"})},
+			},
+			misrouted: 2,
+			output: "" +
+				"hippo-primary -> hippo-instance1-pwr2-0: replica (MISROUTED)\n" +
+				"hippo-replicas -> hippo-instance1-8x7m-0: primary (MISROUTED)\n",
+		},
+		{
+			name: "NotAnInstance",
+			services: []serviceEndpoints{
+				{services[0], endpoints(corev1.EndpointAddress{IP: ""}, corev1.EndpointAddress{IP: "10.0.0.7"})},
+			},
+			misrouted: 2,
+			output: "" +
+				"hippo-primary -> : not a Postgres instance (MISROUTED)\n" +
+				"hippo-primary -> 10.0.0.7: not a Postgres instance (MISROUTED)\n",
+		},
+		{
+			name: "Unknown",
+			services: []serviceEndpoints{
+				{services[1], endpoints(corev1.EndpointAddress{
+					TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "hippo-instance1-zzzz-0"}})},
+			},
+			misrouted: 1,
+			output:    "hippo-replicas -> hippo-instance1-zzzz-0: unknown (connection refused exit status 2)\n",
+		},
+		{
+			// pgBouncer has no role, so its endpoints are not queried.
+			name: "PGBouncer",
+			services: []serviceEndpoints{
+				{services[2], endpoints(corev1.EndpointAddress{IP: "10.0.0.5"})},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := &cobra.Command{}
+			cmd.SetOut(&out)
+
+			assert.Equal(t, checkEndpointRoutes(cmd, tt.services, pods, inRecovery), tt.misrouted)
+			assert.Equal(t, out.String(), tt.output)
+		})
+	}
+}
@@ -0,0 +1,354 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	"github.com/crunchydata/postgres-operator-client/internal/flags"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newShowJobsCommand returns the jobs subcommand of the show command. It lists
+// the Jobs that PGO created for a PostgresCluster in one place.
+func newShowJobsCommand(config *internal.Config) *cobra.Command {
+
+	cmdShowJobs := &cobra.Command{
+		Use:   "jobs CLUSTER_NAME",
+		Short: "Show backup, restore, and upgrade Jobs of a PostgresCluster",
+		Long: `Show the Jobs that PGO created for a PostgresCluster: pgBackRest backups,
+pgBackRest restores, and PGUpgrades. Each Job is shown with its type, status,
+and when it started and finished.
+
+Use the "--logs-failed" flag to also print the logs of the Pods of failed Jobs.
+Use "--since" and "--until" to only show Jobs created in a span of time.
+
+Backups that appear stuck are flagged below the table: a manual backup that
+was requested longer ago than "--stuck-after" without a Job to run it, and
+Pending Jobs whose Pods cannot be scheduled or created. The reason is shown
+when Kubernetes reports one, such as a node selector that matches no node, an
+unbound PersistentVolumeClaim, or an exceeded ResourceQuota.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    events                                              [list]
+    jobs                                                [list]
+    pods                                                [list]
+    pods/log                                            [get]
+    postgresclusters.postgres-operator.crunchydata.com  [get]
+
+### Usage`,
+	}
+
+	cmdShowJobs.Example = internal.FormatExample(`# Show the Jobs of the 'hippo' postgrescluster
+pgo show jobs hippo
+
+# Also print the logs of failed Jobs
+pgo show jobs hippo --logs-failed
+
+# Show the Jobs of the last day
+pgo show jobs hippo --since=24h
+
+### Example output
+NAME                         TYPE                   STATUS    STARTED               COMPLETED
+hippo-repo1-full-8tvd2       backup/scheduled       Complete  2024-05-01T01:00:02Z  2024-05-01T01:01:10Z
+hippo-backup-b9p7            backup/manual          Failed    2024-05-01T14:22:37Z  -
+hippo-pgbackrest-restore     restore                Running   2024-05-01T14:30:00Z  -
+
+WARNING: Job hippo-backup-x2k4 is Pending: PVC: 0/3 nodes are available: pod has unbound immediate PersistentVolumeClaims.`)
+
+	var logsFailed bool
+	cmdShowJobs.Flags().BoolVar(&logsFailed, "logs-failed", false,
+		"print the logs of the Pods of failed Jobs")
+
+	var window flags.Window
+	window.AddFlags(cmdShowJobs.Flags(), "Jobs created")
+
+	var stuckAfter time.Duration
+	cmdShowJobs.Flags().DurationVar(&stuckAfter, "stuck-after", 10*time.Minute,
+		"how long a manual backup can wait for its Job before it is flagged")
+
+	// Limit the number of args, that is, only one cluster name
+	cmdShowJobs.Args = cobra.ExactArgs(1)
+
+	cmdShowJobs.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		clientset, err := kubernetes.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		_, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+
+		cluster, err := client.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		jobs, err := clientset.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.LabelCluster + "=" + args[0],
+		})
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		var warnings []string
+		if message, stuck := stuckBackupTrigger(cluster, jobs.Items, stuckAfter, now); stuck {
+			warnings = append(warnings, message)
+		}
+
+		created := jobs.Items[:0]
+		for _, job := range jobs.Items {
+			if window.Contains(job.CreationTimestamp.Time, now) {
+				created = append(created, job)
+			}
+		}
+		jobs.Items = created
+		if len(jobs.Items) == 0 {
+			cmd.Printf("No Jobs found for cluster %s\n", args[0])
+			for _, warning := range warnings {
+				cmd.PrintErrf("WARNING: %s\n", warning)
+			}
+			return nil
+		}
+
+		// Show the oldest Jobs first.
+		sort.SliceStable(jobs.Items, func(i, j int) bool {
+			return jobs.Items[i].CreationTimestamp.Before(&jobs.Items[j].CreationTimestamp)
+		})
+
+		writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+		_, _ = fmt.Fprintln(writer, "NAME\tTYPE\tSTATUS\tSTARTED\tCOMPLETED")
+
+		var failed []batchv1.Job
+		for _, job := range jobs.Items {
+			status := jobStatus(&job)
+			if status == "Failed" {
+				failed = append(failed, job)
+			}
+			_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", job.GetName(),
+				jobType(&job), status,
+				formatJobTime(job.Status.StartTime), formatJobTime(job.Status.CompletionTime))
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+
+		// Look for the reason only when some Job has not started any Pod yet.
+		var events *corev1.EventList
+		for _, job := range jobs.Items {
+			if jobStatus(&job) != "Pending" {
+				continue
+			}
+			if events == nil {
+				events, err = clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+					FieldSelector: "involvedObject.kind=Job",
+				})
+				if err != nil {
+					return err
+				}
+			}
+			pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+				LabelSelector: "job-name=" + job.GetName(),
+			})
+			if err != nil {
+				return err
+			}
+			if reason := pendingJobReason(&job, pods.Items, events.Items); reason != "" {
+				warnings = append(warnings,
+					fmt.Sprintf("Job %s is Pending: %s", job.GetName(), reason))
+			}
+		}
+		if len(warnings) > 0 {
+			cmd.Println()
+		}
+		for _, warning := range warnings {
+			cmd.PrintErrf("WARNING: %s\n", warning)
+		}
+
+		if !logsFailed {
+			return nil
+		}
+
+		for _, job := range failed {
+			pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+				LabelSelector: "job-name=" + job.GetName(),
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, pod := range pods.Items {
+				for _, container := range pod.Spec.Containers {
+					cmd.Printf("\n--- %s: pod/%s container/%s ---\n",
+						job.GetName(), pod.GetName(), container.Name)
+
+					b, err := clientset.CoreV1().Pods(namespace).
+						GetLogs(pod.GetName(), &corev1.PodLogOptions{
+							Container: container.Name,
+						}).Do(ctx).Raw()
+					if err != nil {
+						cmd.Printf("Error getting logs: %s\n", err)
+						continue
+					}
+					cmd.Printf("%s", b)
+				}
+			}
+		}
+
+		return nil
+	}
+
+	return cmdShowJobs
+}
+
+// jobType describes the purpose of a Job that PGO created using its labels.
+func jobType(job *batchv1.Job) string {
+	labels := job.GetLabels()
+
+	if backup, ok := labels[util.LabelPGBackRestBackup]; ok {
+		if backup == "" {
+			return "backup"
+		}
+		return "backup/" + backup
+	}
+	if _, ok := labels[util.LabelPGBackRestRestore]; ok {
+		return "restore"
+	}
+	if _, ok := labels[util.LabelPGUpgrade]; ok {
+		return "pgupgrade"
+	}
+	return "other"
+}
+
+// jobStatus returns Complete or Failed when the Job has finished, and Pending
+// or Running otherwise.
+func jobStatus(job *batchv1.Job) string {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return "Complete"
+		case batchv1.JobFailed:
+			return "Failed"
+		}
+	}
+	if job.Status.Active > 0 {
+		return "Running"
+	}
+	return "Pending"
+}
+
+// formatJobTime returns timestamp in RFC 3339 format or "-" when it is not set.
+func formatJobTime(timestamp *metav1.Time) string {
+	if timestamp == nil {
+		return "-"
+	}
+	return timestamp.UTC().Format("2006-01-02T15:04:05Z")
+}
+
+// stuckBackupTrigger returns a message when the manual backup annotation of
+// cluster was set longer than stuckAfter before now and no Job in jobs runs
+// that backup.
+func stuckBackupTrigger(
+	cluster *unstructured.Unstructured, jobs []batchv1.Job,
+	stuckAfter time.Duration, now time.Time,
+) (string, bool) {
+	trigger := cluster.GetAnnotations()[util.AnnotationPGBackRestBackup]
+	if trigger == "" {
+		return "", false
+	}
+
+	// PGO records the trigger it handled in status and copies it to the Job.
+	if handled, _, _ := unstructured.NestedString(cluster.Object,
+		"status", "pgbackrest", "manualBackup", "name"); handled == trigger {
+		return "", false
+	}
+	for _, job := range jobs {
+		if job.GetAnnotations()[util.AnnotationPGBackRestBackup] == trigger {
+			return "", false
+		}
+	}
+
+	// The backup command sets the annotation to the current time. Values that
+	// are not timestamps cannot be aged and are not reported.
+	requested, err := time.Parse(time.RFC3339, trigger)
+	if err != nil {
+		return "", false
+	}
+	if waiting := now.Sub(requested); waiting > stuckAfter {
+		return fmt.Sprintf("manual backup %q was requested %s ago but no Job was created;"+
+			" check that spec.backups.pgbackrest.manual is set and the operator is running",
+			trigger, waiting.Round(time.Second)), true
+	}
+	return "", false
+}
+
+// pendingJobReason explains why job has not started, using the scheduling
+// condition of its pods and the events of the Job. It returns an empty string
+// when Kubernetes has not reported a reason.
+func pendingJobReason(job *batchv1.Job, pods []corev1.Pod, events []corev1.Event) string {
+	for _, pod := range pods {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodScheduled &&
+				condition.Status == corev1.ConditionFalse {
+				return classifyPendingReason(condition.Message)
+			}
+		}
+	}
+
+	for _, event := range events {
+		if event.InvolvedObject.Kind == "Job" &&
+			event.InvolvedObject.Name == job.GetName() &&
+			event.InvolvedObject.UID == job.GetUID() &&
+			event.Reason == "FailedCreate" {
+			return classifyPendingReason(event.Message)
+		}
+	}
+	return ""
+}
+
+// classifyPendingReason prefixes message with the kind of problem it reports.
+func classifyPendingReason(message string) string {
+	lower := strings.ToLower(message)
+	switch {
+	case strings.Contains(lower, "exceeded quota"):
+		return "quota: " + message
+	case strings.Contains(lower, "persistentvolumeclaim"):
+		return "PVC: " + message
+	case strings.Contains(lower, "node affinity/selector"),
+		strings.Contains(lower, "node selector"):
+		return "node selector: " + message
+	}
+	return message
+}
@@ -0,0 +1,135 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+func TestJobType(t *testing.T) {
+	for _, tt := range []struct {
+		labels map[string]string
+		expect string
+	}{
+		{labels: map[string]string{util.LabelPGBackRestBackup: "manual"}, expect: "backup/manual"},
+		{labels: map[string]string{util.LabelPGBackRestBackup: ""}, expect: "backup"},
+		{labels: map[string]string{util.LabelPGBackRestRestore: ""}, expect: "restore"},
+		{labels: map[string]string{util.LabelPGUpgrade: "hippo-upgrade"}, expect: "pgupgrade"},
+		{labels: map[string]string{util.LabelCluster: "hippo"}, expect: "other"},
+	} {
+		job := &batchv1.Job{}
+		job.Labels = tt.labels
+		assert.Equal(t, jobType(job), tt.expect, "%v", tt.labels)
+	}
+}
+
+func TestJobStatus(t *testing.T) {
+	job := &batchv1.Job{}
+	assert.Equal(t, jobStatus(job), "Pending")
+
+	job.Status.Active = 1
+	assert.Equal(t, jobStatus(job), "Running")
+
+	job.Status.Conditions = []batchv1.JobCondition{
+		{Type: batchv1.JobFailed, Status: corev1.ConditionFalse},
+	}
+	assert.Equal(t, jobStatus(job), "Running")
+
+	job.Status.Active = 0
+	job.Status.Conditions[0].Status = corev1.ConditionTrue
+	assert.Equal(t, jobStatus(job), "Failed")
+
+	job.Status.Conditions = []batchv1.JobCondition{
+		{Type: batchv1.JobComplete, Status: corev1.ConditionTrue},
+	}
+	assert.Equal(t, jobStatus(job), "Complete")
+}
+
+func TestStuckBackupTrigger(t *testing.T) {
+	now := time.Date(2024, 5, 1, 15, 0, 0, 0, time.UTC)
+	cluster := &unstructured.Unstructured{Object: map[string]any{}}
+
+	_, stuck := stuckBackupTrigger(cluster, nil, 10*time.Minute, now)
+	assert.Assert(t, !stuck, "no annotation")
+
+	cluster.SetAnnotations(map[string]string{
+		util.AnnotationPGBackRestBackup: "2024-05-01T14:30:00Z",
+	})
+	message, stuck := stuckBackupTrigger(cluster, nil, 10*time.Minute, now)
+	assert.Assert(t, stuck)
+	assert.Assert(t, strings.Contains(message, `"2024-05-01T14:30:00Z" was requested 30m0s ago`), message)
+
+	_, stuck = stuckBackupTrigger(cluster, nil, time.Hour, now)
+	assert.Assert(t, !stuck, "not old enough")
+
+	t.Run("JobExists", func(t *testing.T) {
+		job := batchv1.Job{}
+		job.Annotations = map[string]string{util.AnnotationPGBackRestBackup: "2024-05-01T14:30:00Z"}
+
+		_, stuck := stuckBackupTrigger(cluster, []batchv1.Job{job}, 10*time.Minute, now)
+		assert.Assert(t, !stuck)
+	})
+
+	t.Run("Handled", func(t *testing.T) {
+		cluster := cluster.DeepCopy()
+		assert.NilError(t, unstructured.SetNestedField(cluster.Object,
+			"2024-05-01T14:30:00Z", "status", "pgbackrest", "manualBackup", "name"))
+
+		_, stuck := stuckBackupTrigger(cluster, nil, 10*time.Minute, now)
+		assert.Assert(t, !stuck)
+	})
+
+	t.Run("NotTimestamp", func(t *testing.T) {
+		cluster := cluster.DeepCopy()
+		cluster.SetAnnotations(map[string]string{util.AnnotationPGBackRestBackup: "one"})
+
+		_, stuck := stuckBackupTrigger(cluster, nil, 10*time.Minute, now)
+		assert.Assert(t, !stuck)
+	})
+}
+
+func TestPendingJobReason(t *testing.T) {
+	job := &batchv1.Job{}
+	job.Name, job.UID = "hippo-backup-x2k4", "uid1"
+
+	assert.Equal(t, pendingJobReason(job, nil, nil), "")
+
+	unschedulable := corev1.Pod{Status: corev1.PodStatus{
+		Conditions: []corev1.PodCondition{{
+			Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: "Unschedulable",
+			Message: "0/3 nodes are available: 3 node(s) didn't match Pod's node affinity/selector.",
+		}},
+	}}
+	assert.Equal(t, pendingJobReason(job, []corev1.Pod{unschedulable}, nil),
+		"node selector: 0/3 nodes are available: 3 node(s) didn't match Pod's node affinity/selector.")
+
+	unschedulable.Status.Conditions[0].Message =
+		"0/3 nodes are available: pod has unbound immediate PersistentVolumeClaims."
+	assert.Equal(t, pendingJobReason(job, []corev1.Pod{unschedulable}, nil),
+		"PVC: 0/3 nodes are available: pod has unbound immediate PersistentVolumeClaims.")
+
+	events := []corev1.Event{
+		{
+			InvolvedObject: corev1.ObjectReference{Kind: "Job", Name: job.Name, UID: "old"},
+			Reason:         "FailedCreate", Message: "from an earlier Job",
+		},
+		{
+			InvolvedObject: corev1.ObjectReference{Kind: "Job", Name: job.Name, UID: job.UID},
+			Reason:         "FailedCreate",
+			Message:        `Error creating: pods "hippo-backup-x2k4-abcde" is forbidden: exceeded quota: compute`,
+		},
+	}
+	assert.Equal(t, pendingJobReason(job, nil, events),
+		`quota: Error creating: pods "hippo-backup-x2k4-abcde" is forbidden: exceeded quota: compute`)
+}
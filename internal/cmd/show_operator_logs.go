@@ -0,0 +1,284 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	"github.com/crunchydata/postgres-operator-client/internal/flags"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newShowOperatorLogsCommand returns the operator-logs subcommand of the show
+// command. It prints the operator log entries about one PostgresCluster and a
+// summary of its reconcile errors.
+func newShowOperatorLogsCommand(config *internal.Config) *cobra.Command {
+
+	cmdShowOperatorLogs := &cobra.Command{
+		Use:   "operator-logs",
+		Short: "Show operator log entries about a PostgresCluster",
+		Long: `Show the log entries of the PGO operator Pods that mention a PostgresCluster
+by name or UID, followed by the reconcile errors of that cluster grouped by
+their cause.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    pods                                                [list]
+    pods/log                                            [get]
+    postgresclusters.postgres-operator.crunchydata.com  [get]
+
+    Note: Without "--operator-namespace", the operator is found by listing
+    deployments in all namespaces, which requires cluster-scoped RBAC. The
+    namespace of the cluster is used when that is not allowed.
+
+### Usage`,
+	}
+
+	cmdShowOperatorLogs.Example = internal.FormatExample(`# Show what the operator logged about the 'hippo' postgrescluster in the last hour
+pgo show operator-logs --cluster=hippo
+
+# Look further back when the operator runs in another namespace
+pgo show operator-logs --cluster=hippo --since=2h --operator-namespace=pgo
+
+### Example output
+{"level":"error","ts":"2024-05-01T14:22:37Z","msg":"Reconciler error","name":"hippo","namespace":"postgres-operator","error":"..."}
+
+RECONCILE ERRORS
+COUNT  LAST SEEN             CAUSE
+3      2024-05-01T14:22:37Z  the object has been modified; please apply your changes to the latest version and try again`)
+
+	var clusterName, operatorNamespace string
+	window := flags.Window{Since: flags.NewTime("1h")}
+	cmdShowOperatorLogs.Flags().StringVar(&clusterName, "cluster", "", "name of the postgrescluster")
+	cobra.CheckErr(cmdShowOperatorLogs.MarkFlagRequired("cluster"))
+	window.AddFlags(cmdShowOperatorLogs.Flags(), "entries")
+	cmdShowOperatorLogs.Flags().StringVar(&operatorNamespace, "operator-namespace", "",
+		"namespace of the operator; found from its deployment by default")
+
+	cmdShowOperatorLogs.Args = cobra.NoArgs
+
+	cmdShowOperatorLogs.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		clientset, err := kubernetes.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		if operatorNamespace == "" {
+			operatorNamespace = operatorNamespaceFor(ctx, clientset, namespace)
+		}
+
+		// The cluster may already be gone; match entries by name alone then.
+		target := operatorLogTarget{Name: clusterName, Namespace: namespace}
+		_, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		cluster, err := client.Namespace(namespace).Get(ctx, clusterName, metav1.GetOptions{})
+		switch {
+		case err == nil:
+			target.UID = string(cluster.GetUID())
+		case !apierrors.IsNotFound(err):
+			return err
+		}
+
+		pods, err := clientset.CoreV1().Pods(operatorNamespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.LabelOperator,
+		})
+		if err != nil {
+			return err
+		}
+		if len(pods.Items) == 0 {
+			return fmt.Errorf("no operator Pods found in namespace %s", operatorNamespace)
+		}
+
+		now := time.Now()
+		since := metav1.NewTime(window.Since.At(now))
+		var entries []operatorLogEntry
+		for _, pod := range pods.Items {
+			for _, container := range pod.Spec.Containers {
+				b, err := clientset.CoreV1().Pods(operatorNamespace).
+					GetLogs(pod.GetName(), &corev1.PodLogOptions{
+						Container: container.Name,
+						SinceTime: &since,
+					}).Do(ctx).Raw()
+				if err != nil {
+					return err
+				}
+
+				for _, line := range strings.Split(string(b), "\n") {
+					if entry := parseOperatorLogLine(line); target.matches(entry) && entry.within(&window, now) {
+						entries = append(entries, entry)
+						cmd.Println(entry.Line)
+					}
+				}
+			}
+		}
+
+		if len(entries) == 0 {
+			cmd.Printf("No operator log entries found for %s/%s since %s\n",
+				namespace, clusterName, since.UTC().Format(time.RFC3339))
+			return nil
+		}
+
+		groups := groupReconcileErrors(entries)
+		if len(groups) == 0 {
+			return nil
+		}
+
+		cmd.Println("\nRECONCILE ERRORS")
+		writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+		_, _ = fmt.Fprintln(writer, "COUNT\tLAST SEEN\tCAUSE")
+		for _, group := range groups {
+			_, _ = fmt.Fprintf(writer, "%d\t%s\t%s\n", group.Count, group.LastSeen, group.Cause)
+		}
+		return writer.Flush()
+	}
+
+	return cmdShowOperatorLogs
+}
+
+// operatorLogEntry is one line of operator log output. The fields other than
+// Line are set only when the line is JSON.
+type operatorLogEntry struct {
+	Line string
+
+	Error     string
+	Level     string
+	Name      string
+	Namespace string
+	Time      string
+}
+
+// within returns true when entry was logged in window, or when its time is
+// not known.
+func (entry operatorLogEntry) within(window *flags.Window, now time.Time) bool {
+	logged, err := time.Parse(time.RFC3339Nano, entry.Time)
+	return err != nil || window.Contains(logged, now)
+}
+
+// parseOperatorLogLine reads the fields of line that identify its subject and
+// its error, if any.
+func parseOperatorLogLine(line string) operatorLogEntry {
+	entry := operatorLogEntry{Line: strings.TrimSpace(line)}
+
+	var fields map[string]any
+	if json.Unmarshal([]byte(entry.Line), &fields) != nil {
+		return entry
+	}
+
+	str := func(key string) string { s, _ := fields[key].(string); return s }
+	entry.Error = str("error")
+	entry.Level = str("level")
+	entry.Name = str("name")
+	entry.Namespace = str("namespace")
+
+	// The controller-runtime logger nests the object being reconciled.
+	if object, ok := fields["PostgresCluster"].(map[string]any); ok {
+		if name, ok := object["name"].(string); ok {
+			entry.Name = name
+		}
+		if namespace, ok := object["namespace"].(string); ok {
+			entry.Namespace = namespace
+		}
+	}
+
+	switch ts := fields["ts"].(type) {
+	case string:
+		entry.Time = ts
+	case float64:
+		entry.Time = time.Unix(0, int64(ts*float64(time.Second))).UTC().Format(time.RFC3339)
+	}
+
+	return entry
+}
+
+// operatorLogTarget identifies the PostgresCluster to find in operator logs.
+type operatorLogTarget struct {
+	Name, Namespace, UID string
+}
+
+// matches returns true when entry is about target. JSON entries are compared
+// by name and namespace; other lines are searched for both or for the UID.
+func (target operatorLogTarget) matches(entry operatorLogEntry) bool {
+	switch {
+	case entry.Line == "":
+		return false
+	case target.UID != "" && strings.Contains(entry.Line, target.UID):
+		return true
+	case entry.Name != "" || entry.Namespace != "":
+		return entry.Name == target.Name && entry.Namespace == target.Namespace
+	}
+	return strings.Contains(entry.Line, target.Name) &&
+		strings.Contains(entry.Line, target.Namespace)
+}
+
+// reconcileErrorGroup counts the entries that share the same cause.
+type reconcileErrorGroup struct {
+	Cause    string
+	Count    int
+	LastSeen string
+}
+
+// groupReconcileErrors groups the entries that have an error by the innermost
+// part of their error message. Groups are sorted by count, largest first.
+func groupReconcileErrors(entries []operatorLogEntry) []reconcileErrorGroup {
+	index := map[string]*reconcileErrorGroup{}
+	var groups []*reconcileErrorGroup
+
+	for _, entry := range entries {
+		if entry.Error == "" {
+			continue
+		}
+
+		// Wrapped errors read "outer: inner"; the last part is the cause.
+		cause := entry.Error
+		if i := strings.LastIndex(cause, ": "); i >= 0 {
+			cause = cause[i+2:]
+		}
+
+		group, ok := index[cause]
+		if !ok {
+			group = &reconcileErrorGroup{Cause: cause}
+			index[cause] = group
+			groups = append(groups, group)
+		}
+		group.Count++
+		if entry.Time > group.LastSeen {
+			group.LastSeen = entry.Time
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Count > groups[j].Count })
+
+	result := make([]reconcileErrorGroup, len(groups))
+	for i := range groups {
+		result[i] = *groups[i]
+	}
+	return result
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/crunchydata/postgres-operator-client/internal/flags"
+)
+
+func TestParseOperatorLogLine(t *testing.T) {
+	t.Run("JSON", func(t *testing.T) {
+		entry := parseOperatorLogLine(`{"level":"error","ts":"2024-05-01T14:22:37Z",` +
+			`"msg":"Reconciler error","PostgresCluster":{"name":"hippo","namespace":"ns1"},` +
+			`"error":"update failed: conflict"}` + "\n")
+
+		assert.Equal(t, entry.Level, "error")
+		assert.Equal(t, entry.Name, "hippo")
+		assert.Equal(t, entry.Namespace, "ns1")
+		assert.Equal(t, entry.Time, "2024-05-01T14:22:37Z")
+		assert.Equal(t, entry.Error, "update failed: conflict")
+	})
+
+	t.Run("Epoch", func(t *testing.T) {
+		entry := parseOperatorLogLine(`{"ts":1714573357.5,"name":"hippo"}`)
+		assert.Equal(t, entry.Time, "2024-05-01T14:22:37Z")
+	})
+
+	t.Run("Text", func(t *testing.T) {
+		entry := parseOperatorLogLine(` level=info msg="reconciling" name=hippo `)
+		assert.Equal(t, entry, operatorLogEntry{Line: `level=info msg="reconciling" name=hippo`})
+	})
+}
+
+func TestOperatorLogTargetMatches(t *testing.T) {
+	target := operatorLogTarget{Name: "hippo", Namespace: "ns1", UID: "1234-abcd"}
+
+	assert.Assert(t, !target.matches(operatorLogEntry{}))
+	assert.Assert(t, target.matches(operatorLogEntry{Line: "x", Name: "hippo", Namespace: "ns1"}))
+	assert.Assert(t, !target.matches(operatorLogEntry{Line: "x", Name: "hippo", Namespace: "ns2"}))
+	assert.Assert(t, target.matches(operatorLogEntry{Line: "owner 1234-abcd", Name: "other"}))
+	assert.Assert(t, target.matches(operatorLogEntry{Line: "name=hippo namespace=ns1"}))
+	assert.Assert(t, !target.matches(operatorLogEntry{Line: "name=hippo namespace=ns2"}))
+}
+
+func TestOperatorLogEntryWithin(t *testing.T) {
+	now := time.Date(2024, 5, 1, 15, 0, 0, 0, time.UTC)
+	window := flags.Window{Since: flags.NewTime("1h"), Until: flags.NewTime("30m")}
+
+	assert.Assert(t, operatorLogEntry{Time: "2024-05-01T14:22:37Z"}.within(&window, now))
+	assert.Assert(t, !operatorLogEntry{Time: "2024-05-01T14:45:00Z"}.within(&window, now))
+	assert.Assert(t, !operatorLogEntry{Time: "2024-05-01T13:00:00Z"}.within(&window, now))
+
+	// Entries without a time are kept.
+	assert.Assert(t, operatorLogEntry{Line: "panic: runtime error"}.within(&window, now))
+}
+
+func TestGroupReconcileErrors(t *testing.T) {
+	groups := groupReconcileErrors([]operatorLogEntry{
+		{Error: "patroni: dial tcp: timeout", Time: "2024-05-01T01:00:00Z"},
+		{Error: "apply: conflict", Time: "2024-05-01T03:00:00Z"},
+		{Time: "2024-05-01T04:00:00Z"},
+		{Error: "get: conflict", Time: "2024-05-01T02:00:00Z"},
+	})
+
+	assert.DeepEqual(t, groups, []reconcileErrorGroup{
+		{Cause: "conflict", Count: 2, LastSeen: "2024-05-01T03:00:00Z"},
+		{Cause: "timeout", Count: 1, LastSeen: "2024-05-01T01:00:00Z"},
+	})
+}
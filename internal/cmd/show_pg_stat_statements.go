@@ -0,0 +1,274 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// statementSorts are the values of the --sort flag and the columns of
+// pg_stat_statements they order by in PostgreSQL 13 and later.
+var statementSorts = map[string]string{
+	"total-time": "total_exec_time",
+	"mean-time":  "mean_exec_time",
+	"calls":      "calls",
+	"rows":       "rows",
+}
+
+// newShowPGStatStatementsCommand returns the pg-stat-statements subcommand of
+// the show command. It prints the statements that take the most time on the
+// primary and can reset the statistics.
+func newShowPGStatStatementsCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pg-stat-statements CLUSTER_NAME",
+		Short: "Show the top statements from pg_stat_statements",
+		Long: `Show the statements that run on the primary of a PostgresCluster ordered by
+their total time, mean time, calls, or rows, as recorded by the
+pg_stat_statements extension. Statements of every database are shown; the
+extension only needs to be created in the one named by "--dbname".
+
+Use "--reset" after a review to clear the statistics so the next review only
+covers what ran since. Reset asks for confirmation.
+
+### RBAC Requirements
+    Resources  Verbs
+    ---------  -----
+    pods       [list]
+    pods/exec  [create]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Show the ten statements of the 'hippo' postgrescluster that take the most time
+pgo show pg-stat-statements hippo
+
+# Show the statements that return the most rows, then clear the statistics
+pgo show pg-stat-statements hippo --sort=rows --limit=5 --reset
+
+### Example output
+Statistics since 2024-05-01 09:00:00+00
+
+CALLS   TOTAL TIME  MEAN TIME  ROWS    DATABASE  QUERY
+184022  41m12.4s    13.4ms     184022  app       UPDATE pgbench_accounts SET abalance = abalance + $1 WHERE aid = $2
+184022  2m3.2s      0.7ms      184022  app       SELECT abalance FROM pgbench_accounts WHERE aid = $1
+12      48.1s       4.0s       1200    app       SELECT * FROM reports WHERE created > $1 ORDER BY total DESC`)
+
+	var (
+		database string
+		limit    int
+		reset    bool
+		sort     string
+		width    int
+	)
+	cmd.Flags().StringVar(&database, "dbname", "postgres", "database where the pg_stat_statements extension is created")
+	cmd.Flags().IntVar(&limit, "limit", 10, "number of statements to show")
+	cmd.Flags().BoolVar(&reset, "reset", false, "clear the statistics after showing them")
+	cmd.Flags().StringVar(&sort, "sort", "total-time", "order of statements. choices: total-time, mean-time, calls, rows")
+	cmd.Flags().IntVar(&width, "width", 100, "characters of each query to show; 0 shows all")
+
+	// Limit the number of args, that is, only one cluster name
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		if _, ok := statementSorts[sort]; !ok {
+			return fmt.Errorf("unknown sort %q; choices: total-time, mean-time, calls, rows", sort)
+		}
+		if limit < 1 {
+			return errors.New("--limit must be at least 1")
+		}
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+
+		pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.PrimaryInstanceLabels(args[0]),
+		})
+		if err != nil {
+			return err
+		}
+		var pod *corev1.Pod
+		for i := range pods.Items {
+			if podIsReady(&pods.Items[i]) {
+				pod = &pods.Items[i]
+				break
+			}
+		}
+		if pod == nil {
+			return errors.New("no ready primary instance Pod found")
+		}
+		exec := podexec.Container(podExec, namespace, pod.GetName(), util.ContainerDatabase)
+
+		stdout, stderr, err := podexec.PSQL(exec, database, statementsCheckSQL)
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr))
+		}
+		installed, version := parseStatementsCheck(stdout)
+		if !installed {
+			return fmt.Errorf("the pg_stat_statements extension is not created in database %q;"+
+				` add "pg_stat_statements" to shared_preload_libraries in spec.patroni.dynamicConfiguration`+
+				" then run CREATE EXTENSION pg_stat_statements", database)
+		}
+
+		// PostgreSQL 14 records when the statistics were last reset. Older
+		// versions of the extension lack the view, so errors are ignored.
+		var since string
+		if version >= 140000 {
+			stdout, _, _ = podexec.PSQL(exec, database, `SELECT stats_reset FROM pg_stat_statements_info;`)
+			since = strings.TrimSpace(stdout)
+		}
+
+		stdout, stderr, err = podexec.PSQL(exec, database, statementsSQL(version, sort, limit))
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr))
+		}
+		if err := printStatements(cmd, parseStatements(stdout), since, width); err != nil {
+			return err
+		}
+
+		if !reset {
+			return nil
+		}
+		_, _ = fmt.Fprint(config.ErrOut, config.Messages.Sprintf("show.statements.warn-reset")+
+			config.Messages.Sprintf("confirm.continue"))
+		var confirmed *bool
+		for i := 0; confirmed == nil && i < 10; i++ {
+			// retry 10 times or until a confirmation is given or denied,
+			// whichever comes first
+			confirmed = config.Messages.Confirm(config.In, config.ErrOut)
+		}
+		if confirmed == nil || !*confirmed {
+			return ErrCancelled
+		}
+		if _, stderr, err = podexec.PSQL(exec, database, `SELECT pg_stat_statements_reset();`); err != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr))
+		}
+		cmd.Println("pg_stat_statements reset")
+		return nil
+	}
+
+	return cmd
+}
+
+// statementsCheckSQL returns whether pg_stat_statements is created in the
+// current database and the server version.
+const statementsCheckSQL = `SELECT
+  EXISTS (SELECT FROM pg_catalog.pg_extension WHERE extname = 'pg_stat_statements'),
+  current_setting('server_version_num');`
+
+// parseStatementsCheck reads the output of [statementsCheckSQL].
+func parseStatementsCheck(stdout string) (installed bool, version int) {
+	if rows := parseRows(stdout); len(rows) > 0 && len(rows[0]) == 2 {
+		installed = rows[0][0] == "t"
+		version, _ = strconv.Atoi(rows[0][1])
+	}
+	return
+}
+
+// statementsSQL returns the query for the top limit statements by sort. The
+// time columns were renamed in PostgreSQL 13.
+func statementsSQL(version int, sort string, limit int) string {
+	total, mean, order := "total_exec_time", "mean_exec_time", statementSorts[sort]
+	if version < 130000 {
+		total, mean = "total_time", "mean_time"
+		order = strings.Replace(order, "_exec_", "_", 1)
+	}
+	return fmt.Sprintf(`SELECT s.calls, s.%[1]s, s.%[2]s, s.rows, COALESCE(d.datname, ''),
+  regexp_replace(s.query, '\s+', ' ', 'g')
+FROM pg_stat_statements s LEFT JOIN pg_catalog.pg_database d ON d.oid = s.dbid
+ORDER BY s.%[3]s DESC LIMIT %[4]d;`, total, mean, order, limit)
+}
+
+// statement is one row of pg_stat_statements. Times are in milliseconds.
+type statement struct {
+	Calls     int64
+	TotalTime float64
+	MeanTime  float64
+	Rows      int64
+	Database  string
+	Query     string
+}
+
+// parseStatements reads the output of [statementsSQL].
+func parseStatements(stdout string) []statement {
+	var statements []statement
+	for _, fields := range parseRows(stdout) {
+		if len(fields) != 6 {
+			continue
+		}
+		s := statement{Database: fields[4], Query: fields[5]}
+		s.Calls, _ = strconv.ParseInt(fields[0], 10, 64)
+		s.TotalTime, _ = strconv.ParseFloat(fields[1], 64)
+		s.MeanTime, _ = strconv.ParseFloat(fields[2], 64)
+		s.Rows, _ = strconv.ParseInt(fields[3], 10, 64)
+		statements = append(statements, s)
+	}
+	return statements
+}
+
+// formatMilliseconds prints ms rounded to a precision that suits its size.
+func formatMilliseconds(ms float64) string {
+	switch {
+	case ms < 1000:
+		return strconv.FormatFloat(ms, 'f', 1, 64) + "ms"
+	case ms < 60*1000:
+		return strconv.FormatFloat(ms/1000, 'f', 1, 64) + "s"
+	}
+	minutes := int64(ms / 60000)
+	return fmt.Sprintf("%dm%.1fs", minutes, (ms-float64(minutes)*60000)/1000)
+}
+
+// printStatements prints statements with their queries cut to width.
+func printStatements(cmd *cobra.Command, statements []statement, since string, width int) error {
+	if len(statements) == 0 {
+		cmd.Println("No statements recorded")
+		return nil
+	}
+	if since != "" {
+		cmd.Printf("Statistics since %s\n\n", since)
+	}
+
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "CALLS\tTOTAL TIME\tMEAN TIME\tROWS\tDATABASE\tQUERY")
+	for _, s := range statements {
+		query := s.Query
+		if width > 3 && len(query) > width {
+			query = query[:width-3] + "..."
+		}
+		_, _ = fmt.Fprintf(writer, "%d\t%s\t%s\t%d\t%s\t%s\n", s.Calls,
+			formatMilliseconds(s.TotalTime), formatMilliseconds(s.MeanTime),
+			s.Rows, s.Database, query)
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,76 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+)
+
+func TestParseStatementsCheck(t *testing.T) {
+	installed, version := parseStatementsCheck("t\t160002\n")
+	assert.Assert(t, installed)
+	assert.Equal(t, version, 160002)
+
+	installed, _ = parseStatementsCheck("f\t120010\n")
+	assert.Assert(t, !installed)
+
+	installed, version = parseStatementsCheck("")
+	assert.Assert(t, !installed)
+	assert.Equal(t, version, 0)
+}
+
+func TestStatementsSQL(t *testing.T) {
+	sql := statementsSQL(160000, "mean-time", 5)
+	assert.Assert(t, strings.Contains(sql, "s.total_exec_time, s.mean_exec_time"), sql)
+	assert.Assert(t, strings.Contains(sql, "ORDER BY s.mean_exec_time DESC LIMIT 5;"), sql)
+
+	sql = statementsSQL(120000, "total-time", 10)
+	assert.Assert(t, strings.Contains(sql, "s.total_time, s.mean_time"), sql)
+	assert.Assert(t, strings.Contains(sql, "ORDER BY s.total_time DESC LIMIT 10;"), sql)
+
+	sql = statementsSQL(120000, "calls", 10)
+	assert.Assert(t, strings.Contains(sql, "ORDER BY s.calls DESC"), sql)
+}
+
+func TestFormatMilliseconds(t *testing.T) {
+	assert.Equal(t, formatMilliseconds(0.66), "0.7ms")
+	assert.Equal(t, formatMilliseconds(4012.3), "4.0s")
+	assert.Equal(t, formatMilliseconds(2472400), "41m12.4s")
+}
+
+func TestPrintStatements(t *testing.T) {
+	statements := parseStatements("" +
+		"184022\t2472400.1\t13.43\t184022\tapp\tUPDATE pgbench_accounts SET abalance = abalance + $1 WHERE aid = $2\n" +
+		"184022\t123210\t0.66\t184022\tapp\tSELECT abalance FROM pgbench_accounts WHERE aid = $1\n" +
+		"12\t48100\t4008.3\t1200\tapp\tSELECT * FROM reports WHERE created > $1 ORDER BY total DESC\n")
+	assert.Equal(t, len(statements), 3)
+
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+	assert.NilError(t, printStatements(cmd, statements, "2024-05-01 09:00:00+00", 100))
+	assert.Equal(t, out.String(), `Statistics since 2024-05-01 09:00:00+00
+
+CALLS   TOTAL TIME  MEAN TIME  ROWS    DATABASE  QUERY
+184022  41m12.4s    13.4ms     184022  app       UPDATE pgbench_accounts SET abalance = abalance + $1 WHERE aid = $2
+184022  2m3.2s      0.7ms      184022  app       SELECT abalance FROM pgbench_accounts WHERE aid = $1
+12      48.1s       4.0s       1200    app       SELECT * FROM reports WHERE created > $1 ORDER BY total DESC
+`)
+
+	out.Reset()
+	assert.NilError(t, printStatements(cmd, statements[2:], "", 20))
+	assert.Equal(t, out.String(), ""+
+		"CALLS  TOTAL TIME  MEAN TIME  ROWS  DATABASE  QUERY\n"+
+		"12     48.1s       4.0s       1200  app       SELECT * FROM rep...\n")
+
+	out.Reset()
+	assert.NilError(t, printStatements(cmd, nil, "", 0))
+	assert.Equal(t, out.String(), "No statements recorded\n")
+}
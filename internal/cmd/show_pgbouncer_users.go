@@ -0,0 +1,396 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newShowPGBouncerUsersCommand returns the pgbouncer-users subcommand of the
+// show command. It explains which roles can log in through pgBouncer.
+func newShowPGBouncerUsersCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pgbouncer-users CLUSTER_NAME",
+		Short: "Show which users can connect through pgBouncer",
+		Long: `Show how pgBouncer authenticates users and which roles can connect through it.
+
+pgBouncer checks the roles in its auth_file first. PGO puts only its own role
+there; every other role is looked up with auth_query, which calls the
+pgbouncer.get_auth function in the database being connected to. That function
+does not return superusers, replication roles, roles that cannot log in, or
+roles whose password has expired. A role without a password cannot connect
+either.
+
+The report ends with the users in spec.users that cannot connect through
+pgBouncer and the databases of those users that have no pgbouncer.get_auth
+function.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    pods                                                [list]
+    pods/exec                                           [create]
+    postgresclusters.postgres-operator.crunchydata.com  [get]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Show the pgBouncer users of the 'hippo' postgrescluster
+pgo show pgbouncer-users hippo
+
+### Example output
+auth_type: scram-sha-256
+auth_user: _crunchypgbouncer
+auth_query: SELECT username, password from pgbouncer.get_auth($1)
+auth_file: /etc/pgbouncer/~postgres-operator/users.txt (_crunchypgbouncer)
+
+ROLE               IN SPEC  VIA PGBOUNCER  REASON
+_crunchypgbouncer  no       auth_file
+hippo              yes      auth_query
+postgres           no       no             superuser
+rhino              yes      no             no password
+
+WARNING: User rhino in spec.users cannot connect through pgBouncer: no password
+WARNING: Database zoo of user hippo has no pgbouncer.get_auth function`)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		_, clusterClient, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+
+		cluster, err := clusterClient.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if _, found, _ := unstructured.NestedMap(cluster.Object,
+			"spec", "proxy", "pgBouncer"); !found {
+			return fmt.Errorf("postgresclusters/%s does not have pgBouncer enabled", args[0])
+		}
+
+		// Read the configuration files that pgBouncer loaded.
+		bouncers, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.PGBouncerLabels(args[0]),
+		})
+		if err != nil {
+			return err
+		}
+		if len(bouncers.Items) == 0 {
+			return fmt.Errorf("no pgBouncer Pods found for cluster %s", args[0])
+		}
+		bouncer := podexec.Container(podExec, namespace,
+			bouncers.Items[0].GetName(), util.ContainerPGBouncer)
+
+		var stdout, stderr bytes.Buffer
+		if err := bouncer.Exec(nil, &stdout, &stderr, "sh", "-c", "cat /etc/pgbouncer/*.ini"); err != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		settings := parsePGBouncerINI(stdout.String())
+
+		// Only the names of the auth_file are kept; its passwords are never printed.
+		var userlist []string
+		if file := settings["auth_file"]; file != "" {
+			stdout.Reset()
+			stderr.Reset()
+			if err := bouncer.Exec(nil, &stdout, &stderr, "cat", file); err != nil {
+				return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+			}
+			userlist = parsePGBouncerUserlist(stdout.String())
+		}
+
+		// Read the roles and databases from the primary.
+		pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.PrimaryInstanceLabels(args[0]),
+		})
+		if err != nil {
+			return err
+		}
+		if len(pods.Items) != 1 {
+			return fmt.Errorf("primary instance Pod not found")
+		}
+		exec := podexec.Container(podExec, namespace, pods.Items[0].GetName(), util.ContainerDatabase)
+
+		out, errOut, err := podexec.PSQL(exec, "", pgbouncerRolesSQL)
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(errOut))
+		}
+		roles := parsePGBouncerRoles(out)
+
+		out, errOut, err = podexec.PSQL(exec, "",
+			`SELECT datname FROM pg_database WHERE datallowconn AND NOT datistemplate ORDER BY 1;`)
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(errOut))
+		}
+		authQueryDatabases := map[string]bool{}
+		for _, row := range parseRows(out) {
+			found, _, err := podexec.PSQL(exec, row[0],
+				`SELECT to_regprocedure('pgbouncer.get_auth(text)') IS NOT NULL;`)
+			authQueryDatabases[row[0]] = err == nil && strings.TrimSpace(found) == "t"
+		}
+
+		users := clusterSpecUsers(cluster)
+		for _, key := range []string{"auth_type", "auth_user", "auth_query"} {
+			cmd.Printf("%s: %s\n", key, settings[key])
+		}
+		if file := settings["auth_file"]; file != "" {
+			cmd.Printf("auth_file: %s (%s)\n", file, strings.Join(userlist, ", "))
+		}
+		cmd.Println()
+
+		access := pgbouncerAccess(roles, users, userlist, settings["auth_query"] != "")
+		writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+		_, _ = fmt.Fprintln(writer, "ROLE\tIN SPEC\tVIA PGBOUNCER\tREASON")
+		for _, role := range access {
+			inSpec := "no"
+			if role.InSpec {
+				inSpec = "yes"
+			}
+			via := role.Via
+			if via == "" {
+				via = "no"
+			}
+			_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", role.Name, inSpec, via, role.Reason)
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+
+		warnings := pgbouncerMismatches(access, users, authQueryDatabases)
+		if len(warnings) > 0 {
+			cmd.Println()
+		}
+		for _, warning := range warnings {
+			cmd.PrintErrf("WARNING: %s\n", warning)
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// pgbouncerRolesSQL returns the attributes that pgbouncer.get_auth checks for
+// every role that is not predefined.
+const pgbouncerRolesSQL = `SELECT rolname, rolcanlogin, rolsuper, rolreplication,
+  coalesce(rolvaliduntil < CURRENT_TIMESTAMP, false), rolpassword IS NOT NULL
+FROM pg_catalog.pg_authid WHERE rolname NOT LIKE 'pg\_%' ORDER BY 1;`
+
+// pgbouncerRole is a Postgres role and the attributes that decide whether
+// pgBouncer can look it up.
+type pgbouncerRole struct {
+	Name                                   string
+	Login, Superuser, Replication, Expired bool
+	Password                               bool
+}
+
+// parsePGBouncerRoles reads the rows of [pgbouncerRolesSQL].
+func parsePGBouncerRoles(stdout string) []pgbouncerRole {
+	var roles []pgbouncerRole
+	for _, row := range parseRows(stdout) {
+		if len(row) != 6 {
+			continue
+		}
+		roles = append(roles, pgbouncerRole{
+			Name:        row[0],
+			Login:       row[1] == "t",
+			Superuser:   row[2] == "t",
+			Replication: row[3] == "t",
+			Expired:     row[4] == "t",
+			Password:    row[5] == "t",
+		})
+	}
+	return roles
+}
+
+// parsePGBouncerUserlist returns the names in a pgBouncer auth_file. Each line
+// is a quoted name and a quoted password; a quote inside is doubled.
+// - https://www.pgbouncer.org/config.html#authentication-file-format
+func parsePGBouncerUserlist(text string) []string {
+	var names []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, `"`) {
+			continue
+		}
+
+		var name strings.Builder
+		for i := 1; i < len(line); i++ {
+			if line[i] == '"' {
+				if i+1 < len(line) && line[i+1] == '"' {
+					name.WriteByte('"')
+					i++
+					continue
+				}
+				break
+			}
+			name.WriteByte(line[i])
+		}
+		names = append(names, name.String())
+	}
+	return names
+}
+
+// specUser is an entry of spec.users.
+type specUser struct {
+	Name      string
+	Databases []string
+}
+
+// clusterSpecUsers returns the users in the spec of cluster. When spec.users
+// is empty, PGO creates one user named after the cluster with a database of
+// the same name.
+func clusterSpecUsers(cluster *unstructured.Unstructured) []specUser {
+	entries, found, _ := unstructured.NestedSlice(cluster.Object, "spec", "users")
+	if !found || len(entries) == 0 {
+		name := cluster.GetName()
+		return []specUser{{Name: name, Databases: []string{name}}}
+	}
+
+	var users []specUser
+	for _, entry := range entries {
+		if fields, ok := entry.(map[string]any); ok {
+			user := specUser{}
+			user.Name, _, _ = unstructured.NestedString(fields, "name")
+			user.Databases, _, _ = unstructured.NestedStringSlice(fields, "databases")
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+// pgbouncerRoleAccess is whether and how one role connects through pgBouncer.
+type pgbouncerRoleAccess struct {
+	Name   string
+	InSpec bool
+
+	// Via is "auth_file" or "auth_query" when the role can connect; Reason
+	// explains why it cannot.
+	Via, Reason string
+}
+
+// pgbouncerAccess decides how each role that can log in or is in users
+// connects through pgBouncer, sorted by name.
+func pgbouncerAccess(
+	roles []pgbouncerRole, users []specUser, userlist []string, authQuery bool,
+) []pgbouncerRoleAccess {
+	inSpec := map[string]bool{}
+	for _, user := range users {
+		inSpec[user.Name] = true
+	}
+	inFile := map[string]bool{}
+	for _, name := range userlist {
+		inFile[name] = true
+	}
+	exists := map[string]bool{}
+
+	var access []pgbouncerRoleAccess
+	for _, role := range roles {
+		exists[role.Name] = true
+		if !role.Login && !inSpec[role.Name] {
+			continue
+		}
+
+		result := pgbouncerRoleAccess{Name: role.Name, InSpec: inSpec[role.Name]}
+		switch {
+		case inFile[role.Name]:
+			result.Via = "auth_file"
+		case !role.Login:
+			result.Reason = "cannot log in"
+		case !authQuery:
+			result.Reason = "not in auth_file and no auth_query"
+		case role.Superuser:
+			result.Reason = "superuser"
+		case role.Replication:
+			result.Reason = "replication role"
+		case role.Expired:
+			result.Reason = "password expired"
+		case !role.Password:
+			result.Reason = "no password"
+		default:
+			result.Via = "auth_query"
+		}
+		access = append(access, result)
+	}
+
+	for _, user := range users {
+		if !exists[user.Name] {
+			access = append(access, pgbouncerRoleAccess{
+				Name: user.Name, InSpec: true, Reason: "role does not exist",
+			})
+		}
+	}
+
+	sort.Slice(access, func(i, j int) bool { return access[i].Name < access[j].Name })
+	return access
+}
+
+// pgbouncerMismatches returns the users in spec.users that cannot connect
+// through pgBouncer and their databases without the lookup function of
+// auth_query.
+func pgbouncerMismatches(
+	access []pgbouncerRoleAccess, users []specUser, authQueryDatabases map[string]bool,
+) []string {
+	byName := map[string]pgbouncerRoleAccess{}
+	for _, role := range access {
+		byName[role.Name] = role
+	}
+
+	var warnings []string
+	for _, user := range users {
+		role := byName[user.Name]
+		if role.Via == "" {
+			warnings = append(warnings, fmt.Sprintf(
+				"User %s in spec.users cannot connect through pgBouncer: %s", user.Name, role.Reason))
+			continue
+		}
+		if role.Via != "auth_query" {
+			continue
+		}
+		for _, database := range user.Databases {
+			if found, ok := authQueryDatabases[database]; !ok {
+				warnings = append(warnings, fmt.Sprintf(
+					"Database %s of user %s does not exist", database, user.Name))
+			} else if !found {
+				warnings = append(warnings, fmt.Sprintf(
+					"Database %s of user %s has no pgbouncer.get_auth function", database, user.Name))
+			}
+		}
+	}
+	return warnings
+}
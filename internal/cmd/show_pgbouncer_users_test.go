@@ -0,0 +1,87 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParsePGBouncerUserlist(t *testing.T) {
+	assert.DeepEqual(t, parsePGBouncerUserlist(`
+"_crunchypgbouncer" "SCRAM-SHA-256$4096:abc"
+;"commented" "x"
+"say ""hi""" ""
+`), []string{"_crunchypgbouncer", `say "hi"`})
+}
+
+func TestParsePGBouncerRoles(t *testing.T) {
+	roles := parsePGBouncerRoles("hippo\tt\tf\tf\tf\tt\npostgres\tt\tt\tf\tf\tf\nbad\n")
+	assert.DeepEqual(t, roles, []pgbouncerRole{
+		{Name: "hippo", Login: true, Password: true},
+		{Name: "postgres", Login: true, Superuser: true},
+	})
+}
+
+func TestClusterSpecUsers(t *testing.T) {
+	cluster := &unstructured.Unstructured{Object: map[string]any{}}
+	cluster.SetName("hippo")
+	assert.DeepEqual(t, clusterSpecUsers(cluster), []specUser{{Name: "hippo", Databases: []string{"hippo"}}})
+
+	assert.NilError(t, unstructured.SetNestedSlice(cluster.Object, []any{
+		map[string]any{"name": "rhino", "databases": []any{"zoo", "app"}},
+		map[string]any{"name": "postgres"},
+	}, "spec", "users"))
+	assert.DeepEqual(t, clusterSpecUsers(cluster), []specUser{
+		{Name: "rhino", Databases: []string{"zoo", "app"}},
+		{Name: "postgres"},
+	})
+}
+
+func TestPGBouncerAccess(t *testing.T) {
+	roles := []pgbouncerRole{
+		{Name: "_crunchypgbouncer", Login: true, Password: true},
+		{Name: "hippo", Login: true, Password: true},
+		{Name: "postgres", Login: true, Superuser: true, Password: true},
+		{Name: "replicator", Login: true, Replication: true, Password: true},
+		{Name: "group", Password: false},
+		{Name: "nologin"},
+		{Name: "old", Login: true, Expired: true, Password: true},
+		{Name: "rhino", Login: true},
+	}
+	users := []specUser{
+		{Name: "hippo", Databases: []string{"hippo", "zoo", "gone"}},
+		{Name: "rhino", Databases: []string{"hippo"}},
+		{Name: "nologin"},
+		{Name: "missing"},
+	}
+
+	access := pgbouncerAccess(roles, users, []string{"_crunchypgbouncer"}, true)
+	assert.DeepEqual(t, access, []pgbouncerRoleAccess{
+		{Name: "_crunchypgbouncer", Via: "auth_file"},
+		{Name: "hippo", InSpec: true, Via: "auth_query"},
+		{Name: "missing", InSpec: true, Reason: "role does not exist"},
+		{Name: "nologin", InSpec: true, Reason: "cannot log in"},
+		{Name: "old", Reason: "password expired"},
+		{Name: "postgres", Reason: "superuser"},
+		{Name: "replicator", Reason: "replication role"},
+		{Name: "rhino", InSpec: true, Reason: "no password"},
+	})
+
+	assert.DeepEqual(t, pgbouncerMismatches(access, users, map[string]bool{
+		"hippo": true, "zoo": false,
+	}), []string{
+		"Database zoo of user hippo has no pgbouncer.get_auth function",
+		"Database gone of user hippo does not exist",
+		"User rhino in spec.users cannot connect through pgBouncer: no password",
+		"User nologin in spec.users cannot connect through pgBouncer: cannot log in",
+		"User missing in spec.users cannot connect through pgBouncer: role does not exist",
+	})
+
+	withoutQuery := pgbouncerAccess(roles[1:2], nil, nil, false)
+	assert.Equal(t, withoutQuery[0].Reason, "not in auth_file and no auth_query")
+}
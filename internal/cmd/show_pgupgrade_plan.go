@@ -0,0 +1,378 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+const (
+	// upgradeOverhead is how long pg_upgrade takes apart from its transfer of
+	// data and schema: its checks and starting and stopping both servers.
+	upgradeOverhead = 2 * time.Minute
+
+	// upgradeRelationRate is how many relations per second pg_upgrade dumps
+	// and restores the schema of.
+	upgradeRelationRate = 200
+)
+
+// newShowPGUpgradePlanCommand returns the pgupgrade-plan subcommand of the
+// show command. It estimates the disk and time that a major version upgrade
+// of a PostgresCluster needs.
+func newShowPGUpgradePlanCommand(config *internal.Config) *cobra.Command {
+
+	cmdPlan := &cobra.Command{
+		Use:   "pgupgrade-plan CLUSTER_NAME",
+		Short: "Estimate the disk and time of a major upgrade of a PostgresCluster",
+		Long: `Estimate what a major upgrade of a PostgresCluster needs before it starts:
+  - temporary disk on the data volume; the Copy and CopyFileRange transfer
+    methods copy the data directory while the others only write new catalogs
+  - how long pg_upgrade runs, from the size of the data it copies and the
+    number of relations whose schema it dumps and restores
+  - extensions whose installed version is not the default of their package;
+    run ALTER EXTENSION ... UPDATE for them after the upgrade
+
+The target version and transfer method are read from the PGUpgrade named by
+--pgupgrade or by the "postgres-operator.crunchydata.com/allow-upgrade"
+annotation of the cluster, and the flags override them. The estimate is
+written to the "postgres-operator.crunchydata.com/pgo-upgrade-estimate"
+annotation of that PGUpgrade to compare with the upgrade afterward.
+
+Durations assume the copy rate of --copy-rate and are rough; use them to plan
+a maintenance window rather than to promise one.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    pgupgrades.postgres-operator.crunchydata.com        [get patch]
+    pods                                                [list]
+    pods/exec                                           [create]
+    postgresclusters.postgres-operator.crunchydata.com  [get]
+
+    Note: pgupgrades are not needed with --to-version and no PGUpgrade.
+
+### Usage`,
+	}
+
+	cmdPlan.Example = internal.FormatExample(`# Estimate the upgrade of the 'hippo' postgrescluster by its PGUpgrade
+pgo show pgupgrade-plan hippo --pgupgrade=hippo-upgrade
+
+# Estimate an upgrade to Postgres 17 that copies the data
+pgo show pgupgrade-plan hippo --to-version=17 --transfer-method=Copy
+
+### Example output
+ESTIMATE         VALUE
+upgrade          Postgres 16 to 17
+transfer method  Link
+data directory   12.4GiB
+temporary disk   96.0MiB of 18.2GiB free
+duration         about 3m
+
+EXTENSIONS
+  postgis in database app: update from 3.3.2 to 3.4.0
+  adminpack in database postgres: removed in Postgres 17
+
+pgupgrades/hippo-upgrade estimate recorded`)
+
+	var toVersion int
+	var pgUpgrade, transferMethod string
+	copyRate := resource.MustParse("200Mi")
+	cmdPlan.Flags().IntVar(&toVersion, "to-version", 0,
+		"the Postgres major version to upgrade to; defaults to that of the PGUpgrade")
+	cmdPlan.Flags().StringVar(&pgUpgrade, "pgupgrade", "",
+		"name of the PGUpgrade; defaults to the one the cluster allows")
+	cmdPlan.Flags().StringVar(&transferMethod, "transfer-method", "",
+		"how pg_upgrade transfers data: Clone, Copy, CopyFileRange, or Link; defaults to that of the PGUpgrade")
+	cmdPlan.Flags().Var(&quantityFlag{&copyRate}, "copy-rate",
+		"bytes per second that the data volume copies")
+
+	// Limit the number of args, that is, only one cluster name
+	cmdPlan.Args = cobra.ExactArgs(1)
+
+	cmdPlan.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		if copyRate.Value() <= 0 {
+			return fmt.Errorf("--copy-rate must be greater than zero")
+		}
+
+		if pgUpgrade == "" {
+			_, clusters, err := v1beta1.NewPostgresClusterClient(config)
+			if err != nil {
+				return err
+			}
+			cluster, err := clusters.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			pgUpgrade = cluster.GetAnnotations()[util.AllowUpgradeAnnotation()]
+		}
+
+		var mapping *meta.RESTMapping
+		var upgrades dynamic.NamespaceableResourceInterface
+		var upgrade *unstructured.Unstructured
+		if pgUpgrade != "" {
+			if mapping, upgrades, err = v1beta1.NewPGUpgradeClient(config); err != nil {
+				return err
+			}
+			if upgrade, err = upgrades.Namespace(namespace).Get(ctx, pgUpgrade, metav1.GetOptions{}); err != nil {
+				return err
+			}
+			if toVersion == 0 {
+				version, _, _ := unstructured.NestedInt64(upgrade.Object, "spec", "toPostgresVersion")
+				toVersion = int(version)
+			}
+			if transferMethod == "" {
+				transferMethod, _, _ = unstructured.NestedString(upgrade.Object, "spec", "transferMethod")
+			}
+		}
+		if toVersion == 0 {
+			return fmt.Errorf("--to-version is required when there is no PGUpgrade")
+		}
+		if transferMethod == "" {
+			transferMethod = "Link"
+		}
+		switch transferMethod {
+		case "Clone", "Copy", "CopyFileRange", "Link":
+		default:
+			return fmt.Errorf("--transfer-method must be one of Clone, Copy, CopyFileRange, or Link")
+		}
+
+		exec, err := getPrimaryExec(config, args)
+		if err != nil {
+			return err
+		}
+		query := func(database, sql string) ([][]string, error) {
+			stdout, stderr, err := podexec.PSQL(exec, database, sql)
+			if err != nil {
+				return nil, commandError(err, stderr)
+			}
+			return parseRows(stdout), nil
+		}
+
+		rows, err := query("", "SELECT pg_catalog.current_setting('server_version_num')::int / 10000")
+		if err != nil {
+			return err
+		}
+		estimate := upgradeEstimate{ToVersion: toVersion, TransferMethod: transferMethod}
+		if len(rows) == 1 {
+			estimate.FromVersion, _ = strconv.Atoi(rows[0][0])
+		}
+		if toVersion <= estimate.FromVersion {
+			return fmt.Errorf("--to-version must be greater than the current version, %d", estimate.FromVersion)
+		}
+
+		stdout, stderr, err := podexec.Bash(exec, upgradeDiskScript)
+		if err != nil {
+			return commandError(err, stderr)
+		}
+		estimate.DataBytes, estimate.AvailableBytes = parseUpgradeDisk(stdout)
+
+		databases, err := query("", preflightDatabasesSQL)
+		if err != nil {
+			return err
+		}
+		var catalogBytes, relations int64
+		var extensions []upgradeExtension
+		for _, database := range databases {
+			rows, err := query(database[0], upgradeRelationsSQL)
+			if err != nil {
+				return err
+			}
+			for _, row := range rows {
+				if len(row) == 2 {
+					size, _ := strconv.ParseInt(row[0], 10, 64)
+					count, _ := strconv.ParseInt(row[1], 10, 64)
+					catalogBytes, relations = catalogBytes+size, relations+count
+				}
+			}
+
+			if rows, err = query(database[0], upgradeExtensionsSQL); err != nil {
+				return err
+			}
+			for _, row := range rows {
+				if len(row) == 3 {
+					extensions = append(extensions, upgradeExtension{
+						Database: database[0], Name: row[0], Version: row[1], Default: row[2],
+					})
+				}
+			}
+		}
+		estimate.estimate(catalogBytes, relations, copyRate.Value())
+		estimate.Extensions = extensionUpdates(extensions, toVersion)
+		estimate.EstimatedAt = time.Now().UTC().Format(time.RFC3339)
+
+		if err := printUpgradeEstimate(cmd, estimate); err != nil {
+			return err
+		}
+		if upgrade == nil {
+			return nil
+		}
+
+		b, err := json.Marshal(estimate)
+		if err != nil {
+			return err
+		}
+		intent := new(unstructured.Unstructured)
+		if err := internal.ExtractFieldsInto(upgrade, intent, config.Patch.FieldManager); err != nil {
+			return err
+		}
+		intent.SetAnnotations(internal.MergeStringMaps(
+			intent.GetAnnotations(), map[string]string{
+				util.AnnotationUpgradeEstimate: string(b),
+			}))
+		patch, err := intent.MarshalJSON()
+		if err != nil {
+			return err
+		}
+
+		if _, err := upgrades.Namespace(namespace).Patch(ctx, pgUpgrade,
+			types.ApplyPatchType, patch, config.Patch.PatchOptions(metav1.PatchOptions{})); err != nil {
+			return err
+		}
+
+		cmd.Printf("\n%s/%s estimate recorded\n", mapping.Resource.Resource, pgUpgrade)
+		return nil
+	}
+
+	return cmdPlan
+}
+
+// upgradeDiskScript prints the size of the data directory and the space free
+// on its volume, in bytes.
+const upgradeDiskScript = `data="$(psql --no-psqlrc -Atc 'SHOW data_directory')" && ` +
+	`du --summarize --block-size=1 -- "${data}" && df --block-size=1 --output=avail -- "${data}"`
+
+// upgradeRelationsSQL returns the size of the catalogs of a database and the
+// number of relations whose schema pg_upgrade dumps and restores.
+const upgradeRelationsSQL = `SELECT
+  COALESCE(sum(pg_catalog.pg_total_relation_size(c.oid))
+    FILTER (WHERE n.nspname IN ('pg_catalog', 'information_schema')), 0)::bigint,
+  count(*) FILTER (WHERE n.nspname NOT IN ('pg_catalog', 'information_schema') AND n.nspname !~ '^pg_toast')
+FROM pg_catalog.pg_class c JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace`
+
+// upgradeExtensionsSQL lists the installed and default versions of the
+// extensions in a database.
+const upgradeExtensionsSQL = `SELECT e.extname, e.extversion, COALESCE(a.default_version, '')
+FROM pg_catalog.pg_extension e
+LEFT JOIN pg_catalog.pg_available_extensions a ON a.name = e.extname
+ORDER BY 1`
+
+// upgradeEstimate is what "show pgupgrade-plan" expects of an upgrade.
+type upgradeEstimate struct {
+	FromVersion    int    `json:"fromVersion"`
+	ToVersion      int    `json:"toVersion"`
+	TransferMethod string `json:"transferMethod"`
+
+	DataBytes      int64 `json:"dataBytes"`
+	TemporaryBytes int64 `json:"temporaryBytes"`
+	AvailableBytes int64 `json:"availableBytes"`
+
+	Duration    string `json:"duration"`
+	EstimatedAt string `json:"estimatedAt"`
+
+	// Extensions describe what to do with each extension that needs it.
+	Extensions []string `json:"extensions,omitempty"`
+}
+
+// upgradeExtension is an extension installed in a database.
+type upgradeExtension struct {
+	Database, Name, Version, Default string
+}
+
+// estimate sets the temporary disk and duration of estimate from the size of
+// the catalogs, the number of relations, and the bytes per second copied. The
+// new cluster gets catalogs of its own and pg_upgrade writes their schema in
+// dump files of about the same size.
+func (estimate *upgradeEstimate) estimate(catalogBytes, relations, copyRate int64) {
+	duration := upgradeOverhead + time.Duration(relations)*time.Second/upgradeRelationRate
+
+	estimate.TemporaryBytes = 2 * catalogBytes
+	switch estimate.TransferMethod {
+	case "Copy", "CopyFileRange":
+		estimate.TemporaryBytes += estimate.DataBytes
+		duration += time.Duration(float64(estimate.DataBytes) / float64(copyRate) * float64(time.Second))
+	}
+	estimate.Duration = strings.TrimSuffix(duration.Round(time.Minute).String(), "0s")
+}
+
+// parseUpgradeDisk reads the output of [upgradeDiskScript].
+func parseUpgradeDisk(stdout string) (size, available int64) {
+	lines := strings.SplitN(strings.TrimSpace(stdout), "\n", 2)
+	if fields := strings.Fields(lines[0]); len(fields) > 0 {
+		size, _ = strconv.ParseInt(fields[0], 10, 64)
+	}
+	if len(lines) == 2 {
+		available = parseDFAvailable(lines[1])
+	}
+	return size, available
+}
+
+// extensionUpdates describes what to do with the extensions that were removed
+// by target or are not at their default version.
+func extensionUpdates(extensions []upgradeExtension, target int) []string {
+	var updates []string
+	for _, extension := range extensions {
+		prefix := extension.Name + " in database " + extension.Database
+		if removed, ok := removedExtensions[extension.Name]; ok && target >= removed {
+			updates = append(updates, fmt.Sprintf("%s: removed in Postgres %d", prefix, removed))
+		} else if extension.Default != "" && extension.Version != extension.Default {
+			updates = append(updates, fmt.Sprintf("%s: update from %s to %s",
+				prefix, extension.Version, extension.Default))
+		}
+	}
+	return updates
+}
+
+// printUpgradeEstimate prints the estimate followed by the extensions that
+// need attention.
+func printUpgradeEstimate(cmd *cobra.Command, estimate upgradeEstimate) error {
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "ESTIMATE\tVALUE")
+	_, _ = fmt.Fprintf(writer, "upgrade\tPostgres %d to %d\n", estimate.FromVersion, estimate.ToVersion)
+	_, _ = fmt.Fprintf(writer, "transfer method\t%s\n", estimate.TransferMethod)
+	_, _ = fmt.Fprintf(writer, "data directory\t%s\n", formatBytes(estimate.DataBytes))
+	_, _ = fmt.Fprintf(writer, "temporary disk\t%s of %s free\n",
+		formatBytes(estimate.TemporaryBytes), formatBytes(estimate.AvailableBytes))
+	_, _ = fmt.Fprintf(writer, "duration\tabout %s\n", estimate.Duration)
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	if estimate.TemporaryBytes > estimate.AvailableBytes {
+		cmd.PrintErrln("WARNING: The data volume does not have enough free space for this transfer method.")
+	}
+
+	if len(estimate.Extensions) > 0 {
+		cmd.Println("\nEXTENSIONS")
+		for _, extension := range estimate.Extensions {
+			cmd.Printf("  %s\n", extension)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+)
+
+func TestUpgradeEstimate(t *testing.T) {
+	const gi = int64(1) << 30
+
+	t.Run("Link", func(t *testing.T) {
+		estimate := upgradeEstimate{TransferMethod: "Link", DataBytes: 10 * gi}
+		estimate.estimate(48<<20, 12000, 200<<20)
+
+		assert.Equal(t, estimate.TemporaryBytes, int64(96<<20))
+		assert.Equal(t, estimate.Duration, "3m")
+	})
+
+	t.Run("Copy", func(t *testing.T) {
+		estimate := upgradeEstimate{TransferMethod: "Copy", DataBytes: 100 * gi}
+		estimate.estimate(48<<20, 12000, 200<<20)
+
+		assert.Equal(t, estimate.TemporaryBytes, 100*gi+96<<20)
+		assert.Equal(t, estimate.Duration, "12m")
+	})
+
+	t.Run("Hours", func(t *testing.T) {
+		estimate := upgradeEstimate{TransferMethod: "CopyFileRange", DataBytes: 1000 * gi}
+		estimate.estimate(0, 0, 200<<20)
+
+		assert.Equal(t, estimate.Duration, "1h27m")
+	})
+}
+
+func TestParseUpgradeDisk(t *testing.T) {
+	size, available := parseUpgradeDisk(`
+13314398208	/pgdata/pg16
+    Avail
+19541110784
+`[1:])
+	assert.Equal(t, size, int64(13314398208))
+	assert.Equal(t, available, int64(19541110784))
+
+	size, available = parseUpgradeDisk("")
+	assert.Equal(t, size, int64(0))
+	assert.Equal(t, available, int64(0))
+}
+
+func TestExtensionUpdates(t *testing.T) {
+	updates := extensionUpdates([]upgradeExtension{
+		{Database: "app", Name: "pg_stat_statements", Version: "1.10", Default: "1.10"},
+		{Database: "app", Name: "postgis", Version: "3.3.2", Default: "3.4.0"},
+		{Database: "app", Name: "vendored", Version: "1.0"},
+		{Database: "postgres", Name: "adminpack", Version: "2.1", Default: "2.1"},
+	}, 17)
+
+	assert.DeepEqual(t, updates, []string{
+		"postgis in database app: update from 3.3.2 to 3.4.0",
+		"adminpack in database postgres: removed in Postgres 17",
+	})
+	assert.Assert(t, len(extensionUpdates([]upgradeExtension{
+		{Database: "postgres", Name: "adminpack", Version: "2.1", Default: "2.1"},
+	}, 16)) == 0)
+}
+
+func TestPrintUpgradeEstimate(t *testing.T) {
+	var out, errs bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+	cmd.SetErr(&errs)
+
+	assert.NilError(t, printUpgradeEstimate(cmd, upgradeEstimate{
+		FromVersion: 16, ToVersion: 17, TransferMethod: "Copy",
+		DataBytes: 12 << 30, TemporaryBytes: 13 << 30, AvailableBytes: 8 << 30,
+		Duration:   "3m",
+		Extensions: []string{"postgis in database app: update from 3.3.2 to 3.4.0"},
+	}))
+	assert.Equal(t, out.String(), `
+ESTIMATE         VALUE
+upgrade          Postgres 16 to 17
+transfer method  Copy
+data directory   12.0GiB
+temporary disk   13.0GiB of 8.0GiB free
+duration         about 3m
+
+EXTENSIONS
+  postgis in database app: update from 3.3.2 to 3.4.0
+`[1:])
+	assert.Assert(t, bytes.Contains(errs.Bytes(), []byte("not have enough free space")))
+}
@@ -0,0 +1,362 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newShowPGUpgradePreflightCommand returns the pgupgrade-preflight subcommand
+// of the show command. It looks for things that would make a major version
+// upgrade of a PostgresCluster fail.
+func newShowPGUpgradePreflightCommand(config *internal.Config) *cobra.Command {
+
+	cmdPreflight := &cobra.Command{
+		Use:   "pgupgrade-preflight CLUSTER_NAME",
+		Short: "Check a PostgresCluster for known blockers of a major upgrade",
+		Long: `Check a PostgresCluster for things that are known to make pg_upgrade fail
+or leave Postgres unable to start on the target version. Run this before
+creating a PGUpgrade. It checks:
+  - extensions that were removed from Postgres by the target version
+  - settings that were removed or renamed by the target version
+  - free space on the data volume; pg_upgrade copies data unless it links it
+  - columns of data types that pg_upgrade cannot upgrade
+
+The report ends with GO when nothing blocks the upgrade, and the command exits
+with an error otherwise.
+
+### RBAC Requirements
+    Resources  Verbs
+    ---------  -----
+    pods       [list]
+    pods/exec  [create]
+
+### Usage`,
+	}
+
+	cmdPreflight.Example = internal.FormatExample(`# Check the 'hippo' postgrescluster before upgrading it to Postgres 17
+pgo show pgupgrade-preflight hippo --to-version=17
+
+### Example output
+CHECK      STATUS  DETAIL
+extension  block   adminpack in database postgres was removed in Postgres 17
+setting    block   old_snapshot_threshold was removed in Postgres 17
+disk       ok      18Gi free for 2Gi of data
+data type  ok      no columns of unsupported types
+
+Result: NO-GO (2 blockers)`)
+
+	var toVersion int
+	cmdPreflight.Flags().IntVar(&toVersion, "to-version", 0,
+		"the Postgres major version to upgrade to")
+	cobra.CheckErr(cmdPreflight.MarkFlagRequired("to-version"))
+
+	// Limit the number of args, that is, only one cluster name
+	cmdPreflight.Args = cobra.ExactArgs(1)
+
+	cmdPreflight.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+
+		pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.PrimaryInstanceLabels(args[0]),
+		})
+		if err != nil {
+			return err
+		}
+		if len(pods.Items) != 1 {
+			return fmt.Errorf("primary instance Pod not found")
+		}
+		exec := podexec.Container(podExec, namespace, pods.Items[0].GetName(), util.ContainerDatabase)
+
+		query := func(database, sql string) ([][]string, error) {
+			stdout, stderr, err := podexec.PSQL(exec, database, sql)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr))
+			}
+			return parseRows(stdout), nil
+		}
+
+		rows, err := query("", "SELECT pg_catalog.current_setting('server_version_num')::int / 10000")
+		if err != nil {
+			return err
+		}
+		current := 0
+		if len(rows) == 1 {
+			current, _ = strconv.Atoi(rows[0][0])
+		}
+		if toVersion <= current {
+			return fmt.Errorf("--to-version must be greater than the current version, %d", current)
+		}
+
+		var findings []preflightFinding
+
+		databases, err := query("", preflightDatabasesSQL)
+		if err != nil {
+			return err
+		}
+		var extensions, columns []preflightObject
+		for _, row := range databases {
+			rows, err := query(row[0], "SELECT extname FROM pg_catalog.pg_extension")
+			if err != nil {
+				return err
+			}
+			for _, extension := range rows {
+				extensions = append(extensions, preflightObject{row[0], extension[0], ""})
+			}
+
+			rows, err = query(row[0], preflightColumnsSQL)
+			if err != nil {
+				return err
+			}
+			for _, column := range rows {
+				if len(column) == 2 {
+					columns = append(columns, preflightObject{row[0], column[0], column[1]})
+				}
+			}
+		}
+		findings = append(findings, extensionFindings(extensions, toVersion)...)
+
+		settings, err := query("", "SELECT name FROM pg_catalog.pg_settings WHERE source NOT IN ('default', 'override')")
+		if err != nil {
+			return err
+		}
+		names := make([]string, 0, len(settings))
+		for _, row := range settings {
+			names = append(names, row[0])
+		}
+		findings = append(findings, settingFindings(names, toVersion)...)
+
+		size, err := query("", "SELECT sum(pg_catalog.pg_database_size(oid))::bigint FROM pg_catalog.pg_database")
+		if err != nil {
+			return err
+		}
+		var stdout, stderr bytes.Buffer
+		if err := exec.Exec(nil, &stdout, &stderr,
+			"df", "--block-size=1", "--output=avail", "/pgdata"); err != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		var needed int64
+		if len(size) == 1 {
+			needed, _ = strconv.ParseInt(size[0][0], 10, 64)
+		}
+		findings = append(findings, diskFinding(parseDFAvailable(stdout.String()), needed))
+
+		findings = append(findings, dataTypeFindings(columns, current, toVersion)...)
+
+		return printPreflightFindings(cmd, findings)
+	}
+
+	return cmdPreflight
+}
+
+// preflightDatabasesSQL lists the databases that pg_upgrade checks.
+const preflightDatabasesSQL = `SELECT datname FROM pg_catalog.pg_database
+WHERE datallowconn ORDER BY datname`
+
+// preflightColumnsSQL lists the columns of user tables that have a data type
+// that pg_upgrade cannot upgrade in some or all cases. The reg* types that
+// pg_upgrade accepts, regclass, regrole, and regtype, are not listed.
+const preflightColumnsSQL = `SELECT pg_catalog.format('%I.%I.%I', n.nspname, c.relname, a.attname), t.typname
+FROM pg_catalog.pg_attribute a
+JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+JOIN pg_catalog.pg_type t ON t.oid = a.atttypid
+WHERE a.attnum > 0 AND NOT a.attisdropped AND c.relkind IN ('r', 'm', 'p')
+AND n.nspname NOT IN ('pg_catalog', 'information_schema') AND n.nspname !~ '^pg_toast'
+AND t.typname IN ('abstime', 'aclitem', 'regcollation', 'regconfig', 'regdictionary',
+  'regnamespace', 'regoper', 'regoperator', 'regproc', 'regprocedure', 'reltime',
+  'sql_identifier', 'tinterval', 'unknown')
+ORDER BY 1`
+
+// preflightFinding is the result of one check.
+type preflightFinding struct {
+	Check  string
+	Status string // "ok" or "block"
+	Detail string
+}
+
+// preflightObject is something found in a database: an extension or a column
+// and its type.
+type preflightObject struct {
+	Database, Name, Type string
+}
+
+// removedExtensions are extensions that Postgres stopped shipping, by the
+// first major version without them.
+var removedExtensions = map[string]int{
+	"tsearch2":     10,
+	"chkpass":      11,
+	"plpythonu":    15,
+	"plpython2u":   15,
+	"adminpack":    17,
+	"old_snapshot": 17,
+}
+
+// removedSettings are settings that Postgres removed or renamed, by the first
+// major version without them.
+var removedSettings = map[string]int{
+	"wal_keep_segments":                 13,
+	"operator_precedence_warning":       14,
+	"vacuum_cleanup_index_scale_factor": 14,
+	"stats_temp_directory":              15,
+	"force_parallel_mode":               16,
+	"promote_trigger_file":              16,
+	"vacuum_defer_cleanup_age":          16,
+	"db_user_namespace":                 17,
+	"old_snapshot_threshold":            17,
+	"trace_recovery_messages":           17,
+}
+
+// extensionFindings reports extensions that do not exist in target.
+func extensionFindings(extensions []preflightObject, target int) []preflightFinding {
+	var findings []preflightFinding
+	for _, extension := range extensions {
+		if removed, ok := removedExtensions[extension.Name]; ok && target >= removed {
+			findings = append(findings, preflightFinding{"extension", "block", fmt.Sprintf(
+				"%s in database %s was removed in Postgres %d", extension.Name, extension.Database, removed)})
+		}
+	}
+	if len(findings) == 0 {
+		findings = append(findings, preflightFinding{"extension", "ok",
+			fmt.Sprintf("%d extensions are available in Postgres %d", len(extensions), target)})
+	}
+	return findings
+}
+
+// settingFindings reports settings that do not exist in target.
+func settingFindings(settings []string, target int) []preflightFinding {
+	var findings []preflightFinding
+	sort.Strings(settings)
+	for _, setting := range settings {
+		if removed, ok := removedSettings[setting]; ok && target >= removed {
+			findings = append(findings, preflightFinding{"setting", "block",
+				fmt.Sprintf("%s was removed in Postgres %d", setting, removed)})
+		}
+	}
+	if len(findings) == 0 {
+		findings = append(findings, preflightFinding{"setting", "ok", "no removed settings are set"})
+	}
+	return findings
+}
+
+// diskFinding compares the free space on the data volume with the size of the
+// data that pg_upgrade copies.
+func diskFinding(available, needed int64) preflightFinding {
+	detail := fmt.Sprintf("%s free for %s of data",
+		resource.NewQuantity(available, resource.BinarySI).String(),
+		resource.NewQuantity(needed, resource.BinarySI).String())
+	if available < needed {
+		return preflightFinding{"disk", "block", detail}
+	}
+	return preflightFinding{"disk", "ok", detail}
+}
+
+// dataTypeFindings reports columns whose type pg_upgrade rejects when going
+// from current to target.
+func dataTypeFindings(columns []preflightObject, current, target int) []preflightFinding {
+	var findings []preflightFinding
+	for _, column := range columns {
+		var reason string
+		switch column.Type {
+		case "aclitem":
+			if current < 16 && target >= 16 {
+				reason = "changed format in Postgres 16"
+			}
+		case "abstime", "reltime", "tinterval", "sql_identifier":
+			if current < 12 {
+				reason = "changed or was removed in Postgres 12"
+			}
+		default:
+			reason = "cannot be upgraded by pg_upgrade"
+		}
+		if reason != "" {
+			findings = append(findings, preflightFinding{"data type", "block", fmt.Sprintf(
+				"%s in database %s is %s, which %s", column.Name, column.Database, column.Type, reason)})
+		}
+	}
+	if len(findings) == 0 {
+		findings = append(findings, preflightFinding{"data type", "ok", "no columns of unsupported types"})
+	}
+	return findings
+}
+
+// parseRows splits the output of [podexec.PSQL] into rows of fields.
+func parseRows(stdout string) [][]string {
+	var rows [][]string
+	for _, line := range strings.Split(strings.TrimRight(stdout, "\n"), "\n") {
+		if line != "" {
+			rows = append(rows, strings.Split(line, "\t"))
+		}
+	}
+	return rows
+}
+
+// parseDFAvailable reads the output of "df --output=avail", which is a heading
+// followed by one number.
+func parseDFAvailable(stdout string) int64 {
+	fields := strings.Fields(stdout)
+	if len(fields) < 2 {
+		return 0
+	}
+	available, _ := strconv.ParseInt(fields[len(fields)-1], 10, 64)
+	return available
+}
+
+// printPreflightFindings prints findings and the overall result. It returns an
+// error when any finding blocks the upgrade.
+func printPreflightFindings(cmd *cobra.Command, findings []preflightFinding) error {
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "CHECK\tSTATUS\tDETAIL")
+
+	var blockers int
+	for _, finding := range findings {
+		if finding.Status == "block" {
+			blockers++
+		}
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\n", finding.Check, finding.Status, finding.Detail)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	if blockers > 0 {
+		cmd.Printf("\nResult: NO-GO (%d blockers)\n", blockers)
+		return fmt.Errorf("found %d blockers for the upgrade", blockers)
+	}
+	cmd.Println("\nResult: GO")
+	return nil
+}
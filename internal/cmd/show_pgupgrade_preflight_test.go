@@ -0,0 +1,75 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestExtensionFindings(t *testing.T) {
+	extensions := []preflightObject{
+		{Database: "postgres", Name: "adminpack"},
+		{Database: "app", Name: "pg_stat_statements"},
+	}
+
+	assert.DeepEqual(t, extensionFindings(extensions, 16), []preflightFinding{
+		{"extension", "ok", "2 extensions are available in Postgres 16"},
+	})
+	assert.DeepEqual(t, extensionFindings(extensions, 17), []preflightFinding{
+		{"extension", "block", "adminpack in database postgres was removed in Postgres 17"},
+	})
+}
+
+func TestSettingFindings(t *testing.T) {
+	settings := []string{"vacuum_defer_cleanup_age", "work_mem", "old_snapshot_threshold"}
+
+	assert.DeepEqual(t, settingFindings(settings, 16), []preflightFinding{
+		{"setting", "block", "vacuum_defer_cleanup_age was removed in Postgres 16"},
+	})
+	assert.DeepEqual(t, settingFindings(settings, 17), []preflightFinding{
+		{"setting", "block", "old_snapshot_threshold was removed in Postgres 17"},
+		{"setting", "block", "vacuum_defer_cleanup_age was removed in Postgres 16"},
+	})
+	assert.DeepEqual(t, settingFindings([]string{"work_mem"}, 17), []preflightFinding{
+		{"setting", "ok", "no removed settings are set"},
+	})
+}
+
+func TestDiskFinding(t *testing.T) {
+	assert.DeepEqual(t, diskFinding(18<<30, 2<<30),
+		preflightFinding{"disk", "ok", "18Gi free for 2Gi of data"})
+	assert.DeepEqual(t, diskFinding(1<<30, 2<<30),
+		preflightFinding{"disk", "block", "1Gi free for 2Gi of data"})
+}
+
+func TestDataTypeFindings(t *testing.T) {
+	columns := []preflightObject{
+		{"app", "public.acl.item", "aclitem"},
+		{"app", "public.fn.proc", "regproc"},
+	}
+
+	assert.DeepEqual(t, dataTypeFindings(columns, 16, 17), []preflightFinding{
+		{"data type", "block", "public.fn.proc in database app is regproc, which cannot be upgraded by pg_upgrade"},
+	})
+	assert.DeepEqual(t, dataTypeFindings(columns, 15, 16), []preflightFinding{
+		{"data type", "block", "public.acl.item in database app is aclitem, which changed format in Postgres 16"},
+		{"data type", "block", "public.fn.proc in database app is regproc, which cannot be upgraded by pg_upgrade"},
+	})
+	assert.DeepEqual(t, dataTypeFindings(nil, 15, 16), []preflightFinding{
+		{"data type", "ok", "no columns of unsupported types"},
+	})
+}
+
+func TestParseDFAvailable(t *testing.T) {
+	assert.Equal(t, parseDFAvailable("    Avail\n19327352832\n"), int64(19327352832))
+	assert.Equal(t, parseDFAvailable(""), int64(0))
+}
+
+func TestParseRows(t *testing.T) {
+	assert.DeepEqual(t, parseRows("a\tb\nc\td\n"), [][]string{{"a", "b"}, {"c", "d"}})
+	assert.Assert(t, parseRows("\n") == nil)
+}
@@ -0,0 +1,173 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newShowPGBackRestProcessesCommand returns the pgbackrest-processes subcommand
+// of the show command. It lists the pgBackRest operations that are running in
+// the instance and repo host Pods of a PostgresCluster.
+func newShowPGBackRestProcessesCommand(config *internal.Config) *cobra.Command {
+
+	cmdShowProcesses := &cobra.Command{
+		Use:   "pgbackrest-processes CLUSTER_NAME",
+		Short: "Show running pgBackRest operations for a PostgresCluster",
+		Long: `Show the pgBackRest processes running in every instance and repo host Pod
+of a PostgresCluster. This shows whether a backup, archive-push, or restore is
+actually progressing.
+
+### RBAC Requirements
+    Resources  Verbs
+    ---------  -----
+    pods       [list]
+    pods/exec  [create]
+
+### Usage`,
+	}
+
+	cmdShowProcesses.Example = internal.FormatExample(`# Show pgBackRest operations in the 'hippo' postgrescluster
+pgo show pgbackrest-processes hippo
+
+### Example output
+POD                     CONTAINER   PID  COMMAND       REPO  ELAPSED
+hippo-instance1-8x7m-0  database    812  archive-push  -     2s
+hippo-repo-host-0       pgbackrest  97   backup        1     3m12s
+hippo-repo-host-0       pgbackrest  103  backup:local  1     3m11s`)
+
+	// Limit the number of args, that is, only one cluster name
+	cmdShowProcesses.Args = cobra.ExactArgs(1)
+
+	cmdShowProcesses.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+
+		// pgBackRest runs in the database container of instance Pods and in
+		// the pgbackrest container of the repo host.
+		targets := []struct{ selector, container string }{
+			{util.DBInstanceLabels(args[0]), util.ContainerDatabase},
+			{util.RepoHostInstanceLabels(args[0]), util.ContainerPGBackrest},
+		}
+
+		writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+		_, _ = fmt.Fprintln(writer, "POD\tCONTAINER\tPID\tCOMMAND\tREPO\tELAPSED")
+
+		var found bool
+		for _, target := range targets {
+			pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+				LabelSelector: target.selector,
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, pod := range pods.Items {
+				if pod.Status.Phase != corev1.PodRunning {
+					continue
+				}
+				found = true
+
+				exec := podexec.Container(podExec, namespace, pod.GetName(), target.container)
+
+				stdout, stderr, err := podexec.PGBackRestProcesses(exec)
+				if err != nil {
+					return fmt.Errorf("%s: %w: %s", pod.GetName(), err, strings.TrimSpace(stderr))
+				}
+
+				for _, process := range parsePGBackRestProcesses(stdout) {
+					repo := process.Repo
+					if repo == "" {
+						repo = "-"
+					}
+					_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n",
+						pod.GetName(), target.container, process.PID,
+						process.Command, repo, process.Elapsed)
+				}
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("no running Pods found for cluster %s", args[0])
+		}
+
+		return writer.Flush()
+	}
+
+	return cmdShowProcesses
+}
+
+// pgBackRestProcess is one pgBackRest process found in a container.
+type pgBackRestProcess struct {
+	PID     string
+	Elapsed time.Duration
+	Command string
+	Repo    string
+}
+
+// parsePGBackRestProcesses reads the output of
+// [podexec.PGBackRestProcesses]. Lines for processes other than pgbackrest,
+// such as shells that mention it in their arguments, are ignored.
+func parsePGBackRestProcesses(stdout string) []pgBackRestProcess {
+	var processes []pgBackRestProcess
+
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || path.Base(fields[2]) != "pgbackrest" {
+			continue
+		}
+
+		process := pgBackRestProcess{PID: fields[0]}
+		if seconds, err := strconv.Atoi(fields[1]); err == nil {
+			process.Elapsed = time.Duration(seconds) * time.Second
+		}
+
+		for _, arg := range fields[3:] {
+			switch {
+			case strings.HasPrefix(arg, "--repo="):
+				process.Repo = strings.TrimPrefix(arg, "--repo=")
+			case strings.HasPrefix(arg, "-"):
+			case process.Command == "":
+				// The first argument that is not an option is the command.
+				process.Command = arg
+			}
+		}
+
+		processes = append(processes, process)
+	}
+
+	return processes
+}
@@ -0,0 +1,28 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParsePGBackRestProcesses(t *testing.T) {
+	stdout := `
+   97   192 pgbackrest --exec-id=97-b4d1 --repo=1 --stanza=db backup
+  103   191 /usr/bin/pgbackrest --exec-id=97-b4d1 --process=1 --repo=1 --stanza=db backup:local
+  812     2 pgbackrest --stanza=db archive-push pg_wal/000000010000000000000004
+  900     0 bash -ceu -- pgbackrest info
+`
+	assert.DeepEqual(t, parsePGBackRestProcesses(stdout), []pgBackRestProcess{
+		{PID: "97", Elapsed: 192 * time.Second, Command: "backup", Repo: "1"},
+		{PID: "103", Elapsed: 191 * time.Second, Command: "backup:local", Repo: "1"},
+		{PID: "812", Elapsed: 2 * time.Second, Command: "archive-push"},
+	})
+
+	assert.Assert(t, parsePGBackRestProcesses("") == nil)
+}
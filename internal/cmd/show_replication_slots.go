@@ -0,0 +1,247 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newShowReplicationSlotsCommand returns the replication-slots subcommand of
+// the show command. It lists the replication slots on the primary and can drop
+// one that is inactive.
+func newShowReplicationSlotsCommand(config *internal.Config) *cobra.Command {
+
+	cmdShowSlots := &cobra.Command{
+		Use:   "replication-slots CLUSTER_NAME",
+		Short: "Show replication slots and the WAL they retain",
+		Long: `Show the physical and logical replication slots on the primary of a
+PostgresCluster, whether each one is in use, and how much WAL it keeps in
+pg_wal. An inactive slot keeps WAL forever and can fill the WAL volume.
+
+Use the "--drop" flag to drop an inactive slot. Patroni creates a physical slot
+for every replica; those are not dropped because Patroni would recreate them.
+
+### RBAC Requirements
+    Resources  Verbs
+    ---------  -----
+    pods       [list]
+    pods/exec  [create]
+
+### Usage`,
+	}
+
+	cmdShowSlots.Example = internal.FormatExample(`# Show the replication slots of the 'hippo' postgrescluster
+pgo show replication-slots hippo
+
+# Drop an inactive slot
+pgo show replication-slots hippo --drop=old_subscription
+
+### Example output
+SLOT                    TYPE      DATABASE  ACTIVE  RESTART LSN  RETAINED WAL
+hippo_instance1_pwr2_0  physical            true    0/5000060    0 bytes
+old_subscription        logical   app       false   0/1A2B3C40   18 GB
+
+WARNING: Inactive slot old_subscription retains 18 GB of WAL`)
+
+	var drop string
+	cmdShowSlots.Flags().StringVar(&drop, "drop", "",
+		"drop this inactive replication slot after confirmation")
+
+	// Limit the number of args, that is, only one cluster name
+	cmdShowSlots.Args = cobra.ExactArgs(1)
+
+	cmdShowSlots.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+
+		pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.DBInstanceLabels(args[0]),
+		})
+		if err != nil {
+			return err
+		}
+
+		var primary string
+		members := map[string]bool{}
+		for _, pod := range pods.Items {
+			members[patroniSlotName(pod.GetName())] = true
+			if pod.GetLabels()[util.LabelRole] == util.RolePatroniLeader {
+				primary = pod.GetName()
+			}
+		}
+		if primary == "" {
+			return fmt.Errorf("primary instance Pod not found")
+		}
+
+		exec := podexec.Container(podExec, namespace, primary, util.ContainerDatabase)
+		stdout, stderr, err := podexec.PSQL(exec, "", replicationSlotsSQL)
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr))
+		}
+		slots := parseReplicationSlots(stdout)
+
+		if drop != "" {
+			return dropReplicationSlot(cmd, config, exec, slots, members, drop)
+		}
+
+		if len(slots) == 0 {
+			cmd.Printf("No replication slots found for cluster %s\n", args[0])
+			return nil
+		}
+
+		writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+		_, _ = fmt.Fprintln(writer, "SLOT\tTYPE\tDATABASE\tACTIVE\tRESTART LSN\tRETAINED WAL")
+		for _, slot := range slots {
+			_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%t\t%s\t%s\n", slot.Name, slot.Type,
+				slot.Database, slot.Active, slot.RestartLSN, slot.RetainedPretty)
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+
+		var warned bool
+		for _, slot := range slots {
+			if !slot.Active && slot.Retained > 0 && !members[slot.Name] {
+				if !warned {
+					cmd.PrintErrln()
+					warned = true
+				}
+				cmd.PrintErrf("WARNING: Inactive slot %s retains %s of WAL\n",
+					slot.Name, slot.RetainedPretty)
+			}
+		}
+		return nil
+	}
+
+	return cmdShowSlots
+}
+
+// replicationSlot is one row of pg_replication_slots.
+type replicationSlot struct {
+	Name           string
+	Type           string
+	Database       string
+	Active         bool
+	RestartLSN     string
+	Retained       int64
+	RetainedPretty string
+}
+
+// replicationSlotsSQL lists the replication slots of a primary and the amount
+// of WAL that each one retains.
+const replicationSlotsSQL = `SELECT slot_name, slot_type, COALESCE(database, ''), active,
+COALESCE(restart_lsn::text, ''),
+COALESCE(pg_catalog.pg_wal_lsn_diff(pg_catalog.pg_current_wal_lsn(), restart_lsn)::bigint, 0),
+COALESCE(pg_catalog.pg_size_pretty(pg_catalog.pg_wal_lsn_diff(pg_catalog.pg_current_wal_lsn(), restart_lsn)), '')
+FROM pg_catalog.pg_replication_slots ORDER BY slot_name`
+
+// parseReplicationSlots reads the output of [replicationSlotsSQL].
+func parseReplicationSlots(stdout string) []replicationSlot {
+	var slots []replicationSlot
+
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			continue
+		}
+		retained, _ := strconv.ParseInt(fields[5], 10, 64)
+		slots = append(slots, replicationSlot{
+			Name:           fields[0],
+			Type:           fields[1],
+			Database:       fields[2],
+			Active:         fields[3] == "t",
+			RestartLSN:     fields[4],
+			Retained:       retained,
+			RetainedPretty: fields[6],
+		})
+	}
+
+	return slots
+}
+
+// patroniSlotName returns the name of the physical slot that Patroni keeps for
+// the cluster member running in pod.
+func patroniSlotName(pod string) string {
+	return strings.ReplaceAll(pod, "-", "_")
+}
+
+// dropReplicationSlot drops the slot called name after confirmation. It refuses
+// slots that do not exist, are in use, or belong to a Patroni member.
+func dropReplicationSlot(
+	cmd *cobra.Command, config *internal.Config, exec podexec.Executor,
+	slots []replicationSlot, members map[string]bool, name string,
+) error {
+	var slot *replicationSlot
+	for i := range slots {
+		if slots[i].Name == name {
+			slot = &slots[i]
+		}
+	}
+	switch {
+	case slot == nil:
+		return fmt.Errorf("replication slot %q not found", name)
+	case slot.Active:
+		return fmt.Errorf("replication slot %q is active", name)
+	case members[name]:
+		return fmt.Errorf("replication slot %q belongs to a cluster member and is managed by Patroni", name)
+	}
+
+	_, _ = fmt.Fprint(config.ErrOut, config.Messages.Sprintf("show.slots.warn-drop", name, slot.RetainedPretty)+
+		config.Messages.Sprintf("confirm.continue"))
+	var confirmed *bool
+	for i := 0; confirmed == nil && i < 10; i++ {
+		// retry 10 times or until a confirmation is given or denied,
+		// whichever comes first
+		confirmed = config.Messages.Confirm(config.In, config.ErrOut)
+	}
+	if confirmed == nil || !*confirmed {
+		return ErrCancelled
+	}
+
+	// Postgres drops a logical slot only from the database it belongs to.
+	var database string
+	if slot.Type == "logical" {
+		database = slot.Database
+	}
+
+	// The name came from pg_replication_slots, and Postgres only allows lower
+	// case letters, numbers, and underscores in slot names.
+	_, stderr, err := podexec.PSQL(exec, database, fmt.Sprintf(
+		"SELECT pg_catalog.pg_drop_replication_slot('%s')", slot.Name))
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr))
+	}
+
+	cmd.Printf("replication slot %s dropped\n", name)
+	return nil
+}
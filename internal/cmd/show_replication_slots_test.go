@@ -0,0 +1,96 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+)
+
+func TestParseReplicationSlots(t *testing.T) {
+	assert.Assert(t, len(parseReplicationSlots("")) == 0)
+
+	slots := parseReplicationSlots("" +
+		"hippo_instance1_pwr2_0\tphysical\t\tt\t0/5000060\t0\t0 bytes\n" +
+		"old_subscription\tlogical\tapp\tf\t0/1A2B3C40\t19327352832\t18 GB\n" +
+		"never_used\tphysical\t\tf\t\t0\t\n" +
+		"garbage\n")
+
+	assert.DeepEqual(t, slots, []replicationSlot{
+		{
+			Name: "hippo_instance1_pwr2_0", Type: "physical", Active: true,
+			RestartLSN: "0/5000060", RetainedPretty: "0 bytes",
+		},
+		{
+			Name: "old_subscription", Type: "logical", Database: "app",
+			RestartLSN: "0/1A2B3C40", Retained: 19327352832, RetainedPretty: "18 GB",
+		},
+		{Name: "never_used", Type: "physical"},
+	})
+}
+
+func TestPatroniSlotName(t *testing.T) {
+	assert.Equal(t, patroniSlotName("hippo-instance1-pwr2-0"), "hippo_instance1_pwr2_0")
+}
+
+func TestDropReplicationSlot(t *testing.T) {
+	slots := []replicationSlot{
+		{Name: "old_subscription", Type: "logical", Database: "app", RetainedPretty: "1126 MB"},
+		{Name: "old_standby", Type: "physical", RetainedPretty: "16 MB"},
+		{Name: "busy", Type: "physical", Active: true},
+	}
+	run := func(stdin, name string) (*podexec.Fake, string, string, error) {
+		fake := &podexec.Fake{Replies: []podexec.Reply{{Match: "pg_drop_replication_slot"}}}
+		var stdout, stderr bytes.Buffer
+		config := &internal.Config{IOStreams: genericclioptions.IOStreams{
+			In: strings.NewReader(stdin), Out: &stdout, ErrOut: &stderr,
+		}}
+		cmd := &cobra.Command{}
+		cmd.SetOut(&stdout)
+		err := dropReplicationSlot(cmd, config, fake, slots, map[string]bool{}, name)
+		return fake, stdout.String(), stderr.String(), err
+	}
+
+	t.Run("Logical", func(t *testing.T) {
+		// Postgres drops a logical slot only from its own database.
+		fake, stdout, stderr, err := run("yes\n", "old_subscription")
+		assert.NilError(t, err)
+		assert.Equal(t, len(fake.Calls), 1)
+		assert.Assert(t, strings.Contains(strings.Join(fake.Calls[0].Command, " "), " --dbname=app"),
+			"%q", fake.Calls[0].Command)
+		assert.Equal(t, stdout, "replication slot old_subscription dropped\n")
+		assert.Assert(t, strings.HasSuffix(stderr, "(yes/no): "), "%q", stderr)
+	})
+
+	t.Run("Physical", func(t *testing.T) {
+		fake, _, _, err := run("yes\n", "old_standby")
+		assert.NilError(t, err)
+		assert.Equal(t, len(fake.Calls), 1)
+		for _, arg := range fake.Calls[0].Command {
+			assert.Assert(t, !strings.HasPrefix(arg, "--dbname="), "%q", fake.Calls[0].Command)
+		}
+	})
+
+	t.Run("Declined", func(t *testing.T) {
+		fake, stdout, _, err := run("no\n", "old_standby")
+		assert.Assert(t, errors.Is(err, ErrCancelled))
+		assert.Equal(t, len(fake.Calls), 0)
+		assert.Equal(t, stdout, "")
+	})
+
+	t.Run("Active", func(t *testing.T) {
+		_, _, _, err := run("yes\n", "busy")
+		assert.Error(t, err, `replication slot "busy" is active`)
+	})
+}
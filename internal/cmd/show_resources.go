@@ -0,0 +1,448 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newShowResourcesCommand returns the resources subcommand of the show
+// command. It lists the objects PGO created for a cluster and those that are
+// missing.
+func newShowResourcesCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resources CLUSTER_NAME",
+		Short: "Show the objects PGO created for a PostgresCluster",
+		Long: `Show the StatefulSets, Deployments, Services, ConfigMaps, Secrets,
+PersistentVolumeClaims, and CronJobs of a PostgresCluster with their status and
+owner.
+
+Objects are found by the cluster label. The OWNER column follows owner
+references; "none" means the object is not owned by the cluster, so PGO did
+not create it or no longer manages it.
+
+The report ends with the objects a healthy cluster with this spec has that
+were not found.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    configmaps                                          [list]
+    cronjobs.batch                                      [list]
+    deployments.apps                                    [list]
+    persistentvolumeclaims                              [list]
+    postgresclusters.postgres-operator.crunchydata.com  [get]
+    secrets                                             [list]
+    services                                            [list]
+    statefulsets.apps                                   [list]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Show the objects of the 'hippo' postgrescluster
+pgo show resources hippo
+
+### Example output
+KIND                   NAME                         STATUS                                OWNER
+StatefulSet            hippo-instance1-8x7m         1/1 ready                             postgrescluster/hippo
+StatefulSet            hippo-repo-host              1/1 ready                             postgrescluster/hippo
+Service                hippo-ha                     ClusterIP 10.96.14.2                  postgrescluster/hippo
+Service                hippo-primary                headless                              postgrescluster/hippo
+ConfigMap              hippo-config                 1 keys                                postgrescluster/hippo
+ConfigMap              hippo-pgo-history            1 keys                                none
+Secret                 hippo-pguser-hippo           8 keys                                postgrescluster/hippo
+PersistentVolumeClaim  hippo-instance1-8x7m-pgdata  Bound 1Gi                             postgrescluster/hippo
+CronJob                hippo-repo1-full             0 1 * * *, last 2024-05-01T01:00:00Z  postgrescluster/hippo
+
+MISSING
+  PersistentVolumeClaim hippo-repo1
+  Service hippo-replicas`)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		clientset, err := kubernetes.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		_, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		cluster, err := client.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		resources, err := listClusterResources(ctx, clientset, namespace, args[0])
+		if err != nil {
+			return err
+		}
+		resolveResourceOwners(resources, cluster.GetUID(), args[0])
+
+		return printClusterResources(cmd, resources, missingClusterResources(cluster, resources))
+	}
+
+	return cmd
+}
+
+// printClusterResources prints a table of resources followed by missing.
+func printClusterResources(cmd *cobra.Command, resources []clusterResource, missing []string) error {
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "KIND\tNAME\tSTATUS\tOWNER")
+	for _, resource := range resources {
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n",
+			resource.Kind, resource.Name, resource.Status, resource.Owner)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	if len(missing) > 0 {
+		cmd.Println("\nMISSING")
+		for _, line := range missing {
+			cmd.Printf("  %s\n", line)
+		}
+	}
+	return nil
+}
+
+// clusterResource is an object that belongs to a cluster.
+type clusterResource struct {
+	Kind, Name, Status string
+	Labels             map[string]string
+
+	UID    types.UID
+	Owners []metav1.OwnerReference
+
+	// Owner is the controller of the object, filled in by
+	// [resolveResourceOwners].
+	Owner string
+}
+
+// clusterResourceKinds is the order in which kinds are listed.
+var clusterResourceKinds = []string{
+	"StatefulSet", "Deployment", "Service", "ConfigMap", "Secret", "PersistentVolumeClaim", "CronJob",
+}
+
+// listClusterResources returns the objects with the cluster label of
+// clusterName, sorted by kind then name.
+func listClusterResources(
+	ctx context.Context, clientset kubernetes.Interface, namespace, clusterName string,
+) ([]clusterResource, error) {
+	opts := metav1.ListOptions{LabelSelector: util.LabelCluster + "=" + clusterName}
+	var resources []clusterResource
+
+	statefulsets, err := clientset.AppsV1().StatefulSets(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range statefulsets.Items {
+		resources = append(resources, statefulSetResource(&statefulsets.Items[i]))
+	}
+
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range deployments.Items {
+		resources = append(resources, deploymentResource(&deployments.Items[i]))
+	}
+
+	services, err := clientset.CoreV1().Services(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range services.Items {
+		resources = append(resources, serviceResource(&services.Items[i]))
+	}
+
+	configmaps, err := clientset.CoreV1().ConfigMaps(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range configmaps.Items {
+		item := &configmaps.Items[i]
+		resources = append(resources, newClusterResource("ConfigMap", item.ObjectMeta,
+			fmt.Sprintf("%d keys", len(item.Data)+len(item.BinaryData))))
+	}
+
+	secrets, err := clientset.CoreV1().Secrets(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range secrets.Items {
+		item := &secrets.Items[i]
+		resources = append(resources, newClusterResource("Secret", item.ObjectMeta,
+			fmt.Sprintf("%d keys", len(item.Data))))
+	}
+
+	claims, err := clientset.CoreV1().PersistentVolumeClaims(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range claims.Items {
+		resources = append(resources, claimResource(&claims.Items[i]))
+	}
+
+	cronjobs, err := clientset.BatchV1().CronJobs(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range cronjobs.Items {
+		resources = append(resources, cronJobResource(&cronjobs.Items[i]))
+	}
+
+	sortClusterResources(resources)
+	return resources, nil
+}
+
+// newClusterResource returns a resource of kind described by meta.
+func newClusterResource(kind string, meta metav1.ObjectMeta, status string) clusterResource {
+	return clusterResource{
+		Kind: kind, Name: meta.Name, Status: status, Labels: meta.Labels,
+		UID: meta.UID, Owners: meta.OwnerReferences,
+	}
+}
+
+func statefulSetResource(item *appsv1.StatefulSet) clusterResource {
+	var replicas int32 = 1
+	if item.Spec.Replicas != nil {
+		replicas = *item.Spec.Replicas
+	}
+	return newClusterResource("StatefulSet", item.ObjectMeta,
+		fmt.Sprintf("%d/%d ready", item.Status.ReadyReplicas, replicas))
+}
+
+func deploymentResource(item *appsv1.Deployment) clusterResource {
+	var replicas int32 = 1
+	if item.Spec.Replicas != nil {
+		replicas = *item.Spec.Replicas
+	}
+	return newClusterResource("Deployment", item.ObjectMeta,
+		fmt.Sprintf("%d/%d ready", item.Status.ReadyReplicas, replicas))
+}
+
+func serviceResource(item *corev1.Service) clusterResource {
+	status := string(item.Spec.Type)
+	if item.Spec.ClusterIP == corev1.ClusterIPNone {
+		status = "headless"
+	} else if item.Spec.ClusterIP != "" {
+		status += " " + item.Spec.ClusterIP
+	}
+	return newClusterResource("Service", item.ObjectMeta, status)
+}
+
+func claimResource(item *corev1.PersistentVolumeClaim) clusterResource {
+	status := string(item.Status.Phase)
+	if size, ok := item.Status.Capacity[corev1.ResourceStorage]; ok {
+		status += " " + size.String()
+	}
+	return newClusterResource("PersistentVolumeClaim", item.ObjectMeta, status)
+}
+
+func cronJobResource(item *batchv1.CronJob) clusterResource {
+	status := item.Spec.Schedule
+	if item.Spec.Suspend != nil && *item.Spec.Suspend {
+		status += ", suspended"
+	}
+	if item.Status.LastScheduleTime != nil {
+		status += ", last " + item.Status.LastScheduleTime.UTC().Format(time.RFC3339)
+	}
+	return newClusterResource("CronJob", item.ObjectMeta, status)
+}
+
+// sortClusterResources orders resources by [clusterResourceKinds] then name.
+func sortClusterResources(resources []clusterResource) {
+	rank := map[string]int{}
+	for i, kind := range clusterResourceKinds {
+		rank[kind] = i
+	}
+	sort.SliceStable(resources, func(i, j int) bool {
+		if resources[i].Kind != resources[j].Kind {
+			return rank[resources[i].Kind] < rank[resources[j].Kind]
+		}
+		return resources[i].Name < resources[j].Name
+	})
+}
+
+// resolveResourceOwners sets the Owner of each resource to its controller.
+// Objects whose owners do not lead to the cluster with uid are owned by "none".
+func resolveResourceOwners(resources []clusterResource, uid types.UID, clusterName string) {
+	byUID := map[types.UID]*clusterResource{}
+	for i := range resources {
+		byUID[resources[i].UID] = &resources[i]
+	}
+
+	// ownedByCluster follows owner references up to a limited depth so that
+	// a cycle cannot loop forever.
+	var ownedByCluster func(resource *clusterResource, depth int) bool
+	ownedByCluster = func(resource *clusterResource, depth int) bool {
+		for _, owner := range resource.Owners {
+			if owner.UID == uid {
+				return true
+			}
+			if parent, ok := byUID[owner.UID]; ok && depth < 5 && ownedByCluster(parent, depth+1) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for i := range resources {
+		resource := &resources[i]
+		resource.Owner = "none"
+		if !ownedByCluster(resource, 0) {
+			continue
+		}
+		for _, owner := range resource.Owners {
+			if owner.Controller != nil && *owner.Controller || len(resource.Owners) == 1 {
+				name := owner.Name
+				if owner.UID == uid {
+					name = clusterName
+				}
+				resource.Owner = strings.ToLower(owner.Kind) + "/" + name
+				break
+			}
+		}
+	}
+}
+
+// missingClusterResources compares resources to what PGO creates for the spec
+// of cluster and describes what is not there.
+func missingClusterResources(cluster *unstructured.Unstructured, resources []clusterResource) []string {
+	name := cluster.GetName()
+	found := map[string]bool{}
+	for _, resource := range resources {
+		found[resource.Kind+" "+resource.Name] = true
+	}
+
+	expected := []string{
+		"Service " + name + "-ha",
+		"Service " + name + "-ha-config",
+		"Service " + name + "-pods",
+		"Service " + name + "-primary",
+		"Service " + name + "-replicas",
+		"ConfigMap " + name + "-config",
+		"Secret " + name + "-cluster-cert",
+		"Secret " + name + "-replication-cert",
+	}
+	for _, user := range clusterSpecUsers(cluster) {
+		expected = append(expected, "Secret "+name+"-pguser-"+user.Name)
+	}
+
+	repos, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "backups", "pgbackrest", "repos")
+	var repoHost bool
+	if len(repos) > 0 {
+		expected = append(expected, "ConfigMap "+name+"-pgbackrest-config")
+	}
+	for _, entry := range repos {
+		repo, ok := entry.(map[string]any)
+		if !ok {
+			continue
+		}
+		repoName, _, _ := unstructured.NestedString(repo, "name")
+		if _, ok := repo["volume"]; ok {
+			repoHost = true
+			expected = append(expected, "PersistentVolumeClaim "+name+"-"+repoName)
+		}
+		for schedule, suffix := range map[string]string{
+			"full": "full", "differential": "diff", "incremental": "incr",
+		} {
+			if value, _, _ := unstructured.NestedString(repo, "schedules", schedule); value != "" {
+				expected = append(expected, "CronJob "+name+"-"+repoName+"-"+suffix)
+			}
+		}
+	}
+	if repoHost {
+		expected = append(expected, "StatefulSet "+name+"-repo-host", "Secret "+name+"-pgbackrest")
+	}
+
+	if _, ok, _ := unstructured.NestedMap(cluster.Object, "spec", "proxy", "pgBouncer"); ok {
+		expected = append(expected,
+			"Deployment "+name+"-pgbouncer",
+			"Service "+name+"-pgbouncer",
+			"ConfigMap "+name+"-pgbouncer",
+			"Secret "+name+"-pgbouncer")
+	}
+
+	var missing []string
+	for _, object := range expected {
+		if !found[object] {
+			missing = append(missing, object)
+		}
+	}
+	sort.Strings(missing)
+
+	// Instance names have random suffixes, so count them by instance set.
+	sets, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "instances")
+	for i, entry := range sets {
+		set, ok := entry.(map[string]any)
+		if !ok {
+			continue
+		}
+		setName, _, _ := unstructured.NestedString(set, "name")
+		if setName == "" {
+			setName = fmt.Sprintf("%02d", i)
+		}
+		replicas, ok, _ := unstructured.NestedInt64(set, "replicas")
+		if !ok {
+			replicas = 1
+		}
+
+		var statefulsets, data int64
+		for _, resource := range resources {
+			if resource.Labels[util.LabelInstanceSet] != setName {
+				continue
+			}
+			switch {
+			case resource.Kind == "StatefulSet":
+				statefulsets++
+			case resource.Kind == "PersistentVolumeClaim" &&
+				resource.Labels[util.LabelRole] == util.RolePostgresData:
+				data++
+			}
+		}
+		if statefulsets < replicas {
+			missing = append(missing, fmt.Sprintf(
+				"StatefulSet of instance set %s: found %d of %d", setName, statefulsets, replicas))
+		}
+		if data < replicas {
+			missing = append(missing, fmt.Sprintf(
+				"PersistentVolumeClaim for pgdata of instance set %s: found %d of %d", setName, data, replicas))
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,144 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+func TestClusterResourceStatus(t *testing.T) {
+	replicas := int32(2)
+	statefulset := &appsv1.StatefulSet{Spec: appsv1.StatefulSetSpec{Replicas: &replicas}}
+	statefulset.Status.ReadyReplicas = 1
+	assert.Equal(t, statefulSetResource(statefulset).Status, "1/2 ready")
+	assert.Equal(t, deploymentResource(&appsv1.Deployment{}).Status, "0/1 ready")
+
+	assert.Equal(t, serviceResource(&corev1.Service{Spec: corev1.ServiceSpec{
+		Type: corev1.ServiceTypeClusterIP, ClusterIP: corev1.ClusterIPNone,
+	}}).Status, "headless")
+	assert.Equal(t, serviceResource(&corev1.Service{Spec: corev1.ServiceSpec{
+		Type: corev1.ServiceTypeLoadBalancer, ClusterIP: "10.0.0.1",
+	}}).Status, "LoadBalancer 10.0.0.1")
+
+	claim := &corev1.PersistentVolumeClaim{}
+	claim.Status.Phase = corev1.ClaimBound
+	claim.Status.Capacity = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")}
+	assert.Equal(t, claimResource(claim).Status, "Bound 1Gi")
+
+	suspend := true
+	cronjob := &batchv1.CronJob{Spec: batchv1.CronJobSpec{Schedule: "0 1 * * *", Suspend: &suspend}}
+	cronjob.Status.LastScheduleTime = &metav1.Time{Time: time.Date(2024, 5, 1, 1, 0, 0, 0, time.UTC)}
+	assert.Equal(t, cronJobResource(cronjob).Status, "0 1 * * *, suspended, last 2024-05-01T01:00:00Z")
+}
+
+func TestResolveResourceOwners(t *testing.T) {
+	controller := true
+	resources := []clusterResource{
+		{Kind: "PersistentVolumeClaim", Name: "data", UID: "pvc",
+			Owners: []metav1.OwnerReference{{Kind: "StatefulSet", Name: "sts", UID: "sts", Controller: &controller}}},
+		{Kind: "StatefulSet", Name: "sts", UID: "sts",
+			Owners: []metav1.OwnerReference{{Kind: "PostgresCluster", Name: "hippo", UID: "cluster", Controller: &controller}}},
+		{Kind: "Secret", Name: "mine", UID: "secret"},
+		{Kind: "ConfigMap", Name: "other", UID: "cm",
+			Owners: []metav1.OwnerReference{{Kind: "Deployment", Name: "elsewhere", UID: "unknown"}}},
+		{Kind: "Service", Name: "loop", UID: "a", Owners: []metav1.OwnerReference{{Kind: "Service", UID: "a"}}},
+	}
+	resolveResourceOwners(resources, "cluster", "hippo")
+
+	var owners []string
+	for _, resource := range resources {
+		owners = append(owners, resource.Owner)
+	}
+	assert.DeepEqual(t, owners, []string{"statefulset/sts", "postgrescluster/hippo", "none", "none", "none"})
+
+	sortClusterResources(resources)
+	assert.Equal(t, resources[0].Kind, "StatefulSet")
+	assert.Equal(t, resources[len(resources)-1].Kind, "PersistentVolumeClaim")
+}
+
+func TestMissingClusterResources(t *testing.T) {
+	cluster := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{
+			"instances": []any{
+				map[string]any{"name": "instance1", "replicas": int64(2)},
+				map[string]any{},
+			},
+			"backups": map[string]any{"pgbackrest": map[string]any{"repos": []any{
+				map[string]any{"name": "repo1", "volume": map[string]any{},
+					"schedules": map[string]any{"full": "0 1 * * *"}},
+				map[string]any{"name": "repo2", "s3": map[string]any{}},
+			}}},
+			"proxy": map[string]any{"pgBouncer": map[string]any{}},
+		},
+	}}
+	cluster.SetName("hippo")
+
+	var resources []clusterResource
+	for _, name := range []string{
+		"Service hippo-ha", "Service hippo-ha-config", "Service hippo-pods", "Service hippo-primary",
+		"ConfigMap hippo-config", "ConfigMap hippo-pgbackrest-config", "ConfigMap hippo-pgbouncer",
+		"Secret hippo-cluster-cert", "Secret hippo-replication-cert", "Secret hippo-pguser-hippo",
+		"Secret hippo-pgbackrest", "Secret hippo-pgbouncer",
+		"StatefulSet hippo-repo-host", "Deployment hippo-pgbouncer", "Service hippo-pgbouncer",
+		"CronJob hippo-repo1-full",
+	} {
+		kind, name, _ := strings.Cut(name, " ")
+		resources = append(resources, clusterResource{Kind: kind, Name: name})
+	}
+	resources = append(resources,
+		clusterResource{Kind: "StatefulSet", Name: "hippo-instance1-abcd",
+			Labels: map[string]string{util.LabelInstanceSet: "instance1"}},
+		clusterResource{Kind: "PersistentVolumeClaim", Name: "hippo-instance1-abcd-pgdata",
+			Labels: map[string]string{util.LabelInstanceSet: "instance1", util.LabelRole: util.RolePostgresData}},
+		clusterResource{Kind: "StatefulSet", Name: "hippo-01-wxyz",
+			Labels: map[string]string{util.LabelInstanceSet: "01"}},
+		clusterResource{Kind: "PersistentVolumeClaim", Name: "hippo-01-wxyz-pgdata",
+			Labels: map[string]string{util.LabelInstanceSet: "01", util.LabelRole: util.RolePostgresData}},
+	)
+
+	assert.DeepEqual(t, missingClusterResources(cluster, resources), []string{
+		"PersistentVolumeClaim hippo-repo1",
+		"Service hippo-replicas",
+		"StatefulSet of instance set instance1: found 1 of 2",
+		"PersistentVolumeClaim for pgdata of instance set instance1: found 1 of 2",
+	})
+}
+
+func TestPrintClusterResources(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	assert.NilError(t, printClusterResources(cmd, []clusterResource{
+		{Kind: "StatefulSet", Name: "hippo-instance1-8x7m", Status: "1/1 ready", Owner: "postgrescluster/hippo"},
+		{Kind: "StatefulSet", Name: "hippo-repo-host", Status: "1/1 ready", Owner: "postgrescluster/hippo"},
+		{Kind: "Service", Name: "hippo-ha", Status: "ClusterIP 10.0.0.12", Owner: "postgrescluster/hippo"},
+		{Kind: "Service", Name: "hippo-primary", Status: "headless", Owner: "postgrescluster/hippo"},
+		{Kind: "ConfigMap", Name: "hippo-config", Status: "1 keys", Owner: "postgrescluster/hippo"},
+		{Kind: "ConfigMap", Name: "hippo-pgo-history", Status: "1 keys", Owner: "none"},
+		{Kind: "Secret", Name: "hippo-pguser-hippo", Status: "8 keys", Owner: "postgrescluster/hippo"},
+		{Kind: "PersistentVolumeClaim", Name: "hippo-instance1-8x7m-pgdata", Status: "Bound 1Gi", Owner: "postgrescluster/hippo"},
+		{Kind: "CronJob", Name: "hippo-repo1-full", Status: "0 1 * * *, last 2024-05-01T01:00:00Z", Owner: "postgrescluster/hippo"},
+	}, []string{"PersistentVolumeClaim hippo-repo1", "Service hippo-replicas"}))
+
+	assert.Assert(t, strings.HasPrefix(out.String(),
+		"KIND                   NAME                         STATUS"), out.String())
+	assert.Assert(t, strings.HasSuffix(out.String(),
+		"\nMISSING\n  PersistentVolumeClaim hippo-repo1\n  Service hippo-replicas\n"), out.String())
+}
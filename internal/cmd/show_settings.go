@@ -0,0 +1,334 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newShowSettingsCommand returns the settings subcommand of the show command.
+// It lists the PostgreSQL settings of every instance and can restart them to
+// apply the settings that wait for a restart.
+func newShowSettingsCommand(config *internal.Config) *cobra.Command {
+
+	cmdShowSettings := &cobra.Command{
+		Use:   "settings CLUSTER_NAME",
+		Short: "Show PostgreSQL settings and those pending a restart",
+		Long: `Show the PostgreSQL settings that differ from their defaults on every instance
+of a PostgresCluster. Settings that have changed but only take effect after a
+restart show their new value in the PENDING RESTART column.
+
+Use "--pending-restart" to show only those settings along with a plan for
+applying them: replicas are restarted first, one at a time, then the primary
+is switched over to a restarted replica and the former primary is restarted.
+Writes stop only for the switchover. The former primary stays a replica.
+
+Use "--apply-plan" to carry out the plan after confirmation. Each step waits
+for the instance to be ready and its settings applied before the next begins.
+
+### RBAC Requirements
+    Resources  Verbs
+    ---------  -----
+    endpoints  [get]
+    pods       [list]
+    pods/exec  [create]
+
+    Note: Getting Endpoints is only needed with "--apply-plan".
+
+### Usage`,
+	}
+
+	cmdShowSettings.Example = internal.FormatExample(`# Show the settings of the 'hippo' postgrescluster
+pgo show settings hippo
+
+# Show the settings that wait for a restart and how to apply them
+pgo show settings hippo --pending-restart
+
+# Restart the instances of the 'hippo' postgrescluster to apply them
+pgo show settings hippo --apply-plan
+
+### Example output
+INSTANCE                ROLE     PARAMETER        VALUE  PENDING RESTART
+hippo-instance1-2d4n-0  replica  max_connections  100    200
+hippo-instance1-8x7m-0  primary  max_connections  100    200
+
+PLAN
+1. restart hippo-instance1-2d4n-0
+2. switch over from hippo-instance1-8x7m-0 to hippo-instance1-2d4n-0
+3. restart hippo-instance1-8x7m-0`)
+
+	var pendingOnly, applyPlan bool
+	var timeout time.Duration
+	cmdShowSettings.Flags().BoolVar(&pendingOnly, "pending-restart", false,
+		"show only settings that wait for a restart and a plan to apply them")
+	cmdShowSettings.Flags().BoolVar(&applyPlan, "apply-plan", false,
+		"restart instances to apply settings that wait for a restart")
+	cmdShowSettings.Flags().DurationVar(&timeout, "timeout", 5*time.Minute,
+		"how long to wait for each step of the plan")
+
+	// Limit the number of args, that is, only one cluster name
+	cmdShowSettings.Args = cobra.ExactArgs(1)
+
+	cmdShowSettings.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+
+		// The restarts reuse the steps of a failover drill.
+		drill := &failoverDrill{
+			Cluster: args[0],
+			Timeout: timeout,
+			Pods: func() ([]corev1.Pod, error) {
+				pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+					LabelSelector: util.DBInstanceLabels(args[0]),
+				})
+				if err != nil {
+					return nil, err
+				}
+				return pods.Items, nil
+			},
+			Leader: func() (string, error) {
+				endpoints, err := client.Endpoints(namespace).Get(ctx, args[0]+"-ha", metav1.GetOptions{})
+				if err != nil {
+					return "", err
+				}
+				return endpoints.GetAnnotations()["leader"], nil
+			},
+			Exec: func(pod string) podexec.Executor {
+				return podexec.Container(podExec, namespace, pod, util.ContainerDatabase)
+			},
+		}
+
+		pods, err := drill.Pods()
+		if err != nil {
+			return err
+		}
+		sort.Slice(pods, func(i, j int) bool { return pods[i].GetName() < pods[j].GetName() })
+
+		var settings []memberSetting
+		var replicas []string
+		primary := drillPrimary(pods)
+		pending := map[string]bool{}
+		for i := range pods {
+			name := pods[i].GetName()
+			stdout, stderr, err := podexec.PSQL(drill.Exec(name), "", memberSettingsSQL)
+			if err != nil {
+				return fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(stderr))
+			}
+			role := "replica"
+			if name == primary {
+				role = "primary"
+			} else if drillReplica(&pods[i]) {
+				replicas = append(replicas, name)
+			}
+			for _, setting := range parseMemberSettings(name, role, stdout) {
+				pending[name] = pending[name] || setting.Pending != ""
+				if setting.Pending != "" || !(pendingOnly || applyPlan) {
+					settings = append(settings, setting)
+				}
+			}
+		}
+
+		if len(settings) == 0 {
+			if pendingOnly || applyPlan {
+				cmd.Printf("No settings are pending a restart for cluster %s\n", args[0])
+			} else {
+				cmd.Printf("No settings found for cluster %s\n", args[0])
+			}
+			return nil
+		}
+		if err := printMemberSettings(cmd, settings); err != nil {
+			return err
+		}
+		if !(pendingOnly || applyPlan) {
+			return nil
+		}
+
+		plan := planRestarts(primary, replicas, pending)
+		cmd.Println()
+		printRestartPlan(cmd, plan, primary)
+		if !applyPlan || len(plan) == 0 {
+			return nil
+		}
+		_, _ = fmt.Fprint(config.ErrOut, "\n"+config.Messages.Sprintf("show.settings.warn-restart")+
+			config.Messages.Sprintf("confirm.continue"))
+		var confirmed *bool
+		for i := 0; confirmed == nil && i < 10; i++ {
+			// retry 10 times or until a confirmation is given or denied,
+			// whichever comes first
+			confirmed = config.Messages.Confirm(config.In, config.ErrOut)
+		}
+		if confirmed == nil || !*confirmed {
+			return ErrCancelled
+		}
+
+		for i, step := range plan {
+			cmd.Printf("%d. %s...\n", i+1, step)
+			if err := applyRestartStep(drill, step); err != nil {
+				return fmt.Errorf("step %d: %w", i+1, err)
+			}
+		}
+		cmd.Println("All pending settings are applied.")
+		return nil
+	}
+
+	return cmdShowSettings
+}
+
+// memberSetting is a PostgreSQL setting of one instance. Pending is the value
+// that takes effect after a restart, if any.
+type memberSetting struct {
+	Instance, Role string
+	Name, Value    string
+	Pending        string
+}
+
+// memberSettingsSQL prints the name, current value, and value pending a
+// restart of every setting that does not have its default value. The pending
+// value is the last one read from the configuration files.
+const memberSettingsSQL = `SELECT s.name, pg_catalog.current_setting(s.name),
+  CASE WHEN s.pending_restart THEN COALESCE(f.setting, '?') ELSE '' END
+FROM pg_catalog.pg_settings s
+LEFT JOIN LATERAL (
+  SELECT setting FROM pg_catalog.pg_file_settings
+  WHERE name = s.name AND error IS NULL ORDER BY seqno DESC LIMIT 1
+) f ON true
+WHERE s.pending_restart OR s.source NOT IN ('default', 'override', 'client', 'session')
+ORDER BY s.name;`
+
+// parseMemberSettings reads the output of [memberSettingsSQL] on instance.
+func parseMemberSettings(instance, role, stdout string) []memberSetting {
+	var settings []memberSetting
+	for _, row := range parseRows(stdout) {
+		if len(row) == 3 {
+			settings = append(settings, memberSetting{
+				Instance: instance, Role: role,
+				Name: row[0], Value: row[1], Pending: row[2],
+			})
+		}
+	}
+	return settings
+}
+
+// printMemberSettings prints settings as a table.
+func printMemberSettings(cmd *cobra.Command, settings []memberSetting) error {
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "INSTANCE\tROLE\tPARAMETER\tVALUE\tPENDING RESTART")
+	for _, setting := range settings {
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", setting.Instance,
+			setting.Role, setting.Name, setting.Value, setting.Pending)
+	}
+	return writer.Flush()
+}
+
+// restartStep is one step of a plan to apply settings that wait for a
+// restart. A switchover moves the primary from Member to Candidate.
+type restartStep struct {
+	Switchover bool
+	Member     string
+	Candidate  string
+}
+
+func (step restartStep) String() string {
+	if step.Switchover {
+		return fmt.Sprintf("switch over from %s to %s", step.Member, step.Candidate)
+	}
+	return "restart " + step.Member
+}
+
+// planRestarts returns the steps that restart every instance that is pending
+// a restart. Ready replicas are restarted first. The primary is restarted last,
+// after a switchover to a replica when there is one.
+func planRestarts(primary string, replicas []string, pending map[string]bool) []restartStep {
+	var plan []restartStep
+	for _, replica := range replicas {
+		if pending[replica] {
+			plan = append(plan, restartStep{Member: replica})
+		}
+	}
+	if primary != "" && pending[primary] {
+		if len(replicas) > 0 {
+			plan = append(plan, restartStep{Switchover: true, Member: primary, Candidate: replicas[0]})
+		}
+		plan = append(plan, restartStep{Member: primary})
+	}
+	return plan
+}
+
+// printRestartPlan prints plan as numbered steps. It warns when the primary
+// restarts without a switchover, which stops writes for the whole restart.
+func printRestartPlan(cmd *cobra.Command, plan []restartStep, primary string) {
+	cmd.Println("PLAN")
+	var switchover, restartPrimary bool
+	for i, step := range plan {
+		cmd.Printf("%d. %s\n", i+1, step)
+		switchover = switchover || step.Switchover
+		restartPrimary = restartPrimary || (!step.Switchover && step.Member == primary)
+	}
+	if restartPrimary && !switchover {
+		cmd.PrintErrf("WARNING: No ready replica to switch over to; writes stop while %s restarts\n", primary)
+	}
+}
+
+// applyRestartStep carries out step and waits for it to finish.
+func applyRestartStep(drill *failoverDrill, step restartStep) error {
+	if step.Switchover {
+		if err := drill.switchover(step.Candidate); err != nil {
+			return err
+		}
+		_, err := drill.waitFor(func() (bool, error) {
+			pods, err := drill.Pods()
+			return err == nil && drillPrimary(pods) == step.Candidate, nil
+		})
+		return err
+	}
+
+	// Patroni restarts the member and leaves its role as it is. The
+	// "--pending" flag skips members that no longer need it.
+	stdout, stderr, err := podexec.Patronictl(drill.Exec(step.Member),
+		fmt.Sprintf("restart --force --pending %s-ha %s", drill.Cluster, step.Member), "")
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr+stdout))
+	}
+	_, err = drill.waitFor(func() (bool, error) {
+		pods, err := drill.Pods()
+		if err != nil || !(drillPrimary(pods) == step.Member || drillReplicaReady(pods, step.Member)) {
+			return false, err
+		}
+		stdout, _, err := podexec.PSQL(drill.Exec(step.Member), "",
+			"SELECT count(*) FROM pg_catalog.pg_settings WHERE pending_restart;")
+		return err == nil && strings.TrimSpace(stdout) == "0", nil
+	})
+	return err
+}
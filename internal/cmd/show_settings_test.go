@@ -0,0 +1,87 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+)
+
+func TestParseMemberSettings(t *testing.T) {
+	settings := parseMemberSettings("hippo-instance1-8x7m-0", "primary",
+		"max_connections\t100\t200\nshared_buffers\t128MB\t\nbroken\n")
+	assert.DeepEqual(t, settings, []memberSetting{
+		{Instance: "hippo-instance1-8x7m-0", Role: "primary", Name: "max_connections", Value: "100", Pending: "200"},
+		{Instance: "hippo-instance1-8x7m-0", Role: "primary", Name: "shared_buffers", Value: "128MB"},
+	})
+}
+
+func TestPlanRestarts(t *testing.T) {
+	replicas := []string{"hippo-b", "hippo-c"}
+
+	assert.Equal(t, len(planRestarts("hippo-a", replicas, map[string]bool{})), 0)
+
+	assert.DeepEqual(t, planRestarts("hippo-a", replicas, map[string]bool{
+		"hippo-a": true, "hippo-b": true, "hippo-c": true,
+	}), []restartStep{
+		{Member: "hippo-b"},
+		{Member: "hippo-c"},
+		{Switchover: true, Member: "hippo-a", Candidate: "hippo-b"},
+		{Member: "hippo-a"},
+	})
+
+	// The primary is not pending a restart.
+	assert.DeepEqual(t, planRestarts("hippo-a", replicas, map[string]bool{"hippo-c": true}),
+		[]restartStep{{Member: "hippo-c"}})
+
+	// There is no replica to switch over to.
+	assert.DeepEqual(t, planRestarts("hippo-a", nil, map[string]bool{"hippo-a": true}),
+		[]restartStep{{Member: "hippo-a"}})
+}
+
+func TestPrintRestartPlan(t *testing.T) {
+	var out, errOut bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+	cmd.SetErr(&errOut)
+
+	printRestartPlan(cmd, []restartStep{
+		{Member: "hippo-instance1-2d4n-0"},
+		{Switchover: true, Member: "hippo-instance1-8x7m-0", Candidate: "hippo-instance1-2d4n-0"},
+		{Member: "hippo-instance1-8x7m-0"},
+	}, "hippo-instance1-8x7m-0")
+	assert.Equal(t, out.String(), `PLAN
+1. restart hippo-instance1-2d4n-0
+2. switch over from hippo-instance1-8x7m-0 to hippo-instance1-2d4n-0
+3. restart hippo-instance1-8x7m-0
+`)
+	assert.Equal(t, errOut.String(), "")
+
+	out.Reset()
+	printRestartPlan(cmd, []restartStep{{Member: "hippo-a"}}, "hippo-a")
+	assert.Equal(t, out.String(), `PLAN
+1. restart hippo-a
+`)
+	assert.Equal(t, errOut.String(),
+		"WARNING: No ready replica to switch over to; writes stop while hippo-a restarts\n")
+}
+
+func TestPrintMemberSettings(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	assert.NilError(t, printMemberSettings(cmd, []memberSetting{
+		{Instance: "hippo-instance1-2d4n-0", Role: "replica", Name: "max_connections", Value: "100", Pending: "200"},
+		{Instance: "hippo-instance1-8x7m-0", Role: "primary", Name: "max_connections", Value: "100", Pending: "200"},
+	}))
+	assert.Equal(t, out.String(), `INSTANCE                ROLE     PARAMETER        VALUE  PENDING RESTART
+hippo-instance1-2d4n-0  replica  max_connections  100    200
+hippo-instance1-8x7m-0  primary  max_connections  100    200
+`)
+}
@@ -0,0 +1,27 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseReplicaReplay(t *testing.T) {
+	assert.DeepEqual(t,
+		parseReplicaReplay("hippo-delayed-x7k2-0", "4h\t0/5000148\t2024-05-01 10:22:37.1+00\n"),
+		replicaReplay{
+			Member:       "hippo-delayed-x7k2-0",
+			Delay:        "4h",
+			ReplayLSN:    "0/5000148",
+			LastReplayed: "2024-05-01 10:22:37.1+00",
+		})
+
+	assert.DeepEqual(t, parseReplicaReplay("hippo-0", "0\t0/5000148\t\n"),
+		replicaReplay{Member: "hippo-0", Delay: "0", ReplayLSN: "0/5000148"})
+
+	assert.DeepEqual(t, parseReplicaReplay("hippo-0", ""), replicaReplay{Member: "hippo-0"})
+}
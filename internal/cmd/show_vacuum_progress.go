@@ -0,0 +1,307 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newShowVacuumProgressCommand returns the vacuum-progress subcommand of the
+// show command. It lists the long-running maintenance operations in the
+// instances of a PostgresCluster.
+func newShowVacuumProgressCommand(config *internal.Config) *cobra.Command {
+
+	cmdShowProgress := &cobra.Command{
+		Use:     "vacuum-progress CLUSTER_NAME",
+		Aliases: []string{"progress"},
+		Short:   "Show the progress of VACUUM, CREATE INDEX, CLUSTER, and base backups",
+		Long: `Show the operations reported by the pg_stat_progress_vacuum,
+pg_stat_progress_create_index, pg_stat_progress_cluster, and
+pg_stat_progress_basebackup views in every instance of a PostgresCluster,
+primary and replicas alike. PROGRESS is the share of blocks, tuples, or bytes
+done in the current phase, when Postgres reports one.
+
+Postgres 12 added the CREATE INDEX and CLUSTER views, and Postgres 13 added the
+base backup view; instances with older versions only report VACUUM. Relations
+in databases other than postgres are shown by OID.
+
+Use "--watch" to print the operations again every "--interval". Pods are
+watched rather than listed each time.
+
+### RBAC Requirements
+    Resources  Verbs
+    ---------  -----
+    pods       [list watch]
+    pods/exec  [create]
+
+    Note: Pods are only watched with "--watch".
+
+### Usage`,
+	}
+
+	cmdShowProgress.Example = internal.FormatExample(`# Show the maintenance operations running in the 'hippo' postgrescluster
+pgo show vacuum-progress hippo
+
+# Show them again every 10 seconds until interrupted
+pgo show vacuum-progress hippo --watch --interval=10s
+
+### Example output
+POD                     ROLE     OPERATION     PID   DATABASE  RELATION     PHASE                     PROGRESS  ELAPSED
+hippo-instance1-8x7m-0  primary  create index  4388  postgres  public.pets  building index            7.0%      1m40s
+hippo-instance1-8x7m-0  primary  vacuum        4120  postgres  public.pets  scanning heap             41.8%     12m3s
+hippo-instance1-q2pz-0  replica  basebackup    977   -         -            streaming database files  63.2%     4m10s`)
+
+	var watch bool
+	var interval time.Duration
+	cmdShowProgress.Flags().BoolVarP(&watch, "watch", "w", false,
+		"print the operations again every --interval until interrupted")
+	cmdShowProgress.Flags().DurationVar(&interval, "interval", 5*time.Second,
+		"time between updates with --watch")
+
+	// Limit the number of args, that is, only one cluster name
+	cmdShowProgress.Args = cobra.ExactArgs(1)
+
+	cmdShowProgress.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		if watch && interval <= 0 {
+			return fmt.Errorf("--interval must be greater than zero")
+		}
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+
+		// The version of an instance only changes when its Pod is replaced,
+		// so it is read once per Pod.
+		versions := map[string]int{}
+
+		// Pods are listed once without "--watch". With it, each update reads
+		// a cache that watches them.
+		pods := func() ([]*corev1.Pod, error) {
+			list, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+				LabelSelector: util.DBInstanceLabels(args[0]),
+			})
+			if err != nil {
+				return nil, err
+			}
+			pods := make([]*corev1.Pod, len(list.Items))
+			for i := range list.Items {
+				pods[i] = &list.Items[i]
+			}
+			return pods, nil
+		}
+		if watch {
+			cache, _, err := newConfigClusterCache(config)
+			if err != nil {
+				return err
+			}
+			defer cache.Stop()
+			pods = func() ([]*corev1.Pod, error) { return cache.InstancePods(args[0]) }
+		}
+
+		list := func() ([]progressOperation, error) {
+			pods, err := pods()
+			if err != nil {
+				return nil, err
+			}
+
+			var found bool
+			var operations []progressOperation
+			for _, pod := range pods {
+				if pod.Status.Phase != corev1.PodRunning {
+					continue
+				}
+				found = true
+
+				exec := podexec.Container(podExec, namespace, pod.GetName(), util.ContainerDatabase)
+				if versions[string(pod.GetUID())] == 0 {
+					stdout, stderr, err := podexec.PSQL(exec, "",
+						"SELECT pg_catalog.current_setting('server_version_num')::int / 10000")
+					if err != nil {
+						return nil, fmt.Errorf("%s: %w: %s", pod.GetName(), err, strings.TrimSpace(stderr))
+					}
+					versions[string(pod.GetUID())], _ = strconv.Atoi(strings.TrimSpace(stdout))
+				}
+
+				stdout, stderr, err := podexec.PSQL(exec, "", progressSQL(versions[string(pod.GetUID())]))
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w: %s", pod.GetName(), err, strings.TrimSpace(stderr))
+				}
+
+				role := "replica"
+				if pod.GetLabels()[util.LabelRole] == util.RolePatroniLeader {
+					role = "primary"
+				}
+				operations = append(operations, parseProgressOperations(pod.GetName(), role, stdout)...)
+			}
+
+			if !found {
+				return nil, fmt.Errorf("no running Pods found for cluster %s", args[0])
+			}
+			return operations, nil
+		}
+
+		for {
+			operations, err := list()
+			if !watch {
+				if err != nil {
+					return err
+				}
+				return printProgressOperations(cmd.OutOrStdout(), args[0], operations)
+			}
+
+			cmd.Printf("--- %s ---\n", time.Now().UTC().Format(time.RFC3339))
+			if err == nil {
+				err = printProgressOperations(cmd.OutOrStdout(), args[0], operations)
+			}
+			if err != nil {
+				cmd.PrintErrf("Error: %s\n", err)
+			}
+			time.Sleep(interval)
+		}
+	}
+
+	return cmdShowProgress
+}
+
+// progressOperation is one row of a pg_stat_progress view.
+type progressOperation struct {
+	Pod, Role string
+	Operation string
+	PID       string
+	Database  string
+	Relation  string
+	Phase     string
+	Done      int64
+	Total     int64
+	Elapsed   time.Duration
+}
+
+// progressSQL returns a query of the pg_stat_progress views in Postgres
+// version. Every view is reduced to the same columns: operation, pid,
+// database, relation, phase, work done, total work, and seconds elapsed.
+func progressSQL(version int) string {
+	// pg_catalog.regclass only knows the relations of the current database.
+	relation := `CASE WHEN p.datname = pg_catalog.current_database()
+  THEN p.relid::pg_catalog.regclass::text ELSE p.relid::text END`
+	elapsed := `COALESCE(EXTRACT(EPOCH FROM pg_catalog.now() - a.query_start)::bigint, 0)`
+	join := `LEFT JOIN pg_catalog.pg_stat_activity a USING (pid)`
+
+	queries := []string{fmt.Sprintf(`SELECT 'vacuum', p.pid, p.datname, %s, p.phase,
+  CASE WHEN p.phase = 'vacuuming heap' THEN p.heap_blks_vacuumed ELSE p.heap_blks_scanned END,
+  p.heap_blks_total, %s
+FROM pg_catalog.pg_stat_progress_vacuum p %s`, relation, elapsed, join)}
+
+	if version >= 12 {
+		queries = append(queries, fmt.Sprintf(`SELECT 'create index', p.pid, p.datname, %s, p.phase,
+  CASE WHEN p.blocks_total > 0 THEN p.blocks_done ELSE p.tuples_done END,
+  CASE WHEN p.blocks_total > 0 THEN p.blocks_total ELSE p.tuples_total END, %s
+FROM pg_catalog.pg_stat_progress_create_index p %s`, relation, elapsed, join),
+			fmt.Sprintf(`SELECT pg_catalog.lower(p.command), p.pid, p.datname, %s, p.phase,
+  p.heap_blks_scanned, p.heap_blks_total, %s
+FROM pg_catalog.pg_stat_progress_cluster p %s`, relation, elapsed, join))
+	}
+	if version >= 13 {
+		queries = append(queries, fmt.Sprintf(`SELECT 'basebackup', p.pid, '', '', p.phase,
+  p.backup_streamed, COALESCE(p.backup_total, 0),
+  COALESCE(EXTRACT(EPOCH FROM pg_catalog.now() - a.backend_start)::bigint, 0)
+FROM pg_catalog.pg_stat_progress_basebackup p %s`, join))
+	}
+
+	return strings.Join(queries, "\nUNION ALL\n") + "\nORDER BY 1, 2"
+}
+
+// parseProgressOperations reads the output of [progressSQL] in pod.
+func parseProgressOperations(pod, role, stdout string) []progressOperation {
+	var operations []progressOperation
+
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 8 {
+			continue
+		}
+		done, _ := strconv.ParseInt(fields[5], 10, 64)
+		total, _ := strconv.ParseInt(fields[6], 10, 64)
+		seconds, _ := strconv.ParseInt(fields[7], 10, 64)
+		operations = append(operations, progressOperation{
+			Pod:       pod,
+			Role:      role,
+			Operation: fields[0],
+			PID:       fields[1],
+			Database:  fields[2],
+			Relation:  fields[3],
+			Phase:     fields[4],
+			Done:      done,
+			Total:     total,
+			Elapsed:   time.Duration(seconds) * time.Second,
+		})
+	}
+
+	return operations
+}
+
+// Progress returns the share of work done in the current phase, or "-" when
+// Postgres does not report the total.
+func (operation progressOperation) Progress() string {
+	if operation.Total <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(operation.Done)/float64(operation.Total))
+}
+
+// printProgressOperations prints operations as a table, or a note when there
+// are none.
+func printProgressOperations(out io.Writer, clusterName string, operations []progressOperation) error {
+	if len(operations) == 0 {
+		_, err := fmt.Fprintf(out, "No maintenance operations in progress in cluster %s\n", clusterName)
+		return err
+	}
+
+	orDash := func(value string) string {
+		if value == "" {
+			return "-"
+		}
+		return value
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "POD\tROLE\tOPERATION\tPID\tDATABASE\tRELATION\tPHASE\tPROGRESS\tELAPSED")
+	for _, operation := range operations {
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			operation.Pod, operation.Role, operation.Operation, operation.PID,
+			orDash(operation.Database), orDash(operation.Relation), operation.Phase,
+			operation.Progress(), operation.Elapsed)
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestProgressSQL(t *testing.T) {
+	for version, views := range map[int][]string{
+		11: {"pg_stat_progress_vacuum"},
+		12: {"pg_stat_progress_vacuum", "pg_stat_progress_create_index", "pg_stat_progress_cluster"},
+		16: {"pg_stat_progress_vacuum", "pg_stat_progress_create_index", "pg_stat_progress_cluster",
+			"pg_stat_progress_basebackup"},
+	} {
+		sql := progressSQL(version)
+		assert.Equal(t, strings.Count(sql, "FROM pg_catalog.pg_stat_progress_"), len(views), version)
+		for _, view := range views {
+			assert.Assert(t, strings.Contains(sql, "pg_catalog."+view+" p"), "%d: %s", version, view)
+		}
+	}
+}
+
+func TestParseProgressOperations(t *testing.T) {
+	assert.Assert(t, len(parseProgressOperations("pod", "primary", "")) == 0)
+
+	operations := parseProgressOperations("hippo-instance1-8x7m-0", "primary", ""+
+		"vacuum\t4120\tpostgres\tpublic.pets\tscanning heap\t418\t1000\t723\n"+
+		"basebackup\t977\t\t\twaiting for checkpoint to finish\t0\t0\t3\n"+
+		"garbage\n")
+
+	assert.DeepEqual(t, operations, []progressOperation{
+		{
+			Pod: "hippo-instance1-8x7m-0", Role: "primary", Operation: "vacuum", PID: "4120",
+			Database: "postgres", Relation: "public.pets", Phase: "scanning heap",
+			Done: 418, Total: 1000, Elapsed: 723 * time.Second,
+		},
+		{
+			Pod: "hippo-instance1-8x7m-0", Role: "primary", Operation: "basebackup", PID: "977",
+			Phase: "waiting for checkpoint to finish", Elapsed: 3 * time.Second,
+		},
+	})
+	assert.Equal(t, operations[0].Progress(), "41.8%")
+	assert.Equal(t, operations[1].Progress(), "-")
+}
+
+func TestPrintProgressOperations(t *testing.T) {
+	var out bytes.Buffer
+	assert.NilError(t, printProgressOperations(&out, "hippo", nil))
+	assert.Equal(t, out.String(), "No maintenance operations in progress in cluster hippo\n")
+
+	out.Reset()
+	assert.NilError(t, printProgressOperations(&out, "hippo", []progressOperation{
+		{
+			Pod: "hippo-instance1-8x7m-0", Role: "primary", Operation: "vacuum", PID: "4120",
+			Database: "postgres", Relation: "public.pets", Phase: "scanning heap",
+			Done: 418, Total: 1000, Elapsed: 723 * time.Second,
+		},
+		{
+			Pod: "hippo-instance1-q2pz-0", Role: "replica", Operation: "basebackup", PID: "977",
+			Phase: "streaming database files", Done: 632, Total: 1000, Elapsed: 250 * time.Second,
+		},
+	}))
+	assert.Equal(t, out.String(), ""+
+		"POD                     ROLE     OPERATION   PID   DATABASE  RELATION     PHASE                     PROGRESS  ELAPSED\n"+
+		"hippo-instance1-8x7m-0  primary  vacuum      4120  postgres  public.pets  scanning heap             41.8%     12m3s\n"+
+		"hippo-instance1-q2pz-0  replica  basebackup  977   -         -            streaming database files  63.2%     4m10s\n")
+}
@@ -0,0 +1,231 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newShowWALUsageCommand returns the wal-usage subcommand of the show command.
+// It shows what fills pg_wal on the primary.
+func newShowWALUsageCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "wal-usage CLUSTER_NAME",
+		Short: "Show the size of pg_wal and what retains WAL",
+		Long: `Show the size of pg_wal on the primary of a PostgresCluster, the volume it is
+on, and what keeps WAL from being removed: files waiting to be archived and
+replication slots. The settings that size pg_wal and pace checkpoints follow.
+
+When pg_wal is on the data volume, WAL that piles up can fill it and stop
+Postgres. See "pgo set wal-volume" to give pg_wal a volume of its own.
+
+### RBAC Requirements
+    Resources  Verbs
+    ---------  -----
+    pods       [list]
+    pods/exec  [create]
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Show the WAL usage of the 'hippo' postgrescluster
+pgo show wal-usage hippo
+
+### Example output
+pg_wal: 1.2GiB in 77 segments of 16.0MiB
+volume: /pgdata, 41.3GiB used of 50.0GiB (82%)
+
+RETAINED BY                  WAL
+archiving (12 files ready)   192.0MiB
+slot hippo_instance1_8x2k_0  0B
+slot old_subscription        1.1GiB (inactive)
+
+SETTING                       VALUE
+archive_mode                  on
+archive_timeout               1min
+checkpoint_completion_target  0.9
+checkpoint_timeout            5min
+max_slot_wal_keep_size        -1
+max_wal_size                  1GB
+min_wal_size                  80MB
+wal_keep_size                 0
+
+WARNING: pg_wal shares the data volume; see "pgo set wal-volume"`)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		client, err := v1.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+
+		pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: util.PrimaryInstanceLabels(args[0]),
+		})
+		if err != nil {
+			return err
+		}
+		if len(pods.Items) != 1 {
+			return fmt.Errorf("primary instance Pod not found")
+		}
+		exec := podexec.Container(podExec, namespace, pods.Items[0].GetName(), util.ContainerDatabase)
+
+		stdout, stderr, err := podexec.PSQL(exec, "", walUsageSQL)
+		if err != nil {
+			return commandError(err, stderr)
+		}
+		usage := parseWALUsage(stdout)
+
+		// PGO sets PGDATA in the database container. A WAL volume is linked
+		// from there.
+		stdout, stderr, err = podexec.Bash(exec,
+			`df --block-size=1 --output=target,used,size "$(realpath "${PGDATA}/pg_wal")"`)
+		if err != nil {
+			return commandError(err, stderr)
+		}
+		for _, volume := range parseDiskFree(stdout) {
+			usage.Volume = volume
+		}
+
+		return printWALUsage(cmd.OutOrStdout(), cmd.ErrOrStderr(), usage)
+	}
+
+	return cmd
+}
+
+// walUsage is what is in pg_wal and why.
+type walUsage struct {
+	Bytes, Segments, SegmentBytes int64
+
+	// ArchiveReady is the number of segments waiting to be archived.
+	ArchiveReady int64
+
+	Slots    []walSlotRetention
+	Settings [][2]string
+
+	// Volume is the filesystem of pg_wal.
+	Volume volumeUsage
+}
+
+// walSlotRetention is the WAL that a replication slot keeps.
+type walSlotRetention struct {
+	Name   string
+	Active bool
+	Bytes  int64
+}
+
+// walUsageSQL prints the contents of pg_wal, what retains it, and the settings
+// that size it. Every row has a kind, a name, a value, and whether a slot is
+// active.
+const walUsageSQL = `SELECT 'wal', '', coalesce(sum(size), 0)::text, '' FROM pg_catalog.pg_ls_waldir()
+UNION ALL SELECT 'segments', '', count(*)::text, '' FROM pg_catalog.pg_ls_waldir() WHERE name ~ '^[0-9A-F]{24}$'
+UNION ALL SELECT 'segment_size', '', setting, '' FROM pg_catalog.pg_settings WHERE name = 'wal_segment_size'
+UNION ALL SELECT 'ready', '', count(*)::text, '' FROM pg_catalog.pg_ls_archive_statusdir() WHERE name LIKE '%.ready'
+UNION ALL (SELECT 'slot', slot_name, coalesce(pg_catalog.pg_wal_lsn_diff(pg_catalog.pg_current_wal_lsn(), restart_lsn)::bigint, 0)::text, active::text
+  FROM pg_catalog.pg_replication_slots ORDER BY slot_name)
+UNION ALL (SELECT 'setting', name, pg_catalog.current_setting(name), '' FROM pg_catalog.pg_settings
+  WHERE name IN ('archive_mode', 'archive_timeout', 'checkpoint_completion_target', 'checkpoint_timeout',
+    'max_slot_wal_keep_size', 'max_wal_size', 'min_wal_size', 'wal_keep_segments', 'wal_keep_size')
+  ORDER BY name)`
+
+// parseWALUsage reads the output of [walUsageSQL].
+func parseWALUsage(stdout string) walUsage {
+	var usage walUsage
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 4 {
+			continue
+		}
+		number, _ := strconv.ParseInt(fields[2], 10, 64)
+		switch fields[0] {
+		case "wal":
+			usage.Bytes = number
+		case "segments":
+			usage.Segments = number
+		case "segment_size":
+			usage.SegmentBytes = number
+		case "ready":
+			usage.ArchiveReady = number
+		case "slot":
+			usage.Slots = append(usage.Slots, walSlotRetention{
+				Name: fields[1], Bytes: number, Active: fields[3] == "true",
+			})
+		case "setting":
+			usage.Settings = append(usage.Settings, [2]string{fields[1], fields[2]})
+		}
+	}
+	return usage
+}
+
+// printWALUsage writes usage as a summary and two tables. Warnings go to errOut.
+func printWALUsage(out, errOut io.Writer, usage walUsage) error {
+	fmt.Fprintf(out, "pg_wal: %s in %d segments of %s\n",
+		formatBytes(usage.Bytes), usage.Segments, formatBytes(usage.SegmentBytes))
+	if usage.Volume.Size > 0 {
+		fmt.Fprintf(out, "volume: %s, %s used of %s (%d%%)\n", usage.Volume.Mount,
+			formatBytes(usage.Volume.Used), formatBytes(usage.Volume.Size),
+			usage.Volume.Used*100/usage.Volume.Size)
+	}
+	fmt.Fprintln(out)
+
+	w := tabwriter.NewWriter(out, 0, 2, 2, ' ', 0)
+	fmt.Fprintln(w, "RETAINED BY\tWAL")
+	fmt.Fprintf(w, "archiving (%d files ready)\t%s\n",
+		usage.ArchiveReady, formatBytes(usage.ArchiveReady*usage.SegmentBytes))
+	for _, slot := range usage.Slots {
+		retained := formatBytes(slot.Bytes)
+		if !slot.Active {
+			retained += " (inactive)"
+		}
+		fmt.Fprintf(w, "slot %s\t%s\n", slot.Name, retained)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out)
+	w = tabwriter.NewWriter(out, 0, 2, 2, ' ', 0)
+	fmt.Fprintln(w, "SETTING\tVALUE")
+	for _, setting := range usage.Settings {
+		fmt.Fprintf(w, "%s\t%s\n", setting[0], setting[1])
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if usage.Volume.Mount != "" && usage.Volume.Mount != "/pgwal" {
+		_, err := fmt.Fprintln(errOut, "WARNING: pg_wal shares the data volume; see \"pgo set wal-volume\"")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseWALUsage(t *testing.T) {
+	usage := parseWALUsage("" +
+		"wal\t\t1291845632\t\n" +
+		"segments\t\t77\t\n" +
+		"segment_size\t\t16777216\t\n" +
+		"ready\t\t12\t\n" +
+		"slot\thippo_instance1_8x2k_0\t0\ttrue\n" +
+		"slot\told_subscription\t1181116006\tfalse\n" +
+		"setting\tmax_wal_size\t1GB\t\n" +
+		"\n")
+
+	assert.DeepEqual(t, usage, walUsage{
+		Bytes: 1291845632, Segments: 77, SegmentBytes: 16777216, ArchiveReady: 12,
+		Slots: []walSlotRetention{
+			{Name: "hippo_instance1_8x2k_0", Active: true},
+			{Name: "old_subscription", Bytes: 1181116006},
+		},
+		Settings: [][2]string{{"max_wal_size", "1GB"}},
+	})
+}
+
+func TestPrintWALUsage(t *testing.T) {
+	usage := walUsage{
+		Bytes: 1291845632, Segments: 77, SegmentBytes: 16777216, ArchiveReady: 2,
+		Slots:    []walSlotRetention{{Name: "old_subscription", Bytes: 1181116006}},
+		Settings: [][2]string{{"checkpoint_timeout", "5min"}, {"max_wal_size", "1GB"}},
+		Volume:   volumeUsage{Mount: "/pgdata", Used: 44345186304, Size: 53687091200},
+	}
+
+	var out, errOut bytes.Buffer
+	assert.NilError(t, printWALUsage(&out, &errOut, usage))
+	assert.Equal(t, out.String(), `
+pg_wal: 1.2GiB in 77 segments of 16.0MiB
+volume: /pgdata, 41.3GiB used of 50.0GiB (82%)
+
+RETAINED BY                WAL
+archiving (2 files ready)  32.0MiB
+slot old_subscription      1.1GiB (inactive)
+
+SETTING             VALUE
+checkpoint_timeout  5min
+max_wal_size        1GB
+`[1:])
+	assert.Equal(t, errOut.String(), "WARNING: pg_wal shares the data volume; see \"pgo set wal-volume\"\n")
+
+	// There is no warning when pg_wal has its own volume.
+	errOut.Reset()
+	usage.Volume.Mount = "/pgwal"
+	assert.NilError(t, printWALUsage(&out, &errOut, usage))
+	assert.Equal(t, errOut.String(), "")
+}
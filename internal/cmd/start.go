@@ -57,6 +57,7 @@ postgresclusters/hippo start initiated`)
 
 	var forceConflicts bool
 	cmdStart.Flags().BoolVar(&forceConflicts, "force-conflicts", false, "take ownership and overwrite the shutdown setting")
+	config.Record.AddFlags(cmdStart.Flags())
 	cmdStart.RunE = func(cmd *cobra.Command, args []string) error {
 		mapping, client, err := v1beta1.NewPostgresClusterClient(config)
 		if err != nil {
@@ -81,12 +82,11 @@ postgresclusters/hippo start initiated`)
 		}
 
 		msg, err := patchClusterShutdown(cluster, client, requestArgs)
-		if msg != "" {
-			cmd.Print(msg)
-		}
 		if err != nil {
+			cmd.PrintErr(msg)
 			return err
 		}
+		cmd.Print(msg)
 		return nil
 	}
 
@@ -117,6 +117,18 @@ func patchClusterShutdown(cluster *unstructured.Unstructured, client dynamic.Nam
 	if err := unstructured.SetNestedField(intent.Object, args.NewShutdownValue, "spec", "shutdown"); err != nil {
 		return "", err
 	}
+	if err := checkClusterChange(args.Config.ErrOut, &args.Config.Messages, cluster, intent); err != nil {
+		return "", err
+	}
+
+	// Save the change for later rather than sending it.
+	if args.Config.Record.Enabled() {
+		change := internal.NewRecordedChange(internal.RecordApply,
+			args.Mapping.Resource, args.Namespace, args.ClusterName, intent)
+		change.Force = args.ForceConflicts
+		return recordChange(args.Config, change)
+	}
+
 	patch, err := intent.MarshalJSON()
 	if err != nil {
 		return "", err
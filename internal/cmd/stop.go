@@ -6,13 +6,11 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/crunchydata/postgres-operator-client/internal"
 	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
-	"github.com/crunchydata/postgres-operator-client/internal/util"
 )
 
 func newStopCommand(config *internal.Config) *cobra.Command {
@@ -43,18 +41,21 @@ postgresclusters/hippo stop initiated`)
 
 	var forceConflicts bool
 	cmdStop.Flags().BoolVar(&forceConflicts, "force-conflicts", false, "take ownership and overwrite the shutdown setting")
+	config.Record.AddFlags(cmdStop.Flags())
 	cmdStop.RunE = func(cmd *cobra.Command, args []string) error {
-		fmt.Print("WARNING: Stopping a postgrescluster is not destructive but " +
-			"it will take your database offline until you restart it. \nAre you sure you want " +
-			"to continue? (yes/no): ")
-		var confirmed *bool
-		for i := 0; confirmed == nil && i < 10; i++ {
-			// retry 10 times or until a confirmation is given or denied,
-			// whichever comes first
-			confirmed = util.Confirm(os.Stdin, os.Stdout)
-		}
-		if confirmed == nil || !*confirmed {
-			return nil
+		// Recorded changes are confirmed when they are applied.
+		if !config.Record.Enabled() {
+			_, _ = fmt.Fprint(config.ErrOut, config.Messages.Sprintf("stop.warn")+
+				config.Messages.Sprintf("confirm.continue"))
+			var confirmed *bool
+			for i := 0; confirmed == nil && i < 10; i++ {
+				// retry 10 times or until a confirmation is given or denied,
+				// whichever comes first
+				confirmed = config.Messages.Confirm(config.In, config.ErrOut)
+			}
+			if confirmed == nil || !*confirmed {
+				return ErrCancelled
+			}
 		}
 
 		mapping, client, err := v1beta1.NewPostgresClusterClient(config)
@@ -80,12 +81,11 @@ postgresclusters/hippo stop initiated`)
 		}
 
 		msg, err := patchClusterShutdown(cluster, client, requestArgs)
-		if msg != "" {
-			cmd.Printf("%s", msg)
-		}
 		if err != nil {
+			cmd.PrintErr(msg)
 			return err
 		}
+		cmd.Print(msg)
 		return nil
 	}
 
@@ -0,0 +1,25 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// newSyncCommand returns the sync subcommand of the PGO plugin.
+// Subcommands of sync copy objects from one PostgresCluster to another.
+func newSyncCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Copy objects between PostgresClusters",
+		Long:  "Copy objects between PostgresClusters",
+	}
+
+	cmd.AddCommand(newSyncRepoCredentialsCommand(config))
+
+	return cmd
+}
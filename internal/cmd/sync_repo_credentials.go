@@ -0,0 +1,331 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newSyncRepoCredentialsCommand returns the repo-credentials subcommand of the
+// sync command. It copies the pgBackRest configuration Secrets of one cluster
+// to another, such as from a primary cluster to its standby.
+func newSyncRepoCredentialsCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "repo-credentials",
+		Short: "Copy pgBackRest repository Secrets to another PostgresCluster",
+		Long: `Copy the Secrets in "spec.backups.pgbackrest.configuration" of the
+PostgresCluster "--from" to the namespace of the PostgresCluster "--to". These
+hold the credentials and cipher passphrases of repositories, which a standby
+needs to read the repositories of its source. Each is given the cluster label
+of "--to", then both copies are compared by a checksum of their data.
+
+Each of "--from" and "--to" is NAMESPACE/CLUSTER_NAME. Use "--from-context" and
+"--to-context" when the clusters are in different Kubernetes clusters; both
+default to the current context. Replacing Secrets that differ asks for
+confirmation. Overwriting values set by others may require the
+--force-conflicts flag.
+
+"--to" must list the same Secrets in its "spec.backups.pgbackrest.configuration";
+the command warns about those that it does not.
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    postgresclusters.postgres-operator.crunchydata.com  [get]
+    secrets                                             [get patch]
+
+    Note: Patching Secrets is only needed at "--to".
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Copy the repository Secrets of 'prod/hippo' to its standby 'dr/hippo-standby'
+pgo sync repo-credentials --from=prod/hippo --to=dr/hippo-standby
+
+# Copy them from the 'us-east' Kubernetes cluster to 'us-west'
+pgo sync repo-credentials --from=prod/hippo --to=dr/hippo-standby --from-context=us-east --to-context=us-west
+
+### Example output
+secrets/hippo-s3-creds applied to dr
+secrets/hippo-cipher applied to dr
+SECRET          KEYS  SHA256        RESULT
+hippo-cipher    1     9b1f04c3e2a7  match
+hippo-s3-creds  1     3f2a9c01d4e5  match`)
+
+	var from, to, fromContext, toContext string
+	var forceConflicts bool
+	cmd.Flags().StringVar(&from, "from", "", "the source cluster, as NAMESPACE/CLUSTER_NAME")
+	cobra.CheckErr(cmd.MarkFlagRequired("from"))
+	cmd.Flags().StringVar(&to, "to", "", "the cluster to copy to, as NAMESPACE/CLUSTER_NAME")
+	cobra.CheckErr(cmd.MarkFlagRequired("to"))
+	cmd.Flags().StringVar(&fromContext, "from-context", "", "the kubeconfig context of --from")
+	cmd.Flags().StringVar(&toContext, "to-context", "", "the kubeconfig context of --to")
+	cmd.Flags().BoolVar(&forceConflicts, "force-conflicts", false,
+		"take ownership and overwrite the Secrets")
+
+	cmd.Args = cobra.NoArgs
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		source, err := parseClusterReference("--from", from)
+		if err != nil {
+			return err
+		}
+		target, err := parseClusterReference("--to", to)
+		if err != nil {
+			return err
+		}
+		if source == target && fromContext == toContext {
+			return fmt.Errorf("--from and --to are the same cluster")
+		}
+
+		sourceSecrets, sourceNames, err := clusterRepoSecrets(ctx, contextConfig(config, fromContext), source, true)
+		if err != nil {
+			return err
+		}
+		if len(sourceSecrets) == 0 {
+			cmd.Printf("postgresclusters/%s has no Secrets in spec.backups.pgbackrest.configuration. Nothing to do.\n",
+				source.Name)
+			return nil
+		}
+
+		targetConfig := contextConfig(config, toContext)
+		targetSecrets, targetNames, err := clusterRepoSecrets(ctx, targetConfig, target, false)
+		if err != nil {
+			return err
+		}
+		for _, name := range missingStrings(targetNames, sourceNames) {
+			cmd.PrintErrf("WARNING: postgresclusters/%s does not list secrets/%s in spec.backups.pgbackrest.configuration\n",
+				target.Name, name)
+		}
+
+		var replaced []string
+		for _, secret := range sourceSecrets {
+			if existing, ok := targetSecrets[secret.Name]; ok &&
+				secretChecksum(existing.Data) != secretChecksum(secret.Data) {
+				replaced = append(replaced, secret.Name)
+			}
+		}
+		if len(replaced) > 0 {
+			_, _ = fmt.Fprint(config.ErrOut, config.Messages.Sprintf("sync.warn-replace", strings.Join(replaced, ", "))+
+				config.Messages.Sprintf("confirm.continue"))
+			var confirmed *bool
+			for i := 0; confirmed == nil && i < 10; i++ {
+				// retry 10 times or until a confirmation is given or denied,
+				// whichever comes first
+				confirmed = config.Messages.Confirm(config.In, config.ErrOut)
+			}
+			if confirmed == nil || !*confirmed {
+				return ErrCancelled
+			}
+		}
+
+		rest, err := targetConfig.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		clientset, err := kubernetes.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		patchOptions := metav1.PatchOptions{}
+		if forceConflicts {
+			b := true
+			patchOptions.Force = &b
+		}
+
+		var rows []secretSyncRow
+		for _, secret := range sourceSecrets {
+			patch, err := json.Marshal(copiedRepoSecret(secret, target))
+			if err != nil {
+				return err
+			}
+			copied, err := clientset.CoreV1().Secrets(target.Namespace).Patch(ctx, secret.Name,
+				types.ApplyPatchType, patch, config.Patch.PatchOptions(patchOptions))
+			if err != nil {
+				if apierrors.IsConflict(err) {
+					cmd.PrintErrln("SUGGESTION: The --force-conflicts flag may help in performing this operation.")
+				}
+				return err
+			}
+			cmd.Printf("secrets/%s applied to %s\n", secret.Name, target.Namespace)
+
+			// Read the Secret again in case something else changed it.
+			if copied, err = clientset.CoreV1().Secrets(target.Namespace).Get(
+				ctx, secret.Name, metav1.GetOptions{}); err != nil {
+				return err
+			}
+			rows = append(rows, secretSyncRow{
+				Name: secret.Name, Keys: len(secret.Data),
+				Source: secretChecksum(secret.Data), Target: secretChecksum(copied.Data),
+			})
+		}
+
+		return printSecretSync(cmd, rows)
+	}
+
+	return cmd
+}
+
+// clusterReference is a PostgresCluster in a namespace.
+type clusterReference struct {
+	Namespace, Name string
+}
+
+// parseClusterReference reads value of flag as NAMESPACE/CLUSTER_NAME.
+func parseClusterReference(flag, value string) (clusterReference, error) {
+	namespace, name, ok := strings.Cut(value, "/")
+	if !ok || namespace == "" || name == "" || strings.Contains(name, "/") {
+		return clusterReference{}, fmt.Errorf("%s must be NAMESPACE/CLUSTER_NAME, got %q", flag, value)
+	}
+	return clusterReference{Namespace: namespace, Name: name}, nil
+}
+
+// contextConfig returns config for the kubeconfig context called kubeContext,
+// or config itself when kubeContext is empty.
+func contextConfig(config *internal.Config, kubeContext string) *internal.Config {
+	if kubeContext == "" {
+		return config
+	}
+	flags := genericclioptions.NewConfigFlags(true)
+	flags.CacheDir, flags.KubeConfig, flags.Timeout = config.CacheDir, config.KubeConfig, config.Timeout
+	flags.Impersonate, flags.ImpersonateGroup = config.Impersonate, config.ImpersonateGroup
+	flags.Context = &kubeContext
+	return &internal.Config{
+		ConfigFlags: flags,
+		IOStreams:   config.IOStreams,
+		Messages:    config.Messages,
+		Patch:       config.Patch,
+	}
+}
+
+// clusterRepoSecrets returns the Secrets that the pgBackRest configuration of
+// cluster projects, by name, and the names it lists. Missing Secrets are an
+// error when required and skipped otherwise.
+func clusterRepoSecrets(
+	ctx context.Context, config *internal.Config, cluster clusterReference, required bool,
+) (map[string]*corev1.Secret, []string, error) {
+	_, client, err := v1beta1.NewPostgresClusterClient(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	object, err := client.Namespace(cluster.Namespace).Get(ctx, cluster.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	rest, err := config.ToRESTConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(rest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := configurationSecretNames(object)
+	secrets := make(map[string]*corev1.Secret, len(names))
+	for _, name := range names {
+		secret, err := clientset.CoreV1().Secrets(cluster.Namespace).Get(ctx, name, metav1.GetOptions{})
+		switch {
+		case err == nil:
+			secrets[name] = secret
+		case apierrors.IsNotFound(err) && !required:
+		default:
+			return nil, nil, err
+		}
+	}
+	return secrets, names, nil
+}
+
+// copiedRepoSecret returns an intent for a copy of secret in the namespace of
+// target, labeled for target. Only labels, type, and data are copied.
+func copiedRepoSecret(secret *corev1.Secret, target clusterReference) map[string]any {
+	labels := map[string]any{}
+	for key, value := range secret.Labels {
+		labels[key] = value
+	}
+	labels[util.LabelCluster] = target.Name
+
+	data := make(map[string][]byte, len(secret.Data))
+	for key, value := range secret.Data {
+		data[key] = value
+	}
+	copied := generatedSecret(target.Namespace, target.Name, secret.Name, data)
+	copied["metadata"].(map[string]any)["labels"] = labels
+	if secret.Type != "" {
+		copied["type"] = string(secret.Type)
+	}
+	return copied
+}
+
+// secretChecksum returns the SHA-256 of the keys and values of data in order.
+func secretChecksum(data map[string][]byte) string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		hash.Write([]byte(key))
+		hash.Write([]byte{0})
+		hash.Write(data[key])
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// secretSyncRow is one copied Secret and the checksums of both copies.
+type secretSyncRow struct {
+	Name           string
+	Keys           int
+	Source, Target string
+}
+
+// printSecretSync prints rows as a table sorted by name. It fails when any
+// copy does not match its source.
+func printSecretSync(cmd *cobra.Command, rows []secretSyncRow) error {
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Name < rows[j].Name })
+
+	var mismatched []string
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 2, 2, ' ', 0)
+	fmt.Fprintln(w, "SECRET\tKEYS\tSHA256\tRESULT")
+	for _, row := range rows {
+		result := "match"
+		if row.Source != row.Target {
+			result = "MISMATCH"
+			mismatched = append(mismatched, row.Name)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", row.Name, row.Keys, row.Source[:12], result)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(mismatched) > 0 {
+		return fmt.Errorf("the copies of %s do not match their source", strings.Join(mismatched, ", "))
+	}
+	return nil
+}
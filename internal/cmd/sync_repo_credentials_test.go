@@ -0,0 +1,87 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crunchydata/postgres-operator-client/internal/testing/cmp"
+)
+
+func TestParseClusterReference(t *testing.T) {
+	ref, err := parseClusterReference("--from", "prod/hippo")
+	assert.NilError(t, err)
+	assert.Equal(t, ref, clusterReference{Namespace: "prod", Name: "hippo"})
+
+	for _, value := range []string{"", "hippo", "/hippo", "prod/", "prod/hippo/x"} {
+		_, err := parseClusterReference("--to", value)
+		assert.ErrorContains(t, err, "--to must be NAMESPACE/CLUSTER_NAME", "%q", value)
+	}
+}
+
+func TestSecretChecksum(t *testing.T) {
+	a := secretChecksum(map[string][]byte{"s3.conf": []byte("key"), "cipher": []byte("pass")})
+	assert.Equal(t, a, secretChecksum(map[string][]byte{"cipher": []byte("pass"), "s3.conf": []byte("key")}))
+
+	// Moving bytes between keys and values changes the checksum.
+	assert.Assert(t, a != secretChecksum(map[string][]byte{"s3.confk": []byte("ey"), "cipher": []byte("pass")}))
+	assert.Assert(t, a != secretChecksum(map[string][]byte{"s3.conf": []byte("key")}))
+}
+
+func TestCopiedRepoSecret(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "hippo-s3-creds", Namespace: "prod", ResourceVersion: "12",
+			Labels: map[string]string{
+				"app": "hippo",
+				"postgres-operator.crunchydata.com/cluster": "hippo",
+			},
+			OwnerReferences: []metav1.OwnerReference{{Name: "hippo"}},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{"s3.conf": []byte("[global]\n")},
+	}
+
+	assert.Assert(t, cmp.MarshalMatches(
+		copiedRepoSecret(secret, clusterReference{Namespace: "dr", Name: "hippo-standby"}), `
+apiVersion: v1
+data:
+  s3.conf: W2dsb2JhbF0K
+kind: Secret
+metadata:
+  labels:
+    app: hippo
+    postgres-operator.crunchydata.com/cluster: hippo-standby
+  name: hippo-s3-creds
+  namespace: dr
+type: Opaque
+	`))
+}
+
+func TestPrintSecretSync(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	match := secretChecksum(map[string][]byte{"a": []byte("b")})
+	other := secretChecksum(nil)
+	err := printSecretSync(cmd, []secretSyncRow{
+		{Name: "hippo-s3-creds", Keys: 1, Source: match, Target: match},
+		{Name: "hippo-cipher", Keys: 2, Source: other, Target: match},
+	})
+	assert.ErrorContains(t, err, "the copies of hippo-cipher do not match")
+	assert.Equal(t, out.String(), fmt.Sprintf(`
+SECRET          KEYS  SHA256        RESULT
+hippo-cipher    2     %s  MISMATCH
+hippo-s3-creds  1     %s  match
+`[1:], other[:12], match[:12]))
+}
@@ -0,0 +1,25 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// newTestCommand returns the test subcommand of the PGO plugin. Subcommands
+// of test prove that something a PostgresCluster depends on works by using it.
+func newTestCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "Prove that parts of a PostgresCluster work by using them",
+		Long:  "Prove that parts of a PostgresCluster work by using them",
+	}
+
+	cmd.AddCommand(newTestRestoreCommand(config))
+
+	return cmd
+}
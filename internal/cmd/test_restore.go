@@ -0,0 +1,366 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+	"github.com/crunchydata/postgres-operator-client/internal/apis/postgres-operator.crunchydata.com/v1beta1"
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// newTestRestoreCommand returns the restore subcommand of the test command.
+// It restores a backup into a throwaway PostgresCluster and checks the data.
+func newTestRestoreCommand(config *internal.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore CLUSTER_NAME",
+		Short: "Prove that a backup of a PostgresCluster can be restored",
+		Long: `Restore a backup of a PostgresCluster into a throwaway PostgresCluster, count
+the rows of the tables given by --table, and delete the throwaway cluster. The
+result is PASS when the restore finished and every table has at least
+--min-rows rows.
+
+The throwaway cluster is named CLUSTER_NAME-restore-test and has one instance
+with the Postgres version, images, and data volume of the first instance set
+of CLUSTER_NAME. It is created in the namespace of CLUSTER_NAME, or in a new
+namespace with --ephemeral. That namespace is deleted at the end, along with
+everything in it.
+
+Tables are given as DATABASE:TABLE, where TABLE can be qualified by its
+schema, e.g. "app:public.orders".
+
+### RBAC Requirements
+    Resources                                           Verbs
+    ---------                                           -----
+    jobs                                                [list]
+    namespaces                                          [create delete]
+    pods                                                [list]
+    pods/exec                                           [create]
+    postgresclusters.postgres-operator.crunchydata.com  [get create delete]
+
+    Note: namespaces are only needed with --ephemeral.
+
+### Usage`,
+	}
+
+	cmd.Example = internal.FormatExample(`# Restore the latest backup of the 'hippo' postgrescluster in a scratch namespace
+pgo test restore hippo --repoName=repo1 --ephemeral --table=app:public.orders
+
+# Restore the backup to a point in time and check two tables
+pgo test restore hippo --repoName=repo2 --target-time="2 hours ago" \
+  --table=app:public.orders --table=app:public.customers
+
+### Example output
+restoring repo1 of postgresclusters/hippo into postgresclusters/hippo-restore-test in namespace hippo-restore-test-x7k2p...
+CHECK    RESULT  DETAIL
+restore  ok      restored in 4m12s
+table    ok      app:public.orders has 128400 rows
+
+Result: PASS
+deleting namespace hippo-restore-test-x7k2p...`)
+
+	var repoName, targetTime string
+	var tables []string
+	var ephemeral bool
+	var minRows int64
+	var timeout time.Duration
+	cmd.Flags().StringVar(&repoName, "repoName", "", "repository to restore from")
+	cobra.CheckErr(cmd.MarkFlagRequired("repoName"))
+	cmd.Flags().StringVar(&targetTime, "target-time", "latest",
+		`point in time to restore to, e.g. "2024-05-01 14:03 Europe/Berlin" or "45 minutes ago"`)
+	cmd.Flags().BoolVar(&ephemeral, "ephemeral", false,
+		"create the throwaway cluster in a new namespace and delete the namespace at the end")
+	cmd.Flags().StringArrayVar(&tables, "table", nil,
+		"DATABASE:TABLE to count the rows of; can be used multiple times")
+	cmd.Flags().Int64Var(&minRows, "min-rows", 1, "rows that each table must have")
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Minute, "how long to wait for the restore")
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		if !repoNamePattern.MatchString(repoName) {
+			return fmt.Errorf("--repoName must be one of repo1, repo2, repo3, or repo4")
+		}
+		checked, err := parseRestoreTestTables(tables)
+		if err != nil {
+			return err
+		}
+		var options []string
+		if targetTime != "latest" {
+			target, err := parseTargetTime(targetTime, time.Now(), time.Local)
+			if err != nil {
+				return err
+			}
+			if options, err = restoreTargetOptions(nil, target); err != nil {
+				return err
+			}
+		}
+
+		rest, err := config.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		clientset, err := kubernetes.NewForConfig(rest)
+		if err != nil {
+			return err
+		}
+		podExec, err := util.NewPodExecutor(rest)
+		if err != nil {
+			return err
+		}
+		mapping, client, err := v1beta1.NewPostgresClusterClient(config)
+		if err != nil {
+			return err
+		}
+		namespace, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+
+		source, err := client.Namespace(namespace).Get(ctx, args[0], metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		name := args[0] + "-restore-test"
+		intent, err := restoreTestCluster(source, name, namespace, repoName, options)
+		if err != nil {
+			return err
+		}
+
+		scratch := namespace
+		if ephemeral {
+			created, err := clientset.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{GenerateName: args[0] + "-restore-test-"},
+			}, metav1.CreateOptions{FieldManager: config.Patch.FieldManager})
+			if err != nil {
+				return err
+			}
+			scratch = created.Name
+			intent.SetNamespace(scratch)
+
+			defer func() {
+				cmd.Printf("deleting namespace %s...\n", scratch)
+				if err := clientset.CoreV1().Namespaces().Delete(ctx, scratch, metav1.DeleteOptions{}); err != nil {
+					cmd.PrintErrf("WARNING: unable to delete the throwaway namespace: %v\n", err)
+				}
+			}()
+		}
+
+		if _, err := client.Namespace(scratch).Create(ctx, intent,
+			metav1.CreateOptions{FieldManager: config.Patch.FieldManager}); err != nil {
+			return err
+		}
+		if !ephemeral {
+			defer func() {
+				cmd.Printf("deleting %s/%s...\n", mapping.Resource.Resource, name)
+				if err := client.Namespace(scratch).Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+					cmd.PrintErrf("WARNING: unable to delete the throwaway cluster: %v\n", err)
+				}
+			}()
+		}
+		cmd.Printf("restoring %s of %s/%s into %s/%s in namespace %s...\n", repoName,
+			mapping.Resource.Resource, args[0], mapping.Resource.Resource, name, scratch)
+
+		started := time.Now()
+		var primary string
+		for err == nil && primary == "" {
+			var pods *corev1.PodList
+			var jobs *batchv1.JobList
+			if pods, err = clientset.CoreV1().Pods(scratch).List(ctx, metav1.ListOptions{
+				LabelSelector: util.PrimaryInstanceLabels(name),
+			}); err != nil {
+				break
+			}
+			if jobs, err = clientset.BatchV1().Jobs(scratch).List(ctx, metav1.ListOptions{
+				LabelSelector: util.LabelCluster + "=" + name + "," + util.LabelPGBackRestRestore,
+			}); err != nil {
+				break
+			}
+			if primary, err = restoreTestProgress(pods.Items, jobs.Items); err == nil && primary == "" {
+				if time.Since(started) > timeout {
+					err = fmt.Errorf("the restore did not finish within %s", timeout)
+				} else {
+					time.Sleep(10 * time.Second)
+				}
+			}
+		}
+
+		check := readinessCheck{Name: "restore", Healthy: err == nil}
+		if err == nil {
+			check.Detail = "restored in " + time.Since(started).Round(time.Second).String()
+		} else {
+			check.Detail = err.Error()
+		}
+		checks := []readinessCheck{check}
+
+		if primary != "" {
+			exec := podexec.Container(podExec, scratch, primary, util.ContainerDatabase)
+			for _, table := range checked {
+				stdout, stderr, err := podexec.PSQL(exec, table.Database,
+					"SELECT count(*) FROM "+tableCopy{Table: table.Table}.tableName())
+				checks = append(checks, table.check(stdout, commandError(err, stderr), minRows))
+			}
+		}
+		if err := printReadinessChecks(cmd, checks); err != nil {
+			return err
+		}
+
+		var failed int
+		for _, check := range checks {
+			if !check.Healthy {
+				failed++
+			}
+		}
+		if failed > 0 {
+			cmd.Println("\nResult: FAIL")
+			return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+		}
+		cmd.Println("\nResult: PASS")
+		return nil
+	}
+
+	return cmd
+}
+
+// restoreTestTable is a table whose rows "test restore" counts.
+type restoreTestTable struct {
+	Database, Table string
+}
+
+func (table restoreTestTable) String() string { return table.Database + ":" + table.Table }
+
+// check returns the result of counting the rows of table, as printed by psql
+// in stdout. The table must have at least minRows rows.
+func (table restoreTestTable) check(stdout string, err error, minRows int64) readinessCheck {
+	check := readinessCheck{Name: "table", Subject: table.String()}
+	if err != nil {
+		check.Detail = table.String() + ": " + err.Error()
+		return check
+	}
+	rows, err := strconv.ParseInt(strings.TrimSpace(stdout), 10, 64)
+	if err != nil {
+		check.Detail = fmt.Sprintf("%s: unexpected output %q", table, strings.TrimSpace(stdout))
+		return check
+	}
+	check.measured(float64(rows))
+	check.Healthy = rows >= minRows
+	check.Detail = fmt.Sprintf("%s has %d rows", table, rows)
+	if !check.Healthy {
+		check.Detail += fmt.Sprintf(", fewer than %d", minRows)
+	}
+	return check
+}
+
+// parseRestoreTestTables reads the values of --table.
+func parseRestoreTestTables(values []string) ([]restoreTestTable, error) {
+	tables := make([]restoreTestTable, 0, len(values))
+	for _, value := range values {
+		database, table, ok := strings.Cut(value, ":")
+		if !ok || database == "" || table == "" {
+			return nil, fmt.Errorf("--table must be DATABASE:TABLE, got %q", value)
+		}
+		tables = append(tables, restoreTestTable{Database: database, Table: table})
+	}
+	return tables, nil
+}
+
+// restoreTestCluster returns a PostgresCluster named name in namespace that
+// restores repoName of source with options. It has one instance like the
+// first instance set of source.
+func restoreTestCluster(
+	source *unstructured.Unstructured, name, namespace, repoName string, options []string,
+) (*unstructured.Unstructured, error) {
+	version, _, err := unstructured.NestedFieldNoCopy(source.Object, "spec", "postgresVersion")
+	if err != nil {
+		return nil, err
+	}
+	cluster, err := generateUnstructuredClusterYaml(name, fmt.Sprint(version))
+	if err != nil {
+		return nil, err
+	}
+	cluster.SetNamespace(namespace)
+
+	for _, path := range [][]string{
+		{"spec", "image"},
+		{"spec", "imagePullPolicy"},
+		{"spec", "imagePullSecrets"},
+		{"spec", "postGISVersion"},
+		{"spec", "backups", "pgbackrest", "image"},
+	} {
+		if value, found, _ := unstructured.NestedFieldCopy(source.Object, path...); found {
+			if err := unstructured.SetNestedField(cluster.Object, value, path...); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// The restored data must fit, so use the data volume of the source.
+	instances, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "instances")
+	sources, _, _ := unstructured.NestedSlice(source.Object, "spec", "instances")
+	if len(sources) > 0 {
+		if first, ok := sources[0].(map[string]any); ok {
+			if claim, found, _ := unstructured.NestedFieldCopy(first, "dataVolumeClaimSpec"); found {
+				instances[0].(map[string]any)["dataVolumeClaimSpec"] = claim
+			}
+		}
+	}
+	instances[0].(map[string]any)["replicas"] = int64(1)
+	if err := unstructured.SetNestedSlice(cluster.Object, instances, "spec", "instances"); err != nil {
+		return nil, err
+	}
+
+	dataSource := map[string]any{
+		"clusterName":      source.GetName(),
+		"clusterNamespace": source.GetNamespace(),
+		"repoName":         repoName,
+	}
+	if len(options) > 0 {
+		values := make([]any, len(options))
+		for i := range options {
+			values[i] = options[i]
+		}
+		dataSource["options"] = values
+	}
+	if err := unstructured.SetNestedMap(cluster.Object, dataSource,
+		"spec", "dataSource", "postgresCluster"); err != nil {
+		return nil, err
+	}
+	return cluster, nil
+}
+
+// restoreTestProgress returns the ready primary of a throwaway cluster, or
+// empty when it is not ready yet. It fails when a restore Job in jobs failed.
+func restoreTestProgress(pods []corev1.Pod, jobs []batchv1.Job) (string, error) {
+	for i := range jobs {
+		for _, condition := range jobs[i].Status.Conditions {
+			if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+				return "", fmt.Errorf("restore Job %s failed: %s", jobs[i].Name,
+					strings.TrimSpace(condition.Reason+" "+condition.Message))
+			}
+		}
+	}
+	for i := range pods {
+		if podIsReady(&pods[i]) {
+			return pods[i].Name, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crunchydata/postgres-operator-client/internal/testing/cmp"
+)
+
+func TestParseRestoreTestTables(t *testing.T) {
+	tables, err := parseRestoreTestTables([]string{"app:public.orders", "postgres:events"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, tables, []restoreTestTable{
+		{Database: "app", Table: "public.orders"},
+		{Database: "postgres", Table: "events"},
+	})
+
+	for _, value := range []string{"orders", ":orders", "app:"} {
+		_, err := parseRestoreTestTables([]string{value})
+		assert.ErrorContains(t, err, "DATABASE:TABLE")
+	}
+}
+
+func TestRestoreTestTableCheck(t *testing.T) {
+	table := restoreTestTable{Database: "app", Table: "public.orders"}
+
+	check := table.check("128400\n", nil, 1)
+	assert.Assert(t, check.Healthy)
+	assert.Equal(t, check.Detail, "app:public.orders has 128400 rows")
+	assert.Equal(t, *check.Value, float64(128400))
+
+	check = table.check("0\n", nil, 1)
+	assert.Assert(t, !check.Healthy)
+	assert.Equal(t, check.Detail, "app:public.orders has 0 rows, fewer than 1")
+
+	check = table.check("", errors.New(`relation "public.orders" does not exist`), 1)
+	assert.Assert(t, !check.Healthy)
+	assert.Equal(t, check.Detail, `app:public.orders: relation "public.orders" does not exist`)
+}
+
+func TestRestoreTestCluster(t *testing.T) {
+	var source unstructured.Unstructured
+	assert.NilError(t, yaml.Unmarshal([]byte(`
+apiVersion: postgres-operator.crunchydata.com/v1beta1
+kind: PostgresCluster
+metadata:
+  name: hippo
+  namespace: prod
+spec:
+  image: registry.example.com/postgres:16
+  postgresVersion: 16
+  instances:
+  - name: instance1
+    replicas: 3
+    dataVolumeClaimSpec:
+      accessModes: [ReadWriteOnce]
+      resources:
+        requests:
+          storage: 50Gi
+  backups:
+    pgbackrest:
+      image: registry.example.com/pgbackrest:2.51
+`), &source))
+
+	cluster, err := restoreTestCluster(&source, "hippo-restore-test", "scratch", "repo2",
+		[]string{"--type=time", `--target="2024-05-01 12:00:00+00"`})
+	assert.NilError(t, err)
+	assert.Assert(t, cmp.MarshalMatches(cluster.Object, `
+apiVersion: postgres-operator.crunchydata.com/v1beta1
+kind: PostgresCluster
+metadata:
+  name: hippo-restore-test
+  namespace: scratch
+spec:
+  backups:
+    pgbackrest:
+      image: registry.example.com/pgbackrest:2.51
+      repos:
+      - name: repo1
+        volume:
+          volumeClaimSpec:
+            accessModes:
+            - ReadWriteOnce
+            resources:
+              requests:
+                storage: 1Gi
+  dataSource:
+    postgresCluster:
+      clusterName: hippo
+      clusterNamespace: prod
+      options:
+      - --type=time
+      - --target="2024-05-01 12:00:00+00"
+      repoName: repo2
+  image: registry.example.com/postgres:16
+  instances:
+  - dataVolumeClaimSpec:
+      accessModes:
+      - ReadWriteOnce
+      resources:
+        requests:
+          storage: 50Gi
+    replicas: 1
+  postgresVersion: 16
+`))
+}
+
+func TestRestoreTestProgress(t *testing.T) {
+	ready := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "hippo-restore-test-abcd-0"}}
+	ready.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+
+	primary, err := restoreTestProgress(nil, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, primary, "")
+
+	primary, err = restoreTestProgress([]corev1.Pod{ready}, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, primary, "hippo-restore-test-abcd-0")
+
+	failed := batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "hippo-restore-test-pgbackrest-restore"}}
+	failed.Status.Conditions = []batchv1.JobCondition{{
+		Type: batchv1.JobFailed, Status: corev1.ConditionTrue,
+		Reason: "BackoffLimitExceeded", Message: "Job has reached the specified backoff limit",
+	}}
+	_, err = restoreTestProgress(nil, []batchv1.Job{failed})
+	assert.Error(t, err, "restore Job hippo-restore-test-pgbackrest-restore failed: "+
+		"BackoffLimitExceeded Job has reached the specified backoff limit")
+}
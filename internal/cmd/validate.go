@@ -0,0 +1,314 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+// specViolation is a rule of PGO that a change to a PostgresCluster breaks.
+type specViolation struct {
+	Field   string
+	Message string
+
+	// Warning is true when the change is allowed but likely unintended.
+	Warning bool
+}
+
+func (v specViolation) String() string { return v.Field + ": " + v.Message }
+
+// repoNamePattern matches the names of pgBackRest repositories allowed by PGO.
+var repoNamePattern = regexp.MustCompile(`^repo[1-4]$`)
+
+// checkClusterChange compares current with the result of applying intent to
+// it. It prints warnings to out and returns an error that explains the rules
+// the change breaks, if any, before it is sent to the Kubernetes API.
+func checkClusterChange(
+	out io.Writer, messages *internal.MessageConfig, current, intent *unstructured.Unstructured,
+) error {
+	return checkClusterProposal(out, messages, current, mergeIntent(current, intent))
+}
+
+// checkClusterProposal is [checkClusterChange] for a proposed object that
+// replaces current.
+func checkClusterProposal(
+	out io.Writer, messages *internal.MessageConfig, current, proposed *unstructured.Unstructured,
+) error {
+	var errs []string
+	for _, violation := range validateClusterChange(current, proposed) {
+		if violation.Warning {
+			_, _ = fmt.Fprint(out, messages.Sprintf("validate.warn", violation))
+		} else {
+			errs = append(errs, "  "+violation.String())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New("this change to postgrescluster " + current.GetName() +
+			" would be rejected or break the cluster:\n" + strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// mergeIntent returns a copy of current with the fields of intent applied the
+// way server-side apply does: objects are merged, lists of objects are merged
+// by their "name" field, and everything else is replaced.
+func mergeIntent(current, intent *unstructured.Unstructured) *unstructured.Unstructured {
+	merged := current.DeepCopy()
+	merged.Object = mergeValue(merged.Object, runtime.DeepCopyJSON(intent.Object)).(map[string]interface{})
+	return merged
+}
+
+func mergeValue(current, intent interface{}) interface{} {
+	switch intent := intent.(type) {
+	case map[string]interface{}:
+		current, ok := current.(map[string]interface{})
+		if !ok {
+			return intent
+		}
+		for key, value := range intent {
+			current[key] = mergeValue(current[key], value)
+		}
+		return current
+
+	case []interface{}:
+		current, ok := current.([]interface{})
+		if !ok || !namedList(intent) || !namedList(current) {
+			return intent
+		}
+		for _, item := range intent {
+			name := item.(map[string]interface{})["name"]
+			found := false
+			for i := range current {
+				if current[i].(map[string]interface{})["name"] == name {
+					current[i], found = mergeValue(current[i], item), true
+				}
+			}
+			if !found {
+				current = append(current, item)
+			}
+		}
+		return current
+	}
+	return intent
+}
+
+// namedList returns whether every item of list is an object with a name.
+func namedList(list []interface{}) bool {
+	for _, item := range list {
+		if object, ok := item.(map[string]interface{}); !ok || object["name"] == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// validateClusterChange returns the rules that changing current to proposed
+// breaks. These are rules that the Kubernetes API or PGO enforce after the
+// change is sent, often with messages that are hard to act on.
+func validateClusterChange(current, proposed *unstructured.Unstructured) []specViolation {
+	var violations []specViolation
+
+	// PostgreSQL cannot be downgraded in place, and moving forward without
+	// a PGUpgrade leaves Postgres unable to read its data.
+	before := postgresVersion(current)
+	after := postgresVersion(proposed)
+	switch {
+	case before > 0 && after < before:
+		violations = append(violations, specViolation{
+			Field: "spec.postgresVersion",
+			Message: fmt.Sprintf("PostgreSQL cannot be downgraded from %d to %d;"+
+				" restore a backup into a new cluster instead", before, after),
+		})
+	case before > 0 && after > before:
+		violations = append(violations, specViolation{
+			Field: "spec.postgresVersion", Warning: true,
+			Message: fmt.Sprintf("changing from %d to %d only works after a PGUpgrade"+
+				" of this cluster has completed", before, after),
+		})
+	}
+
+	beforeRepos := clusterRepoNames(current)
+	afterRepos := clusterRepoNames(proposed)
+	seen := map[string]bool{}
+	for _, name := range afterRepos {
+		if !repoNamePattern.MatchString(name) {
+			violations = append(violations, specViolation{
+				Field:   "spec.backups.pgbackrest.repos",
+				Message: fmt.Sprintf("%q is not a valid repository name; use repo1, repo2, repo3, or repo4", name),
+			})
+		}
+		if seen[name] {
+			violations = append(violations, specViolation{
+				Field:   "spec.backups.pgbackrest.repos",
+				Message: fmt.Sprintf("repository %q is defined more than once", name),
+			})
+		}
+		seen[name] = true
+	}
+	if len(beforeRepos) > 0 && len(afterRepos) == 0 {
+		violations = append(violations, specViolation{
+			Field:   "spec.backups.pgbackrest.repos",
+			Message: "at least one repository is required; every backup would become unreachable",
+		})
+	} else {
+		for _, name := range beforeRepos {
+			if !seen[name] {
+				violations = append(violations, specViolation{
+					Field: "spec.backups.pgbackrest.repos", Warning: true,
+					Message: fmt.Sprintf("removing repository %q stops its backups and"+
+						" archiving; its data is not deleted", name),
+				})
+			}
+		}
+	}
+
+	manualRepo, _, _ := unstructured.NestedString(proposed.Object,
+		"spec", "backups", "pgbackrest", "manual", "repoName")
+	if manualRepo != "" && !seen[manualRepo] {
+		violations = append(violations, specViolation{
+			Field:   "spec.backups.pgbackrest.manual.repoName",
+			Message: fmt.Sprintf("repository %q is not defined in spec.backups.pgbackrest.repos", manualRepo),
+		})
+	}
+
+	restoring, _, _ := unstructured.NestedBool(proposed.Object,
+		"spec", "backups", "pgbackrest", "restore", "enabled")
+	restoreRepo, _, _ := unstructured.NestedString(proposed.Object,
+		"spec", "backups", "pgbackrest", "restore", "repoName")
+	if restoring && restoreRepo != "" && !seen[restoreRepo] {
+		violations = append(violations, specViolation{
+			Field:   "spec.backups.pgbackrest.restore.repoName",
+			Message: fmt.Sprintf("repository %q is not defined in spec.backups.pgbackrest.repos", restoreRepo),
+		})
+	}
+
+	// PGO only restores and backs up clusters that are running.
+	shutdown, _, _ := unstructured.NestedBool(proposed.Object, "spec", "shutdown")
+	if shutdown && restoring && annotationChanged(current, proposed,
+		"postgres-operator.crunchydata.com/pgbackrest-restore") {
+		violations = append(violations, specViolation{
+			Field:   "spec.shutdown",
+			Message: "a restore cannot start while the cluster is shut down; start the cluster first",
+		})
+	}
+	if shutdown && annotationChanged(current, proposed,
+		"postgres-operator.crunchydata.com/pgbackrest-backup") {
+		violations = append(violations, specViolation{
+			Field:   "spec.shutdown",
+			Message: "a backup cannot start while the cluster is shut down; start the cluster first",
+		})
+	}
+	wasShutdown, _, _ := unstructured.NestedBool(current.Object, "spec", "shutdown")
+	if shutdown && !wasShutdown && restoring && !annotationChanged(current, proposed,
+		"postgres-operator.crunchydata.com/pgbackrest-restore") {
+		violations = append(violations, specViolation{
+			Field: "spec.shutdown", Warning: true,
+			Message: "a restore is still enabled; it starts again when the cluster starts" +
+				` unless "pgo restore disable" is run`,
+		})
+	}
+
+	// Instance sets need distinct names, and their volumes can grow but
+	// never shrink.
+	beforeSets := instanceSets(current)
+	names := map[string]bool{}
+	items, _, _ := unstructured.NestedSlice(proposed.Object, "spec", "instances")
+	for i, item := range items {
+		set, _ := item.(map[string]interface{})
+		name, _, _ := unstructured.NestedString(set, "name")
+		if name == "" {
+			name = fmt.Sprintf("%02d", i)
+		}
+		if names[name] {
+			violations = append(violations, specViolation{
+				Field:   "spec.instances",
+				Message: fmt.Sprintf("instance set %q is defined more than once", name),
+			})
+		}
+		names[name] = true
+
+		for _, volume := range []string{"dataVolumeClaimSpec", "walVolumeClaimSpec"} {
+			was, ok1 := storageRequest(beforeSets[name], volume)
+			is, ok2 := storageRequest(set, volume)
+			if ok1 && ok2 && is.Cmp(was) < 0 {
+				violations = append(violations, specViolation{
+					Field: fmt.Sprintf("spec.instances[%s].%s", name, volume),
+					Message: fmt.Sprintf("volumes cannot shrink from %s to %s;"+
+						" add a new instance set with smaller volumes instead", was.String(), is.String()),
+				})
+			}
+		}
+	}
+
+	return violations
+}
+
+// postgresVersion returns the major version of PostgreSQL in the spec of cluster.
+func postgresVersion(cluster *unstructured.Unstructured) int64 {
+	value, _, _ := unstructured.NestedFieldNoCopy(cluster.Object, "spec", "postgresVersion")
+	switch value := value.(type) {
+	case int64:
+		return value
+	case float64:
+		// Numbers decoded from YAML and some JSON decoders are floats.
+		return int64(value)
+	}
+	return 0
+}
+
+// clusterRepoNames returns the names of the pgBackRest repositories of cluster.
+func clusterRepoNames(cluster *unstructured.Unstructured) []string {
+	repos, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "backups", "pgbackrest", "repos")
+	names := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		if repo, ok := repo.(map[string]interface{}); ok {
+			name, _ := repo["name"].(string)
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// instanceSets returns the instance sets of cluster by name.
+func instanceSets(cluster *unstructured.Unstructured) map[string]map[string]interface{} {
+	items, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "instances")
+	sets := make(map[string]map[string]interface{}, len(items))
+	for i, item := range items {
+		if set, ok := item.(map[string]interface{}); ok {
+			name, _ := set["name"].(string)
+			if name == "" {
+				name = fmt.Sprintf("%02d", i)
+			}
+			sets[name] = set
+		}
+	}
+	return sets
+}
+
+// storageRequest returns the storage requested by volume of an instance set.
+func storageRequest(set map[string]interface{}, volume string) (resource.Quantity, bool) {
+	value, found, _ := unstructured.NestedString(set, volume, "resources", "requests", "storage")
+	if !found {
+		return resource.Quantity{}, false
+	}
+	quantity, err := resource.ParseQuantity(value)
+	return quantity, err == nil
+}
+
+// annotationChanged returns whether the annotation key differs between
+// current and proposed.
+func annotationChanged(current, proposed *unstructured.Unstructured, key string) bool {
+	return current.GetAnnotations()[key] != proposed.GetAnnotations()[key]
+}
@@ -0,0 +1,253 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crunchydata/postgres-operator-client/internal"
+)
+
+func unstructuredFromYAML(t *testing.T, text string) *unstructured.Unstructured {
+	t.Helper()
+	object := new(unstructured.Unstructured)
+	assert.NilError(t, yaml.Unmarshal([]byte(strings.TrimSpace(text)), &object.Object))
+	return object
+}
+
+func TestMergeIntent(t *testing.T) {
+	current := unstructuredFromYAML(t, `
+metadata:
+  name: hippo
+spec:
+  postgresVersion: 16
+  instances:
+  - name: one
+    replicas: 2
+  - name: two
+  backups:
+    pgbackrest:
+      repos:
+      - name: repo1
+`)
+	intent := unstructuredFromYAML(t, `
+spec:
+  instances:
+  - name: two
+    replicas: 3
+  backups:
+    pgbackrest:
+      manual:
+        repoName: repo1
+`)
+
+	merged := mergeIntent(current, intent)
+	b, err := yaml.Marshal(merged.Object)
+	assert.NilError(t, err)
+	assert.Equal(t, string(b), strings.TrimSpace(`
+metadata:
+  name: hippo
+spec:
+  backups:
+    pgbackrest:
+      manual:
+        repoName: repo1
+      repos:
+      - name: repo1
+  instances:
+  - name: one
+    replicas: 2
+  - name: two
+    replicas: 3
+  postgresVersion: 16
+`)+"\n")
+
+	// The current object is unchanged.
+	replicas, _, _ := unstructured.NestedSlice(current.Object, "spec", "instances")
+	assert.Equal(t, len(replicas[1].(map[string]interface{})), 1)
+}
+
+func TestValidateClusterChange(t *testing.T) {
+	current := `
+metadata:
+  name: hippo
+  annotations:
+    postgres-operator.crunchydata.com/pgbackrest-backup: "one"
+spec:
+  postgresVersion: 16
+  instances:
+  - name: one
+    dataVolumeClaimSpec:
+      resources:
+        requests:
+          storage: 10Gi
+  backups:
+    pgbackrest:
+      repos:
+      - name: repo1
+      - name: repo2
+`
+
+	for _, tt := range []struct {
+		Name, Proposed string
+		Violations     []specViolation
+	}{
+		{
+			Name:     "NoChange",
+			Proposed: current,
+		},
+		{
+			Name: "Downgrade",
+			Proposed: strings.Replace(current,
+				"postgresVersion: 16", "postgresVersion: 15", 1),
+			Violations: []specViolation{{
+				Field:   "spec.postgresVersion",
+				Message: "PostgreSQL cannot be downgraded from 16 to 15; restore a backup into a new cluster instead",
+			}},
+		},
+		{
+			Name: "Upgrade",
+			Proposed: strings.Replace(current,
+				"postgresVersion: 16", "postgresVersion: 17", 1),
+			Violations: []specViolation{{
+				Field: "spec.postgresVersion", Warning: true,
+				Message: "changing from 16 to 17 only works after a PGUpgrade of this cluster has completed",
+			}},
+		},
+		{
+			Name: "RemoveRepo",
+			Proposed: strings.Replace(current,
+				"      - name: repo2\n", "", 1),
+			Violations: []specViolation{{
+				Field: "spec.backups.pgbackrest.repos", Warning: true,
+				Message: `removing repository "repo2" stops its backups and archiving; its data is not deleted`,
+			}},
+		},
+		{
+			Name: "RemoveEveryRepo",
+			Proposed: strings.Replace(current,
+				"      repos:\n      - name: repo1\n      - name: repo2\n", "      repos: []\n", 1),
+			Violations: []specViolation{{
+				Field:   "spec.backups.pgbackrest.repos",
+				Message: "at least one repository is required; every backup would become unreachable",
+			}},
+		},
+		{
+			Name: "BadRepoName",
+			Proposed: strings.Replace(current,
+				"name: repo2", "name: repo5", 1),
+			Violations: []specViolation{
+				{
+					Field:   "spec.backups.pgbackrest.repos",
+					Message: `"repo5" is not a valid repository name; use repo1, repo2, repo3, or repo4`,
+				},
+				{
+					Field: "spec.backups.pgbackrest.repos", Warning: true,
+					Message: `removing repository "repo2" stops its backups and archiving; its data is not deleted`,
+				},
+			},
+		},
+		{
+			Name: "UnknownRestoreRepo",
+			Proposed: current + `
+      restore:
+        enabled: true
+        repoName: repo3
+`,
+			Violations: []specViolation{{
+				Field:   "spec.backups.pgbackrest.restore.repoName",
+				Message: `repository "repo3" is not defined in spec.backups.pgbackrest.repos`,
+			}},
+		},
+		{
+			Name: "BackupWhileShutdown",
+			Proposed: strings.Replace(current, `"one"`, `"two"`, 1) + `
+  shutdown: true
+`,
+			Violations: []specViolation{{
+				Field:   "spec.shutdown",
+				Message: "a backup cannot start while the cluster is shut down; start the cluster first",
+			}},
+		},
+		{
+			Name: "RestoreWhileShutdown",
+			Proposed: strings.Replace(current, "  annotations:\n", "  annotations:\n"+
+				"    postgres-operator.crunchydata.com/pgbackrest-restore: \"now\"\n", 1) + `
+      restore:
+        enabled: true
+        repoName: repo1
+  shutdown: true
+`,
+			Violations: []specViolation{{
+				Field:   "spec.shutdown",
+				Message: "a restore cannot start while the cluster is shut down; start the cluster first",
+			}},
+		},
+		{
+			Name: "ShrinkVolume",
+			Proposed: strings.Replace(current,
+				"storage: 10Gi", "storage: 5Gi", 1),
+			Violations: []specViolation{{
+				Field:   "spec.instances[one].dataVolumeClaimSpec",
+				Message: "volumes cannot shrink from 10Gi to 5Gi; add a new instance set with smaller volumes instead",
+			}},
+		},
+		{
+			Name: "GrowVolume",
+			Proposed: strings.Replace(current,
+				"storage: 10Gi", "storage: 20Gi", 1),
+		},
+	} {
+		t.Run(tt.Name, func(t *testing.T) {
+			violations := validateClusterChange(
+				unstructuredFromYAML(t, current), unstructuredFromYAML(t, tt.Proposed))
+			assert.DeepEqual(t, violations, tt.Violations)
+		})
+	}
+}
+
+func TestCheckClusterChange(t *testing.T) {
+	current := unstructuredFromYAML(t, `
+metadata:
+  name: hippo
+spec:
+  postgresVersion: 16
+  backups:
+    pgbackrest:
+      repos:
+      - name: repo1
+`)
+
+	t.Run("Error", func(t *testing.T) {
+		var out bytes.Buffer
+		err := checkClusterChange(&out, &internal.MessageConfig{}, current, unstructuredFromYAML(t, `
+spec:
+  postgresVersion: 17
+  backups:
+    pgbackrest:
+      manual:
+        repoName: repo2
+`))
+		assert.Equal(t, out.String(), "WARNING: spec.postgresVersion: changing from 16 to 17"+
+			" only works after a PGUpgrade of this cluster has completed\n")
+		assert.Error(t, err, "this change to postgrescluster hippo would be rejected or break the cluster:\n"+
+			`  spec.backups.pgbackrest.manual.repoName: repository "repo2" is not defined in spec.backups.pgbackrest.repos`)
+	})
+
+	t.Run("Allowed", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NilError(t, checkClusterChange(&out, &internal.MessageConfig{}, current, unstructuredFromYAML(t, `
+spec:
+  shutdown: true
+`)))
+		assert.Equal(t, out.String(), "")
+	})
+}
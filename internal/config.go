@@ -5,6 +5,10 @@
 package internal
 
 import (
+	"io"
+	"os"
+	"strconv"
+
 	"github.com/spf13/pflag"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
@@ -14,7 +18,13 @@ type Config struct {
 	*genericclioptions.ConfigFlags
 	genericclioptions.IOStreams
 
-	Patch PatchConfig
+	Messages MessageConfig
+	Patch    PatchConfig
+	Quiet    QuietConfig
+	ReadOnly ReadOnlyConfig
+	Record   RecordConfig
+
+	fixture *fixtureClient
 }
 
 func (cfg *Config) Namespace() (string, error) {
@@ -43,3 +53,65 @@ func (cfg *PatchConfig) PatchOptions(opts metav1.PatchOptions) metav1.PatchOptio
 	opts.FieldManager = cfg.FieldManager
 	return opts
 }
+
+// QuietConfig holds the --quiet flag. Commands print their data to stdout and
+// their warnings, suggestions, progress, and prompts to stderr. Quiet commands
+// print only their data and errors.
+type QuietConfig struct {
+	Flag bool
+}
+
+func (cfg *QuietConfig) AddFlags(flags *pflag.FlagSet) {
+	flags.BoolVarP(&cfg.Flag, "quiet", "q", cfg.Flag,
+		"Print only data, without warnings, progress, or prompts. Errors are still printed.")
+}
+
+// Writer returns a writer that writes to w unless cfg is on. It checks cfg at
+// each write, so it can be made before flags are parsed.
+func (cfg *QuietConfig) Writer(w io.Writer) io.Writer {
+	return quietWriter{cfg: cfg, w: w}
+}
+
+type quietWriter struct {
+	cfg *QuietConfig
+	w   io.Writer
+}
+
+func (q quietWriter) Write(p []byte) (int, error) {
+	if q.cfg.Flag {
+		return len(p), nil
+	}
+	return q.w.Write(p)
+}
+
+// ReadOnlyEnv names the environment variable that turns on read-only mode.
+// The --read-only flag cannot turn it off.
+const ReadOnlyEnv = "PGO_READ_ONLY"
+
+// ReadOnlyConfig holds the --read-only flag. In read-only mode, commands that
+// change objects in Kubernetes refuse to run.
+type ReadOnlyConfig struct {
+	Flag bool
+}
+
+func (cfg *ReadOnlyConfig) AddFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&cfg.Flag, "read-only", cfg.Flag,
+		"Refuse to run commands that change objects in Kubernetes. "+
+			"Always on when the PGO_READ_ONLY environment variable is set.")
+}
+
+// Enabled returns whether commands that change objects should refuse to run.
+// Any value of [ReadOnlyEnv] other than one like "false" turns it on.
+func (cfg *ReadOnlyConfig) Enabled() bool {
+	return cfg.Flag || ReadOnlyFromEnv()
+}
+
+// ReadOnlyFromEnv returns whether [ReadOnlyEnv] turns on read-only mode.
+func ReadOnlyFromEnv() bool {
+	value, ok := os.LookupEnv(ReadOnlyEnv)
+	if !ok || value == "" {
+		return false
+	}
+	enabled, err := strconv.ParseBool(value)
+	return err != nil || enabled
+}
@@ -0,0 +1,27 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestQuietConfig(t *testing.T) {
+	var out bytes.Buffer
+	var cfg QuietConfig
+	w := cfg.Writer(&out)
+
+	_, _ = fmt.Fprintln(w, "WARNING: one")
+	cfg.Flag = true
+	n, err := fmt.Fprintln(w, "WARNING: two")
+	assert.NilError(t, err)
+	assert.Equal(t, n, len("WARNING: two\n"))
+
+	assert.Equal(t, out.String(), "WARNING: one\n")
+}
@@ -0,0 +1,54 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package exec
+
+import "strings"
+
+// PGBackRestInfo runs "pgbackrest info" for one repository, or all of them
+// when repoNum is empty. The output is always JSON.
+func PGBackRestInfo(exec Executor, repoNum string) (string, string, error) {
+	command := "pgbackrest info --output=json"
+	if repoNum != "" {
+		command += " --repo=" + repoNum
+	}
+	return Bash(exec, command)
+}
+
+// PGBackRestCheck runs "pgbackrest check". The console log level is forced to
+// detail in case it is set elsewhere.
+func PGBackRestCheck(exec Executor) (string, string, error) {
+	return Bash(exec, "pgbackrest check --log-level-console=detail")
+}
+
+// PGBackRestProcesses returns the PID, elapsed seconds, and arguments of every
+// running pgBackRest process, one per line.
+func PGBackRestProcesses(exec Executor) (string, string, error) {
+	// The bracket keeps grep from matching itself. Exit status 1 means grep
+	// found nothing, which is not an error here.
+	return Bash(exec, "ps -eo pid=,etimes=,args= --width 500 | { grep '[p]gbackrest' || true; }")
+}
+
+// Patronictl runs a patronictl subcommand. The output is in format unless
+// that is empty.
+func Patronictl(exec Executor, subcommand, format string) (string, string, error) {
+	command := "patronictl " + subcommand
+	if format != "" {
+		command += " --format " + format
+	}
+	return Bash(exec, command)
+}
+
+// PSQL runs sql in database using the local socket of the database container.
+// Rows are printed one per line with tab-separated fields and no headers. An
+// empty database connects to the default database of the postgres user.
+func PSQL(exec Executor, database, sql string) (string, string, error) {
+	// Send the statements on stdin so they need no quoting.
+	command := []string{"psql", "--no-psqlrc", "--quiet", "--no-align",
+		"--tuples-only", "--field-separator=\t", "--set=ON_ERROR_STOP=1", "--file=-"}
+	if database != "" {
+		command = append(command, "--dbname="+database)
+	}
+	return Output(exec, strings.NewReader(sql), command...)
+}
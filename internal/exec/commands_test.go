@@ -0,0 +1,118 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package exec
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestPGBackRestInfo(t *testing.T) {
+
+	t.Run("default", func(t *testing.T) {
+		expected := errors.New("pass-through")
+		exec := func(
+			stdin io.Reader, stdout, stderr io.Writer, command ...string,
+		) error {
+			assert.DeepEqual(t, command, []string{"bash", "-ceu", "--", "pgbackrest info --output=json"})
+			assert.Assert(t, stdout != nil, "should capture stdout")
+			assert.Assert(t, stderr != nil, "should capture stderr")
+			return expected
+		}
+		_, _, err := PGBackRestInfo(Func(exec), "")
+		assert.ErrorContains(t, err, "pass-through")
+
+	})
+
+	t.Run("repo 2", func(t *testing.T) {
+		expected := errors.New("pass-through")
+		exec := func(
+			stdin io.Reader, stdout, stderr io.Writer, command ...string,
+		) error {
+			assert.DeepEqual(t, command, []string{"bash", "-ceu", "--", "pgbackrest info --output=json --repo=2"})
+			assert.Assert(t, stdout != nil, "should capture stdout")
+			assert.Assert(t, stderr != nil, "should capture stderr")
+			return expected
+		}
+		_, _, err := PGBackRestInfo(Func(exec), "2")
+		assert.ErrorContains(t, err, "pass-through")
+
+	})
+}
+
+func TestPatronictl(t *testing.T) {
+
+	t.Run("default", func(t *testing.T) {
+		expected := errors.New("pass-through")
+		exec := func(
+			stdin io.Reader, stdout, stderr io.Writer, command ...string,
+		) error {
+			assert.DeepEqual(t, command, []string{"bash", "-ceu", "--", "patronictl sub-command"})
+			assert.Assert(t, stdout != nil, "should capture stdout")
+			assert.Assert(t, stderr != nil, "should capture stderr")
+			return expected
+		}
+		_, _, err := Patronictl(Func(exec), "sub-command", "")
+		assert.ErrorContains(t, err, "pass-through")
+
+	})
+
+}
+
+func TestPGBackRestProcesses(t *testing.T) {
+
+	t.Run("default", func(t *testing.T) {
+		expected := errors.New("pass-through")
+		exec := func(
+			stdin io.Reader, stdout, stderr io.Writer, command ...string,
+		) error {
+			assert.DeepEqual(t, command, []string{"bash", "-ceu", "--",
+				"ps -eo pid=,etimes=,args= --width 500 | { grep '[p]gbackrest' || true; }"})
+			assert.Assert(t, stdout != nil, "should capture stdout")
+			assert.Assert(t, stderr != nil, "should capture stderr")
+			return expected
+		}
+		_, _, err := PGBackRestProcesses(Func(exec))
+		assert.ErrorContains(t, err, "pass-through")
+
+	})
+
+}
+
+func TestPSQL(t *testing.T) {
+
+	t.Run("default", func(t *testing.T) {
+		expected := errors.New("pass-through")
+		exec := func(
+			stdin io.Reader, stdout, stderr io.Writer, command ...string,
+		) error {
+			assert.DeepEqual(t, command, []string{"psql", "--no-psqlrc", "--quiet",
+				"--no-align", "--tuples-only", "--field-separator=\t",
+				"--set=ON_ERROR_STOP=1", "--file=-"})
+			b, _ := io.ReadAll(stdin)
+			assert.Equal(t, string(b), "SELECT 1")
+			assert.Assert(t, stdout != nil, "should capture stdout")
+			assert.Assert(t, stderr != nil, "should capture stderr")
+			return expected
+		}
+		_, _, err := PSQL(Func(exec), "", "SELECT 1")
+		assert.ErrorContains(t, err, "pass-through")
+	})
+
+	t.Run("database", func(t *testing.T) {
+		exec := func(
+			stdin io.Reader, stdout, stderr io.Writer, command ...string,
+		) error {
+			assert.Equal(t, command[len(command)-1], "--dbname=app")
+			return nil
+		}
+		_, _, err := PSQL(Func(exec), "app", "SELECT 1")
+		assert.NilError(t, err)
+	})
+
+}
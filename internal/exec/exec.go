@@ -0,0 +1,54 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package exec runs commands in the containers of a PostgresCluster. Commands
+// go through an [Executor] so they can be faked or recorded in tests.
+package exec
+
+import (
+	"bytes"
+	"io"
+)
+
+// Executor runs command in a container. Non-nil streams (stdin, stdout, and
+// stderr) are attached to the remote process.
+type Executor interface {
+	Exec(stdin io.Reader, stdout, stderr io.Writer, command ...string) error
+}
+
+// Func is a function that implements [Executor].
+type Func func(stdin io.Reader, stdout, stderr io.Writer, command ...string) error
+
+// Exec calls fn.
+func (fn Func) Exec(stdin io.Reader, stdout, stderr io.Writer, command ...string) error {
+	return fn(stdin, stdout, stderr, command...)
+}
+
+// PodExecFunc runs command in container of pod in namespace, like the function
+// returned by util.NewPodExecutor.
+type PodExecFunc = func(
+	namespace, pod, container string,
+	stdin io.Reader, stdout, stderr io.Writer, command ...string,
+) error
+
+// Container returns an [Executor] that runs commands in container of pod in
+// namespace using podExec.
+func Container(podExec PodExecFunc, namespace, pod, container string) Executor {
+	return Func(func(stdin io.Reader, stdout, stderr io.Writer, command ...string) error {
+		return podExec(namespace, pod, container, stdin, stdout, stderr, command...)
+	})
+}
+
+// Output runs command with stdin and returns what it printed to stdout and
+// stderr.
+func Output(exec Executor, stdin io.Reader, command ...string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	err := exec.Exec(stdin, &stdout, &stderr, command...)
+	return stdout.String(), stderr.String(), err
+}
+
+// Bash runs a one-line bash script with errexit and nounset.
+func Bash(exec Executor, script string) (string, string, error) {
+	return Output(exec, nil, "bash", "-ceu", "--", script)
+}
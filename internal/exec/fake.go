@@ -0,0 +1,175 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package exec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Call is one command given to an [Executor] and what it printed.
+type Call struct {
+	Command []string `json:"command"`
+	Stdin   string   `json:"stdin,omitempty"`
+	Stdout  string   `json:"stdout,omitempty"`
+	Stderr  string   `json:"stderr,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// text is what a [Reply] is matched against: the command joined by spaces,
+// then a newline, then its stdin.
+func (call Call) text() string {
+	return strings.Join(call.Command, " ") + "\n" + call.Stdin
+}
+
+// Reply is the canned output of a [Fake] for the commands it matches.
+type Reply struct {
+	// Match is found in the command, joined by spaces, or in its stdin. An
+	// empty Match matches every command.
+	Match string
+
+	Stdout, Stderr string
+	Err            error
+
+	// Once replies answer only the first command they match.
+	Once bool
+}
+
+// Fake is an [Executor] that answers commands with canned output rather than
+// running them. It keeps every command it is given in Calls.
+type Fake struct {
+	// Replies are tried in order; the first one that matches answers the
+	// command. Commands that match none fail.
+	Replies []Reply
+
+	Calls []Call
+
+	mu    sync.Mutex
+	spent map[int]bool
+}
+
+// Replay returns a [Fake] that answers calls as they were answered. Calls of
+// the same command are answered in order, and then the last of them again.
+func Replay(calls []Call) *Fake {
+	last := map[string]int{}
+	for i, call := range calls {
+		last[call.text()] = i
+	}
+
+	fake := &Fake{}
+	for i, call := range calls {
+		reply := Reply{Match: call.text(), Stdout: call.Stdout, Stderr: call.Stderr, Once: last[call.text()] != i}
+		if call.Error != "" {
+			reply.Err = errors.New(call.Error)
+		}
+		fake.Replies = append(fake.Replies, reply)
+	}
+	return fake
+}
+
+// Exec answers command with the first of Replies that matches it.
+func (fake *Fake) Exec(stdin io.Reader, stdout, stderr io.Writer, command ...string) error {
+	call := Call{Command: command}
+	if stdin != nil {
+		b, err := io.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+		call.Stdin = string(b)
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	reply := Reply{Err: fmt.Errorf("unexpected command %q", command)}
+	for i, candidate := range fake.Replies {
+		if !fake.spent[i] && strings.Contains(call.text(), candidate.Match) {
+			if candidate.Once {
+				if fake.spent == nil {
+					fake.spent = map[int]bool{}
+				}
+				fake.spent[i] = true
+			}
+			reply = candidate
+			break
+		}
+	}
+
+	call.Stdout, call.Stderr = reply.Stdout, reply.Stderr
+	if reply.Err != nil {
+		call.Error = reply.Err.Error()
+	}
+	fake.Calls = append(fake.Calls, call)
+
+	if stdout != nil {
+		if _, err := io.WriteString(stdout, reply.Stdout); err != nil {
+			return err
+		}
+	}
+	if stderr != nil {
+		if _, err := io.WriteString(stderr, reply.Stderr); err != nil {
+			return err
+		}
+	}
+	return reply.Err
+}
+
+// Recorder is an [Executor] that runs commands with another and keeps them,
+// with what they printed, in Calls. It reads all of stdin before it starts a
+// command, so it is not suited to large uploads.
+type Recorder struct {
+	Executor Executor
+
+	Calls []Call
+
+	mu sync.Mutex
+}
+
+// Exec runs command with recorder.Executor and records it.
+func (recorder *Recorder) Exec(stdin io.Reader, stdout, stderr io.Writer, command ...string) error {
+	call := Call{Command: command}
+	if stdin != nil {
+		b, err := io.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+		call.Stdin = string(b)
+		stdin = strings.NewReader(call.Stdin)
+	}
+
+	var outBuffer, errBuffer bytes.Buffer
+	if stdout != nil {
+		stdout = io.MultiWriter(stdout, &outBuffer)
+	}
+	if stderr != nil {
+		stderr = io.MultiWriter(stderr, &errBuffer)
+	}
+
+	err := recorder.Executor.Exec(stdin, stdout, stderr, command...)
+
+	call.Stdout, call.Stderr = outBuffer.String(), errBuffer.String()
+	if err != nil {
+		call.Error = err.Error()
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	recorder.Calls = append(recorder.Calls, call)
+
+	return err
+}
+
+// Fake returns a [Fake] that answers the recorded commands as they were
+// answered; see [Replay].
+func (recorder *Recorder) Fake() *Fake {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	return Replay(recorder.Calls)
+}
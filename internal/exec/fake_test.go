@@ -0,0 +1,107 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package exec
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestFake(t *testing.T) {
+	fake := &Fake{Replies: []Reply{
+		{Match: "pgbackrest info", Stdout: "[]"},
+		{Match: "SELECT 1", Stdout: "1\n"},
+		{Match: "patronictl", Stderr: "connection refused", Err: errors.New("exit status 1")},
+	}}
+
+	stdout, _, err := PGBackRestInfo(fake, "")
+	assert.NilError(t, err)
+	assert.Equal(t, stdout, "[]")
+
+	stdout, _, err = PSQL(fake, "app", "SELECT 1")
+	assert.NilError(t, err)
+	assert.Equal(t, stdout, "1\n")
+
+	_, stderr, err := Patronictl(fake, "list", "json")
+	assert.ErrorContains(t, err, "exit status 1")
+	assert.Equal(t, stderr, "connection refused")
+
+	_, _, err = Bash(fake, "date")
+	assert.ErrorContains(t, err, `unexpected command ["bash" "-ceu" "--" "date"]`)
+
+	assert.Equal(t, len(fake.Calls), 4)
+	assert.Equal(t, fake.Calls[1].Stdin, "SELECT 1")
+	assert.Equal(t, fake.Calls[2].Error, "exit status 1")
+	assert.DeepEqual(t, fake.Calls[3].Command, []string{"bash", "-ceu", "--", "date"})
+}
+
+func TestRecorder(t *testing.T) {
+	recorder := &Recorder{Executor: Func(func(
+		stdin io.Reader, stdout, stderr io.Writer, command ...string,
+	) error {
+		if stdin != nil {
+			b, _ := io.ReadAll(stdin)
+			_, _ = io.WriteString(stdout, strings.ToUpper(string(b)))
+			return nil
+		}
+		_, _ = io.WriteString(stderr, "no such file")
+		return errors.New("exit status 2")
+	})}
+
+	stdout, _, err := PSQL(recorder, "", "select 1")
+	assert.NilError(t, err)
+	assert.Equal(t, stdout, "SELECT 1", "output passes through")
+
+	_, stderr, err := Bash(recorder, "cat missing")
+	assert.ErrorContains(t, err, "exit status 2")
+	assert.Equal(t, stderr, "no such file", "output passes through")
+
+	assert.Equal(t, len(recorder.Calls), 2)
+	assert.Equal(t, recorder.Calls[0].Stdin, "select 1")
+	assert.Equal(t, recorder.Calls[0].Stdout, "SELECT 1")
+	assert.DeepEqual(t, recorder.Calls[1], Call{
+		Command: []string{"bash", "-ceu", "--", "cat missing"},
+		Stderr:  "no such file",
+		Error:   "exit status 2",
+	})
+
+	t.Run("Fake", func(t *testing.T) {
+		fake := recorder.Fake()
+
+		stdout, _, err := PSQL(fake, "", "select 1")
+		assert.NilError(t, err)
+		assert.Equal(t, stdout, "SELECT 1")
+
+		_, stderr, err := Bash(fake, "cat missing")
+		assert.ErrorContains(t, err, "exit status 2")
+		assert.Equal(t, stderr, "no such file")
+
+		_, _, err = PSQL(fake, "", "select 2")
+		assert.ErrorContains(t, err, "unexpected command")
+	})
+}
+
+func TestReplay(t *testing.T) {
+	fake := Replay([]Call{
+		{Command: []string{"patronictl", "list"}, Stdout: "starting"},
+		{Command: []string{"date"}, Stdout: "today"},
+		{Command: []string{"patronictl", "list"}, Stdout: "running"},
+	})
+
+	// Calls of the same command are answered in order, and then the last again.
+	for _, expected := range []string{"starting", "running", "running"} {
+		stdout, _, err := Output(fake, nil, "patronictl", "list")
+		assert.NilError(t, err)
+		assert.Equal(t, stdout, expected)
+	}
+
+	stdout, _, err := Output(fake, nil, "date")
+	assert.NilError(t, err)
+	assert.Equal(t, stdout, "today")
+}
@@ -0,0 +1,135 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// Environment variables that replace the Kubernetes cluster with fixtures.
+// Each names a directory; see [util.FakeCluster] for its files. When --context
+// is set and the directory has a subdirectory of that name, the subdirectory
+// is used instead so one directory can hold fixtures of many clusters.
+const (
+	// FakeClusterEnv answers every request and command from fixtures.
+	FakeClusterEnv = "PGO_FAKE_CLUSTER"
+
+	// RecordClusterEnv sends every request and command to the cluster and
+	// appends them to fixtures.
+	RecordClusterEnv = "PGO_RECORD_CLUSTER"
+)
+
+// fixtureClient holds the clients of a fake or recorded cluster so that every
+// command of a process shares them and the order of their fixtures.
+type fixtureClient struct {
+	once   sync.Once
+	err    error
+	config *rest.Config
+
+	discovery discovery.CachedDiscoveryInterface
+	mapper    meta.RESTMapper
+}
+
+// fixtureDir returns the directory named by the environment variable env,
+// considering --context.
+func (cfg *Config) fixtureDir(env string) string {
+	dir := os.Getenv(env)
+	if dir == "" || cfg.ConfigFlags == nil || cfg.ConfigFlags.Context == nil || *cfg.ConfigFlags.Context == "" {
+		return dir
+	}
+	context := filepath.Join(dir, *cfg.ConfigFlags.Context)
+	if info, err := os.Stat(context); env == RecordClusterEnv || (err == nil && info.IsDir()) {
+		return context
+	}
+	return dir
+}
+
+// fixtures returns the clients of a fake or recorded cluster, or nil when
+// neither environment variable is set.
+func (cfg *Config) fixtures() (*fixtureClient, error) {
+	if os.Getenv(FakeClusterEnv) == "" && os.Getenv(RecordClusterEnv) == "" {
+		return nil, nil
+	}
+	if cfg.fixture == nil {
+		cfg.fixture = &fixtureClient{}
+	}
+
+	client := cfg.fixture
+	client.once.Do(func() {
+		if dir := cfg.fixtureDir(FakeClusterEnv); dir != "" {
+			var fake *util.FakeCluster
+			if fake, client.err = util.LoadFakeCluster(dir); client.err == nil {
+				// A Transport cannot be combined with TLS options, so start
+				// from an empty config.
+				client.config = &rest.Config{Host: "http://pgo.fake", Transport: fake}
+			}
+		} else {
+			var config *rest.Config
+			if config, client.err = cfg.ConfigFlags.ToRESTConfig(); client.err == nil {
+				client.config, client.err = util.NewClusterRecorder(
+					cfg.fixtureDir(RecordClusterEnv), config)
+			}
+		}
+		if client.err != nil {
+			return
+		}
+
+		var dc *discovery.DiscoveryClient
+		if dc, client.err = discovery.NewDiscoveryClientForConfig(client.config); client.err == nil {
+			client.discovery = memory.NewMemCacheClient(dc)
+			client.mapper = restmapper.NewShortcutExpander(
+				restmapper.NewDeferredDiscoveryRESTMapper(client.discovery), client.discovery)
+		}
+	})
+	return client, client.err
+}
+
+// ToRESTConfig returns the config of the Kubernetes cluster, or of fixtures
+// when [FakeClusterEnv] or [RecordClusterEnv] is set.
+func (cfg *Config) ToRESTConfig() (*rest.Config, error) {
+	client, err := cfg.fixtures()
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return cfg.ConfigFlags.ToRESTConfig()
+	}
+	return rest.CopyConfig(client.config), nil
+}
+
+// ToDiscoveryClient is like [Config.ToRESTConfig] for discovery.
+func (cfg *Config) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
+	client, err := cfg.fixtures()
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return cfg.ConfigFlags.ToDiscoveryClient()
+	}
+	return client.discovery, nil
+}
+
+// ToRESTMapper is like [Config.ToRESTConfig] for the mapping of kinds to
+// resources.
+func (cfg *Config) ToRESTMapper() (meta.RESTMapper, error) {
+	client, err := cfg.fixtures()
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return cfg.ConfigFlags.ToRESTMapper()
+	}
+	return client.mapper, nil
+}
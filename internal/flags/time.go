@@ -0,0 +1,199 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package flags has command line flags that more than one command uses, so
+// that they read their values the same way.
+package flags
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	// Zone names like "Europe/Berlin" work on machines without a time zone
+	// database.
+	_ "time/tzdata"
+)
+
+// relativeTimePattern matches phrases like "45 minutes ago" and "2h ago".
+var relativeTimePattern = regexp.MustCompile(
+	`^(\d+(?:\.\d+)?)\s*(s|sec|second|m|min|minute|h|hour|d|day|w|week)s?\s+ago$`)
+
+// ErrUnreadableTime is returned by [ParseTime] for a value in none of its forms.
+var ErrUnreadableTime = errors.New("unable to read time")
+
+// units are the lengths of the units in [relativeTimePattern] and of the day
+// and week suffixes of [ParseDuration].
+var units = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "second": time.Second,
+	"m": time.Minute, "min": time.Minute, "minute": time.Minute,
+	"h": time.Hour, "hour": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour,
+}
+
+// ParseDuration reads a duration like [time.ParseDuration] does. It also
+// reads a number of days or weeks, such as "30d" or "2w".
+func ParseDuration(value string) (time.Duration, error) {
+	for _, suffix := range []string{"d", "w"} {
+		if number, ok := strings.CutSuffix(value, suffix); ok {
+			n, err := strconv.ParseFloat(number, 64)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			return time.Duration(n * float64(units[suffix])), nil
+		}
+	}
+	return time.ParseDuration(value)
+}
+
+// relativeTime returns how long ago value is when it is "now" or a phrase
+// like "45 minutes ago".
+func relativeTime(value string) (time.Duration, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "now" {
+		return 0, true
+	}
+	if match := relativeTimePattern.FindStringSubmatch(value); match != nil {
+		number, _ := strconv.ParseFloat(match[1], 64)
+		return time.Duration(number * float64(units[match[2]])), true
+	}
+	return 0, false
+}
+
+// ParseTime reads a point in time such as "2024-05-01 14:03 Europe/Berlin",
+// "2024-05-01T12:03:00Z", "2021-06-09 14:15:11-04", or "45 minutes ago". Times
+// without a zone are in local.
+func ParseTime(value string, now time.Time, local *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	if ago, ok := relativeTime(value); ok {
+		return now.Add(-ago), nil
+	}
+
+	// A zone name can follow the date and time.
+	location := local
+	if i := strings.LastIndexByte(value, ' '); i > 0 {
+		if name := value[i+1:]; name == "UTC" || strings.Contains(name, "/") {
+			zone, err := time.LoadLocation(name)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("unknown time zone %q", name)
+			}
+			location, value = zone, value[:i]
+		}
+	}
+
+	// Zone offsets are part of the layout.
+	for _, date := range []string{"2006-01-02 ", "2006-01-02T"} {
+		for _, clock := range []string{"15:04:05.999999999", "15:04"} {
+			for _, offset := range []string{"", "Z07:00", "-07", "-0700"} {
+				if parsed, err := time.ParseInLocation(date+clock+offset, value, location); err == nil {
+					return parsed, nil
+				}
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf(
+		`%w %q; use a duration like "6h" or "30d", a time like "2024-05-01 14:03 Europe/Berlin", or "45 minutes ago"`,
+		ErrUnreadableTime, value)
+}
+
+// Time implements [pflag.Value] for a point in time. A duration, such as "6h"
+// or "30d", is that long before the time the command uses it; so is a phrase
+// like "45 minutes ago". Anything else is read by [ParseTime].
+type Time struct {
+	value string
+
+	// ago is set when value is relative to now; at is set otherwise.
+	ago time.Duration
+	at  time.Time
+}
+
+var _ pflag.Value = (*Time)(nil)
+
+// NewTime returns a Time with value, which must be valid. Use it for defaults.
+func NewTime(value string) Time {
+	var t Time
+	if err := t.Set(value); err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func (t *Time) String() string { return t.value }
+func (t *Time) Type() string   { return "time" }
+
+func (t *Time) Set(value string) error {
+	if value == "" {
+		*t = Time{}
+		return nil
+	}
+
+	parsed := Time{value: value}
+	if ago, err := ParseDuration(value); err == nil {
+		if ago < 0 {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		parsed.ago = ago
+	} else if ago, ok := relativeTime(value); ok {
+		parsed.ago = ago
+	} else if parsed.at, err = ParseTime(value, time.Now(), time.Local); err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// IsZero returns true when t was not set.
+func (t *Time) IsZero() bool { return t.value == "" }
+
+// At returns the time that t is when now is now. It is zero when t is not set.
+func (t *Time) At(now time.Time) time.Time {
+	switch {
+	case t.IsZero():
+		return time.Time{}
+	case t.at.IsZero():
+		return now.Add(-t.ago)
+	}
+	return t.at
+}
+
+// Ago returns how long before now t is. It is zero when t is not set.
+func (t *Time) Ago(now time.Time) time.Duration {
+	if t.IsZero() {
+		return 0
+	}
+	return now.Sub(t.At(now))
+}
+
+// Window is a span of time given by "--since" and "--until" flags.
+type Window struct {
+	Since, Until Time
+}
+
+// AddFlags adds the "--since" and "--until" flags to flags. What describes
+// what they filter, e.g. "entries".
+func (w *Window) AddFlags(flags *pflag.FlagSet, what string) {
+	flags.Var(&w.Since, "since",
+		fmt.Sprintf(`only show %s from after this, e.g. "6h", "2024-05-01 14:03", or "2 days ago"`, what))
+	flags.Var(&w.Until, "until",
+		fmt.Sprintf(`only show %s from before this, in the same forms as --since`, what))
+}
+
+// Contains returns true when t is in w when now is now. An end that was not
+// set does not limit w.
+func (w *Window) Contains(t, now time.Time) bool {
+	if !w.Since.IsZero() && t.Before(w.Since.At(now)) {
+		return false
+	}
+	if !w.Until.IsZero() && t.After(w.Until.At(now)) {
+		return false
+	}
+	return true
+}
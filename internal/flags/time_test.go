@@ -0,0 +1,106 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package flags
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseDuration(t *testing.T) {
+	for _, tt := range []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"90m", 90 * time.Minute},
+		{"30d", 30 * 24 * time.Hour},
+		{"1.5d", 36 * time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+	} {
+		parsed, err := ParseDuration(tt.value)
+		assert.NilError(t, err, tt.value)
+		assert.Equal(t, parsed, tt.expected, tt.value)
+	}
+
+	for _, value := range []string{"d", "-1d", "soon"} {
+		_, err := ParseDuration(value)
+		assert.Assert(t, err != nil, value)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	parsed, err := ParseTime("now", now, time.UTC)
+	assert.NilError(t, err)
+	assert.Equal(t, parsed, now)
+
+	parsed, err = ParseTime("2 days ago", now, time.UTC)
+	assert.NilError(t, err)
+	assert.Equal(t, parsed, now.Add(-48*time.Hour))
+
+	parsed, err = ParseTime("2024-04-30T08:00:00Z", now, time.UTC)
+	assert.NilError(t, err)
+	assert.Equal(t, parsed, time.Date(2024, 4, 30, 8, 0, 0, 0, time.UTC))
+
+	_, err = ParseTime("yesterday", now, time.UTC)
+	assert.Assert(t, errors.Is(err, ErrUnreadableTime))
+	assert.ErrorContains(t, err, `"yesterday"`)
+}
+
+func TestTime(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Unset", func(t *testing.T) {
+		var unset Time
+		assert.Assert(t, unset.IsZero())
+		assert.Assert(t, unset.At(now).IsZero())
+		assert.Equal(t, unset.Ago(now), time.Duration(0))
+		assert.Equal(t, unset.String(), "")
+	})
+
+	t.Run("Relative", func(t *testing.T) {
+		for value, ago := range map[string]time.Duration{
+			"6h":             6 * time.Hour,
+			"7d":             7 * 24 * time.Hour,
+			"45 minutes ago": 45 * time.Minute,
+			"now":            0,
+		} {
+			parsed := NewTime(value)
+			assert.Equal(t, parsed.String(), value)
+			assert.Equal(t, parsed.At(now), now.Add(-ago), value)
+			assert.Equal(t, parsed.Ago(now), ago, value)
+		}
+	})
+
+	t.Run("Absolute", func(t *testing.T) {
+		var parsed Time
+		assert.NilError(t, parsed.Set("2024-04-30 12:00 UTC"))
+		assert.Equal(t, parsed.At(now), time.Date(2024, 4, 30, 12, 0, 0, 0, time.UTC))
+		assert.Equal(t, parsed.Ago(now), 24*time.Hour)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		var parsed Time
+		assert.ErrorContains(t, parsed.Set("-6h"), "invalid duration")
+		assert.ErrorContains(t, parsed.Set("last tuesday"), "unable to read time")
+		assert.Assert(t, parsed.IsZero())
+	})
+}
+
+func TestWindow(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	var all Window
+	assert.Assert(t, all.Contains(time.Time{}, now))
+
+	window := Window{Since: NewTime("2d"), Until: NewTime("1d")}
+	assert.Assert(t, !window.Contains(now.Add(-72*time.Hour), now))
+	assert.Assert(t, window.Contains(now.Add(-36*time.Hour), now))
+	assert.Assert(t, !window.Contains(now.Add(-time.Hour), now))
+}
@@ -0,0 +1,192 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/crunchydata/postgres-operator-client/internal/util"
+)
+
+// Messages are the user-facing messages of the CLI in English, by their ID.
+// IDs do not change when the English text does, so translations and scripts
+// can rely on them. Run "pgo generate messages" to start a translation.
+var Messages = map[string]string{
+	"apply.confirm":              "\nDo you want to apply these changes? (yes/no): ",
+	"confirm.continue":           "Are you sure you want to continue? (yes/no): ",
+	"confirm.continue-backups":   "Are you sure you want to continue without backups? (yes/no): ",
+	"confirm.do-continue":        "Do you want to continue? (yes/no): ",
+	"confirm.no":                 "n,no",
+	"confirm.retry":              "Please type yes or no and then press enter: ",
+	"confirm.yes":                "y,yes",
+	"copy.warn-truncate":         "WARNING: Every row of %s in %s will be deleted before the copy. ",
+	"create.warn-no-backups":     "WARNING: Running a production postgrescluster without backups is not recommended. \n",
+	"delete.warn":                "WARNING: Deleting a postgrescluster is destructive and data retention is dependent on PV configuration. \n",
+	"drill.warn-failover":        "WARNING: This drill interrupts connections to the primary. ",
+	"impact.warn":                "WARNING: This change to postgrescluster %s restarts, removes, or adds instances:\n",
+	"import.warn-tables":         "WARNING: Database %q already has %d tables; objects in the dump may conflict. ",
+	"migrate.warn-auth":          "WARNING: This changes the password verifiers of %d role(s) and requires SCRAM for every connection.\n",
+	"migrate.warn-namespace":     "WARNING: This deletes postgrescluster %s in namespace %s and the volumes it owns. ",
+	"prune.warn":                 "\nWARNING: This will delete %d objects. Deleted volumes cannot be recovered.\n",
+	"rebuild.warn":               "WARNING: Rebuilding a replica deletes its data. ",
+	"restore.warn":               "WARNING: You are about to restore from pgBackRest with %+v\nWARNING: This action is destructive and PostgreSQL will be unavailable while its data is restored.\n\n",
+	"restart.warn":               "WARNING: This restarts every instance of %d postgrescluster(s), %d at a time. ",
+	"restart.warn-pods":          "WARNING: This restarts %d instance(s) of postgrescluster %s, one at a time. ",
+	"restart.warn-set":           "WARNING: This restarts every instance of instance set %s of postgrescluster %s, one at a time. ",
+	"rotate.warn-cipher":         "WARNING: %s will be encrypted with a new passphrase and a full backup will be taken to it.\n",
+	"scope.hint-unwatched":       "Use --namespace=%s to create it in a watched namespace, or add %q to PGO_TARGET_NAMESPACES of the operator.\n",
+	"scope.warn-unwatched":       "WARNING: No operator watches namespace %q; a PostgresCluster there will never be reconciled.\n",
+	"scope.watches":              "  deployments/%s in namespace %q watches: %s\n",
+	"seed.warn-replace":          "WARNING: The pgbench tables already exist and will be replaced. ",
+	"show.settings.warn-restart": "WARNING: This restarts instances and switches over the primary. ",
+	"show.slots.warn-drop":       "WARNING: Dropping replication slot %s releases %s of WAL. Anything that consumes this slot will have to be set up again.\n",
+	"show.statements.warn-reset": "WARNING: Resetting clears the statistics of every statement. ",
+	"show.user.warn-sensitive":   "WARNING: This command will show sensitive password information.\n",
+	"stop.warn":                  "WARNING: Stopping a postgrescluster is not destructive but it will take your database offline until you restart it. \n",
+	"sync.warn-replace":          "WARNING: %s differ(s) from the source and will be replaced. ",
+	"validate.warn":              "WARNING: %s\n",
+}
+
+// MessageConfig holds the --locale flag. It chooses the catalog that
+// user-facing messages are read from. Catalogs are JSON files of message IDs
+// and translated text named for their locale, such as "pt_BR.json", in Dir.
+// Only prompts, their answers, and warnings are in the catalog. Tables,
+// reports, and errors stay in English so that scripts can read them.
+type MessageConfig struct {
+	Locale string
+	Dir    string
+
+	// ErrOut is where a catalog that cannot be read is reported, once.
+	ErrOut io.Writer
+
+	catalog map[string]string
+}
+
+func (cfg *MessageConfig) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&cfg.Locale, "locale", cfg.Locale,
+		"Language of messages, such as \"de\" or \"pt_BR\". "+
+			"Defaults to the PGO_LOCALE, LC_ALL, LC_MESSAGES, or LANG environment variable.")
+}
+
+// Sprintf formats the message id according to the catalog of the locale.
+// Messages missing from the catalog, or translated with a different number
+// of arguments, are formatted in English.
+func (cfg *MessageConfig) Sprintf(id string, args ...any) string {
+	if cfg.catalog == nil {
+		var err error
+		if cfg.catalog, err = cfg.Catalog(); err != nil && cfg.ErrOut != nil {
+			_, _ = fmt.Fprintf(cfg.ErrOut, "WARNING: Messages are in English: %v\n", err)
+		}
+		if cfg.catalog == nil {
+			cfg.catalog = map[string]string{}
+		}
+	}
+	format, ok := cfg.catalog[id]
+	if !ok || !UsableTranslation(Messages[id], format) {
+		format = Messages[id]
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Confirm reads a yes or no answer from reader like [util.Confirm]. Answers
+// of the locale, separated by commas in the catalog, count too.
+func (cfg *MessageConfig) Confirm(reader io.Reader, writer io.Writer) *bool {
+	return util.ConfirmIn(reader, writer,
+		strings.Split(cfg.Sprintf("confirm.yes"), ","),
+		strings.Split(cfg.Sprintf("confirm.no"), ","),
+		cfg.Sprintf("confirm.retry"))
+}
+
+// Catalog reads the translations of the locale, if there are any. A locale
+// with a region, such as "pt_BR", falls back to its language, "pt".
+func (cfg *MessageConfig) Catalog() (map[string]string, error) {
+	dir := cfg.Dir
+	if dir == "" {
+		dir = os.Getenv("PGO_MESSAGES_DIR")
+	}
+	if dir == "" {
+		if config, err := os.UserConfigDir(); err == nil {
+			dir = filepath.Join(config, "kubectl-pgo", "messages")
+		}
+	}
+
+	for _, name := range localeNames(cfg.resolveLocale()) {
+		data, err := os.ReadFile(filepath.Join(dir, name+".json"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		catalog := map[string]string{}
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Join(dir, name+".json"), err)
+		}
+		return catalog, nil
+	}
+	return nil, nil
+}
+
+// resolveLocale returns the locale of the flag or, when that is empty, of the
+// environment in the order that gettext reads it.
+func (cfg *MessageConfig) resolveLocale() string {
+	if cfg.Locale != "" {
+		return cfg.Locale
+	}
+	for _, name := range []string{"PGO_LOCALE", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// localeNames returns the catalog names to try for locale, most specific
+// first. The "C" and "POSIX" locales, and English, use the built-in messages.
+func localeNames(locale string) []string {
+	// Drop the codeset and modifier, as in "de_DE.UTF-8@euro".
+	locale, _, _ = strings.Cut(locale, ".")
+	locale, _, _ = strings.Cut(locale, "@")
+	locale = strings.ReplaceAll(locale, "-", "_")
+
+	language, _, _ := strings.Cut(locale, "_")
+	switch language {
+	case "", "C", "POSIX", "en":
+		return nil
+	}
+	if language == locale {
+		return []string{language}
+	}
+	return []string{locale, language}
+}
+
+// formatVerb matches the verbs of a format string but not escaped percents.
+var formatVerb = regexp.MustCompile(`%(%|[-+# 0]*(\[\d+\])?[\d.*]*[a-zA-Z])`)
+
+// UsableTranslation returns whether translated uses as many arguments as the
+// English format string does. Translations may reorder arguments with explicit
+// indexes, such as "%[2]s".
+func UsableTranslation(english, translated string) bool {
+	return countVerbs(english) == countVerbs(translated)
+}
+
+// countVerbs returns the number of arguments that format uses.
+func countVerbs(format string) int {
+	var n int
+	for _, verb := range formatVerb.FindAllString(format, -1) {
+		if verb != "%%" {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,73 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestLocaleNames(t *testing.T) {
+	assert.Assert(t, localeNames("") == nil)
+	assert.Assert(t, localeNames("C") == nil)
+	assert.Assert(t, localeNames("en_US.UTF-8") == nil)
+	assert.DeepEqual(t, localeNames("de"), []string{"de"})
+	assert.DeepEqual(t, localeNames("de_DE.UTF-8@euro"), []string{"de_DE", "de"})
+	assert.DeepEqual(t, localeNames("pt-BR"), []string{"pt_BR", "pt"})
+}
+
+func TestUsableTranslation(t *testing.T) {
+	assert.Assert(t, UsableTranslation("%s has %d", "%[2]d in %[1]s"))
+	assert.Assert(t, UsableTranslation("100%% done", "100 %% fertig"))
+	assert.Assert(t, !UsableTranslation("%s has %d", "%s hat"))
+	assert.Assert(t, !UsableTranslation("no args", "%v"))
+}
+
+func TestMessageConfig(t *testing.T) {
+	dir := t.TempDir()
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "de.json"), []byte(`{
+		"confirm.continue": "Möchten Sie fortfahren? (ja/nein): ",
+		"confirm.yes": "j,ja",
+		"confirm.no": "n,nein",
+		"prune.warn": "\nWARNUNG: Dies löscht Objekte.\n"
+	}`), 0o600))
+
+	t.Setenv("PGO_LOCALE", "")
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "de_AT.UTF-8")
+
+	config := MessageConfig{Dir: dir}
+	assert.Equal(t, config.Sprintf("confirm.continue"), "Möchten Sie fortfahren? (ja/nein): ")
+	for input, expected := range map[string]bool{"ja": true, "yes": true, "nein": false} {
+		confirmed := config.Confirm(strings.NewReader(input), io.Discard)
+		assert.Assert(t, confirmed != nil && *confirmed == expected, input)
+	}
+	assert.Equal(t, config.Sprintf("rebuild.warn"), Messages["rebuild.warn"], "not translated")
+	assert.Equal(t, config.Sprintf("prune.warn", 4),
+		"\nWARNING: This will delete 4 objects. Deleted volumes cannot be recovered.\n",
+		"missing an argument")
+
+	// The flag takes precedence over the environment.
+	config = MessageConfig{Dir: dir, Locale: "fr"}
+	assert.Equal(t, config.Sprintf("confirm.continue"), Messages["confirm.continue"])
+
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "es.json"), []byte(`{`), 0o600))
+	var stderr strings.Builder
+	config = MessageConfig{Dir: dir, Locale: "es", ErrOut: &stderr}
+	_, err := config.Catalog()
+	assert.ErrorContains(t, err, "es.json")
+	assert.Equal(t, config.Sprintf("confirm.continue"), Messages["confirm.continue"])
+	assert.Equal(t, config.Sprintf("confirm.yes"), Messages["confirm.yes"])
+
+	// The catalog that cannot be read is reported once.
+	assert.Equal(t, strings.Count(stderr.String(), "es.json"), 1, "%q", stderr.String())
+	assert.Assert(t, strings.HasPrefix(stderr.String(), "WARNING: Messages are in English: "))
+}
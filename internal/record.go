@@ -0,0 +1,154 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	kyaml "sigs.k8s.io/yaml"
+)
+
+// The operations that can be recorded and replayed.
+const (
+	RecordApply  = "apply"
+	RecordCreate = "create"
+	RecordDelete = "delete"
+)
+
+// RecordConfig holds the --record flag of mutating commands. When File is set,
+// those commands append their intended change to File rather than sending it
+// to the Kubernetes API.
+type RecordConfig struct {
+	File string
+}
+
+func (cfg *RecordConfig) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&cfg.File, "record", cfg.File,
+		"Append the intended change to this file instead of applying it. "+
+			"Replay it later with \"pgo apply --from-record\".")
+}
+
+// Enabled returns whether changes should be recorded rather than applied.
+func (cfg *RecordConfig) Enabled() bool { return cfg.File != "" }
+
+// RecordedChange is one request to the Kubernetes API captured by --record.
+type RecordedChange struct {
+	Operation    string `json:"operation"`
+	Group        string `json:"group"`
+	Version      string `json:"version"`
+	Resource     string `json:"resource"`
+	Namespace    string `json:"namespace"`
+	Name         string `json:"name"`
+	FieldManager string `json:"fieldManager,omitempty"`
+	Force        bool   `json:"force,omitempty"`
+
+	// Object is the server-side apply patch or the manifest to create. It is
+	// empty for deletes.
+	Object map[string]interface{} `json:"object,omitempty"`
+}
+
+// NewRecordedChange returns a change for object in namespace of resource gvr.
+func NewRecordedChange(operation string, gvr schema.GroupVersionResource,
+	namespace, name string, object *unstructured.Unstructured,
+) RecordedChange {
+	change := RecordedChange{
+		Operation: operation,
+		Group:     gvr.Group,
+		Version:   gvr.Version,
+		Resource:  gvr.Resource,
+		Namespace: namespace,
+		Name:      name,
+	}
+	if object != nil {
+		change.Object = object.Object
+	}
+	return change
+}
+
+// GroupVersionResource returns the resource this change is for.
+func (change RecordedChange) GroupVersionResource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group: change.Group, Version: change.Version, Resource: change.Resource,
+	}
+}
+
+// String describes change the same way commands describe their results.
+func (change RecordedChange) String() string {
+	return fmt.Sprintf("%s %s/%s in namespace %s",
+		change.Operation, change.Resource, change.Name, change.Namespace)
+}
+
+// AppendRecord adds change as a new YAML document at the end of the file at
+// path, creating the file when it does not exist.
+func AppendRecord(path string, change RecordedChange) error {
+	b, err := kyaml.Marshal(change)
+	if err != nil {
+		return err
+	}
+
+	// #nosec G304 -- We intentionally write to the file supplied by the user.
+	file, err := os.OpenFile(filepath.Clean(path),
+		os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(append([]byte("---\n"), b...))
+
+	return errors.Join(err, file.Close())
+}
+
+// ReadRecords parses every change in a file written by [AppendRecord].
+func ReadRecords(r io.Reader) ([]RecordedChange, error) {
+	var changes []RecordedChange
+
+	decoder := yaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		var change RecordedChange
+		err := decoder.Decode(&change)
+		if errors.Is(err, io.EOF) {
+			return changes, nil
+		}
+		if err != nil {
+			return changes, err
+		}
+
+		// Skip empty documents.
+		if change.Operation == "" && change.Name == "" {
+			continue
+		}
+
+		switch change.Operation {
+		case RecordApply, RecordCreate, RecordDelete:
+		default:
+			return changes, fmt.Errorf("unknown operation %q for %s/%s",
+				change.Operation, change.Resource, change.Name)
+		}
+		if change.Resource == "" || change.Name == "" || change.Namespace == "" {
+			return changes, fmt.Errorf("incomplete change: %s", change)
+		}
+
+		changes = append(changes, change)
+	}
+}
+
+// ReadRecordsFile parses every change in the file at path.
+func ReadRecordsFile(path string) ([]RecordedChange, error) {
+	// #nosec G304 -- We intentionally read the file supplied by the user.
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	return ReadRecords(bytes.NewReader(b))
+}
@@ -0,0 +1,71 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestRecords(t *testing.T) {
+	gvr := schema.GroupVersionResource{
+		Group: "postgres-operator.crunchydata.com", Version: "v1beta1", Resource: "postgresclusters",
+	}
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "changes.yaml")
+
+		intent := &unstructured.Unstructured{Object: map[string]interface{}{
+			"spec": map[string]interface{}{"shutdown": true},
+		}}
+		apply := NewRecordedChange(RecordApply, gvr, "ns1", "hippo", intent)
+		apply.Force = true
+		assert.NilError(t, AppendRecord(path, apply))
+		assert.NilError(t, AppendRecord(path,
+			NewRecordedChange(RecordDelete, gvr, "ns2", "rhino", nil)))
+
+		changes, err := ReadRecordsFile(path)
+		assert.NilError(t, err)
+		assert.Equal(t, len(changes), 2)
+
+		assert.Equal(t, changes[0].Operation, RecordApply)
+		assert.Equal(t, changes[0].GroupVersionResource(), gvr)
+		assert.Equal(t, changes[0].Force, true)
+		assert.DeepEqual(t, changes[0].Object, intent.Object)
+
+		assert.Equal(t, changes[1].String(), "delete postgresclusters/rhino in namespace ns2")
+		assert.Assert(t, changes[1].Object == nil)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		changes, err := ReadRecords(strings.NewReader("---\n---\n"))
+		assert.NilError(t, err)
+		assert.Equal(t, len(changes), 0)
+	})
+
+	t.Run("UnknownOperation", func(t *testing.T) {
+		_, err := ReadRecords(strings.NewReader(`
+operation: explode
+resource: postgresclusters
+namespace: ns
+name: hippo
+`))
+		assert.ErrorContains(t, err, `unknown operation "explode"`)
+	})
+
+	t.Run("Incomplete", func(t *testing.T) {
+		_, err := ReadRecords(strings.NewReader(`
+operation: delete
+resource: postgresclusters
+name: hippo
+`))
+		assert.ErrorContains(t, err, "incomplete change")
+	})
+}
@@ -80,3 +80,213 @@ func (e *pgbackrestFormat) Set(v string) error {
 func (e *pgbackrestFormat) Type() string {
 	return "string"
 }
+
+// 'generate alerts' output format options
+// - https://prometheus.io/docs/prometheus/latest/configuration/alerting_rules/
+// - https://prometheus-operator.dev/docs/api-reference/api/#monitoring.coreos.com/v1.PrometheusRule
+type alertFormat string
+
+const (
+	PrometheusRuleAlerts alertFormat = "prometheusrule"
+	YAMLAlerts           alertFormat = "yaml"
+)
+
+// String is used both by fmt.Print and by Cobra in help text
+func (e *alertFormat) String() string {
+	return string(*e)
+}
+
+// Set must have pointer receiver so it doesn't change the value of a copy
+func (e *alertFormat) Set(v string) error {
+	switch v {
+	case "prometheusrule", "yaml":
+		*e = alertFormat(v)
+		return nil
+	default:
+		return errors.New(`must be one of "prometheusrule", "yaml"`)
+	}
+}
+
+// Type is only used in help text
+func (e *alertFormat) Type() string {
+	return "string"
+}
+
+// 'api export' output format options
+// - https://spec.openapis.org/oas/v3.0.3
+// - https://json-schema.org/draft/2020-12/schema
+type apiFormat string
+
+const (
+	OpenAPIFormat    apiFormat = "openapi"
+	JSONSchemaFormat apiFormat = "jsonschema"
+)
+
+// String is used both by fmt.Print and by Cobra in help text
+func (e *apiFormat) String() string {
+	return string(*e)
+}
+
+// Set must have pointer receiver so it doesn't change the value of a copy
+func (e *apiFormat) Set(v string) error {
+	switch v {
+	case "openapi", "jsonschema":
+		*e = apiFormat(v)
+		return nil
+	default:
+		return errors.New(`must be one of "openapi", "jsonschema"`)
+	}
+}
+
+// Type is only used in help text
+func (e *apiFormat) Type() string {
+	return "string"
+}
+
+// 'explain-query' output format options
+// - https://www.postgresql.org/docs/current/sql-explain.html
+type explainFormat string
+
+const (
+	TextExplain explainFormat = "text"
+	JSONExplain explainFormat = "json"
+)
+
+// String is used both by fmt.Print and by Cobra in help text
+func (e *explainFormat) String() string {
+	return string(*e)
+}
+
+// Set must have pointer receiver so it doesn't change the value of a copy
+func (e *explainFormat) Set(v string) error {
+	switch v {
+	case "text", "json":
+		*e = explainFormat(v)
+		return nil
+	default:
+		return errors.New(`must be one of "text", "json"`)
+	}
+}
+
+// Type is only used in help text
+func (e *explainFormat) Type() string {
+	return "string"
+}
+
+// 'support export' collection options
+type exportCollection string
+
+const (
+	CollectAll            exportCollection = "all"
+	CollectKubernetesOnly exportCollection = "k8s-only"
+)
+
+// String is used both by fmt.Print and by Cobra in help text
+func (e *exportCollection) String() string {
+	return string(*e)
+}
+
+// Set must have pointer receiver so it doesn't change the value of a copy
+func (e *exportCollection) Set(v string) error {
+	switch v {
+	case "all", "k8s-only":
+		*e = exportCollection(v)
+		return nil
+	default:
+		return errors.New(`must be one of "all", "k8s-only"`)
+	}
+}
+
+// Type is only used in help text
+func (e *exportCollection) Type() string {
+	return "string"
+}
+
+// 'check ready-for-release' and 'check corruption' output format options
+type readinessFormat string
+
+const (
+	TextReadiness readinessFormat = "text"
+	JSONReadiness readinessFormat = "json"
+	PromReadiness readinessFormat = "prom"
+)
+
+// String is used both by fmt.Print and by Cobra in help text
+func (e *readinessFormat) String() string {
+	return string(*e)
+}
+
+// Set must have pointer receiver so it doesn't change the value of a copy
+func (e *readinessFormat) Set(v string) error {
+	switch v {
+	case "text", "json", "prom":
+		*e = readinessFormat(v)
+		return nil
+	default:
+		return errors.New(`must be one of "text", "json", "prom"`)
+	}
+}
+
+// Type is only used in help text
+func (e *readinessFormat) Type() string {
+	return "string"
+}
+
+// 'check images' and 'check tls' output format options
+type checkFormat string
+
+const (
+	TextCheck checkFormat = "text"
+	PromCheck checkFormat = "prom"
+)
+
+// String is used both by fmt.Print and by Cobra in help text
+func (e *checkFormat) String() string {
+	return string(*e)
+}
+
+// Set must have pointer receiver so it doesn't change the value of a copy
+func (e *checkFormat) Set(v string) error {
+	switch v {
+	case "text", "prom":
+		*e = checkFormat(v)
+		return nil
+	default:
+		return errors.New(`must be one of "text", "prom"`)
+	}
+}
+
+// Type is only used in help text
+func (e *checkFormat) Type() string {
+	return "string"
+}
+
+// 'conform' output format options
+type conformFormat string
+
+const (
+	TextConform  conformFormat = "text"
+	JSONConform  conformFormat = "json"
+	SARIFConform conformFormat = "sarif"
+)
+
+// String is used both by fmt.Print and by Cobra in help text
+func (e *conformFormat) String() string {
+	return string(*e)
+}
+
+// Set must have pointer receiver so it doesn't change the value of a copy
+func (e *conformFormat) Set(v string) error {
+	switch v {
+	case "text", "json", "sarif":
+		*e = conformFormat(v)
+		return nil
+	default:
+		return errors.New(`must be one of "text", "json", "sarif"`)
+	}
+}
+
+// Type is only used in help text
+func (e *conformFormat) Type() string {
+	return "string"
+}
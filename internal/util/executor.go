@@ -5,13 +5,20 @@
 package util
 
 import (
+	"errors"
+	"fmt"
 	"io"
+	"net"
+	"net/http"
+	"strconv"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes/scheme"
 	clientv1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
 	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/transport/spdy"
 )
 
 // podExecutor runs command on container in pod in namespace. Non-nil streams
@@ -26,6 +33,15 @@ type podExecutor func(
 // The RBAC settings required for this are "resources=pods/exec,verbs=create"
 func NewPodExecutor(config *rest.Config) (podExecutor, error) {
 
+	// Fixtures stand in for the cluster; see [FakeCluster].
+	switch transport := config.Transport.(type) {
+	case *FakeCluster:
+		return transport.Exec, nil
+	case *ClusterRecorder:
+		exec, err := NewPodExecutor(transport.Config)
+		return transport.recordExec(exec), err
+	}
+
 	client, err := clientv1.NewForConfig(config)
 
 	return func(
@@ -56,3 +72,122 @@ func NewPodExecutor(config *rest.Config) (podExecutor, error) {
 		return err
 	}, err
 }
+
+// podTerminal runs command on container in pod in namespace with a terminal
+// of size. The remote process reads stdin and writes to stdout through it.
+type podTerminal func(
+	namespace, pod, container string,
+	stdin io.Reader, stdout io.Writer, size remotecommand.TerminalSize, command ...string,
+) error
+
+// NewPodTerminal returns a terminal function. It is used when a person types
+// into a program in a Container, like "kubectl exec --tty".
+// The RBAC settings required for this are "resources=pods/exec,verbs=create"
+func NewPodTerminal(config *rest.Config) (podTerminal, error) {
+
+	switch config.Transport.(type) {
+	case *FakeCluster, *ClusterRecorder:
+		return nil, errors.New("terminals are not possible with fixtures")
+	}
+
+	client, err := clientv1.NewForConfig(config)
+
+	return func(
+		namespace, pod, container string,
+		stdin io.Reader, stdout io.Writer, size remotecommand.TerminalSize, command ...string,
+	) error {
+		request := client.RESTClient().Post().
+			Resource("pods").SubResource("exec").
+			Namespace(namespace).Name(pod).
+			VersionedParams(&corev1.PodExecOptions{
+				Container: container,
+				Command:   command,
+				Stdin:     true,
+				Stdout:    true,
+				TTY:       true,
+			}, scheme.ParameterCodec)
+
+		exec, err := remotecommand.NewSPDYExecutor(config, "POST", request.URL())
+
+		if err == nil {
+			err = exec.Stream(remotecommand.StreamOptions{
+				Stdin:             stdin,
+				Stdout:            stdout,
+				Tty:               true,
+				TerminalSizeQueue: &terminalSize{size: &size},
+			})
+		}
+
+		return err
+	}, err
+}
+
+// terminalSize is a [remotecommand.TerminalSizeQueue] that reports one size.
+type terminalSize struct{ size *remotecommand.TerminalSize }
+
+// Next returns the size once, then nil to stop reporting.
+func (queue *terminalSize) Next() *remotecommand.TerminalSize {
+	size := queue.size
+	queue.size = nil
+	return size
+}
+
+// podPortForwarder forwards a local port to port of pod in namespace. It returns
+// the local address and a function that stops forwarding.
+type podPortForwarder func(namespace, pod string, port int32) (string, func(), error)
+
+// NewPodPortForwarder returns a port forwarder function. It is used when the
+// client connects to a Pod directly, like "kubectl port-forward".
+// The RBAC settings required for this are "resources=pods/portforward,verbs=create"
+func NewPodPortForwarder(config *rest.Config) (podPortForwarder, error) {
+
+	switch config.Transport.(type) {
+	case *FakeCluster, *ClusterRecorder:
+		return nil, errors.New("port forwarding is not possible with fixtures")
+	}
+
+	client, err := clientv1.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	transport, upgrader, err := spdy.RoundTripperFor(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(namespace, pod string, port int32) (string, func(), error) {
+		request := client.RESTClient().Post().
+			Resource("pods").SubResource("portforward").
+			Namespace(namespace).Name(pod)
+
+		dialer := spdy.NewDialer(upgrader,
+			&http.Client{Transport: transport}, "POST", request.URL())
+
+		stop, ready := make(chan struct{}), make(chan struct{})
+		forwarder, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"},
+			[]string{"0:" + strconv.Itoa(int(port))}, stop, ready, io.Discard, io.Discard)
+		if err != nil {
+			return "", nil, err
+		}
+
+		failed := make(chan error, 1)
+		go func() { failed <- forwarder.ForwardPorts() }()
+
+		select {
+		case <-ready:
+		case err := <-failed:
+			if err == nil {
+				err = fmt.Errorf("port forwarding to %s stopped", pod)
+			}
+			return "", nil, err
+		}
+
+		ports, err := forwarder.GetPorts()
+		if err != nil || len(ports) != 1 {
+			close(stop)
+			return "", nil, fmt.Errorf("unable to forward a port to %s: %v", pod, err)
+		}
+		return net.JoinHostPort("127.0.0.1", strconv.Itoa(int(ports[0].Local))),
+			func() { close(stop) }, nil
+	}, nil
+}
@@ -0,0 +1,327 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"k8s.io/client-go/rest"
+
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+)
+
+// The files of a fixture directory. Each line of them is one JSON object.
+const (
+	fakeRequestsFile = "requests.jsonl"
+	fakeCommandsFile = "commands.jsonl"
+)
+
+// FakeRequest is one request to the Kubernetes API and its response.
+type FakeRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Query  string `json:"query,omitempty"`
+
+	// Request is the body that was sent, for reference. It is not matched.
+	Request json.RawMessage `json:"request,omitempty"`
+
+	Status int `json:"status,omitempty"`
+
+	// Body is a JSON response; Text is any other response, such as logs.
+	Body json.RawMessage `json:"body,omitempty"`
+	Text string          `json:"text,omitempty"`
+}
+
+// FakeCommand is one command run in a container and what it printed.
+type FakeCommand struct {
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+
+	podexec.Call
+}
+
+// FakeCluster answers requests to the Kubernetes API and commands in Pods from
+// fixtures rather than a cluster. Fixtures that match are used in the order
+// they are written, each once, and then the last of them again. This way a
+// fixture directory can play out a change over time, like a restore.
+// Commands are answered by a [podexec.Replay] of each container.
+type FakeCluster struct {
+	Requests []FakeRequest
+	Commands []FakeCommand
+
+	mu         sync.Mutex
+	used       map[int]bool
+	containers map[[3]string]*podexec.Fake
+}
+
+// LoadFakeCluster reads the fixtures in dir. Either file may be missing.
+func LoadFakeCluster(dir string) (*FakeCluster, error) {
+	fake := &FakeCluster{}
+	if err := readJSONLines(filepath.Join(dir, fakeRequestsFile), func(b []byte) error {
+		var request FakeRequest
+		err := json.Unmarshal(b, &request)
+		fake.Requests = append(fake.Requests, request)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if err := readJSONLines(filepath.Join(dir, fakeCommandsFile), func(b []byte) error {
+		var command FakeCommand
+		err := json.Unmarshal(b, &command)
+		fake.Commands = append(fake.Commands, command)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return fake, nil
+}
+
+// readJSONLines calls fn with every line of the file called name that is
+// not blank.
+func readJSONLines(name string, fn func([]byte) error) error {
+	file, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if b := bytes.TrimSpace(scanner.Bytes()); len(b) > 0 {
+			if err := fn(b); err != nil {
+				return fmt.Errorf("%s:%d: %w", name, line, err)
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// normalQuery returns query with its parameters sorted.
+func normalQuery(query string) string {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return query
+	}
+	return values.Encode()
+}
+
+// next returns the index of the request to use among those that match, or -1.
+// It must be called while holding fake.mu.
+func (fake *FakeCluster) next(matches func(FakeRequest) bool) int {
+	if fake.used == nil {
+		fake.used = map[int]bool{}
+	}
+	last := -1
+	for i, request := range fake.Requests {
+		if !matches(request) {
+			continue
+		}
+		last = i
+		if !fake.used[i] {
+			fake.used[i] = true
+			return i
+		}
+	}
+	return last
+}
+
+// RoundTrip implements [http.RoundTripper] by answering req with a fixture.
+// Requests without one get a NotFound response.
+func (fake *FakeCluster) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	query := normalQuery(req.URL.RawQuery)
+
+	fake.mu.Lock()
+	i := fake.next(func(r FakeRequest) bool {
+		return r.Method == req.Method && r.Path == req.URL.Path && normalQuery(r.Query) == query
+	})
+	fake.mu.Unlock()
+
+	response := &http.Response{
+		Header:  http.Header{"Content-Type": []string{"application/json"}},
+		Request: req,
+	}
+	if i < 0 {
+		message := fmt.Sprintf("no fixture for %s %s", req.Method, req.URL.RequestURI())
+		body, _ := json.Marshal(map[string]any{
+			"apiVersion": "v1", "kind": "Status", "status": "Failure",
+			"message": message, "reason": "NotFound", "code": http.StatusNotFound,
+		})
+		response.StatusCode = http.StatusNotFound
+		response.Body = io.NopCloser(bytes.NewReader(body))
+		return response, nil
+	}
+
+	fixture := fake.Requests[i]
+	response.StatusCode = fixture.Status
+	if response.StatusCode == 0 {
+		response.StatusCode = http.StatusOK
+	}
+	if fixture.Body != nil {
+		response.Body = io.NopCloser(bytes.NewReader(fixture.Body))
+	} else {
+		response.Header.Set("Content-Type", "text/plain")
+		response.Body = io.NopCloser(strings.NewReader(fixture.Text))
+	}
+	return response, nil
+}
+
+// Exec answers command in container of pod in namespace with a fixture.
+// Commands without one fail.
+func (fake *FakeCluster) Exec(
+	namespace, pod, container string,
+	stdin io.Reader, stdout, stderr io.Writer, command ...string,
+) error {
+	fake.mu.Lock()
+	if fake.containers == nil {
+		calls := map[[3]string][]podexec.Call{}
+		for _, c := range fake.Commands {
+			key := [3]string{c.Namespace, c.Pod, c.Container}
+			calls[key] = append(calls[key], c.Call)
+		}
+		fake.containers = map[[3]string]*podexec.Fake{}
+		for key, calls := range calls {
+			fake.containers[key] = podexec.Replay(calls)
+		}
+	}
+	replay := fake.containers[[3]string{namespace, pod, container}]
+	fake.mu.Unlock()
+
+	if replay == nil {
+		return fmt.Errorf("no fixture for command %q in %s/%s container %s",
+			command, namespace, pod, container)
+	}
+	return replay.Exec(stdin, stdout, stderr, command...)
+}
+
+// ClusterRecorder sends requests and commands to a cluster and appends them,
+// with their responses, to the fixture files of Dir. A [FakeCluster] of Dir
+// answers them the same way later.
+type ClusterRecorder struct {
+	Dir string
+
+	// Config reaches the cluster. The recorder is the Transport of a copy.
+	Config *rest.Config
+
+	transport http.RoundTripper
+	mu        sync.Mutex
+}
+
+// NewClusterRecorder returns a config that reaches the cluster of config
+// through a [ClusterRecorder] that writes to dir.
+func NewClusterRecorder(dir string, config *rest.Config) (*rest.Config, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	transport, err := rest.TransportFor(config)
+	if err != nil {
+		return nil, err
+	}
+	recorder := &ClusterRecorder{Dir: dir, Config: config, transport: transport}
+	return &rest.Config{
+		Host:      config.Host,
+		APIPath:   config.APIPath,
+		UserAgent: config.UserAgent,
+		QPS:       config.QPS,
+		Burst:     config.Burst,
+		Timeout:   config.Timeout,
+		Transport: recorder,
+	}, nil
+}
+
+// appendFixture writes value as one line of the file called name in Dir.
+func (recorder *ClusterRecorder) appendFixture(name string, value any) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	file, err := os.OpenFile(filepath.Join(recorder.Dir, name),
+		os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(b, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// RoundTrip implements [http.RoundTripper] by sending req to the cluster and
+// recording its response. Responses are read completely, so watches and
+// followed logs return only once they end.
+func (recorder *ClusterRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	fixture := FakeRequest{Method: req.Method, Path: req.URL.Path, Query: req.URL.RawQuery}
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if json.Valid(b) {
+			fixture.Request = b
+		}
+		req.Body = io.NopCloser(bytes.NewReader(b))
+	}
+
+	response, err := recorder.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = io.NopCloser(bytes.NewReader(b))
+
+	fixture.Status = response.StatusCode
+	if json.Valid(b) {
+		fixture.Body = b
+	} else {
+		fixture.Text = string(b)
+	}
+	return response, recorder.appendFixture(fakeRequestsFile, fixture)
+}
+
+// recordExec returns an executor that runs commands with exec and records them.
+func (recorder *ClusterRecorder) recordExec(exec podExecutor) podExecutor {
+	return func(
+		namespace, pod, container string,
+		stdin io.Reader, stdout, stderr io.Writer, command ...string,
+	) error {
+		calls := &podexec.Recorder{Executor: podexec.Container(exec, namespace, pod, container)}
+		err := calls.Exec(stdin, stdout, stderr, command...)
+		if len(calls.Calls) == 0 {
+			return err
+		}
+
+		fixture := FakeCommand{Namespace: namespace, Pod: pod, Container: container, Call: calls.Calls[0]}
+		if recordErr := recorder.appendFixture(fakeCommandsFile, fixture); err == nil {
+			err = recordErr
+		}
+		return err
+	}
+}
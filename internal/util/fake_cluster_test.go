@@ -0,0 +1,118 @@
+// Copyright 2021 - 2025 Crunchy Data Solutions, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/client-go/rest"
+
+	podexec "github.com/crunchydata/postgres-operator-client/internal/exec"
+)
+
+func TestFakeClusterRoundTrip(t *testing.T) {
+	fake := &FakeCluster{Requests: []FakeRequest{
+		{Method: "GET", Path: "/api/v1/pods", Query: "b=2&a=1", Body: []byte(`{"n":1}`)},
+		{Method: "GET", Path: "/api/v1/pods", Query: "a=1&b=2", Body: []byte(`{"n":2}`)},
+		{Method: "GET", Path: "/logs", Status: 201, Text: "hello"},
+	}}
+	get := func(target string) (int, string) {
+		response, err := fake.RoundTrip(httptest.NewRequest("GET", target, nil))
+		assert.NilError(t, err)
+		b, err := io.ReadAll(response.Body)
+		assert.NilError(t, err)
+		return response.StatusCode, string(b)
+	}
+
+	// Fixtures that match are used in order, and then the last one again.
+	for _, expected := range []string{`{"n":1}`, `{"n":2}`, `{"n":2}`} {
+		status, body := get("/api/v1/pods?a=1&b=2")
+		assert.Equal(t, status, 200)
+		assert.Equal(t, body, expected)
+	}
+
+	status, body := get("/logs")
+	assert.Equal(t, status, 201)
+	assert.Equal(t, body, "hello")
+
+	status, body = get("/api/v1/pods?a=1")
+	assert.Equal(t, status, 404)
+	assert.Assert(t, strings.Contains(body, `"no fixture for GET /api/v1/pods?a=1"`), body)
+}
+
+func TestFakeClusterExec(t *testing.T) {
+	fake := &FakeCluster{Commands: []FakeCommand{
+		{Namespace: "ns", Pod: "pod", Container: "database", Call: podexec.Call{
+			Command: []string{"psql", "-f-"}, Stdin: "SELECT 1", Stdout: "1\n"}},
+		{Namespace: "ns", Pod: "pod", Container: "database", Call: podexec.Call{
+			Command: []string{"false"}, Stderr: "oops\n", Error: "command terminated with exit code 1"}},
+	}}
+
+	var stdout, stderr bytes.Buffer
+	assert.NilError(t, fake.Exec("ns", "pod", "database",
+		strings.NewReader("SELECT 1"), &stdout, &stderr, "psql", "-f-"))
+	assert.Equal(t, stdout.String(), "1\n")
+
+	err := fake.Exec("ns", "pod", "database", nil, &stdout, &stderr, "false")
+	assert.ErrorContains(t, err, "exit code 1")
+	assert.Equal(t, stderr.String(), "oops\n")
+
+	err = fake.Exec("ns", "pod", "database", strings.NewReader("SELECT 2"), nil, nil, "psql", "-f-")
+	assert.ErrorContains(t, err, `unexpected command ["psql" "-f-"]`)
+
+	err = fake.Exec("ns", "other", "database", nil, nil, nil, "false")
+	assert.ErrorContains(t, err, `no fixture for command ["false"] in ns/other container database`)
+}
+
+func TestClusterRecorder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"kind":"PodList"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	dir := filepath.Join(t.TempDir(), "ctx")
+	config, err := NewClusterRecorder(dir, &rest.Config{Host: server.URL})
+	assert.NilError(t, err)
+
+	client := &http.Client{Transport: config.Transport}
+	response, err := client.Get(server.URL + "/api/v1/pods?limit=5")
+	assert.NilError(t, err)
+	_ = response.Body.Close()
+
+	exec := config.Transport.(*ClusterRecorder).recordExec(func(
+		_, _, _ string, stdin io.Reader, stdout, _ io.Writer, _ ...string,
+	) error {
+		_, _ = io.Copy(stdout, stdin)
+		return errors.New("exit code 2")
+	})
+	var stdout bytes.Buffer
+	assert.ErrorContains(t, exec("ns", "pod", "database", strings.NewReader("echo"), &stdout, nil, "cat"),
+		"exit code 2")
+	assert.Equal(t, stdout.String(), "echo")
+
+	requests, err := os.ReadFile(filepath.Join(dir, fakeRequestsFile))
+	assert.NilError(t, err)
+	assert.Equal(t, string(requests),
+		`{"method":"GET","path":"/api/v1/pods","query":"limit=5","status":200,"body":{"kind":"PodList"}}`+"\n")
+
+	// What was recorded is answered the same way.
+	fake, err := LoadFakeCluster(dir)
+	assert.NilError(t, err)
+	stdout.Reset()
+	assert.ErrorContains(t, fake.Exec("ns", "pod", "database", strings.NewReader("echo"), &stdout, nil, "cat"),
+		"exit code 2")
+	assert.Equal(t, stdout.String(), "echo")
+	assert.Equal(t, len(fake.Requests), 1)
+}
@@ -8,6 +8,7 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"strings"
 
 	"k8s.io/utils/strings/slices"
 )
@@ -18,6 +19,13 @@ import (
 // "no", "No", "NO" all deny confirmation and return 'false'. If the input is not
 // recognized, nil is returned.
 func Confirm(reader io.Reader, writer io.Writer) *bool {
+	return ConfirmIn(reader, writer, nil, nil, "Please type yes or no and then press enter: ")
+}
+
+// ConfirmIn is like [Confirm] but also accepts the answers in yes and no, such
+// as those of another language, in any case. It prints retry when the input
+// is not recognized.
+func ConfirmIn(reader io.Reader, writer io.Writer, yes, no []string, retry string) *bool {
 	var response string
 	var boolVar bool
 
@@ -27,19 +35,28 @@ func Confirm(reader io.Reader, writer io.Writer) *bool {
 	}
 
 	if scanner.Err() != nil || response == "" {
-		_, _ = fmt.Fprint(writer, "Please type yes or no and then press enter: ")
+		_, _ = fmt.Fprint(writer, retry)
 		return nil
 	}
 
+	answer := func(answers []string) bool {
+		for _, a := range answers {
+			if a = strings.TrimSpace(a); a != "" && strings.EqualFold(a, response) {
+				return true
+			}
+		}
+		return false
+	}
+
 	yesResponses := []string{"y", "Y", "yes", "Yes", "YES"}
 	noResponses := []string{"n", "N", "no", "No", "NO"}
-	if slices.Contains(yesResponses, response) {
+	if slices.Contains(yesResponses, response) || answer(yes) {
 		boolVar = true
 		return &boolVar
-	} else if slices.Contains(noResponses, response) {
+	} else if slices.Contains(noResponses, response) || answer(no) {
 		return &boolVar
 	} else {
-		_, _ = fmt.Fprint(writer, "Please type yes or no and then press enter: ")
+		_, _ = fmt.Fprint(writer, retry)
 		return nil
 	}
 }
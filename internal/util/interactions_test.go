@@ -53,4 +53,17 @@ func TestConfirm(t *testing.T) {
 			}
 		})
 	}
+
+	t.Run("Translated", func(t *testing.T) {
+		ja, nein := []string{"j", "ja"}, []string{"n", "nein"}
+		for input, expected := range map[string]bool{"JA": true, "yes": true, "Nein": false, "no": false} {
+			confirmed := ConfirmIn(strings.NewReader(input), io.Discard, ja, nein, "")
+			assert.Assert(t, confirmed != nil, input)
+			assert.Equal(t, *confirmed, expected, input)
+		}
+
+		var writer bytes.Buffer
+		assert.Assert(t, ConfirmIn(strings.NewReader("si"), &writer, ja, nein, "Bitte ja oder nein: ") == nil)
+		assert.Equal(t, writer.String(), "Bitte ja oder nein: ")
+	})
 }
@@ -19,6 +19,12 @@ const (
 	// LabelData is used to identify Pods and Volumes store Postgres data.
 	LabelData = labelPrefix + "data"
 
+	// LabelInstance is used to identify the objects of one Postgres instance.
+	LabelInstance = labelPrefix + "instance"
+
+	// LabelInstanceSet is used to identify the objects of one instance set.
+	LabelInstanceSet = labelPrefix + "instance-set"
+
 	// LabelRole is used to identify object roles.
 	LabelRole = labelPrefix + "role"
 
@@ -31,6 +37,36 @@ const (
 
 	// LabelPGBackRestDedicated is used to identify the Repo Host pod
 	LabelPGBackRestDedicated = labelPrefix + "pgbackrest-dedicated"
+
+	// LabelPGBackRestBackup is used to identify pgBackRest backup Jobs. Its
+	// value is the kind of backup: manual, replica-create, or scheduled.
+	LabelPGBackRestBackup = labelPrefix + "pgbackrest-backup"
+
+	// AnnotationPGBackRestBackup is set on a PostgresCluster to start a manual
+	// backup. PGO copies its value to the Job that runs the backup.
+	AnnotationPGBackRestBackup = labelPrefix + "pgbackrest-backup"
+
+	// AnnotationUserPolicies is set on a PostgresCluster by "pgo set
+	// user-policy". Its value is JSON of the role settings applied to each
+	// user, which "pgo check user-policy" compares with Postgres.
+	AnnotationUserPolicies = labelPrefix + "pgo-user-policies"
+
+	// AnnotationUpgradeEstimate is set on a PGUpgrade by "pgo show
+	// pgupgrade-plan". Its value is JSON of the estimated disk and duration of
+	// the upgrade, to compare with the upgrade once it is done.
+	AnnotationUpgradeEstimate = labelPrefix + "pgo-upgrade-estimate"
+
+	// AnnotationPausedBackupSchedules is set on a PostgresCluster by "pgo pause
+	// backupschedule". Its value is JSON of the schedules removed from each
+	// repository, which "pgo resume backupschedule" puts back.
+	AnnotationPausedBackupSchedules = labelPrefix + "pgo-paused-backup-schedules"
+
+	// LabelPGBackRestRestore is used to identify pgBackRest restore Jobs.
+	LabelPGBackRestRestore = labelPrefix + "pgbackrest-restore"
+
+	// LabelPGUpgrade is used to identify PGUpgrade Jobs. Its value is the
+	// name of the PGUpgrade object.
+	LabelPGUpgrade = labelPrefix + "pgupgrade"
 )
 
 const (
@@ -56,6 +92,15 @@ const (
 
 	// RolePostgresUser is the LabelRole applied to PostgreSQL user secrets.
 	RolePostgresUser = "pguser"
+
+	// RolePostgresData is the LabelRole applied to PostgreSQL data volumes.
+	RolePostgresData = "pgdata"
+
+	// RolePostgresWAL is the LabelRole applied to PostgreSQL WAL volumes.
+	RolePostgresWAL = "pgwal"
+
+	// RolePGBouncer is the LabelRole applied to pgBouncer objects.
+	RolePGBouncer = "pgbouncer"
 )
 
 const (
@@ -66,6 +111,9 @@ const (
 	ContainerDatabase = "database"
 
 	ContainerPGBackrest = "pgbackrest"
+
+	// ContainerPGBouncer is the name of the container running pgBouncer.
+	ContainerPGBouncer = "pgbouncer"
 )
 
 // DBInstanceLabels provides labels for a PostgreSQL cluster primary or replica instance
@@ -81,12 +129,41 @@ func PrimaryInstanceLabels(clusterName string) string {
 		LabelRole + "=" + RolePatroniLeader
 }
 
+// ReplicaInstanceLabels provides labels for PostgreSQL cluster replica instances
+func ReplicaInstanceLabels(clusterName string) string {
+	return LabelCluster + "=" + clusterName + "," +
+		LabelData + "=" + DataPostgres + "," +
+		LabelRole + "=" + RolePatroniReplica
+}
+
+// InstanceSetLabels provides labels for the PostgreSQL instances of one
+// instance set
+func InstanceSetLabels(clusterName, instanceSetName string) string {
+	return LabelCluster + "=" + clusterName + "," +
+		LabelInstanceSet + "=" + instanceSetName + "," +
+		LabelData + "=" + DataPostgres
+}
+
+// InstanceVolumeLabels provides labels for the data and WAL volumes of one
+// PostgreSQL instance
+func InstanceVolumeLabels(clusterName, instanceName string) string {
+	return LabelCluster + "=" + clusterName + "," +
+		LabelInstance + "=" + instanceName + "," +
+		LabelRole + " in (" + RolePostgresData + "," + RolePostgresWAL + ")"
+}
+
 // RepoHostInstanceLabels provides labels for a Backrest Repo Host instances
 func RepoHostInstanceLabels(clusterName string) string {
 	return LabelCluster + "=" + clusterName + "," +
 		LabelPGBackRestDedicated + "="
 }
 
+// PGBouncerLabels provides labels for the pgBouncer Pods of a PostgreSQL cluster
+func PGBouncerLabels(clusterName string) string {
+	return LabelCluster + "=" + clusterName + "," +
+		LabelRole + "=" + RolePGBouncer
+}
+
 // PostgresUserSecretLabels provides labels for the Postgres user Secret
 func PostgresUserSecretLabels(clusterName string) string {
 	return LabelCluster + "=" + clusterName + "," +
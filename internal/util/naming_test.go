@@ -17,3 +17,26 @@ func TestPrimaryInstanceLabels(t *testing.T) {
 			"postgres-operator.crunchydata.com/data=postgres,"+
 			"postgres-operator.crunchydata.com/role=master")
 }
+
+func TestReplicaInstanceLabels(t *testing.T) {
+
+	assert.Equal(t, ReplicaInstanceLabels("testcluster1"),
+		"postgres-operator.crunchydata.com/cluster=testcluster1,"+
+			"postgres-operator.crunchydata.com/data=postgres,"+
+			"postgres-operator.crunchydata.com/role=replica")
+}
+
+func TestInstanceSetLabels(t *testing.T) {
+
+	assert.Equal(t, InstanceSetLabels("testcluster1", "delayed"),
+		"postgres-operator.crunchydata.com/cluster=testcluster1,"+
+			"postgres-operator.crunchydata.com/instance-set=delayed,"+
+			"postgres-operator.crunchydata.com/data=postgres")
+}
+
+func TestPGBouncerLabels(t *testing.T) {
+
+	assert.Equal(t, PGBouncerLabels("testcluster1"),
+		"postgres-operator.crunchydata.com/cluster=testcluster1,"+
+			"postgres-operator.crunchydata.com/role=pgbouncer")
+}